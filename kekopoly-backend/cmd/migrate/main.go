@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/kekopoly/backend/internal/config"
+	"github.com/kekopoly/backend/internal/db/migrations"
+	"github.com/kekopoly/backend/internal/db/mongodb"
+	"go.uber.org/zap"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "report what pending migrations would change without writing")
+	status := flag.Bool("status", false, "list applied and pending migrations and exit")
+	flag.Parse()
+
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		fmt.Printf("Failed to initialize logger: %v\n", err)
+		os.Exit(1)
+	}
+	defer logger.Sync()
+	sugar := logger.Sugar()
+
+	cfg, err := config.Load()
+	if err != nil {
+		sugar.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	client, err := mongodb.Connect(ctx, cfg.MongoDB.URI, sugar)
+	if err != nil {
+		sugar.Fatalf("Failed to connect to MongoDB: %v", err)
+	}
+	defer client.Disconnect(context.Background())
+
+	runner, err := migrations.NewRunner(client.Database(cfg.MongoDB.Database), sugar)
+	if err != nil {
+		sugar.Fatalf("Invalid migration set: %v", err)
+	}
+
+	if *status {
+		applied, err := runner.Applied(ctx)
+		if err != nil {
+			sugar.Fatalf("Failed to read migration status: %v", err)
+		}
+		for _, m := range migrations.All() {
+			state := "pending"
+			if rec, ok := applied[m.Version]; ok {
+				state = rec.Status
+				if rec.Status == migrations.StatusApplied {
+					state = fmt.Sprintf("applied %s (%d documents)", rec.AppliedAt.Format(time.RFC3339), rec.Affected)
+				}
+			}
+			fmt.Printf("%04d  %-50s  %s\n", m.Version, m.Description, state)
+		}
+		return
+	}
+
+	results, err := runner.Run(ctx, *dryRun)
+	if err != nil {
+		sugar.Fatalf("Migration failed: %v", err)
+	}
+
+	if len(results) == 0 {
+		fmt.Println("No pending migrations")
+		return
+	}
+
+	for _, r := range results {
+		verb := "changed"
+		if r.DryRun {
+			verb = "would change"
+		}
+		fmt.Printf("%04d  %-50s  %s %d documents\n", r.Version, r.Description, verb, r.Affected)
+	}
+}
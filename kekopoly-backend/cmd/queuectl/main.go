@@ -0,0 +1,214 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/kekopoly/backend/internal/config"
+	"github.com/kekopoly/backend/internal/queue"
+	"go.uber.org/zap"
+)
+
+const usage = `Usage: queuectl [-redis host:port] <command> [arguments]
+
+Commands:
+  streams                       show each partition's stream and the worker reading it
+  list                          list the games with dead letters
+  show <gameId> [messageId]     list a game's dead letters, or show one with its errors
+  requeue <gameId> [filters]    move dead letters back onto the game's stream, attempts reset
+  purge <gameId> <filters>      delete the dead letters the filters pick
+  drain <gameId>                delete all of a game's dead letters
+
+Filters:
+  -id a,b          message IDs
+  -type name       message type, such as game_start
+  -older-than 1h   parked longer ago than this
+`
+
+func main() {
+	redisAddr := flag.String("redis", "", "Redis address; empty uses redis.uri from the configuration")
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	addr, opts := settings(*redisAddr)
+	q, err := queue.NewRedisQueueWithOptions(addr, zap.NewNop(), opts)
+	if err != nil {
+		fail("%v", err)
+	}
+	defer q.Close()
+
+	command, args := flag.Arg(0), flag.Args()[1:]
+	switch command {
+	case "streams":
+		streams(q)
+	case "list":
+		list(q)
+	case "show":
+		if len(args) == 0 || len(args) > 2 {
+			fail("Usage: queuectl show <gameId> [messageId]")
+		}
+		if len(args) == 1 {
+			showQueue(q, args[0])
+		} else {
+			showMessage(q, args[0], args[1])
+		}
+	case "requeue":
+		gameID, filter := parseFilter("requeue", args)
+		requeued, err := q.RequeueDeadLetters(gameID, filter)
+		if err != nil {
+			fail("Failed to requeue dead letters: %v", err)
+		}
+		fmt.Printf("Requeued %d messages\n", requeued)
+	case "purge":
+		gameID, filter := parseFilter("purge", args)
+		if filter.IsEmpty() {
+			fail("purge needs -id, -type or -older-than; use drain to delete everything")
+		}
+		purged, err := q.PurgeDeadLetters(gameID, filter)
+		if err != nil {
+			fail("Failed to purge dead letters: %v", err)
+		}
+		fmt.Printf("Purged %d messages\n", purged)
+	case "drain":
+		if len(args) != 1 {
+			fail("Usage: queuectl drain <gameId>")
+		}
+		drained, err := q.DrainDeadLetterQueue(args[0])
+		if err != nil {
+			fail("Failed to drain dead letters: %v", err)
+		}
+		fmt.Printf("Drained %d messages\n", drained)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+// settings picks the Redis address, the flag then the configuration then the default,
+// and the queue's partitions from the configuration
+func settings(flagAddr string) (string, queue.Options) {
+	addr := "localhost:6379"
+	var opts queue.Options
+	if cfg, err := config.Load(); err == nil {
+		if cfg.Redis.URI != "" {
+			addr = cfg.Redis.URI
+		}
+		opts = queue.Options{Partitions: cfg.Queue.Partitions, MaxLen: cfg.Queue.MaxLen}
+	}
+	if flagAddr != "" {
+		addr = flagAddr
+	}
+	return addr, opts
+}
+
+func streams(q *queue.RedisQueue) {
+	infos, err := q.Streams()
+	if err != nil {
+		fail("Failed to read streams: %v", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "PARTITION\tSTREAM\tLENGTH\tPENDING\tWORKER")
+	for _, info := range infos {
+		owner := info.Owner
+		if owner == "" {
+			owner = "-"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%d\t%d\t%s\n", info.Partition, info.Stream, info.Length, info.Pending, owner)
+	}
+	w.Flush()
+}
+
+func list(q *queue.RedisQueue) {
+	queues, err := q.ListDeadLetterQueues()
+	if err != nil {
+		fail("Failed to list dead letter queues: %v", err)
+	}
+	if len(queues) == 0 {
+		fmt.Println("No dead letters")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "GAME\tCOUNT\tOLDEST\tNEWEST")
+	for _, dlq := range queues {
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", dlq.GameID, dlq.Count, age(dlq.Oldest), age(dlq.Newest))
+	}
+	w.Flush()
+}
+
+func showQueue(q *queue.RedisQueue, gameID string) {
+	messages, err := q.DeadLetters(gameID)
+	if err != nil {
+		fail("Failed to read dead letters: %v", err)
+	}
+	if len(messages) == 0 {
+		fmt.Println("No dead letters")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tTYPE\tPLAYER\tATTEMPTS\tPARKED\tLAST ERROR")
+	for _, msg := range messages {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\n", msg.ID, msg.Type, msg.PlayerID, msg.Attempts, age(queue.ParkedAt(&msg)), msg.LastError)
+	}
+	w.Flush()
+}
+
+func showMessage(q *queue.RedisQueue, gameID, messageID string) {
+	msg, err := q.DeadLetter(gameID, messageID)
+	if err != nil {
+		fail("Failed to read dead letters: %v", err)
+	}
+	if msg == nil {
+		fail("No dead letter %s in game %s", messageID, gameID)
+	}
+	out, err := json.MarshalIndent(msg, "", "  ")
+	if err != nil {
+		fail("Failed to encode message: %v", err)
+	}
+	fmt.Println(string(out))
+}
+
+// parseFilter reads a game ID followed by filter flags
+func parseFilter(command string, args []string) (string, queue.DeadLetterFilter) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		fail("Usage: queuectl %s <gameId> [-id a,b] [-type name] [-older-than 1h]", command)
+	}
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	ids := flags.String("id", "", "comma separated message IDs")
+	msgType := flags.String("type", "", "message type")
+	olderThan := flags.Duration("older-than", 0, "only messages parked longer ago than this")
+	flags.Parse(args[1:])
+
+	filter := queue.DeadLetterFilter{Type: queue.MessageType(*msgType)}
+	if *ids != "" {
+		filter.IDs = strings.Split(*ids, ",")
+	}
+	if *olderThan > 0 {
+		filter.Before = time.Now().Add(-*olderThan)
+	}
+	return args[0], filter
+}
+
+// age formats how long ago t was
+func age(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return time.Since(t).Round(time.Second).String() + " ago"
+}
+
+func fail(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
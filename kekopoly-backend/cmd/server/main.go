@@ -2,18 +2,23 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/kekopoly/backend/internal/api"
 	"github.com/kekopoly/backend/internal/config"
+	"github.com/kekopoly/backend/internal/db/migrations"
 	"github.com/kekopoly/backend/internal/db/mongodb"
 	"github.com/kekopoly/backend/internal/db/redis"
+	"github.com/kekopoly/backend/internal/game/bots"
 	"github.com/kekopoly/backend/internal/game/manager"
 	"github.com/kekopoly/backend/internal/game/websocket"
 	"github.com/kekopoly/backend/internal/queue"
+	"github.com/kekopoly/backend/internal/settlement"
 	"go.uber.org/zap"
 )
 
@@ -49,6 +54,20 @@ func main() {
 	}()
 	sugar.Info("Connected to MongoDB")
 
+	// Ensure indexes and the games schema validator, reporting any drift
+	if err := mongodb.CreateIndexes(ctx, mongoClient, cfg.MongoDB.Database, sugar); err != nil {
+		sugar.Errorf("Failed to ensure MongoDB indexes: %v", err)
+	}
+
+	// Apply pending schema migrations before anything loads game documents
+	if cfg.MongoDB.AutoMigrate {
+		runner, err := migrations.NewRunner(mongoClient.Database(cfg.MongoDB.Database), sugar)
+		if err != nil {
+			sugar.Fatalf("Invalid migration set: %v", err)
+		}
+		applyMigrations(ctx, runner, sugar)
+	}
+
 	// Initialize Redis connection with retry capabilities
 	redisClient, err := redis.Connect(ctx, cfg.Redis.URI, sugar)
 	if err != nil {
@@ -62,7 +81,10 @@ func main() {
 	sugar.Info("Connected to Redis")
 
 	// Initialize Redis queue
-	redisQueue, err := queue.NewRedisQueue(cfg.Redis.URI, logger)
+	redisQueue, err := queue.NewRedisQueueWithOptions(cfg.Redis.URI, logger, queue.Options{
+		Partitions: cfg.Queue.Partitions,
+		MaxLen:     cfg.Queue.MaxLen,
+	})
 	if err != nil {
 		sugar.Fatalf("Failed to initialize Redis queue: %v", err)
 	}
@@ -71,36 +93,103 @@ func main() {
 
 	// Initialize WebSocket hub without game manager first
 	hub := websocket.NewHub(ctx, nil, mongoClient, redisClient, sugar, redisQueue)
-	go hub.Run()
-	sugar.Info("WebSocket hub is running")
+	if cfg.Bots.TakeOver {
+		if _, err := bots.New(cfg.Bots.TakeOverStrategy); err != nil {
+			sugar.Fatalf("Invalid bots.take_over_strategy: %v", err)
+		}
+	}
+	// Invites are signed with a key derived from the JWT secret, so every node accepts them
+	managerOpts := manager.Options{
+		InviteSecret: cfg.JWT.Secret,
+		InviteTTL:    time.Duration(cfg.Game.InviteTTL) * time.Second,
+		MaxPause:     time.Duration(cfg.Game.MaxPause) * time.Second,
+		Bots: manager.BotOptions{
+			ThinkDelay:       time.Duration(cfg.Bots.ThinkDelay) * time.Millisecond,
+			TakeOver:         cfg.Bots.TakeOver,
+			TakeOverAfter:    time.Duration(cfg.Bots.TakeOverAfter) * time.Second,
+			TakeOverStrategy: cfg.Bots.TakeOverStrategy,
+		},
+	}
+	workerOpts := queue.WorkerOptions{
+		Block:    time.Duration(cfg.Queue.Block) * time.Second,
+		LeaseTTL: time.Duration(cfg.Queue.LeaseTTL) * time.Second,
+	}
+	if cfg.Cluster.Enabled {
+		// The bus and the game leases must agree on this node's ID
+		cluster := manager.NewCluster(redisClient, cfg.Cluster.NodeID,
+			time.Duration(cfg.Cluster.LeaseTTL)*time.Second, time.Duration(cfg.Cluster.ForwardTimeout)*time.Second)
+		hub.SetBus(websocket.NewBus(redisClient, cluster.NodeID(), time.Duration(cfg.Cluster.PresenceTTL)*time.Second, sugar))
+		managerOpts.Cluster = cluster
+		workerOpts.Consumer = cluster.NodeID()
+	}
+	if cfg.WebSocket.EventBufferSize > 0 {
+		hub.SetEventLog(websocket.NewEventLog(redisClient, cfg.WebSocket.EventBufferSize, time.Duration(cfg.WebSocket.EventTTL)*time.Second))
+	}
+	hub.SetSpectators(cfg.WebSocket.MaxSpectators, time.Duration(cfg.WebSocket.SpectatorDelay)*time.Second)
+	hub.SetChat(websocket.NewChat(redisClient, websocket.ChatOptions{
+		HistorySize: cfg.Chat.HistorySize,
+		HistoryTTL:  time.Duration(cfg.Chat.HistoryTTL) * time.Second,
+		MaxLength:   cfg.Chat.MaxLength,
+		RateLimit:   cfg.Chat.RateLimit,
+		RateWindow:  time.Duration(cfg.Chat.RateWindow) * time.Second,
+		Filter:      websocket.WordFilter(cfg.Chat.BlockedWords, cfg.Chat.BlockLinks),
+	}))
 
 	// Initialize game manager with the message queue
-	gameManager := manager.NewGameManager(ctx, mongoClient, redisClient, sugar, hub, redisQueue)
+	gameManager := manager.NewGameManagerWithOptions(ctx, mongoClient, redisClient, sugar, hub, redisQueue, managerOpts)
 	sugar.Info("Game manager initialized")
 
+	// Enable escrow deposits and on-chain payouts
+	if cfg.Settlement.Enabled {
+		chain, err := settlement.NewChainClient(cfg)
+		if err != nil {
+			sugar.Fatalf("Failed to initialize settlement chain: %v", err)
+		}
+		settler, err := settlement.NewService(
+			chain,
+			settlement.NewMongoStore(mongoClient, cfg.MongoDB.Database, cfg.MongoDB.TxColl),
+			gameManager,
+			settlement.Config{
+				PayoutShares:  cfg.Settlement.PayoutShares,
+				FeeBps:        cfg.Settlement.FeeBps,
+				MaxAttempts:   cfg.Settlement.MaxAttempts,
+				RetryInterval: time.Duration(cfg.Settlement.RetryInterval) * time.Second,
+			},
+			sugar,
+		)
+		if err != nil {
+			sugar.Fatalf("Invalid settlement configuration: %v", err)
+		}
+		gameManager.SetSettler(settler, cfg.Settlement.Stake, time.Duration(cfg.Settlement.DepositTimeout)*time.Second)
+
+		// Refunds that failed before the restart are sent again
+		go func() {
+			if err := settler.RetryRefunds(ctx); err != nil {
+				sugar.Errorf("Failed to retry refunds: %v", err)
+			}
+		}()
+	}
+
 	// Set the game manager in the hub
 	hub.SetGameManager(gameManager)
 	sugar.Info("Game manager set in WebSocket hub")
-
-	// Initialize queue worker
-	worker := queue.NewWorker(redisQueue, gameManager, logger)
-
-	// Run an initial cleanup of stale queues
-	sugar.Info("Running initial cleanup of stale queues")
-
-	// First, clear all stale queues (for games that no longer exist)
-	clearedCount, err := worker.ClearAllStaleQueues()
-	if err != nil {
-		sugar.Warnf("Failed to clear stale queues: %v", err)
-	} else {
-		sugar.Infof("Cleared %d stale queues", clearedCount)
+	hub.SetLobby(websocket.NewLobby(redisClient, websocket.LobbyOptions{
+		FlushInterval:  time.Duration(cfg.WebSocket.LobbyInterval) * time.Second,
+		ResyncInterval: time.Duration(cfg.WebSocket.LobbyResync) * time.Second,
+	}))
+
+	// Move messages left in the list queues used before streams
+	if moved, err := redisQueue.MigrateListQueues(); err != nil {
+		sugar.Warnf("Failed to move old queued messages to streams: %v", err)
+	} else if moved > 0 {
+		sugar.Infof("Moved %d old queued messages to streams", moved)
 	}
 
-	// Then process any remaining queues
-	worker.CleanupStaleQueues()
-
-	// Start the worker
-	worker.Start()
+	// Start the queue worker; it shares the partitions with the other nodes' workers
+	worker := queue.NewWorkerWithOptions(redisQueue, gameManager, logger, workerOpts)
+	if err := worker.Start(); err != nil {
+		sugar.Fatalf("Failed to start queue worker: %v", err)
+	}
 	sugar.Info("Queue worker started")
 
 	// Initialize API server with the database clients
@@ -130,3 +219,33 @@ func main() {
 
 	sugar.Info("Server exited properly")
 }
+
+const (
+	// migrationWait is how long a node waits for a peer's migration before starting anyway
+	migrationWait = 2 * time.Minute
+	// migrationPoll is how often it checks whether the migration has finished
+	migrationPoll = 5 * time.Second
+)
+
+// applyMigrations runs the pending migrations. Nodes starting together race to claim
+// them, so a node that finds one in progress waits for its peer to finish. If it is
+// still unfinished after migrationWait, perhaps because the node applying it crashed,
+// the node starts without migrating and logs an error for an operator.
+func applyMigrations(ctx context.Context, runner *migrations.Runner, sugar *zap.SugaredLogger) {
+	deadline := time.Now().Add(migrationWait)
+	for {
+		_, err := runner.Run(ctx, false)
+		if err == nil {
+			return
+		}
+		if !errors.Is(err, migrations.ErrMigrationInProgress) {
+			sugar.Fatalf("Failed to apply migrations: %v", err)
+		}
+		if time.Now().After(deadline) {
+			sugar.Errorf("Starting without applying migrations: %v", err)
+			return
+		}
+		sugar.Infof("Waiting for another node to finish migrating: %v", err)
+		time.Sleep(migrationPoll)
+	}
+}
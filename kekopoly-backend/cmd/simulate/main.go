@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/kekopoly/backend/internal/game/simulation"
+)
+
+func main() {
+	games := flag.Int("games", 1000, "number of games to play")
+	players := flag.Int("players", 4, "players in each game")
+	strategies := flag.String("strategies", "heuristic,greedy,random", "comma separated bot strategies, handed out to the players in turn")
+	seed := flag.Int64("seed", time.Now().UnixNano(), "seed for the dice; the same seed replays the same games")
+	workers := flag.Int("workers", 0, "games played at once; 0 means one per CPU")
+	rulesPath := flag.String("rules", "", "JSON ruleset to play; empty uses the built-in board and cards")
+	format := flag.String("format", "json", "report format: json or csv")
+	outPath := flag.String("out", "", "file to write the report to; empty writes to stdout")
+	flag.Parse()
+
+	if *format != "json" && *format != "csv" {
+		fail("unknown format %q; use json or csv", *format)
+	}
+
+	rules, err := simulation.DefaultRuleset()
+	if *rulesPath != "" {
+		rules, err = simulation.LoadRuleset(*rulesPath)
+	}
+	if err != nil {
+		fail("%v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	started := time.Now()
+	report, err := simulation.Run(ctx, rules, simulation.Config{
+		Games:      *games,
+		Players:    *players,
+		Seed:       *seed,
+		Strategies: strings.Split(*strategies, ","),
+		Workers:    *workers,
+	})
+	if err != nil {
+		fail("Simulation failed: %v", err)
+	}
+	fmt.Fprintf(os.Stderr, "Played %d games with seed %d in %s\n", report.Games, report.Seed, time.Since(started).Round(time.Millisecond))
+
+	var out io.Writer = os.Stdout
+	if *outPath != "" {
+		file, err := os.Create(*outPath)
+		if err != nil {
+			fail("Failed to create report: %v", err)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	if *format == "csv" {
+		err = report.WriteCSV(out)
+	} else {
+		err = report.WriteJSON(out)
+	}
+	if err != nil {
+		fail("Failed to write report: %v", err)
+	}
+}
+
+func fail(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
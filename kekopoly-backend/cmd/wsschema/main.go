@@ -0,0 +1,30 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/kekopoly/backend/internal/game/protocol"
+)
+
+// wsschema writes the JSON Schema for the game WebSocket protocol
+func main() {
+	output := flag.String("o", "", "file to write the schema to (default stdout)")
+	flag.Parse()
+
+	schema, err := protocol.Schema()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to generate schema: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *output == "" {
+		os.Stdout.Write(schema)
+		return
+	}
+	if err := os.WriteFile(*output, schema, 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write schema: %v\n", err)
+		os.Exit(1)
+	}
+}
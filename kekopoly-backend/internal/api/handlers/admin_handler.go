@@ -0,0 +1,725 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+	"github.com/kekopoly/backend/internal/api/middleware/auth"
+	"github.com/kekopoly/backend/internal/audit"
+	"github.com/kekopoly/backend/internal/game/manager"
+	"github.com/kekopoly/backend/internal/game/models"
+	"github.com/kekopoly/backend/internal/game/snapshot"
+	"github.com/kekopoly/backend/internal/game/websocket"
+	"github.com/kekopoly/backend/internal/queue"
+	"github.com/kekopoly/backend/internal/settlement"
+)
+
+// Listing limits for the admin API
+const (
+	defaultAdminListLimit = 100
+	maxAdminListLimit     = 1000
+)
+
+// AdminHandler handles operator requests. Every action that changes something is
+// written to the audit log.
+type AdminHandler struct {
+	gameManager  *manager.GameManager
+	wsHub        *websocket.Hub
+	transactions settlement.Store  // Nil when there is no database
+	audit        audit.Store       // Nil when there is no database; actions are only logged
+	bans         *auth.WalletBans  // Nil without Redis
+	queue        *queue.RedisQueue // Nil without Redis
+	logger       *zap.SugaredLogger
+}
+
+// NewAdminHandler creates a new AdminHandler
+func NewAdminHandler(gameManager *manager.GameManager, wsHub *websocket.Hub, transactions settlement.Store, auditLog audit.Store, bans *auth.WalletBans, messageQueue *queue.RedisQueue, logger *zap.SugaredLogger) *AdminHandler {
+	return &AdminHandler{
+		gameManager:  gameManager,
+		wsHub:        wsHub,
+		transactions: transactions,
+		audit:        auditLog,
+		bans:         bans,
+		queue:        messageQueue,
+		logger:       logger,
+	}
+}
+
+// ImportGameRequest is an exported game to recreate. Players maps the IDs of players
+// in the archive to the accounts that should play their seats.
+type ImportGameRequest struct {
+	Archive *snapshot.Archive `json:"archive"`
+	Players map[string]string `json:"players,omitempty"`
+}
+
+// EndGameRequest ends a game. Status is COMPLETED, to settle it as usual, or ABANDONED,
+// to refund every buy-in instead.
+type EndGameRequest struct {
+	Status   string `json:"status" validate:"required,oneof=COMPLETED ABANDONED"`
+	WinnerID string `json:"winnerId,omitempty"` // Completed games only
+	Reason   string `json:"reason,omitempty"`
+}
+
+// AdjustBalanceRequest adds Amount, which may be negative, to a player's balance
+type AdjustBalanceRequest struct {
+	PlayerID string `json:"playerId" validate:"required"`
+	Amount   int    `json:"amount" validate:"required"`
+	Reason   string `json:"reason" validate:"required"`
+}
+
+// RemovePlayerRequest names the player to take out of a game, by ID or by wallet
+type RemovePlayerRequest struct {
+	PlayerID      string `json:"playerId,omitempty"`
+	WalletAddress string `json:"walletAddress,omitempty"`
+	Reason        string `json:"reason,omitempty"`
+}
+
+// ResetGameRequest puts an abandoned game back in the lobby. HostID names the new host;
+// by default the game keeps its host.
+type ResetGameRequest struct {
+	HostID string `json:"hostId,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// BanWalletRequest bans a wallet
+type BanWalletRequest struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+// DeadLetterFilterRequest picks dead letters. Empty fields match every message.
+type DeadLetterFilterRequest struct {
+	IDs       []string `json:"ids,omitempty"`
+	Type      string   `json:"type,omitempty"`
+	OlderThan int      `json:"olderThan,omitempty"` // Seconds since the message was parked
+	Reason    string   `json:"reason,omitempty"`
+}
+
+// filter converts the request to a queue filter
+func (r DeadLetterFilterRequest) filter() queue.DeadLetterFilter {
+	filter := queue.DeadLetterFilter{IDs: r.IDs, Type: queue.MessageType(r.Type)}
+	if r.OlderThan > 0 {
+		filter.Before = time.Now().Add(-time.Duration(r.OlderThan) * time.Second)
+	}
+	return filter
+}
+
+// details describes the filter for the audit log
+func (r DeadLetterFilterRequest) details(count int) map[string]interface{} {
+	details := map[string]interface{}{"messages": count}
+	if len(r.IDs) > 0 {
+		details["ids"] = r.IDs
+	}
+	if r.Type != "" {
+		details["type"] = r.Type
+	}
+	if r.OlderThan > 0 {
+		details["olderThan"] = r.OlderThan
+	}
+	return details
+}
+
+// ListGames lists games of any status, newest first. status takes a comma separated
+// list and defaults to the games still being played or waiting to start.
+func (h *AdminHandler) ListGames(c echo.Context) error {
+	statuses := []models.GameStatus{models.GameStatusLobby, models.GameStatusActive, models.GameStatusPaused}
+	if param := c.QueryParam("status"); param != "" {
+		statuses = nil
+		for _, status := range strings.Split(param, ",") {
+			statuses = append(statuses, models.GameStatus(strings.ToUpper(strings.TrimSpace(status))))
+		}
+	}
+	limit, err := listLimit(c)
+	if err != nil {
+		return err
+	}
+
+	games, err := h.gameManager.FindGames(statuses...)
+	if err != nil {
+		h.logger.Errorf("Failed to list games: %v", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to list games")
+	}
+	total := len(games)
+	if len(games) > limit {
+		games = games[:limit]
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"games": games,
+		"total": total,
+	})
+}
+
+// GetGame returns any game with the socket sessions this node has seen for its players
+func (h *AdminHandler) GetGame(c echo.Context) error {
+	game, err := h.game(c)
+	if err != nil {
+		return err
+	}
+
+	sessions := map[string][]websocket.SessionInfo{}
+	if h.wsHub != nil {
+		playerIDs := make([]string, 0, len(game.Players))
+		for _, player := range game.Players {
+			playerIDs = append(playerIDs, player.ID)
+		}
+		sessions = h.wsHub.PlayerSessions(game.ID.Hex(), playerIDs)
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"game":     game,
+		"sessions": sessions,
+	})
+}
+
+// EndGame force-ends a game, as completed or abandoned
+func (h *AdminHandler) EndGame(c echo.Context) error {
+	var req EndGameRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	req.Status = strings.ToUpper(req.Status)
+	if err := c.Validate(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	game, err := h.game(c)
+	if err != nil {
+		return err
+	}
+	gameID := game.ID.Hex()
+
+	err = h.gameManager.EndGame(gameID, models.GameStatus(req.Status), req.WinnerID)
+	h.record(c, audit.Entry{
+		Action:   "end_game",
+		GameID:   gameID,
+		PlayerID: req.WinnerID,
+		Reason:   req.Reason,
+		Details:  map[string]interface{}{"status": req.Status},
+	}, err)
+	if err != nil {
+		return h.actionError(err, "end game", gameID)
+	}
+	return c.JSON(http.StatusOK, map[string]string{"gameId": gameID, "status": req.Status})
+}
+
+// AdjustBalance changes a player's balance. A reason is required for the audit log.
+func (h *AdminHandler) AdjustBalance(c echo.Context) error {
+	var req AdjustBalanceRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if err := c.Validate(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	game, err := h.game(c)
+	if err != nil {
+		return err
+	}
+	gameID := game.ID.Hex()
+
+	var before int
+	if player := findGamePlayer(game, req.PlayerID); player != nil {
+		before = player.Balance
+	}
+	player, err := h.gameManager.AdjustBalance(gameID, req.PlayerID, req.Amount)
+	details := map[string]interface{}{"amount": req.Amount, "before": before}
+	if player != nil {
+		details["after"] = player.Balance
+	}
+	h.record(c, audit.Entry{
+		Action:   "adjust_balance",
+		GameID:   gameID,
+		PlayerID: req.PlayerID,
+		Reason:   req.Reason,
+		Details:  details,
+	}, err)
+	if err != nil {
+		return h.actionError(err, "adjust balance", gameID)
+	}
+	return c.JSON(http.StatusOK, player)
+}
+
+// RemovePlayer kicks a player, named by ID or wallet, out of a game
+func (h *AdminHandler) RemovePlayer(c echo.Context) error {
+	var req RemovePlayerRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if (req.PlayerID == "") == (req.WalletAddress == "") {
+		return echo.NewHTTPError(http.StatusBadRequest, "Give either playerId or walletAddress")
+	}
+	game, err := h.game(c)
+	if err != nil {
+		return err
+	}
+	gameID := game.ID.Hex()
+
+	playerID := req.PlayerID
+	if req.WalletAddress != "" {
+		for _, player := range game.Players {
+			if player.WalletAddress == req.WalletAddress {
+				playerID = player.ID
+				break
+			}
+		}
+		if playerID == "" {
+			return echo.NewHTTPError(http.StatusNotFound, "No player in the game has that wallet")
+		}
+	}
+
+	err = h.gameManager.RemovePlayer(gameID, playerID)
+	h.record(c, audit.Entry{
+		Action:   "remove_player",
+		GameID:   gameID,
+		PlayerID: playerID,
+		Wallet:   req.WalletAddress,
+		Reason:   req.Reason,
+	}, err)
+	if err != nil {
+		return h.actionError(err, "remove player", gameID)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ResetGame puts an abandoned game back in the lobby
+func (h *AdminHandler) ResetGame(c echo.Context) error {
+	var req ResetGameRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	game, err := h.game(c)
+	if err != nil {
+		return err
+	}
+	gameID := game.ID.Hex()
+
+	hostID := req.HostID
+	if hostID == "" {
+		hostID = game.HostID
+	} else if findGamePlayer(game, hostID) == nil {
+		return echo.NewHTTPError(http.StatusNotFound, manager.ErrPlayerNotInGame.Error())
+	}
+	if game.Status != models.GameStatusAbandoned {
+		err = manager.ErrNotAbandoned
+	} else {
+		err = h.gameManager.ResetGameStatus(gameID, hostID)
+	}
+	h.record(c, audit.Entry{
+		Action:   "reset_game",
+		GameID:   gameID,
+		PlayerID: hostID,
+		Reason:   req.Reason,
+	}, err)
+	if err != nil {
+		return h.actionError(err, "reset game", gameID)
+	}
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Game has been reset to LOBBY status",
+		"hostId":  hostID,
+	})
+}
+
+// CleanupStaleGames removes stale/duplicate game records from the database
+func (h *AdminHandler) CleanupStaleGames(c echo.Context) error {
+	staleGames, err := h.gameManager.CleanupStaleGames()
+	h.record(c, audit.Entry{
+		Action:  "cleanup_games",
+		Details: map[string]interface{}{"games": staleGames},
+	}, err)
+	if err != nil {
+		h.logger.Errorf("Error cleaning up stale games: %v", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to clean up stale games")
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message":      "Stale games cleanup completed",
+		"gamesRemoved": len(staleGames),
+		"games":        staleGames,
+	})
+}
+
+// GetWalletBan shows whether a wallet is banned
+func (h *AdminHandler) GetWalletBan(c echo.Context) error {
+	if h.bans == nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "Wallet bans are unavailable")
+	}
+	ban, err := h.bans.Get(c.Request().Context(), c.Param("walletAddress"))
+	if err != nil {
+		h.logger.Errorf("Failed to look up wallet ban: %v", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to look up wallet ban")
+	}
+	if ban == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Wallet is not banned")
+	}
+	return c.JSON(http.StatusOK, ban)
+}
+
+// BanWallet bans a wallet. Its tokens, and those of the account it is linked to, stop
+// working straight away, and it can't sign in, be linked or refresh a session. Sockets
+// it already has open stay up until it is kicked from its game.
+func (h *AdminHandler) BanWallet(c echo.Context) error {
+	if h.bans == nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "Wallet bans are unavailable")
+	}
+	var req BanWalletRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	adminID, _ := c.Get("userID").(string)
+	ban := auth.WalletBan{
+		WalletAddress: c.Param("walletAddress"),
+		Reason:        req.Reason,
+		BannedBy:      adminID,
+		BannedAt:      time.Now().UTC(),
+	}
+
+	err := h.bans.Ban(c.Request().Context(), ban)
+	h.record(c, audit.Entry{Action: "ban_wallet", Wallet: ban.WalletAddress, Reason: req.Reason}, err)
+	if err != nil {
+		h.logger.Errorf("Failed to ban wallet %s: %v", ban.WalletAddress, err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to ban wallet")
+	}
+	return c.JSON(http.StatusOK, ban)
+}
+
+// UnbanWallet lifts a wallet's ban
+func (h *AdminHandler) UnbanWallet(c echo.Context) error {
+	if h.bans == nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "Wallet bans are unavailable")
+	}
+	walletAddress := c.Param("walletAddress")
+
+	unbanned, err := h.bans.Unban(c.Request().Context(), walletAddress)
+	h.record(c, audit.Entry{Action: "unban_wallet", Wallet: walletAddress, Reason: c.QueryParam("reason")}, err)
+	if err != nil {
+		h.logger.Errorf("Failed to unban wallet %s: %v", walletAddress, err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to unban wallet")
+	}
+	if !unbanned {
+		return echo.NewHTTPError(http.StatusNotFound, "Wallet is not banned")
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// DrainDeadLetters deletes a game's dead-lettered queue messages
+func (h *AdminHandler) DrainDeadLetters(c echo.Context) error {
+	if h.queue == nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "Message queue is unavailable")
+	}
+	gameID := strings.ToLower(c.Param("gameId"))
+
+	drained, err := h.queue.DrainDeadLetterQueue(gameID)
+	h.record(c, audit.Entry{Action: "drain_dead_letters", GameID: gameID, Details: map[string]interface{}{"messages": drained}}, err)
+	if err != nil {
+		h.logger.Errorf("Failed to drain dead letters for game %s: %v", gameID, err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to drain dead letters")
+	}
+	return c.JSON(http.StatusOK, map[string]int64{"drained": drained})
+}
+
+// DrainAllDeadLetters deletes every game's dead letter queue
+func (h *AdminHandler) DrainAllDeadLetters(c echo.Context) error {
+	if h.queue == nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "Message queue is unavailable")
+	}
+
+	queues, err := h.queue.ClearDeadLetterQueues()
+	h.record(c, audit.Entry{Action: "drain_dead_letters", Details: map[string]interface{}{"queues": queues}}, err)
+	if err != nil {
+		h.logger.Errorf("Failed to drain dead letter queues: %v", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to drain dead letters")
+	}
+	return c.JSON(http.StatusOK, map[string]int64{"queues": queues})
+}
+
+// ListDeadLetterQueues lists the games with dead letters, with how many each has and
+// when the oldest and newest were parked
+func (h *AdminHandler) ListDeadLetterQueues(c echo.Context) error {
+	if h.queue == nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "Message queue is unavailable")
+	}
+	queues, err := h.queue.ListDeadLetterQueues()
+	if err != nil {
+		h.logger.Errorf("Failed to list dead letter queues: %v", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to list dead letter queues")
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"queues": queues})
+}
+
+// ListStreams returns each queue partition's stream and the worker reading it
+func (h *AdminHandler) ListStreams(c echo.Context) error {
+	if h.queue == nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "Message queue is unavailable")
+	}
+	streams, err := h.queue.Streams()
+	if err != nil {
+		h.logger.Errorf("Failed to read queue streams: %v", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to read queue streams")
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"streams": streams})
+}
+
+// ListDeadLetters returns a game's dead letters, oldest first
+func (h *AdminHandler) ListDeadLetters(c echo.Context) error {
+	if h.queue == nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "Message queue is unavailable")
+	}
+	gameID := strings.ToLower(c.Param("gameId"))
+
+	messages, err := h.queue.DeadLetters(gameID)
+	if err != nil {
+		h.logger.Errorf("Failed to read dead letters for game %s: %v", gameID, err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to read dead letters")
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"messages": messages})
+}
+
+// GetDeadLetter returns one dead letter with its error history
+func (h *AdminHandler) GetDeadLetter(c echo.Context) error {
+	if h.queue == nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "Message queue is unavailable")
+	}
+	gameID := strings.ToLower(c.Param("gameId"))
+
+	msg, err := h.queue.DeadLetter(gameID, c.Param("messageId"))
+	if err != nil {
+		h.logger.Errorf("Failed to read dead letters for game %s: %v", gameID, err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to read dead letters")
+	}
+	if msg == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Dead letter not found")
+	}
+	return c.JSON(http.StatusOK, msg)
+}
+
+// RequeueDeadLetters moves a game's dead-lettered messages back onto its queue with
+// their attempts reset: all of them, or those the body picks
+func (h *AdminHandler) RequeueDeadLetters(c echo.Context) error {
+	if h.queue == nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "Message queue is unavailable")
+	}
+	var req DeadLetterFilterRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	gameID := strings.ToLower(c.Param("gameId"))
+
+	requeued, err := h.queue.RequeueDeadLetters(gameID, req.filter())
+	h.record(c, audit.Entry{Action: "requeue_dead_letters", GameID: gameID, Reason: req.Reason, Details: req.details(requeued)}, err)
+	if err != nil {
+		h.logger.Errorf("Failed to requeue dead letters for game %s: %v", gameID, err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to requeue dead letters")
+	}
+	return c.JSON(http.StatusOK, map[string]int{"requeued": requeued})
+}
+
+// PurgeDeadLetters deletes the dead letters the body picks. Deleting all of them is
+// left to DrainDeadLetters.
+func (h *AdminHandler) PurgeDeadLetters(c echo.Context) error {
+	if h.queue == nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "Message queue is unavailable")
+	}
+	var req DeadLetterFilterRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	filter := req.filter()
+	if filter.IsEmpty() {
+		return echo.NewHTTPError(http.StatusBadRequest, "Give ids, type or olderThan; drain the queue to delete everything")
+	}
+	gameID := strings.ToLower(c.Param("gameId"))
+
+	purged, err := h.queue.PurgeDeadLetters(gameID, filter)
+	h.record(c, audit.Entry{Action: "purge_dead_letters", GameID: gameID, Reason: req.Reason, Details: req.details(purged)}, err)
+	if err != nil {
+		h.logger.Errorf("Failed to purge dead letters for game %s: %v", gameID, err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to purge dead letters")
+	}
+	return c.JSON(http.StatusOK, map[string]int{"purged": purged})
+}
+
+// ListAuditLog returns audit entries, newest first
+func (h *AdminHandler) ListAuditLog(c echo.Context) error {
+	if h.audit == nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "Audit log is unavailable")
+	}
+	limit, err := listLimit(c)
+	if err != nil {
+		return err
+	}
+
+	entries, err := h.audit.List(c.Request().Context(), audit.Filter{
+		AdminID: c.QueryParam("adminId"),
+		GameID:  strings.ToLower(c.QueryParam("gameId")),
+		Action:  c.QueryParam("action"),
+		Limit:   limit,
+	})
+	if err != nil {
+		h.logger.Errorf("Failed to list audit log: %v", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to list audit log")
+	}
+	if entries == nil {
+		entries = []audit.Entry{}
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"entries": entries})
+}
+
+// ExportGame downloads a game, its recent events and its transactions as a snapshot archive
+func (h *AdminHandler) ExportGame(c echo.Context) error {
+	game, err := h.game(c)
+	if err != nil {
+		return err
+	}
+	gameID := game.ID.Hex()
+
+	ctx := c.Request().Context()
+	var events []snapshot.Event
+	if h.wsHub != nil {
+		kept, err := h.wsHub.GameEvents(ctx, gameID)
+		if err != nil {
+			h.logger.Errorf("Failed to read events for game %s: %v", gameID, err)
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to read game events")
+		}
+		for _, event := range kept {
+			events = append(events, snapshot.Event{Seq: event.Seq, Exclude: event.ExcludePlayerID, Message: json.RawMessage(event.Data)})
+		}
+	}
+
+	var txs []models.Transaction
+	if h.transactions != nil {
+		if txs, err = h.transactions.GameTransactions(ctx, gameID, ""); err != nil {
+			h.logger.Errorf("Failed to read transactions for game %s: %v", gameID, err)
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to read game transactions")
+		}
+	}
+
+	h.record(c, audit.Entry{Action: "export_game", GameID: gameID}, nil)
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="game-%s.json"`, game.Code))
+	c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+	c.Response().WriteHeader(http.StatusOK)
+	return snapshot.New(game, events, txs).Write(c.Response())
+}
+
+// ImportGame creates a new game from a snapshot archive
+func (h *AdminHandler) ImportGame(c echo.Context) error {
+	var req ImportGameRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if req.Archive == nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Missing archive")
+	}
+	archive := req.Archive
+	if err := archive.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := archive.RenamePlayers(req.Players); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	gameID, err := h.gameManager.ImportGame(&archive.Game)
+	h.record(c, audit.Entry{
+		Action:  "import_game",
+		GameID:  gameID,
+		Details: map[string]interface{}{"from": archive.Game.ID.Hex(), "exportedAt": archive.ExportedAt.Format(time.RFC3339)},
+	}, err)
+	if err != nil {
+		h.logger.Errorf("Failed to import game: %v", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to import game")
+	}
+	game, err := h.gameManager.GetGame(gameID)
+	if err != nil {
+		h.logger.Errorf("Failed to load imported game %s: %v", gameID, err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load imported game")
+	}
+
+	return c.JSON(http.StatusCreated, map[string]string{
+		"gameId": gameID,
+		"code":   game.Code,
+		"status": string(game.Status),
+	})
+}
+
+// game loads the game named in the path, by ID or room code
+func (h *AdminHandler) game(c echo.Context) (*models.Game, error) {
+	gameID := strings.ToLower(c.Param("gameId"))
+	if gameID == "" {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "Missing game ID")
+	}
+	game, err := h.gameManager.GameSnapshot(gameID)
+	if errors.Is(err, manager.ErrGameNotFound) {
+		return nil, echo.NewHTTPError(http.StatusNotFound, "Game not found")
+	}
+	if err != nil {
+		h.logger.Errorf("Failed to read game %s: %v", gameID, err)
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, "Failed to read game")
+	}
+	return game, nil
+}
+
+// record writes an operator action to the audit log. Failed actions are recorded too,
+// with their error. The action has already happened, so a failure to record it is
+// logged rather than returned.
+func (h *AdminHandler) record(c echo.Context, entry audit.Entry, actionErr error) {
+	entry.AdminID, _ = c.Get("userID").(string)
+	entry.RequestID, _ = c.Get("requestID").(string)
+	if actionErr != nil {
+		entry.Error = actionErr.Error()
+	}
+	h.logger.Infow("Admin action", "adminId", entry.AdminID, "action", entry.Action, "gameId", entry.GameID,
+		"playerId", entry.PlayerID, "walletAddress", entry.Wallet, "reason", entry.Reason, "error", entry.Error)
+
+	if h.audit == nil {
+		return
+	}
+	if err := h.audit.Record(c.Request().Context(), &entry); err != nil {
+		h.logger.Errorf("Failed to record admin action %s: %v", entry.Action, err)
+	}
+}
+
+// actionError maps a failed game manager call to an HTTP error. Errors the operator can
+// act on keep their message; anything else, such as a store failure or a forwarding
+// timeout, is logged and reported as a server error.
+func (h *AdminHandler) actionError(err error, what, gameID string) error {
+	if status := accessStatus(err); status != 0 {
+		return echo.NewHTTPError(status, err.Error())
+	}
+	switch {
+	case errors.Is(err, manager.ErrInvalidEnding):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	case errors.Is(err, manager.ErrGameOver), errors.Is(err, manager.ErrNegativeBalance),
+		errors.Is(err, manager.ErrNotAbandoned):
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	}
+	h.logger.Errorf("Failed to %s in game %s: %v", what, gameID, err)
+	return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to %s", what))
+}
+
+// listLimit reads the limit query parameter
+func listLimit(c echo.Context) (int, error) {
+	param := c.QueryParam("limit")
+	if param == "" {
+		return defaultAdminListLimit, nil
+	}
+	limit, err := strconv.Atoi(param)
+	if err != nil || limit <= 0 || limit > maxAdminListLimit {
+		return 0, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxAdminListLimit))
+	}
+	return limit, nil
+}
+
+// findGamePlayer returns the player in the game, or nil
+func findGamePlayer(game *models.Game, playerID string) *models.Player {
+	for i := range game.Players {
+		if game.Players[i].ID == playerID {
+			return &game.Players[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/kekopoly/backend/internal/game/manager"
+)
+
+func TestActionError(t *testing.T) {
+	h := &AdminHandler{logger: zap.NewNop().Sugar()}
+
+	cases := []struct {
+		err    error
+		status int
+	}{
+		{manager.ErrPlayerNotInGame, http.StatusNotFound},
+		{fmt.Errorf("%w: an abandoned game has no winner", manager.ErrInvalidEnding), http.StatusBadRequest},
+		{manager.ErrGameOver, http.StatusConflict},
+		{fmt.Errorf("%w; it is 10", manager.ErrNegativeBalance), http.StatusConflict},
+		{manager.ErrNotAbandoned, http.StatusConflict},
+		{fmt.Errorf("failed to persist balance: %w", errors.New("connection reset")), http.StatusInternalServerError},
+		{manager.ErrOwnerUnavailable, http.StatusInternalServerError},
+	}
+	for _, c := range cases {
+		err := h.actionError(c.err, "adjust balance", "g1")
+		assert.Equal(t, c.status, httpStatus(err), c.err.Error())
+	}
+
+	// Server errors don't leak their details
+	err := h.actionError(errors.New("failed to persist balance: connection reset"), "adjust balance", "g1")
+	assert.Equal(t, "Failed to adjust balance", err.(*echo.HTTPError).Message)
+}
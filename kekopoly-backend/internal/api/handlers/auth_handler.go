@@ -1,7 +1,9 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
@@ -10,51 +12,71 @@ import (
 	"github.com/kekopoly/backend/internal/api/middleware/auth"
 	solanaauth "github.com/kekopoly/backend/internal/auth"
 	"github.com/kekopoly/backend/internal/config"
+	"github.com/kekopoly/backend/internal/users"
 )
 
 // AuthHandler handles authentication-related requests
 type AuthHandler struct {
-	cfg       *config.Config
-	logger    *zap.SugaredLogger
-	validator *solanaauth.SolanaValidator
+	cfg        *config.Config
+	logger     *zap.SugaredLogger
+	validator  *solanaauth.SolanaValidator
+	users      users.Store
+	tokens     *auth.TokenStore
+	challenges *solanaauth.ChallengeStore
+	bans       *auth.WalletBans // Nil without Redis
 }
 
 // NewAuthHandler creates a new AuthHandler
-func NewAuthHandler(cfg *config.Config, logger *zap.SugaredLogger) *AuthHandler {
+func NewAuthHandler(cfg *config.Config, userStore users.Store, tokenStore *auth.TokenStore, challengeStore *solanaauth.ChallengeStore, bans *auth.WalletBans, logger *zap.SugaredLogger) *AuthHandler {
 	handler := &AuthHandler{
-		cfg:    cfg,
-		logger: logger,
-	}
-
-	// Create validator if config is available
-	if cfg != nil {
-		var rpcURL string
-		if cfg.Solana.RpcURL != "" {
-			rpcURL = cfg.Solana.RpcURL
-			logger.Infow("Initializing Solana validator with configured RPC URL", "url", rpcURL)
-		} else {
-			logger.Info("Initializing Solana validator with default mainnet RPC URL")
-		}
+		cfg:        cfg,
+		logger:     logger,
+		users:      userStore,
+		tokens:     tokenStore,
+		challenges: challengeStore,
+		bans:       bans,
+	}
+
+	handler.validator = newSolanaValidator(cfg, logger)
+
+	return handler
+}
 
-		// Create validator
-		handler.validator = solanaauth.NewSolanaValidator(rpcURL)
+// newSolanaValidator builds the wallet signature validator from config. A mode the
+// config isn't allowed to use falls back to strict rather than weakening validation.
+func newSolanaValidator(cfg *config.Config, logger *zap.SugaredLogger) *solanaauth.SolanaValidator {
+	if cfg == nil {
+		logger.Warn("No configuration provided - creating strict mainnet validator")
+		return solanaauth.NewSolanaValidator("")
+	}
 
-		// Set validator state based on config
+	mode := solanaauth.ValidatorMode(cfg.Solana.ValidatorMode)
+	if mode == "" {
+		mode = solanaauth.ModeStrict
 		if cfg.Solana.DevMode {
-			logger.Warn("Development mode enabled - signature validation will be bypassed")
-			handler.validator.Disable()
-		} else {
-			logger.Info("Production mode - signature validation is enabled")
-			handler.validator.Enable()
+			mode = solanaauth.ModeOff
 		}
-	} else {
-		// Create disabled validator if no config
-		logger.Warn("No configuration provided - creating disabled validator")
-		handler.validator = solanaauth.NewSolanaValidator("")
-		handler.validator.Disable()
 	}
 
-	return handler
+	opts := solanaauth.ValidatorOptions{
+		RPCURL:     cfg.Solana.RpcURL,
+		Network:    cfg.Solana.Network,
+		Mode:       mode,
+		DevMode:    cfg.Solana.DevMode,
+		DevWallets: cfg.Solana.DevWallets,
+		Logger:     logger,
+	}
+	validator, err := solanaauth.NewSolanaValidatorWithOptions(opts)
+	if err != nil {
+		logger.Errorw("Invalid Solana validator configuration, falling back to strict mode", "mode", mode, "error", err)
+		opts.Mode = solanaauth.ModeStrict
+		opts.DevWallets = nil
+		validator, _ = solanaauth.NewSolanaValidatorWithOptions(opts)
+	}
+	if validator.Mode() == solanaauth.ModeOff {
+		logger.Warn("Development mode enabled - signature validation will be bypassed")
+	}
+	return validator
 }
 
 // RegisterRequest represents a user registration request
@@ -85,6 +107,8 @@ type AuthResponse struct {
 	Email         string `json:"email,omitempty"`
 	WalletAddress string `json:"walletAddress,omitempty"`
 	Token         string `json:"token"`
+	RefreshToken  string `json:"refreshToken,omitempty"`
+	ExpiresIn     int    `json:"expiresIn,omitempty"` // Access token lifetime in seconds
 }
 
 // Register handles user registration
@@ -98,27 +122,40 @@ func (h *AuthHandler) Register(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
-	// In a real implementation, we would:
-	// 1. Check if user already exists
-	// 2. Hash the password
-	// 3. Store user in database
-
-	// For this simplified implementation, we'll just generate a token
-	userID := uuid.New().String()
+	if h.users == nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "User accounts are unavailable")
+	}
 
-	// Generate JWT token
-	token, err := auth.GenerateJWT(userID, "", h.cfg.JWT.Secret, h.cfg.JWT.Expiration)
+	passwordHash, err := users.HashPassword(req.Password)
 	if err != nil {
-		h.logger.Errorf("Failed to generate JWT: %v", err)
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to generate token")
+		h.logger.Errorf("Failed to hash password: %v", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create account")
 	}
 
-	return c.JSON(http.StatusCreated, AuthResponse{
-		UserID:   userID,
-		Username: req.Username,
-		Email:    req.Email,
-		Token:    token,
-	})
+	now := time.Now()
+	user := &users.User{
+		ID:           uuid.New().String(),
+		Email:        req.Email,
+		Username:     req.Username,
+		PasswordHash: passwordHash,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		LastLoginAt:  now,
+	}
+
+	if err := h.users.Create(c.Request().Context(), user); err != nil {
+		switch {
+		case errors.Is(err, users.ErrEmailTaken), errors.Is(err, users.ErrUsernameTaken):
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		default:
+			h.logger.Errorf("Failed to create user: %v", err)
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create account")
+		}
+	}
+
+	h.logger.Infow("User registered", "userId", user.ID, "username", user.Username)
+
+	return h.respondWithSession(c, http.StatusCreated, user, "", "")
 }
 
 // Login handles user login
@@ -132,25 +169,30 @@ func (h *AuthHandler) Login(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
-	// In a real implementation, we would:
-	// 1. Retrieve user from database
-	// 2. Verify password hash
+	if h.users == nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "User accounts are unavailable")
+	}
 
-	// For this simplified implementation, we'll just generate a token
-	userID := uuid.New().String() // In a real implementation, this would be the actual user ID
+	user, err := h.users.FindByEmail(c.Request().Context(), req.Email)
+	if err != nil && !errors.Is(err, users.ErrUserNotFound) {
+		h.logger.Errorf("Failed to look up user: %v", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to log in")
+	}
 
-	// Generate JWT token
-	token, err := auth.GenerateJWT(userID, "", h.cfg.JWT.Secret, h.cfg.JWT.Expiration)
-	if err != nil {
-		h.logger.Errorf("Failed to generate JWT: %v", err)
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to generate token")
+	// Unknown email and wrong password get the same response
+	if user == nil || !user.CheckPassword(req.Password) {
+		return echo.NewHTTPError(http.StatusUnauthorized, users.ErrInvalidCredentials.Error())
 	}
 
-	return c.JSON(http.StatusOK, AuthResponse{
-		UserID: userID,
-		Email:  req.Email,
-		Token:  token,
-	})
+	if err := h.checkWalletBan(c, user.WalletAddress); err != nil {
+		return err
+	}
+
+	if err := h.users.TouchLogin(c.Request().Context(), user.ID); err != nil {
+		h.logger.Warnf("Failed to record login for user %s: %v", user.ID, err)
+	}
+
+	return h.respondWithSession(c, http.StatusOK, user, user.WalletAddress, "")
 }
 
 // WalletConnect handles wallet connection
@@ -164,23 +206,10 @@ func (h *AuthHandler) WalletConnect(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
-	// Check if we should run in development mode
-	var devMode bool
-	if h.cfg != nil && h.cfg.Solana.DevMode {
-		devMode = true
-		h.logger.Warn("DEVELOPMENT MODE: Signature verification may be bypassed")
-	}
-
-	// If validator is nil, create an empty one and disable it
+	// Fall back to a strict validator if the handler was built without one
 	if h.validator == nil {
-		h.logger.Warn("Creating fallback validator in disabled state")
+		h.logger.Warn("Creating fallback validator in strict mode")
 		h.validator = solanaauth.NewSolanaValidator("")
-		h.validator.Disable()
-	}
-
-	// In dev mode, disable validation
-	if devMode {
-		h.validator.Disable()
 	}
 
 	// Get format from request
@@ -197,75 +226,384 @@ func (h *AuthHandler) WalletConnect(c echo.Context) error {
 	h.logger.Infow("Wallet connection attempt",
 		"wallet", req.WalletAddress,
 		"format", format,
-		"validation_enabled", h.validator.IsEnabled())
+		"validator_mode", h.validator.Mode())
+
+	if h.users == nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "User accounts are unavailable")
+	}
+
+	// Only signatures over an outstanding server-issued challenge are accepted
+	req.Format = format
+	if err := h.verifyChallengeSignature(c, req); err != nil {
+		return err
+	}
+	if err := h.checkWalletBan(c, req.WalletAddress); err != nil {
+		return err
+	}
+
+	// Resolve the account that owns this wallet, creating one on first sign-in
+	user, err := h.findOrCreateWalletUser(c, req.WalletAddress)
+	if err != nil {
+		h.logger.Errorf("Failed to resolve user for wallet %s: %v", req.WalletAddress, err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load account")
+	}
+
+	// Log successful authentication
+	h.logger.Infow("Wallet authenticated successfully",
+		"wallet", req.WalletAddress,
+		"userId", user.ID)
+
+	return h.respondWithSession(c, http.StatusOK, user, req.WalletAddress, "")
+}
+
+// Challenge issues a single-use Sign-In-With-Solana message for a wallet
+func (h *AuthHandler) Challenge(c echo.Context) error {
+	wallet := c.QueryParam("wallet")
+	if wallet == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Missing wallet address")
+	}
 
-	// Verify signature if validation is enabled
-	valid, err := h.validator.VerifySignature(
-		req.WalletAddress,
-		req.Message,
-		req.Signature,
-		format,
-	)
+	if h.challenges == nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "Wallet sign-in is unavailable")
+	}
+
+	challenge, err := h.challenges.Issue(c.Request().Context(), wallet)
+	if err != nil {
+		if errors.Is(err, solanaauth.ErrInvalidWallet) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		h.logger.Errorf("Failed to issue challenge for wallet %s: %v", wallet, err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to issue challenge")
+	}
+
+	return c.JSON(http.StatusOK, challenge)
+}
+
+// verifyChallengeSignature checks that req signs an outstanding challenge for the wallet
+// and consumes the challenge. The challenge is only consumed once the signature checks
+// out, so a client may retry with another signature encoding.
+func (h *AuthHandler) verifyChallengeSignature(c echo.Context, req WalletConnectRequest) error {
+	if h.challenges == nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "Wallet sign-in is unavailable")
+	}
+
+	ctx := c.Request().Context()
+
+	nonce, err := h.challenges.Lookup(ctx, req.WalletAddress, req.Message)
+	if err != nil {
+		if errors.Is(err, solanaauth.ErrChallengeNotFound) {
+			h.logger.Warnf("Rejected wallet sign-in for %s: %v", req.WalletAddress, err)
+			return echo.NewHTTPError(http.StatusUnauthorized, "Challenge expired or unknown, request a new one")
+		}
+		h.logger.Errorf("Failed to look up challenge: %v", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to verify challenge")
+	}
 
-	// Handle validation errors
+	valid, err := h.validator.VerifySignature(req.WalletAddress, req.Message, req.Signature, req.Format)
 	if err != nil {
 		h.logger.Errorf("Signature verification error: %v", err)
 		return echo.NewHTTPError(http.StatusBadRequest, "Invalid signature: "+err.Error())
 	}
-
-	// Handle invalid signatures
 	if !valid {
 		h.logger.Warnf("Invalid signature for wallet %s", req.WalletAddress)
 		return echo.NewHTTPError(http.StatusUnauthorized, "Signature verification failed")
 	}
 
-	// Generate a user ID and JWT token
-	userID := uuid.New().String()
-	token, err := auth.GenerateJWT(userID, req.WalletAddress, h.cfg.JWT.Secret, h.cfg.JWT.Expiration)
+	// Consuming is atomic, so a replayed signature racing this request loses
+	if err := h.challenges.Consume(ctx, req.WalletAddress, nonce); err != nil {
+		if errors.Is(err, solanaauth.ErrChallengeNotFound) {
+			return echo.NewHTTPError(http.StatusUnauthorized, "Challenge already used, request a new one")
+		}
+		h.logger.Errorf("Failed to consume challenge: %v", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to verify challenge")
+	}
+
+	return nil
+}
+
+// findOrCreateWalletUser returns the account linked to walletAddress, creating a wallet-only account if none exists
+func (h *AuthHandler) findOrCreateWalletUser(c echo.Context, walletAddress string) (*users.User, error) {
+	ctx := c.Request().Context()
+
+	user, err := h.users.FindByWallet(ctx, walletAddress)
+	if err == nil {
+		if err := h.users.TouchLogin(ctx, user.ID); err != nil {
+			h.logger.Warnf("Failed to record login for user %s: %v", user.ID, err)
+		}
+		return user, nil
+	}
+	if !errors.Is(err, users.ErrUserNotFound) {
+		return nil, err
+	}
+
+	now := time.Now()
+	user = &users.User{
+		ID:            uuid.New().String(),
+		WalletAddress: walletAddress,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+		LastLoginAt:   now,
+	}
+	if err := h.users.Create(ctx, user); err != nil {
+		// Another request created the account first
+		if errors.Is(err, users.ErrWalletLinked) {
+			return h.users.FindByWallet(ctx, walletAddress)
+		}
+		return nil, err
+	}
+
+	h.logger.Infow("Created account for new wallet", "wallet", walletAddress, "userId", user.ID)
+	return user, nil
+}
+
+// LinkWallet links a signed-for wallet to the authenticated account
+func (h *AuthHandler) LinkWallet(c echo.Context) error {
+	userID, ok := c.Get("userID").(string)
+	if !ok || userID == "" {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req WalletConnectRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if h.users == nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "User accounts are unavailable")
+	}
+
+	if h.validator == nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "Signature validation is unavailable")
+	}
+
+	// The caller must prove they control the wallet before it is attached
+	if err := h.verifyChallengeSignature(c, req); err != nil {
+		return err
+	}
+	if err := h.checkWalletBan(c, req.WalletAddress); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	// A wallet that already belongs to another account can't be taken over
+	if owner, err := h.users.FindByWallet(ctx, req.WalletAddress); err == nil && owner.ID != userID {
+		return echo.NewHTTPError(http.StatusConflict, users.ErrWalletLinked.Error())
+	} else if err != nil && !errors.Is(err, users.ErrUserNotFound) {
+		h.logger.Errorf("Failed to look up wallet owner: %v", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to link wallet")
+	}
+
+	user, err := h.users.LinkWallet(ctx, userID, req.WalletAddress)
 	if err != nil {
-		h.logger.Errorf("Failed to generate JWT: %v", err)
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to generate token")
+		switch {
+		case errors.Is(err, users.ErrUserNotFound):
+			return echo.NewHTTPError(http.StatusNotFound, "User not found")
+		case errors.Is(err, users.ErrWalletLinked):
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		default:
+			h.logger.Errorf("Failed to link wallet: %v", err)
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to link wallet")
+		}
 	}
 
-	// Log successful authentication
-	h.logger.Infow("Wallet authenticated successfully",
-		"wallet", req.WalletAddress,
-		"userId", userID)
+	h.logger.Infow("Wallet linked to account", "wallet", user.WalletAddress, "userId", user.ID)
 
-	return c.JSON(http.StatusOK, AuthResponse{
-		UserID:        userID,
-		WalletAddress: req.WalletAddress,
-		Token:         token,
-	})
+	// Tokens issued before the link carry no wallet, so the caller's session is replaced
+	// by one that does rather than left running alongside it
+	if claims, ok := c.Get("claims").(*auth.Claims); ok && h.tokens != nil {
+		if err := h.tokens.RevokeFamily(ctx, claims.SessionID); err != nil {
+			h.logger.Errorf("Failed to revoke session: %v", err)
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to link wallet")
+		}
+	}
+	return h.respondWithSession(c, http.StatusOK, user, user.WalletAddress, "")
 }
 
-// RefreshToken handles token refresh
+// RefreshTokenRequest represents a token refresh request
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refreshToken" validate:"required"`
+}
+
+// RefreshToken exchanges a refresh token for a new access token and a rotated refresh token
 func (h *AuthHandler) RefreshToken(c echo.Context) error {
-	// Get user ID from context (set by JWT middleware)
-	userID := c.Get("userID").(string)
-	walletAddress := ""
-	if addr, ok := c.Get("walletAddress").(string); ok {
-		walletAddress = addr
+	var req RefreshTokenRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if h.tokens == nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "Token refresh is unavailable")
+	}
+
+	ctx := c.Request().Context()
+
+	session, refreshToken, err := h.tokens.Rotate(ctx, req.RefreshToken)
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrRefreshTokenReused):
+			h.logger.Warnw("Refresh token reuse detected, session revoked")
+			return echo.NewHTTPError(http.StatusUnauthorized, err.Error())
+		case errors.Is(err, auth.ErrInvalidRefreshToken), errors.Is(err, auth.ErrSessionRevoked):
+			return echo.NewHTTPError(http.StatusUnauthorized, err.Error())
+		default:
+			h.logger.Errorf("Failed to rotate refresh token: %v", err)
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to refresh token")
+		}
+	}
+
+	// The wallet is read from the account, not the session, so a wallet linked since
+	// the session began is carried by its tokens
+	walletAddress := session.WalletAddress
+	if h.users != nil {
+		user, err := h.users.FindByID(ctx, session.UserID)
+		if err != nil {
+			if errors.Is(err, users.ErrUserNotFound) {
+				return echo.NewHTTPError(http.StatusUnauthorized, "User not found")
+			}
+			h.logger.Errorf("Failed to look up user %s: %v", session.UserID, err)
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to refresh token")
+		}
+		walletAddress = user.WalletAddress
+	}
+	if err := h.checkWalletBan(c, walletAddress); err != nil {
+		return err
 	}
 
-	// Generate new token
-	token, err := auth.GenerateJWT(userID, walletAddress, h.cfg.JWT.Secret, h.cfg.JWT.Expiration)
+	ttl := h.accessTokenTTL()
+	token, _, err := auth.GenerateAccessToken(session.UserID, walletAddress, h.role(session.UserID), session.FamilyID, h.cfg.JWT.Secret, ttl)
 	if err != nil {
 		h.logger.Errorf("Failed to generate JWT: %v", err)
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to generate token")
 	}
 
-	return c.JSON(http.StatusOK, map[string]string{
-		"token": token,
+	return c.JSON(http.StatusOK, AuthResponse{
+		UserID:        session.UserID,
+		WalletAddress: walletAddress,
+		Token:         token,
+		RefreshToken:  refreshToken,
+		ExpiresIn:     int(ttl.Seconds()),
 	})
 }
 
-// Logout handles user logout
+// Logout revokes the current access token and its refresh token family
 func (h *AuthHandler) Logout(c echo.Context) error {
-	// In a real implementation, we would:
-	// 1. Add the token to a blacklist
-	// 2. Possibly invalidate any sessions
+	claims, ok := c.Get("claims").(*auth.Claims)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	// Without a token store, tokens simply expire on their own
+	if h.tokens == nil {
+		return c.NoContent(http.StatusNoContent)
+	}
+
+	ctx := c.Request().Context()
+
+	if claims.ExpiresAt != nil {
+		if err := h.tokens.DenyAccessToken(ctx, claims.ID, claims.ExpiresAt.Time); err != nil {
+			h.logger.Errorf("Failed to deny access token: %v", err)
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to log out")
+		}
+	}
 
-	// For this simplified implementation, we'll just return success
+	if err := h.tokens.RevokeFamily(ctx, claims.SessionID); err != nil {
+		h.logger.Errorf("Failed to revoke session: %v", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to log out")
+	}
+
+	h.logger.Infow("User logged out", "userId", claims.UserID, "jti", claims.ID)
 	return c.NoContent(http.StatusNoContent)
 }
+
+// checkWalletBan refuses a sign-in, link or refresh for a banned wallet
+func (h *AuthHandler) checkWalletBan(c echo.Context, walletAddress string) error {
+	if h.bans == nil {
+		return nil
+	}
+	banned, err := h.bans.IsBanned(c.Request().Context(), walletAddress)
+	if err != nil {
+		h.logger.Errorf("Failed to check ban for wallet %s: %v", walletAddress, err)
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "Failed to check wallet ban")
+	}
+	if banned {
+		h.logger.Infow("Refused banned wallet", "wallet", walletAddress)
+		return echo.NewHTTPError(http.StatusForbidden, "Wallet is banned")
+	}
+	return nil
+}
+
+// respondWithSession issues an access token (and a refresh token when a token store is
+// configured) and writes the auth response. An empty familyID starts a new session.
+func (h *AuthHandler) respondWithSession(c echo.Context, status int, user *users.User, walletAddress, familyID string) error {
+	response := AuthResponse{
+		UserID:        user.ID,
+		Username:      user.Username,
+		Email:         user.Email,
+		WalletAddress: walletAddress,
+	}
+
+	// Fall back to a single long-lived token when refresh tokens can't be stored
+	if h.tokens == nil {
+		token, err := auth.GenerateJWT(user.ID, walletAddress, h.role(user.ID), h.cfg.JWT.Secret, h.cfg.JWT.Expiration)
+		if err != nil {
+			h.logger.Errorf("Failed to generate JWT: %v", err)
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to generate token")
+		}
+		response.Token = token
+		return c.JSON(status, response)
+	}
+
+	if familyID == "" {
+		familyID = auth.NewFamilyID()
+	}
+
+	refreshToken, err := h.tokens.IssueRefreshToken(c.Request().Context(), auth.RefreshSession{
+		UserID:        user.ID,
+		WalletAddress: walletAddress,
+		FamilyID:      familyID,
+	})
+	if err != nil {
+		h.logger.Errorf("Failed to issue refresh token: %v", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to generate token")
+	}
+
+	ttl := h.accessTokenTTL()
+	token, _, err := auth.GenerateAccessToken(user.ID, walletAddress, h.role(user.ID), familyID, h.cfg.JWT.Secret, ttl)
+	if err != nil {
+		h.logger.Errorf("Failed to generate JWT: %v", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to generate token")
+	}
+
+	response.Token = token
+	response.RefreshToken = refreshToken
+	response.ExpiresIn = int(ttl.Seconds())
+	return c.JSON(status, response)
+}
+
+// role returns the role a user's access tokens carry. It is looked up each time a token
+// is issued, so a change to the admin list applies from the user's next refresh.
+func (h *AuthHandler) role(userID string) string {
+	for _, id := range h.cfg.Admin.UserIDs {
+		if id == userID {
+			return auth.RoleAdmin
+		}
+	}
+	return ""
+}
+
+// accessTokenTTL returns the configured access token lifetime
+func (h *AuthHandler) accessTokenTTL() time.Duration {
+	if h.cfg.JWT.AccessTokenTTL > 0 {
+		return time.Duration(h.cfg.JWT.AccessTokenTTL) * time.Minute
+	}
+	return 15 * time.Minute
+}
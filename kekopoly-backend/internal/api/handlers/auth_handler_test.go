@@ -0,0 +1,379 @@
+package handlers
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gagliardetto/solana-go"
+	"github.com/go-playground/validator/v10"
+	"github.com/go-redis/redis/v8"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/kekopoly/backend/internal/api/middleware/auth"
+	solanaauth "github.com/kekopoly/backend/internal/auth"
+	"github.com/kekopoly/backend/internal/config"
+	"github.com/kekopoly/backend/internal/users"
+)
+
+// testValidator mirrors the API server's request validator
+type testValidator struct {
+	validator *validator.Validate
+}
+
+func (v *testValidator) Validate(i interface{}) error {
+	if err := v.validator.Struct(i); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return nil
+}
+
+func newTestAuthHandler(t *testing.T) (*echo.Echo, *AuthHandler, *users.MemoryStore) {
+	t.Helper()
+
+	e := echo.New()
+	e.Validator = &testValidator{validator: validator.New()}
+
+	cfg := &config.Config{}
+	cfg.JWT.Secret = "test-secret"
+	cfg.JWT.Expiration = 1
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	tokens := auth.NewTokenStore(client, time.Hour)
+	challenges := solanaauth.NewChallengeStore(client, "kekopoly.test", "https://kekopoly.test", "devnet", time.Minute)
+
+	store := users.NewMemoryStore()
+	bans := auth.NewWalletBans(client, store)
+	return e, NewAuthHandler(cfg, store, tokens, challenges, bans, zap.NewNop().Sugar()), store
+}
+
+// walletRequest fetches a challenge for the wallet and returns a signed wallet-connect body
+func walletRequest(t *testing.T, e *echo.Echo, h *AuthHandler, wallet solana.PrivateKey) string {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, "/?wallet="+wallet.PublicKey().String(), nil)
+	rec := httptest.NewRecorder()
+	require.NoError(t, h.Challenge(e.NewContext(req, rec)))
+
+	var challenge solanaauth.Challenge
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &challenge))
+
+	sig, err := wallet.Sign([]byte(challenge.Message))
+	require.NoError(t, err)
+
+	body, err := json.Marshal(WalletConnectRequest{
+		WalletAddress: wallet.PublicKey().String(),
+		Signature:     hex.EncodeToString(sig[:]),
+		Message:       challenge.Message,
+		Format:        "hex",
+	})
+	require.NoError(t, err)
+	return string(body)
+}
+
+// callJSON runs a handler with a JSON body and returns the recorder and handler error
+func callJSON(e *echo.Echo, h echo.HandlerFunc, body string, set map[string]interface{}) (*httptest.ResponseRecorder, error) {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	for k, v := range set {
+		c.Set(k, v)
+	}
+	return rec, h(c)
+}
+
+func httpStatus(err error) int {
+	if he, ok := err.(*echo.HTTPError); ok {
+		return he.Code
+	}
+	return 0
+}
+
+func TestRegisterAndLoginUseStoredIdentity(t *testing.T) {
+	e, h, _ := newTestAuthHandler(t)
+
+	rec, err := callJSON(e, h.Register, `{"email":"Alice@Example.com","username":"alice","password":"hunter2hunter2"}`, nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	var registered AuthResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &registered))
+	assert.Equal(t, "alice@example.com", registered.Email)
+
+	// Logging in twice returns the same user ID
+	for i := 0; i < 2; i++ {
+		rec, err = callJSON(e, h.Login, `{"email":"alice@example.com","password":"hunter2hunter2"}`, nil)
+		require.NoError(t, err)
+
+		var login AuthResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &login))
+		assert.Equal(t, registered.UserID, login.UserID)
+		assert.Equal(t, "alice", login.Username)
+	}
+
+	_, err = callJSON(e, h.Login, `{"email":"alice@example.com","password":"wrong-password"}`, nil)
+	assert.Equal(t, http.StatusUnauthorized, httpStatus(err))
+
+	_, err = callJSON(e, h.Login, `{"email":"nobody@example.com","password":"hunter2hunter2"}`, nil)
+	assert.Equal(t, http.StatusUnauthorized, httpStatus(err))
+}
+
+func TestRegisterRejectsDuplicates(t *testing.T) {
+	e, h, _ := newTestAuthHandler(t)
+
+	_, err := callJSON(e, h.Register, `{"email":"bob@example.com","username":"bob","password":"password123"}`, nil)
+	require.NoError(t, err)
+
+	_, err = callJSON(e, h.Register, `{"email":"BOB@example.com","username":"bobby","password":"password123"}`, nil)
+	assert.Equal(t, http.StatusConflict, httpStatus(err), "email is case-insensitive")
+
+	_, err = callJSON(e, h.Register, `{"email":"other@example.com","username":"Bob","password":"password123"}`, nil)
+	assert.Equal(t, http.StatusConflict, httpStatus(err), "username is case-insensitive")
+}
+
+func TestWalletConnectReturnsPersistentIdentity(t *testing.T) {
+	e, h, _ := newTestAuthHandler(t)
+	wallet := solana.NewWallet().PrivateKey
+
+	rec, err := callJSON(e, h.WalletConnect, walletRequest(t, e, h, wallet), nil)
+	require.NoError(t, err)
+	var first AuthResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &first))
+
+	rec, err = callJSON(e, h.WalletConnect, walletRequest(t, e, h, wallet), nil)
+	require.NoError(t, err)
+	var second AuthResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &second))
+
+	assert.NotEmpty(t, first.UserID)
+	assert.Equal(t, first.UserID, second.UserID)
+}
+
+func TestLinkWalletToExistingAccount(t *testing.T) {
+	e, h, store := newTestAuthHandler(t)
+
+	rec, err := callJSON(e, h.Register, `{"email":"carol@example.com","username":"carol","password":"password123"}`, nil)
+	require.NoError(t, err)
+	var account AuthResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &account))
+
+	wallet := solana.NewWallet().PrivateKey
+	claims, err := auth.ParseToken(account.Token, "test-secret")
+	require.NoError(t, err)
+	rec, err = callJSON(e, h.LinkWallet, walletRequest(t, e, h, wallet), map[string]interface{}{"userID": account.UserID, "claims": claims})
+	require.NoError(t, err)
+	var linked AuthResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &linked))
+	assert.Equal(t, wallet.PublicKey().String(), linked.WalletAddress)
+
+	// The session from before the link, whose tokens carry no wallet, is over
+	_, err = callJSON(e, h.RefreshToken, `{"refreshToken":"`+account.RefreshToken+`"}`, nil)
+	assert.Equal(t, http.StatusUnauthorized, httpStatus(err))
+
+	// Refreshing the new session carries the wallet
+	rec, err = callJSON(e, h.RefreshToken, `{"refreshToken":"`+linked.RefreshToken+`"}`, nil)
+	require.NoError(t, err)
+	var refreshed AuthResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &refreshed))
+	refreshedClaims, err := auth.ParseToken(refreshed.Token, "test-secret")
+	require.NoError(t, err)
+	assert.Equal(t, wallet.PublicKey().String(), refreshedClaims.WalletAddress)
+
+	// Signing in with the wallet now resolves to the registered account
+	rec, err = callJSON(e, h.WalletConnect, walletRequest(t, e, h, wallet), nil)
+	require.NoError(t, err)
+	var walletLogin AuthResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &walletLogin))
+	assert.Equal(t, account.UserID, walletLogin.UserID)
+	assert.Equal(t, "carol", walletLogin.Username)
+
+	// Another account can't claim the same wallet
+	rec, err = callJSON(e, h.Register, `{"email":"dave@example.com","username":"dave","password":"password123"}`, nil)
+	require.NoError(t, err)
+	var other AuthResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &other))
+
+	_, err = callJSON(e, h.LinkWallet, walletRequest(t, e, h, wallet), map[string]interface{}{"userID": other.UserID})
+	assert.Equal(t, http.StatusConflict, httpStatus(err))
+
+	owner, err := store.FindByWallet(context.Background(), wallet.PublicKey().String())
+	require.NoError(t, err)
+	assert.Equal(t, account.UserID, owner.ID)
+}
+
+func TestRefreshRotationAndLogout(t *testing.T) {
+	e, h, _ := newTestAuthHandler(t)
+
+	rec, err := callJSON(e, h.Register, `{"email":"erin@example.com","username":"erin","password":"password123"}`, nil)
+	require.NoError(t, err)
+	var login AuthResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &login))
+	require.NotEmpty(t, login.RefreshToken)
+	assert.Equal(t, 15*60, login.ExpiresIn)
+
+	// Rotate once
+	rec, err = callJSON(e, h.RefreshToken, `{"refreshToken":"`+login.RefreshToken+`"}`, nil)
+	require.NoError(t, err)
+	var refreshed AuthResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &refreshed))
+	assert.Equal(t, login.UserID, refreshed.UserID)
+	assert.NotEqual(t, login.RefreshToken, refreshed.RefreshToken)
+
+	// Logging out revokes the access token and the session's refresh tokens
+	claims, err := auth.ParseToken(refreshed.Token, "test-secret")
+	require.NoError(t, err)
+	rec, err = callJSON(e, h.Logout, "", map[string]interface{}{"claims": claims})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	revoked, err := h.tokens.IsRevoked(context.Background(), claims)
+	require.NoError(t, err)
+	assert.True(t, revoked)
+
+	_, err = callJSON(e, h.RefreshToken, `{"refreshToken":"`+refreshed.RefreshToken+`"}`, nil)
+	assert.Equal(t, http.StatusUnauthorized, httpStatus(err))
+}
+
+func TestRefreshCarriesCurrentWallet(t *testing.T) {
+	e, h, store := newTestAuthHandler(t)
+
+	rec, err := callJSON(e, h.Register, `{"email":"frank@example.com","username":"frank","password":"password123"}`, nil)
+	require.NoError(t, err)
+	var login AuthResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &login))
+
+	// A wallet linked from another session shows up in this one's next token
+	_, err = store.LinkWallet(context.Background(), login.UserID, "wallet-1")
+	require.NoError(t, err)
+
+	rec, err = callJSON(e, h.RefreshToken, `{"refreshToken":"`+login.RefreshToken+`"}`, nil)
+	require.NoError(t, err)
+	var refreshed AuthResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &refreshed))
+	assert.Equal(t, "wallet-1", refreshed.WalletAddress)
+
+	claims, err := auth.ParseToken(refreshed.Token, "test-secret")
+	require.NoError(t, err)
+	assert.Equal(t, "wallet-1", claims.WalletAddress)
+}
+
+func TestBannedWalletIsRefused(t *testing.T) {
+	e, h, store := newTestAuthHandler(t)
+	ctx := context.Background()
+	wallet := solana.NewWallet().PrivateKey
+	address := wallet.PublicKey().String()
+
+	rec, err := callJSON(e, h.WalletConnect, walletRequest(t, e, h, wallet), nil)
+	require.NoError(t, err)
+	var login AuthResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &login))
+
+	rec, err = callJSON(e, h.Register, `{"email":"gina@example.com","username":"gina","password":"password123"}`, nil)
+	require.NoError(t, err)
+	var other AuthResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &other))
+
+	require.NoError(t, h.bans.Ban(ctx, auth.WalletBan{WalletAddress: address, BannedAt: time.Now()}))
+
+	// The wallet can't sign in, refresh its session or be linked to another account
+	_, err = callJSON(e, h.WalletConnect, walletRequest(t, e, h, wallet), nil)
+	assert.Equal(t, http.StatusForbidden, httpStatus(err))
+	_, err = callJSON(e, h.RefreshToken, `{"refreshToken":"`+login.RefreshToken+`"}`, nil)
+	assert.Equal(t, http.StatusForbidden, httpStatus(err))
+	_, err = callJSON(e, h.LinkWallet, walletRequest(t, e, h, wallet), map[string]interface{}{"userID": other.UserID})
+	assert.Equal(t, http.StatusForbidden, httpStatus(err))
+
+	// Nor can an account whose linked wallet is banned log in with its password
+	_, err = store.LinkWallet(ctx, other.UserID, "wallet-2")
+	require.NoError(t, err)
+	require.NoError(t, h.bans.Ban(ctx, auth.WalletBan{WalletAddress: "wallet-2", BannedAt: time.Now()}))
+	_, err = callJSON(e, h.Login, `{"email":"gina@example.com","password":"password123"}`, nil)
+	assert.Equal(t, http.StatusForbidden, httpStatus(err))
+}
+
+func TestJWTMiddlewareRejectsRevokedToken(t *testing.T) {
+	e, h, _ := newTestAuthHandler(t)
+	ctx := context.Background()
+
+	familyID := auth.NewFamilyID()
+	_, err := h.tokens.IssueRefreshToken(ctx, auth.RefreshSession{UserID: "user-1", FamilyID: familyID})
+	require.NoError(t, err)
+
+	token, claims, err := auth.GenerateAccessToken("user-1", "", "", familyID, "test-secret", time.Minute)
+	require.NoError(t, err)
+
+	handler := auth.JWTMiddleware("test-secret", h.tokens)(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+	call := func() error {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		return handler(e.NewContext(req, httptest.NewRecorder()))
+	}
+
+	assert.NoError(t, call())
+
+	require.NoError(t, h.tokens.DenyAccessToken(ctx, claims.ID, claims.ExpiresAt.Time))
+	assert.Equal(t, http.StatusUnauthorized, httpStatus(call()))
+}
+
+func TestWalletConnectRequiresOutstandingChallenge(t *testing.T) {
+	e, h, _ := newTestAuthHandler(t)
+	wallet := solana.NewWallet().PrivateKey
+
+	body := walletRequest(t, e, h, wallet)
+	_, err := callJSON(e, h.WalletConnect, body, nil)
+	require.NoError(t, err)
+
+	// Replaying the same signed challenge fails
+	_, err = callJSON(e, h.WalletConnect, body, nil)
+	assert.Equal(t, http.StatusUnauthorized, httpStatus(err))
+
+	// A self-made message is rejected even with a valid signature
+	message := "Login to Kekopoly with wallet " + wallet.PublicKey().String()
+	sig, err := wallet.Sign([]byte(message))
+	require.NoError(t, err)
+	forged, _ := json.Marshal(WalletConnectRequest{
+		WalletAddress: wallet.PublicKey().String(),
+		Signature:     hex.EncodeToString(sig[:]),
+		Message:       message,
+		Format:        "hex",
+	})
+	_, err = callJSON(e, h.WalletConnect, string(forged), nil)
+	assert.Equal(t, http.StatusUnauthorized, httpStatus(err))
+}
+
+func TestWalletConnectKeepsChallengeOnBadSignature(t *testing.T) {
+	e, h, _ := newTestAuthHandler(t)
+	wallet := solana.NewWallet().PrivateKey
+	other := solana.NewWallet().PrivateKey
+
+	var req WalletConnectRequest
+	require.NoError(t, json.Unmarshal([]byte(walletRequest(t, e, h, wallet)), &req))
+
+	// Signed by the wrong key: rejected, but the challenge stays usable
+	good := req.Signature
+	sig, err := other.Sign([]byte(req.Message))
+	require.NoError(t, err)
+	req.Signature = hex.EncodeToString(sig[:])
+	bad, _ := json.Marshal(req)
+	_, err = callJSON(e, h.WalletConnect, string(bad), nil)
+	assert.Equal(t, http.StatusUnauthorized, httpStatus(err))
+
+	req.Signature = good
+	retry, _ := json.Marshal(req)
+	_, err = callJSON(e, h.WalletConnect, string(retry), nil)
+	assert.NoError(t, err)
+}
@@ -1,17 +1,22 @@
 package handlers
 
 import (
-	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/labstack/echo/v4"
 	"go.uber.org/zap"
 
+	"github.com/kekopoly/backend/internal/game/bots"
 	"github.com/kekopoly/backend/internal/game/manager"
 	"github.com/kekopoly/backend/internal/game/models"
+	"github.com/kekopoly/backend/internal/game/protocol"
 	"github.com/kekopoly/backend/internal/game/websocket"
+	"github.com/kekopoly/backend/internal/settlement"
 )
 
 // GameHandler handles game-related requests
@@ -34,11 +39,41 @@ func NewGameHandler(gameManager *manager.GameManager, wsHub *websocket.Hub, logg
 type CreateGameRequest struct {
 	GameName   string `json:"gameName" validate:"required"`
 	MaxPlayers int    `json:"maxPlayers,omitempty"`
+	BuyIn      int    `json:"buyIn,omitempty" validate:"gte=0"` // Raw KMT units, 0 for the server default
+	Visibility string `json:"visibility,omitempty"`             // PUBLIC (default), UNLISTED or PRIVATE
+	Password   string `json:"password,omitempty"`
+}
+
+// DepositRequest represents a buy-in deposit submission
+type DepositRequest struct {
+	Signature string `json:"signature" validate:"required"`
 }
 
 // JoinGameRequest represents a join game request
 type JoinGameRequest struct {
 	WalletAddress string `json:"walletAddress" validate:"required"`
+	Password      string `json:"password,omitempty"`
+	Invite        string `json:"invite,omitempty"` // Token from the host's invite link
+}
+
+// InviteRequest asks for an invite link to a game
+type InviteRequest struct {
+	TTL int `json:"ttl,omitempty" validate:"gte=0"` // Seconds, 0 for the server default
+}
+
+// PlayerTargetRequest names the player a host control applies to
+type PlayerTargetRequest struct {
+	PlayerID string `json:"playerId" validate:"required"`
+}
+
+// AddBotRequest asks for a bot to fill a seat in the lobby
+type AddBotRequest struct {
+	Strategy string `json:"strategy,omitempty"` // random, greedy or heuristic (the default)
+}
+
+// LockGameRequest locks or unlocks a game to new players
+type LockGameRequest struct {
+	Locked bool `json:"locked"`
 }
 
 // ActionRequest represents a game action request
@@ -66,131 +101,115 @@ func (h *GameHandler) CreateGame(c echo.Context) error {
 	if maxPlayers == 0 {
 		maxPlayers = 6 // Default max players if not specified
 	}
-	gameID, err := h.gameManager.CreateGame(userID, walletAddress, req.GameName, maxPlayers)
+	gameID, err := h.gameManager.CreateGameWithAccess(userID, walletAddress, req.GameName, maxPlayers, req.BuyIn, manager.GameAccess{
+		Visibility: models.GameVisibility(req.Visibility),
+		Password:   req.Password,
+	})
+	if errors.Is(err, manager.ErrInvalidAccess) {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if errors.Is(err, manager.ErrSettlementDisabled) {
+		return echo.NewHTTPError(http.StatusBadRequest, "Buy-ins are not available on this server")
+	}
 	if err != nil {
 		h.logger.Errorf("Failedcto create game: %v", err)
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create game")
 	}
 
-	// Broadcast new game to all connected clients
-	go h.broadcastNewGame(gameID)
-
 	return c.JSON(http.StatusCreated, map[string]string{
 		"gameId": gameID,
 	})
 }
 
-// broadcastNewGame fetches the newly created game and broadcasts it to all connected clients
-func (h *GameHandler) broadcastNewGame(gameID string) {
-	h.logger.Infof("Broadcasting new game %s at %s", gameID, time.Now().Format(time.RFC3339))
-
-	// Get the newly created game
-	game, err := h.gameManager.GetGame(gameID)
+// ListGames lists the games in the lobby. The query parameters openSeats, minBuyIn,
+// maxBuyIn, mode and status filter them as lobby_subscribe does.
+func (h *GameHandler) ListGames(c echo.Context) error {
+	filter, err := lobbyFilter(c)
 	if err != nil {
-		h.logger.Errorf("Failed to get newly created game for broadcast: %v", err)
-		return
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
-	// Transform the game to the response format
-	hostName := ""
-	if len(game.Players) > 0 {
-		hostName = game.Players[0].WalletAddress
-		if len(hostName) > 10 {
-			hostName = hostName[:6] + "..." + hostName[len(hostName)-4:]
-		}
+	games, err := h.wsHub.LobbyGames(filter)
+	if err != nil {
+		h.logger.Errorf("Failed to list games: %v", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to list games")
 	}
 
-	gameResponse := map[string]interface{}{
-		"id":         game.ID.Hex(),
-		"name":       game.Name,
-		"status":     string(game.Status),
-		"players":    len(game.Players),
-		"maxPlayers": game.MaxPlayers, // Use the actual value from the game model
-		"createdAt":  game.CreatedAt.Format(time.RFC3339),
-		"hostName":   hostName,
-		"updatedAt":  time.Now().Format(time.RFC3339), // Add timestamp for tracking
-	}
+	// Return the response in the format expected by the frontend: { games: [...] }
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"games": games,
+	})
+}
 
-	// Create the broadcast message
-	broadcastMsg := map[string]interface{}{
-		"type":      "new_game_created",
-		"game":      gameResponse,
-		"timestamp": time.Now().Format(time.RFC3339),
+// lobbyFilter reads a lobby filter from the query string
+func lobbyFilter(c echo.Context) (protocol.LobbyFilter, error) {
+	filter := protocol.LobbyFilter{
+		Mode:   c.QueryParam("mode"),
+		Status: c.QueryParam("status"),
+	}
+	for _, param := range []struct {
+		name string
+		dest *int
+	}{{"openSeats", &filter.OpenSeats}, {"minBuyIn", &filter.MinBuyIn}} {
+		if raw := c.QueryParam(param.name); raw != "" {
+			value, err := strconv.Atoi(raw)
+			if err != nil {
+				return filter, fmt.Errorf("%s must be a number", param.name)
+			}
+			*param.dest = value
+		}
 	}
-
-	// Marshal to JSON
-	msgBytes, err := json.Marshal(broadcastMsg)
-	if err != nil {
-		h.logger.Errorf("Failed to marshal new game broadcast message: %v", err)
-		return
+	if raw := c.QueryParam("maxBuyIn"); raw != "" {
+		value, err := strconv.Atoi(raw)
+		if err != nil {
+			return filter, fmt.Errorf("maxBuyIn must be a number")
+		}
+		filter.MaxBuyIn = &value
 	}
+	return filter, (&protocol.LobbySubscribeRequest{LobbyFilter: filter}).Validate()
+}
 
-	// Count connected lobby clients
-	h.logger.Infof("Preparing to broadcast new game %s to lobby clients", gameID)
-
-	// Broadcast to all connected clients (using a special "lobby" game ID)
-	h.wsHub.BroadcastToGame("lobby", msgBytes)
-	h.logger.Infof("Broadcasted new game %s to all connected lobby clients", gameID)
-
-	// Also log the raw message for debugging
-	h.logger.Infof("Broadcast message content: %s", string(msgBytes))
+// SpectatableGame is a game listed for spectators
+type SpectatableGame struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	Status        string `json:"status"`
+	Players       int    `json:"players"`
+	CurrentTurn   string `json:"currentTurn,omitempty"`
+	Spectators    int    `json:"spectators"`
+	MaxSpectators int    `json:"maxSpectators"`
 }
 
-// ListGames lists available games
-func (h *GameHandler) ListGames(c echo.Context) error {
-	// Get all available games from the game manager
+// ListSpectatableGames lists the games open to spectators
+func (h *GameHandler) ListSpectatableGames(c echo.Context) error {
+	gamesList := make([]SpectatableGame, 0)
+	limit := h.wsHub.SpectatorLimit()
+	if limit <= 0 {
+		return c.JSON(http.StatusOK, map[string]interface{}{"games": gamesList})
+	}
+
 	games, err := h.gameManager.ListAvailableGames()
 	if err != nil {
-		h.logger.Errorf("Failed to list games: %v", err)
+		h.logger.Errorf("Failed to list games for spectators: %v", err)
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to list games")
 	}
 
-	// Transform the game model to a simplified response format
-	type GameResponse struct {
-		ID         string `json:"id"`
-		Name       string `json:"name"`
-		Status     string `json:"status"`
-		Players    int    `json:"players"`
-		MaxPlayers int    `json:"maxPlayers"`
-		CreatedAt  string `json:"createdAt"`
-		HostName   string `json:"hostName,omitempty"`
-	}
-
-	gamesList := make([]GameResponse, 0, len(games))
 	for _, game := range games {
-		hostName := ""
-		if len(game.Players) > 0 {
-			// Assume the first player is the host
-			hostName = game.Players[0].WalletAddress
-			// Truncate the wallet address for display
-			if len(hostName) > 10 {
-				hostName = hostName[:6] + "..." + hostName[len(hostName)-4:]
-			}
-		}
-
-		// Count only active players for the lobby display
-		activePlayerCount := 0
-		h.logger.Debugf("Checking players for game %s (%s):", game.ID.Hex(), game.Name)
-		for _, player := range game.Players {
-			h.logger.Debugf("  - Player ID: %s, Status: %s", player.ID, player.Status)
-			if player.Status == models.PlayerStatusActive {
-				activePlayerCount++
-			}
+		if !websocket.Spectatable(game) {
+			continue
 		}
-		h.logger.Debugf("Active player count for game %s: %d", game.ID.Hex(), activePlayerCount)
-
-		gamesList = append(gamesList, GameResponse{
-			ID:         game.ID.Hex(),
-			Name:       game.Name, // Assuming there's a Name field in the game model
-			Status:     string(game.Status),
-			Players:    activePlayerCount, // Use the count of active players
-			MaxPlayers: game.MaxPlayers,   // Use the actual value from the game model
-			CreatedAt:  game.CreatedAt.Format(time.RFC3339),
-			HostName:   hostName,
+		gameID := game.ID.Hex()
+		gamesList = append(gamesList, SpectatableGame{
+			ID:            gameID,
+			Name:          game.Name,
+			Status:        string(game.Status),
+			Players:       len(game.Players),
+			CurrentTurn:   game.CurrentTurn,
+			Spectators:    h.wsHub.SpectatorCount(gameID),
+			MaxSpectators: limit,
 		})
 	}
 
-	// Return the response in the format expected by the frontend: { games: [...] }
 	return c.JSON(http.StatusOK, map[string]interface{}{
 		"games": gamesList,
 	})
@@ -237,7 +256,13 @@ func (h *GameHandler) JoinGame(c echo.Context) error {
 	userID := c.Get("userID").(string)
 
 	// Join game
-	sessionID, err := h.gameManager.JoinGame(gameID, userID, req.WalletAddress)
+	sessionID, err := h.gameManager.JoinGameWithOptions(gameID, userID, req.WalletAddress, manager.JoinOptions{
+		Password: req.Password,
+		Invite:   req.Invite,
+	})
+	if status := accessStatus(err); status != 0 {
+		return echo.NewHTTPError(status, err.Error())
+	}
 	if err != nil {
 		h.logger.Errorf("Failed to join game: %v", err)
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to join game")
@@ -285,6 +310,9 @@ func (h *GameHandler) StartGame(c echo.Context) error {
 		if err.Error() == "only the host can start the game" {
 			return echo.NewHTTPError(http.StatusForbidden, "Only the host can start the game")
 		}
+		if errors.Is(err, manager.ErrDepositsPending) {
+			return echo.NewHTTPError(http.StatusConflict, "Waiting for players to pay the buy-in")
+		}
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to start game")
 	}
 
@@ -292,18 +320,248 @@ func (h *GameHandler) StartGame(c echo.Context) error {
 	return c.NoContent(http.StatusNoContent)
 }
 
-// PauseGame pauses a game
-func (h *GameHandler) PauseGame(c echo.Context) error {
-	gameID := c.Param("gameId")
+// accessStatus maps the game manager's access and host control errors to an HTTP
+// status, or returns 0 for any other error
+func accessStatus(err error) int {
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, manager.ErrWrongPassword), errors.Is(err, manager.ErrInvalidInvite):
+		return http.StatusUnauthorized
+	case errors.Is(err, manager.ErrNotHost), errors.Is(err, manager.ErrInviteRequired),
+		errors.Is(err, manager.ErrGameLocked), errors.Is(err, manager.ErrBanned):
+		return http.StatusForbidden
+	case errors.Is(err, manager.ErrPlayerNotInGame), errors.Is(err, manager.ErrGameNotFound):
+		return http.StatusNotFound
+	}
+	return 0
+}
+
+// CreateInvite returns an invite link token for the game. Only the host can invite.
+func (h *GameHandler) CreateInvite(c echo.Context) error {
+	gameID := strings.ToLower(c.Param("gameId"))
 	if gameID == "" {
 		return echo.NewHTTPError(http.StatusBadRequest, "Missing game ID")
 	}
 
-	// In a real implementation, we would pause the game
-	// For this simplified implementation, we'll just return success
+	var req InviteRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := c.Validate(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	userID := c.Get("userID").(string)
+	invite, expires, err := h.gameManager.CreateInvite(gameID, userID, time.Duration(req.TTL)*time.Second)
+	if status := accessStatus(err); status != 0 {
+		return echo.NewHTTPError(status, err.Error())
+	}
+	if err != nil {
+		h.logger.Errorf("Failed to create invite for game %s: %v", gameID, err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create invite")
+	}
+
+	return c.JSON(http.StatusCreated, map[string]string{
+		"invite":    invite,
+		"expiresAt": expires.Format(time.RFC3339),
+	})
+}
+
+// KickPlayer removes a player from the game's lobby. They can join again.
+func (h *GameHandler) KickPlayer(c echo.Context) error {
+	return h.hostControl(c, "kick player", func(gameID, hostID, playerID string) error {
+		return h.gameManager.KickPlayer(gameID, hostID, playerID)
+	})
+}
+
+// BanPlayer removes a player from the game's lobby and keeps them out
+func (h *GameHandler) BanPlayer(c echo.Context) error {
+	return h.hostControl(c, "ban player", func(gameID, hostID, playerID string) error {
+		return h.gameManager.BanPlayer(gameID, hostID, playerID)
+	})
+}
+
+// TransferHost makes another player the game's host
+func (h *GameHandler) TransferHost(c echo.Context) error {
+	return h.hostControl(c, "transfer host", func(gameID, hostID, playerID string) error {
+		return h.gameManager.TransferHost(gameID, hostID, playerID)
+	})
+}
+
+// hostControl runs a host control that names another player
+func (h *GameHandler) hostControl(c echo.Context, what string, control func(gameID, hostID, playerID string) error) error {
+	gameID := strings.ToLower(c.Param("gameId"))
+	if gameID == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Missing game ID")
+	}
+
+	var req PlayerTargetRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := c.Validate(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	userID := c.Get("userID").(string)
+	err := control(gameID, userID, req.PlayerID)
+	if status := accessStatus(err); status != 0 {
+		return echo.NewHTTPError(status, err.Error())
+	}
+	if err != nil {
+		h.logger.Errorf("Failed to %s in game %s: %v", what, gameID, err)
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	}
 	return c.NoContent(http.StatusNoContent)
 }
 
+// LockGame locks or unlocks the game to new players
+func (h *GameHandler) LockGame(c echo.Context) error {
+	gameID := strings.ToLower(c.Param("gameId"))
+	if gameID == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Missing game ID")
+	}
+
+	var req LockGameRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+
+	userID := c.Get("userID").(string)
+	err := h.gameManager.LockGame(gameID, userID, req.Locked)
+	if status := accessStatus(err); status != 0 {
+		return echo.NewHTTPError(status, err.Error())
+	}
+	if err != nil {
+		h.logger.Errorf("Failed to lock game %s: %v", gameID, err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to lock game")
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// AddBot seats a bot in the game's lobby. Only the host can add bots.
+func (h *GameHandler) AddBot(c echo.Context) error {
+	gameID := strings.ToLower(c.Param("gameId"))
+	if gameID == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Missing game ID")
+	}
+
+	var req AddBotRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if req.Strategy == "" {
+		req.Strategy = bots.StrategyHeuristic
+	}
+
+	userID := c.Get("userID").(string)
+	player, err := h.gameManager.AddBot(gameID, userID, req.Strategy)
+	if errors.Is(err, bots.ErrUnknownStrategy) || errors.Is(err, manager.ErrBotBuyIn) {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if status := accessStatus(err); status != 0 {
+		return echo.NewHTTPError(status, err.Error())
+	}
+	if err != nil {
+		h.logger.Errorf("Failed to add bot to game %s: %v", gameID, err)
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	}
+	return c.JSON(http.StatusCreated, player)
+}
+
+// SubmitDeposit verifies the caller's buy-in transaction for a lobby game
+func (h *GameHandler) SubmitDeposit(c echo.Context) error {
+	gameID := c.Param("gameId")
+	if gameID == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Missing game ID")
+	}
+
+	var req DepositRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := c.Validate(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	userID := c.Get("userID").(string)
+
+	player, err := h.gameManager.SubmitDeposit(gameID, userID, req.Signature)
+	if err != nil {
+		h.logger.Warnf("Deposit from %s for game %s rejected: %v", userID, gameID, err)
+		switch {
+		case errors.Is(err, manager.ErrSettlementDisabled), errors.Is(err, manager.ErrNoBuyIn):
+			return echo.NewHTTPError(http.StatusBadRequest, "This game has no buy-in")
+		case errors.Is(err, manager.ErrDepositAlreadyVerified), errors.Is(err, manager.ErrDepositAlreadySubmitted):
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		case errors.Is(err, settlement.ErrDepositNotFound):
+			return echo.NewHTTPError(http.StatusUnprocessableEntity, "Deposit transaction not found or not finalized yet")
+		case errors.Is(err, settlement.ErrDepositMismatch):
+			return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to verify deposit")
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"playerId":        player.ID,
+		"depositStatus":   player.DepositStatus,
+		"depositedAmount": player.DepositedAmount,
+		"buyIn":           player.InitialDeposit,
+	})
+}
+
+// PauseGame pauses an active game. The host pauses it straight away; other players
+// vote, and it pauses once most of them have.
+func (h *GameHandler) PauseGame(c echo.Context) error {
+	gameID := strings.ToLower(c.Param("gameId"))
+	if gameID == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Missing game ID")
+	}
+
+	userID := c.Get("userID").(string)
+	paused, err := h.gameManager.PauseGame(gameID, userID)
+	if status := pauseStatus(err); status != 0 {
+		return echo.NewHTTPError(status, err.Error())
+	}
+	if err != nil {
+		h.logger.Errorf("Failed to pause game %s: %v", gameID, err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to pause game")
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"paused": paused})
+}
+
+// ResumeGame resumes a paused game, straight away for the host and by vote for others
+func (h *GameHandler) ResumeGame(c echo.Context) error {
+	gameID := strings.ToLower(c.Param("gameId"))
+	if gameID == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Missing game ID")
+	}
+
+	userID := c.Get("userID").(string)
+	resumed, err := h.gameManager.ResumeGame(gameID, userID)
+	if status := pauseStatus(err); status != 0 {
+		return echo.NewHTTPError(status, err.Error())
+	}
+	if err != nil {
+		h.logger.Errorf("Failed to resume game %s: %v", gameID, err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to resume game")
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"resumed": resumed})
+}
+
+// pauseStatus maps pause and resume errors to an HTTP status, or 0 for other errors
+func pauseStatus(err error) int {
+	switch {
+	case errors.Is(err, manager.ErrCannotVote):
+		return http.StatusForbidden
+	case errors.Is(err, manager.ErrGamePaused), errors.Is(err, manager.ErrGameNotActive),
+		errors.Is(err, manager.ErrGameNotPaused):
+		return http.StatusConflict
+	}
+	return accessStatus(err)
+}
+
 // GetGameState gets the current state of a game
 func (h *GameHandler) GetGameState(c echo.Context) error {
 	gameID := c.Param("gameId")
@@ -386,59 +644,6 @@ func (h *GameHandler) SpecialAction(c echo.Context) error {
 	return h.handleGameAction(c, models.ActionTypeSpecial)
 }
 
-// CleanupStaleGames removes stale/duplicate game records from the database
-func (h *GameHandler) CleanupStaleGames(c echo.Context) error {
-	logger := c.Get("logger").(*zap.SugaredLogger)
-	logger.Info("Starting cleanup of stale game rooms")
-
-	// Get stale games (games older than 24 hours or with duplicate IDs)
-	staleGames, err := h.gameManager.CleanupStaleGames()
-	if err != nil {
-		logger.Errorf("Error cleaning up stale games: %v", err)
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to clean up stale games")
-	}
-
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"message":      "Stale games cleanup completed",
-		"gamesRemoved": len(staleGames),
-		"games":        staleGames,
-	})
-}
-
-// ResetGame resets an abandoned game back to LOBBY status
-func (h *GameHandler) ResetGame(c echo.Context) error {
-	gameID := c.Param("gameId")
-	if gameID == "" {
-		return echo.NewHTTPError(http.StatusBadRequest, "Missing game ID")
-	}
-
-	// Get user ID from context (set by JWT middleware)
-	userID := c.Get("userID").(string)
-
-	// Get the game to check its status
-	game, err := h.gameManager.GetGame(gameID)
-	if err != nil {
-		h.logger.Errorf("Failed to get game for reset: %v", err)
-		return echo.NewHTTPError(http.StatusNotFound, "Game not found")
-	}
-
-	// Only allow resetting abandoned games
-	if game.Status != models.GameStatusAbandoned {
-		return echo.NewHTTPError(http.StatusBadRequest, "Only abandoned games can be reset")
-	}
-
-	// Reset the game status to LOBBY
-	err = h.gameManager.ResetGameStatus(gameID, userID)
-	if err != nil {
-		h.logger.Errorf("Failed to reset game: %v", err)
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to reset game")
-	}
-
-	return c.JSON(http.StatusOK, map[string]string{
-		"message": "Game has been reset to LOBBY status",
-	})
-}
-
 // SyncGameState forces a complete game state sync to all clients
 func (h *GameHandler) SyncGameState(c echo.Context) error {
 	gameID := c.Param("gameId")
@@ -497,6 +702,12 @@ func (h *GameHandler) handleGameAction(c echo.Context, actionType models.ActionT
 
 	// Process action
 	err := h.gameManager.ProcessGameAction(action)
+	if errors.Is(err, manager.ErrGamePaused) {
+		return echo.NewHTTPError(http.StatusConflict, "The game is paused")
+	}
+	if errors.Is(err, manager.ErrMemeNotFound) {
+		return echo.NewHTTPError(http.StatusBadRequest, "The meme must be one of your chat messages")
+	}
 	if err != nil {
 		h.logger.Errorf("Failed to process action: %v", err)
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to process action")
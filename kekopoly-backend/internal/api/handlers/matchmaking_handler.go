@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+	"github.com/kekopoly/backend/internal/matchmaking"
+)
+
+// MatchmakingHandler handles the quick-match queue
+type MatchmakingHandler struct {
+	matchmaker *matchmaking.Matchmaker
+	logger     *zap.SugaredLogger
+}
+
+// NewMatchmakingHandler creates a new MatchmakingHandler
+func NewMatchmakingHandler(matchmaker *matchmaking.Matchmaker, logger *zap.SugaredLogger) *MatchmakingHandler {
+	return &MatchmakingHandler{
+		matchmaker: matchmaker,
+		logger:     logger,
+	}
+}
+
+// EnqueueRequest represents a quick-match request
+type EnqueueRequest struct {
+	Players int    `json:"players" validate:"required"`
+	BuyIn   int    `json:"buyIn,omitempty" validate:"gte=0"` // Raw KMT units, 0 for the server default
+	Mode    string `json:"mode,omitempty"`
+}
+
+// Enqueue puts the user in the quick-match queue. The match arrives as a match_found
+// message on the lobby socket.
+func (h *MatchmakingHandler) Enqueue(c echo.Context) error {
+	if h.matchmaker == nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "Matchmaking is unavailable")
+	}
+
+	var req EnqueueRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := c.Validate(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	// Get user ID from context (set by JWT middleware)
+	userID := c.Get("userID").(string)
+	walletAddress := c.Get("walletAddress").(string)
+
+	status, err := h.matchmaker.Enqueue(c.Request().Context(), userID, walletAddress, matchmaking.Preferences{
+		Players: req.Players,
+		BuyIn:   req.BuyIn,
+		Mode:    req.Mode,
+	})
+	if errors.Is(err, matchmaking.ErrInvalidPreferences) {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err != nil {
+		h.logger.Errorf("Failed to queue user %s for matchmaking: %v", userID, err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to join the queue")
+	}
+	return c.JSON(http.StatusAccepted, status)
+}
+
+// GetStatus reports the user's place in the quick-match queue
+func (h *MatchmakingHandler) GetStatus(c echo.Context) error {
+	if h.matchmaker == nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "Matchmaking is unavailable")
+	}
+
+	userID := c.Get("userID").(string)
+	status, err := h.matchmaker.Status(c.Request().Context(), userID)
+	if errors.Is(err, matchmaking.ErrNotQueued) {
+		return echo.NewHTTPError(http.StatusNotFound, "Not in the queue")
+	}
+	if err != nil {
+		h.logger.Errorf("Failed to read matchmaking status for user %s: %v", userID, err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to read the queue")
+	}
+	return c.JSON(http.StatusOK, status)
+}
+
+// Dequeue takes the user out of the quick-match queue
+func (h *MatchmakingHandler) Dequeue(c echo.Context) error {
+	if h.matchmaker == nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "Matchmaking is unavailable")
+	}
+
+	userID := c.Get("userID").(string)
+	err := h.matchmaker.Dequeue(c.Request().Context(), userID)
+	if errors.Is(err, matchmaking.ErrNotQueued) {
+		return echo.NewHTTPError(http.StatusNotFound, "Not in the queue")
+	}
+	if err != nil {
+		h.logger.Errorf("Failed to remove user %s from matchmaking: %v", userID, err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to leave the queue")
+	}
+	return c.NoContent(http.StatusNoContent)
+}
@@ -1,20 +1,25 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/labstack/echo/v4"
 	"go.uber.org/zap"
+
+	"github.com/kekopoly/backend/internal/users"
 )
 
 // UserHandler handles user-related requests
 type UserHandler struct {
+	users  users.Store
 	logger *zap.SugaredLogger
 }
 
 // NewUserHandler creates a new UserHandler
-func NewUserHandler(logger *zap.SugaredLogger) *UserHandler {
+func NewUserHandler(userStore users.Store, logger *zap.SugaredLogger) *UserHandler {
 	return &UserHandler{
+		users:  userStore,
 		logger: logger,
 	}
 }
@@ -22,8 +27,8 @@ func NewUserHandler(logger *zap.SugaredLogger) *UserHandler {
 // UserProfileResponse represents a user profile response
 type UserProfileResponse struct {
 	UserID        string `json:"userId"`
-	Username      string `json:"username"`
-	Email         string `json:"email"`
+	Username      string `json:"username,omitempty"`
+	Email         string `json:"email,omitempty"`
 	WalletAddress string `json:"walletAddress,omitempty"`
 	AvatarURL     string `json:"avatarUrl,omitempty"`
 }
@@ -48,14 +53,20 @@ func (h *UserHandler) GetProfile(c echo.Context) error {
 	// Get user ID from context (set by JWT middleware)
 	userID := c.Get("userID").(string)
 
-	// In a real implementation, we would fetch user profile from database
-	// For this simplified implementation, we'll just return mock data
-	return c.JSON(http.StatusOK, UserProfileResponse{
-		UserID:        userID,
-		Username:      "player_" + userID[:6],
-		Email:         "user@example.com",
-		WalletAddress: c.Get("walletAddress").(string),
-	})
+	if h.users == nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "User accounts are unavailable")
+	}
+
+	user, err := h.users.FindByID(c.Request().Context(), userID)
+	if err != nil {
+		if errors.Is(err, users.ErrUserNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "User not found")
+		}
+		h.logger.Errorf("Failed to load profile for user %s: %v", userID, err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load profile")
+	}
+
+	return c.JSON(http.StatusOK, profileResponse(user))
 }
 
 // UpdateProfile updates the user's profile
@@ -72,11 +83,45 @@ func (h *UserHandler) UpdateProfile(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
-	// In a real implementation, we would update user profile in database
-	// For this simplified implementation, we'll just return success
+	if h.users == nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "User accounts are unavailable")
+	}
+
+	var update users.ProfileUpdate
+	if req.Username != "" {
+		update.Username = &req.Username
+	}
+	if req.AvatarURL != "" {
+		update.AvatarURL = &req.AvatarURL
+	}
+
+	user, err := h.users.UpdateProfile(c.Request().Context(), userID, update)
+	if err != nil {
+		switch {
+		case errors.Is(err, users.ErrUserNotFound):
+			return echo.NewHTTPError(http.StatusNotFound, "User not found")
+		case errors.Is(err, users.ErrUsernameTaken):
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		default:
+			h.logger.Errorf("Failed to update profile for user %s: %v", userID, err)
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to update profile")
+		}
+	}
+
 	h.logger.Infof("User %s updated profile", userID)
 
-	return c.NoContent(http.StatusNoContent)
+	return c.JSON(http.StatusOK, profileResponse(user))
+}
+
+// profileResponse converts a stored user into its API representation
+func profileResponse(user *users.User) UserProfileResponse {
+	return UserProfileResponse{
+		UserID:        user.ID,
+		Username:      user.Username,
+		Email:         user.Email,
+		WalletAddress: user.WalletAddress,
+		AvatarURL:     user.AvatarURL,
+	}
 }
 
 // GetWallet gets the user's wallet information
@@ -1,12 +1,13 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
 	"time"
 
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/gorilla/websocket"
 	"github.com/labstack/echo/v4"
 	"go.uber.org/zap"
@@ -21,14 +22,17 @@ type WebSocketHandler struct {
 	hub    *gameWs.Hub
 	logger *zap.SugaredLogger
 	cfg    *config.Config // Added config field
+	// denylist rejects tokens revoked by logout; nil disables the check
+	denylist auth.Denylist
 }
 
 // NewWebSocketHandler creates a new WebSocketHandler
-func NewWebSocketHandler(hub *gameWs.Hub, logger *zap.SugaredLogger, cfg *config.Config) *WebSocketHandler { // Added cfg parameter
+func NewWebSocketHandler(hub *gameWs.Hub, logger *zap.SugaredLogger, cfg *config.Config, denylist auth.Denylist) *WebSocketHandler { // Added cfg parameter
 	return &WebSocketHandler{
-		hub:    hub,
-		logger: logger,
-		cfg:    cfg, // Store config
+		hub:      hub,
+		logger:   logger,
+		cfg:      cfg, // Store config
+		denylist: denylist,
 	}
 }
 
@@ -69,24 +73,19 @@ func (h *WebSocketHandler) validateToken(tokenString string) (*auth.Claims, erro
 		return nil, fmt.Errorf("JWT secret not configured")
 	}
 
-	token, err := jwt.ParseWithClaims(tokenString, &auth.Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(h.cfg.JWT.Secret), nil
-	})
-
+	claims, err := auth.ParseToken(tokenString, h.cfg.JWT.Secret)
 	if err != nil {
-		return nil, fmt.Errorf("token parsing failed: %w", err)
-	}
-
-	if !token.Valid {
-		return nil, fmt.Errorf("invalid token")
+		return nil, err
 	}
 
-	claims, ok := token.Claims.(*auth.Claims)
-	if !ok {
-		return nil, fmt.Errorf("failed to extract claims")
+	if h.denylist != nil {
+		revoked, err := h.denylist.IsRevoked(context.Background(), claims)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check token status: %w", err)
+		}
+		if revoked {
+			return nil, fmt.Errorf("token has been revoked")
+		}
 	}
 
 	return claims, nil
@@ -137,6 +136,15 @@ func (h *WebSocketHandler) HandleConnection(c echo.Context) error {
 	}
 	h.logger.Infof("SessionID: %s", sessionID)
 
+	// Only the game's players may connect here; kicked and banned players stay out
+	if err := h.hub.CanPlay(gameID, userID); err != nil {
+		h.logger.Infof("Connection to game %s from %s rejected: %v", gameID, userID, err)
+		if errors.Is(err, gameWs.ErrGameNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "Game not found")
+		}
+		return echo.NewHTTPError(http.StatusForbidden, "Not a player in this game")
+	}
+
 	// Log complete connection parameters
 	h.logger.Infof("Attempting to upgrade connection - GameID: %s (lowercase), PlayerID: %s, SessionID: %s",
 		gameID, userID, sessionID)
@@ -155,7 +163,10 @@ func (h *WebSocketHandler) HandleConnection(c echo.Context) error {
 	h.logger.Infof("Connection successfully upgraded to WebSocket")
 
 	// Handle WebSocket connection
-	h.hub.HandleWebSocketConnection(conn, gameID, userID, sessionID)
+	if err := h.hub.HandleWebSocketConnection(conn, gameID, userID, sessionID); err != nil {
+		h.logger.Infof("Connection to game %s closed: %v", gameID, err)
+		return nil
+	}
 	h.logger.Infof("WebSocket connection handed to hub")
 
 	return nil
@@ -213,9 +224,65 @@ func (h *WebSocketHandler) HandleLobbyConnection(c echo.Context) error {
 
 	h.logger.Infof("Lobby connection successfully upgraded to WebSocket")
 
-	// Handle WebSocket connection with "lobby" as the gameID
-	h.hub.HandleWebSocketConnection(conn, "lobby", userID, sessionID)
+	h.hub.HandleLobbyConnection(conn, userID, sessionID)
 	h.logger.Infof("Lobby WebSocket connection handed to hub")
 
 	return nil
 }
+
+// HandleSpectatorConnection handles read-only WebSocket connections watching a game
+func (h *WebSocketHandler) HandleSpectatorConnection(c echo.Context) error {
+	gameID := strings.ToLower(c.Param("gameId"))
+	if gameID == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Missing game ID")
+	}
+
+	var userID string
+	if id, ok := c.Get("userID").(string); ok && id != "" {
+		userID = id
+	} else {
+		tokenString := c.QueryParam("token")
+		if tokenString == "" {
+			h.logger.Warn("Spectator connection rejected: Missing token in query parameter and no UserID in context")
+			return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized: Missing token")
+		}
+		claims, err := h.validateToken(tokenString)
+		if err != nil {
+			h.logger.Warnf("Spectator connection rejected: Token validation failed: %v", err)
+			return echo.NewHTTPError(http.StatusUnauthorized, fmt.Sprintf("Unauthorized: Invalid token (%v)", err))
+		}
+		userID = claims.UserID
+	}
+
+	sessionID := c.QueryParam("sessionId")
+	if sessionID == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Missing session ID")
+	}
+
+	// Turn away spectators before upgrading while the reason can still be an HTTP status
+	if err := h.hub.CanSpectate(gameID, userID); err != nil {
+		h.logger.Infof("Spectator connection to game %s from %s rejected: %v", gameID, userID, err)
+		switch {
+		case errors.Is(err, gameWs.ErrSpectatingDisabled):
+			return echo.NewHTTPError(http.StatusForbidden, "Spectating is disabled")
+		case errors.Is(err, gameWs.ErrBannedFromGame):
+			return echo.NewHTTPError(http.StatusForbidden, "Banned from this game")
+		case errors.Is(err, gameWs.ErrSpectatorsFull):
+			return echo.NewHTTPError(http.StatusConflict, "Game has no room for more spectators")
+		default:
+			return echo.NewHTTPError(http.StatusNotFound, "Game not found or not open to spectators")
+		}
+	}
+
+	conn, err := upgrader.Upgrade(c.Response(), c.Request(), nil)
+	if err != nil {
+		h.logger.Errorf("Failed to upgrade spectator connection: %v", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to establish WebSocket connection")
+	}
+
+	// The hub checks again, since other spectators may have joined meanwhile
+	if err := h.hub.HandleSpectatorConnection(conn, gameID, userID, sessionID); err != nil {
+		h.logger.Infof("Spectator connection to game %s closed: %v", gameID, err)
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RequireAdmin only lets through tokens with the admin role. It runs after
+// JWTMiddleware, which sets the caller's claims.
+func RequireAdmin() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			claims, ok := c.Get("claims").(*Claims)
+			if !ok || claims.Role != RoleAdmin {
+				return echo.NewHTTPError(http.StatusForbidden, "admin access required")
+			}
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireAdmin(t *testing.T) {
+	handler := RequireAdmin()(func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+
+	for name, tc := range map[string]struct {
+		claims *Claims
+		want   int
+	}{
+		"admin":     {&Claims{UserID: "operator", Role: RoleAdmin}, http.StatusNoContent},
+		"player":    {&Claims{UserID: "player"}, http.StatusForbidden},
+		"no claims": {nil, http.StatusForbidden},
+	} {
+		c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/api/v1/admin", nil), httptest.NewRecorder())
+		if tc.claims != nil {
+			c.Set("claims", tc.claims)
+		}
+		err := handler(c)
+		if tc.want == http.StatusNoContent {
+			assert.NoError(t, err, name)
+			assert.Equal(t, tc.want, c.Response().Status, name)
+			continue
+		}
+		var httpErr *echo.HTTPError
+		if assert.ErrorAs(t, err, &httpErr, name) {
+			assert.Equal(t, tc.want, httpErr.Code, name)
+		}
+	}
+}
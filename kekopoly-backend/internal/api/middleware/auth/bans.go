@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/kekopoly/backend/internal/users"
+)
+
+// WalletBan is a wallet an operator banned
+type WalletBan struct {
+	WalletAddress string    `json:"walletAddress"`
+	Reason        string    `json:"reason"`
+	BannedBy      string    `json:"bannedBy"` // The operator's user ID
+	BannedAt      time.Time `json:"bannedAt"`
+}
+
+// WalletBans keeps banned wallets in Redis. It is a Denylist, so tokens for a banned
+// wallet stop working as soon as it is banned, on every node. With a user store it
+// also checks the wallet linked to the token's account, which may not be in the token.
+type WalletBans struct {
+	client *redis.Client
+	users  users.Store // Nil to check only the wallet in the token
+}
+
+// NewWalletBans creates a Redis-backed wallet ban list
+func NewWalletBans(client *redis.Client, userStore users.Store) *WalletBans {
+	return &WalletBans{client: client, users: userStore}
+}
+
+func bannedWalletKey(walletAddress string) string { return "auth:banned_wallet:" + walletAddress }
+
+// Ban bans a wallet until it is unbanned, replacing any earlier ban
+func (b *WalletBans) Ban(ctx context.Context, ban WalletBan) error {
+	data, err := json.Marshal(ban)
+	if err != nil {
+		return fmt.Errorf("failed to encode ban: %w", err)
+	}
+	if err := b.client.Set(ctx, bannedWalletKey(ban.WalletAddress), data, 0).Err(); err != nil {
+		return fmt.Errorf("failed to store ban: %w", err)
+	}
+	return nil
+}
+
+// Unban lifts a wallet's ban and reports whether it was banned
+func (b *WalletBans) Unban(ctx context.Context, walletAddress string) (bool, error) {
+	deleted, err := b.client.Del(ctx, bannedWalletKey(walletAddress)).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to lift ban: %w", err)
+	}
+	return deleted > 0, nil
+}
+
+// Get returns a wallet's ban, or nil if it isn't banned
+func (b *WalletBans) Get(ctx context.Context, walletAddress string) (*WalletBan, error) {
+	data, err := b.client.Get(ctx, bannedWalletKey(walletAddress)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load ban: %w", err)
+	}
+	var ban WalletBan
+	if err := json.Unmarshal(data, &ban); err != nil {
+		return nil, fmt.Errorf("failed to decode ban: %w", err)
+	}
+	return &ban, nil
+}
+
+// IsBanned reports whether a wallet is banned
+func (b *WalletBans) IsBanned(ctx context.Context, walletAddress string) (bool, error) {
+	if walletAddress == "" {
+		return false, nil
+	}
+	banned, err := b.client.Exists(ctx, bannedWalletKey(walletAddress)).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to check wallet ban: %w", err)
+	}
+	return banned > 0, nil
+}
+
+// IsRevoked reports whether the token's wallet, or the wallet now linked to its
+// account, is banned
+func (b *WalletBans) IsRevoked(ctx context.Context, claims *Claims) (bool, error) {
+	banned, err := b.IsBanned(ctx, claims.WalletAddress)
+	if err != nil || banned || b.users == nil || claims.UserID == "" {
+		return banned, err
+	}
+	user, err := b.users.FindByID(ctx, claims.UserID)
+	if errors.Is(err, users.ErrUserNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to look up user's wallet: %w", err)
+	}
+	if user.WalletAddress == claims.WalletAddress {
+		return false, nil
+	}
+	return b.IsBanned(ctx, user.WalletAddress)
+}
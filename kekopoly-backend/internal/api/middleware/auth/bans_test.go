@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kekopoly/backend/internal/users"
+)
+
+func TestWalletBans(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	store := users.NewMemoryStore()
+	bans := NewWalletBans(client, store)
+	ctx := context.Background()
+
+	require.NoError(t, bans.Ban(ctx, WalletBan{WalletAddress: "w1", Reason: "chargebacks", BannedBy: "operator", BannedAt: time.Now()}))
+	ban, err := bans.Get(ctx, "w1")
+	require.NoError(t, err)
+	require.NotNil(t, ban)
+	assert.Equal(t, "chargebacks", ban.Reason)
+
+	// Tokens for the wallet stop working, along with any other list's revocations
+	lists := Denylists{NewTokenStore(client, time.Hour), bans}
+	for wallet, want := range map[string]bool{"w1": true, "w2": false, "": false} {
+		revoked, err := lists.IsRevoked(ctx, &Claims{UserID: "u1", WalletAddress: wallet})
+		require.NoError(t, err)
+		assert.Equal(t, want, revoked, wallet)
+	}
+
+	// A wallet linked to the account since the token was issued is checked too
+	require.NoError(t, store.Create(ctx, &users.User{ID: "u2", WalletAddress: "w1"}))
+	revoked, err := bans.IsRevoked(ctx, &Claims{UserID: "u2"})
+	require.NoError(t, err)
+	assert.True(t, revoked)
+
+	unbanned, err := bans.Unban(ctx, "w1")
+	require.NoError(t, err)
+	assert.True(t, unbanned)
+	revoked, err = bans.IsRevoked(ctx, &Claims{UserID: "u2"})
+	require.NoError(t, err)
+	assert.False(t, revoked)
+	ban, err = bans.Get(ctx, "w1")
+	require.NoError(t, err)
+	assert.Nil(t, ban)
+}
@@ -7,18 +7,25 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 )
 
+// RoleAdmin is the role of operators allowed to use the admin API
+const RoleAdmin = "admin"
+
 // Claims represents the JWT claims
 type Claims struct {
 	UserID        string `json:"userId"`
 	WalletAddress string `json:"walletAddress,omitempty"`
+	Role          string `json:"role,omitempty"` // Empty for players
+	SessionID     string `json:"sid,omitempty"`  // Refresh token family the access token belongs to
 	jwt.RegisteredClaims
 }
 
 // JWTMiddleware creates a JWT middleware for authentication
-func JWTMiddleware(secret string) echo.MiddlewareFunc {
+// Uses variadic denylist parameter for backward compatibility
+func JWTMiddleware(secret string, denylist ...Denylist) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			// Extract token from Authorization header
@@ -34,27 +41,20 @@ func JWTMiddleware(secret string) echo.MiddlewareFunc {
 			}
 
 			// Parse and validate token
-			token, err := jwt.ParseWithClaims(parts[1], &Claims{}, func(token *jwt.Token) (interface{}, error) {
-				// Validate the signing algorithm
-				if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-					return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-				}
-				return []byte(secret), nil
-			})
-
+			claims, err := ParseToken(parts[1], secret)
 			if err != nil {
 				return echo.NewHTTPError(http.StatusUnauthorized, "invalid or expired token")
 			}
 
-			// Check if token is valid
-			if !token.Valid {
-				return echo.NewHTTPError(http.StatusUnauthorized, "invalid token")
-			}
-
-			// Extract claims from token
-			claims, ok := token.Claims.(*Claims)
-			if !ok {
-				return echo.NewHTTPError(http.StatusInternalServerError, "failed to extract claims")
+			// Reject tokens revoked by logout or refresh token reuse
+			for _, d := range denylist {
+				revoked, err := d.IsRevoked(c.Request().Context(), claims)
+				if err != nil {
+					return echo.NewHTTPError(http.StatusServiceUnavailable, "failed to check token status")
+				}
+				if revoked {
+					return echo.NewHTTPError(http.StatusUnauthorized, "token has been revoked")
+				}
 			}
 
 			// Set claims in context
@@ -62,24 +62,59 @@ func JWTMiddleware(secret string) echo.MiddlewareFunc {
 			if claims.WalletAddress != "" {
 				c.Set("walletAddress", claims.WalletAddress)
 			}
+			c.Set("claims", claims)
 
 			return next(c)
 		}
 	}
 }
 
+// ParseToken validates a signed token and returns its claims
+func ParseToken(tokenString, secret string) (*Claims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+		// Validate the signing algorithm
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(secret), nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("token parsing failed: %w", err)
+	}
+
+	// Check if token is valid
+	if !token.Valid {
+		return nil, fmt.Errorf("invalid token")
+	}
+
+	claims, ok := token.Claims.(*Claims)
+	if !ok {
+		return nil, fmt.Errorf("failed to extract claims")
+	}
+
+	return claims, nil
+}
+
 // GenerateJWT generates a JWT token for a user
-func GenerateJWT(userID, walletAddress, secret string, expirationHours int) (string, error) {
-	// Create expiration time
-	expirationTime := time.Now().Add(time.Duration(expirationHours) * time.Hour)
+func GenerateJWT(userID, walletAddress, role, secret string, expirationHours int) (string, error) {
+	token, _, err := GenerateAccessToken(userID, walletAddress, role, "", secret, time.Duration(expirationHours)*time.Hour)
+	return token, err
+}
+
+// GenerateAccessToken generates a JWT with a unique jti, bound to the given refresh token family
+func GenerateAccessToken(userID, walletAddress, role, sessionID, secret string, ttl time.Duration) (string, *Claims, error) {
+	now := time.Now()
 
 	// Create claims
 	claims := &Claims{
 		UserID:        userID,
 		WalletAddress: walletAddress,
+		Role:          role,
+		SessionID:     sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expirationTime),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ID:        uuid.New().String(),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
 	}
 
@@ -89,8 +124,8 @@ func GenerateJWT(userID, walletAddress, secret string, expirationHours int) (str
 	// Sign token with secret
 	tokenString, err := token.SignedString([]byte(secret))
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
-	return tokenString, nil
+	return tokenString, claims, nil
 }
@@ -0,0 +1,217 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrInvalidRefreshToken is returned for unknown or expired refresh tokens
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	// ErrRefreshTokenReused is returned when an already rotated refresh token is presented again
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected, session revoked")
+	// ErrSessionRevoked is returned when the token family was revoked
+	ErrSessionRevoked = errors.New("session has been revoked")
+)
+
+// Denylist reports whether an otherwise valid access token has been revoked
+type Denylist interface {
+	IsRevoked(ctx context.Context, claims *Claims) (bool, error)
+}
+
+// Denylists revokes a token when any of its lists does
+type Denylists []Denylist
+
+// IsRevoked implements Denylist
+func (d Denylists) IsRevoked(ctx context.Context, claims *Claims) (bool, error) {
+	for _, list := range d {
+		revoked, err := list.IsRevoked(ctx, claims)
+		if err != nil || revoked {
+			return revoked, err
+		}
+	}
+	return false, nil
+}
+
+// RefreshSession is the identity bound to a refresh token
+type RefreshSession struct {
+	UserID        string
+	WalletAddress string
+	FamilyID      string
+}
+
+// TokenStore keeps rotating refresh tokens, token families and the access token denylist in Redis
+type TokenStore struct {
+	client     *redis.Client
+	refreshTTL time.Duration
+}
+
+// NewTokenStore creates a Redis-backed token store
+func NewTokenStore(client *redis.Client, refreshTTL time.Duration) *TokenStore {
+	return &TokenStore{
+		client:     client,
+		refreshTTL: refreshTTL,
+	}
+}
+
+// Redis keys. Refresh tokens are stored by hash so a Redis dump doesn't leak usable tokens.
+func refreshKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return "auth:refresh:" + hex.EncodeToString(sum[:])
+}
+
+func familyKey(familyID string) string { return "auth:family:" + familyID }
+
+func denylistKey(jti string) string { return "auth:denylist:" + jti }
+
+// NewFamilyID returns an identifier for a new login session
+func NewFamilyID() string {
+	return uuid.New().String()
+}
+
+// IssueRefreshToken creates a refresh token in the given family and keeps the family alive
+func (s *TokenStore) IssueRefreshToken(ctx context.Context, session RefreshSession) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+
+	key := refreshKey(token)
+	pipe := s.client.TxPipeline()
+	pipe.HSet(ctx, key, map[string]interface{}{
+		"userId":        session.UserID,
+		"walletAddress": session.WalletAddress,
+		"family":        session.FamilyID,
+		"used":          "0",
+	})
+	pipe.Expire(ctx, key, s.refreshTTL)
+	pipe.Set(ctx, familyKey(session.FamilyID), session.UserID, s.refreshTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return "", fmt.Errorf("failed to store refresh token: %w", err)
+	}
+
+	return token, nil
+}
+
+// rotateScript atomically marks a refresh token as used.
+// Returns 1 on success, 0 if unknown, -1 if the family is revoked and -2 on reuse
+// (in which case the family is revoked as a side effect).
+var rotateScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return 0
+end
+local family = redis.call('HGET', KEYS[1], 'family')
+local familyKey = ARGV[1] .. family
+if redis.call('EXISTS', familyKey) == 0 then
+	return -1
+end
+if redis.call('HGET', KEYS[1], 'used') == '1' then
+	redis.call('DEL', familyKey)
+	return -2
+end
+redis.call('HSET', KEYS[1], 'used', '1')
+return 1
+`)
+
+// Rotate consumes a refresh token and issues its replacement in the same family.
+// Presenting a token that was already rotated revokes the whole family.
+func (s *TokenStore) Rotate(ctx context.Context, token string) (*RefreshSession, string, error) {
+	key := refreshKey(token)
+
+	result, err := rotateScript.Run(ctx, s.client, []string{key}, familyKey("")).Int()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+
+	switch result {
+	case 0:
+		return nil, "", ErrInvalidRefreshToken
+	case -1:
+		return nil, "", ErrSessionRevoked
+	case -2:
+		return nil, "", ErrRefreshTokenReused
+	}
+
+	fields, err := s.client.HGetAll(ctx, key).Result()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read refresh token: %w", err)
+	}
+
+	session := &RefreshSession{
+		UserID:        fields["userId"],
+		WalletAddress: fields["walletAddress"],
+		FamilyID:      fields["family"],
+	}
+
+	next, err := s.IssueRefreshToken(ctx, *session)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return session, next, nil
+}
+
+// RevokeFamily ends a login session; its refresh tokens and access tokens stop working
+func (s *TokenStore) RevokeFamily(ctx context.Context, familyID string) error {
+	if familyID == "" {
+		return nil
+	}
+	if err := s.client.Del(ctx, familyKey(familyID)).Err(); err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	return nil
+}
+
+// DenyAccessToken puts a token's jti on the denylist until the token would have expired anyway
+func (s *TokenStore) DenyAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	if jti == "" {
+		return nil
+	}
+
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+
+	if err := s.client.Set(ctx, denylistKey(jti), "1", ttl).Err(); err != nil {
+		return fmt.Errorf("failed to deny access token: %w", err)
+	}
+	return nil
+}
+
+// IsRevoked reports whether the token's jti is denied or its session was revoked
+func (s *TokenStore) IsRevoked(ctx context.Context, claims *Claims) (bool, error) {
+	if claims.ID == "" && claims.SessionID == "" {
+		return false, nil
+	}
+
+	pipe := s.client.Pipeline()
+	var denied, family *redis.IntCmd
+	if claims.ID != "" {
+		denied = pipe.Exists(ctx, denylistKey(claims.ID))
+	}
+	if claims.SessionID != "" {
+		family = pipe.Exists(ctx, familyKey(claims.SessionID))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, fmt.Errorf("failed to check token revocation: %w", err)
+	}
+
+	if denied != nil && denied.Val() > 0 {
+		return true, nil
+	}
+	if family != nil && family.Val() == 0 {
+		return true, nil
+	}
+	return false, nil
+}
@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestTokenStore(t *testing.T) (*TokenStore, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return NewTokenStore(client, time.Hour), mr
+}
+
+func TestRotateIssuesNewTokenInSameFamily(t *testing.T) {
+	store, _ := newTestTokenStore(t)
+	ctx := context.Background()
+
+	first, err := store.IssueRefreshToken(ctx, RefreshSession{UserID: "u1", WalletAddress: "w1", FamilyID: "fam"})
+	require.NoError(t, err)
+
+	session, second, err := store.Rotate(ctx, first)
+	require.NoError(t, err)
+	assert.Equal(t, "u1", session.UserID)
+	assert.Equal(t, "w1", session.WalletAddress)
+	assert.Equal(t, "fam", session.FamilyID)
+	assert.NotEqual(t, first, second)
+
+	_, _, err = store.Rotate(ctx, second)
+	assert.NoError(t, err)
+}
+
+func TestReusedRefreshTokenRevokesFamily(t *testing.T) {
+	store, _ := newTestTokenStore(t)
+	ctx := context.Background()
+
+	first, err := store.IssueRefreshToken(ctx, RefreshSession{UserID: "u1", FamilyID: "fam"})
+	require.NoError(t, err)
+	_, second, err := store.Rotate(ctx, first)
+	require.NoError(t, err)
+
+	// Replaying the rotated token kills the session
+	_, _, err = store.Rotate(ctx, first)
+	assert.ErrorIs(t, err, ErrRefreshTokenReused)
+
+	// Including the legitimate successor
+	_, _, err = store.Rotate(ctx, second)
+	assert.ErrorIs(t, err, ErrSessionRevoked)
+
+	// And access tokens bound to the family
+	revoked, err := store.IsRevoked(ctx, &Claims{SessionID: "fam"})
+	require.NoError(t, err)
+	assert.True(t, revoked)
+}
+
+func TestRotateUnknownToken(t *testing.T) {
+	store, _ := newTestTokenStore(t)
+
+	_, _, err := store.Rotate(context.Background(), "not-a-token")
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+}
+
+func TestDenyAccessTokenExpiresWithToken(t *testing.T) {
+	store, mr := newTestTokenStore(t)
+	ctx := context.Background()
+
+	_, claims, err := GenerateAccessToken("u1", "", "", "", "secret", time.Minute)
+	require.NoError(t, err)
+
+	revoked, err := store.IsRevoked(ctx, claims)
+	require.NoError(t, err)
+	assert.False(t, revoked)
+
+	require.NoError(t, store.DenyAccessToken(ctx, claims.ID, claims.ExpiresAt.Time))
+	revoked, err = store.IsRevoked(ctx, claims)
+	require.NoError(t, err)
+	assert.True(t, revoked)
+
+	// The denylist entry doesn't outlive the token
+	mr.FastForward(2 * time.Minute)
+	assert.False(t, mr.Exists(denylistKey(claims.ID)))
+}
@@ -16,10 +16,15 @@ import (
 
 	"github.com/kekopoly/backend/internal/api/handlers"
 	"github.com/kekopoly/backend/internal/api/middleware/auth"
+	"github.com/kekopoly/backend/internal/audit"
+	solanaauth "github.com/kekopoly/backend/internal/auth"
 	"github.com/kekopoly/backend/internal/config"
 	"github.com/kekopoly/backend/internal/game/manager"
 	"github.com/kekopoly/backend/internal/game/websocket"
+	"github.com/kekopoly/backend/internal/matchmaking"
 	"github.com/kekopoly/backend/internal/queue"
+	"github.com/kekopoly/backend/internal/settlement"
+	"github.com/kekopoly/backend/internal/users"
 )
 
 // CustomValidator is the request validator for Echo
@@ -55,6 +60,9 @@ type Server struct {
 	mongoClient  *mongo.Client
 	redisClient  *redis.Client
 	messageQueue *queue.RedisQueue
+	userStore    users.Store
+	matchmaker   *matchmaking.Matchmaker
+	stopMatching context.CancelFunc
 }
 
 // NewServer creates a new API server
@@ -81,7 +89,11 @@ func NewServerWithClients(cfg *config.Config, gameManager *manager.GameManager,
 		}
 
 		// Create Redis queue
-		redisQueue, err = queue.NewRedisQueue(redisAddr, logger.Desugar())
+		// The partitions must match the worker's, or games land on the wrong streams
+		redisQueue, err = queue.NewRedisQueueWithOptions(redisAddr, logger.Desugar(), queue.Options{
+			Partitions: cfg.Queue.Partitions,
+			MaxLen:     cfg.Queue.MaxLen,
+		})
 		if err != nil {
 			logger.Warnf("Failed to initialize Redis queue: %v", err)
 		} else {
@@ -89,11 +101,15 @@ func NewServerWithClients(cfg *config.Config, gameManager *manager.GameManager,
 		}
 	}
 
-	// Create WebSocket Hub with message queue
-	wsHub := websocket.NewHub(context.Background(), gameManager, mongoClient, redisClient, logger, redisQueue)
-
-	// Set the WebSocket hub in the game manager
-	gameManager.SetWebSocketHub(wsHub)
+	// Serve connections from the hub the game manager broadcasts through, so the
+	// cluster bus and event log configured on it apply. Create one if there is none.
+	wsHub, ok := gameManager.WebSocketHub().(*websocket.Hub)
+	if !ok || wsHub == nil {
+		wsHub = websocket.NewHub(context.Background(), gameManager, mongoClient, redisClient, logger, redisQueue)
+		gameManager.SetWebSocketHub(wsHub)
+	} else if redisQueue != nil {
+		wsHub.SetMessageQueue(redisQueue)
+	}
 
 	// Set the message queue in the game manager if available
 	if redisQueue != nil {
@@ -109,6 +125,35 @@ func NewServerWithClients(cfg *config.Config, gameManager *manager.GameManager,
 		ActiveConnections: 0,
 	}
 
+	// Set up the user account store when MongoDB is available
+	var userStore users.Store
+	if mongoClient != nil {
+		mongoUsers := users.NewMongoStore(mongoClient, cfg.MongoDB.Database, cfg.MongoDB.PlayerColl)
+		indexCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := mongoUsers.EnsureIndexes(indexCtx); err != nil {
+			logger.Errorf("Failed to ensure user indexes: %v", err)
+		}
+		cancel()
+		userStore = mongoUsers
+	}
+
+	// Quick match shares its queue between nodes through Redis
+	var matchmaker *matchmaking.Matchmaker
+	stopMatching := func() {}
+	if cfg.Matchmaking.Enabled && redisClient != nil {
+		matchmaker = matchmaking.NewMatchmaker(redisClient, gameManager, wsHub, matchmaking.Options{
+			Interval:   time.Duration(cfg.Matchmaking.Interval) * time.Second,
+			WidenAfter: time.Duration(cfg.Matchmaking.WidenAfter) * time.Second,
+			BuyInStep:  cfg.Matchmaking.BuyInStep,
+			MaxWiden:   cfg.Matchmaking.MaxWiden,
+			MaxWait:    time.Duration(cfg.Matchmaking.MaxWait) * time.Second,
+			AllowBuyIn: cfg.Settlement.Enabled,
+		}, logger)
+		matchCtx, cancel := context.WithCancel(context.Background())
+		go matchmaker.Run(matchCtx)
+		stopMatching = cancel
+	}
+
 	server := &Server{
 		echo:         e,
 		cfg:          cfg,
@@ -119,6 +164,9 @@ func NewServerWithClients(cfg *config.Config, gameManager *manager.GameManager,
 		mongoClient:  mongoClient,
 		redisClient:  redisClient,
 		messageQueue: redisQueue,
+		userStore:    userStore,
+		matchmaker:   matchmaker,
+		stopMatching: stopMatching,
 	}
 
 	// Configure middleware
@@ -198,10 +246,38 @@ func (s *Server) metricsMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 func (s *Server) configureRoutes() {
 	// Create handlers
 	gameHandler := handlers.NewGameHandler(s.gameManager, s.wsHub, s.logger)
-	authHandler := handlers.NewAuthHandler(s.cfg, s.logger)
-	userHandler := handlers.NewUserHandler(s.logger)
-	wsHandler := handlers.NewWebSocketHandler(s.wsHub, s.logger, s.cfg)
+	// Refresh tokens and revocation need Redis; without it tokens just expire
+	var tokenStore *auth.TokenStore
+	var challengeStore *solanaauth.ChallengeStore
+	var walletBans *auth.WalletBans
+	var denylists []auth.Denylist
+	if s.redisClient != nil {
+		tokenStore = auth.NewTokenStore(s.redisClient, time.Duration(s.cfg.JWT.RefreshTokenTTL)*time.Hour)
+		walletBans = auth.NewWalletBans(s.redisClient, s.userStore)
+		denylists = append(denylists, tokenStore, walletBans)
+		challengeStore = solanaauth.NewChallengeStore(s.redisClient,
+			s.cfg.Solana.SignInDomain,
+			s.cfg.Solana.SignInURI,
+			s.cfg.Solana.Network,
+			time.Duration(s.cfg.Solana.ChallengeTTL)*time.Second)
+	}
+
+	authHandler := handlers.NewAuthHandler(s.cfg, s.userStore, tokenStore, challengeStore, walletBans, s.logger)
+	userHandler := handlers.NewUserHandler(s.userStore, s.logger)
+	var wsDenylist auth.Denylist
+	if s.redisClient != nil {
+		wsDenylist = auth.Denylists{tokenStore, walletBans}
+	}
+	wsHandler := handlers.NewWebSocketHandler(s.wsHub, s.logger, s.cfg, wsDenylist)
 	healthHandler := handlers.NewHealthHandler(s.mongoClient, s.redisClient, s.logger)
+	matchmakingHandler := handlers.NewMatchmakingHandler(s.matchmaker, s.logger)
+	var transactions settlement.Store
+	var auditLog audit.Store
+	if s.mongoClient != nil {
+		transactions = settlement.NewMongoStore(s.mongoClient, s.cfg.MongoDB.Database, s.cfg.MongoDB.TxColl)
+		auditLog = audit.NewMongoStore(s.mongoClient, s.cfg.MongoDB.Database, s.cfg.MongoDB.AuditColl)
+	}
+	adminHandler := handlers.NewAdminHandler(s.gameManager, s.wsHub, transactions, auditLog, walletBans, s.messageQueue, s.logger)
 
 	// Start the ping/pong monitor for inactive client detection
 	wsHandler.StartPingPongMonitor()
@@ -213,12 +289,15 @@ func (s *Server) configureRoutes() {
 	authGroup := apiV1.Group("/auth")
 	authGroup.POST("/register", authHandler.Register)
 	authGroup.POST("/login", authHandler.Login)
+	authGroup.GET("/challenge", authHandler.Challenge)
 	authGroup.POST("/wallet-connect", authHandler.WalletConnect)
-	authGroup.GET("/refresh-token", authHandler.RefreshToken)
-	authGroup.POST("/logout", authHandler.Logout)
+	authGroup.POST("/refresh-token", authHandler.RefreshToken)
 
 	// JWT middleware for protected routes
-	jwtMiddleware := auth.JWTMiddleware(s.cfg.JWT.Secret)
+	jwtMiddleware := auth.JWTMiddleware(s.cfg.JWT.Secret, denylists...)
+
+	// Logout needs the caller's token claims to revoke them
+	authGroup.POST("/logout", authHandler.Logout, jwtMiddleware)
 
 	// User routes (JWT required)
 	userGroup := apiV1.Group("/user", jwtMiddleware)
@@ -226,20 +305,58 @@ func (s *Server) configureRoutes() {
 	userGroup.PATCH("/profile", userHandler.UpdateProfile)
 	userGroup.GET("/wallet", userHandler.GetWallet)
 	userGroup.POST("/wallet/verify", userHandler.VerifyWallet)
+	userGroup.POST("/wallet/link", authHandler.LinkWallet)
 
 	// Game routes (JWT required)
 	gameGroup := apiV1.Group("/games", jwtMiddleware)
 	gameGroup.POST("", gameHandler.CreateGame)
 	gameGroup.GET("", gameHandler.ListGames)
+	gameGroup.GET("/spectatable", gameHandler.ListSpectatableGames)
 	gameGroup.GET("/:gameId", gameHandler.GetGameDetails)
 	gameGroup.POST("/:gameId/join", gameHandler.JoinGame)
+	gameGroup.POST("/:gameId/invites", gameHandler.CreateInvite)
+	gameGroup.POST("/:gameId/kick", gameHandler.KickPlayer)
+	gameGroup.POST("/:gameId/ban", gameHandler.BanPlayer)
+	gameGroup.POST("/:gameId/lock", gameHandler.LockGame)
+	gameGroup.POST("/:gameId/transfer-host", gameHandler.TransferHost)
+	gameGroup.POST("/:gameId/bots", gameHandler.AddBot)
 	gameGroup.POST("/:gameId/leave", gameHandler.LeaveGame)
 	gameGroup.POST("/:gameId/start", gameHandler.StartGame)
+	gameGroup.POST("/:gameId/deposit", gameHandler.SubmitDeposit)
 	gameGroup.POST("/:gameId/pause", gameHandler.PauseGame)
-	gameGroup.POST("/:gameId/reset", gameHandler.ResetGame)
+	gameGroup.POST("/:gameId/resume", gameHandler.ResumeGame)
 	gameGroup.GET("/:gameId/state", gameHandler.GetGameState)
 	gameGroup.POST("/:gameId/sync", gameHandler.SyncGameState)
-	gameGroup.POST("/cleanup", gameHandler.CleanupStaleGames)
+
+	// Operator routes (JWT and an admin account required)
+	adminGroup := apiV1.Group("/admin", jwtMiddleware, auth.RequireAdmin())
+	adminGroup.GET("/games", adminHandler.ListGames)
+	adminGroup.POST("/games/cleanup", adminHandler.CleanupStaleGames)
+	adminGroup.POST("/games/import", adminHandler.ImportGame)
+	adminGroup.GET("/games/:gameId", adminHandler.GetGame)
+	adminGroup.POST("/games/:gameId/end", adminHandler.EndGame)
+	adminGroup.POST("/games/:gameId/balance", adminHandler.AdjustBalance)
+	adminGroup.POST("/games/:gameId/kick", adminHandler.RemovePlayer)
+	adminGroup.POST("/games/:gameId/reset", adminHandler.ResetGame)
+	adminGroup.GET("/games/:gameId/export", adminHandler.ExportGame)
+	adminGroup.GET("/wallets/:walletAddress/ban", adminHandler.GetWalletBan)
+	adminGroup.POST("/wallets/:walletAddress/ban", adminHandler.BanWallet)
+	adminGroup.DELETE("/wallets/:walletAddress/ban", adminHandler.UnbanWallet)
+	adminGroup.GET("/queues", adminHandler.ListDeadLetterQueues)
+	adminGroup.GET("/queues/streams", adminHandler.ListStreams)
+	adminGroup.POST("/queues/drain", adminHandler.DrainAllDeadLetters)
+	adminGroup.GET("/queues/:gameId/dead", adminHandler.ListDeadLetters)
+	adminGroup.GET("/queues/:gameId/dead/:messageId", adminHandler.GetDeadLetter)
+	adminGroup.POST("/queues/:gameId/drain", adminHandler.DrainDeadLetters)
+	adminGroup.POST("/queues/:gameId/requeue", adminHandler.RequeueDeadLetters)
+	adminGroup.POST("/queues/:gameId/purge", adminHandler.PurgeDeadLetters)
+	adminGroup.GET("/audit", adminHandler.ListAuditLog)
+
+	// Quick-match routes (JWT required)
+	matchGroup := apiV1.Group("/matchmaking", jwtMiddleware)
+	matchGroup.POST("", matchmakingHandler.Enqueue)
+	matchGroup.GET("", matchmakingHandler.GetStatus)
+	matchGroup.DELETE("", matchmakingHandler.Dequeue)
 
 	// Game actions routes (JWT required)
 	actionGroup := apiV1.Group("/games/:gameId/actions", jwtMiddleware)
@@ -260,6 +377,7 @@ func (s *Server) configureRoutes() {
 	// WebSocket routes (JWT required)
 	s.echo.GET("/ws/:gameId", wsHandler.HandleConnection)
 	s.echo.GET("/ws/lobby", wsHandler.HandleLobbyConnection) // New endpoint for lobby connections
+	s.echo.GET("/ws/:gameId/spectate", wsHandler.HandleSpectatorConnection)
 
 	// Health check endpoints (no auth required)
 	s.echo.GET("/health", healthHandler.Check)
@@ -281,6 +399,8 @@ func (s *Server) Start() error {
 
 // Shutdown gracefully shuts down the API server
 func (s *Server) Shutdown(ctx context.Context) error {
+	s.stopMatching()
+
 	// Close the message queue if it exists
 	if s.messageQueue != nil {
 		if err := s.messageQueue.Close(); err != nil {
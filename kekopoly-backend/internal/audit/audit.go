@@ -0,0 +1,142 @@
+// Package audit records what operators do through the admin API
+package audit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Entry is one operator action
+type Entry struct {
+	ID        string                 `bson:"_id" json:"id"`
+	Time      time.Time              `bson:"time" json:"time"`
+	AdminID   string                 `bson:"adminId" json:"adminId"` // The operator's user ID
+	Action    string                 `bson:"action" json:"action"`
+	GameID    string                 `bson:"gameId,omitempty" json:"gameId,omitempty"`
+	PlayerID  string                 `bson:"playerId,omitempty" json:"playerId,omitempty"`
+	Wallet    string                 `bson:"walletAddress,omitempty" json:"walletAddress,omitempty"`
+	Reason    string                 `bson:"reason,omitempty" json:"reason,omitempty"`
+	Details   map[string]interface{} `bson:"details,omitempty" json:"details,omitempty"`
+	Error     string                 `bson:"error,omitempty" json:"error,omitempty"` // Why the action failed; empty if it succeeded
+	RequestID string                 `bson:"requestId,omitempty" json:"requestId,omitempty"`
+}
+
+// Filter narrows a listing of the log. Empty fields match everything.
+type Filter struct {
+	AdminID string
+	GameID  string
+	Action  string
+	Limit   int // Newest entries first; 0 for all
+}
+
+// Store keeps the audit log
+type Store interface {
+	// Record appends an entry, filling in its ID and time if they are empty
+	Record(ctx context.Context, entry *Entry) error
+	// List returns matching entries, newest first
+	List(ctx context.Context, filter Filter) ([]Entry, error)
+}
+
+// stamp fills in an entry's ID and time
+func stamp(entry *Entry) {
+	if entry.ID == "" {
+		entry.ID = uuid.New().String()
+	}
+	if entry.Time.IsZero() {
+		entry.Time = time.Now().UTC()
+	}
+}
+
+// MongoStore keeps the audit log in a MongoDB collection
+type MongoStore struct {
+	collection *mongo.Collection
+}
+
+// NewMongoStore creates a Mongo-backed audit log
+func NewMongoStore(client *mongo.Client, dbName, collName string) *MongoStore {
+	return &MongoStore{collection: client.Database(dbName).Collection(collName)}
+}
+
+// Record implements Store
+func (s *MongoStore) Record(ctx context.Context, entry *Entry) error {
+	stamp(entry)
+	if _, err := s.collection.InsertOne(ctx, entry); err != nil {
+		return fmt.Errorf("failed to record audit entry: %w", err)
+	}
+	return nil
+}
+
+// List implements Store
+func (s *MongoStore) List(ctx context.Context, filter Filter) ([]Entry, error) {
+	query := bson.M{}
+	if filter.AdminID != "" {
+		query["adminId"] = filter.AdminID
+	}
+	if filter.GameID != "" {
+		query["gameId"] = filter.GameID
+	}
+	if filter.Action != "" {
+		query["action"] = filter.Action
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "time", Value: -1}})
+	if filter.Limit > 0 {
+		opts.SetLimit(int64(filter.Limit))
+	}
+
+	cursor, err := s.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit log: %w", err)
+	}
+	var entries []Entry
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, fmt.Errorf("failed to decode audit log: %w", err)
+	}
+	return entries, nil
+}
+
+// MemoryStore is an in-process Store for tests and development
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+// NewMemoryStore creates an empty in-memory audit log
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// Record implements Store
+func (s *MemoryStore) Record(ctx context.Context, entry *Entry) error {
+	stamp(entry)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, *entry)
+	return nil
+}
+
+// List implements Store
+func (s *MemoryStore) List(ctx context.Context, filter Filter) ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []Entry
+	for i := len(s.entries) - 1; i >= 0; i-- {
+		entry := s.entries[i]
+		if (filter.AdminID != "" && entry.AdminID != filter.AdminID) ||
+			(filter.GameID != "" && entry.GameID != filter.GameID) ||
+			(filter.Action != "" && entry.Action != filter.Action) {
+			continue
+		}
+		out = append(out, entry)
+		if filter.Limit > 0 && len(out) == filter.Limit {
+			break
+		}
+	}
+	return out, nil
+}
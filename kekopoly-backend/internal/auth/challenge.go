@@ -0,0 +1,153 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/go-redis/redis/v8"
+)
+
+var (
+	// ErrChallengeNotFound is returned when a message doesn't match an outstanding challenge
+	ErrChallengeNotFound = errors.New("no outstanding challenge for this message")
+	// ErrInvalidWallet is returned when a wallet address is not a valid Solana public key
+	ErrInvalidWallet = errors.New("invalid wallet address")
+)
+
+// Challenge is a Sign-In-With-Solana message issued by the server
+type Challenge struct {
+	Domain         string    `json:"domain"`
+	Address        string    `json:"address"`
+	Statement      string    `json:"statement"`
+	URI            string    `json:"uri"`
+	Version        string    `json:"version"`
+	ChainID        string    `json:"chainId"`
+	Nonce          string    `json:"nonce"`
+	IssuedAt       time.Time `json:"issuedAt"`
+	ExpirationTime time.Time `json:"expirationTime"`
+	Message        string    `json:"message"` // The exact text the wallet must sign
+}
+
+// ChallengeStore issues single-use sign-in challenges and tracks them in Redis
+type ChallengeStore struct {
+	client  *redis.Client
+	domain  string
+	uri     string
+	chainID string
+	ttl     time.Duration
+}
+
+// NewChallengeStore creates a challenge store for the given sign-in domain
+func NewChallengeStore(client *redis.Client, domain, uri, chainID string, ttl time.Duration) *ChallengeStore {
+	return &ChallengeStore{
+		client:  client,
+		domain:  domain,
+		uri:     uri,
+		chainID: chainID,
+		ttl:     ttl,
+	}
+}
+
+// challengeKey is the Redis key for an outstanding challenge
+func challengeKey(walletAddress, nonce string) string {
+	return fmt.Sprintf("auth:challenge:%s:%s", walletAddress, nonce)
+}
+
+// Issue creates a new challenge for the wallet
+func (s *ChallengeStore) Issue(ctx context.Context, walletAddress string) (*Challenge, error) {
+	if _, err := solana.PublicKeyFromBase58(walletAddress); err != nil {
+		return nil, ErrInvalidWallet
+	}
+
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	challenge := &Challenge{
+		Domain:         s.domain,
+		Address:        walletAddress,
+		Statement:      "Sign in to Kekopoly",
+		URI:            s.uri,
+		Version:        "1",
+		ChainID:        s.chainID,
+		Nonce:          hex.EncodeToString(buf),
+		IssuedAt:       now,
+		ExpirationTime: now.Add(s.ttl),
+	}
+	challenge.Message = challenge.format()
+
+	if err := s.client.Set(ctx, challengeKey(walletAddress, challenge.Nonce), challenge.Message, s.ttl).Err(); err != nil {
+		return nil, fmt.Errorf("failed to store challenge: %w", err)
+	}
+
+	return challenge, nil
+}
+
+// Lookup checks that message is exactly an outstanding challenge for the wallet
+// and returns its nonce. The challenge stays outstanding until Consume is called.
+func (s *ChallengeStore) Lookup(ctx context.Context, walletAddress, message string) (string, error) {
+	nonce := ParseNonce(message)
+	if nonce == "" {
+		return "", ErrChallengeNotFound
+	}
+
+	stored, err := s.client.Get(ctx, challengeKey(walletAddress, nonce)).Result()
+	if err == redis.Nil {
+		return "", ErrChallengeNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to load challenge: %w", err)
+	}
+
+	if stored != message {
+		return "", ErrChallengeNotFound
+	}
+
+	return nonce, nil
+}
+
+// Consume atomically removes a challenge. Only one caller can consume a given
+// challenge; everyone else gets ErrChallengeNotFound.
+func (s *ChallengeStore) Consume(ctx context.Context, walletAddress, nonce string) error {
+	deleted, err := s.client.Del(ctx, challengeKey(walletAddress, nonce)).Result()
+	if err != nil {
+		return fmt.Errorf("failed to consume challenge: %w", err)
+	}
+	if deleted == 0 {
+		return ErrChallengeNotFound
+	}
+	return nil
+}
+
+// format renders the challenge in the Sign-In-With-Solana message format
+func (c *Challenge) format() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s wants you to sign in with your Solana account:\n", c.Domain)
+	fmt.Fprintf(&b, "%s\n\n", c.Address)
+	fmt.Fprintf(&b, "%s\n\n", c.Statement)
+	fmt.Fprintf(&b, "URI: %s\n", c.URI)
+	fmt.Fprintf(&b, "Version: %s\n", c.Version)
+	fmt.Fprintf(&b, "Chain ID: %s\n", c.ChainID)
+	fmt.Fprintf(&b, "Nonce: %s\n", c.Nonce)
+	fmt.Fprintf(&b, "Issued At: %s\n", c.IssuedAt.Format(time.RFC3339))
+	fmt.Fprintf(&b, "Expiration Time: %s", c.ExpirationTime.Format(time.RFC3339))
+	return b.String()
+}
+
+// ParseNonce extracts the nonce line from a sign-in message
+func ParseNonce(message string) string {
+	for _, line := range strings.Split(message, "\n") {
+		if strings.HasPrefix(line, "Nonce: ") {
+			return strings.TrimSpace(strings.TrimPrefix(line, "Nonce: "))
+		}
+	}
+	return ""
+}
@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// FakeRPC is an in-memory RPCClient for tests and offline development
+type FakeRPC struct {
+	mu       sync.Mutex
+	balances map[solana.PublicKey]*rpc.UiTokenAmount
+	err      error
+}
+
+// NewFakeRPC creates a fake RPC where no wallet holds any tokens
+func NewFakeRPC() *FakeRPC {
+	return &FakeRPC{balances: make(map[solana.PublicKey]*rpc.UiTokenAmount)}
+}
+
+// SetTokenBalance gives the wallet's associated token account for mint a raw balance
+func (f *FakeRPC) SetTokenBalance(wallet, mint string, amount uint64, decimals uint8) error {
+	owner, err := solana.PublicKeyFromBase58(wallet)
+	if err != nil {
+		return fmt.Errorf("invalid wallet address: %w", err)
+	}
+	mintKey, err := solana.PublicKeyFromBase58(mint)
+	if err != nil {
+		return fmt.Errorf("invalid mint address: %w", err)
+	}
+	account, _, err := solana.FindAssociatedTokenAddress(owner, mintKey)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.balances[account] = &rpc.UiTokenAmount{
+		Amount:   strconv.FormatUint(amount, 10),
+		Decimals: decimals,
+	}
+	return nil
+}
+
+// FailWith makes every call return err until it is called again with nil
+func (f *FakeRPC) FailWith(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.err = err
+}
+
+// GetTokenAccountBalance implements RPCClient
+func (f *FakeRPC) GetTokenAccountBalance(ctx context.Context, account solana.PublicKey, commitment rpc.CommitmentType) (*rpc.GetTokenAccountBalanceResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	balance, ok := f.balances[account]
+	if !ok {
+		return nil, rpc.ErrNotFound
+	}
+	value := *balance
+	return &rpc.GetTokenAccountBalanceResult{Value: &value}, nil
+}
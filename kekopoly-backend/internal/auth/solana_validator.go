@@ -1,81 +1,202 @@
 package auth
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
-	"sync"
 
 	"github.com/gagliardetto/solana-go"
 	"github.com/gagliardetto/solana-go/rpc"
+	"go.uber.org/zap"
 )
 
+// ValidatorMode controls how strictly wallet signatures are checked
+type ValidatorMode string
+
+const (
+	// ModeStrict verifies every signature
+	ModeStrict ValidatorMode = "strict"
+	// ModeDev verifies signatures, but only from the configured dev wallets
+	ModeDev ValidatorMode = "dev"
+	// ModeOff accepts any signature; only allowed in dev mode
+	ModeOff ValidatorMode = "off"
+)
+
+// ErrModeNotAllowed is returned when a validator mode is requested outside dev mode
+var ErrModeNotAllowed = errors.New("validator mode not allowed outside dev mode")
+
+// RPCClient is the subset of the Solana RPC API the validator uses
+type RPCClient interface {
+	GetTokenAccountBalance(ctx context.Context, account solana.PublicKey, commitment rpc.CommitmentType) (*rpc.GetTokenAccountBalanceResult, error)
+}
+
+// ValidatorOptions configures a SolanaValidator
+type ValidatorOptions struct {
+	RPCURL     string // Defaults to the public endpoint for Network
+	Network    string // mainnet, devnet, testnet or localnet
+	Mode       ValidatorMode
+	DevMode    bool     // Required for ModeOff, and for ModeDev on mainnet
+	DevWallets []string // Public keys of the test keypairs accepted in ModeDev
+	RPC        RPCClient
+	Logger     *zap.SugaredLogger
+}
+
 // SolanaValidator handles Solana signature validation
 type SolanaValidator struct {
-	client  *rpc.Client
-	rpcURL  string
-	enabled bool
-	mu      sync.RWMutex // For thread-safe operations
+	client     RPCClient
+	rpcURL     string
+	network    string
+	mode       ValidatorMode
+	devWallets map[string]bool
+	logger     *zap.SugaredLogger
 }
 
-// NewSolanaValidator creates a new SolanaValidator
+// NewSolanaValidator creates a strict mainnet validator
 func NewSolanaValidator(rpcURL string) *SolanaValidator {
-	// If no RPC URL is provided, use mainnet
-	if rpcURL == "" {
-		rpcURL = rpc.MainNetBeta_RPC
+	// Strict mode is always allowed, so this can't fail
+	validator, _ := NewSolanaValidatorWithOptions(ValidatorOptions{
+		RPCURL:  rpcURL,
+		Network: "mainnet",
+		Mode:    ModeStrict,
+	})
+	return validator
+}
+
+// NewSolanaValidatorWithOptions creates a validator in the given mode. ModeOff is refused
+// unless DevMode is set, as is ModeDev on mainnet.
+func NewSolanaValidatorWithOptions(opts ValidatorOptions) (*SolanaValidator, error) {
+	if opts.Mode == "" {
+		opts.Mode = ModeStrict
+	}
+	if opts.Network == "" {
+		opts.Network = "mainnet"
+	}
+	if opts.Logger == nil {
+		opts.Logger = zap.NewNop().Sugar()
+	}
+
+	switch opts.Mode {
+	case ModeStrict:
+	case ModeDev:
+		if isMainnet(opts.Network) && !opts.DevMode {
+			return nil, fmt.Errorf("%w: %s on %s", ErrModeNotAllowed, opts.Mode, opts.Network)
+		}
+	case ModeOff:
+		if !opts.DevMode {
+			return nil, fmt.Errorf("%w: %s", ErrModeNotAllowed, opts.Mode)
+		}
+	default:
+		return nil, fmt.Errorf("unknown validator mode %q", opts.Mode)
+	}
+
+	devWallets := make(map[string]bool)
+	for _, wallet := range opts.DevWallets {
+		if _, err := solana.PublicKeyFromBase58(wallet); err != nil {
+			return nil, fmt.Errorf("invalid dev wallet %q: %w", wallet, err)
+		}
+		devWallets[wallet] = true
 	}
 
-	// Create the validator with enabled state
-	validator := &SolanaValidator{
-		rpcURL:  rpcURL,
-		enabled: true,
+	// If no RPC URL is provided, use the public endpoint for the network
+	if opts.RPCURL == "" {
+		opts.RPCURL = defaultRPCURL(opts.Network)
+	}
+	if opts.RPC == nil {
+		opts.RPC = rpc.New(opts.RPCURL)
 	}
 
-	// Initialize the client
-	validator.client = rpc.New(rpcURL)
+	opts.Logger.Infow("[SolanaValidator] Initialized",
+		"mode", opts.Mode,
+		"network", opts.Network,
+		"rpc", opts.RPCURL,
+		"devWallets", len(devWallets))
 
-	return validator
+	return &SolanaValidator{
+		client:     opts.RPC,
+		rpcURL:     opts.RPCURL,
+		network:    opts.Network,
+		mode:       opts.Mode,
+		devWallets: devWallets,
+		logger:     opts.Logger,
+	}, nil
 }
 
-// IsEnabled returns whether validation is enabled
-func (v *SolanaValidator) IsEnabled() bool {
-	v.mu.RLock()
-	defer v.mu.RUnlock()
-	return v.enabled
+// isMainnet reports whether network names the Solana mainnet cluster
+func isMainnet(network string) bool {
+	switch strings.ToLower(network) {
+	case "mainnet", "mainnet-beta":
+		return true
+	}
+	return false
 }
 
-// Enable enables validation
-func (v *SolanaValidator) Enable() {
-	v.mu.Lock()
-	defer v.mu.Unlock()
-	v.enabled = true
+// defaultRPCURL returns the public RPC endpoint for a network
+func defaultRPCURL(network string) string {
+	switch strings.ToLower(network) {
+	case "devnet":
+		return rpc.DevNet_RPC
+	case "testnet":
+		return rpc.TestNet_RPC
+	case "localnet", "localhost":
+		return rpc.LocalNet_RPC
+	default:
+		return rpc.MainNetBeta_RPC
+	}
 }
 
-// Disable disables validation
-func (v *SolanaValidator) Disable() {
-	v.mu.Lock()
-	defer v.mu.Unlock()
-	v.enabled = false
+// Mode returns the validator's mode
+func (v *SolanaValidator) Mode() ValidatorMode {
+	return v.mode
 }
 
-// VerifySignature verifies a Solana signature
+// Network returns the cluster the validator talks to
+func (v *SolanaValidator) Network() string {
+	return v.network
+}
+
+// VerifySignature verifies a Solana signature according to the validator's mode
 // Returns true if valid, false if invalid
 func (v *SolanaValidator) VerifySignature(walletAddress, message, signature string, format string) (bool, error) {
-	// Check if validation is enabled
-	v.mu.RLock()
-	if !v.enabled {
-		v.mu.RUnlock()
-		return true, nil // Always return true if validation is disabled
+	valid, reason, err := v.verify(walletAddress, message, signature, format)
+	if err != nil {
+		reason = err.Error()
+	}
+	v.logger.Infow("[SolanaValidator] Auth decision",
+		"mode", v.mode,
+		"network", v.network,
+		"wallet", walletAddress,
+		"accepted", valid,
+		"reason", reason)
+	return valid, err
+}
+
+// verify applies the mode's rules and reports why a signature was accepted or rejected
+func (v *SolanaValidator) verify(walletAddress, message, signature string, format string) (bool, string, error) {
+	switch v.mode {
+	case ModeOff:
+		return true, "validation off", nil
+	case ModeDev:
+		if !v.devWallets[walletAddress] {
+			return false, "wallet is not a dev keypair", nil
+		}
 	}
-	v.mu.RUnlock()
 
-	// Ensure validator is properly initialized
-	if v.client == nil {
-		return false, errors.New("solana validator client not initialized")
+	valid, err := verifyEd25519(walletAddress, message, signature, format)
+	if err != nil {
+		return false, "", err
 	}
+	if !valid {
+		return false, "signature mismatch", nil
+	}
+	return true, "signature verified", nil
+}
 
+// verifyEd25519 checks the signature over message against the wallet's public key
+func verifyEd25519(walletAddress, message, signature string, format string) (bool, error) {
 	// Parse wallet public key
 	pubKey, err := solana.PublicKeyFromBase58(walletAddress)
 	if err != nil {
@@ -130,6 +251,65 @@ func (v *SolanaValidator) VerifySignature(walletAddress, message, signature stri
 	return solanaSig.Verify(pubKey, messageBytes), nil
 }
 
+// TokenBalance returns the raw amount of mint held in the wallet's associated token account.
+// A wallet that never held the token has a balance of zero.
+func (v *SolanaValidator) TokenBalance(ctx context.Context, walletAddress, mint string) (uint64, error) {
+	owner, err := solana.PublicKeyFromBase58(walletAddress)
+	if err != nil {
+		return 0, fmt.Errorf("invalid wallet address: %w", err)
+	}
+	mintKey, err := solana.PublicKeyFromBase58(mint)
+	if err != nil {
+		return 0, fmt.Errorf("invalid mint address: %w", err)
+	}
+
+	account, _, err := solana.FindAssociatedTokenAddress(owner, mintKey)
+	if err != nil {
+		return 0, fmt.Errorf("failed to derive token account: %w", err)
+	}
+
+	result, err := v.client.GetTokenAccountBalance(ctx, account, rpc.CommitmentConfirmed)
+	if err != nil {
+		if isAccountNotFound(err) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to get token balance: %w", err)
+	}
+	if result == nil || result.Value == nil {
+		return 0, nil
+	}
+
+	amount, err := strconv.ParseUint(result.Value.Amount, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid token amount %q: %w", result.Value.Amount, err)
+	}
+	return amount, nil
+}
+
+// HasMinimumBalance reports whether the wallet holds at least min raw units of mint
+func (v *SolanaValidator) HasMinimumBalance(ctx context.Context, walletAddress, mint string, min uint64) (bool, error) {
+	balance, err := v.TokenBalance(ctx, walletAddress, mint)
+	if err != nil {
+		return false, err
+	}
+
+	ok := balance >= min
+	v.logger.Infow("[SolanaValidator] Balance check",
+		"mode", v.mode,
+		"network", v.network,
+		"wallet", walletAddress,
+		"mint", mint,
+		"balance", balance,
+		"minimum", min,
+		"accepted", ok)
+	return ok, nil
+}
+
+// isAccountNotFound reports whether the RPC error means the token account doesn't exist
+func isAccountNotFound(err error) bool {
+	return errors.Is(err, rpc.ErrNotFound) || strings.Contains(err.Error(), "could not find account")
+}
+
 // Helper to parse signature from buffer format (JSON array of numbers)
 func parseBufferSignature(bufferStr string) ([]byte, error) {
 	// Remove brackets and all whitespace
@@ -0,0 +1,134 @@
+package auth
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// signed returns a wallet address and its hex signature over message
+func signed(t *testing.T, message string) (string, string) {
+	t.Helper()
+	wallet := solana.NewWallet()
+	sig, err := wallet.PrivateKey.Sign([]byte(message))
+	require.NoError(t, err)
+	return wallet.PublicKey().String(), hex.EncodeToString(sig[:])
+}
+
+func TestValidatorModesAreGatedByDevMode(t *testing.T) {
+	_, err := NewSolanaValidatorWithOptions(ValidatorOptions{Mode: ModeOff, RPC: NewFakeRPC()})
+	assert.ErrorIs(t, err, ErrModeNotAllowed)
+
+	_, err = NewSolanaValidatorWithOptions(ValidatorOptions{Mode: ModeDev, Network: "mainnet", RPC: NewFakeRPC()})
+	assert.ErrorIs(t, err, ErrModeNotAllowed)
+
+	v, err := NewSolanaValidatorWithOptions(ValidatorOptions{Mode: ModeDev, Network: "devnet", RPC: NewFakeRPC()})
+	require.NoError(t, err)
+	assert.Equal(t, ModeDev, v.Mode())
+
+	v, err = NewSolanaValidatorWithOptions(ValidatorOptions{Mode: ModeOff, DevMode: true, RPC: NewFakeRPC()})
+	require.NoError(t, err)
+	assert.Equal(t, ModeOff, v.Mode())
+
+	_, err = NewSolanaValidatorWithOptions(ValidatorOptions{Mode: "lenient", RPC: NewFakeRPC()})
+	assert.Error(t, err)
+}
+
+func TestStrictModeVerifiesSignatures(t *testing.T) {
+	v := NewSolanaValidator("")
+	assert.Equal(t, ModeStrict, v.Mode())
+
+	wallet, sig := signed(t, "hello")
+	valid, err := v.VerifySignature(wallet, "hello", sig, "hex")
+	require.NoError(t, err)
+	assert.True(t, valid)
+
+	valid, err = v.VerifySignature(wallet, "goodbye", sig, "hex")
+	require.NoError(t, err)
+	assert.False(t, valid)
+}
+
+func TestDevModeOnlyAcceptsDevWallets(t *testing.T) {
+	devWallet, devSig := signed(t, "hello")
+	otherWallet, otherSig := signed(t, "hello")
+
+	v, err := NewSolanaValidatorWithOptions(ValidatorOptions{
+		Mode:       ModeDev,
+		Network:    "devnet",
+		DevWallets: []string{devWallet},
+		RPC:        NewFakeRPC(),
+	})
+	require.NoError(t, err)
+
+	valid, err := v.VerifySignature(devWallet, "hello", devSig, "hex")
+	require.NoError(t, err)
+	assert.True(t, valid)
+
+	// A dev wallet still needs a real signature
+	valid, err = v.VerifySignature(devWallet, "hello", otherSig, "hex")
+	require.NoError(t, err)
+	assert.False(t, valid)
+
+	valid, err = v.VerifySignature(otherWallet, "hello", otherSig, "hex")
+	require.NoError(t, err)
+	assert.False(t, valid)
+}
+
+func TestAuthDecisionsAreLoggedWithMode(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	v, err := NewSolanaValidatorWithOptions(ValidatorOptions{
+		Mode:    ModeOff,
+		DevMode: true,
+		RPC:     NewFakeRPC(),
+		Logger:  zap.New(core).Sugar(),
+	})
+	require.NoError(t, err)
+
+	valid, err := v.VerifySignature("anything", "hello", "not-a-signature", "hex")
+	require.NoError(t, err)
+	assert.True(t, valid)
+
+	decisions := logs.FilterMessage("[SolanaValidator] Auth decision").All()
+	require.Len(t, decisions, 1)
+	fields := decisions[0].ContextMap()
+	assert.Equal(t, ModeOff, fields["mode"])
+	assert.Equal(t, true, fields["accepted"])
+}
+
+func TestHasMinimumBalance(t *testing.T) {
+	fake := NewFakeRPC()
+	v, err := NewSolanaValidatorWithOptions(ValidatorOptions{RPC: fake})
+	require.NoError(t, err)
+
+	wallet := solana.NewWallet().PublicKey().String()
+	mint := solana.NewWallet().PublicKey().String()
+
+	// A wallet without a token account holds nothing
+	ok, err := v.HasMinimumBalance(context.Background(), wallet, mint, 1)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, fake.SetTokenBalance(wallet, mint, 5000, 6))
+	balance, err := v.TokenBalance(context.Background(), wallet, mint)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(5000), balance)
+
+	ok, err = v.HasMinimumBalance(context.Background(), wallet, mint, 5000)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = v.HasMinimumBalance(context.Background(), wallet, mint, 5001)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	fake.FailWith(errors.New("rpc down"))
+	_, err = v.HasMinimumBalance(context.Background(), wallet, mint, 1)
+	assert.Error(t, err)
+}
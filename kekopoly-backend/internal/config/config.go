@@ -6,12 +6,20 @@ import (
 
 // Config holds all configuration for the application
 type Config struct {
-	Server  ServerConfig  `mapstructure:"server"`
-	MongoDB MongoDBConfig `mapstructure:"mongodb"`
-	Redis   RedisConfig   `mapstructure:"redis"`
-	JWT     JWTConfig     `mapstructure:"jwt"`
-	Game    GameConfig    `mapstructure:"game"`
-	Solana  SolanaConfig  `mapstructure:"solana"`
+	Server      ServerConfig      `mapstructure:"server"`
+	MongoDB     MongoDBConfig     `mapstructure:"mongodb"`
+	Redis       RedisConfig       `mapstructure:"redis"`
+	Queue       QueueConfig       `mapstructure:"queue"`
+	JWT         JWTConfig         `mapstructure:"jwt"`
+	Game        GameConfig        `mapstructure:"game"`
+	Solana      SolanaConfig      `mapstructure:"solana"`
+	Settlement  SettlementConfig  `mapstructure:"settlement"`
+	Cluster     ClusterConfig     `mapstructure:"cluster"`
+	WebSocket   WebSocketConfig   `mapstructure:"websocket"`
+	Chat        ChatConfig        `mapstructure:"chat"`
+	Matchmaking MatchmakingConfig `mapstructure:"matchmaking"`
+	Bots        BotsConfig        `mapstructure:"bots"`
+	Admin       AdminConfig       `mapstructure:"admin"`
 }
 
 // ServerConfig holds server-specific configuration
@@ -31,6 +39,9 @@ type MongoDBConfig struct {
 	PropColl   string `mapstructure:"property_collection"`
 	CardColl   string `mapstructure:"card_collection"`
 	TxColl     string `mapstructure:"transaction_collection"`
+	AuditColl  string `mapstructure:"audit_collection"` // Operator actions through the admin API
+	// AutoMigrate applies pending schema migrations at server startup
+	AutoMigrate bool `mapstructure:"auto_migrate"`
 }
 
 // RedisConfig holds Redis connection configuration
@@ -40,10 +51,21 @@ type RedisConfig struct {
 	DB       int    `mapstructure:"db"`
 }
 
+// QueueConfig holds settings for the Redis Streams message queue
+type QueueConfig struct {
+	Partitions int   `mapstructure:"partitions"` // Streams games are spread over; must be the same on every node
+	MaxLen     int64 `mapstructure:"max_len"`    // Entries kept per stream, roughly, once handled
+	Block      int   `mapstructure:"block"`      // Seconds a worker's read waits for new messages
+	LeaseTTL   int   `mapstructure:"lease_ttl"`  // Seconds before another worker takes over the partitions of one that died
+}
+
 // JWTConfig holds JWT configuration
 type JWTConfig struct {
 	Secret     string `mapstructure:"secret"`
-	Expiration int    `mapstructure:"expiration"` // in hours
+	Expiration int    `mapstructure:"expiration"` // in hours, used when refresh tokens are unavailable
+	// Access tokens are short-lived; clients renew them with a rotating refresh token
+	AccessTokenTTL  int `mapstructure:"access_token_ttl"`  // in minutes
+	RefreshTokenTTL int `mapstructure:"refresh_token_ttl"` // in hours
 }
 
 // GameConfig holds game-specific configuration
@@ -55,6 +77,8 @@ type GameConfig struct {
 	CardDeckSize           int `mapstructure:"card_deck_size"`
 	MinimumPlayersToStart  int `mapstructure:"minimum_players_to_start"`
 	IdleGameExpiryDuration int `mapstructure:"idle_game_expiry"` // in hours
+	InviteTTL              int `mapstructure:"invite_ttl"`       // Seconds a game invite lasts unless the host says otherwise
+	MaxPause               int `mapstructure:"max_pause"`        // Seconds a paused game waits before resuming by itself
 }
 
 // SolanaConfig holds Solana blockchain configuration
@@ -62,6 +86,81 @@ type SolanaConfig struct {
 	RpcURL  string `mapstructure:"rpc_url"`
 	Network string `mapstructure:"network"`
 	DevMode bool   `mapstructure:"dev_mode"`
+	// Signature checking: "strict", "dev" (only DevWallets) or "off" (requires DevMode).
+	// Empty means "off" in dev mode and "strict" otherwise.
+	ValidatorMode string   `mapstructure:"validator_mode"`
+	DevWallets    []string `mapstructure:"dev_wallets"` // Test keypair public keys accepted in "dev" mode
+	// Sign-In-With-Solana challenge settings
+	SignInDomain string `mapstructure:"sign_in_domain"`
+	SignInURI    string `mapstructure:"sign_in_uri"`
+	ChallengeTTL int    `mapstructure:"challenge_ttl"` // in seconds
+}
+
+// SettlementConfig holds KMT escrow and payout configuration
+type SettlementConfig struct {
+	Enabled        bool   `mapstructure:"enabled"`
+	Chain          string `mapstructure:"chain"`          // "solana" or "fake" for offline development
+	EscrowKeypair  string `mapstructure:"escrow_keypair"` // Path to the escrow wallet's keypair file
+	TokenMint      string `mapstructure:"token_mint"`
+	Stake          int    `mapstructure:"stake"`           // Default buy-in in raw token units
+	DepositTimeout int    `mapstructure:"deposit_timeout"` // Seconds a lobby player has to pay the buy-in
+	PayoutShares   []int  `mapstructure:"payout_shares"`   // Percent of the pot by finishing position
+	FeeBps         int    `mapstructure:"fee_bps"`
+	MaxAttempts    int    `mapstructure:"max_attempts"`
+	RetryInterval  int    `mapstructure:"retry_interval"` // in seconds
+}
+
+// ClusterConfig holds settings for running several server nodes behind a load balancer
+type ClusterConfig struct {
+	Enabled        bool   `mapstructure:"enabled"`
+	NodeID         string `mapstructure:"node_id"`         // Empty means a random ID per process
+	PresenceTTL    int    `mapstructure:"presence_ttl"`    // Seconds before an unrefreshed player is treated as gone
+	LeaseTTL       int    `mapstructure:"lease_ttl"`       // Seconds a dead node keeps its games before another node takes over
+	ForwardTimeout int    `mapstructure:"forward_timeout"` // Seconds to wait for a game's owner to answer a forwarded action
+}
+
+// WebSocketConfig holds settings for game WebSocket connections
+type WebSocketConfig struct {
+	EventBufferSize int `mapstructure:"event_buffer_size"` // Recent broadcasts kept per game for clients resuming; 0 disables numbering
+	EventTTL        int `mapstructure:"event_ttl"`         // Seconds a quiet game's broadcasts are kept
+	MaxSpectators   int `mapstructure:"max_spectators"`    // Spectators allowed per game; 0 disables spectating
+	SpectatorDelay  int `mapstructure:"spectator_delay"`   // Seconds spectators see broadcasts after players do
+	LobbyInterval   int `mapstructure:"lobby_interval"`    // Seconds between lobby updates; changes within one are sent together
+	LobbyResync     int `mapstructure:"lobby_resync"`      // Seconds between rebuilding every lobby listing from the database
+}
+
+// ChatConfig holds settings for in-game chat
+type ChatConfig struct {
+	HistorySize  int      `mapstructure:"history_size"`  // Messages kept per game and sent to players who connect
+	HistoryTTL   int      `mapstructure:"history_ttl"`   // Seconds a quiet game's chat is kept
+	MaxLength    int      `mapstructure:"max_length"`    // Longest message in characters
+	RateLimit    int      `mapstructure:"rate_limit"`    // Messages a player may send per rate window; 0 for no limit
+	RateWindow   int      `mapstructure:"rate_window"`   // Seconds
+	BlockedWords []string `mapstructure:"blocked_words"` // Masked with asterisks
+	BlockLinks   bool     `mapstructure:"block_links"`   // Reject messages containing URLs
+}
+
+// MatchmakingConfig holds settings for the quick-match queue
+type MatchmakingConfig struct {
+	Enabled    bool `mapstructure:"enabled"`
+	Interval   int  `mapstructure:"interval"`    // Seconds between matching rounds
+	WidenAfter int  `mapstructure:"widen_after"` // Seconds a player waits before each widening of their preferences
+	BuyInStep  int  `mapstructure:"buy_in_step"` // Percent of the preferred buy-in each widening step allows either way
+	MaxWiden   int  `mapstructure:"max_widen"`   // Widening steps; each also allows one more or fewer player
+	MaxWait    int  `mapstructure:"max_wait"`    // Seconds before an unmatched player leaves the queue
+}
+
+// BotsConfig holds settings for server-side bot players
+type BotsConfig struct {
+	ThinkDelay       int    `mapstructure:"think_delay"`        // Milliseconds a bot pauses before each action
+	TakeOver         bool   `mapstructure:"take_over"`          // Bots play the seats of disconnected players until they return
+	TakeOverAfter    int    `mapstructure:"take_over_after"`    // Seconds a player is disconnected before a bot takes over
+	TakeOverStrategy string `mapstructure:"take_over_strategy"` // random, greedy or heuristic
+}
+
+// AdminConfig holds settings for the operator API
+type AdminConfig struct {
+	UserIDs []string `mapstructure:"user_ids"` // Users whose tokens carry the admin role, for /api/v1/admin
 }
 
 // Load reads configuration from a file or environment variables
@@ -110,15 +209,25 @@ func setDefaults() {
 	viper.SetDefault("mongodb.property_collection", "properties")
 	viper.SetDefault("mongodb.card_collection", "cards")
 	viper.SetDefault("mongodb.transaction_collection", "transactions")
+	viper.SetDefault("mongodb.audit_collection", "admin_audit")
+	viper.SetDefault("mongodb.auto_migrate", true)
 
 	// Redis defaults
 	viper.SetDefault("redis.uri", "localhost:6379")
 	viper.SetDefault("redis.password", "")
 	viper.SetDefault("redis.db", 0)
 
+	// Queue defaults
+	viper.SetDefault("queue.partitions", 16)
+	viper.SetDefault("queue.max_len", 10000)
+	viper.SetDefault("queue.block", 5)
+	viper.SetDefault("queue.lease_ttl", 15)
+
 	// JWT defaults
 	viper.SetDefault("jwt.secret", "replace-with-secure-secret")
 	viper.SetDefault("jwt.expiration", 24)
+	viper.SetDefault("jwt.access_token_ttl", 15)
+	viper.SetDefault("jwt.refresh_token_ttl", 168) // 7 days
 
 	// Game defaults
 	viper.SetDefault("game.disconnection_timeout", 180) // 3 minutes
@@ -128,9 +237,67 @@ func setDefaults() {
 	viper.SetDefault("game.card_deck_size", 16)
 	viper.SetDefault("game.minimum_players_to_start", 2)
 	viper.SetDefault("game.idle_game_expiry", 24)
+	viper.SetDefault("game.invite_ttl", 86400)
+	viper.SetDefault("game.max_pause", 600) // 10 minutes
 
 	// Solana defaults
 	viper.SetDefault("solana.rpc_url", "") // Empty means use the default mainnet
 	viper.SetDefault("solana.network", "mainnet")
 	viper.SetDefault("solana.dev_mode", false) // Default to dev mode for easier development
+	viper.SetDefault("solana.validator_mode", "")
+	viper.SetDefault("solana.dev_wallets", []string{})
+	viper.SetDefault("solana.sign_in_domain", "localhost:5173")
+	viper.SetDefault("solana.sign_in_uri", "http://localhost:5173")
+	viper.SetDefault("solana.challenge_ttl", 300)
+
+	// Settlement defaults
+	viper.SetDefault("settlement.enabled", false)
+	viper.SetDefault("settlement.chain", "solana")
+	viper.SetDefault("settlement.stake", 0)
+	viper.SetDefault("settlement.deposit_timeout", 600)
+	viper.SetDefault("settlement.payout_shares", []int{100}) // Winner takes all
+	viper.SetDefault("settlement.fee_bps", 0)
+	viper.SetDefault("settlement.max_attempts", 5)
+	viper.SetDefault("settlement.retry_interval", 30)
+
+	// Cluster defaults
+	viper.SetDefault("cluster.enabled", false)
+	viper.SetDefault("cluster.node_id", "")
+	viper.SetDefault("cluster.presence_ttl", 90)
+	viper.SetDefault("cluster.lease_ttl", 15)
+	viper.SetDefault("cluster.forward_timeout", 5)
+
+	// WebSocket defaults
+	viper.SetDefault("websocket.event_buffer_size", 500)
+	viper.SetDefault("websocket.event_ttl", 3600)
+	viper.SetDefault("websocket.max_spectators", 20)
+	viper.SetDefault("websocket.spectator_delay", 5)
+	viper.SetDefault("websocket.lobby_interval", 1)
+	viper.SetDefault("websocket.lobby_resync", 60)
+
+	// Chat defaults
+	viper.SetDefault("chat.history_size", 100)
+	viper.SetDefault("chat.history_ttl", 86400)
+	viper.SetDefault("chat.max_length", 500)
+	viper.SetDefault("chat.rate_limit", 5)
+	viper.SetDefault("chat.rate_window", 10)
+	viper.SetDefault("chat.blocked_words", []string{})
+	viper.SetDefault("chat.block_links", true)
+
+	// Matchmaking defaults
+	viper.SetDefault("matchmaking.enabled", true)
+	viper.SetDefault("matchmaking.interval", 2)
+	viper.SetDefault("matchmaking.widen_after", 15)
+	viper.SetDefault("matchmaking.buy_in_step", 10)
+	viper.SetDefault("matchmaking.max_widen", 4)
+	viper.SetDefault("matchmaking.max_wait", 600)
+
+	// Bot defaults
+	viper.SetDefault("bots.think_delay", 1000)
+	viper.SetDefault("bots.take_over", false)
+	viper.SetDefault("bots.take_over_after", 45)
+	viper.SetDefault("bots.take_over_strategy", "heuristic")
+
+	// Admin defaults: nobody until operators are listed
+	viper.SetDefault("admin.user_ids", []string{})
 }
@@ -0,0 +1,37 @@
+package migrations
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/kekopoly/backend/internal/db/mongodb"
+	"github.com/kekopoly/backend/internal/game/models"
+)
+
+// All returns every known migration. Append new migrations with the next
+// version number; never renumber or edit one that has shipped.
+func All() []Migration {
+	return []Migration{
+		{
+			Version:     1,
+			Description: "backfill player jail fields",
+			Up: chain(
+				backfillPlayerField(mongodb.GamesCollection, "inJail", false),
+				backfillPlayerField(mongodb.GamesCollection, "jailTurns", 0),
+			),
+		},
+		{
+			Version:     2,
+			Description: "backfill market condition and settlement status",
+			Up: chain(
+				backfillField(mongodb.GamesCollection, "marketCondition", models.MarketConditionNormal),
+				backfillField(mongodb.GamesCollection, "marketConditionRemainingTurns", 0),
+				// Games that ended before settlement existed are never settled
+				backfillFieldWhere(mongodb.GamesCollection, "settlementStatus", endedGames, models.SettlementStatusSkipped),
+				backfillField(mongodb.GamesCollection, "settlementStatus", models.SettlementStatusPending),
+			),
+		},
+	}
+}
+
+// endedGames matches games that are over
+var endedGames = bson.M{"status": bson.M{"$in": bson.A{models.GameStatusCompleted, models.GameStatusAbandoned}}}
@@ -0,0 +1,285 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// CollectionName is the collection recording applied migrations
+const CollectionName = "migrations"
+
+// Migration statuses stored in the migrations collection
+const (
+	StatusRunning = "running"
+	StatusApplied = "applied"
+)
+
+// Migration is a single versioned change to stored data
+type Migration struct {
+	Version     int
+	Description string
+	// Up applies the migration. When dryRun is true it must not modify any data
+	// and should return the number of documents it would change.
+	Up func(ctx context.Context, db *mongo.Database, dryRun bool) (int64, error)
+}
+
+// Record is the document stored in the migrations collection for each migration
+type Record struct {
+	Version     int       `bson:"_id" json:"version"`
+	Description string    `bson:"description" json:"description"`
+	Status      string    `bson:"status" json:"status"`
+	StartedAt   time.Time `bson:"startedAt" json:"startedAt"`
+	AppliedAt   time.Time `bson:"appliedAt,omitempty" json:"appliedAt,omitempty"`
+	Affected    int64     `bson:"affected" json:"affected"`
+}
+
+// Result describes the outcome of running one migration
+type Result struct {
+	Version     int
+	Description string
+	Affected    int64
+	DryRun      bool
+}
+
+// ErrMigrationInProgress is returned when another process holds a migration
+var ErrMigrationInProgress = errors.New("migration is already being applied by another process")
+
+// Runner applies pending migrations in version order
+type Runner struct {
+	db         *mongo.Database
+	migrations []Migration
+	logger     *zap.SugaredLogger
+}
+
+// NewRunner creates a runner for the given migrations, defaulting to All()
+func NewRunner(db *mongo.Database, logger *zap.SugaredLogger, migrations ...Migration) (*Runner, error) {
+	if len(migrations) == 0 {
+		migrations = All()
+	}
+
+	sorted, err := sortAndValidate(migrations)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Runner{
+		db:         db,
+		migrations: sorted,
+		logger:     logger,
+	}, nil
+}
+
+// Applied returns the recorded migrations keyed by version
+func (r *Runner) Applied(ctx context.Context) (map[int]Record, error) {
+	cursor, err := r.db.Collection(CollectionName).Find(ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations collection: %w", err)
+	}
+
+	var records []Record
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, fmt.Errorf("failed to decode migration records: %w", err)
+	}
+
+	applied := make(map[int]Record, len(records))
+	for _, rec := range records {
+		applied[rec.Version] = rec
+	}
+	return applied, nil
+}
+
+// Pending returns migrations that have not been applied yet, in order
+func (r *Runner) Pending(ctx context.Context) ([]Migration, error) {
+	applied, err := r.Applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return pendingMigrations(r.migrations, applied)
+}
+
+// Run applies all pending migrations in order. With dryRun set nothing is written
+// and each result reports how many documents would change.
+func (r *Runner) Run(ctx context.Context, dryRun bool) ([]Result, error) {
+	pending, err := r.Pending(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pending) == 0 {
+		r.logger.Info("[Migrations] No pending migrations")
+		return nil, nil
+	}
+
+	coll := r.db.Collection(CollectionName)
+	var results []Result
+
+	for _, m := range pending {
+		if dryRun {
+			affected, err := m.Up(ctx, r.db, true)
+			if err != nil {
+				return results, fmt.Errorf("dry run of migration %d failed: %w", m.Version, err)
+			}
+			r.logger.Infof("[Migrations] (dry run) %04d %s: would change %d documents", m.Version, m.Description, affected)
+			results = append(results, Result{Version: m.Version, Description: m.Description, Affected: affected, DryRun: true})
+			continue
+		}
+
+		// Claim the migration; the _id doubles as a lock so concurrent nodes
+		// starting at the same time don't apply it twice
+		record := Record{
+			Version:     m.Version,
+			Description: m.Description,
+			Status:      StatusRunning,
+			StartedAt:   time.Now(),
+		}
+		if _, err := coll.InsertOne(ctx, record); err != nil {
+			if mongo.IsDuplicateKeyError(err) {
+				return results, fmt.Errorf("migration %d: %w", m.Version, ErrMigrationInProgress)
+			}
+			return results, fmt.Errorf("failed to record migration %d: %w", m.Version, err)
+		}
+
+		r.logger.Infof("[Migrations] Applying %04d %s", m.Version, m.Description)
+		affected, err := m.Up(ctx, r.db, false)
+		if err != nil {
+			// Release the claim so the migration is retried on the next run
+			if _, delErr := coll.DeleteOne(ctx, bson.M{"_id": m.Version}); delErr != nil {
+				r.logger.Errorf("[Migrations] Failed to release claim for %04d: %v", m.Version, delErr)
+			}
+			return results, fmt.Errorf("migration %d failed: %w", m.Version, err)
+		}
+
+		_, err = coll.UpdateOne(ctx, bson.M{"_id": m.Version}, bson.M{"$set": bson.M{
+			"status":    StatusApplied,
+			"appliedAt": time.Now(),
+			"affected":  affected,
+		}})
+		if err != nil {
+			return results, fmt.Errorf("failed to mark migration %d as applied: %w", m.Version, err)
+		}
+
+		r.logger.Infof("[Migrations] Applied %04d %s: changed %d documents", m.Version, m.Description, affected)
+		results = append(results, Result{Version: m.Version, Description: m.Description, Affected: affected})
+	}
+
+	return results, nil
+}
+
+// pendingMigrations returns the migrations from the first one not yet applied onward,
+// so migrations are only ever applied in version order. A migration left in the
+// running state is either being applied by another process or was interrupted by a
+// crash; nothing after it runs until it is finished or its record is removed by hand.
+func pendingMigrations(migrations []Migration, applied map[int]Record) ([]Migration, error) {
+	for i, m := range migrations {
+		rec, ok := applied[m.Version]
+		if ok && rec.Status == StatusApplied {
+			continue
+		}
+		if ok && rec.Status == StatusRunning {
+			return nil, fmt.Errorf("migration %d was started at %s and has not finished: %w",
+				m.Version, rec.StartedAt.Format(time.RFC3339), ErrMigrationInProgress)
+		}
+
+		pending := migrations[i:]
+		for _, later := range pending[1:] {
+			if _, ok := applied[later.Version]; ok {
+				return nil, fmt.Errorf("migration %d is recorded but earlier migration %d is not", later.Version, m.Version)
+			}
+		}
+		return pending, nil
+	}
+	return nil, nil
+}
+
+// sortAndValidate orders migrations by version and rejects duplicates or invalid entries
+func sortAndValidate(migrations []Migration) ([]Migration, error) {
+	sorted := make([]Migration, len(migrations))
+	copy(sorted, migrations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+
+	for i, m := range sorted {
+		if m.Version <= 0 {
+			return nil, fmt.Errorf("migration %q has invalid version %d", m.Description, m.Version)
+		}
+		if m.Up == nil {
+			return nil, fmt.Errorf("migration %d has no Up function", m.Version)
+		}
+		if i > 0 && sorted[i-1].Version == m.Version {
+			return nil, fmt.Errorf("duplicate migration version %d", m.Version)
+		}
+	}
+
+	return sorted, nil
+}
+
+// backfillPlayerField sets a field on every embedded player in the collection that lacks it
+func backfillPlayerField(collection, field string, value interface{}) func(ctx context.Context, db *mongo.Database, dryRun bool) (int64, error) {
+	return func(ctx context.Context, db *mongo.Database, dryRun bool) (int64, error) {
+		coll := db.Collection(collection)
+		filter := bson.M{"players": bson.M{"$elemMatch": bson.M{field: bson.M{"$exists": false}}}}
+
+		if dryRun {
+			return coll.CountDocuments(ctx, filter)
+		}
+
+		update := bson.M{"$set": bson.M{"players.$[p]." + field: value}}
+		opts := options.Update().SetArrayFilters(options.ArrayFilters{
+			Filters: []interface{}{bson.M{"p." + field: bson.M{"$exists": false}}},
+		})
+
+		result, err := coll.UpdateMany(ctx, filter, update, opts)
+		if err != nil {
+			return 0, fmt.Errorf("failed to backfill players.%s: %w", field, err)
+		}
+		return result.ModifiedCount, nil
+	}
+}
+
+// backfillField sets a top-level field on every document in the collection that lacks it
+func backfillField(collection, field string, value interface{}) func(ctx context.Context, db *mongo.Database, dryRun bool) (int64, error) {
+	return backfillFieldWhere(collection, field, bson.M{}, value)
+}
+
+// backfillFieldWhere sets a top-level field on the documents matching where that lack it
+func backfillFieldWhere(collection, field string, where bson.M, value interface{}) func(ctx context.Context, db *mongo.Database, dryRun bool) (int64, error) {
+	return func(ctx context.Context, db *mongo.Database, dryRun bool) (int64, error) {
+		coll := db.Collection(collection)
+		filter := bson.M{field: bson.M{"$exists": false}}
+		for key, condition := range where {
+			filter[key] = condition
+		}
+
+		if dryRun {
+			return coll.CountDocuments(ctx, filter)
+		}
+
+		result, err := coll.UpdateMany(ctx, filter, bson.M{"$set": bson.M{field: value}})
+		if err != nil {
+			return 0, fmt.Errorf("failed to backfill %s: %w", field, err)
+		}
+		return result.ModifiedCount, nil
+	}
+}
+
+// chain runs several migration steps as one, summing affected counts
+func chain(steps ...func(ctx context.Context, db *mongo.Database, dryRun bool) (int64, error)) func(ctx context.Context, db *mongo.Database, dryRun bool) (int64, error) {
+	return func(ctx context.Context, db *mongo.Database, dryRun bool) (int64, error) {
+		var total int64
+		for _, step := range steps {
+			n, err := step(ctx, db, dryRun)
+			if err != nil {
+				return total, err
+			}
+			total += n
+		}
+		return total, nil
+	}
+}
@@ -0,0 +1,77 @@
+package migrations
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func noop(ctx context.Context, db *mongo.Database, dryRun bool) (int64, error) { return 0, nil }
+
+func TestSortAndValidateOrdersByVersion(t *testing.T) {
+	sorted, err := sortAndValidate([]Migration{
+		{Version: 3, Description: "c", Up: noop},
+		{Version: 1, Description: "a", Up: noop},
+		{Version: 2, Description: "b", Up: noop},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []int{1, 2, 3}, []int{sorted[0].Version, sorted[1].Version, sorted[2].Version})
+}
+
+func TestSortAndValidateRejectsInvalidSets(t *testing.T) {
+	_, err := sortAndValidate([]Migration{{Version: 1, Up: noop}, {Version: 1, Up: noop}})
+	assert.Error(t, err, "duplicate versions")
+
+	_, err = sortAndValidate([]Migration{{Version: 0, Up: noop}})
+	assert.Error(t, err, "non-positive version")
+
+	_, err = sortAndValidate([]Migration{{Version: 1}})
+	assert.Error(t, err, "missing Up")
+}
+
+func TestPendingMigrationsSkipsApplied(t *testing.T) {
+	all := []Migration{{Version: 1, Up: noop}, {Version: 2, Up: noop}, {Version: 3, Up: noop}}
+	applied := map[int]Record{
+		1: {Version: 1, Status: StatusApplied},
+	}
+
+	pending, err := pendingMigrations(all, applied)
+	require.NoError(t, err)
+
+	require.Len(t, pending, 2)
+	assert.Equal(t, 2, pending[0].Version)
+	assert.Equal(t, 3, pending[1].Version)
+}
+
+func TestPendingMigrationsStopsAtRunning(t *testing.T) {
+	all := []Migration{{Version: 1, Up: noop}, {Version: 2, Up: noop}, {Version: 3, Up: noop}}
+	applied := map[int]Record{
+		1: {Version: 1, Status: StatusApplied},
+		2: {Version: 2, Status: StatusRunning},
+	}
+
+	// Migration 3 must not run on top of a half-applied migration 2
+	pending, err := pendingMigrations(all, applied)
+	assert.ErrorIs(t, err, ErrMigrationInProgress)
+	assert.Empty(t, pending)
+}
+
+func TestPendingMigrationsRejectsGaps(t *testing.T) {
+	all := []Migration{{Version: 1, Up: noop}, {Version: 2, Up: noop}, {Version: 3, Up: noop}}
+	applied := map[int]Record{
+		1: {Version: 1, Status: StatusApplied},
+		3: {Version: 3, Status: StatusApplied},
+	}
+
+	_, err := pendingMigrations(all, applied)
+	assert.Error(t, err)
+}
+
+func TestAllMigrationsAreValid(t *testing.T) {
+	_, err := sortAndValidate(All())
+	assert.NoError(t, err)
+}
@@ -0,0 +1,302 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+const (
+	// GamesCollection is the collection holding game documents
+	GamesCollection = "games"
+	// TransactionsCollection is the collection holding game transactions
+	TransactionsCollection = "transactions"
+)
+
+// IndexSpec describes an index the application expects to exist
+type IndexSpec struct {
+	Collection string
+	Name       string
+	Keys       bson.D
+	Unique     bool
+}
+
+// IndexDrift describes a difference between expected and existing indexes
+type IndexDrift struct {
+	Collection string
+	Name       string
+	Kind       string // "missing", "mismatched" or "unexpected"
+	Detail     string
+}
+
+// Index drift kinds
+const (
+	DriftMissing    = "missing"
+	DriftMismatched = "mismatched"
+	DriftUnexpected = "unexpected"
+)
+
+// ExpectedIndexes returns the indexes the application relies on
+func ExpectedIndexes() []IndexSpec {
+	return []IndexSpec{
+		{
+			Collection: GamesCollection,
+			Name:       "code_unique",
+			Keys:       bson.D{{Key: "code", Value: 1}},
+			Unique:     true,
+		},
+		{
+			Collection: GamesCollection,
+			Name:       "status_lastActivity",
+			Keys:       bson.D{{Key: "status", Value: 1}, {Key: "lastActivity", Value: -1}},
+		},
+		{
+			Collection: GamesCollection,
+			Name:       "players_playerId",
+			Keys:       bson.D{{Key: "players.playerId", Value: 1}},
+		},
+		{
+			Collection: TransactionsCollection,
+			Name:       "gameId_timestamp",
+			Keys:       bson.D{{Key: "gameId", Value: 1}, {Key: "timestamp", Value: 1}},
+		},
+	}
+}
+
+// existingIndex is the subset of listIndexes output used for drift detection
+type existingIndex struct {
+	Name   string `bson:"name"`
+	Key    bson.D `bson:"key"`
+	Unique bool   `bson:"unique"`
+}
+
+// CreateIndexes creates indexes and the games schema validator, logging any index drift
+// Uses variadic logger parameter for backward compatibility
+func CreateIndexes(ctx context.Context, client *mongo.Client, dbName string, logger ...*zap.SugaredLogger) error {
+	var log *zap.SugaredLogger
+	if len(logger) > 0 && logger[0] != nil {
+		log = logger[0]
+	} else {
+		log = zap.NewNop().Sugar()
+	}
+
+	db := client.Database(dbName)
+
+	if err := ApplyGameValidator(ctx, db); err != nil {
+		return err
+	}
+
+	drift, err := IndexDriftReport(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	// Create only the missing indexes; mismatched ones need a manual decision
+	// since dropping a unique index on a live collection is not something to do silently
+	missing := make(map[string]bool)
+	for _, d := range drift {
+		if d.Kind == DriftMissing {
+			missing[d.Collection+"."+d.Name] = true
+		}
+	}
+
+	for _, spec := range ExpectedIndexes() {
+		if !missing[spec.Collection+"."+spec.Name] {
+			continue
+		}
+
+		model := mongo.IndexModel{
+			Keys:    spec.Keys,
+			Options: options.Index().SetName(spec.Name).SetUnique(spec.Unique),
+		}
+		if _, err := db.Collection(spec.Collection).Indexes().CreateOne(ctx, model); err != nil {
+			return fmt.Errorf("failed to create index %s on %s: %w", spec.Name, spec.Collection, err)
+		}
+		log.Infow("Created index", "collection", spec.Collection, "index", spec.Name)
+	}
+
+	if len(drift) == 0 {
+		log.Info("MongoDB indexes are up to date")
+		return nil
+	}
+
+	for _, d := range drift {
+		switch d.Kind {
+		case DriftMissing:
+			// Already created above
+		case DriftMismatched:
+			log.Warnw("Index drift detected", "collection", d.Collection, "index", d.Name, "kind", d.Kind, "detail", d.Detail)
+		default:
+			log.Infow("Index drift detected", "collection", d.Collection, "index", d.Name, "kind", d.Kind, "detail", d.Detail)
+		}
+	}
+
+	return nil
+}
+
+// IndexDriftReport compares the expected indexes against those present in the database
+func IndexDriftReport(ctx context.Context, db *mongo.Database) ([]IndexDrift, error) {
+	existing := make(map[string][]existingIndex)
+
+	for _, collName := range expectedCollections() {
+		cursor, err := db.Collection(collName).Indexes().List(ctx)
+		if err != nil {
+			// listIndexes fails with NamespaceNotFound for collections that don't exist yet
+			if isNamespaceNotFound(err) {
+				existing[collName] = nil
+				continue
+			}
+			return nil, fmt.Errorf("failed to list indexes for %s: %w", collName, err)
+		}
+
+		var indexes []existingIndex
+		if err := cursor.All(ctx, &indexes); err != nil {
+			return nil, fmt.Errorf("failed to decode indexes for %s: %w", collName, err)
+		}
+		existing[collName] = indexes
+	}
+
+	return diffIndexes(ExpectedIndexes(), existing), nil
+}
+
+// diffIndexes computes the drift between expected and existing indexes per collection
+func diffIndexes(expected []IndexSpec, existing map[string][]existingIndex) []IndexDrift {
+	var drift []IndexDrift
+	expectedNames := make(map[string]bool)
+
+	for _, spec := range expected {
+		expectedNames[spec.Collection+"."+spec.Name] = true
+
+		var found *existingIndex
+		for i := range existing[spec.Collection] {
+			if existing[spec.Collection][i].Name == spec.Name {
+				found = &existing[spec.Collection][i]
+				break
+			}
+		}
+
+		if found == nil {
+			// An index with the same keys under another name satisfies the query
+			// plan but would make CreateOne fail, so report it instead
+			for i := range existing[spec.Collection] {
+				if keysEqual(spec.Keys, existing[spec.Collection][i].Key) {
+					other := existing[spec.Collection][i]
+					expectedNames[spec.Collection+"."+other.Name] = true
+					drift = append(drift, IndexDrift{
+						Collection: spec.Collection,
+						Name:       spec.Name,
+						Kind:       DriftMismatched,
+						Detail:     fmt.Sprintf("keys %s exist under name %q unique=%t", formatKeys(other.Key), other.Name, other.Unique),
+					})
+					found = &other
+					break
+				}
+			}
+			if found != nil {
+				continue
+			}
+
+			drift = append(drift, IndexDrift{
+				Collection: spec.Collection,
+				Name:       spec.Name,
+				Kind:       DriftMissing,
+				Detail:     fmt.Sprintf("keys %s", formatKeys(spec.Keys)),
+			})
+			continue
+		}
+
+		if !keysEqual(spec.Keys, found.Key) || spec.Unique != found.Unique {
+			drift = append(drift, IndexDrift{
+				Collection: spec.Collection,
+				Name:       spec.Name,
+				Kind:       DriftMismatched,
+				Detail: fmt.Sprintf("expected keys %s unique=%t, found keys %s unique=%t",
+					formatKeys(spec.Keys), spec.Unique, formatKeys(found.Key), found.Unique),
+			})
+		}
+	}
+
+	for collName, indexes := range existing {
+		for _, idx := range indexes {
+			if idx.Name == "_id_" || expectedNames[collName+"."+idx.Name] {
+				continue
+			}
+			drift = append(drift, IndexDrift{
+				Collection: collName,
+				Name:       idx.Name,
+				Kind:       DriftUnexpected,
+				Detail:     fmt.Sprintf("keys %s", formatKeys(idx.Key)),
+			})
+		}
+	}
+
+	return drift
+}
+
+// expectedCollections returns the distinct collections referenced by ExpectedIndexes
+func expectedCollections() []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, spec := range ExpectedIndexes() {
+		if !seen[spec.Collection] {
+			seen[spec.Collection] = true
+			names = append(names, spec.Collection)
+		}
+	}
+	return names
+}
+
+// keysEqual compares two index key documents, ignoring numeric type differences
+func keysEqual(a, b bson.D) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Key != b[i].Key || fmt.Sprint(normalizeKeyValue(a[i].Value)) != fmt.Sprint(normalizeKeyValue(b[i].Value)) {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeKeyValue converts numeric index directions to a common type
+func normalizeKeyValue(v interface{}) interface{} {
+	switch n := v.(type) {
+	case int:
+		return int64(n)
+	case int32:
+		return int64(n)
+	case int64:
+		return n
+	case float64:
+		return int64(n)
+	default:
+		return v
+	}
+}
+
+// formatKeys renders index keys for log output
+func formatKeys(keys bson.D) string {
+	s := "{"
+	for i, k := range keys {
+		if i > 0 {
+			s += ", "
+		}
+		s += fmt.Sprintf("%s: %v", k.Key, normalizeKeyValue(k.Value))
+	}
+	return s + "}"
+}
+
+// isNamespaceNotFound reports whether err is MongoDB's NamespaceNotFound (code 26)
+func isNamespaceNotFound(err error) bool {
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) {
+		return cmdErr.Code == 26
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package mongodb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestDiffIndexesReportsMissingMismatchedAndUnexpected(t *testing.T) {
+	expected := []IndexSpec{
+		{Collection: "games", Name: "code_unique", Keys: bson.D{{Key: "code", Value: 1}}, Unique: true},
+		{Collection: "games", Name: "status_lastActivity", Keys: bson.D{{Key: "status", Value: 1}, {Key: "lastActivity", Value: -1}}},
+		{Collection: "transactions", Name: "gameId_timestamp", Keys: bson.D{{Key: "gameId", Value: 1}, {Key: "timestamp", Value: 1}}},
+	}
+
+	existing := map[string][]existingIndex{
+		"games": {
+			{Name: "_id_", Key: bson.D{{Key: "_id", Value: int32(1)}}},
+			// Same keys, but not unique
+			{Name: "code_unique", Key: bson.D{{Key: "code", Value: int32(1)}}},
+			{Name: "name_1", Key: bson.D{{Key: "name", Value: int32(1)}}},
+		},
+		"transactions": nil,
+	}
+
+	drift := diffIndexes(expected, existing)
+
+	kinds := make(map[string]string)
+	for _, d := range drift {
+		kinds[d.Collection+"."+d.Name] = d.Kind
+	}
+
+	assert.Equal(t, DriftMismatched, kinds["games.code_unique"])
+	assert.Equal(t, DriftMissing, kinds["games.status_lastActivity"])
+	assert.Equal(t, DriftMissing, kinds["transactions.gameId_timestamp"])
+	assert.Equal(t, DriftUnexpected, kinds["games.name_1"])
+	assert.NotContains(t, kinds, "games._id_")
+	assert.Len(t, drift, 4)
+}
+
+func TestDiffIndexesMatchesKeysUnderDifferentName(t *testing.T) {
+	expected := []IndexSpec{
+		{Collection: "games", Name: "code_unique", Keys: bson.D{{Key: "code", Value: 1}}, Unique: true},
+	}
+	existing := map[string][]existingIndex{
+		"games": {{Name: "code_1", Key: bson.D{{Key: "code", Value: float64(1)}}, Unique: true}},
+	}
+
+	drift := diffIndexes(expected, existing)
+
+	// The renamed index is reported once as mismatched, not also as unexpected
+	assert.Len(t, drift, 1)
+	assert.Equal(t, DriftMismatched, drift[0].Kind)
+	assert.Equal(t, "code_unique", drift[0].Name)
+}
+
+func TestDiffIndexesNoDrift(t *testing.T) {
+	var existing = map[string][]existingIndex{}
+	for _, spec := range ExpectedIndexes() {
+		existing[spec.Collection] = append(existing[spec.Collection], existingIndex{
+			Name:   spec.Name,
+			Key:    spec.Keys,
+			Unique: spec.Unique,
+		})
+	}
+
+	assert.Empty(t, diffIndexes(ExpectedIndexes(), existing))
+}
@@ -241,10 +241,3 @@ func CreateClient(ctx context.Context, uri string, logger *zap.SugaredLogger) (*
 func GetCollection(client *mongo.Client, dbName, collName string) *mongo.Collection {
 	return client.Database(dbName).Collection(collName)
 }
-
-// CreateIndexes creates indexes for the collections
-func CreateIndexes(ctx context.Context, client *mongo.Client, dbName string) error {
-	// This function can be expanded to create indexes for different collections
-	// For now, it's a placeholder for future index creation
-	return nil
-}
@@ -0,0 +1,140 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Integer fields may be stored as int32 or int64 depending on their magnitude
+var bsonInt = bson.A{"int", "long"}
+
+// GameSchema returns the $jsonSchema validator for the games collection.
+// It mirrors models.Game; keep both in sync when adding fields.
+func GameSchema() bson.M {
+	playerSchema := bson.M{
+		"bsonType": "object",
+		"required": bson.A{"playerId", "balance", "position", "status"},
+		"properties": bson.M{
+			"playerId":                bson.M{"bsonType": "string"},
+			"userId":                  bson.M{"bsonType": "string"},
+			"walletAddress":           bson.M{"bsonType": "string"},
+			"walletSignature":         bson.M{"bsonType": "string"},
+			"characterToken":          bson.M{"bsonType": "string"},
+			"position":                bson.M{"bsonType": bsonInt, "minimum": 0},
+			"balance":                 bson.M{"bsonType": bsonInt},
+			"cards":                   bson.M{"bsonType": bson.A{"array", "null"}},
+			"shadowbanned":            bson.M{"bsonType": "bool"},
+			"shadowbanRemainingTurns": bson.M{"bsonType": bsonInt},
+			"status": bson.M{
+				"enum": bson.A{"ACTIVE", "DISCONNECTED", "BANKRUPT", "FORFEITED"},
+			},
+			"disconnectedAt": bson.M{"bsonType": "date"},
+			"properties":     bson.M{"bsonType": bson.A{"array", "null"}},
+			"initialDeposit": bson.M{"bsonType": bsonInt},
+			"depositStatus": bson.M{
+				"enum": bson.A{"UNPAID", "PARTIAL", "VERIFIED"},
+			},
+			"depositedAmount": bson.M{"bsonType": bsonInt, "minimum": 0},
+			"depositTxIds":    bson.M{"bsonType": bson.A{"array", "null"}, "items": bson.M{"bsonType": "string"}},
+			"depositDeadline": bson.M{"bsonType": "date"},
+			"netWorth":        bson.M{"bsonType": bsonInt},
+			"inJail":          bson.M{"bsonType": "bool"},
+			"jailTurns":       bson.M{"bsonType": bsonInt, "minimum": 0},
+			"bot":             bson.M{"bsonType": "string"},
+			"substitute":      bson.M{"bsonType": "bool"},
+		},
+	}
+
+	return bson.M{
+		"bsonType": "object",
+		"required": bson.A{"code", "status", "players", "hostId", "maxPlayers", "createdAt"},
+		"properties": bson.M{
+			"code": bson.M{"bsonType": "string", "pattern": "^[A-Z0-9]{6}$"},
+			"name": bson.M{"bsonType": "string"},
+			"status": bson.M{
+				"enum": bson.A{"LOBBY", "ACTIVE", "PAUSED", "COMPLETED", "ABANDONED"},
+			},
+			"createdAt":   bson.M{"bsonType": "date"},
+			"updatedAt":   bson.M{"bsonType": "date"},
+			"players":     bson.M{"bsonType": bson.A{"array", "null"}, "items": playerSchema},
+			"hostId":      bson.M{"bsonType": "string"},
+			"maxPlayers":  bson.M{"bsonType": bsonInt, "minimum": 0},
+			"currentTurn": bson.M{"bsonType": "string"},
+			"turnOrder":   bson.M{"bsonType": bson.A{"array", "null"}, "items": bson.M{"bsonType": "string"}},
+			"boardState": bson.M{
+				"bsonType": "object",
+				"properties": bson.M{
+					"properties":     bson.M{"bsonType": bson.A{"array", "null"}},
+					"cardsRemaining": bson.M{"bsonType": "object"},
+				},
+			},
+			"lastActivity": bson.M{"bsonType": "date"},
+			"marketCondition": bson.M{
+				"enum": bson.A{"", "NORMAL", "BULL", "CRASH"},
+			},
+			"marketConditionRemainingTurns": bson.M{"bsonType": bsonInt},
+			"winnerId":                      bson.M{"bsonType": "string"},
+			"settlementStatus": bson.M{
+				"enum": bson.A{"", "PENDING", "IN_PROGRESS", "COMPLETED", "FAILED", "SKIPPED"},
+			},
+			"buyIn": bson.M{"bsonType": bsonInt, "minimum": 0},
+			"mode":  bson.M{"bsonType": "string"},
+			"visibility": bson.M{
+				"enum": bson.A{"", "PUBLIC", "UNLISTED", "PRIVATE"},
+			},
+			"passwordHash":  bson.M{"bsonType": "string"},
+			"locked":        bson.M{"bsonType": "bool"},
+			"bannedPlayers": bson.M{"bsonType": bson.A{"array", "null"}, "items": bson.M{"bsonType": "string"}},
+			"pauseVotes":    bson.M{"bsonType": bson.A{"array", "null"}, "items": bson.M{"bsonType": "string"}},
+			"pause": bson.M{
+				"bsonType": bson.A{"object", "null"},
+				"required": bson.A{"pausedBy", "pausedAt", "resumesAt"},
+				"properties": bson.M{
+					"pausedBy":    bson.M{"bsonType": "string"},
+					"pausedAt":    bson.M{"bsonType": "date"},
+					"resumesAt":   bson.M{"bsonType": "date"},
+					"resumeVotes": bson.M{"bsonType": bson.A{"array", "null"}, "items": bson.M{"bsonType": "string"}},
+				},
+			},
+		},
+	}
+}
+
+// ApplyGameValidator creates the games collection with its validator, or updates
+// the validator on an existing collection. Validation is "moderate" so documents
+// written before the validator existed can still be updated.
+func ApplyGameValidator(ctx context.Context, db *mongo.Database) error {
+	validator := bson.M{"$jsonSchema": GameSchema()}
+
+	names, err := db.ListCollectionNames(ctx, bson.M{"name": GamesCollection})
+	if err != nil {
+		return fmt.Errorf("failed to list collections: %w", err)
+	}
+
+	if len(names) == 0 {
+		opts := options.CreateCollection().
+			SetValidator(validator).
+			SetValidationLevel("moderate").
+			SetValidationAction("error")
+		if err := db.CreateCollection(ctx, GamesCollection, opts); err != nil {
+			return fmt.Errorf("failed to create games collection with validator: %w", err)
+		}
+		return nil
+	}
+
+	cmd := bson.D{
+		{Key: "collMod", Value: GamesCollection},
+		{Key: "validator", Value: validator},
+		{Key: "validationLevel", Value: "moderate"},
+		{Key: "validationAction", Value: "error"},
+	}
+	if err := db.RunCommand(ctx, cmd).Err(); err != nil {
+		return fmt.Errorf("failed to update games validator: %w", err)
+	}
+
+	return nil
+}
@@ -276,7 +276,8 @@ func Publish(ctx context.Context, client *redis.Client, channel string, message
 	return client.Publish(ctx, channel, message).Err()
 }
 
-// Subscribe creates a Redis subscription to a channel
-func Subscribe(ctx context.Context, client *redis.Client, channel string) *redis.PubSub {
-	return client.Subscribe(ctx, channel)
+// Subscribe creates a Redis subscription to the channels. Channels can be added
+// later with PubSub.Subscribe.
+func Subscribe(ctx context.Context, client *redis.Client, channels ...string) *redis.PubSub {
+	return client.Subscribe(ctx, channels...)
 }
@@ -0,0 +1,274 @@
+// Package bots decides what server-side bot players do on their turn.
+//
+// A Strategy only chooses purchases and builds. TakeTurn wraps it with the moves every
+// player must make: paying rent that is due and, last of all, rolling the dice, which
+// ends the turn. The game manager plays the actions through ProcessGameAction, the
+// same way a human's actions arrive.
+package bots
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kekopoly/backend/internal/game/models"
+)
+
+// Strategy names
+const (
+	StrategyRandom    = "random"
+	StrategyGreedy    = "greedy"
+	StrategyHeuristic = "heuristic"
+)
+
+// ErrUnknownStrategy is returned for a strategy name New doesn't know
+var ErrUnknownStrategy = errors.New("unknown bot strategy")
+
+// Strategy chooses a bot's optional moves for one turn
+type Strategy interface {
+	// Name identifies the strategy, as passed to New
+	Name() string
+	// Plan returns the purchases and builds the bot makes before it rolls, in order.
+	// The game must not be changed.
+	Plan(game *models.Game, player *models.Player, rng *rand.Rand) []models.GameAction
+}
+
+// New returns the strategy with the given name, ignoring case
+func New(name string) (Strategy, error) {
+	switch strings.ToLower(name) {
+	case StrategyRandom:
+		return Random{}, nil
+	case StrategyGreedy:
+		return Greedy{}, nil
+	case StrategyHeuristic:
+		return Heuristic{}, nil
+	}
+	return nil, fmt.Errorf("%w %q; use %s", ErrUnknownStrategy, name, strings.Join(Names(), ", "))
+}
+
+// Names lists the strategies New knows
+func Names() []string {
+	return []string{StrategyRandom, StrategyGreedy, StrategyHeuristic}
+}
+
+// TakeTurn returns every action the bot takes this turn: rent it owes for the square it
+// is on, the strategy's moves, then the roll
+func TakeTurn(strategy Strategy, game *models.Game, playerID string, rng *rand.Rand) []models.GameAction {
+	player := findPlayer(game, playerID)
+	if player == nil {
+		return nil
+	}
+
+	var actions []models.GameAction
+	if !player.InJail {
+		if square := propertyAt(game, player.Position); square != nil && square.OwnerID != "" && square.OwnerID != playerID && !square.Mortgaged {
+			actions = append(actions, propertyAction(game, playerID, models.ActionTypePayRent, square.ID))
+		}
+	}
+	actions = append(actions, strategy.Plan(game, player, rng)...)
+	return append(actions, action(game, playerID, models.ActionTypeRollDice, nil))
+}
+
+// Random buys the property it is on half the time
+type Random struct{}
+
+// Name implements Strategy
+func (Random) Name() string { return StrategyRandom }
+
+// Plan implements Strategy
+func (Random) Plan(game *models.Game, player *models.Player, rng *rand.Rand) []models.GameAction {
+	square := forSale(game, player)
+	if square == nil || player.Balance < square.Price || rng.Intn(2) == 0 {
+		return nil
+	}
+	return []models.GameAction{propertyAction(game, player.ID, models.ActionTypeBuyProperty, square.ID)}
+}
+
+// Greedy buys every property it can afford
+type Greedy struct{}
+
+// Name implements Strategy
+func (Greedy) Name() string { return StrategyGreedy }
+
+// Plan implements Strategy
+func (Greedy) Plan(game *models.Game, player *models.Player, rng *rand.Rand) []models.GameAction {
+	square := forSale(game, player)
+	if square == nil || player.Balance < square.Price {
+		return nil
+	}
+	return []models.GameAction{propertyAction(game, player.ID, models.ActionTypeBuyProperty, square.ID)}
+}
+
+// Heuristic keeps a cash reserve for rent. It spends into the reserve to complete a
+// colour group or stop an opponent completing one, and builds on the groups it holds
+// while it can afford to.
+type Heuristic struct{}
+
+// Heuristic tuning
+const (
+	// reserve is the cash the heuristic keeps back for rent
+	reserve = 200
+	// maxEngagements is the most engagements a property can hold
+	maxEngagements = 4
+)
+
+// Name implements Strategy
+func (Heuristic) Name() string { return StrategyHeuristic }
+
+// Plan implements Strategy
+func (Heuristic) Plan(game *models.Game, player *models.Player, rng *rand.Rand) []models.GameAction {
+	var actions []models.GameAction
+	balance := player.Balance
+	owned := make(map[string]bool, len(player.Properties))
+	for _, id := range player.Properties {
+		owned[id] = true
+	}
+
+	if square := forSale(game, player); square != nil {
+		// How much of the reserve the purchase is worth spending
+		keep := reserve
+		switch {
+		case completesGroup(game, square, player.ID):
+			keep = 0
+		case completesGroup(game, square, groupRival(game, square, player.ID)):
+			keep = reserve / 2
+		}
+		if balance-square.Price >= keep {
+			actions = append(actions, propertyAction(game, player.ID, models.ActionTypeBuyProperty, square.ID))
+			balance -= square.Price
+			owned[square.ID] = true
+		}
+	}
+
+	// Build once per held group, cheapest group first, on its least developed property
+	for _, group := range heldGroups(game, owned) {
+		target := group[0]
+		for _, property := range group[1:] {
+			if property.Engagements < target.Engagements {
+				target = property
+			}
+		}
+		cost := buildCost(target)
+		if target.Engagements >= maxEngagements || balance-cost < reserve {
+			continue
+		}
+		actions = append(actions, propertyAction(game, player.ID, models.ActionTypeBuildEngagement, target.ID))
+		balance -= cost
+	}
+	return actions
+}
+
+// buildCost is what the heuristic budgets for an engagement: half the property's price
+func buildCost(property *models.Property) int {
+	return property.Price / 2
+}
+
+// heldGroups returns the colour groups where the bot owns every property and none is
+// mortgaged, cheapest first
+func heldGroups(game *models.Game, owned map[string]bool) [][]*models.Property {
+	groups := make(map[string][]*models.Property)
+	for i := range game.BoardState.Properties {
+		property := &game.BoardState.Properties[i]
+		if property.Type == models.PropertyTypeRegular && property.Group != "" {
+			groups[property.Group] = append(groups[property.Group], property)
+		}
+	}
+
+	var held [][]*models.Property
+	for _, group := range groups {
+		complete := true
+		for _, property := range group {
+			if !owned[property.ID] || property.Mortgaged {
+				complete = false
+				break
+			}
+		}
+		if complete {
+			held = append(held, group)
+		}
+	}
+	sort.Slice(held, func(i, j int) bool {
+		if held[i][0].Price != held[j][0].Price {
+			return held[i][0].Price < held[j][0].Price
+		}
+		return held[i][0].Group < held[j][0].Group
+	})
+	return held
+}
+
+// completesGroup reports whether buying square would give ownerID its whole colour group
+func completesGroup(game *models.Game, square *models.Property, ownerID string) bool {
+	if ownerID == "" || square.Group == "" {
+		return false
+	}
+	for _, property := range game.BoardState.Properties {
+		if property.Group == square.Group && property.ID != square.ID && property.OwnerID != ownerID {
+			return false
+		}
+	}
+	return true
+}
+
+// groupRival returns the opponent owning the rest of square's group, if a single one does
+func groupRival(game *models.Game, square *models.Property, playerID string) string {
+	rival := ""
+	for _, property := range game.BoardState.Properties {
+		if property.Group != square.Group || property.ID == square.ID {
+			continue
+		}
+		if property.OwnerID == "" || property.OwnerID == playerID || (rival != "" && property.OwnerID != rival) {
+			return ""
+		}
+		rival = property.OwnerID
+	}
+	return rival
+}
+
+// forSale returns the unowned property the player is standing on, if any
+func forSale(game *models.Game, player *models.Player) *models.Property {
+	if player.InJail {
+		return nil
+	}
+	square := propertyAt(game, player.Position)
+	if square == nil || square.OwnerID != "" || square.Price <= 0 || square.Type == models.PropertyTypeSpecial {
+		return nil
+	}
+	return square
+}
+
+// propertyAt returns the property at a board position, if any
+func propertyAt(game *models.Game, position int) *models.Property {
+	for i := range game.BoardState.Properties {
+		if game.BoardState.Properties[i].Position == position {
+			return &game.BoardState.Properties[i]
+		}
+	}
+	return nil
+}
+
+func findPlayer(game *models.Game, playerID string) *models.Player {
+	for i := range game.Players {
+		if game.Players[i].ID == playerID {
+			return &game.Players[i]
+		}
+	}
+	return nil
+}
+
+// propertyAction builds an action on a property, with the payload the game manager expects
+func propertyAction(game *models.Game, playerID string, actionType models.ActionType, propertyID string) models.GameAction {
+	return action(game, playerID, actionType, map[string]interface{}{"propertyId": propertyID})
+}
+
+func action(game *models.Game, playerID string, actionType models.ActionType, payload interface{}) models.GameAction {
+	return models.GameAction{
+		Type:      actionType,
+		PlayerID:  playerID,
+		GameID:    game.ID.Hex(),
+		Payload:   payload,
+		Timestamp: time.Now(),
+	}
+}
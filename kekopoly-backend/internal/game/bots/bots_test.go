@@ -0,0 +1,130 @@
+package bots
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/kekopoly/backend/internal/game/models"
+)
+
+// testGame has a bot standing on the first of two pink properties, with a third group
+// the bot already holds
+func testGame(balance int) *models.Game {
+	return &models.Game{
+		ID: primitive.NewObjectID(),
+		Players: []models.Player{
+			{ID: "bot", Position: 11, Balance: balance, Properties: []string{"green-1", "green-2"}},
+			{ID: "human", Position: 3},
+		},
+		BoardState: models.BoardState{Properties: []models.Property{
+			{ID: "pink-1", Type: models.PropertyTypeRegular, Group: "pink", Position: 11, Price: 140},
+			{ID: "pink-2", Type: models.PropertyTypeRegular, Group: "pink", Position: 13, Price: 140},
+			{ID: "green-1", Type: models.PropertyTypeRegular, Group: "green", Position: 31, Price: 300, OwnerID: "bot"},
+			{ID: "green-2", Type: models.PropertyTypeRegular, Group: "green", Position: 32, Price: 300, OwnerID: "bot", Engagements: 1},
+			{ID: "airdrop", Type: models.PropertyTypeSpecial, Position: 7},
+		}},
+	}
+}
+
+// types lists the action types and their property IDs
+func types(actions []models.GameAction) []string {
+	var got []string
+	for _, action := range actions {
+		entry := string(action.Type)
+		if payload, ok := action.Payload.(map[string]interface{}); ok {
+			entry += " " + payload["propertyId"].(string)
+		}
+		got = append(got, entry)
+	}
+	return got
+}
+
+func TestNew(t *testing.T) {
+	for _, name := range Names() {
+		strategy, err := New(name)
+		require.NoError(t, err)
+		assert.Equal(t, name, strategy.Name())
+	}
+	strategy, err := New("Greedy")
+	require.NoError(t, err)
+	assert.Equal(t, StrategyGreedy, strategy.Name())
+
+	_, err = New("cheater")
+	assert.ErrorIs(t, err, ErrUnknownStrategy)
+}
+
+func TestTakeTurn(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	// Greedy buys whatever it can afford, and every turn ends with the roll
+	game := testGame(150)
+	assert.Equal(t, []string{"BUY_PROPERTY pink-1", "ROLL_DICE"}, types(TakeTurn(Greedy{}, game, "bot", rng)))
+	game = testGame(100)
+	assert.Equal(t, []string{"ROLL_DICE"}, types(TakeTurn(Greedy{}, game, "bot", rng)))
+
+	// Rent comes first, and nobody buys from jail or someone else's square
+	game = testGame(1000)
+	game.BoardState.Properties[0].OwnerID = "human"
+	assert.Equal(t, []string{"PAY_RENT pink-1", "ROLL_DICE"}, types(TakeTurn(Greedy{}, game, "bot", rng)))
+	game.Players[0].InJail = true
+	assert.Equal(t, []string{"ROLL_DICE"}, types(TakeTurn(Greedy{}, game, "bot", rng)))
+
+	// Special squares can't be bought
+	game = testGame(1000)
+	game.Players[0].Position = 7
+	assert.Equal(t, []string{"ROLL_DICE"}, types(TakeTurn(Greedy{}, game, "bot", rng)))
+
+	assert.Nil(t, TakeTurn(Greedy{}, game, "nobody", rng))
+}
+
+func TestRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	bought := 0
+	for i := 0; i < 100; i++ {
+		if len(Random{}.Plan(testGame(1000), &testGame(1000).Players[0], rng)) > 0 {
+			bought++
+		}
+	}
+	assert.InDelta(t, 50, bought, 20)
+	game := testGame(100)
+	assert.Empty(t, Random{}.Plan(game, &game.Players[0], rng))
+}
+
+func TestHeuristic(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	plan := func(game *models.Game) []string {
+		return types(Heuristic{}.Plan(game, &game.Players[0], rng))
+	}
+
+	// Rich enough to buy and build on the held group's least developed property
+	assert.Equal(t, []string{"BUY_PROPERTY pink-1", "BUILD_ENGAGEMENT green-1"}, plan(testGame(1000)))
+
+	// A purchase that would eat into the reserve waits, and so does building
+	assert.Empty(t, plan(testGame(300)))
+
+	// Unless it completes a group, which is worth the reserve
+	game := testGame(150)
+	game.BoardState.Properties[1].OwnerID = "bot"
+	assert.Equal(t, []string{"BUY_PROPERTY pink-1"}, plan(game))
+
+	// Blocking an opponent's group is worth half the reserve
+	game = testGame(250)
+	game.BoardState.Properties[1].OwnerID = "human"
+	assert.Equal(t, []string{"BUY_PROPERTY pink-1"}, plan(game))
+	game.Players[0].Balance = 200
+	assert.Empty(t, plan(game))
+
+	// Fully built or mortgaged groups aren't built on
+	game = testGame(1000)
+	game.Players[0].Position = 0
+	game.BoardState.Properties[2].Engagements = maxEngagements
+	game.BoardState.Properties[3].Engagements = maxEngagements
+	assert.Empty(t, plan(game))
+	game.BoardState.Properties[2].Engagements = 0
+	game.BoardState.Properties[3].Mortgaged = true
+	assert.Empty(t, plan(game))
+}
@@ -0,0 +1,338 @@
+package manager
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/kekopoly/backend/internal/game/models"
+	"github.com/kekopoly/backend/internal/game/protocol"
+)
+
+var (
+	// ErrInvalidAccess is returned when a game is created with an unknown visibility
+	ErrInvalidAccess = errors.New("invalid game access")
+	// ErrNotHost is returned when someone other than the host uses a host control
+	ErrNotHost = errors.New("only the host can do that")
+	// ErrPlayerNotInGame is returned when a host control names someone not in the game
+	ErrPlayerNotInGame = errors.New("player is not in the game")
+	// ErrWrongPassword is returned when joining a password protected game without the password
+	ErrWrongPassword = errors.New("wrong game password")
+	// ErrInviteRequired is returned when joining a private game without an invite
+	ErrInviteRequired = errors.New("this game is private; ask the host for an invite")
+	// ErrInvalidInvite is returned for an invite that is forged, expired or for another game
+	ErrInvalidInvite = errors.New("invite is invalid or has expired")
+	// ErrGameLocked is returned when joining a game the host has locked
+	ErrGameLocked = errors.New("the host has locked this game")
+	// ErrBanned is returned when a player the host banned tries to join
+	ErrBanned = errors.New("you are banned from this game")
+)
+
+// defaultInviteTTL is how long invites last when neither the host nor the options say
+const defaultInviteTTL = 24 * time.Hour
+
+// GameAccess controls who can find and join a new game
+type GameAccess struct {
+	Visibility models.GameVisibility // Empty for PUBLIC
+	Password   string                // Empty for none; only a hash is stored
+}
+
+// JoinOptions is what a player presents to join a protected game. A valid invite
+// stands in for the password.
+type JoinOptions struct {
+	Password string `json:"password,omitempty"`
+	Invite   string `json:"invite,omitempty"`
+}
+
+// HostNotifier is implemented by hubs that tell a game's clients who the host is
+type HostNotifier interface {
+	UpdateHostID(gameID string, hostID string)
+}
+
+// PlayerDisconnector is implemented by hubs that can close a player's game socket,
+// on whichever node it is connected to
+type PlayerDisconnector interface {
+	DisconnectPlayer(gameID, playerID string)
+}
+
+// resolve checks the access settings and hashes the password
+func (a GameAccess) resolve() (models.GameVisibility, string, error) {
+	visibility := models.GameVisibility(strings.ToUpper(string(a.Visibility)))
+	switch visibility {
+	case "":
+		visibility = models.GameVisibilityPublic
+	case models.GameVisibilityPublic, models.GameVisibilityUnlisted, models.GameVisibilityPrivate:
+	default:
+		return "", "", fmt.Errorf("%w: unknown visibility %q", ErrInvalidAccess, a.Visibility)
+	}
+	if a.Password == "" {
+		return visibility, "", nil
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(a.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to hash game password: %w", err)
+	}
+	return visibility, string(hash), nil
+}
+
+// checkJoinAccess reports why a new player can't join the game, if anything. The
+// caller must hold the session lock.
+func (gm *GameManager) checkJoinAccess(game *models.Game, playerID string, opts JoinOptions) error {
+	for _, banned := range game.BannedPlayers {
+		if banned == playerID {
+			return ErrBanned
+		}
+	}
+	if game.Locked {
+		return ErrGameLocked
+	}
+	if opts.Invite != "" {
+		return gm.verifyInvite(opts.Invite, game.ID.Hex())
+	}
+	if game.Visibility == models.GameVisibilityPrivate {
+		return ErrInviteRequired
+	}
+	if game.PasswordHash != "" && bcrypt.CompareHashAndPassword([]byte(game.PasswordHash), []byte(opts.Password)) != nil {
+		return ErrWrongPassword
+	}
+	return nil
+}
+
+// invitePayload is the signed part of an invite token
+type invitePayload struct {
+	GameID  string `json:"g"`
+	Expires int64  `json:"e"` // Unix seconds
+}
+
+// inviteKey derives the invite signing key, so invites can't be confused with
+// anything else signed with the same secret
+func inviteKey(secret string) []byte {
+	if secret == "" {
+		random := make([]byte, 32)
+		if _, err := rand.Read(random); err != nil {
+			panic(fmt.Sprintf("failed to generate invite key: %v", err))
+		}
+		return random
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("kekopoly:invites"))
+	return mac.Sum(nil)
+}
+
+// CreateInvite returns a signed invite to the game that lasts for ttl, or the default
+// invite lifetime when ttl is 0. Only the host can invite.
+func (gm *GameManager) CreateInvite(gameID, hostID string, ttl time.Duration) (string, time.Time, error) {
+	game, err := gm.GetGame(gameID)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if game.HostID != hostID {
+		return "", time.Time{}, ErrNotHost
+	}
+	if ttl <= 0 {
+		ttl = gm.inviteTTL
+	}
+
+	expires := time.Now().Add(ttl).Truncate(time.Second)
+	payload, err := json.Marshal(invitePayload{GameID: game.ID.Hex(), Expires: expires.Unix()})
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to encode invite: %w", err)
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + gm.signInvite(encoded), expires, nil
+}
+
+// signInvite signs an encoded invite payload
+func (gm *GameManager) signInvite(encoded string) string {
+	mac := hmac.New(sha256.New, gm.inviteKey)
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// verifyInvite checks an invite was signed by this server for the game and hasn't expired
+func (gm *GameManager) verifyInvite(token, gameID string) error {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(gm.signInvite(encoded))) {
+		return ErrInvalidInvite
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return ErrInvalidInvite
+	}
+	var payload invitePayload
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return ErrInvalidInvite
+	}
+	if !strings.EqualFold(payload.GameID, gameID) || time.Now().Unix() >= payload.Expires {
+		return ErrInvalidInvite
+	}
+	return nil
+}
+
+// hostSession returns the session of a game whose host is hostID. The session is
+// returned locked; the caller must unlock it.
+func (gm *GameManager) hostSession(gameID, hostID string) (*GameSession, error) {
+	gm.activeGamesMutex.RLock()
+	session, exists := gm.activeGames[gm.resolveGameID(gameID)]
+	gm.activeGamesMutex.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("game session not found")
+	}
+
+	session.mutex.Lock()
+	if session.Game.HostID != hostID {
+		session.mutex.Unlock()
+		return nil, ErrNotHost
+	}
+	return session, nil
+}
+
+// KickPlayer removes a player from the lobby. Any buy-in they paid is refunded.
+func (gm *GameManager) KickPlayer(gameID, hostID, playerID string) error {
+	if _, handled, err := gm.remote(forwardRequest{Op: opKickPlayer, GameID: gameID, PlayerID: hostID, Target: playerID}); handled {
+		return err
+	}
+
+	session, err := gm.hostSession(gameID, hostID)
+	if err != nil {
+		return err
+	}
+	defer session.mutex.Unlock()
+
+	if err := gm.removeFromLobby(session, playerID, "kicked"); err != nil {
+		return err
+	}
+	gm.logger.Infof("Host %s kicked player %s from game %s", hostID, playerID, session.Game.ID.Hex())
+	return nil
+}
+
+// BanPlayer keeps a player out of the game, removing them from the lobby if they are in it
+func (gm *GameManager) BanPlayer(gameID, hostID, playerID string) error {
+	if _, handled, err := gm.remote(forwardRequest{Op: opBanPlayer, GameID: gameID, PlayerID: hostID, Target: playerID}); handled {
+		return err
+	}
+
+	session, err := gm.hostSession(gameID, hostID)
+	if err != nil {
+		return err
+	}
+	defer session.mutex.Unlock()
+	game := session.Game
+
+	if playerID == hostID {
+		return fmt.Errorf("the host can't ban themselves")
+	}
+	if findPlayer(game, playerID) != nil {
+		if err := gm.removeFromLobby(session, playerID, "banned"); err != nil {
+			return err
+		}
+	}
+	for _, banned := range game.BannedPlayers {
+		if banned == playerID {
+			return nil
+		}
+	}
+	game.BannedPlayers = append(game.BannedPlayers, playerID)
+	if err := gm.store.SetGameFields(gm.ctx, game.ID, bson.M{"bannedPlayers": game.BannedPlayers}); err != nil {
+		return fmt.Errorf("failed to persist ban: %w", err)
+	}
+	gm.logger.Infof("Host %s banned player %s from game %s", hostID, playerID, game.ID.Hex())
+	return nil
+}
+
+// removeFromLobby takes a player out of a game that hasn't started and closes their
+// socket. The caller must hold the session lock.
+func (gm *GameManager) removeFromLobby(session *GameSession, playerID, reason string) error {
+	game := session.Game
+	if game.Status != models.GameStatusLobby {
+		return fmt.Errorf("players can only be removed before the game starts")
+	}
+	if playerID == game.HostID {
+		return fmt.Errorf("the host can't remove themselves")
+	}
+	player := findPlayer(game, playerID)
+	if player == nil {
+		return ErrPlayerNotInGame
+	}
+
+	gm.evictPlayer(session, *player, reason)
+	if err := gm.persistPlayers(game); err != nil {
+		return fmt.Errorf("failed to persist removal: %w", err)
+	}
+	if disconnector, ok := gm.wsHub.(PlayerDisconnector); ok {
+		disconnector.DisconnectPlayer(game.ID.Hex(), playerID)
+	}
+	return nil
+}
+
+// LockGame stops anyone else joining the game, or lets them join again
+func (gm *GameManager) LockGame(gameID, hostID string, locked bool) error {
+	if _, handled, err := gm.remote(forwardRequest{Op: opLockGame, GameID: gameID, PlayerID: hostID, Locked: locked}); handled {
+		return err
+	}
+
+	session, err := gm.hostSession(gameID, hostID)
+	if err != nil {
+		return err
+	}
+	defer session.mutex.Unlock()
+	game := session.Game
+
+	game.Locked = locked
+	game.UpdatedAt = time.Now()
+	if err := gm.store.SetGameFields(gm.ctx, game.ID, bson.M{"locked": locked, "updatedAt": game.UpdatedAt}); err != nil {
+		return fmt.Errorf("failed to persist lock: %w", err)
+	}
+
+	gm.logger.Infof("Host %s set game %s locked=%t", hostID, game.ID.Hex(), locked)
+	gm.notifyListing(game.ID.Hex())
+	gm.broadcast(game.ID.Hex(), &protocol.GameLocked{Locked: locked, Timestamp: time.Now().Format(time.RFC3339)})
+	return nil
+}
+
+// TransferHost makes another player in the game the host
+func (gm *GameManager) TransferHost(gameID, hostID, newHostID string) error {
+	if _, handled, err := gm.remote(forwardRequest{Op: opTransferHost, GameID: gameID, PlayerID: hostID, Target: newHostID}); handled {
+		return err
+	}
+
+	session, err := gm.hostSession(gameID, hostID)
+	if err != nil {
+		return err
+	}
+	defer session.mutex.Unlock()
+	game := session.Game
+
+	player := findPlayer(game, newHostID)
+	if player == nil {
+		return ErrPlayerNotInGame
+	}
+	if player.Status != models.PlayerStatusActive {
+		return fmt.Errorf("the new host must be an active player")
+	}
+	if newHostID == hostID {
+		return nil
+	}
+
+	game.HostID = newHostID
+	game.UpdatedAt = time.Now()
+	if err := gm.store.SetGameFields(gm.ctx, game.ID, bson.M{"hostId": newHostID, "updatedAt": game.UpdatedAt}); err != nil {
+		return fmt.Errorf("failed to persist host: %w", err)
+	}
+
+	gm.logger.Infof("Host %s handed game %s to %s", hostID, game.ID.Hex(), newHostID)
+	gm.notifyListing(game.ID.Hex())
+	if notifier, ok := gm.wsHub.(HostNotifier); ok {
+		notifier.UpdateHostID(game.ID.Hex(), newHostID)
+	}
+	return nil
+}
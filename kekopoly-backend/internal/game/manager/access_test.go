@@ -0,0 +1,147 @@
+package manager
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/kekopoly/backend/internal/game/models"
+)
+
+// recordingHub records what the game manager asks the hub to do
+type recordingHub struct {
+	mu           sync.Mutex
+	disconnected []string
+	hosts        []string
+}
+
+func (h *recordingHub) BroadcastToGame(gameID string, message []byte) {}
+
+func (h *recordingHub) DisconnectPlayer(gameID, playerID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.disconnected = append(h.disconnected, playerID)
+}
+
+func (h *recordingHub) UpdateHostID(gameID, hostID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.hosts = append(h.hosts, hostID)
+}
+
+func newTestManager(t *testing.T, hub WebSocketHub) *GameManager {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	return NewGameManagerWithOptions(ctx, nil, nil, zap.NewNop().Sugar(), hub, nil, Options{
+		Store:        NewMemoryGameStore(),
+		InviteSecret: "test-secret",
+	})
+}
+
+func TestJoinAccess(t *testing.T) {
+	gm := newTestManager(t, nil)
+
+	_, err := gm.CreateGameWithAccess("alice", "wallet-alice", "", 4, 0, GameAccess{Visibility: "secret"})
+	assert.ErrorIs(t, err, ErrInvalidAccess)
+
+	// A password keeps out anyone without it
+	gameID, err := gm.CreateGameWithAccess("alice", "wallet-alice", "", 4, 0, GameAccess{Visibility: "unlisted", Password: "hunter2"})
+	require.NoError(t, err)
+	game, err := gm.GetGame(gameID)
+	require.NoError(t, err)
+	assert.Equal(t, models.GameVisibilityUnlisted, game.Visibility)
+	assert.NotContains(t, game.PasswordHash, "hunter2")
+
+	_, err = gm.JoinGame(gameID, "bob", "wallet-bob")
+	assert.ErrorIs(t, err, ErrWrongPassword)
+	_, err = gm.JoinGameWithOptions(gameID, "bob", "wallet-bob", JoinOptions{Password: "hunter2"})
+	require.NoError(t, err)
+
+	// Private games need an invite from the host, which stands in for the password
+	privateID, err := gm.CreateGameWithAccess("alice", "wallet-alice", "", 4, 0, GameAccess{Visibility: models.GameVisibilityPrivate, Password: "hunter2"})
+	require.NoError(t, err)
+	_, err = gm.JoinGameWithOptions(privateID, "carol", "wallet-carol", JoinOptions{Password: "hunter2"})
+	assert.ErrorIs(t, err, ErrInviteRequired)
+
+	_, _, err = gm.CreateInvite(privateID, "bob", 0)
+	assert.ErrorIs(t, err, ErrNotHost)
+	invite, expires, err := gm.CreateInvite(privateID, "alice", time.Hour)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), expires, 2*time.Second)
+
+	// Invites only work for the game they were made for, and can't be altered
+	_, err = gm.JoinGameWithOptions(gameID, "carol", "wallet-carol", JoinOptions{Invite: invite})
+	assert.ErrorIs(t, err, ErrInvalidInvite)
+	_, err = gm.JoinGameWithOptions(privateID, "carol", "wallet-carol", JoinOptions{Invite: invite + "x"})
+	assert.ErrorIs(t, err, ErrInvalidInvite)
+	_, err = gm.JoinGameWithOptions(privateID, "carol", "wallet-carol", JoinOptions{Invite: invite})
+	require.NoError(t, err)
+
+	// Expired invites are refused
+	expired, _, err := gm.CreateInvite(privateID, "alice", time.Nanosecond)
+	require.NoError(t, err)
+	time.Sleep(time.Second)
+	_, err = gm.JoinGameWithOptions(privateID, "dave", "wallet-dave", JoinOptions{Invite: expired})
+	assert.ErrorIs(t, err, ErrInvalidInvite)
+
+	// Only public games are listed
+	publicID, err := gm.CreateGame("erin", "wallet-erin", "", 4, 0)
+	require.NoError(t, err)
+	games, err := gm.ListAvailableGames()
+	require.NoError(t, err)
+	require.Len(t, games, 1)
+	assert.Equal(t, publicID, games[0].ID.Hex())
+}
+
+func TestHostControls(t *testing.T) {
+	hub := &recordingHub{}
+	gm := newTestManager(t, hub)
+
+	gameID, err := gm.CreateGame("alice", "wallet-alice", "", 4, 0)
+	require.NoError(t, err)
+	for _, player := range []string{"bob", "carol"} {
+		_, err = gm.JoinGame(gameID, player, "wallet-"+player)
+		require.NoError(t, err)
+	}
+
+	assert.ErrorIs(t, gm.KickPlayer(gameID, "bob", "carol"), ErrNotHost)
+	assert.ErrorIs(t, gm.KickPlayer(gameID, "alice", "zed"), ErrPlayerNotInGame)
+	assert.Error(t, gm.KickPlayer(gameID, "alice", "alice"))
+
+	// A kicked player can come back; a banned one can't
+	require.NoError(t, gm.KickPlayer(gameID, "alice", "bob"))
+	require.NoError(t, gm.BanPlayer(gameID, "alice", "carol"))
+	assert.Equal(t, []string{"bob", "carol"}, hub.disconnected)
+
+	stored := storedGame(t, gm.store, gameID)
+	assert.Len(t, stored.Players, 1)
+	assert.Equal(t, []string{"carol"}, stored.BannedPlayers)
+
+	_, err = gm.JoinGame(gameID, "carol", "wallet-carol")
+	assert.ErrorIs(t, err, ErrBanned)
+	_, err = gm.JoinGame(gameID, "bob", "wallet-bob")
+	require.NoError(t, err)
+
+	// Nobody new gets into a locked game
+	require.NoError(t, gm.LockGame(gameID, "alice", true))
+	_, err = gm.JoinGame(gameID, "dave", "wallet-dave")
+	assert.ErrorIs(t, err, ErrGameLocked)
+	assert.True(t, storedGame(t, gm.store, gameID).Locked)
+	require.NoError(t, gm.LockGame(gameID, "alice", false))
+	_, err = gm.JoinGame(gameID, "dave", "wallet-dave")
+	require.NoError(t, err)
+
+	// Handing the game on makes the old host an ordinary player
+	assert.ErrorIs(t, gm.TransferHost(gameID, "alice", "carol"), ErrPlayerNotInGame)
+	require.NoError(t, gm.TransferHost(gameID, "alice", "bob"))
+	assert.Equal(t, []string{"bob"}, hub.hosts)
+	assert.Equal(t, "bob", storedGame(t, gm.store, gameID).HostID)
+	assert.ErrorIs(t, gm.LockGame(gameID, "alice", true), ErrNotHost)
+	require.NoError(t, gm.KickPlayer(gameID, "bob", "alice"))
+}
@@ -0,0 +1,282 @@
+package manager
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/kekopoly/backend/internal/game/models"
+	"github.com/kekopoly/backend/internal/game/protocol"
+)
+
+var (
+	// ErrGameOver is returned when an operator changes a game that has already ended
+	ErrGameOver = errors.New("game is already over")
+	// ErrInvalidEnding is returned for a status or winner a game can't be ended with
+	ErrInvalidEnding = errors.New("invalid way to end a game")
+	// ErrNegativeBalance is returned when an adjustment would leave a balance below zero
+	ErrNegativeBalance = errors.New("balance can't go below zero")
+)
+
+// FindGames returns the games with any of the statuses, newest first. Games this node is
+// playing are as they stand in memory; the rest are as last stored.
+func (gm *GameManager) FindGames(statuses ...models.GameStatus) ([]*models.Game, error) {
+	stored, err := gm.store.FindGamesByStatus(gm.ctx, statuses...)
+	if err != nil {
+		return nil, err
+	}
+
+	games := make([]*models.Game, 0, len(stored))
+	for i := range stored {
+		game := &stored[i]
+		gm.activeGamesMutex.RLock()
+		session, exists := gm.activeGames[game.ID.Hex()]
+		gm.activeGamesMutex.RUnlock()
+		if exists {
+			session.mutex.RLock()
+			live, err := copyGame(session.Game)
+			session.mutex.RUnlock()
+			if err != nil {
+				return nil, err
+			}
+			// The stored status can lag behind the game's
+			if !containsStatus(statuses, live.Status) {
+				continue
+			}
+			game = live
+		}
+		games = append(games, game)
+	}
+	sort.Slice(games, func(i, j int) bool { return games[i].CreatedAt.After(games[j].CreatedAt) })
+	return games, nil
+}
+
+// containsStatus reports whether status is one of statuses
+func containsStatus(statuses []models.GameStatus, status models.GameStatus) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// EndGame ends a game on an operator's say-so. status is COMPLETED or ABANDONED. A
+// completed game is settled as usual, with winnerID first if it names a player; an
+// abandoned game pays nobody and refunds every buy-in.
+func (gm *GameManager) EndGame(gameID string, status models.GameStatus, winnerID string) error {
+	if _, handled, err := gm.remote(forwardRequest{Op: opEndGame, GameID: gameID, Status: status, Target: winnerID}); handled {
+		return err
+	}
+	return gm.endGame(gameID, status, winnerID, time.Time{})
+}
+
+// endGame ends a game this node owns. A forwarded request passes its deadline, so it
+// doesn't end the game once the node that sent it has given up waiting.
+func (gm *GameManager) endGame(gameID string, status models.GameStatus, winnerID string, deadline time.Time) error {
+	switch status {
+	case models.GameStatusCompleted:
+	case models.GameStatusAbandoned:
+		if winnerID != "" {
+			return fmt.Errorf("%w: an abandoned game has no winner", ErrInvalidEnding)
+		}
+	default:
+		return fmt.Errorf("%w: a game can only be ended as %s or %s", ErrInvalidEnding, models.GameStatusCompleted, models.GameStatusAbandoned)
+	}
+
+	session, err := gm.lockedSession(gameID, deadline)
+	if err != nil {
+		return err
+	}
+	defer session.mutex.Unlock()
+	game := session.Game
+
+	if gameOver(game) {
+		return ErrGameOver
+	}
+	if winnerID != "" && findPlayer(game, winnerID) == nil {
+		return ErrPlayerNotInGame
+	}
+
+	now := time.Now()
+	game.Status = status
+	game.WinnerID = winnerID
+	game.Pause = nil
+	game.PauseVotes = nil
+	game.UpdatedAt = now
+	if err := gm.store.SetGameFields(gm.ctx, game.ID, bson.M{
+		"status":     game.Status,
+		"winnerId":   game.WinnerID,
+		"pause":      game.Pause,
+		"pauseVotes": game.PauseVotes,
+		"updatedAt":  now,
+	}); err != nil {
+		return fmt.Errorf("failed to persist end of game: %w", err)
+	}
+
+	gm.logger.Infof("Game %s ended by an operator as %s (winner %q)", game.ID.Hex(), status, winnerID)
+	gm.broadcast(game.ID.Hex(), &protocol.GameEnded{
+		Status:    string(status),
+		WinnerID:  winnerID,
+		Timestamp: now.Format(time.RFC3339),
+	})
+	gm.publishState(game)
+
+	if status == models.GameStatusCompleted {
+		gm.settleGame(game)
+		return nil
+	}
+	for _, player := range game.Players {
+		gm.refundDeposit(game, player)
+	}
+	return nil
+}
+
+// AdjustBalance adds amount, which may be negative, to a player's balance. It returns
+// the player as they are afterwards.
+func (gm *GameManager) AdjustBalance(gameID, playerID string, amount int) (*models.Player, error) {
+	if reply, handled, err := gm.remote(forwardRequest{Op: opAdjustBalance, GameID: gameID, Target: playerID, Amount: amount}); handled {
+		if err != nil {
+			return nil, err
+		}
+		return reply.Player, nil
+	}
+	return gm.adjustBalance(gameID, playerID, amount, time.Time{})
+}
+
+// adjustBalance adjusts a balance in a game this node owns. Like endGame, it takes the
+// deadline of a forwarded request, so an adjustment the sender reported as failed isn't
+// made anyway and then made again when the operator retries.
+func (gm *GameManager) adjustBalance(gameID, playerID string, amount int, deadline time.Time) (*models.Player, error) {
+	session, err := gm.lockedSession(gameID, deadline)
+	if err != nil {
+		return nil, err
+	}
+	defer session.mutex.Unlock()
+	game := session.Game
+
+	if gameOver(game) {
+		return nil, ErrGameOver
+	}
+	player := findPlayer(game, playerID)
+	if player == nil {
+		return nil, ErrPlayerNotInGame
+	}
+	if player.Balance+amount < 0 {
+		return nil, fmt.Errorf("%w; it is %d", ErrNegativeBalance, player.Balance)
+	}
+
+	player.Balance += amount
+	if err := gm.persistPlayers(game); err != nil {
+		return nil, fmt.Errorf("failed to persist balance: %w", err)
+	}
+
+	gm.logger.Infof("Operator adjusted player %s's balance in game %s by %d to %d", playerID, game.ID.Hex(), amount, player.Balance)
+	gm.publishState(game)
+	adjusted := *player
+	return &adjusted, nil
+}
+
+// RemovePlayer takes a player out of a game on an operator's say-so and closes their
+// socket. A player in the lobby is evicted and refunded, like a kicked player; a player
+// in a game being played forfeits. If they were the host, the host passes to the next
+// human player. A lobby left empty is abandoned.
+func (gm *GameManager) RemovePlayer(gameID, playerID string) error {
+	if _, handled, err := gm.remote(forwardRequest{Op: opRemovePlayer, GameID: gameID, Target: playerID}); handled {
+		return err
+	}
+	return gm.removePlayer(gameID, playerID, time.Time{})
+}
+
+// removePlayer removes a player from a game this node owns, unless deadline has passed
+func (gm *GameManager) removePlayer(gameID, playerID string, deadline time.Time) error {
+	session, err := gm.lockedSession(gameID, deadline)
+	if err != nil {
+		return err
+	}
+	defer session.mutex.Unlock()
+	game := session.Game
+
+	if gameOver(game) {
+		return ErrGameOver
+	}
+	player := findPlayer(game, playerID)
+	if player == nil {
+		return ErrPlayerNotInGame
+	}
+
+	if game.Status == models.GameStatusLobby {
+		gm.evictPlayer(session, *player, "removed")
+		if len(game.Players) == 0 {
+			game.Status = models.GameStatusAbandoned
+		}
+	} else {
+		player.Status = models.PlayerStatusForfeited
+		gm.handlePlayerForfeiture(game, playerID)
+		gm.broadcast(game.ID.Hex(), &protocol.PlayerEvicted{
+			PlayerID:  playerID,
+			Reason:    "removed",
+			Timestamp: time.Now().Format(time.RFC3339),
+		})
+	}
+	newHost := ""
+	if game.HostID == playerID {
+		newHost = nextHost(game, playerID)
+		game.HostID = newHost
+	}
+
+	if err := gm.store.SetGameFields(gm.ctx, game.ID, bson.M{
+		"players":      game.Players,
+		"boardState":   game.BoardState,
+		"turnOrder":    game.TurnOrder,
+		"currentTurn":  game.CurrentTurn,
+		"hostId":       game.HostID,
+		"status":       game.Status,
+		"winnerId":     game.WinnerID,
+		"updatedAt":    time.Now(),
+		"lastActivity": time.Now(),
+	}); err != nil {
+		return fmt.Errorf("failed to persist removal: %w", err)
+	}
+
+	gm.logger.Infof("Operator removed player %s from game %s", playerID, game.ID.Hex())
+	if disconnector, ok := gm.wsHub.(PlayerDisconnector); ok {
+		disconnector.DisconnectPlayer(game.ID.Hex(), playerID)
+	}
+	if notifier, ok := gm.wsHub.(HostNotifier); ok && newHost != "" {
+		notifier.UpdateHostID(game.ID.Hex(), newHost)
+	}
+	gm.publishState(game)
+	if game.Status == models.GameStatusCompleted {
+		gm.settleGame(game)
+	} else {
+		gm.scheduleBot(session)
+	}
+	return nil
+}
+
+// gameOver reports whether a game has ended
+func gameOver(game *models.Game) bool {
+	return game.Status == models.GameStatusCompleted || game.Status == models.GameStatusAbandoned
+}
+
+// nextHost picks who hosts a game after its host leaves: the first human player still
+// active, or failing that any active player. It returns "" if nobody is left.
+func nextHost(game *models.Game, leaving string) string {
+	fallback := ""
+	for _, player := range game.Players {
+		if player.ID == leaving || player.Status != models.PlayerStatusActive {
+			continue
+		}
+		if player.Bot == "" {
+			return player.ID
+		}
+		if fallback == "" {
+			fallback = player.ID
+		}
+	}
+	return fallback
+}
@@ -0,0 +1,95 @@
+package manager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kekopoly/backend/internal/game/models"
+)
+
+func TestEndGame(t *testing.T) {
+	gm := newTestManager(t, &recordingHub{})
+	gameID, _ := startedGame(t, gm, "brian")
+
+	assert.ErrorIs(t, gm.EndGame(gameID, models.GameStatusPaused, ""), ErrInvalidEnding)
+	assert.ErrorIs(t, gm.EndGame(gameID, models.GameStatusAbandoned, "brian"), ErrInvalidEnding)
+	assert.ErrorIs(t, gm.EndGame(gameID, models.GameStatusCompleted, "dave"), ErrPlayerNotInGame)
+
+	require.NoError(t, gm.EndGame(gameID, models.GameStatusCompleted, "brian"))
+	assert.Equal(t, models.GameStatusCompleted, status(t, gm, gameID))
+	game, err := gm.GetGame(gameID)
+	require.NoError(t, err)
+	assert.Equal(t, "brian", game.WinnerID)
+
+	assert.ErrorIs(t, gm.EndGame(gameID, models.GameStatusAbandoned, ""), ErrGameOver)
+	_, err = gm.AdjustBalance(gameID, "brian", 100)
+	assert.ErrorIs(t, err, ErrGameOver)
+}
+
+func TestAdjustBalance(t *testing.T) {
+	gm := newTestManager(t, &recordingHub{})
+	gameID, _ := startedGame(t, gm, "brian")
+
+	_, err := gm.AdjustBalance(gameID, "dave", 100)
+	assert.ErrorIs(t, err, ErrPlayerNotInGame)
+
+	player, err := gm.AdjustBalance(gameID, "brian", 250)
+	require.NoError(t, err)
+	balance := player.Balance
+
+	// A balance can be cut but not below zero
+	_, err = gm.AdjustBalance(gameID, "brian", -balance-1)
+	assert.ErrorIs(t, err, ErrNegativeBalance)
+	player, err = gm.AdjustBalance(gameID, "brian", -balance)
+	require.NoError(t, err)
+	assert.Zero(t, player.Balance)
+}
+
+func TestExpiredForwardedAdjustmentIsRefused(t *testing.T) {
+	gm := newTestManager(t, &recordingHub{})
+	gameID, _ := startedGame(t, gm, "brian")
+	game, err := gm.GetGame(gameID)
+	require.NoError(t, err)
+	balance := findPlayer(game, "brian").Balance
+
+	// The sender stopped waiting before the owner got the game's lock
+	_, err = gm.adjustBalance(gameID, "brian", 100, time.Now().Add(-time.Millisecond))
+	assert.ErrorIs(t, err, ErrOwnerUnavailable)
+	game, err = gm.GetGame(gameID)
+	require.NoError(t, err)
+	assert.Equal(t, balance, findPlayer(game, "brian").Balance)
+
+	player, err := gm.adjustBalance(gameID, "brian", 100, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, balance+100, player.Balance)
+}
+
+func TestRemovePlayer(t *testing.T) {
+	hub := &recordingHub{}
+	gm := newTestManager(t, hub)
+	gameID, _ := startedGame(t, gm, "brian", "carol")
+
+	// Removing the host mid-game forfeits them and hands the game to the next player
+	require.NoError(t, gm.RemovePlayer(gameID, "alice"))
+	game, err := gm.GetGame(gameID)
+	require.NoError(t, err)
+	assert.Equal(t, "brian", game.HostID)
+	assert.Equal(t, models.PlayerStatusForfeited, findPlayer(game, "alice").Status)
+	assert.Equal(t, models.GameStatusActive, game.Status)
+	assert.Contains(t, hub.disconnected, "alice")
+	assert.Contains(t, hub.hosts, "brian")
+
+	// Down to one player, the game is over
+	require.NoError(t, gm.RemovePlayer(gameID, "carol"))
+	assert.Equal(t, models.GameStatusCompleted, status(t, gm, gameID))
+	assert.ErrorIs(t, gm.RemovePlayer(gameID, "brian"), ErrGameOver)
+
+	// A lobby left empty is abandoned
+	lobbyID, err := gm.CreateGame("alice", "wallet-alice", "", 4, 0)
+	require.NoError(t, err)
+	require.NoError(t, gm.RemovePlayer(lobbyID, "alice"))
+	assert.Equal(t, models.GameStatusAbandoned, status(t, gm, lobbyID))
+}
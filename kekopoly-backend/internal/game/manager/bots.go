@@ -0,0 +1,255 @@
+package manager
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/kekopoly/backend/internal/game/bots"
+	"github.com/kekopoly/backend/internal/game/models"
+	"github.com/kekopoly/backend/internal/game/protocol"
+)
+
+// ErrBotBuyIn is returned when adding a bot to a game with a buy-in, which a bot can't pay
+var ErrBotBuyIn = errors.New("bots can't join games with a buy-in")
+
+// BotOptions configures server-side bot players
+type BotOptions struct {
+	// ThinkDelay is the pause before each bot action, so humans can follow the game;
+	// 0 means one second
+	ThinkDelay time.Duration
+	// TakeOver lets a bot play the seat of a player disconnected from an active game
+	// for TakeOverAfter, until they come back
+	TakeOver      bool
+	TakeOverAfter time.Duration // 0 means 45 seconds
+	// TakeOverStrategy is the strategy substitute bots play; empty means heuristic
+	TakeOverStrategy string
+}
+
+// withDefaults fills in the zero values
+func (o BotOptions) withDefaults() BotOptions {
+	if o.ThinkDelay <= 0 {
+		o.ThinkDelay = time.Second
+	}
+	if o.TakeOverAfter <= 0 {
+		o.TakeOverAfter = 45 * time.Second
+	}
+	if o.TakeOverStrategy == "" {
+		o.TakeOverStrategy = bots.StrategyHeuristic
+	}
+	return o
+}
+
+// AddBot seats a bot playing the given strategy in a lobby game. Only the host can add bots.
+func (gm *GameManager) AddBot(gameID, hostID, strategy string) (*models.Player, error) {
+	if reply, handled, err := gm.remote(forwardRequest{Op: opAddBot, GameID: gameID, PlayerID: hostID, Strategy: strategy}); handled {
+		if err != nil {
+			return nil, err
+		}
+		return reply.Player, nil
+	}
+
+	chosen, err := bots.New(strategy)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := gm.hostSession(gameID, hostID)
+	if err != nil {
+		return nil, err
+	}
+	defer session.mutex.Unlock()
+	game := session.Game
+
+	if game.Status != models.GameStatusLobby {
+		return nil, fmt.Errorf("bots can only be added before the game starts")
+	}
+	if game.BuyIn > 0 {
+		return nil, ErrBotBuyIn
+	}
+	if len(game.Players) >= game.MaxPlayers {
+		return nil, fmt.Errorf("game is full")
+	}
+
+	bot := models.Player{
+		ID:         "bot-" + uuid.New().String()[:8],
+		Status:     models.PlayerStatusActive,
+		Balance:    1500, // Initial balance, should come from config
+		Cards:      []models.Card{},
+		Properties: []string{},
+		NetWorth:   1500,
+		Bot:        chosen.Name(),
+	}
+	game.Players = append(game.Players, bot)
+	game.TurnOrder = append(game.TurnOrder, bot.ID)
+	if err := gm.persistPlayers(game); err != nil {
+		return nil, fmt.Errorf("failed to persist bot: %w", err)
+	}
+
+	gm.logger.Infof("Host %s added %s bot %s to game %s", hostID, bot.Bot, bot.ID, game.ID.Hex())
+	gm.notifyListing(game.ID.Hex())
+	gm.broadcast(game.ID.Hex(), &protocol.BotSeat{
+		PlayerID:  bot.ID,
+		Strategy:  bot.Bot,
+		Timestamp: time.Now().Format(time.RFC3339),
+	})
+	return &bot, nil
+}
+
+// scheduleBot starts the current player's turn if a bot holds the seat and isn't already
+// playing. The caller must hold the session lock.
+func (gm *GameManager) scheduleBot(session *GameSession) {
+	game := session.Game
+	if session.botTurn || game.Status != models.GameStatusActive {
+		return
+	}
+	player := findPlayer(game, game.CurrentTurn)
+	if player == nil || player.Bot == "" || player.Status != models.PlayerStatusActive {
+		return
+	}
+	session.botTurn = true
+	go gm.playBotTurn(session, player.ID)
+}
+
+// playBotTurn plays one turn for a bot, pausing before each action. The turn is given
+// up if a human takes the seat back or the game stops part way through.
+func (gm *GameManager) playBotTurn(session *GameSession, playerID string) {
+	defer func() {
+		session.mutex.Lock()
+		session.botTurn = false
+		// Doubles, or another bot next in turn
+		gm.scheduleBot(session)
+		session.mutex.Unlock()
+	}()
+
+	if !gm.botPause() {
+		return
+	}
+	session.mutex.RLock()
+	strategy, ok := gm.botSeat(session, playerID)
+	var actions []models.GameAction
+	if ok {
+		rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+		actions = bots.TakeTurn(strategy, session.Game, playerID, rng)
+	}
+	session.mutex.RUnlock()
+
+	for i, action := range actions {
+		if i > 0 && !gm.botPause() {
+			return
+		}
+		session.mutex.RLock()
+		_, ok := gm.botSeat(session, playerID)
+		session.mutex.RUnlock()
+		if !ok {
+			return
+		}
+
+		if err := gm.ProcessGameAction(action); err != nil {
+			gm.logger.Warnf("Bot %s failed to %s in game %s: %v", playerID, action.Type, action.GameID, err)
+			if action.Type == models.ActionTypeRollDice {
+				return
+			}
+		}
+	}
+}
+
+// botSeat returns the strategy of the bot whose turn it is, if playerID still is one.
+// The caller must hold the session lock.
+func (gm *GameManager) botSeat(session *GameSession, playerID string) (bots.Strategy, bool) {
+	game := session.Game
+	player := findPlayer(game, playerID)
+	if game.Status != models.GameStatusActive || game.CurrentTurn != playerID || player == nil || player.Bot == "" {
+		return nil, false
+	}
+	strategy, err := bots.New(player.Bot)
+	if err != nil {
+		gm.logger.Errorf("Bot %s in game %s has %v", playerID, game.ID.Hex(), err)
+		return nil, false
+	}
+	return strategy, true
+}
+
+// botPause waits before a bot's next action, and reports false if the manager stopped
+func (gm *GameManager) botPause() bool {
+	timer := time.NewTimer(gm.bots.ThinkDelay)
+	defer timer.Stop()
+	select {
+	case <-gm.ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// substituteBot lets a bot play a disconnected player's seat if they still haven't come back
+func (gm *GameManager) substituteBot(gameID, playerID, sessionID string) {
+	gm.activeGamesMutex.RLock()
+	session, exists := gm.activeGames[gameID]
+	gm.activeGamesMutex.RUnlock()
+	if !exists {
+		return
+	}
+
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+	game := session.Game
+
+	if _, reconnected := session.ConnectedPlayers[playerID]; reconnected || game.Status != models.GameStatusActive {
+		return
+	}
+	if connection, ok := session.PlayerConnections[sessionID]; !ok || connection.IsConnected {
+		return
+	}
+	player := findPlayer(game, playerID)
+	if player == nil || player.Status != models.PlayerStatusDisconnected || player.Bot != "" {
+		return
+	}
+	// A pause pushes the takeover back; resuming arms a later timer
+	if player.DisconnectedAt != nil && time.Since(*player.DisconnectedAt) < gm.bots.TakeOverAfter {
+		return
+	}
+
+	player.Status = models.PlayerStatusActive
+	player.Bot = gm.bots.TakeOverStrategy
+	player.Substitute = true
+	if err := gm.persistPlayers(game); err != nil {
+		gm.logger.Errorf("Failed to persist bot takeover of %s in game %s: %v", playerID, gameID, err)
+	}
+
+	gm.logger.Infof("A %s bot took over the seat of disconnected player %s in game %s", player.Bot, playerID, gameID)
+	gm.notifyListing(gameID)
+	gm.broadcast(gameID, &protocol.BotSeat{
+		PlayerID:   playerID,
+		Strategy:   player.Bot,
+		Substitute: true,
+		Timestamp:  time.Now().Format(time.RFC3339),
+	})
+	gm.scheduleBot(session)
+}
+
+// releaseSubstitute hands a seat back to the player a bot was standing in for. It
+// reports whether there was a substitute. The caller must hold the session lock.
+func (gm *GameManager) releaseSubstitute(session *GameSession, player *models.Player) bool {
+	if !player.Substitute {
+		return false
+	}
+	player.Bot = ""
+	player.Substitute = false
+	game := session.Game
+	if err := gm.store.SetGameFields(gm.ctx, game.ID, bson.M{"players": game.Players, "updatedAt": time.Now()}); err != nil {
+		gm.logger.Errorf("Failed to persist return of player %s to game %s: %v", player.ID, game.ID.Hex(), err)
+	}
+
+	gm.logger.Infof("Player %s took their seat back from a bot in game %s", player.ID, game.ID.Hex())
+	gm.notifyListing(game.ID.Hex())
+	gm.broadcast(game.ID.Hex(), &protocol.BotSeat{
+		PlayerID:  player.ID,
+		Timestamp: time.Now().Format(time.RFC3339),
+	})
+	return true
+}
@@ -0,0 +1,142 @@
+package manager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kekopoly/backend/internal/game/bots"
+	"github.com/kekopoly/backend/internal/game/models"
+)
+
+// newBotManager returns a test manager whose bots think quickly. Rolls only end a turn
+// when there is a hub.
+func newBotManager(t *testing.T, opts BotOptions) *GameManager {
+	t.Helper()
+	gm := newTestManager(t, &recordingHub{})
+	opts.ThinkDelay = time.Millisecond
+	gm.bots = opts.withDefaults()
+	return gm
+}
+
+// seat returns whose turn it is and a copy of a player, read under the session lock
+func seat(t *testing.T, gm *GameManager, gameID, playerID string) (string, models.Player) {
+	t.Helper()
+	gm.activeGamesMutex.RLock()
+	session := gm.activeGames[gameID]
+	gm.activeGamesMutex.RUnlock()
+	require.NotNil(t, session)
+
+	session.mutex.RLock()
+	defer session.mutex.RUnlock()
+	player := findPlayer(session.Game, playerID)
+	require.NotNil(t, player)
+	return session.Game.CurrentTurn, *player
+}
+
+// playUntilBotTurn rolls for alice until the turn passes to someone else
+func playUntilBotTurn(t *testing.T, gm *GameManager, gameID string) {
+	t.Helper()
+	for i := 0; i < 20; i++ {
+		if turn, _ := seat(t, gm, gameID, "alice"); turn != "alice" {
+			return
+		}
+		require.NoError(t, gm.ProcessGameAction(models.GameAction{
+			Type:     models.ActionTypeRollDice,
+			PlayerID: "alice",
+			GameID:   gameID,
+		}))
+	}
+	t.Fatal("alice kept the turn")
+}
+
+func TestAddBot(t *testing.T) {
+	gm := newBotManager(t, BotOptions{})
+
+	gameID, err := gm.CreateGame("alice", "wallet-alice", "", 2, 0)
+	require.NoError(t, err)
+	_, err = gm.JoinGame(gameID, "brian", "wallet-brian")
+	require.NoError(t, err)
+
+	_, err = gm.AddBot(gameID, "brian", bots.StrategyGreedy)
+	assert.ErrorIs(t, err, ErrNotHost)
+	_, err = gm.AddBot(gameID, "alice", "cheater")
+	assert.ErrorIs(t, err, bots.ErrUnknownStrategy)
+	_, err = gm.AddBot(gameID, "alice", bots.StrategyGreedy)
+	assert.Error(t, err, "the game is full")
+
+	// Bots can't put up a buy-in
+	openID, err := gm.CreateGame("alice", "wallet-alice", "", 4, 0)
+	require.NoError(t, err)
+	session := gm.activeGames[openID]
+	session.Game.BuyIn = 100
+	_, err = gm.AddBot(openID, "alice", bots.StrategyGreedy)
+	assert.ErrorIs(t, err, ErrBotBuyIn)
+	session.Game.BuyIn = 0
+
+	bot, err := gm.AddBot(openID, "alice", "Random")
+	require.NoError(t, err)
+	assert.Equal(t, bots.StrategyRandom, bot.Bot)
+
+	stored := storedGame(t, gm.store, openID)
+	require.Len(t, stored.Players, 2)
+	assert.Equal(t, bots.StrategyRandom, stored.Players[1].Bot)
+	assert.Contains(t, stored.TurnOrder, bot.ID)
+}
+
+func TestBotPlaysItsTurn(t *testing.T) {
+	gm := newBotManager(t, BotOptions{})
+
+	gameID, err := gm.CreateGame("alice", "wallet-alice", "", 4, 0)
+	require.NoError(t, err)
+	bot, err := gm.AddBot(gameID, "alice", bots.StrategyHeuristic)
+	require.NoError(t, err)
+	require.NoError(t, gm.StartGame(gameID, "alice"))
+
+	// Whoever goes first, the bot rolls and hands the turn back to alice
+	for round := 0; round < 3; round++ {
+		playUntilBotTurn(t, gm, gameID)
+		require.Eventually(t, func() bool {
+			turn, _ := seat(t, gm, gameID, bot.ID)
+			return turn == "alice"
+		}, 5*time.Second, 5*time.Millisecond)
+	}
+	_, played := seat(t, gm, gameID, bot.ID)
+	assert.NotZero(t, played.Position)
+}
+
+func TestBotTakeOver(t *testing.T) {
+	gm := newBotManager(t, BotOptions{TakeOver: true, TakeOverAfter: 20 * time.Millisecond})
+
+	gameID, err := gm.CreateGame("alice", "wallet-alice", "", 4, 0)
+	require.NoError(t, err)
+	brianSession, err := gm.JoinGame(gameID, "brian", "wallet-brian")
+	require.NoError(t, err)
+	gm.PlayerConnected(gameID, "brian", brianSession)
+	require.NoError(t, gm.StartGame(gameID, "alice"))
+
+	// A bot stands in for brian once they have been gone long enough, and plays their turns
+	gm.PlayerDisconnected(gameID, brianSession)
+	require.Eventually(t, func() bool {
+		_, brian := seat(t, gm, gameID, "brian")
+		return brian.Substitute
+	}, 5*time.Second, 5*time.Millisecond)
+	_, brian := seat(t, gm, gameID, "brian")
+	assert.Equal(t, bots.StrategyHeuristic, brian.Bot)
+	assert.Equal(t, models.PlayerStatusActive, brian.Status)
+
+	playUntilBotTurn(t, gm, gameID)
+	require.Eventually(t, func() bool {
+		turn, _ := seat(t, gm, gameID, "brian")
+		return turn == "alice"
+	}, 5*time.Second, 5*time.Millisecond)
+
+	// Coming back hands the seat back
+	gm.PlayerConnected(gameID, "brian", "brian-again")
+	_, brian = seat(t, gm, gameID, "brian")
+	assert.Empty(t, brian.Bot)
+	assert.False(t, brian.Substitute)
+	assert.Empty(t, storedGame(t, gm.store, gameID).Players[1].Bot)
+}
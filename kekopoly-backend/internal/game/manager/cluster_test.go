@@ -0,0 +1,110 @@
+package manager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/kekopoly/backend/internal/game/models"
+)
+
+const testLeaseTTL = 3 * time.Second
+
+// newTestNode starts a game manager as its own node on the shared Redis and store
+func newTestNode(t *testing.T, mr *miniredis.Miniredis, store GameStore, nodeID string) (*GameManager, context.CancelFunc) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() {
+		cancel()
+		client.Close()
+	})
+
+	gm := NewGameManagerWithOptions(ctx, nil, client, zap.NewNop().Sugar(), nil, nil, Options{
+		Store:   store,
+		Cluster: NewCluster(client, nodeID, testLeaseTTL, 2*time.Second),
+	})
+	return gm, func() {
+		cancel()
+		client.Close()
+	}
+}
+
+func TestGameOwnershipAcrossNodes(t *testing.T) {
+	mr := miniredis.RunT(t)
+	store := NewMemoryGameStore()
+
+	nodeA, stopA := newTestNode(t, mr, store, "node-a")
+	nodeB, _ := newTestNode(t, mr, store, "node-b")
+
+	gameID, err := nodeA.CreateGame("alice", "wallet-alice", "", 4, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "node-a", mustGet(t, mr, leaseKey(gameID)))
+
+	// Node B doesn't own the game, so joining and starting run on node A
+	_, err = nodeB.JoinGame(gameID, "bob", "wallet-bob")
+	require.NoError(t, err)
+	require.NoError(t, nodeB.StartGame(gameID, "alice"))
+	assert.False(t, nodeB.holdsLease(gameID))
+
+	stored := storedGame(t, store, gameID)
+	require.Len(t, stored.Players, 2)
+	require.Equal(t, models.GameStatusActive, stored.Status)
+	current := stored.CurrentTurn
+	other := "alice"
+	if current == "alice" {
+		other = "bob"
+	}
+
+	// The owner's errors come back with their message intact
+	err = nodeB.ProcessGameAction(models.GameAction{Type: models.ActionTypeRollDice, GameID: gameID, PlayerID: other})
+	require.EqualError(t, err, "not player's turn")
+	require.NoError(t, nodeB.ProcessGameAction(models.GameAction{Type: models.ActionTypeRollDice, GameID: gameID, PlayerID: current}))
+
+	// Node A dies; once its lease runs out node B takes the game over from the store
+	stopA()
+	mr.FastForward(testLeaseTTL)
+
+	err = nodeB.ProcessGameAction(models.GameAction{Type: models.ActionTypeRollDice, GameID: gameID, PlayerID: other})
+	require.EqualError(t, err, "not player's turn")
+	assert.True(t, nodeB.holdsLease(gameID))
+	assert.Equal(t, "node-b", mustGet(t, mr, leaseKey(gameID)))
+
+	game, err := nodeB.GetGame(gameID)
+	require.NoError(t, err)
+	assert.Equal(t, models.GameStatusActive, game.Status)
+	assert.Len(t, game.Players, 2)
+	assert.Equal(t, current, game.CurrentTurn)
+}
+
+func TestForwardedErrorsKeepTheirSentinel(t *testing.T) {
+	reply := forwardReply{Error: "deposit check: " + ErrDepositsPending.Error(), Kind: "deposits_pending"}
+	err := reply.err()
+	assert.ErrorIs(t, err, ErrDepositsPending)
+	assert.Equal(t, "deposit check: "+ErrDepositsPending.Error(), err.Error())
+
+	assert.NoError(t, (&forwardReply{}).err())
+}
+
+func mustGet(t *testing.T, mr *miniredis.Miniredis, key string) string {
+	t.Helper()
+	value, err := mr.Get(key)
+	require.NoError(t, err)
+	return value
+}
+
+func storedGame(t *testing.T, store GameStore, gameID string) *models.Game {
+	t.Helper()
+	objID, err := primitive.ObjectIDFromHex(gameID)
+	require.NoError(t, err)
+	game, err := store.FindGame(context.Background(), objID)
+	require.NoError(t, err)
+	return game
+}
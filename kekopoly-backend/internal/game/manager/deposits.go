@@ -0,0 +1,310 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/kekopoly/backend/internal/game/models"
+	"github.com/kekopoly/backend/internal/game/protocol"
+	"github.com/kekopoly/backend/internal/settlement"
+)
+
+var (
+	// ErrSettlementDisabled is returned for buy-in operations when no settler is configured
+	ErrSettlementDisabled = errors.New("settlement is not enabled")
+	// ErrNoBuyIn is returned when a deposit is submitted for a player who owes nothing
+	ErrNoBuyIn = errors.New("this game has no buy-in")
+	// ErrDepositAlreadyVerified is returned when a funded player submits another deposit
+	ErrDepositAlreadyVerified = errors.New("deposit already verified")
+	// ErrDepositAlreadySubmitted is returned when a transaction was already credited
+	ErrDepositAlreadySubmitted = errors.New("deposit transaction already submitted")
+	// ErrDepositsPending is returned by StartGame while players are still inside their deposit window
+	ErrDepositsPending = errors.New("waiting for players to pay the buy-in")
+)
+
+// applyBuyIn sets up a new player's deposit obligation for the game
+func (gm *GameManager) applyBuyIn(player *models.Player, buyIn int, now time.Time) {
+	player.InitialDeposit = buyIn
+	if buyIn <= 0 {
+		return
+	}
+	deadline := now.Add(gm.depositTimeout)
+	player.DepositStatus = models.DepositStatusUnpaid
+	player.DepositDeadline = &deadline
+}
+
+// SubmitDeposit verifies a lobby player's buy-in transaction and credits it
+func (gm *GameManager) SubmitDeposit(gameID, playerID, signature string) (*models.Player, error) {
+	if gm.settler == nil {
+		return nil, ErrSettlementDisabled
+	}
+	if reply, handled, err := gm.remote(forwardRequest{Op: opSubmitDeposit, GameID: gameID, PlayerID: playerID, Signature: signature}); handled {
+		if err != nil {
+			return nil, err
+		}
+		return reply.Player, nil
+	}
+
+	gm.activeGamesMutex.RLock()
+	session, exists := gm.activeGames[strings.ToLower(gameID)]
+	gm.activeGamesMutex.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("game session not found")
+	}
+
+	// Validate and snapshot under the lock, but don't hold it during the chain lookup
+	session.mutex.Lock()
+	if session.Game.Status != models.GameStatusLobby {
+		session.mutex.Unlock()
+		return nil, fmt.Errorf("deposits can only be made in the lobby")
+	}
+	player := findPlayer(session.Game, playerID)
+	if err := checkDepositable(player, signature); err != nil {
+		session.mutex.Unlock()
+		return nil, err
+	}
+	snapshot := *session.Game
+	snapshot.Players = append([]models.Player(nil), session.Game.Players...)
+	session.mutex.Unlock()
+
+	ctx, cancel := context.WithTimeout(gm.ctx, 30*time.Second)
+	amount, err := gm.settler.VerifyDeposit(ctx, &snapshot, playerID, signature)
+	cancel()
+	if errors.Is(err, settlement.ErrDepositRecorded) {
+		// Credited before the player was refunded and rejoined
+		return nil, ErrDepositAlreadySubmitted
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+
+	// The player may have been evicted, or credited the same transaction, meanwhile
+	player = findPlayer(session.Game, playerID)
+	if err := checkDepositable(player, signature); err != nil {
+		return nil, err
+	}
+
+	player.DepositedAmount += amount
+	player.DepositTxIDs = append(player.DepositTxIDs, signature)
+	if player.DepositedAmount >= player.InitialDeposit {
+		player.DepositStatus = models.DepositStatusVerified
+	} else {
+		player.DepositStatus = models.DepositStatusPartial
+	}
+	credited := *player
+
+	if err := gm.persistPlayers(session.Game); err != nil {
+		gm.logger.Errorf("Failed to persist deposit for player %s in game %s: %v", playerID, gameID, err)
+	}
+
+	gm.broadcast(session.Game.ID.Hex(), &protocol.DepositStatus{
+		PlayerID:        playerID,
+		DepositStatus:   credited.DepositStatus,
+		DepositedAmount: credited.DepositedAmount,
+		BuyIn:           credited.InitialDeposit,
+		Timestamp:       time.Now().Format(time.RFC3339),
+	})
+
+	gm.logger.Infof("Player %s deposited %d/%d for game %s", playerID, credited.DepositedAmount, credited.InitialDeposit, gameID)
+	return &credited, nil
+}
+
+// checkDepositable reports why a player can't be credited with signature, if anything
+func checkDepositable(player *models.Player, signature string) error {
+	if player == nil {
+		return fmt.Errorf("player not found in game")
+	}
+	if player.InitialDeposit <= 0 {
+		return ErrNoBuyIn
+	}
+	if player.DepositStatus == models.DepositStatusVerified {
+		return ErrDepositAlreadyVerified
+	}
+	for _, txID := range player.DepositTxIDs {
+		if txID == signature {
+			return ErrDepositAlreadySubmitted
+		}
+	}
+	return nil
+}
+
+// enforceDeposits is the StartGame gate. Players still inside their deposit window block
+// the start; unfunded players past it are evicted and any partial deposit is refunded.
+// The caller must hold the session lock.
+func (gm *GameManager) enforceDeposits(session *GameSession) error {
+	game := session.Game
+	if game.BuyIn <= 0 {
+		return nil
+	}
+
+	waiting, expired := depositGate(game, time.Now())
+
+	if len(waiting) > 0 {
+		return fmt.Errorf("%w: %s", ErrDepositsPending, strings.Join(waiting, ", "))
+	}
+	if len(expired) == 0 {
+		return nil
+	}
+
+	for _, p := range expired {
+		gm.evictPlayer(session, p, "deposit_timeout")
+	}
+	if err := gm.persistPlayers(game); err != nil {
+		return fmt.Errorf("failed to persist evictions: %w", err)
+	}
+
+	return nil
+}
+
+// depositGate splits unfunded players into those still inside their deposit window
+// (or the host) and those past it
+func depositGate(game *models.Game, now time.Time) (waiting []string, expired []models.Player) {
+	for _, p := range game.Players {
+		if p.InitialDeposit <= 0 || p.DepositStatus == models.DepositStatusVerified {
+			continue
+		}
+		// The host is never evicted from their own game
+		if p.ID == game.HostID || p.DepositDeadline == nil || now.Before(*p.DepositDeadline) {
+			waiting = append(waiting, p.ID)
+			continue
+		}
+		expired = append(expired, p)
+	}
+	return waiting, expired
+}
+
+// evictPlayer removes a player from the game and refunds any buy-in they sent. reason is
+// deposit_timeout for players who never paid in time, kicked or banned when the host
+// removed them, or removed when an operator did. The caller must hold the session lock.
+func (gm *GameManager) evictPlayer(session *GameSession, player models.Player, reason string) {
+	game := session.Game
+
+	players := game.Players[:0]
+	for _, p := range game.Players {
+		if p.ID != player.ID {
+			players = append(players, p)
+		}
+	}
+	game.Players = players
+
+	turnOrder := game.TurnOrder[:0]
+	for _, id := range game.TurnOrder {
+		if id != player.ID {
+			turnOrder = append(turnOrder, id)
+		}
+	}
+	game.TurnOrder = turnOrder
+
+	if sessionID, ok := session.ConnectedPlayers[player.ID]; ok {
+		delete(session.PlayerConnections, sessionID)
+		delete(session.ConnectedPlayers, player.ID)
+	}
+
+	gm.logger.Infof("Evicted player %s from game %s: %s", player.ID, game.ID.Hex(), reason)
+	gm.notifyListing(game.ID.Hex())
+
+	gm.broadcast(game.ID.Hex(), &protocol.PlayerEvicted{
+		PlayerID:  player.ID,
+		Reason:    reason,
+		Timestamp: time.Now().Format(time.RFC3339),
+	})
+
+	gm.refundDeposit(game, player)
+}
+
+// pendingRefund is a buy-in owed back before the manager had a settler to send it
+type pendingRefund struct {
+	gameID primitive.ObjectID
+	player models.Player
+}
+
+// refundDeposit returns any buy-in the player sent, in the background. The settler
+// retries failed refunds itself. Lobbies abandoned while the manager starts are refunded
+// once SetSettler is called.
+func (gm *GameManager) refundDeposit(game *models.Game, player models.Player) {
+	if player.DepositedAmount <= 0 {
+		return
+	}
+	gm.refundsMutex.Lock()
+	settler := gm.settler
+	if settler == nil {
+		gm.pendingRefunds = append(gm.pendingRefunds, pendingRefund{gameID: game.ID, player: player})
+		gm.refundsMutex.Unlock()
+		return
+	}
+	gm.refundsMutex.Unlock()
+
+	gameRef := &models.Game{ID: game.ID}
+	go func() {
+		if err := settler.Refund(gm.ctx, gameRef, player); err != nil {
+			gm.logger.Errorf("Failed to refund player %s for game %s: %v", player.ID, gameRef.ID.Hex(), err)
+		}
+	}()
+}
+
+// abandonGame ends a game nobody is going to finish as ABANDONED and refunds every
+// buy-in paid into it. The cleanup tasks use it for lobbies left behind by a restart
+// and for games left idle. Callers holding the game's session must hold its lock.
+func (gm *GameManager) abandonGame(game *models.Game, reason string) error {
+	now := time.Now()
+	if err := gm.store.SetGameFields(gm.ctx, game.ID, bson.M{
+		"status":    models.GameStatusAbandoned,
+		"updatedAt": now,
+	}); err != nil {
+		return fmt.Errorf("failed to abandon game %s: %w", game.ID.Hex(), err)
+	}
+	game.Status = models.GameStatusAbandoned
+	game.UpdatedAt = now
+
+	gm.logger.Infof("Abandoned game %s: %s", game.ID.Hex(), reason)
+	gm.broadcast(game.ID.Hex(), &protocol.GameEnded{
+		Status:    string(models.GameStatusAbandoned),
+		Timestamp: now.Format(time.RFC3339),
+	})
+	for _, player := range game.Players {
+		gm.refundDeposit(game, player)
+	}
+	return nil
+}
+
+// persistPlayers writes the game's players and turn order to the database
+func (gm *GameManager) persistPlayers(game *models.Game) error {
+	return gm.store.SetGameFields(gm.ctx, game.ID, bson.M{
+		"players":      game.Players,
+		"turnOrder":    game.TurnOrder,
+		"updatedAt":    time.Now(),
+		"lastActivity": time.Now(),
+	})
+}
+
+// broadcast sends a message to everyone in the game if a hub is attached
+func (gm *GameManager) broadcast(gameID string, message protocol.Outbound) {
+	if gm.wsHub == nil {
+		return
+	}
+	msgBytes, err := protocol.Encode(message)
+	if err != nil {
+		gm.logger.Errorf("%v", err)
+		return
+	}
+	gm.wsHub.BroadcastToGame(gameID, msgBytes)
+}
+
+// findPlayer returns a pointer to the player in the game, or nil
+func findPlayer(game *models.Game, playerID string) *models.Player {
+	for i := range game.Players {
+		if game.Players[i].ID == playerID {
+			return &game.Players[i]
+		}
+	}
+	return nil
+}
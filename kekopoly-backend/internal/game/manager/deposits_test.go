@@ -0,0 +1,165 @@
+package manager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/kekopoly/backend/internal/game/models"
+	"github.com/kekopoly/backend/internal/settlement"
+)
+
+func TestDepositGateWaitsThenExpires(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Minute)
+
+	game := &models.Game{
+		HostID: "host",
+		BuyIn:  100,
+		Players: []models.Player{
+			{ID: "host", InitialDeposit: 100, DepositStatus: models.DepositStatusUnpaid, DepositDeadline: &past},
+			{ID: "paid", InitialDeposit: 100, DepositStatus: models.DepositStatusVerified, DepositDeadline: &past},
+			{ID: "late", InitialDeposit: 100, DepositStatus: models.DepositStatusPartial, DepositDeadline: &past},
+			{ID: "early", InitialDeposit: 100, DepositStatus: models.DepositStatusUnpaid, DepositDeadline: &future},
+		},
+	}
+
+	waiting, expired := depositGate(game, now)
+	assert.Equal(t, []string{"host", "early"}, waiting)
+	assert.Len(t, expired, 1)
+	assert.Equal(t, "late", expired[0].ID)
+
+	// Once the window closes, only the host still blocks the start
+	waiting, expired = depositGate(game, future.Add(time.Second))
+	assert.Equal(t, []string{"host"}, waiting)
+	assert.Len(t, expired, 2)
+}
+
+func TestCheckDepositable(t *testing.T) {
+	assert.Error(t, checkDepositable(nil, "sig"))
+	assert.ErrorIs(t, checkDepositable(&models.Player{}, "sig"), ErrNoBuyIn)
+	assert.ErrorIs(t, checkDepositable(&models.Player{InitialDeposit: 10, DepositStatus: models.DepositStatusVerified}, "sig"), ErrDepositAlreadyVerified)
+	assert.ErrorIs(t, checkDepositable(&models.Player{InitialDeposit: 10, DepositTxIDs: []string{"sig"}}, "sig"), ErrDepositAlreadySubmitted)
+	assert.NoError(t, checkDepositable(&models.Player{InitialDeposit: 10, DepositTxIDs: []string{"other"}}, "sig"))
+}
+
+// withSettler gives the manager a settlement service paying out on a fake chain
+func withSettler(t *testing.T, gm *GameManager) *settlement.FakeChain {
+	t.Helper()
+	chain := settlement.NewFakeChain()
+	settler, err := settlement.NewService(chain, settlement.NewMemoryStore(), gm, settlement.Config{
+		PayoutShares:  []int{100},
+		MaxAttempts:   1,
+		RetryInterval: time.Second,
+	}, zap.NewNop().Sugar())
+	require.NoError(t, err)
+	gm.SetSettler(settler, 100, time.Minute)
+	return chain
+}
+
+// requireRefund waits for a refund of amount to reach wallet on the chain
+func requireRefund(t *testing.T, chain *settlement.FakeChain, wallet string, amount uint64) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		for _, payout := range chain.Payouts() {
+			if payout.Wallet == wallet && payout.Amount == amount {
+				return true
+			}
+		}
+		return false
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestIdleLobbyCleanupRefundsDeposits(t *testing.T) {
+	gm := newTestManager(t, &recordingHub{})
+	chain := withSettler(t, gm)
+
+	gameID, err := gm.CreateGame("alice", "wallet-alice", "", 4, 100)
+	require.NoError(t, err)
+	_, err = gm.JoinGame(gameID, "bob", "wallet-bob")
+	require.NoError(t, err)
+
+	// Alice paid her buy-in, then everyone wandered off for a day
+	gm.activeGamesMutex.RLock()
+	session := gm.activeGames[gameID]
+	gm.activeGamesMutex.RUnlock()
+	session.mutex.Lock()
+	alice := findPlayer(session.Game, "alice")
+	alice.DepositedAmount = 100
+	alice.DepositTxIDs = []string{"deposit-alice"}
+	alice.DepositStatus = models.DepositStatusVerified
+	session.Game.LastActivity = time.Now().Add(-25 * time.Hour)
+	session.mutex.Unlock()
+
+	gm.cleanupExpiredSessions()
+
+	requireRefund(t, chain, "wallet-alice", 100)
+	assert.Len(t, chain.Payouts(), 1, "bob paid nothing")
+	game, err := gm.store.FindGame(context.Background(), session.Game.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.GameStatusAbandoned, game.Status)
+}
+
+func TestRestartRefundsLobbyDeposits(t *testing.T) {
+	store := NewMemoryGameStore()
+	lobby := &models.Game{
+		ID:     primitive.NewObjectID(),
+		Status: models.GameStatusLobby,
+		BuyIn:  100,
+		Players: []models.Player{
+			{ID: "alice", WalletAddress: "wallet-alice", InitialDeposit: 100, DepositedAmount: 100, DepositTxIDs: []string{"deposit-alice"}, DepositStatus: models.DepositStatusVerified},
+			{ID: "bob", WalletAddress: "wallet-bob", InitialDeposit: 100},
+		},
+	}
+	require.NoError(t, store.InsertGame(context.Background(), lobby))
+
+	// The lobby is abandoned as the manager starts, before settlement is set up
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	gm := NewGameManagerWithOptions(ctx, nil, nil, zap.NewNop().Sugar(), nil, nil, Options{Store: store})
+	game, err := store.FindGame(context.Background(), lobby.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.GameStatusAbandoned, game.Status)
+
+	chain := withSettler(t, gm)
+	requireRefund(t, chain, "wallet-alice", 100)
+	assert.Len(t, chain.Payouts(), 1)
+}
+
+func TestRejoinedPlayerCantResubmitDeposit(t *testing.T) {
+	gm := newTestManager(t, &recordingHub{})
+	chain := withSettler(t, gm)
+
+	gameID, err := gm.CreateGame("alice", "wallet-alice", "", 4, 100)
+	require.NoError(t, err)
+	_, err = gm.JoinGame(gameID, "bob", "wallet-bob")
+	require.NoError(t, err)
+
+	sig := chain.Deposit("wallet-bob", 100, settlement.DepositReference(gameID))
+	_, err = gm.SubmitDeposit(gameID, "bob", sig)
+	require.NoError(t, err)
+
+	// Bob is removed and refunded, then rejoins and tries the same transaction again
+	require.NoError(t, gm.RemovePlayer(gameID, "bob"))
+	requireRefund(t, chain, "wallet-bob", 100)
+	_, err = gm.JoinGame(gameID, "bob", "wallet-bob")
+	require.NoError(t, err)
+
+	_, err = gm.SubmitDeposit(gameID, "bob", sig)
+	assert.ErrorIs(t, err, ErrDepositAlreadySubmitted)
+
+	// A new deposit is credited and refunded on its own
+	sig = chain.Deposit("wallet-bob", 100, settlement.DepositReference(gameID))
+	_, err = gm.SubmitDeposit(gameID, "bob", sig)
+	require.NoError(t, err)
+	require.NoError(t, gm.RemovePlayer(gameID, "bob"))
+	require.Eventually(t, func() bool {
+		return len(chain.Payouts()) == 2
+	}, 2*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,335 @@
+package manager
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/kekopoly/backend/internal/game/bots"
+	"github.com/kekopoly/backend/internal/game/models"
+	"github.com/kekopoly/backend/internal/settlement"
+)
+
+// forwardOp names a GameManager operation that runs on the game's owner
+type forwardOp string
+
+const (
+	opJoinGame           forwardOp = "join_game"
+	opStartGame          forwardOp = "start_game"
+	opGameAction         forwardOp = "game_action"
+	opSubmitDeposit      forwardOp = "submit_deposit"
+	opUpdateGame         forwardOp = "update_game"
+	opResetGame          forwardOp = "reset_game"
+	opPlayerConnected    forwardOp = "player_connected"
+	opPlayerDisconnected forwardOp = "player_disconnected"
+	opKickPlayer         forwardOp = "kick_player"
+	opBanPlayer          forwardOp = "ban_player"
+	opLockGame           forwardOp = "lock_game"
+	opTransferHost       forwardOp = "transfer_host"
+	opAddBot             forwardOp = "add_bot"
+	opPauseGame          forwardOp = "pause_game"
+	opResumeGame         forwardOp = "resume_game"
+	opEndGame            forwardOp = "end_game"
+	opAdjustBalance      forwardOp = "adjust_balance"
+	opRemovePlayer       forwardOp = "remove_player"
+)
+
+// forwardGrace is how long past a forwarded request's deadline the sender waits for a reply
+const forwardGrace = 2 * time.Second
+
+// forwardRequest carries an operation to the node that owns the game
+type forwardRequest struct {
+	ID        string             `json:"id"`
+	Op        forwardOp          `json:"op"`
+	GameID    string             `json:"gameId"`
+	PlayerID  string             `json:"playerId,omitempty"`
+	SessionID string             `json:"sessionId,omitempty"`
+	Wallet    string             `json:"wallet,omitempty"`
+	Signature string             `json:"signature,omitempty"`
+	Action    *models.GameAction `json:"action,omitempty"`
+	Game      []byte             `json:"game,omitempty"` // BSON, so every stored field survives
+	Join      *JoinOptions       `json:"join,omitempty"`
+	Target    string             `json:"target,omitempty"` // Player a host control applies to
+	Locked    bool               `json:"locked,omitempty"`
+	Strategy  string             `json:"strategy,omitempty"` // Bot strategy to add
+	Status    models.GameStatus  `json:"status,omitempty"`   // Status an operator ends the game with
+	Amount    int                `json:"amount,omitempty"`   // Balance adjustment
+	Deadline  time.Time          `json:"deadline"`
+}
+
+// forwardReply is the owner's answer to a forwardRequest
+type forwardReply struct {
+	Result   string         `json:"result,omitempty"`
+	Player   *models.Player `json:"player,omitempty"`
+	Error    string         `json:"error,omitempty"`
+	Kind     string         `json:"kind,omitempty"` // Sentinel error the caller can match with errors.Is
+	NotOwner bool           `json:"notOwner,omitempty"`
+}
+
+// forwardedErrors are the sentinel errors callers match on, keyed by a stable name
+var forwardedErrors = map[string]error{
+	"settlement_disabled":       ErrSettlementDisabled,
+	"no_buy_in":                 ErrNoBuyIn,
+	"deposit_already_verified":  ErrDepositAlreadyVerified,
+	"deposit_already_submitted": ErrDepositAlreadySubmitted,
+	"deposits_pending":          ErrDepositsPending,
+	"game_not_found":            ErrGameNotFound,
+	"owner_unavailable":         ErrOwnerUnavailable,
+	"deposit_not_found":         settlement.ErrDepositNotFound,
+	"deposit_mismatch":          settlement.ErrDepositMismatch,
+	"invalid_access":            ErrInvalidAccess,
+	"not_host":                  ErrNotHost,
+	"player_not_in_game":        ErrPlayerNotInGame,
+	"wrong_password":            ErrWrongPassword,
+	"invite_required":           ErrInviteRequired,
+	"invalid_invite":            ErrInvalidInvite,
+	"game_locked":               ErrGameLocked,
+	"banned":                    ErrBanned,
+	"bot_buy_in":                ErrBotBuyIn,
+	"unknown_strategy":          bots.ErrUnknownStrategy,
+	"game_paused":               ErrGamePaused,
+	"game_not_active":           ErrGameNotActive,
+	"game_not_paused":           ErrGameNotPaused,
+	"cannot_vote":               ErrCannotVote,
+	"game_over":                 ErrGameOver,
+	"invalid_ending":            ErrInvalidEnding,
+	"negative_balance":          ErrNegativeBalance,
+	"not_abandoned":             ErrNotAbandoned,
+}
+
+// remoteError is an error returned by the owning node. It keeps the owner's message
+// and still matches the sentinel it wrapped.
+type remoteError struct {
+	msg      string
+	sentinel error
+}
+
+func (e *remoteError) Error() string { return e.msg }
+func (e *remoteError) Unwrap() error { return e.sentinel }
+
+// err rebuilds the owner's error, if any
+func (r *forwardReply) err() error {
+	if r.Error == "" {
+		return nil
+	}
+	return &remoteError{msg: r.Error, sentinel: forwardedErrors[r.Kind]}
+}
+
+// requestQueue is the Redis list a node takes forwarded requests from
+func requestQueue(nodeID string) string {
+	return fmt.Sprintf("kekopoly:node:%s:requests", nodeID)
+}
+
+// replyKey is the Redis list a forwarded request's reply is pushed to
+func replyKey(requestID string) string {
+	return fmt.Sprintf("kekopoly:reply:%s", requestID)
+}
+
+// remote runs an operation on the game's owner when that is another node. handled is
+// false when this node owns the game and should run the operation itself.
+func (gm *GameManager) remote(req forwardRequest) (reply *forwardReply, handled bool, err error) {
+	if gm.cluster == nil {
+		return nil, false, nil
+	}
+	req.GameID = gm.resolveGameID(req.GameID)
+
+	// A lease can move while a request is in flight; look the owner up once more if so
+	for attempt := 0; attempt < 2; attempt++ {
+		owner, err := gm.ownerOf(req.GameID)
+		if err != nil {
+			return nil, true, err
+		}
+		if owner == gm.cluster.nodeID {
+			return nil, false, nil
+		}
+
+		reply, err := gm.forward(owner, req)
+		if err != nil {
+			return nil, true, err
+		}
+		if reply.NotOwner {
+			continue
+		}
+		return reply, true, reply.err()
+	}
+	return nil, true, fmt.Errorf("%w: game %s keeps changing owner", ErrOwnerUnavailable, req.GameID)
+}
+
+// forward sends a request to a node and waits for its reply
+func (gm *GameManager) forward(nodeID string, req forwardRequest) (*forwardReply, error) {
+	timeout := gm.cluster.forwardTimeout
+	req.ID = uuid.New().String()
+	req.Deadline = time.Now().Add(timeout)
+
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode forwarded request: %w", err)
+	}
+
+	queue := requestQueue(nodeID)
+	pipe := gm.cluster.client.TxPipeline()
+	pipe.LPush(gm.ctx, queue, payload)
+	// Requests to a node that died shouldn't pile up forever
+	pipe.Expire(gm.ctx, queue, 10*timeout)
+	if _, err := pipe.Exec(gm.ctx); err != nil {
+		return nil, fmt.Errorf("failed to forward request to node %s: %w", nodeID, err)
+	}
+
+	// The owner refuses operator requests it locks the game for after the deadline, but
+	// may still be finishing one it locked just before, so wait a little past it
+	result, err := gm.cluster.client.BRPop(gm.ctx, timeout+forwardGrace, replyKey(req.ID)).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("%w: node %s did not answer %s for game %s", ErrOwnerUnavailable, nodeID, req.Op, req.GameID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read reply from node %s: %w", nodeID, err)
+	}
+
+	var reply forwardReply
+	if err := json.Unmarshal([]byte(result[1]), &reply); err != nil {
+		return nil, fmt.Errorf("failed to decode reply from node %s: %w", nodeID, err)
+	}
+	return &reply, nil
+}
+
+// serveForwarded runs requests other nodes forward to this one until the manager stops
+func (gm *GameManager) serveForwarded() {
+	queue := requestQueue(gm.cluster.nodeID)
+	for gm.ctx.Err() == nil {
+		result, err := gm.cluster.client.BRPop(gm.ctx, time.Second, queue).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			if gm.ctx.Err() != nil {
+				return
+			}
+			gm.logger.Errorf("[Cluster] Failed to read forwarded requests: %v", err)
+			time.Sleep(time.Second)
+			continue
+		}
+		if gm.ctx.Err() != nil {
+			// Stopping; the sender times out and retries against the next owner
+			return
+		}
+		go gm.handleForwarded(result[1])
+	}
+}
+
+// handleForwarded runs one forwarded request and pushes the reply
+func (gm *GameManager) handleForwarded(payload string) {
+	var req forwardRequest
+	if err := json.Unmarshal([]byte(payload), &req); err != nil {
+		gm.logger.Errorf("[Cluster] Dropping malformed forwarded request: %v", err)
+		return
+	}
+	if time.Now().After(req.Deadline) {
+		gm.logger.Warnf("[Cluster] Dropping expired %s request for game %s", req.Op, req.GameID)
+		return
+	}
+
+	var reply forwardReply
+	if !gm.holdsLease(req.GameID) {
+		reply.NotOwner = true
+	} else {
+		result, player, err := gm.runForwarded(req)
+		reply.Result = result
+		reply.Player = player
+		if err != nil {
+			reply.Error = err.Error()
+			for kind, sentinel := range forwardedErrors {
+				if errors.Is(err, sentinel) {
+					reply.Kind = kind
+					break
+				}
+			}
+		}
+	}
+
+	encoded, err := json.Marshal(reply)
+	if err != nil {
+		gm.logger.Errorf("[Cluster] Failed to encode reply to %s: %v", req.ID, err)
+		return
+	}
+	key := replyKey(req.ID)
+	pipe := gm.cluster.client.TxPipeline()
+	pipe.LPush(gm.ctx, key, encoded)
+	pipe.Expire(gm.ctx, key, gm.cluster.forwardTimeout)
+	if _, err := pipe.Exec(gm.ctx); err != nil {
+		gm.logger.Errorf("[Cluster] Failed to reply to %s: %v", req.ID, err)
+	}
+}
+
+// runForwarded dispatches a forwarded request to the local operation
+func (gm *GameManager) runForwarded(req forwardRequest) (string, *models.Player, error) {
+	switch req.Op {
+	case opJoinGame:
+		var opts JoinOptions
+		if req.Join != nil {
+			opts = *req.Join
+		}
+		sessionID, err := gm.JoinGameWithOptions(req.GameID, req.PlayerID, req.Wallet, opts)
+		return sessionID, nil, err
+	case opStartGame:
+		return "", nil, gm.StartGame(req.GameID, req.PlayerID)
+	case opGameAction:
+		if req.Action == nil {
+			return "", nil, fmt.Errorf("missing game action")
+		}
+		return "", nil, gm.ProcessGameAction(*req.Action)
+	case opSubmitDeposit:
+		player, err := gm.SubmitDeposit(req.GameID, req.PlayerID, req.Signature)
+		return "", player, err
+	case opUpdateGame:
+		var game models.Game
+		if err := bson.Unmarshal(req.Game, &game); err != nil {
+			return "", nil, fmt.Errorf("failed to decode game: %w", err)
+		}
+		return "", nil, gm.UpdateGame(&game)
+	case opResetGame:
+		return "", nil, gm.ResetGameStatus(req.GameID, req.PlayerID)
+	case opPlayerConnected:
+		gm.PlayerConnected(req.GameID, req.PlayerID, req.SessionID)
+		return "", nil, nil
+	case opPlayerDisconnected:
+		gm.PlayerDisconnected(req.GameID, req.SessionID)
+		return "", nil, nil
+	case opKickPlayer:
+		return "", nil, gm.KickPlayer(req.GameID, req.PlayerID, req.Target)
+	case opBanPlayer:
+		return "", nil, gm.BanPlayer(req.GameID, req.PlayerID, req.Target)
+	case opLockGame:
+		return "", nil, gm.LockGame(req.GameID, req.PlayerID, req.Locked)
+	case opTransferHost:
+		return "", nil, gm.TransferHost(req.GameID, req.PlayerID, req.Target)
+	case opAddBot:
+		player, err := gm.AddBot(req.GameID, req.PlayerID, req.Strategy)
+		return "", player, err
+	case opPauseGame:
+		paused, err := gm.PauseGame(req.GameID, req.PlayerID)
+		if paused {
+			return "paused", nil, err
+		}
+		return "", nil, err
+	case opResumeGame:
+		resumed, err := gm.ResumeGame(req.GameID, req.PlayerID)
+		if resumed {
+			return "resumed", nil, err
+		}
+		return "", nil, err
+	case opEndGame:
+		return "", nil, gm.endGame(req.GameID, req.Status, req.Target, req.Deadline)
+	case opAdjustBalance:
+		player, err := gm.adjustBalance(req.GameID, req.Target, req.Amount, req.Deadline)
+		return "", player, err
+	case opRemovePlayer:
+		return "", nil, gm.removePlayer(req.GameID, req.Target, req.Deadline)
+	default:
+		return "", nil, fmt.Errorf("unknown forwarded operation %q", req.Op)
+	}
+}
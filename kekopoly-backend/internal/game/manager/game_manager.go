@@ -2,7 +2,6 @@ package manager
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"math/rand"
@@ -18,6 +17,7 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/kekopoly/backend/internal/game/models"
+	"github.com/kekopoly/backend/internal/game/protocol"
 	"github.com/kekopoly/backend/internal/game/utils"
 )
 
@@ -40,6 +40,42 @@ type GameManager struct {
 	storage          Storage
 	wsHub            WebSocketHub
 	messageQueue     MessageQueue
+	settler          Settler
+	pendingRefunds   []pendingRefund // Refunds owed before the settler was set
+	refundsMutex     sync.Mutex
+	stake            int           // Default buy-in when settlement is enabled
+	depositTimeout   time.Duration // How long a lobby player has to pay the buy-in
+	store            GameStore
+	cluster          *Cluster             // Nil when this node runs every game itself
+	leases           map[string]time.Time // Game ID -> when this node's lease runs out
+	leaseMutex       sync.Mutex
+	takeoverMutex    sync.Mutex
+	inviteKey        []byte        // Signs invite tokens
+	inviteTTL        time.Duration // How long invites last unless the host says otherwise
+	bots             BotOptions
+	maxPause         time.Duration // How long a game stays paused before resuming by itself
+	rng              *rand.Rand    // Rolls dice and orders turns when set; nil uses the clock
+}
+
+// Options configures a GameManager beyond the defaults
+type Options struct {
+	// Store persists games; defaults to MongoDB when a client is given
+	Store GameStore
+	// Cluster shares games with other nodes; nil runs every game on this node
+	Cluster *Cluster
+	// InviteSecret signs game invites. Nodes sharing games need the same secret; when
+	// empty a random one is used and invites only work on this node until it restarts.
+	InviteSecret string
+	// InviteTTL is how long invites last by default; 0 means 24 hours
+	InviteTTL time.Duration
+	// Bots configures bot players and whether they stand in for disconnected players
+	Bots BotOptions
+	// MaxPause is how long a paused game waits before resuming by itself; 0 means 10 minutes
+	MaxPause time.Duration
+	// Rand, when set, rolls the dice and shuffles the turn order, so games can be replayed
+	// from a seed. It isn't safe for concurrent use, so only set it for a manager whose
+	// games are played from one goroutine.
+	Rand *rand.Rand
 }
 
 // WebSocketHub defines the interface for broadcasting messages to clients
@@ -47,6 +83,18 @@ type WebSocketHub interface {
 	BroadcastToGame(gameID string, message []byte)
 }
 
+// StatePublisher is implemented by hubs that send the game state to clients that
+// subscribed to it
+type StatePublisher interface {
+	PublishState(gameID string, game *models.Game)
+}
+
+// ListingNotifier is implemented by hubs that keep lobby clients' game listings up to
+// date. It is told whenever a game's status or players change.
+type ListingNotifier interface {
+	GameListingChanged(gameID string)
+}
+
 // MessageQueue defines the interface for the message queue
 type MessageQueue interface {
 	EnqueuePlayerTokenUpdate(gameID, playerID string, tokenData map[string]interface{}) error
@@ -54,12 +102,20 @@ type MessageQueue interface {
 	EnqueueGameStart(gameID string, hostID string, data map[string]interface{}) error
 }
 
+// Settler verifies escrow deposits, refunds them and pays out finished games
+type Settler interface {
+	VerifyDeposit(ctx context.Context, game *models.Game, playerID, signature string) (int, error)
+	Refund(ctx context.Context, game *models.Game, player models.Player) error
+	Settle(ctx context.Context, game *models.Game) error
+}
+
 // GameSession represents an active game session
 type GameSession struct {
 	Game              *models.Game
 	ConnectedPlayers  map[string]string // playerID -> sessionID
 	PlayerConnections map[string]PlayerConnection
 	mutex             sync.RWMutex
+	botTurn           bool // A bot is playing its turn
 }
 
 // PlayerConnection holds a player's connection information
@@ -72,6 +128,11 @@ type PlayerConnection struct {
 
 // NewGameManager creates a new game manager instance
 func NewGameManager(ctx context.Context, mongoClient *mongo.Client, redisClient *redis.Client, logger *zap.SugaredLogger, wsHub WebSocketHub, messageQueue MessageQueue) *GameManager {
+	return NewGameManagerWithOptions(ctx, mongoClient, redisClient, logger, wsHub, messageQueue, Options{})
+}
+
+// NewGameManagerWithOptions creates a game manager with a custom store or cluster membership
+func NewGameManagerWithOptions(ctx context.Context, mongoClient *mongo.Client, redisClient *redis.Client, logger *zap.SugaredLogger, wsHub WebSocketHub, messageQueue MessageQueue, opts Options) *GameManager {
 	manager := &GameManager{
 		ctx:          ctx,
 		mongoClient:  mongoClient,
@@ -82,6 +143,28 @@ func NewGameManager(ctx context.Context, mongoClient *mongo.Client, redisClient
 		games:        make(map[string]*models.Game),
 		wsHub:        wsHub,
 		messageQueue: messageQueue,
+		store:        opts.Store,
+		cluster:      opts.Cluster,
+		inviteKey:    inviteKey(opts.InviteSecret),
+		inviteTTL:    opts.InviteTTL,
+		bots:         opts.Bots.withDefaults(),
+		maxPause:     opts.MaxPause,
+		rng:          opts.Rand,
+	}
+	if opts.InviteSecret == "" {
+		logger.Warn("No invite secret set; game invites will stop working when this node restarts")
+	}
+	if manager.inviteTTL <= 0 {
+		manager.inviteTTL = defaultInviteTTL
+	}
+	if manager.maxPause <= 0 {
+		manager.maxPause = defaultMaxPause
+	}
+	if manager.store == nil && mongoClient != nil {
+		manager.store = NewMongoGameStore(mongoClient, manager.dbName)
+	}
+	if manager.cluster != nil {
+		manager.leases = make(map[string]time.Time)
 	}
 
 	// First cleanup lobby games immediately on server start (synchronously)
@@ -91,6 +174,12 @@ func NewGameManager(ctx context.Context, mongoClient *mongo.Client, redisClient
 	// Begin background cleanup task
 	go manager.runCleanupTask()
 
+	if manager.cluster != nil {
+		go manager.runLeaseRenewal()
+		go manager.serveForwarded()
+		logger.Infof("[Cluster] Game manager running as node %s", manager.cluster.nodeID)
+	}
+
 	return manager
 }
 
@@ -100,12 +189,35 @@ func (gm *GameManager) SetWebSocketHub(hub WebSocketHub) {
 	gm.logger.Info("WebSocket hub set for game manager")
 }
 
+// WebSocketHub returns the WebSocket hub the game manager broadcasts through
+func (gm *GameManager) WebSocketHub() WebSocketHub {
+	return gm.wsHub
+}
+
 // SetMessageQueue sets the message queue for the game manager
 func (gm *GameManager) SetMessageQueue(queue MessageQueue) {
 	gm.messageQueue = queue
 	gm.logger.Info("Message queue set for game manager")
 }
 
+// SetSettler enables on-chain settlement. stake is the buy-in for games created without one;
+// players who haven't paid within depositTimeout can be evicted when the host starts the game.
+func (gm *GameManager) SetSettler(settler Settler, stake int, depositTimeout time.Duration) {
+	gm.refundsMutex.Lock()
+	gm.settler = settler
+	pending := gm.pendingRefunds
+	gm.pendingRefunds = nil
+	gm.refundsMutex.Unlock()
+	gm.stake = stake
+	gm.depositTimeout = depositTimeout
+	gm.logger.Infof("Settlement enabled for game manager with default buy-in %d", stake)
+
+	// Lobbies abandoned as the manager started, before it had a settler
+	for _, refund := range pending {
+		gm.refundDeposit(&models.Game{ID: refund.gameID}, refund.player)
+	}
+}
+
 // cleanupLobbyGamesAndLoadActive ensures lobby games are cleaned up before loading active games
 func (gm *GameManager) cleanupLobbyGamesAndLoadActive() {
 	gm.logger.Info("Cleaning up lobby games and loading active games")
@@ -117,77 +229,82 @@ func (gm *GameManager) cleanupLobbyGamesAndLoadActive() {
 	gm.loadActiveGamesFromDB()
 }
 
-// cleanupLobbyGamesOnRestart marks all games in LOBBY status as COMPLETED
-// This ensures that no lobby games are preserved across server restarts
+// cleanupLobbyGamesOnRestart abandons the games left in LOBBY status and refunds
+// their buy-ins. This ensures that no lobby games are preserved across server restarts.
+// Other nodes in a cluster are still running their lobbies, so only the ones nobody
+// owns are cleaned up.
 func (gm *GameManager) cleanupLobbyGamesOnRestart() {
 	gm.logger.Info("Cleaning up lobby games on server restart")
 
-	// Check if the MongoDB client is available
-	if gm.mongoClient == nil {
-		gm.logger.Warn("MongoDB client is nil, skipping cleanup of lobby games.")
+	// Check if the store is available
+	if gm.store == nil {
+		gm.logger.Warn("Game store is nil, skipping cleanup of lobby games.")
 		return
 	}
 
-	collection := gm.mongoClient.Database(gm.dbName).Collection("games")
-
-	// Find all games in LOBBY state
-	lobbyFilter := bson.M{
-		"status": models.GameStatusLobby,
-	}
-
-	// Update them to COMPLETED
-	update := bson.M{
-		"$set": bson.M{
-			"status":    models.GameStatusCompleted,
-			"updatedAt": time.Now(),
-		},
-	}
-
-	result, err := collection.UpdateMany(gm.ctx, lobbyFilter, update)
+	games, err := gm.store.FindGamesByStatus(gm.ctx, models.GameStatusLobby)
 	if err != nil {
 		gm.logger.Errorf("Failed to clean up lobby games on restart: %v", err)
-	} else {
-		gm.logger.Infof("Cleaned up %d lobby games on server restart", result.ModifiedCount)
+		return
 	}
+
+	cleaned := 0
+	for i := range games {
+		game := &games[i]
+		if gm.cluster != nil {
+			owned, err := gm.cluster.client.Exists(gm.ctx, leaseKey(game.ID.Hex())).Result()
+			if err != nil {
+				gm.logger.Errorf("Failed to check lease for lobby game %s: %v", game.ID.Hex(), err)
+				continue
+			}
+			if owned > 0 {
+				continue
+			}
+		}
+		if err := gm.abandonGame(game, "lobby left behind by a restart"); err != nil {
+			gm.logger.Errorf("Failed to clean up lobby game %s: %v", game.ID.Hex(), err)
+			continue
+		}
+		cleaned++
+	}
+	gm.logger.Infof("Cleaned up %d lobby games on server restart", cleaned)
 }
 
-// loadActiveGamesFromDB loads active games from the database into memory
+// loadActiveGamesFromDB loads active games from the database into memory.
+// In a cluster a node only loads the games whose lease it gets.
 func (gm *GameManager) loadActiveGamesFromDB() {
 	gm.logger.Info("Loading active games from database")
 
-	// Check if the MongoDB client is available
-	if gm.mongoClient == nil {
-		gm.logger.Warn("MongoDB client is nil, skipping loading active games from DB.")
+	// Check if the store is available
+	if gm.store == nil {
+		gm.logger.Warn("Game store is nil, skipping loading active games from DB.")
 		return
 	}
 
-	collection := gm.mongoClient.Database(gm.dbName).Collection("games")
-	filter := bson.M{
-		"status": bson.M{
-			"$in": []models.GameStatus{
-				// Only include ACTIVE and PAUSED games, never LOBBY games
-				models.GameStatusActive,
-				models.GameStatusPaused,
-			},
-		},
-	}
-
-	cursor, err := collection.Find(gm.ctx, filter)
+	// Only include ACTIVE and PAUSED games, never LOBBY games
+	games, err := gm.store.FindGamesByStatus(gm.ctx, models.GameStatusActive, models.GameStatusPaused)
 	if err != nil {
 		gm.logger.Errorf("Failed to query active games: %v", err)
 		return
 	}
-	defer cursor.Close(gm.ctx)
 
-	var games []models.Game
-	if err := cursor.All(gm.ctx, &games); err != nil {
-		gm.logger.Errorf("Failed to decode games: %v", err)
-		return
-	}
+	loaded := 0
+	for i := range games {
+		game := &games[i]
+		if gm.cluster != nil {
+			owner, err := gm.cluster.acquire(gm.ctx, game.ID.Hex())
+			if err != nil {
+				gm.logger.Errorf("[Cluster] %v", err)
+				continue
+			}
+			if owner != gm.cluster.nodeID {
+				continue
+			}
+			gm.recordLease(game.ID.Hex())
+		}
 
-	for _, game := range games {
 		gameSession := &GameSession{
-			Game:              &game,
+			Game:              game,
 			ConnectedPlayers:  make(map[string]string),
 			PlayerConnections: make(map[string]PlayerConnection),
 		}
@@ -195,11 +312,16 @@ func (gm *GameManager) loadActiveGamesFromDB() {
 		gm.activeGamesMutex.Lock()
 		gm.activeGames[game.ID.Hex()] = gameSession
 		gm.activeGamesMutex.Unlock()
+		loaded++
+
+		gameSession.mutex.Lock()
+		gm.armAutoResume(gameSession)
+		gameSession.mutex.Unlock()
 
 		gm.logger.Infof("Loaded game %s with status %s", game.ID.Hex(), game.Status)
 	}
 
-	gm.logger.Infof("Loaded %d active games", len(games))
+	gm.logger.Infof("Loaded %d active games", loaded)
 }
 
 // runCleanupTask periodically cleans up expired game sessions
@@ -242,17 +364,10 @@ func (gm *GameManager) cleanupExpiredSessions() {
 			lastActivity.Before(inactivityThreshold) {
 			gm.logger.Infof("Removing expired game session: %s", gameID)
 
-			// Update game status in database to COMPLETED
-			collection := gm.mongoClient.Database(gm.dbName).Collection("games")
-			_, err := collection.UpdateOne(
-				gm.ctx,
-				bson.M{"_id": session.Game.ID},
-				bson.M{"$set": bson.M{
-					"status":    models.GameStatusCompleted,
-					"updatedAt": time.Now(),
-				}},
-			)
-
+			// Nobody will finish it, so refund the buy-ins
+			session.mutex.Lock()
+			err := gm.abandonGame(session.Game, "inactive for 24+ hours")
+			session.mutex.Unlock()
 			if err != nil {
 				gm.logger.Errorf("Failed to update expired game status: %v", err)
 			}
@@ -264,34 +379,34 @@ func (gm *GameManager) cleanupExpiredSessions() {
 }
 
 // CreateGame creates a new game
-func (gm *GameManager) CreateGame(hostPlayerID, hostWalletAddress, gameName string, maxPlayers int) (string, error) {
+func (gm *GameManager) CreateGame(hostPlayerID, hostWalletAddress, gameName string, maxPlayers, buyIn int) (string, error) {
+	return gm.CreateGameWithAccess(hostPlayerID, hostWalletAddress, gameName, maxPlayers, buyIn, GameAccess{})
+}
+
+// CreateGameWithAccess creates a game that may be unlisted, private or password protected
+func (gm *GameManager) CreateGameWithAccess(hostPlayerID, hostWalletAddress, gameName string, maxPlayers, buyIn int, access GameAccess) (string, error) {
 	gameID := primitive.NewObjectID()
 	now := time.Now()
 
-	// Generate a unique room code
-	roomCode, err := utils.GenerateRoomCode()
+	visibility, passwordHash, err := access.resolve()
 	if err != nil {
-		return "", fmt.Errorf("failed to generate room code: %w", err)
+		return "", err
 	}
 
-	// Ensure the code is unique by checking the database
-	for {
-		collection := gm.mongoClient.Database(gm.dbName).Collection("games")
-		count, err := collection.CountDocuments(gm.ctx, bson.M{"code": roomCode})
-		if err != nil {
-			return "", fmt.Errorf("failed to check room code uniqueness: %w", err)
-		}
-
-		if count == 0 {
-			// Code is unique, we can use it
-			break
-		}
+	// Buy-ins are held in escrow, so they need settlement; 0 means the default stake
+	if buyIn < 0 {
+		return "", fmt.Errorf("buy-in must not be negative")
+	}
+	if gm.settler == nil && buyIn > 0 {
+		return "", ErrSettlementDisabled
+	}
+	if gm.settler != nil && buyIn == 0 {
+		buyIn = gm.stake
+	}
 
-		// Generate a new code and try again
-		roomCode, err = utils.GenerateRoomCode()
-		if err != nil {
-			return "", fmt.Errorf("failed to generate room code: %w", err)
-		}
+	roomCode, err := gm.uniqueRoomCode()
+	if err != nil {
+		return "", err
 	}
 
 	// If no game name is provided, use a default name with the room code
@@ -327,29 +442,30 @@ func (gm *GameManager) CreateGame(hostPlayerID, hostWalletAddress, gameName stri
 		LastActivity:     now,
 		MarketCondition:  models.MarketConditionNormal,
 		SettlementStatus: models.SettlementStatusPending,
+		BuyIn:            buyIn,
+		Mode:             models.GameModeClassic,
+		Visibility:       visibility,
+		PasswordHash:     passwordHash,
 	}
 
 	// Create host player
 	hostPlayer := models.Player{
-		ID:             hostPlayerID,
-		WalletAddress:  hostWalletAddress,
-		Status:         models.PlayerStatusActive,
-		Balance:        1500, // Initial balance, should come from config
-		Position:       0,    // Start position
-		Cards:          []models.Card{},
-		Properties:     []string{},
-		InitialDeposit: 0,    // No deposit yet
-		NetWorth:       1500, // Same as initial balance
+		ID:            hostPlayerID,
+		WalletAddress: hostWalletAddress,
+		Status:        models.PlayerStatusActive,
+		Balance:       1500, // Initial balance, should come from config
+		Position:      0,    // Start position
+		Cards:         []models.Card{},
+		Properties:    []string{},
+		NetWorth:      1500, // Same as initial balance
 	}
+	gm.applyBuyIn(&hostPlayer, buyIn, now)
 
 	game.Players = append(game.Players, hostPlayer)
 	game.TurnOrder = []string{hostPlayerID}
 
-	// Store in MongoDB
-	collection := gm.mongoClient.Database(gm.dbName).Collection("games")
-	_, err = collection.InsertOne(gm.ctx, game)
-	if err != nil {
-		return "", fmt.Errorf("failed to store game: %w", err)
+	if err := gm.insertGame(game); err != nil {
+		return "", err
 	}
 
 	// Create game session
@@ -374,10 +490,50 @@ func (gm *GameManager) CreateGame(hostPlayerID, hostWalletAddress, gameName stri
 	gm.activeGamesMutex.Unlock()
 
 	gm.logger.Infof("Created new game %s with code %s and host %s", gameID.Hex(), roomCode, hostPlayerID)
+	gm.notifyListing(gameID.Hex())
 
 	return gameID.Hex(), nil
 }
 
+// uniqueRoomCode generates a room code no stored game uses yet
+func (gm *GameManager) uniqueRoomCode() (string, error) {
+	for {
+		roomCode, err := utils.GenerateRoomCode()
+		if err != nil {
+			return "", fmt.Errorf("failed to generate room code: %w", err)
+		}
+		_, err = gm.store.FindGameByCode(gm.ctx, roomCode)
+		if errors.Is(err, ErrGameNotFound) {
+			return roomCode, nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to check room code uniqueness: %w", err)
+		}
+	}
+}
+
+// insertGame stores a new game. In a cluster this node takes the lease first, so a
+// restarting node can't mistake the new game for an orphan.
+func (gm *GameManager) insertGame(game *models.Game) error {
+	gameID := game.ID.Hex()
+	if gm.cluster != nil {
+		if err := gm.claimGame(gameID); err != nil {
+			return err
+		}
+	}
+
+	if err := gm.store.InsertGame(gm.ctx, game); err != nil {
+		if gm.cluster != nil {
+			gm.dropLease(gameID)
+			if releaseErr := gm.cluster.release(gm.ctx, gameID); releaseErr != nil {
+				gm.logger.Errorf("[Cluster] Failed to release lease for game %s: %v", gameID, releaseErr)
+			}
+		}
+		return fmt.Errorf("failed to store game: %w", err)
+	}
+	return nil
+}
+
 // GetGame retrieves a game by ID
 func (gm *GameManager) GetGame(gameID string) (*models.Game, error) {
 	// Normalize gameID to lowercase
@@ -406,17 +562,7 @@ func (gm *GameManager) GetGame(gameID string) (*models.Game, error) {
 		return nil, fmt.Errorf("invalid game ID: %w", err)
 	}
 
-	collection := gm.mongoClient.Database(gm.dbName).Collection("games")
-	var game models.Game
-	err = collection.FindOne(gm.ctx, bson.M{"_id": objID}).Decode(&game)
-	if err != nil {
-		if errors.Is(err, mongo.ErrNoDocuments) {
-			return nil, fmt.Errorf("game not found")
-		}
-		return nil, fmt.Errorf("failed to get game: %w", err)
-	}
-
-	return &game, nil
+	return gm.store.FindGame(gm.ctx, objID)
 }
 
 // GetGameByRoomCode retrieves a game by room code
@@ -425,21 +571,32 @@ func (gm *GameManager) GetGameByRoomCode(roomCode string) (*models.Game, error)
 	normalizedRoomCode := strings.ToUpper(roomCode)
 	gm.logger.Debugf("GetGameByRoomCode: Normalized roomCode from %s to %s", roomCode, normalizedRoomCode)
 
-	collection := gm.mongoClient.Database(gm.dbName).Collection("games")
-	var game models.Game
-	err := collection.FindOne(gm.ctx, bson.M{"code": normalizedRoomCode}).Decode(&game)
+	game, err := gm.store.FindGameByCode(gm.ctx, normalizedRoomCode)
 	if err != nil {
-		if errors.Is(err, mongo.ErrNoDocuments) {
+		if errors.Is(err, ErrGameNotFound) {
 			return nil, fmt.Errorf("game not found with room code: %s", normalizedRoomCode)
 		}
 		return nil, fmt.Errorf("failed to get game by room code: %w", err)
 	}
 
-	return &game, nil
+	return game, nil
 }
 
-// JoinGame adds a player to a game
+// JoinGame adds a player to a public game without a password
 func (gm *GameManager) JoinGame(gameID, playerID, walletAddress string) (string, error) {
+	return gm.JoinGameWithOptions(gameID, playerID, walletAddress, JoinOptions{})
+}
+
+// JoinGameWithOptions adds a player to a game, checking the password or invite they
+// present against the game's access rules
+func (gm *GameManager) JoinGameWithOptions(gameID, playerID, walletAddress string, opts JoinOptions) (string, error) {
+	if reply, handled, err := gm.remote(forwardRequest{Op: opJoinGame, GameID: gameID, PlayerID: playerID, Wallet: walletAddress, Join: &opts}); handled {
+		if err != nil {
+			return "", err
+		}
+		return reply.Result, nil
+	}
+
 	// Normalize gameID to lowercase
 	normalizedGameID := strings.ToLower(gameID)
 	gm.logger.Debugf("JoinGame: Normalized gameID from %s to %s", gameID, normalizedGameID)
@@ -494,6 +651,10 @@ func (gm *GameManager) JoinGame(gameID, playerID, walletAddress string) (string,
 		}
 	}
 
+	if err := gm.checkJoinAccess(session.Game, playerID, opts); err != nil {
+		return "", err
+	}
+
 	// Check if game is full
 	if len(session.Game.Players) >= session.Game.MaxPlayers { // Use MaxPlayers from game data
 		return "", fmt.Errorf("game is full")
@@ -501,16 +662,16 @@ func (gm *GameManager) JoinGame(gameID, playerID, walletAddress string) (string,
 
 	// Create new player
 	newPlayer := models.Player{
-		ID:             playerID,
-		WalletAddress:  walletAddress,
-		Status:         models.PlayerStatusActive,
-		Balance:        1500, // Initial balance, should come from config
-		Position:       0,    // Start position
-		Cards:          []models.Card{},
-		Properties:     []string{},
-		InitialDeposit: 0,    // No deposit yet
-		NetWorth:       1500, // Same as initial balance
+		ID:            playerID,
+		WalletAddress: walletAddress,
+		Status:        models.PlayerStatusActive,
+		Balance:       1500, // Initial balance, should come from config
+		Position:      0,    // Start position
+		Cards:         []models.Card{},
+		Properties:    []string{},
+		NetWorth:      1500, // Same as initial balance
 	}
+	gm.applyBuyIn(&newPlayer, session.Game.BuyIn, time.Now())
 
 	// Add player to game
 	session.Game.Players = append(session.Game.Players, newPlayer)
@@ -518,25 +679,13 @@ func (gm *GameManager) JoinGame(gameID, playerID, walletAddress string) (string,
 	session.Game.UpdatedAt = time.Now()
 	session.Game.LastActivity = time.Now()
 
-	// Update game in database
-	objID, err := primitive.ObjectIDFromHex(gameID)
-	if err != nil {
-		return "", fmt.Errorf("invalid game ID: %w", err)
-	}
-
-	collection := gm.mongoClient.Database(gm.dbName).Collection("games")
-	_, err = collection.UpdateOne(
-		gm.ctx,
-		bson.M{"_id": objID},
-		bson.M{
-			"$set": bson.M{
-				"players":      session.Game.Players,
-				"turnOrder":    session.Game.TurnOrder,
-				"updatedAt":    session.Game.UpdatedAt,
-				"lastActivity": session.Game.LastActivity,
-			},
-		},
-	)
+	// Update game in database; gameID may be a room code, so use the stored ID
+	err := gm.store.SetGameFields(gm.ctx, session.Game.ID, bson.M{
+		"players":      session.Game.Players,
+		"turnOrder":    session.Game.TurnOrder,
+		"updatedAt":    session.Game.UpdatedAt,
+		"lastActivity": session.Game.LastActivity,
+	})
 
 	if err != nil {
 		return "", fmt.Errorf("failed to update game: %w", err)
@@ -552,12 +701,17 @@ func (gm *GameManager) JoinGame(gameID, playerID, walletAddress string) (string,
 	}
 
 	gm.logger.Infof("Player %s joined game %s", playerID, gameID)
+	gm.notifyListing(session.Game.ID.Hex())
 
 	return sessionID, nil
 }
 
 // StartGame starts a game
 func (gm *GameManager) StartGame(gameID string, requestingPlayerID string) error {
+	if _, handled, err := gm.remote(forwardRequest{Op: opStartGame, GameID: gameID, PlayerID: requestingPlayerID}); handled {
+		return err
+	}
+
 	// Normalize gameID to lowercase
 	normalizedGameID := strings.ToLower(gameID)
 	gm.logger.Debugf("StartGame: Normalized gameID from %s to %s", gameID, normalizedGameID)
@@ -609,6 +763,14 @@ func (gm *GameManager) StartGame(gameID string, requestingPlayerID string) error
 		return fmt.Errorf("only the host can start the game")
 	}
 
+	// Every buy-in must be in escrow before play begins
+	if err := gm.enforceDeposits(session); err != nil {
+		return err
+	}
+	if len(session.Game.Players) < 2 {
+		return fmt.Errorf("not enough funded players to start the game")
+	}
+
 	// First, enqueue the game start operation in the message queue
 	// This ensures that even if there's a connection issue during the transition,
 	// the game start operation will be processed
@@ -635,7 +797,10 @@ func (gm *GameManager) StartGame(gameID string, requestingPlayerID string) error
 	// Randomize turn order before starting
 	if len(session.Game.TurnOrder) > 1 {
 		// Use a more modern approach for random shuffling
-		r := rand.New(rand.NewSource(time.Now().UnixNano()))
+		r := gm.rng
+		if r == nil {
+			r = rand.New(rand.NewSource(time.Now().UnixNano()))
+		}
 		r.Shuffle(len(session.Game.TurnOrder), func(i, j int) {
 			session.Game.TurnOrder[i], session.Game.TurnOrder[j] = session.Game.TurnOrder[j], session.Game.TurnOrder[i]
 		})
@@ -645,16 +810,18 @@ func (gm *GameManager) StartGame(gameID string, requestingPlayerID string) error
 	session.Game.UpdatedAt = time.Now()
 	session.Game.LastActivity = time.Now()
 
-	// Update game in database - use UpdateGame to ensure all fields are preserved
-	if updateErr := gm.UpdateGame(session.Game); updateErr != nil {
+	// Update game in database - save the whole game to ensure all fields are preserved.
+	// UpdateGame would take the session lock we already hold.
+	if updateErr := gm.saveGame(session.Game); updateErr != nil {
 		return fmt.Errorf("failed to update game: %w", updateErr)
 	}
 
 	gm.logger.Infof("Game %s started with %d players", gameID, len(session.Game.Players))
+	gm.notifyListing(session.Game.ID.Hex())
 
 	// Broadcast game_started event to all clients in the game
 	if gm.wsHub != nil {
-		// Create game state to include in the broadcast
+		// Game state for the message queue
 		gameState := map[string]interface{}{
 			"type":        "game_started",
 			"gameId":      gameID,
@@ -675,40 +842,44 @@ func (gm *GameManager) StartGame(gameID string, requestingPlayerID string) error
 			}
 		}
 
-		// Marshal to JSON
-		msgBytes, jsonErr := json.Marshal(gameState)
-		if jsonErr != nil {
-			gm.logger.Errorf("Failed to marshal game_started message: %v", jsonErr)
-		} else {
-			// Broadcast to all clients in the game
-			gm.wsHub.BroadcastToGame(gameID, msgBytes)
-			gm.logger.Infof("Broadcasted game_started event to all clients in game %s", gameID)
-		}
+		// Broadcast to all clients in the game
+		gm.broadcast(gameID, &protocol.GameStarted{
+			GameID:      gameID,
+			Status:      string(session.Game.Status),
+			CurrentTurn: session.Game.CurrentTurn,
+			Players:     session.Game.Players,
+			TurnOrder:   session.Game.TurnOrder,
+			Timestamp:   time.Now().Format(time.RFC3339),
+		})
+		gm.logger.Infof("Broadcasted game_started event to all clients in game %s", gameID)
 
 		// Immediately broadcast the first turn
-		turnMsg := map[string]interface{}{
-			"type":        "game_turn",
-			"currentTurn": session.Game.CurrentTurn,
-			"turnOrder":   session.Game.TurnOrder,
-			"gameId":      gameID,
-			"timestamp":   time.Now().Format(time.RFC3339),
-		}
-		if turnBytes, err := json.Marshal(turnMsg); err == nil {
-			gm.wsHub.BroadcastToGame(gameID, turnBytes)
-			gm.logger.Infof("Broadcasted game_turn event to all clients in game %s", gameID)
-		} else {
-			gm.logger.Errorf("Failed to marshal game_turn message: %v", err)
-		}
+		gm.broadcast(gameID, &protocol.GameTurn{
+			GameID:      gameID,
+			CurrentTurn: session.Game.CurrentTurn,
+			TurnOrder:   session.Game.TurnOrder,
+			Timestamp:   time.Now().Format(time.RFC3339),
+		})
+		gm.logger.Infof("Broadcasted game_turn event to all clients in game %s", gameID)
+		gm.publishState(session.Game)
 	} else {
 		gm.logger.Warnf("WebSocket hub is nil, cannot broadcast game_started event")
 	}
 
+	gm.scheduleBot(session)
 	return nil
 }
 
 // PlayerDisconnected handles a player disconnection
 // This is called by the hub when a websocket connection is closed
 func (gm *GameManager) PlayerDisconnected(gameID, sessionID string) { // Reverted signature to use sessionID
+	if _, handled, err := gm.remote(forwardRequest{Op: opPlayerDisconnected, GameID: gameID, SessionID: sessionID}); handled {
+		if err != nil {
+			gm.logger.Errorf("[PlayerDisconnected] Failed to forward disconnect for game %s: %v", gameID, err)
+		}
+		return
+	}
+
 	gm.logger.Debugf("[PlayerDisconnected] Called for game %s, session %s", gameID, sessionID)
 	now := time.Now()
 
@@ -847,12 +1018,7 @@ func (gm *GameManager) PlayerDisconnected(gameID, sessionID string) { // Reverte
 	}
 
 	gm.logger.Debugf("[PlayerDisconnected] Attempting to update game %s in MongoDB with fields: %+v", gameID, updateFields)
-	collection := gm.mongoClient.Database(gm.dbName).Collection("games")
-	_, err = collection.UpdateOne(
-		gm.ctx,
-		bson.M{"_id": objID},
-		bson.M{"$set": updateFields},
-	)
+	err = gm.store.SetGameFields(gm.ctx, objID, updateFields)
 	if err != nil {
 		gm.logger.Errorf("[PlayerDisconnected] Failed to update game %s in database: %v", gameID, err)
 		// Continue even if DB update fails, to broadcast state
@@ -869,17 +1035,15 @@ func (gm *GameManager) PlayerDisconnected(gameID, sessionID string) { // Reverte
 		}
 	}
 
-	updateMsg := map[string]interface{}{
-		"type":         "active_players", // Keep type as active_players, frontend handles status
-		"gameId":       gameID,
-		"players":      broadcastPlayers,    // Send updated list including the player with DISCONNECTED status
-		"hostId":       session.Game.HostID, // Send current host ID
-		"previousHost": previousHostID,      // Indicate previous host if changed
-		"leftPlayerId": playerID,            // Explicitly state who left
-		"gameStatus":   session.Game.Status, // Send the current game status (might be ABANDONED)
-	}
-
-	msgBytes, _ := json.Marshal(updateMsg)
+	// Sent as active_players; the frontend handles the status
+	msgBytes, _ := protocol.Encode(&protocol.PlayerLeft{
+		GameID:       gameID,
+		Players:      broadcastPlayers,    // Send updated list including the player with DISCONNECTED status
+		HostID:       session.Game.HostID, // Send current host ID
+		PreviousHost: previousHostID,      // Indicate previous host if changed
+		LeftPlayerID: playerID,            // Explicitly state who left
+		GameStatus:   session.Game.Status, // Send the current game status (might be ABANDONED)
+	})
 	gm.logger.Debugf("[PlayerDisconnected] Broadcasting player update to game %s: %s", gameID, string(msgBytes))
 	if gm.wsHub != nil {
 		gm.wsHub.BroadcastToGame(gameID, msgBytes)
@@ -887,12 +1051,19 @@ func (gm *GameManager) PlayerDisconnected(gameID, sessionID string) { // Reverte
 		gm.logger.Warnf("[PlayerDisconnected] wsHub is nil, cannot broadcast player update for game %s", gameID)
 	}
 
-	// Removed broadcastLobbyUpdate calls - rely on frontend polling for now
+	gm.notifyListing(gameID)
 
 	gm.logger.Debugf("[PlayerDisconnected] Finished processing disconnection for player %s (Session: %s) in game %s", playerID, sessionID, gameID)
 
 	// Optionally start a timeout goroutine for forfeiture if player doesn't reconnect
 	// go gm.handleDisconnectionTimeout(gameID, playerID, sessionID) // Disabled for now
+
+	// A bot can keep the seat warm instead
+	if gm.bots.TakeOver && session.Game.Status == models.GameStatusActive {
+		time.AfterFunc(gm.bots.TakeOverAfter, func() {
+			gm.substituteBot(gameID, playerID, sessionID)
+		})
+	}
 }
 
 // handleDisconnectionTimeout handles the timeout for disconnected players
@@ -944,28 +1115,71 @@ func (gm *GameManager) handleDisconnectionTimeout(gameID, playerID, sessionID st
 			return
 		}
 
-		collection := gm.mongoClient.Database(gm.dbName).Collection("games")
-		_, err = collection.UpdateOne(
-			gm.ctx,
-			bson.M{"_id": objID},
-			bson.M{
-				"$set": bson.M{
-					"players":      session.Game.Players,
-					"turnOrder":    session.Game.TurnOrder, // In case turn order changed
-					"updatedAt":    time.Now(),
-					"lastActivity": time.Now(),
-				},
-			},
-		)
+		err = gm.store.SetGameFields(gm.ctx, objID, bson.M{
+			"players":      session.Game.Players,
+			"turnOrder":    session.Game.TurnOrder, // In case turn order changed
+			"currentTurn":  session.Game.CurrentTurn,
+			"status":       session.Game.Status,
+			"winnerId":     session.Game.WinnerID,
+			"updatedAt":    time.Now(),
+			"lastActivity": time.Now(),
+		})
 
 		if err != nil {
 			gm.logger.Errorf("Failed to update game for forfeiture: %v", err)
 		}
 
 		gm.logger.Infof("Player %s forfeited game %s due to disconnection timeout", playerID, gameID)
+		gm.notifyListing(gameID)
+
+		if session.Game.Status == models.GameStatusCompleted {
+			gm.settleGame(session.Game)
+		}
 	}
 }
 
+// settleGame pays out a completed game in the background
+func (gm *GameManager) settleGame(game *models.Game) {
+	if gm.settler == nil {
+		return
+	}
+
+	// Settle a snapshot so later changes to the live game don't race with payouts
+	snapshot := *game
+	snapshot.Players = append([]models.Player(nil), game.Players...)
+
+	go func() {
+		if err := gm.settler.Settle(gm.ctx, &snapshot); err != nil {
+			gm.logger.Errorf("Settlement for game %s failed: %v", snapshot.ID.Hex(), err)
+		}
+	}()
+}
+
+// SetSettlementStatus records a game's settlement progress in memory and in the database
+func (gm *GameManager) SetSettlementStatus(gameID string, status models.SettlementStatus) error {
+	objID, err := primitive.ObjectIDFromHex(gameID)
+	if err != nil {
+		return fmt.Errorf("invalid game ID: %w", err)
+	}
+
+	gm.activeGamesMutex.RLock()
+	session, exists := gm.activeGames[gameID]
+	gm.activeGamesMutex.RUnlock()
+
+	if exists {
+		session.mutex.Lock()
+		session.Game.SettlementStatus = status
+		session.mutex.Unlock()
+	}
+
+	err = gm.store.SetGameFields(gm.ctx, objID, bson.M{"settlementStatus": status})
+	if err != nil {
+		return fmt.Errorf("failed to update settlement status: %w", err)
+	}
+
+	return nil
+}
+
 // handlePlayerForfeiture handles the forfeiture of a player
 func (gm *GameManager) handlePlayerForfeiture(game *models.Game, playerID string) {
 	// Find player's properties
@@ -1045,8 +1259,9 @@ func (gm *GameManager) PlayerReconnected(gameID, playerID, sessionID string) err
 		return fmt.Errorf("player not found in game")
 	}
 
-	// Check if player status is DISCONNECTED
-	if session.Game.Players[playerIndex].Status != models.PlayerStatusDisconnected {
+	// Check if player status is DISCONNECTED, or a bot is playing their seat
+	if !gm.releaseSubstitute(session, &session.Game.Players[playerIndex]) &&
+		session.Game.Players[playerIndex].Status != models.PlayerStatusDisconnected {
 		return fmt.Errorf("player is not in DISCONNECTED status")
 	}
 
@@ -1071,30 +1286,28 @@ func (gm *GameManager) PlayerReconnected(gameID, playerID, sessionID string) err
 		return fmt.Errorf("invalid game ID: %w", err)
 	}
 
-	collection := gm.mongoClient.Database(gm.dbName).Collection("games")
-	_, err = collection.UpdateOne(
-		gm.ctx,
-		bson.M{"_id": objID},
-		bson.M{
-			"$set": bson.M{
-				"players":      session.Game.Players,
-				"updatedAt":    time.Now(),
-				"lastActivity": time.Now(),
-			},
-		},
-	)
+	err = gm.store.SetGameFields(gm.ctx, objID, bson.M{
+		"players":      session.Game.Players,
+		"updatedAt":    time.Now(),
+		"lastActivity": time.Now(),
+	})
 
 	if err != nil {
 		return fmt.Errorf("failed to update game: %w", err)
 	}
 
 	gm.logger.Infof("Player %s reconnected to game %s", playerID, gameID)
+	gm.notifyListing(gameID)
 
 	return nil
 }
 
 // ProcessGameAction processes a game action
 func (gm *GameManager) ProcessGameAction(action models.GameAction) error {
+	if _, handled, err := gm.remote(forwardRequest{Op: opGameAction, GameID: action.GameID, PlayerID: action.PlayerID, Action: &action}); handled {
+		return err
+	}
+
 	gameID := action.GameID
 	playerID := action.PlayerID
 
@@ -1110,8 +1323,11 @@ func (gm *GameManager) ProcessGameAction(action models.GameAction) error {
 	defer session.mutex.Unlock()
 
 	// Validate game status
+	if session.Game.Status == models.GameStatusPaused {
+		return ErrGamePaused
+	}
 	if session.Game.Status != models.GameStatusActive {
-		return fmt.Errorf("game is not active")
+		return ErrGameNotActive
 	}
 
 	// Check if it's player's turn (except for certain actions)
@@ -1138,34 +1354,58 @@ func (gm *GameManager) ProcessGameAction(action models.GameAction) error {
 	}
 
 	// Process action based on type
+	var err error
 	switch action.Type {
 	case models.ActionTypeRollDice:
-		return gm.processRollDiceAction(session.Game, playerID, action.Payload)
+		err = gm.processRollDiceAction(session.Game, playerID, action.Payload)
 	case models.ActionTypeBuyProperty:
-		return gm.processBuyPropertyAction(session.Game, playerID, action.Payload)
+		err = gm.processBuyPropertyAction(session.Game, playerID, action.Payload)
 	case models.ActionTypePayRent:
-		return gm.processPayRentAction(session.Game, playerID, action.Payload)
+		err = gm.processPayRentAction(session.Game, playerID, action.Payload)
 	case models.ActionTypeDrawCard:
-		return gm.processDrawCardAction(session.Game, playerID, action.Payload)
+		err = gm.processDrawCardAction(session.Game, playerID, action.Payload)
 	case models.ActionTypeUseCard:
-		return gm.processUseCardAction(session.Game, playerID, action.Payload)
+		err = gm.processUseCardAction(session.Game, playerID, action.Payload)
 	case models.ActionTypeMortgageProperty:
-		return gm.processMortgagePropertyAction(session.Game, playerID, action.Payload)
+		err = gm.processMortgagePropertyAction(session.Game, playerID, action.Payload)
 	case models.ActionTypeUnmortgageProperty:
-		return gm.processUnmortgagePropertyAction(session.Game, playerID, action.Payload)
+		err = gm.processUnmortgagePropertyAction(session.Game, playerID, action.Payload)
 	case models.ActionTypeBuildEngagement:
-		return gm.processBuildEngagementAction(session.Game, playerID, action.Payload)
+		err = gm.processBuildEngagementAction(session.Game, playerID, action.Payload)
 	case models.ActionTypeBuildCheckmark:
-		return gm.processBuildCheckmarkAction(session.Game, playerID, action.Payload)
+		err = gm.processBuildCheckmarkAction(session.Game, playerID, action.Payload)
 	case models.ActionTypeEndTurn:
-		return gm.processEndTurnAction(session.Game, playerID, action.Payload)
+		err = gm.processEndTurnAction(session.Game, playerID, action.Payload)
 	case models.ActionTypeTrade:
-		return gm.processTradeAction(session.Game, playerID, action.Payload)
+		err = gm.processTradeAction(session.Game, playerID, action.Payload)
 	case models.ActionTypeSpecial:
-		return gm.processSpecialAction(session.Game, playerID, action.Payload)
+		err = gm.processSpecialAction(session.Game, playerID, action.Payload)
 	default:
 		return fmt.Errorf("unknown action type: %s", action.Type)
 	}
+	if err != nil {
+		return err
+	}
+
+	gm.publishState(session.Game)
+	gm.scheduleBot(session)
+	return nil
+}
+
+// publishState sends the game's state to subscribed clients, if the hub supports it.
+// Actions can bankrupt players or end the game, so the listing is refreshed too.
+func (gm *GameManager) publishState(game *models.Game) {
+	if publisher, ok := gm.wsHub.(StatePublisher); ok {
+		publisher.PublishState(game.ID.Hex(), game)
+	}
+	gm.notifyListing(game.ID.Hex())
+}
+
+// notifyListing tells the hub a game's lobby listing may have changed, if it supports it
+func (gm *GameManager) notifyListing(gameID string) {
+	if notifier, ok := gm.wsHub.(ListingNotifier); ok {
+		notifier.GameListingChanged(gameID)
+	}
 }
 
 // Helper function to check if an action can be performed outside of player's turn
@@ -1178,15 +1418,24 @@ func isNonTurnAction(actionType models.ActionType) bool {
 	}
 }
 
+// rollDice rolls two dice, from the seeded source if there is one
+func (gm *GameManager) rollDice() (int, int) {
+	if gm.rng != nil {
+		return 1 + gm.rng.Intn(6), 1 + gm.rng.Intn(6)
+	}
+	// Generate random dice values (1-6 for each die)
+	dice1 := 1 + int(time.Now().UnixNano()%6)
+	time.Sleep(1 * time.Millisecond)
+	dice2 := 1 + int(time.Now().UnixNano()%6)
+	return dice1, dice2
+}
+
 // Placeholder for action processing methods
 func (gm *GameManager) processRollDiceAction(game *models.Game, playerID string, payload interface{}) error {
 	gm.logger.Infof("Player %s rolling dice in game %s", playerID, game.ID.Hex())
 
-	// Generate random dice values (1-6 for each die)
-	dice1 := 1 + (time.Now().UnixNano() % 6)
-	time.Sleep(1 * time.Millisecond)
-	dice2 := 1 + (time.Now().UnixNano() % 6)
-	totalMove := int(dice1 + dice2)
+	dice1, dice2 := gm.rollDice()
+	totalMove := dice1 + dice2
 
 	// Find the player
 	playerIndex := -1
@@ -1212,15 +1461,11 @@ func (gm *GameManager) processRollDiceAction(game *models.Game, playerID string,
 			player.Position = (25 + totalMove) % 40
 			// Broadcast release notification
 			if gm.wsHub != nil {
-				msg := map[string]interface{}{
-					"type":     "jail_event",
-					"playerId": playerID,
-					"event":    "released",
-					"dice":     []int{int(dice1), int(dice2)},
-				}
-				if msgBytes, err := json.Marshal(msg); err == nil {
-					gm.wsHub.BroadcastToGame(game.ID.Hex(), msgBytes)
-				}
+				gm.broadcast(game.ID.Hex(), &protocol.JailEvent{
+					PlayerID: playerID,
+					Event:    protocol.JailEventReleased,
+					Dice:     []int{dice1, dice2},
+				})
 			}
 			gm.logger.Infof("Player %s moved from jail (25) to %d", playerID, player.Position)
 		} else {
@@ -1233,30 +1478,22 @@ func (gm *GameManager) processRollDiceAction(game *models.Game, playerID string,
 				player.Position = (25 + totalMove) % 40
 				gm.logger.Infof("Player %s served jail time and is released, moved from jail (25) to %d", playerID, player.Position)
 				if gm.wsHub != nil {
-					msg := map[string]interface{}{
-						"type":     "jail_event",
-						"playerId": playerID,
-						"event":    "released_time",
-						"dice":     []int{int(dice1), int(dice2)},
-					}
-					if msgBytes, err := json.Marshal(msg); err == nil {
-						gm.wsHub.BroadcastToGame(game.ID.Hex(), msgBytes)
-					}
+					gm.broadcast(game.ID.Hex(), &protocol.JailEvent{
+						PlayerID: playerID,
+						Event:    protocol.JailEventReleasedTime,
+						Dice:     []int{dice1, dice2},
+					})
 				}
 			} else {
 				// Still in jail, do not move
 				gm.logger.Infof("Player %s is still in jail, %d turns left", playerID, player.JailTurns)
 				if gm.wsHub != nil {
-					msg := map[string]interface{}{
-						"type":      "jail_event",
-						"playerId":  playerID,
-						"event":     "stay",
-						"jailTurns": player.JailTurns,
-						"dice":      []int{int(dice1), int(dice2)},
-					}
-					if msgBytes, err := json.Marshal(msg); err == nil {
-						gm.wsHub.BroadcastToGame(game.ID.Hex(), msgBytes)
-					}
+					gm.broadcast(game.ID.Hex(), &protocol.JailEvent{
+						PlayerID:  playerID,
+						Event:     protocol.JailEventStay,
+						JailTurns: player.JailTurns,
+						Dice:      []int{dice1, dice2},
+					})
 				}
 			}
 		}
@@ -1271,15 +1508,11 @@ func (gm *GameManager) processRollDiceAction(game *models.Game, playerID string,
 			player.JailTurns = 3
 			gm.logger.Infof("Player %s landed on Go to Jail! Sent to jail (25) for 3 turns.", playerID)
 			if gm.wsHub != nil {
-				msg := map[string]interface{}{
-					"type":      "jail_event",
-					"playerId":  playerID,
-					"event":     "jailed",
-					"jailTurns": 3,
-				}
-				if msgBytes, err := json.Marshal(msg); err == nil {
-					gm.wsHub.BroadcastToGame(game.ID.Hex(), msgBytes)
-				}
+				gm.broadcast(game.ID.Hex(), &protocol.JailEvent{
+					PlayerID:  playerID,
+					Event:     protocol.JailEventJailed,
+					JailTurns: 3,
+				})
 			}
 		} else {
 			// Normal move
@@ -1292,18 +1525,11 @@ func (gm *GameManager) processRollDiceAction(game *models.Game, playerID string,
 	game.UpdatedAt = time.Now()
 
 	// Update game in database
-	collection := gm.mongoClient.Database(gm.dbName).Collection("games")
-	_, err := collection.UpdateOne(
-		gm.ctx,
-		bson.M{"_id": game.ID},
-		bson.M{
-			"$set": bson.M{
-				"players":      game.Players,
-				"updatedAt":    game.UpdatedAt,
-				"lastActivity": game.LastActivity,
-			},
-		},
-	)
+	err := gm.store.SetGameFields(gm.ctx, game.ID, bson.M{
+		"players":      game.Players,
+		"updatedAt":    game.UpdatedAt,
+		"lastActivity": game.LastActivity,
+	})
 	if err != nil {
 		return fmt.Errorf("failed to update game after rolling dice: %w", err)
 	}
@@ -1344,12 +1570,7 @@ func (gm *GameManager) processRollDiceAction(game *models.Game, playerID string,
 			game.CurrentTurn = game.TurnOrder[nextIndex]
 			nextPlayerID = game.CurrentTurn
 			// Also update DB for currentTurn
-			collection := gm.mongoClient.Database(gm.dbName).Collection("games")
-			_, _ = collection.UpdateOne(
-				gm.ctx,
-				bson.M{"_id": game.ID},
-				bson.M{"$set": bson.M{"currentTurn": game.CurrentTurn, "updatedAt": time.Now()}},
-			)
+			_ = gm.store.SetGameFields(gm.ctx, game.ID, bson.M{"currentTurn": game.CurrentTurn, "updatedAt": time.Now()})
 		}
 		// Find the next player (or current if doubles) for name
 		var playerName string = "Player_" + nextPlayerID[:4]
@@ -1359,15 +1580,11 @@ func (gm *GameManager) processRollDiceAction(game *models.Game, playerID string,
 				break
 			}
 		}
-		turnMsg := map[string]interface{}{
-			"type":          "turn_changed",
-			"currentTurn":   nextPlayerID,
-			"playerName":    playerName,
-			"rolledDoubles": rolledDoubles,
-		}
-		if msgBytes, err := json.Marshal(turnMsg); err == nil {
-			gm.wsHub.BroadcastToGame(game.ID.Hex(), msgBytes)
-		}
+		gm.broadcast(game.ID.Hex(), &protocol.TurnChanged{
+			CurrentTurn:   nextPlayerID,
+			PlayerName:    playerName,
+			RolledDoubles: rolledDoubles,
+		})
 	}
 	// --- END TURN MANAGEMENT ---
 
@@ -1454,19 +1671,12 @@ func (gm *GameManager) processBuyPropertyAction(game *models.Game, playerID stri
 	// In a real implementation, this would be stored in the database
 
 	// Update game in database
-	collection := gm.mongoClient.Database(gm.dbName).Collection("games")
-	_, err := collection.UpdateOne(
-		gm.ctx,
-		bson.M{"_id": game.ID},
-		bson.M{
-			"$set": bson.M{
-				"players":      game.Players,
-				"boardState":   game.BoardState,
-				"updatedAt":    game.UpdatedAt,
-				"lastActivity": game.LastActivity,
-			},
-		},
-	)
+	err := gm.store.SetGameFields(gm.ctx, game.ID, bson.M{
+		"players":      game.Players,
+		"boardState":   game.BoardState,
+		"updatedAt":    game.UpdatedAt,
+		"lastActivity": game.LastActivity,
+	})
 
 	if err != nil {
 		return fmt.Errorf("failed to update game after buying property: %w", err)
@@ -1587,18 +1797,11 @@ func (gm *GameManager) processPayRentAction(game *models.Game, playerID string,
 	// In a real implementation, this would be stored in the database
 
 	// Update game in database
-	collection := gm.mongoClient.Database(gm.dbName).Collection("games")
-	_, err := collection.UpdateOne(
-		gm.ctx,
-		bson.M{"_id": game.ID},
-		bson.M{
-			"$set": bson.M{
-				"players":      game.Players,
-				"updatedAt":    game.UpdatedAt,
-				"lastActivity": game.LastActivity,
-			},
-		},
-	)
+	err := gm.store.SetGameFields(gm.ctx, game.ID, bson.M{
+		"players":      game.Players,
+		"updatedAt":    game.UpdatedAt,
+		"lastActivity": game.LastActivity,
+	})
 
 	if err != nil {
 		return fmt.Errorf("failed to update game after paying rent: %w", err)
@@ -1683,21 +1886,14 @@ func (gm *GameManager) processEndTurnAction(game *models.Game, playerID string,
 	game.UpdatedAt = time.Now()
 
 	// Update game in database
-	collection := gm.mongoClient.Database(gm.dbName).Collection("games")
-	_, err := collection.UpdateOne(
-		gm.ctx,
-		bson.M{"_id": game.ID},
-		bson.M{
-			"$set": bson.M{
-				"currentTurn":                   game.CurrentTurn,
-				"marketCondition":               game.MarketCondition,
-				"marketConditionRemainingTurns": game.MarketConditionRemainingTurns,
-				"players":                       game.Players,
-				"updatedAt":                     game.UpdatedAt,
-				"lastActivity":                  game.LastActivity,
-			},
-		},
-	)
+	err := gm.store.SetGameFields(gm.ctx, game.ID, bson.M{
+		"currentTurn":                   game.CurrentTurn,
+		"marketCondition":               game.MarketCondition,
+		"marketConditionRemainingTurns": game.MarketConditionRemainingTurns,
+		"players":                       game.Players,
+		"updatedAt":                     game.UpdatedAt,
+		"lastActivity":                  game.LastActivity,
+	})
 
 	if err != nil {
 		return fmt.Errorf("failed to update game after ending turn: %w", err)
@@ -1714,12 +1910,8 @@ func (gm *GameManager) processTradeAction(game *models.Game, playerID string, pa
 	return nil
 }
 
-func (gm *GameManager) processSpecialAction(game *models.Game, playerID string, payload interface{}) error {
-	// This would implement the logic for special actions
-	return nil
-}
-
-// ListAvailableGames returns all available games that are in LOBBY, ACTIVE, or ABANDONED status
+// ListAvailableGames returns all public games that are in LOBBY, ACTIVE, or ABANDONED status.
+// Unlisted and private games are left out.
 func (gm *GameManager) ListAvailableGames() ([]*models.Game, error) {
 	var games []*models.Game
 
@@ -1740,30 +1932,16 @@ func (gm *GameManager) ListAvailableGames() ([]*models.Game, error) {
 	gm.activeGamesMutex.RUnlock()
 
 	// Then check the database for any games not in memory
-	if gm.mongoClient != nil {
-		collection := gm.mongoClient.Database(gm.dbName).Collection("games")
-
+	if gm.store != nil {
 		// Find games in LOBBY, ACTIVE, or ABANDONED status
-		filter := bson.M{
-			"status": bson.M{
-				"$in": []models.GameStatus{
-					models.GameStatusLobby,
-					models.GameStatusActive,
-					models.GameStatusAbandoned,
-				},
-			},
-		}
-
-		cursor, err := collection.Find(gm.ctx, filter)
+		dbGames, err := gm.store.FindGamesByStatus(gm.ctx,
+			models.GameStatusLobby,
+			models.GameStatusActive,
+			models.GameStatusAbandoned,
+		)
 		if err != nil {
 			return games, fmt.Errorf("failed to query games from database: %w", err)
 		}
-		defer cursor.Close(gm.ctx)
-
-		var dbGames []models.Game
-		if err := cursor.All(gm.ctx, &dbGames); err != nil {
-			return games, fmt.Errorf("failed to decode games from database: %w", err)
-		}
 
 		// Check if games are already in memory
 		for i := range dbGames {
@@ -1784,6 +1962,14 @@ func (gm *GameManager) ListAvailableGames() ([]*models.Game, error) {
 		}
 	}
 
+	public := games[:0]
+	for _, game := range games {
+		if game.IsPublic() {
+			public = append(public, game)
+		}
+	}
+	games = public
+
 	gm.logger.Infof("Found %d available games", len(games))
 	return games, nil
 }
@@ -1888,16 +2074,11 @@ func (gm *GameManager) CleanupStaleGames() ([]string, error) {
 						gameSession.Game.TurnOrder = newTurnOrder
 
 						// Update the game in the database
-						collection := gm.mongoClient.Database(gm.dbName).Collection("games")
-						_, err := collection.UpdateOne(
-							gm.ctx,
-							bson.M{"_id": gameSession.Game.ID},
-							bson.M{"$set": bson.M{
-								"turnOrder":    gameSession.Game.TurnOrder,
-								"updatedAt":    time.Now(),
-								"lastActivity": time.Now(),
-							}},
-						)
+						err := gm.store.SetGameFields(gm.ctx, gameSession.Game.ID, bson.M{
+							"turnOrder":    gameSession.Game.TurnOrder,
+							"updatedAt":    time.Now(),
+							"lastActivity": time.Now(),
+						})
 
 						if err != nil {
 							gm.logger.Errorf("Failed to update host transfer: %v", err)
@@ -1917,17 +2098,11 @@ func (gm *GameManager) CleanupStaleGames() ([]string, error) {
 			gamesToRemove = append(gamesToRemove, gameID)
 			removedGames = append(removedGames, gameID)
 
-			// Update game status in database to COMPLETED
-			if gm.mongoClient != nil {
-				collection := gm.mongoClient.Database(gm.dbName).Collection("games")
-				_, err := collection.UpdateOne(
-					gm.ctx,
-					bson.M{"_id": gameSession.Game.ID},
-					bson.M{"$set": bson.M{
-						"status":    models.GameStatusCompleted,
-						"updatedAt": time.Now(),
-					}},
-				)
+			// Nobody will finish it, so refund the buy-ins
+			if gm.store != nil {
+				gameSession.mutex.Lock()
+				err := gm.abandonGame(gameSession.Game, removalReason)
+				gameSession.mutex.Unlock()
 
 				if err != nil {
 					gm.logger.Errorf("Failed to update stale game status: %v", err)
@@ -1953,6 +2128,7 @@ func (gm *GameManager) CleanupStaleGames() ([]string, error) {
 	// Second pass - remove the identified games
 	for _, gameID := range gamesToRemove {
 		delete(gm.activeGames, gameID)
+		gm.notifyListing(gameID)
 	}
 
 	gm.logger.Infof("Cleaned up %d stale/duplicate games", len(removedGames))
@@ -1976,6 +2152,8 @@ func (gm *GameManager) RemoveGameSession(gameID string) {
 	if _, exists := gm.activeGames[gameID]; exists {
 		delete(gm.activeGames, gameID)
 		gm.logger.Infof("Removed game session %s from active games map", gameID)
+		gm.notifyListing(gameID)
+		// The lease renewal loop releases the game's lease now it's gone from memory
 	} else {
 		gm.logger.Warnf("Attempted to remove non-existent game session %s from active games map", gameID)
 	}
@@ -1987,6 +2165,13 @@ func (gm *GameManager) RemoveGameSession(gameID string) {
 // PlayerConnected is called by the hub when a player's WebSocket connects successfully.
 // It updates the GameSession state to track the connection.
 func (gm *GameManager) PlayerConnected(gameID, playerID, sessionID string) {
+	if _, handled, err := gm.remote(forwardRequest{Op: opPlayerConnected, GameID: gameID, PlayerID: playerID, SessionID: sessionID}); handled {
+		if err != nil {
+			gm.logger.Errorf("[PlayerConnected] Failed to forward connect for game %s: %v", gameID, err)
+		}
+		return
+	}
+
 	gm.logger.Debugf("[PlayerConnected] Called for game %s, player %s, session %s", gameID, playerID, sessionID)
 
 	gm.activeGamesMutex.RLock()
@@ -2039,11 +2224,13 @@ func (gm *GameManager) PlayerConnected(gameID, playerID, sessionID string) {
 	playerFound := false
 	for i := range session.Game.Players {
 		if session.Game.Players[i].ID == playerID {
+			gm.releaseSubstitute(session, &session.Game.Players[i])
 			if session.Game.Players[i].Status != models.PlayerStatusActive {
 				gm.logger.Infof("[PlayerConnected] Updating player %s status from %s to ACTIVE in game %s", playerID, session.Game.Players[i].Status, gameID)
 				session.Game.Players[i].Status = models.PlayerStatusActive
 				// Optionally save this change immediately or let it be saved on next action
 				// For now, let's assume it gets saved later to avoid excessive DB writes.
+				gm.notifyListing(gameID)
 			} else {
 				gm.logger.Debugf("[PlayerConnected] Player %s already ACTIVE in game %s", playerID, gameID)
 			}
@@ -2067,21 +2254,18 @@ func (gm *GameManager) UpdateGame(game *models.Game) error {
 		return fmt.Errorf("game is nil")
 	}
 
-	// Update game in database
-	collection := gm.mongoClient.Database(gm.dbName).Collection("games")
-	game.UpdatedAt = time.Now()
-	game.LastActivity = time.Now()
-
-	_, err := collection.UpdateOne(
-		gm.ctx,
-		bson.M{"_id": game.ID},
-		bson.M{
-			"$set": game,
-		},
-	)
+	if gm.cluster != nil {
+		raw, err := bson.Marshal(game)
+		if err != nil {
+			return fmt.Errorf("failed to encode game: %w", err)
+		}
+		if _, handled, err := gm.remote(forwardRequest{Op: opUpdateGame, GameID: game.ID.Hex(), Game: raw}); handled {
+			return err
+		}
+	}
 
-	if err != nil {
-		return fmt.Errorf("failed to update game in database: %w", err)
+	if err := gm.saveGame(game); err != nil {
+		return err
 	}
 
 	// Update game in memory if it exists in active games
@@ -2095,6 +2279,18 @@ func (gm *GameManager) UpdateGame(game *models.Game) error {
 		session.mutex.Unlock()
 		gm.logger.Infof("Updated game %s in memory", game.ID.Hex())
 	}
+	gm.notifyListing(game.ID.Hex())
 
 	return nil
 }
+
+// saveGame writes the whole game to the database
+func (gm *GameManager) saveGame(game *models.Game) error {
+	game.UpdatedAt = time.Now()
+	game.LastActivity = time.Now()
+
+	if err := gm.store.SetGameFields(gm.ctx, game.ID, game); err != nil {
+		return fmt.Errorf("failed to update game in database: %w", err)
+	}
+	return nil
+}
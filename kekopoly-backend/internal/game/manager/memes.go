@@ -0,0 +1,70 @@
+package manager
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/kekopoly/backend/internal/game/models"
+	"github.com/kekopoly/backend/internal/game/protocol"
+)
+
+// SpecialPostMeme is the special action a player takes to put one of their chat
+// messages forward as their meme, with the payload
+// {"action": "POST_MEME", "chatMessageId": <id>}
+const SpecialPostMeme = "POST_MEME"
+
+// ErrMemeNotFound is returned when a posted meme doesn't point to a public chat
+// message of the player's in the game's chat history
+var ErrMemeNotFound = errors.New("meme not found in the game's chat")
+
+// ChatReader is implemented by hubs that keep a game's chat, so game rules can check
+// the message a player points to
+type ChatReader interface {
+	ChatMessage(gameID string, id int64) (*protocol.ChatEntry, error)
+}
+
+func (gm *GameManager) processSpecialAction(game *models.Game, playerID string, payload interface{}) error {
+	payloadMap, ok := payload.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("invalid payload format")
+	}
+
+	action, _ := payloadMap["action"].(string)
+	switch action {
+	case SpecialPostMeme:
+		return gm.postMeme(game, playerID, payloadMap)
+	default:
+		return fmt.Errorf("unknown special action: %q", action)
+	}
+}
+
+// postMeme checks the chat message a player put forward as their meme and shows it
+// to the other players
+func (gm *GameManager) postMeme(game *models.Game, playerID string, payload map[string]interface{}) error {
+	id, ok := payload["chatMessageId"].(float64)
+	if !ok || id != float64(int64(id)) {
+		return fmt.Errorf("chat message ID must be a whole number")
+	}
+
+	chat, ok := gm.wsHub.(ChatReader)
+	if !ok {
+		return fmt.Errorf("%w: chat is not enabled", ErrMemeNotFound)
+	}
+	gameID := game.ID.Hex()
+	entry, err := chat.ChatMessage(gameID, int64(id))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrMemeNotFound, err)
+	}
+	// Whispers can't be judged by the other players
+	if entry.PlayerID != playerID || entry.To != "" {
+		return fmt.Errorf("%w: message %d isn't the player's public message", ErrMemeNotFound, entry.ID)
+	}
+
+	gm.logger.Infof("Player %s posted chat message %d as a meme in game %s", playerID, entry.ID, gameID)
+	gm.broadcast(gameID, &protocol.MemePosted{
+		PlayerID:      playerID,
+		ChatMessageID: entry.ID,
+		Message:       entry.Message,
+	})
+	return nil
+}
@@ -0,0 +1,298 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrOwnerUnavailable is returned when the node that owns a game doesn't answer a forwarded request
+var ErrOwnerUnavailable = errors.New("game owner unavailable")
+
+// renewLeaseScript extends a lease only if this node still holds it
+var renewLeaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// releaseLeaseScript deletes a lease only if this node still holds it
+var releaseLeaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// Cluster lets several nodes share the games. Each game is owned by one node at a
+// time through a lease in Redis, and the other nodes forward changes to the owner.
+type Cluster struct {
+	client         *redis.Client
+	nodeID         string
+	leaseTTL       time.Duration
+	forwardTimeout time.Duration
+}
+
+// NewCluster creates the cluster membership for this node. An empty nodeID gets a
+// random one. Leases are renewed every third of leaseTTL, so a node that dies loses
+// its games to the other nodes within leaseTTL.
+func NewCluster(client *redis.Client, nodeID string, leaseTTL, forwardTimeout time.Duration) *Cluster {
+	if nodeID == "" {
+		nodeID = uuid.New().String()
+	}
+	if leaseTTL <= 0 {
+		leaseTTL = 15 * time.Second
+	}
+	if forwardTimeout <= 0 {
+		forwardTimeout = 5 * time.Second
+	}
+	return &Cluster{
+		client:         client,
+		nodeID:         nodeID,
+		leaseTTL:       leaseTTL,
+		forwardTimeout: forwardTimeout,
+	}
+}
+
+// NodeID returns this node's ID
+func (c *Cluster) NodeID() string {
+	return c.nodeID
+}
+
+// leaseKey holds the ID of the node that owns a game
+func leaseKey(gameID string) string {
+	return fmt.Sprintf("kekopoly:game:%s:lease", strings.ToLower(gameID))
+}
+
+// acquire takes the game's lease if nobody holds it and returns the owner
+func (c *Cluster) acquire(ctx context.Context, gameID string) (string, error) {
+	key := leaseKey(gameID)
+	for attempt := 0; attempt < 3; attempt++ {
+		ok, err := c.client.SetNX(ctx, key, c.nodeID, c.leaseTTL).Result()
+		if err != nil {
+			return "", fmt.Errorf("failed to acquire lease for game %s: %w", gameID, err)
+		}
+		if ok {
+			return c.nodeID, nil
+		}
+
+		owner, err := c.client.Get(ctx, key).Result()
+		if errors.Is(err, redis.Nil) {
+			// Expired between the two calls; try again
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read lease for game %s: %w", gameID, err)
+		}
+		if owner == c.nodeID {
+			// Held from before a restart with the same node ID
+			if _, err := c.renew(ctx, gameID); err != nil {
+				return "", err
+			}
+		}
+		return owner, nil
+	}
+	return "", fmt.Errorf("failed to acquire lease for game %s: lease keeps changing", gameID)
+}
+
+// renew extends this node's lease, reporting false if another node has it now
+func (c *Cluster) renew(ctx context.Context, gameID string) (bool, error) {
+	n, err := renewLeaseScript.Run(ctx, c.client, []string{leaseKey(gameID)}, c.nodeID, c.leaseTTL.Milliseconds()).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to renew lease for game %s: %w", gameID, err)
+	}
+	return n == 1, nil
+}
+
+// release gives up this node's lease so another node can take the game at once
+func (c *Cluster) release(ctx context.Context, gameID string) error {
+	return releaseLeaseScript.Run(ctx, c.client, []string{leaseKey(gameID)}, c.nodeID).Err()
+}
+
+// holdsLease reports whether this node owns the game. Single-node managers own every game.
+func (gm *GameManager) holdsLease(gameID string) bool {
+	if gm.cluster == nil {
+		return true
+	}
+	gm.leaseMutex.Lock()
+	defer gm.leaseMutex.Unlock()
+	expiry, ok := gm.leases[strings.ToLower(gameID)]
+	return ok && time.Now().Before(expiry)
+}
+
+// recordLease notes that this node holds the game's lease as of now
+func (gm *GameManager) recordLease(gameID string) {
+	gm.leaseMutex.Lock()
+	defer gm.leaseMutex.Unlock()
+	gm.leases[strings.ToLower(gameID)] = time.Now().Add(gm.cluster.leaseTTL)
+}
+
+// claimGame takes the lease for a game this node just created
+func (gm *GameManager) claimGame(gameID string) error {
+	owner, err := gm.cluster.acquire(gm.ctx, gameID)
+	if err != nil {
+		return err
+	}
+	if owner != gm.cluster.nodeID {
+		return fmt.Errorf("game %s is already owned by node %s", gameID, owner)
+	}
+	gm.recordLease(gameID)
+	return nil
+}
+
+// ownerOf returns the node that owns the game. A game nobody owns, because it is new
+// to the cluster or its owner died, is taken over by this node from the store.
+func (gm *GameManager) ownerOf(gameID string) (string, error) {
+	if gm.holdsLease(gameID) {
+		return gm.cluster.nodeID, nil
+	}
+
+	// One takeover at a time, so concurrent requests don't load the game twice
+	gm.takeoverMutex.Lock()
+	defer gm.takeoverMutex.Unlock()
+	if gm.holdsLease(gameID) {
+		return gm.cluster.nodeID, nil
+	}
+
+	owner, err := gm.cluster.acquire(gm.ctx, gameID)
+	if err != nil {
+		return "", err
+	}
+	if owner != gm.cluster.nodeID {
+		return owner, nil
+	}
+
+	if err := gm.takeOver(gameID); err != nil {
+		if releaseErr := gm.cluster.release(gm.ctx, gameID); releaseErr != nil {
+			gm.logger.Errorf("[Cluster] Failed to release lease for game %s: %v", gameID, releaseErr)
+		}
+		return "", err
+	}
+	return gm.cluster.nodeID, nil
+}
+
+// takeOver loads a game this node just acquired from the store, replacing any copy
+// left over from an earlier lease
+func (gm *GameManager) takeOver(gameID string) error {
+	objID, err := primitive.ObjectIDFromHex(gameID)
+	if err != nil {
+		return fmt.Errorf("invalid game ID: %w", err)
+	}
+	game, err := gm.store.FindGame(gm.ctx, objID)
+	if err != nil {
+		if errors.Is(err, ErrGameNotFound) {
+			return fmt.Errorf("game session not found")
+		}
+		return err
+	}
+
+	session := &GameSession{
+		Game:              game,
+		ConnectedPlayers:  make(map[string]string),
+		PlayerConnections: make(map[string]PlayerConnection),
+	}
+	gm.activeGamesMutex.Lock()
+	gm.activeGames[strings.ToLower(gameID)] = session
+	gm.activeGamesMutex.Unlock()
+	gm.recordLease(gameID)
+
+	gm.logger.Infof("[Cluster] Node %s took over game %s (status %s)", gm.cluster.nodeID, gameID, game.Status)
+
+	// A bot whose turn it was, or the pause's timer, stopped with the old owner
+	session.mutex.Lock()
+	gm.scheduleBot(session)
+	gm.armAutoResume(session)
+	session.mutex.Unlock()
+	return nil
+}
+
+// runLeaseRenewal keeps this node's leases alive. Games whose lease was lost are dropped
+// from memory, and leases for games no longer in memory are released.
+func (gm *GameManager) runLeaseRenewal() {
+	ticker := time.NewTicker(gm.cluster.leaseTTL / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-gm.ctx.Done():
+			return
+		case <-ticker.C:
+			gm.renewLeases()
+		}
+	}
+}
+
+// renewLeases runs one round of lease renewal
+func (gm *GameManager) renewLeases() {
+	gm.leaseMutex.Lock()
+	gameIDs := make([]string, 0, len(gm.leases))
+	for gameID := range gm.leases {
+		gameIDs = append(gameIDs, gameID)
+	}
+	gm.leaseMutex.Unlock()
+
+	for _, gameID := range gameIDs {
+		gm.activeGamesMutex.RLock()
+		_, active := gm.activeGames[gameID]
+		gm.activeGamesMutex.RUnlock()
+
+		if !active {
+			gm.dropLease(gameID)
+			if err := gm.cluster.release(gm.ctx, gameID); err != nil {
+				gm.logger.Warnf("[Cluster] Failed to release lease for game %s: %v", gameID, err)
+			}
+			continue
+		}
+
+		held, err := gm.cluster.renew(gm.ctx, gameID)
+		if err != nil {
+			// Keep serving until the local lease runs out; Redis may come back
+			gm.logger.Warnf("[Cluster] %v", err)
+			if !gm.holdsLease(gameID) {
+				gm.loseGame(gameID)
+			}
+			continue
+		}
+		if !held {
+			gm.loseGame(gameID)
+			continue
+		}
+		gm.recordLease(gameID)
+	}
+}
+
+// loseGame forgets a game whose lease passed to another node
+func (gm *GameManager) loseGame(gameID string) {
+	gm.dropLease(gameID)
+	gm.activeGamesMutex.Lock()
+	delete(gm.activeGames, gameID)
+	gm.activeGamesMutex.Unlock()
+	gm.logger.Warnf("[Cluster] Node %s lost the lease for game %s", gm.cluster.nodeID, gameID)
+}
+
+// dropLease removes the local record of a lease
+func (gm *GameManager) dropLease(gameID string) {
+	gm.leaseMutex.Lock()
+	delete(gm.leases, gameID)
+	gm.leaseMutex.Unlock()
+}
+
+// resolveGameID turns a room code into the game's ID so requests route by ID
+func (gm *GameManager) resolveGameID(gameID string) string {
+	normalizedGameID := strings.ToLower(gameID)
+	if _, err := primitive.ObjectIDFromHex(normalizedGameID); err == nil || len(normalizedGameID) != 6 {
+		return normalizedGameID
+	}
+	game, err := gm.store.FindGameByCode(gm.ctx, normalizedGameID)
+	if err != nil {
+		return normalizedGameID
+	}
+	return game.ID.Hex()
+}
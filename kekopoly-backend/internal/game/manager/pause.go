@@ -0,0 +1,301 @@
+package manager
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/kekopoly/backend/internal/game/models"
+	"github.com/kekopoly/backend/internal/game/protocol"
+)
+
+var (
+	// ErrGamePaused is returned for actions in a paused game, and for pausing it again
+	ErrGamePaused = errors.New("game is paused")
+	// ErrGameNotActive is returned when pausing a game that isn't being played
+	ErrGameNotActive = errors.New("game is not active")
+	// ErrGameNotPaused is returned when resuming a game that isn't paused
+	ErrGameNotPaused = errors.New("game is not paused")
+	// ErrCannotVote is returned when someone who isn't playing votes to pause or resume
+	ErrCannotVote = errors.New("only active players can vote to pause or resume")
+)
+
+// defaultMaxPause is how long a game stays paused when the options don't say
+const defaultMaxPause = 10 * time.Minute
+
+// Who pauses or resumes a game when it isn't the host
+const (
+	pausedByVote     = "vote"
+	resumedByTimeout = "timeout"
+)
+
+// PauseGame pauses an active game. The host pauses it straight away; anyone else playing
+// casts a vote, and the game pauses once a majority of the human players still in it
+// have voted. paused reports whether the game is now paused.
+func (gm *GameManager) PauseGame(gameID, playerID string) (paused bool, err error) {
+	if reply, handled, err := gm.remote(forwardRequest{Op: opPauseGame, GameID: gameID, PlayerID: playerID}); handled {
+		if err != nil {
+			return false, err
+		}
+		return reply.Result == "paused", nil
+	}
+
+	session, err := gm.lockedSession(gameID, time.Time{})
+	if err != nil {
+		return false, err
+	}
+	defer session.mutex.Unlock()
+	game := session.Game
+
+	switch game.Status {
+	case models.GameStatusActive:
+	case models.GameStatusPaused:
+		return false, ErrGamePaused
+	default:
+		return false, ErrGameNotActive
+	}
+
+	if playerID == game.HostID {
+		return true, gm.pause(session, playerID)
+	}
+
+	votes, needed, err := gm.castVote(game, &game.PauseVotes, playerID)
+	if err != nil {
+		return false, err
+	}
+	if votes >= needed {
+		return true, gm.pause(session, pausedByVote)
+	}
+	if err := gm.store.SetGameFields(gm.ctx, game.ID, bson.M{"pauseVotes": game.PauseVotes}); err != nil {
+		return false, fmt.Errorf("failed to persist vote: %w", err)
+	}
+	gm.broadcastVote(game, playerID, "pause", votes, needed)
+	return false, nil
+}
+
+// ResumeGame resumes a paused game. As with pausing, the host resumes it straight away
+// and anyone else playing casts a vote. resumed reports whether play has resumed.
+func (gm *GameManager) ResumeGame(gameID, playerID string) (resumed bool, err error) {
+	if reply, handled, err := gm.remote(forwardRequest{Op: opResumeGame, GameID: gameID, PlayerID: playerID}); handled {
+		if err != nil {
+			return false, err
+		}
+		return reply.Result == "resumed", nil
+	}
+
+	session, err := gm.lockedSession(gameID, time.Time{})
+	if err != nil {
+		return false, err
+	}
+	defer session.mutex.Unlock()
+	game := session.Game
+
+	if game.Status != models.GameStatusPaused || game.Pause == nil {
+		return false, ErrGameNotPaused
+	}
+
+	if playerID == game.HostID {
+		return true, gm.resume(session, playerID)
+	}
+
+	votes, needed, err := gm.castVote(game, &game.Pause.ResumeVotes, playerID)
+	if err != nil {
+		return false, err
+	}
+	if votes >= needed {
+		return true, gm.resume(session, pausedByVote)
+	}
+	if err := gm.store.SetGameFields(gm.ctx, game.ID, bson.M{"pause": game.Pause}); err != nil {
+		return false, fmt.Errorf("failed to persist vote: %w", err)
+	}
+	gm.broadcastVote(game, playerID, "resume", votes, needed)
+	return false, nil
+}
+
+// lockedSession returns a game's session, locked; the caller must unlock it. A forwarded
+// request passes its deadline, which is checked once the lock is held: a request that
+// waited for the lock past it is refused, since its sender has already reported failure.
+func (gm *GameManager) lockedSession(gameID string, deadline time.Time) (*GameSession, error) {
+	gm.activeGamesMutex.RLock()
+	session, exists := gm.activeGames[gm.resolveGameID(gameID)]
+	gm.activeGamesMutex.RUnlock()
+	if !exists {
+		return nil, ErrGameNotFound
+	}
+	session.mutex.Lock()
+	if !deadline.IsZero() && time.Now().After(deadline) {
+		session.mutex.Unlock()
+		return nil, fmt.Errorf("%w: request for game %s expired before it ran", ErrOwnerUnavailable, gameID)
+	}
+	return session, nil
+}
+
+// castVote adds the player's vote and counts the votes of the human players still in
+// the game. A majority of them carries it. The caller must hold the session lock.
+func (gm *GameManager) castVote(game *models.Game, votes *[]string, playerID string) (int, int, error) {
+	voters := make(map[string]bool)
+	for _, player := range game.Players {
+		if player.Status == models.PlayerStatusActive && player.Bot == "" {
+			voters[player.ID] = true
+		}
+	}
+	if findPlayer(game, playerID) == nil {
+		return 0, 0, ErrPlayerNotInGame
+	}
+	if !voters[playerID] {
+		return 0, 0, ErrCannotVote
+	}
+
+	cast := false
+	counted := 0
+	for _, voter := range *votes {
+		cast = cast || voter == playerID
+		if voters[voter] {
+			counted++
+		}
+	}
+	if !cast {
+		*votes = append(*votes, playerID)
+		counted++
+	}
+	return counted, len(voters)/2 + 1, nil
+}
+
+// broadcastVote tells the game about a vote that didn't carry yet
+func (gm *GameManager) broadcastVote(game *models.Game, playerID, action string, votes, needed int) {
+	gm.logger.Infof("Player %s voted to %s game %s (%d of %d)", playerID, action, game.ID.Hex(), votes, needed)
+	gm.broadcast(game.ID.Hex(), &protocol.PauseVote{
+		PlayerID:  playerID,
+		Action:    action,
+		Votes:     votes,
+		Needed:    needed,
+		Timestamp: time.Now().Format(time.RFC3339),
+	})
+}
+
+// pause stops play until the game is resumed or maxPause runs out. The caller must
+// hold the session lock.
+func (gm *GameManager) pause(session *GameSession, pausedBy string) error {
+	game := session.Game
+	now := time.Now()
+	game.Status = models.GameStatusPaused
+	game.PauseVotes = nil
+	game.Pause = &models.GamePause{
+		PausedBy:  pausedBy,
+		PausedAt:  now,
+		ResumesAt: now.Add(gm.maxPause),
+	}
+	game.UpdatedAt = now
+	if err := gm.store.SetGameFields(gm.ctx, game.ID, bson.M{
+		"status":     game.Status,
+		"pause":      game.Pause,
+		"pauseVotes": game.PauseVotes,
+		"updatedAt":  now,
+	}); err != nil {
+		return fmt.Errorf("failed to persist pause: %w", err)
+	}
+
+	gm.logger.Infof("Game %s paused by %s until %s", game.ID.Hex(), pausedBy, game.Pause.ResumesAt.Format(time.RFC3339))
+	gm.armAutoResume(session)
+	gm.notifyListing(game.ID.Hex())
+	gm.broadcast(game.ID.Hex(), &protocol.GamePaused{
+		PausedBy:  pausedBy,
+		ResumesAt: game.Pause.ResumesAt.Format(time.RFC3339),
+		Timestamp: now.Format(time.RFC3339),
+	})
+	return nil
+}
+
+// resume restarts play. Disconnected players' clocks skip the time spent paused, so a
+// bot takes over their seat no sooner than if the game had never paused. The caller must
+// hold the session lock.
+func (gm *GameManager) resume(session *GameSession, resumedBy string) error {
+	game := session.Game
+	gameID := game.ID.Hex()
+	now := time.Now()
+	pausedAt := game.Pause.PausedAt
+
+	for i := range game.Players {
+		player := &game.Players[i]
+		if player.Status != models.PlayerStatusDisconnected || player.DisconnectedAt == nil {
+			continue
+		}
+		disconnectedAt := now
+		if player.DisconnectedAt.Before(pausedAt) {
+			disconnectedAt = player.DisconnectedAt.Add(now.Sub(pausedAt))
+		}
+		player.DisconnectedAt = &disconnectedAt
+		gm.armTakeOver(session, player.ID, time.Until(disconnectedAt.Add(gm.bots.TakeOverAfter)))
+	}
+
+	game.Status = models.GameStatusActive
+	game.Pause = nil
+	game.UpdatedAt = now
+	game.LastActivity = now
+	if err := gm.store.SetGameFields(gm.ctx, game.ID, bson.M{
+		"status":       game.Status,
+		"pause":        game.Pause,
+		"players":      game.Players,
+		"updatedAt":    now,
+		"lastActivity": now,
+	}); err != nil {
+		return fmt.Errorf("failed to persist resume: %w", err)
+	}
+
+	gm.logger.Infof("Game %s resumed by %s after %s", gameID, resumedBy, now.Sub(pausedAt).Round(time.Second))
+	gm.notifyListing(gameID)
+	gm.broadcast(gameID, &protocol.GameResumed{
+		ResumedBy: resumedBy,
+		Timestamp: now.Format(time.RFC3339),
+	})
+	gm.scheduleBot(session)
+	return nil
+}
+
+// armTakeOver starts the clock for a bot to take over a disconnected player's seat
+// again after a pause. The caller must hold the session lock.
+func (gm *GameManager) armTakeOver(session *GameSession, playerID string, after time.Duration) {
+	if !gm.bots.TakeOver {
+		return
+	}
+	gameID := session.Game.ID.Hex()
+	for sessionID, connection := range session.PlayerConnections {
+		if connection.PlayerID == playerID && !connection.IsConnected {
+			time.AfterFunc(after, func() {
+				gm.substituteBot(gameID, playerID, sessionID)
+			})
+			return
+		}
+	}
+}
+
+// armAutoResume resumes a paused game when its pause runs out, unless it was resumed
+// and paused again in the meantime. The caller must hold the session lock.
+func (gm *GameManager) armAutoResume(session *GameSession) {
+	game := session.Game
+	if game.Status != models.GameStatusPaused || game.Pause == nil {
+		return
+	}
+	gameID := game.ID.Hex()
+	pausedAt := game.Pause.PausedAt
+	time.AfterFunc(time.Until(game.Pause.ResumesAt), func() {
+		gm.activeGamesMutex.RLock()
+		current, exists := gm.activeGames[gameID]
+		gm.activeGamesMutex.RUnlock()
+		if !exists || current != session {
+			return
+		}
+
+		session.mutex.Lock()
+		defer session.mutex.Unlock()
+		pause := session.Game.Pause
+		if session.Game.Status != models.GameStatusPaused || pause == nil || !pause.PausedAt.Equal(pausedAt) {
+			return
+		}
+		if err := gm.resume(session, resumedByTimeout); err != nil {
+			gm.logger.Errorf("Failed to resume game %s after its pause ran out: %v", gameID, err)
+		}
+	})
+}
@@ -0,0 +1,131 @@
+package manager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kekopoly/backend/internal/game/models"
+)
+
+// startedGame starts a game hosted by alice with the other players connected
+func startedGame(t *testing.T, gm *GameManager, others ...string) (string, map[string]string) {
+	t.Helper()
+	gameID, err := gm.CreateGame("alice", "wallet-alice", "", 4, 0)
+	require.NoError(t, err)
+	sessions := make(map[string]string)
+	for _, playerID := range others {
+		sessionID, err := gm.JoinGame(gameID, playerID, "wallet-"+playerID)
+		require.NoError(t, err)
+		gm.PlayerConnected(gameID, playerID, sessionID)
+		sessions[playerID] = sessionID
+	}
+	require.NoError(t, gm.StartGame(gameID, "alice"))
+	return gameID, sessions
+}
+
+// status reads a game's status under the session lock
+func status(t *testing.T, gm *GameManager, gameID string) models.GameStatus {
+	t.Helper()
+	gm.activeGamesMutex.RLock()
+	session := gm.activeGames[gameID]
+	gm.activeGamesMutex.RUnlock()
+	require.NotNil(t, session)
+
+	session.mutex.RLock()
+	defer session.mutex.RUnlock()
+	return session.Game.Status
+}
+
+func TestPauseAndResume(t *testing.T) {
+	gm := newTestManager(t, &recordingHub{})
+
+	lobbyID, err := gm.CreateGame("alice", "wallet-alice", "", 4, 0)
+	require.NoError(t, err)
+	_, err = gm.PauseGame(lobbyID, "alice")
+	assert.ErrorIs(t, err, ErrGameNotActive)
+
+	gameID, _ := startedGame(t, gm, "brian", "carol")
+	_, err = gm.PauseGame(gameID, "dave")
+	assert.ErrorIs(t, err, ErrPlayerNotInGame)
+
+	// Two of the three players carry a vote, and voting twice counts once
+	paused, err := gm.PauseGame(gameID, "brian")
+	require.NoError(t, err)
+	assert.False(t, paused)
+	paused, err = gm.PauseGame(gameID, "brian")
+	require.NoError(t, err)
+	assert.False(t, paused)
+	assert.Equal(t, models.GameStatusActive, status(t, gm, gameID))
+
+	paused, err = gm.PauseGame(gameID, "carol")
+	require.NoError(t, err)
+	assert.True(t, paused)
+	stored := storedGame(t, gm.store, gameID)
+	assert.Equal(t, models.GameStatusPaused, stored.Status)
+	require.NotNil(t, stored.Pause)
+	assert.Equal(t, "vote", stored.Pause.PausedBy)
+	assert.Empty(t, stored.PauseVotes)
+
+	// Nothing can be played while paused
+	turn, _ := seat(t, gm, gameID, "alice")
+	err = gm.ProcessGameAction(models.GameAction{Type: models.ActionTypeRollDice, PlayerID: turn, GameID: gameID})
+	assert.ErrorIs(t, err, ErrGamePaused)
+	_, err = gm.PauseGame(gameID, "alice")
+	assert.ErrorIs(t, err, ErrGamePaused)
+
+	// The host doesn't need a vote
+	resumed, err := gm.ResumeGame(gameID, "brian")
+	require.NoError(t, err)
+	assert.False(t, resumed)
+	resumed, err = gm.ResumeGame(gameID, "alice")
+	require.NoError(t, err)
+	assert.True(t, resumed)
+	assert.Equal(t, models.GameStatusActive, status(t, gm, gameID))
+	assert.Nil(t, storedGame(t, gm.store, gameID).Pause)
+
+	_, err = gm.ResumeGame(gameID, "alice")
+	assert.ErrorIs(t, err, ErrGameNotPaused)
+	require.NoError(t, gm.ProcessGameAction(models.GameAction{Type: models.ActionTypeRollDice, PlayerID: turn, GameID: gameID}))
+}
+
+func TestPauseRunsOut(t *testing.T) {
+	gm := newTestManager(t, &recordingHub{})
+	gm.maxPause = 20 * time.Millisecond
+
+	gameID, _ := startedGame(t, gm, "brian")
+	paused, err := gm.PauseGame(gameID, "alice")
+	require.NoError(t, err)
+	require.True(t, paused)
+
+	require.Eventually(t, func() bool {
+		return status(t, gm, gameID) == models.GameStatusActive
+	}, 5*time.Second, 5*time.Millisecond)
+}
+
+func TestPauseFreezesTakeOver(t *testing.T) {
+	gm := newBotManager(t, BotOptions{TakeOver: true, TakeOverAfter: 50 * time.Millisecond})
+
+	gameID, sessions := startedGame(t, gm, "brian")
+	gm.PlayerDisconnected(gameID, sessions["brian"])
+	_, err := gm.PauseGame(gameID, "alice")
+	require.NoError(t, err)
+
+	// No bot takes brian's seat while the game is paused
+	time.Sleep(100 * time.Millisecond)
+	_, brian := seat(t, gm, gameID, "brian")
+	assert.False(t, brian.Substitute)
+	disconnectedAt := *brian.DisconnectedAt
+
+	// Resuming restarts brian's clock where it stopped
+	_, err = gm.ResumeGame(gameID, "alice")
+	require.NoError(t, err)
+	_, brian = seat(t, gm, gameID, "brian")
+	assert.True(t, brian.DisconnectedAt.After(disconnectedAt.Add(90*time.Millisecond)))
+	require.Eventually(t, func() bool {
+		_, brian := seat(t, gm, gameID, "brian")
+		return brian.Substitute
+	}, 5*time.Second, 5*time.Millisecond)
+}
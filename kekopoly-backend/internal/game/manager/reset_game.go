@@ -8,44 +8,41 @@ import (
 	"github.com/kekopoly/backend/internal/game/models"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// ErrNotAbandoned is returned when resetting a game that wasn't abandoned
+var ErrNotAbandoned = errors.New("only abandoned games can be reset")
+
 // ResetGameStatus resets an abandoned game back to LOBBY status
 func (gm *GameManager) ResetGameStatus(gameID string, requestingPlayerID string) error {
+	if _, handled, err := gm.remote(forwardRequest{Op: opResetGame, GameID: gameID, PlayerID: requestingPlayerID}); handled {
+		return err
+	}
+
 	// Get game from database to ensure we have the latest state
 	objID, err := primitive.ObjectIDFromHex(gameID)
 	if err != nil {
 		return fmt.Errorf("invalid game ID: %w", err)
 	}
 
-	collection := gm.mongoClient.Database(gm.dbName).Collection("games")
-	var game models.Game
-	err = collection.FindOne(gm.ctx, bson.M{"_id": objID}).Decode(&game)
+	game, err := gm.store.FindGame(gm.ctx, objID)
 	if err != nil {
-		if errors.Is(err, mongo.ErrNoDocuments) {
-			return fmt.Errorf("game not found")
-		}
-		return fmt.Errorf("failed to get game: %w", err)
+		return err
 	}
 
 	// Verify the game is in ABANDONED status
 	if game.Status != models.GameStatusAbandoned {
-		return fmt.Errorf("only abandoned games can be reset")
+		return ErrNotAbandoned
 	}
 
 	// Update game status to LOBBY
 	now := time.Now()
-	_, err = collection.UpdateOne(
-		gm.ctx,
-		bson.M{"_id": objID},
-		bson.M{"$set": bson.M{
-			"status":       models.GameStatusLobby,
-			"updatedAt":    now,
-			"lastActivity": now,
-			"hostId":       requestingPlayerID, // Set the requesting player as the new host
-		}},
-	)
+	err = gm.store.SetGameFields(gm.ctx, objID, bson.M{
+		"status":       models.GameStatusLobby,
+		"updatedAt":    now,
+		"lastActivity": now,
+		"hostId":       requestingPlayerID, // Set the requesting player as the new host
+	})
 
 	if err != nil {
 		return fmt.Errorf("failed to update game status: %w", err)
@@ -65,7 +62,7 @@ func (gm *GameManager) ResetGameStatus(gameID string, requestingPlayerID string)
 	session, exists := gm.activeGames[gameID]
 	if !exists {
 		session = &GameSession{
-			Game:              &game,
+			Game:              game,
 			ConnectedPlayers:  make(map[string]string),
 			PlayerConnections: make(map[string]PlayerConnection),
 		}
@@ -80,5 +77,6 @@ func (gm *GameManager) ResetGameStatus(gameID string, requestingPlayerID string)
 	}
 
 	gm.logger.Infof("Game %s reset from ABANDONED to LOBBY status by player %s", gameID, requestingPlayerID)
+	gm.notifyListing(gameID)
 	return nil
 }
@@ -0,0 +1,111 @@
+package manager
+
+import (
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/kekopoly/backend/internal/game/models"
+)
+
+// GameSnapshot returns a copy of a game as it stands, for saving. A game this node is
+// playing is copied under its lock; any other game is read from the store.
+func (gm *GameManager) GameSnapshot(gameID string) (*models.Game, error) {
+	gameID = gm.resolveGameID(gameID)
+	gm.activeGamesMutex.RLock()
+	session, exists := gm.activeGames[gameID]
+	gm.activeGamesMutex.RUnlock()
+	if exists {
+		session.mutex.RLock()
+		defer session.mutex.RUnlock()
+		return copyGame(session.Game)
+	}
+
+	objID, err := primitive.ObjectIDFromHex(gameID)
+	if err != nil {
+		return nil, ErrGameNotFound
+	}
+	return gm.store.FindGame(gm.ctx, objID)
+}
+
+// ImportGame creates a new game from a saved one, with its own ID and room code, and
+// returns the new game's ID. The copy is never settled: its buy-in and deposits are
+// cleared, so it can't touch escrow. A paused copy gets a fresh pause, and games still
+// being played are loaded on this node.
+func (gm *GameManager) ImportGame(saved *models.Game) (string, error) {
+	roomCode, err := gm.uniqueRoomCode()
+	if err != nil {
+		return "", err
+	}
+
+	game, err := copyGame(saved)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	game.ID = primitive.NewObjectID()
+	game.Code = roomCode
+	game.PasswordHash = ""
+	game.CreatedAt = now
+	game.UpdatedAt = now
+	game.LastActivity = now
+	game.BuyIn = 0
+	game.SettlementStatus = models.SettlementStatusPending
+	for i := range game.Players {
+		player := &game.Players[i]
+		player.InitialDeposit = 0
+		player.DepositStatus = ""
+		player.DepositedAmount = 0
+		player.DepositTxIDs = nil
+		player.DepositDeadline = nil
+	}
+	if game.Pause != nil {
+		game.Pause.PausedAt = now
+		game.Pause.ResumesAt = now.Add(gm.maxPause)
+	}
+
+	if err := gm.insertGame(game); err != nil {
+		return "", err
+	}
+	gameID := game.ID.Hex()
+	gm.logger.Infof("Imported game %s with code %s from a snapshot (status %s)", gameID, roomCode, game.Status)
+
+	switch game.Status {
+	case models.GameStatusLobby, models.GameStatusActive, models.GameStatusPaused:
+	default:
+		return gameID, nil
+	}
+
+	session := &GameSession{
+		Game:              game,
+		ConnectedPlayers:  make(map[string]string),
+		PlayerConnections: make(map[string]PlayerConnection),
+	}
+	gm.activeGamesMutex.Lock()
+	gm.activeGames[gameID] = session
+	gm.activeGamesMutex.Unlock()
+
+	session.mutex.Lock()
+	gm.scheduleBot(session)
+	gm.armAutoResume(session)
+	session.mutex.Unlock()
+
+	gm.notifyListing(gameID)
+	return gameID, nil
+}
+
+// copyGame deep copies a game through BSON, so the copy shares nothing with it
+func copyGame(game *models.Game) (*models.Game, error) {
+	raw, err := bson.Marshal(game)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy game: %w", err)
+	}
+	var copied models.Game
+	if err := bson.Unmarshal(raw, &copied); err != nil {
+		return nil, fmt.Errorf("failed to copy game: %w", err)
+	}
+	return &copied, nil
+}
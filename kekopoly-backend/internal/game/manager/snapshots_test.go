@@ -0,0 +1,76 @@
+package manager
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kekopoly/backend/internal/game/models"
+	"github.com/kekopoly/backend/internal/game/snapshot"
+)
+
+// importFixture sets up a game from an archive in testdata
+func importFixture(t *testing.T, gm *GameManager, name string) (string, *snapshot.Archive) {
+	t.Helper()
+	archive, err := snapshot.Load(filepath.Join("testdata", name))
+	require.NoError(t, err)
+	gameID, err := gm.ImportGame(&archive.Game)
+	require.NoError(t, err)
+	return gameID, archive
+}
+
+func TestImportGame(t *testing.T) {
+	gm := newTestManager(t, &recordingHub{})
+
+	gameID, archive := importFixture(t, gm, "jailed.json")
+	assert.NotEqual(t, archive.Game.ID.Hex(), gameID)
+
+	// The copy starts where the saved game was, but can't touch escrow
+	imported, err := gm.GameSnapshot(gameID)
+	require.NoError(t, err)
+	assert.NotEqual(t, archive.Game.Code, imported.Code)
+	assert.Equal(t, models.GameStatusActive, imported.Status)
+	assert.Equal(t, "alice", imported.CurrentTurn)
+	assert.Zero(t, imported.BuyIn)
+	alice := findPlayer(imported, "alice")
+	require.NotNil(t, alice)
+	assert.True(t, alice.InJail)
+	assert.Equal(t, 640, alice.Balance)
+	assert.Zero(t, alice.DepositedAmount)
+	assert.Empty(t, alice.DepositTxIDs)
+	assert.Equal(t, "alice", imported.BoardState.Properties[0].OwnerID)
+	assert.Equal(t, imported.Code, storedGame(t, gm.store, gameID).Code)
+
+	// It can be played straight away
+	require.NoError(t, gm.ProcessGameAction(models.GameAction{Type: models.ActionTypeRollDice, PlayerID: "alice", GameID: gameID}))
+
+	// A snapshot of it imports again, into another game
+	again, err := gm.ImportGame(imported)
+	require.NoError(t, err)
+	assert.NotEqual(t, gameID, again)
+	_, err = gm.GameSnapshot("6712a0c0e4b0a1b2c3d4e5f6")
+	assert.ErrorIs(t, err, ErrGameNotFound)
+}
+
+func TestImportPausedGame(t *testing.T) {
+	gm := newTestManager(t, &recordingHub{})
+
+	archive, err := snapshot.Load(filepath.Join("testdata", "jailed.json"))
+	require.NoError(t, err)
+	archive.Game.Status = models.GameStatusPaused
+	archive.Game.Pause = &models.GamePause{PausedBy: "alice", PausedAt: archive.ExportedAt, ResumesAt: archive.ExportedAt}
+
+	// The saved pause ran out long ago; the copy gets a fresh one
+	gameID, err := gm.ImportGame(&archive.Game)
+	require.NoError(t, err)
+	assert.Equal(t, models.GameStatusPaused, status(t, gm, gameID))
+	imported, err := gm.GameSnapshot(gameID)
+	require.NoError(t, err)
+	assert.True(t, imported.Pause.ResumesAt.After(archive.ExportedAt))
+
+	resumed, err := gm.ResumeGame(gameID, "alice")
+	require.NoError(t, err)
+	assert.True(t, resumed)
+}
@@ -0,0 +1,204 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/kekopoly/backend/internal/game/models"
+)
+
+// ErrGameNotFound is returned by a GameStore when no game matches
+var ErrGameNotFound = errors.New("game not found")
+
+// GameStore persists individual games. It is the state a node loads from when it
+// takes over a game another node owned.
+type GameStore interface {
+	InsertGame(ctx context.Context, game *models.Game) error
+	FindGame(ctx context.Context, id primitive.ObjectID) (*models.Game, error)
+	FindGameByCode(ctx context.Context, code string) (*models.Game, error)
+	FindGamesByStatus(ctx context.Context, statuses ...models.GameStatus) ([]models.Game, error)
+	// SetGameFields applies a $set-style update; fields is a bson.M or a whole game
+	SetGameFields(ctx context.Context, id primitive.ObjectID, fields interface{}) error
+}
+
+// MongoGameStore keeps games in a MongoDB collection
+type MongoGameStore struct {
+	collection *mongo.Collection
+}
+
+// NewMongoGameStore creates a store backed by the games collection
+func NewMongoGameStore(client *mongo.Client, dbName string) *MongoGameStore {
+	return &MongoGameStore{collection: client.Database(dbName).Collection("games")}
+}
+
+// InsertGame implements GameStore
+func (s *MongoGameStore) InsertGame(ctx context.Context, game *models.Game) error {
+	_, err := s.collection.InsertOne(ctx, game)
+	return err
+}
+
+// FindGame implements GameStore
+func (s *MongoGameStore) FindGame(ctx context.Context, id primitive.ObjectID) (*models.Game, error) {
+	return s.findOne(ctx, bson.M{"_id": id})
+}
+
+// FindGameByCode implements GameStore
+func (s *MongoGameStore) FindGameByCode(ctx context.Context, code string) (*models.Game, error) {
+	return s.findOne(ctx, bson.M{"code": strings.ToUpper(code)})
+}
+
+func (s *MongoGameStore) findOne(ctx context.Context, filter bson.M) (*models.Game, error) {
+	var game models.Game
+	if err := s.collection.FindOne(ctx, filter).Decode(&game); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrGameNotFound
+		}
+		return nil, fmt.Errorf("failed to get game: %w", err)
+	}
+	return &game, nil
+}
+
+// FindGamesByStatus implements GameStore
+func (s *MongoGameStore) FindGamesByStatus(ctx context.Context, statuses ...models.GameStatus) ([]models.Game, error) {
+	cursor, err := s.collection.Find(ctx, bson.M{"status": bson.M{"$in": statuses}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query games: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var games []models.Game
+	if err := cursor.All(ctx, &games); err != nil {
+		return nil, fmt.Errorf("failed to decode games: %w", err)
+	}
+	return games, nil
+}
+
+// SetGameFields implements GameStore
+func (s *MongoGameStore) SetGameFields(ctx context.Context, id primitive.ObjectID, fields interface{}) error {
+	_, err := s.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": fields})
+	return err
+}
+
+// MemoryGameStore keeps games in memory, for tests and single-process tools.
+// Games are stored as BSON documents so updates behave like MongoDB's $set.
+type MemoryGameStore struct {
+	mu    sync.RWMutex
+	games map[primitive.ObjectID]bson.M
+}
+
+// NewMemoryGameStore creates an empty in-memory store
+func NewMemoryGameStore() *MemoryGameStore {
+	return &MemoryGameStore{games: make(map[primitive.ObjectID]bson.M)}
+}
+
+// InsertGame implements GameStore
+func (s *MemoryGameStore) InsertGame(ctx context.Context, game *models.Game) error {
+	doc, err := toDocument(game)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.games[game.ID]; exists {
+		return fmt.Errorf("game %s already exists", game.ID.Hex())
+	}
+	s.games[game.ID] = doc
+	return nil
+}
+
+// FindGame implements GameStore
+func (s *MemoryGameStore) FindGame(ctx context.Context, id primitive.ObjectID) (*models.Game, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	doc, ok := s.games[id]
+	if !ok {
+		return nil, ErrGameNotFound
+	}
+	return fromDocument(doc)
+}
+
+// FindGameByCode implements GameStore
+func (s *MemoryGameStore) FindGameByCode(ctx context.Context, code string) (*models.Game, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, doc := range s.games {
+		if doc["code"] == strings.ToUpper(code) {
+			return fromDocument(doc)
+		}
+	}
+	return nil, ErrGameNotFound
+}
+
+// FindGamesByStatus implements GameStore
+func (s *MemoryGameStore) FindGamesByStatus(ctx context.Context, statuses ...models.GameStatus) ([]models.Game, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var games []models.Game
+	for _, doc := range s.games {
+		for _, status := range statuses {
+			if doc["status"] == string(status) {
+				game, err := fromDocument(doc)
+				if err != nil {
+					return nil, err
+				}
+				games = append(games, *game)
+				break
+			}
+		}
+	}
+	return games, nil
+}
+
+// SetGameFields implements GameStore
+func (s *MemoryGameStore) SetGameFields(ctx context.Context, id primitive.ObjectID, fields interface{}) error {
+	update, err := toDocument(fields)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	doc, ok := s.games[id]
+	if !ok {
+		// Like UpdateOne, updating a missing game matches nothing
+		return nil
+	}
+	for key, value := range update {
+		doc[key] = value
+	}
+	return nil
+}
+
+// toDocument converts a game or a field map to a BSON document
+func toDocument(v interface{}) (bson.M, error) {
+	raw, err := bson.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode game: %w", err)
+	}
+	var doc bson.M
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("failed to encode game: %w", err)
+	}
+	return doc, nil
+}
+
+// fromDocument decodes a stored document into a fresh game
+func fromDocument(doc bson.M) (*models.Game, error) {
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode game: %w", err)
+	}
+	var game models.Game
+	if err := bson.Unmarshal(raw, &game); err != nil {
+		return nil, fmt.Errorf("failed to decode game: %w", err)
+	}
+	return &game, nil
+}
@@ -25,6 +25,27 @@ type Game struct {
 	MarketConditionRemainingTurns int                `bson:"marketConditionRemainingTurns" json:"marketConditionRemainingTurns"`
 	WinnerID                      string             `bson:"winnerId,omitempty" json:"winnerId,omitempty"`
 	SettlementStatus              SettlementStatus   `bson:"settlementStatus" json:"settlementStatus"`
+	BuyIn                         int                `bson:"buyIn" json:"buyIn"` // KMT each player deposits into escrow, 0 for free games
+	Mode                          GameMode           `bson:"mode,omitempty" json:"mode,omitempty"`
+	Visibility                    GameVisibility     `bson:"visibility,omitempty" json:"visibility,omitempty"` // Empty means PUBLIC
+	PasswordHash                  string             `bson:"passwordHash,omitempty" json:"-"`                  // bcrypt; empty for no password
+	Locked                        bool               `bson:"locked,omitempty" json:"locked,omitempty"`         // Set by the host to stop anyone else joining
+	BannedPlayers                 []string           `bson:"bannedPlayers,omitempty" json:"bannedPlayers,omitempty"`
+	PauseVotes                    []string           `bson:"pauseVotes,omitempty" json:"pauseVotes,omitempty"` // Players asking to pause an active game
+	Pause                         *GamePause         `bson:"pause,omitempty" json:"pause,omitempty"`           // Set while the game is PAUSED
+}
+
+// GamePause describes a paused game
+type GamePause struct {
+	PausedBy    string    `bson:"pausedBy" json:"pausedBy"` // The host's ID, or "vote"
+	PausedAt    time.Time `bson:"pausedAt" json:"pausedAt"`
+	ResumesAt   time.Time `bson:"resumesAt" json:"resumesAt"` // When the game resumes by itself
+	ResumeVotes []string  `bson:"resumeVotes,omitempty" json:"resumeVotes,omitempty"`
+}
+
+// IsPublic reports whether the game is listed for anyone to find
+func (g *Game) IsPublic() bool {
+	return g.Visibility == "" || g.Visibility == GameVisibilityPublic
 }
 
 // BoardState represents the current state of the game board
@@ -42,26 +63,33 @@ type CardCount struct {
 
 // Player represents a player in the game
 type Player struct {
-	ID                      string       `bson:"playerId" json:"playerId"`
-	UserID                  string       `bson:"userId" json:"userId"`
-	WalletAddress           string       `bson:"walletAddress" json:"walletAddress"`
-	WalletSignature         string       `bson:"walletSignature" json:"walletSignature"`
-	CharacterToken          string       `bson:"characterToken" json:"characterToken"`
-	Position                int          `bson:"position" json:"position"`
-	Balance                 int          `bson:"balance" json:"balance"`
-	Cards                   []Card       `bson:"cards" json:"cards"`
-	Shadowbanned            bool         `bson:"shadowbanned" json:"shadowbanned"`
-	ShadowbanRemainingTurns int          `bson:"shadowbanRemainingTurns" json:"shadowbanRemainingTurns"`
-	Status                  PlayerStatus `bson:"status" json:"status"`
-	DisconnectedAt          *time.Time   `bson:"disconnectedAt,omitempty" json:"disconnectedAt,omitempty"`
-	Properties              []string     `bson:"properties" json:"properties"`
-	InitialDeposit          int          `bson:"initialDeposit" json:"initialDeposit"`
-	NetWorth                int          `bson:"netWorth" json:"netWorth"`
+	ID                      string        `bson:"playerId" json:"playerId"`
+	UserID                  string        `bson:"userId" json:"userId"`
+	WalletAddress           string        `bson:"walletAddress" json:"walletAddress"`
+	WalletSignature         string        `bson:"walletSignature" json:"walletSignature"`
+	CharacterToken          string        `bson:"characterToken" json:"characterToken"`
+	Position                int           `bson:"position" json:"position"`
+	Balance                 int           `bson:"balance" json:"balance"`
+	Cards                   []Card        `bson:"cards" json:"cards"`
+	Shadowbanned            bool          `bson:"shadowbanned" json:"shadowbanned"`
+	ShadowbanRemainingTurns int           `bson:"shadowbanRemainingTurns" json:"shadowbanRemainingTurns"`
+	Status                  PlayerStatus  `bson:"status" json:"status"`
+	DisconnectedAt          *time.Time    `bson:"disconnectedAt,omitempty" json:"disconnectedAt,omitempty"`
+	Properties              []string      `bson:"properties" json:"properties"`
+	InitialDeposit          int           `bson:"initialDeposit" json:"initialDeposit"`
+	DepositStatus           DepositStatus `bson:"depositStatus,omitempty" json:"depositStatus,omitempty"`
+	DepositedAmount         int           `bson:"depositedAmount" json:"depositedAmount"`
+	DepositTxIDs            []string      `bson:"depositTxIds,omitempty" json:"depositTxIds,omitempty"`
+	DepositDeadline         *time.Time    `bson:"depositDeadline,omitempty" json:"depositDeadline,omitempty"`
+	NetWorth                int           `bson:"netWorth" json:"netWorth"`
 	// WebSocket session ID is not stored in the database
 	SessionID string `bson:"-" json:"sessionId,omitempty"`
 	// --- Jail fields ---
 	InJail    bool `bson:"inJail" json:"inJail"`
 	JailTurns int  `bson:"jailTurns" json:"jailTurns"`
+	// --- Bot fields ---
+	Bot        string `bson:"bot,omitempty" json:"bot,omitempty"`               // Strategy of the bot playing this seat; empty for humans
+	Substitute bool   `bson:"substitute,omitempty" json:"substitute,omitempty"` // The bot is standing in for a disconnected player
 }
 
 // Property represents a property on the game board
@@ -113,6 +141,8 @@ type Transaction struct {
 	Timestamp     time.Time       `bson:"timestamp" json:"timestamp"`
 	OnChainStatus OnChainStatus   `bson:"onChainStatus" json:"onChainStatus"`
 	OnChainTxID   string          `bson:"onChainTxId,omitempty" json:"onChainTxId,omitempty"`
+	Wallet        string          `bson:"wallet,omitempty" json:"wallet,omitempty"`       // The player's wallet, for transfers to or from escrow
+	Reference     string          `bson:"reference,omitempty" json:"reference,omitempty"` // The transfer's memo
 	Signature     string          `bson:"signature,omitempty" json:"signature,omitempty"`
 }
 
@@ -136,6 +166,22 @@ const (
 	GameStatusAbandoned GameStatus = "ABANDONED"
 )
 
+// GameMode represents the rules variant a game is played with
+type GameMode string
+
+const (
+	GameModeClassic GameMode = "CLASSIC"
+)
+
+// GameVisibility controls who can find and join a game
+type GameVisibility string
+
+const (
+	GameVisibilityPublic   GameVisibility = "PUBLIC"   // Listed in the lobby
+	GameVisibilityUnlisted GameVisibility = "UNLISTED" // Joinable with the room code, but not listed
+	GameVisibilityPrivate  GameVisibility = "PRIVATE"  // Joinable only with an invite from the host
+)
+
 // PlayerStatus represents the status of a player
 type PlayerStatus string
 
@@ -146,6 +192,15 @@ const (
 	PlayerStatusForfeited    PlayerStatus = "FORFEITED"
 )
 
+// DepositStatus represents whether a player has paid the buy-in
+type DepositStatus string
+
+const (
+	DepositStatusUnpaid   DepositStatus = "UNPAID"
+	DepositStatusPartial  DepositStatus = "PARTIAL"
+	DepositStatusVerified DepositStatus = "VERIFIED"
+)
+
 // PropertyType represents the type of a property
 type PropertyType string
 
@@ -191,6 +246,7 @@ const (
 	SettlementStatusInProgress SettlementStatus = "IN_PROGRESS"
 	SettlementStatusCompleted  SettlementStatus = "COMPLETED"
 	SettlementStatusFailed     SettlementStatus = "FAILED"
+	SettlementStatusSkipped    SettlementStatus = "SKIPPED"
 )
 
 // TransactionType represents the type of a transaction
@@ -204,6 +260,7 @@ const (
 	TransactionTypePenalty        TransactionType = "PENALTY"
 	TransactionTypeGameSettlement TransactionType = "GAME_SETTLEMENT"
 	TransactionTypeDeposit        TransactionType = "DEPOSIT"
+	TransactionTypeRefund         TransactionType = "REFUND"
 )
 
 // OnChainStatus represents the status of an on-chain transaction
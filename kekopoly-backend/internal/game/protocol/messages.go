@@ -0,0 +1,1007 @@
+package protocol
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/kekopoly/backend/internal/game/models"
+	"github.com/kekopoly/backend/internal/game/statediff"
+)
+
+// MessageType is the "type" of a WebSocket message
+type MessageType string
+
+// Client message types
+const (
+	TypeVerifyHost           MessageType = "verify_host"
+	TypeStartGame            MessageType = "game:start"
+	TypeGetActivePlayers     MessageType = "get_active_players"
+	TypeRollDice             MessageType = "roll_dice"
+	TypeUpdatePlayerInfo     MessageType = "update_player_info"
+	TypeUpdatePlayer         MessageType = "update_player"
+	TypeSetPlayerToken       MessageType = "set_player_token"
+	TypeGetGameState         MessageType = "get_game_state"
+	TypeSetHost              MessageType = "set_host"
+	TypeResume               MessageType = "resume"
+	TypeSyncState            MessageType = "sync_state"
+	TypeGetChatHistory       MessageType = "get_chat_history"
+	TypeMutePlayer           MessageType = "mute_player"
+	TypeKickFromChat         MessageType = "kick_from_chat"
+	TypeLobbySubscribe       MessageType = "lobby_subscribe"
+	TypeGetHost              MessageType = "get_host"
+	TypeHostInfo             MessageType = "host_info"
+	TypeGetCurrentTurn       MessageType = "get_current_turn"
+	TypeCurrentTurnResponse  MessageType = "current_turn_response"
+	TypeCheckGameStarted     MessageType = "check_game_started"
+	TypeGameStartedStatus    MessageType = "game_started_status"
+	TypeBroadcastGameStarted MessageType = "broadcast_game_started"
+	TypeBuyProperty          MessageType = "buy_property"
+	TypeClientNavigating     MessageType = "client_navigating"
+)
+
+// Types sent by both clients and the server
+const (
+	TypePlayerJoined MessageType = "player_joined"
+	TypePlayerReady  MessageType = "player_ready"
+	TypeGameStarted  MessageType = "game_started"
+	TypeChatMessage  MessageType = "chat_message"
+)
+
+// Server message types
+const (
+	TypeAck                   MessageType = "ack"
+	TypeError                 MessageType = "error"
+	TypePlayerJoinedAck       MessageType = "player_joined_ack"
+	TypePlayerUpdated         MessageType = "player_updated"
+	TypeDiceRolled            MessageType = "dice_rolled"
+	TypeGameStateUpdate       MessageType = "game_state_update"
+	TypeHostSetConfirmed      MessageType = "host_set_confirmed"
+	TypeHostVerification      MessageType = "host_verification"
+	TypeHostChanged           MessageType = "host_changed"
+	TypeActivePlayers         MessageType = "active_players"
+	TypePlayerDisconnected    MessageType = "player_disconnected"
+	TypePlayerReconnected     MessageType = "player_reconnected"
+	TypeReconnectionSucceeded MessageType = "reconnection_successful"
+	TypeCompleteStateSync     MessageType = "complete_state_sync"
+	TypeGameTurn              MessageType = "game_turn"
+	TypeTurnChanged           MessageType = "turn_changed"
+	TypeJailEvent             MessageType = "jail_event"
+	TypeDepositStatus         MessageType = "deposit_status"
+	TypePlayerEvicted         MessageType = "player_evicted"
+	TypeNewGameCreated        MessageType = "new_game_created"
+	TypeResumed               MessageType = "resumed"
+	TypeStateFull             MessageType = "state_full"
+	TypeStateDelta            MessageType = "state_delta"
+	TypeChatHistory           MessageType = "chat_history"
+	TypeChatModerated         MessageType = "chat_moderated"
+	TypeMemePosted            MessageType = "meme_posted"
+	TypeLobbyGames            MessageType = "lobby_games"
+	TypeLobbyUpdate           MessageType = "lobby_update"
+	TypeMatchFound            MessageType = "match_found"
+	TypeGameLocked            MessageType = "game_locked"
+	TypeBotSeat               MessageType = "bot_seat"
+	TypeGamePaused            MessageType = "game_paused"
+	TypeGameResumed           MessageType = "game_resumed"
+	TypePauseVote             MessageType = "pause_vote"
+	TypeGameEnded             MessageType = "game_ended"
+)
+
+// --- Client messages ---
+
+// VerifyHostRequest asks whether the sender is the game's host
+type VerifyHostRequest struct {
+	PlayerID string `json:"playerId,omitempty"`
+	GameID   string `json:"gameId,omitempty"`
+}
+
+// StartGameRequest asks to start the game; only the host may
+type StartGameRequest struct {
+	GameID        string `json:"gameId,omitempty"`
+	HostID        string `json:"hostId,omitempty"`
+	Timestamp     int64  `json:"timestamp,omitempty"`
+	ForceNavigate bool   `json:"forceNavigate,omitempty"`
+}
+
+// PlayerInfo is a player's lobby profile
+type PlayerInfo struct {
+	ID             string `json:"id"`
+	Name           string `json:"name,omitempty"`
+	Token          string `json:"token,omitempty"`
+	CharacterToken string `json:"characterToken,omitempty"`
+	Emoji          string `json:"emoji,omitempty"`
+	Color          string `json:"color,omitempty"`
+	IsHost         bool   `json:"isHost,omitempty"`
+	IsReady        bool   `json:"isReady,omitempty"`
+}
+
+// PlayerJoinedRequest announces the sender's profile to the game
+type PlayerJoinedRequest struct {
+	Player PlayerInfo `json:"player"`
+
+	fields map[string]interface{}
+}
+
+// UnmarshalJSON keeps the player object as sent, so fields PlayerInfo doesn't
+// name still reach the other clients
+func (r *PlayerJoinedRequest) UnmarshalJSON(data []byte) error {
+	type plain PlayerJoinedRequest
+	if err := json.Unmarshal(data, (*plain)(r)); err != nil {
+		return err
+	}
+	var raw struct {
+		Player map[string]interface{} `json:"player"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	r.fields = raw.Player
+	return nil
+}
+
+// Fields returns the player object as the client sent it
+func (r *PlayerJoinedRequest) Fields() map[string]interface{} {
+	return r.fields
+}
+
+// Validate implements Validator
+func (r *PlayerJoinedRequest) Validate() error {
+	if r.Player.ID == "" {
+		return fmt.Errorf("player.id is required")
+	}
+	return nil
+}
+
+// GetActivePlayersRequest asks for an active_players broadcast
+type GetActivePlayersRequest struct{}
+
+// RollDiceRequest rolls the dice for the sender's turn
+type RollDiceRequest struct{}
+
+// UpdatePlayerRequest changes a player's token or profile. It is sent as
+// update_player_info, update_player or set_player_token.
+type UpdatePlayerRequest struct {
+	PlayerID       string `json:"playerId"`
+	Token          string `json:"token,omitempty"`
+	CharacterToken string `json:"characterToken,omitempty"`
+	Emoji          string `json:"emoji,omitempty"`
+	Color          string `json:"color,omitempty"`
+	Name           string `json:"name,omitempty"`
+}
+
+// Validate implements Validator
+func (r *UpdatePlayerRequest) Validate() error {
+	if r.PlayerID == "" {
+		return fmt.Errorf("playerId is required")
+	}
+	return nil
+}
+
+// PlayerReadyRequest changes a player's ready flag in the lobby
+type PlayerReadyRequest struct {
+	PlayerID  string `json:"playerId"`
+	IsReady   bool   `json:"isReady"`
+	MessageID string `json:"messageId,omitempty"`
+	Timestamp int64  `json:"timestamp,omitempty"` // Unix milliseconds
+	Priority  string `json:"priority,omitempty"`
+}
+
+// Validate implements Validator
+func (r *PlayerReadyRequest) Validate() error {
+	if r.PlayerID == "" {
+		return fmt.Errorf("playerId is required")
+	}
+	return nil
+}
+
+// GetGameStateRequest asks for a game_state_update
+type GetGameStateRequest struct {
+	Full bool `json:"full,omitempty"`
+}
+
+// SetHostRequest makes another player the host
+type SetHostRequest struct {
+	HostID string `json:"hostId"`
+	GameID string `json:"gameId,omitempty"` // Defaults to the sender's game
+}
+
+// Validate implements Validator
+func (r *SetHostRequest) Validate() error {
+	if r.HostID == "" {
+		return fmt.Errorf("hostId is required")
+	}
+	return nil
+}
+
+// ResumeRequest asks for the game broadcasts after the last one the client saw
+type ResumeRequest struct {
+	LastSeq int64 `json:"lastSeq"`
+}
+
+// Validate implements Validator
+func (r *ResumeRequest) Validate() error {
+	if r.LastSeq < 0 {
+		return fmt.Errorf("lastSeq must not be negative")
+	}
+	return nil
+}
+
+// SyncStateRequest subscribes the sender to state updates. Version is the state
+// version the client holds, 0 for none; it gets a state_full if that's not current.
+type SyncStateRequest struct {
+	Version int64 `json:"version"`
+}
+
+// ChatMessageRequest posts to the game's chat, or whispers to one player when To is set
+type ChatMessageRequest struct {
+	Message string `json:"message"`
+	To      string `json:"to,omitempty"` // Player ID to whisper to
+}
+
+// Validate implements Validator
+func (r *ChatMessageRequest) Validate() error {
+	if strings.TrimSpace(r.Message) == "" {
+		return fmt.Errorf("message is required")
+	}
+	return nil
+}
+
+// GetChatHistoryRequest asks for a chat_history
+type GetChatHistoryRequest struct{}
+
+// MutePlayerRequest stops a player posting to chat for a while; only the host may
+type MutePlayerRequest struct {
+	PlayerID string `json:"playerId"`
+	Minutes  int    `json:"minutes"` // 0 lifts a mute
+}
+
+// Validate implements Validator
+func (r *MutePlayerRequest) Validate() error {
+	if r.PlayerID == "" {
+		return fmt.Errorf("playerId is required")
+	}
+	if r.Minutes < 0 {
+		return fmt.Errorf("minutes must not be negative")
+	}
+	return nil
+}
+
+// KickFromChatRequest removes a player from the game's chat for the rest of the
+// game; only the host may
+type KickFromChatRequest struct {
+	PlayerID string `json:"playerId"`
+}
+
+// Validate implements Validator
+func (r *KickFromChatRequest) Validate() error {
+	if r.PlayerID == "" {
+		return fmt.Errorf("playerId is required")
+	}
+	return nil
+}
+
+// LobbyFilter narrows the games a lobby client is sent. Unset fields match every game.
+type LobbyFilter struct {
+	OpenSeats int    `json:"openSeats,omitempty"` // At least this many free seats
+	MinBuyIn  int    `json:"minBuyIn,omitempty"`
+	MaxBuyIn  *int   `json:"maxBuyIn,omitempty"` // 0 lists only free games
+	Mode      string `json:"mode,omitempty"`
+	Status    string `json:"status,omitempty"` // LOBBY, ACTIVE or ABANDONED
+}
+
+// Matches reports whether a game listing passes the filter
+func (f LobbyFilter) Matches(game GameListing) bool {
+	if f.OpenSeats > 0 && game.OpenSeats < f.OpenSeats {
+		return false
+	}
+	if game.BuyIn < f.MinBuyIn || (f.MaxBuyIn != nil && game.BuyIn > *f.MaxBuyIn) {
+		return false
+	}
+	if f.Mode != "" && !strings.EqualFold(f.Mode, game.Mode) {
+		return false
+	}
+	if f.Status != "" && !strings.EqualFold(f.Status, game.Status) {
+		return false
+	}
+	return true
+}
+
+// LobbySubscribeRequest replaces the lobby client's filter
+type LobbySubscribeRequest struct {
+	LobbyFilter
+}
+
+// Validate implements Validator
+func (r *LobbySubscribeRequest) Validate() error {
+	if r.OpenSeats < 0 || r.MinBuyIn < 0 || (r.MaxBuyIn != nil && *r.MaxBuyIn < 0) {
+		return fmt.Errorf("openSeats, minBuyIn and maxBuyIn can't be negative")
+	}
+	switch strings.ToUpper(r.Status) {
+	case "", "LOBBY", "ACTIVE", "ABANDONED":
+		return nil
+	}
+	return fmt.Errorf("status must be LOBBY, ACTIVE or ABANDONED")
+}
+
+// The messages below are relayed to the other clients in the game unchanged
+
+// GetHostRequest asks the other clients who the host is
+type GetHostRequest struct {
+	GameID string `json:"gameId,omitempty"`
+}
+
+// HostInfo answers get_host
+type HostInfo struct {
+	GameID string `json:"gameId,omitempty"`
+	HostID string `json:"hostId,omitempty"`
+}
+
+// GetCurrentTurnRequest asks the other clients whose turn it is
+type GetCurrentTurnRequest struct{}
+
+// CurrentTurnResponse answers get_current_turn
+type CurrentTurnResponse struct {
+	CurrentPlayerID string `json:"currentPlayerId"`
+	GameID          string `json:"gameId,omitempty"`
+}
+
+// CheckGameStartedRequest asks the other clients whether the game has started
+type CheckGameStartedRequest struct {
+	GameID string `json:"gameId,omitempty"`
+}
+
+// GameStartedStatus answers check_game_started
+type GameStartedStatus struct {
+	GameID        string `json:"gameId,omitempty"`
+	IsGameStarted bool   `json:"isGameStarted,omitempty"`
+}
+
+// GameStartedNotice tells the other clients the host started the game. It is sent
+// as game_started or broadcast_game_started.
+type GameStartedNotice struct {
+	GameID        string `json:"gameId,omitempty"`
+	HostID        string `json:"hostId,omitempty"`
+	Timestamp     int64  `json:"timestamp,omitempty"`
+	ForceNavigate bool   `json:"forceNavigate,omitempty"`
+}
+
+// BuyPropertyNotice tells the other clients a property was bought
+type BuyPropertyNotice struct {
+	PropertyID interface{} `json:"propertyId,omitempty"` // Property ID or board position
+	PlayerID   string      `json:"playerId,omitempty"`
+}
+
+// ClientNavigating tells the other clients the sender's socket is about to close
+type ClientNavigating struct {
+	PlayerID      string `json:"playerId,omitempty"`
+	GameID        string `json:"gameId,omitempty"`
+	WillReconnect bool   `json:"willReconnect,omitempty"`
+	Timestamp     int64  `json:"timestamp,omitempty"`
+}
+
+// --- Server messages ---
+
+// Ack confirms a versioned client request succeeded
+type Ack struct {
+	Header
+	For MessageType `json:"for"` // Type of the acknowledged request
+}
+
+// MessageType implements Outbound
+func (Ack) MessageType() MessageType { return TypeAck }
+
+// Error reports a failed client request
+type Error struct {
+	Header
+	Code        string      `json:"code"`
+	Message     string      `json:"message"`
+	For         MessageType `json:"for,omitempty"`         // Type of the failed request, when known
+	CurrentTurn string      `json:"currentTurn,omitempty"` // Set with not_your_turn
+}
+
+// MessageType implements Outbound
+func (Error) MessageType() MessageType { return TypeError }
+
+// PlayerJoinedAck confirms the sender's player_joined
+type PlayerJoinedAck struct {
+	Header
+	Success bool                   `json:"success"`
+	Player  map[string]interface{} `json:"player"`
+	GameID  string                 `json:"gameId"`
+}
+
+// MessageType implements Outbound
+func (PlayerJoinedAck) MessageType() MessageType { return TypePlayerJoinedAck }
+
+// PlayerJoined shares a player's profile with the game
+type PlayerJoined struct {
+	Header
+	Player map[string]interface{} `json:"player"`
+}
+
+// MessageType implements Outbound
+func (PlayerJoined) MessageType() MessageType { return TypePlayerJoined }
+
+// PlayerUpdated shares a player's changed profile with the game
+type PlayerUpdated struct {
+	Header
+	Player map[string]interface{} `json:"player"`
+}
+
+// MessageType implements Outbound
+func (PlayerUpdated) MessageType() MessageType { return TypePlayerUpdated }
+
+// PlayerReady shares a player's ready flag with the game
+type PlayerReady struct {
+	Header
+	PlayerID            string `json:"playerId"`
+	IsReady             bool   `json:"isReady"`
+	MessageID           string `json:"messageId,omitempty"`
+	ResponseToMessageID string `json:"responseToMessageId,omitempty"`
+	Timestamp           int64  `json:"timestamp"` // Unix milliseconds
+}
+
+// MessageType implements Outbound
+func (PlayerReady) MessageType() MessageType { return TypePlayerReady }
+
+// DiceRolled reports a player's dice roll and where it took them
+type DiceRolled struct {
+	Header
+	PlayerID  string `json:"playerId"`
+	Position  int    `json:"position"`
+	Balance   int    `json:"balance"`
+	Dice      []int  `json:"dice"`
+	Dice1     int    `json:"dice1"`
+	Dice2     int    `json:"dice2"`
+	Timestamp string `json:"timestamp"` // RFC 3339
+}
+
+// MessageType implements Outbound
+func (DiceRolled) MessageType() MessageType { return TypeDiceRolled }
+
+// GameState is the snapshot in a game_state_update
+type GameState struct {
+	GameID   string                 `json:"gameId"`
+	Status   string                 `json:"status"`
+	GameInfo map[string]interface{} `json:"gameInfo"`
+}
+
+// GameStateUpdate answers get_game_state
+type GameStateUpdate struct {
+	Header
+	GameID string    `json:"gameId"`
+	State  GameState `json:"state"`
+}
+
+// MessageType implements Outbound
+func (GameStateUpdate) MessageType() MessageType { return TypeGameStateUpdate }
+
+// HostSetConfirmed answers set_host
+type HostSetConfirmed struct {
+	Header
+	HostID string `json:"hostId"`
+	GameID string `json:"gameId"`
+}
+
+// MessageType implements Outbound
+func (HostSetConfirmed) MessageType() MessageType { return TypeHostSetConfirmed }
+
+// HostVerification answers verify_host
+type HostVerification struct {
+	Header
+	Success bool   `json:"success"`
+	HostID  string `json:"hostId"`
+	GameID  string `json:"gameId"`
+	Message string `json:"message,omitempty"`
+}
+
+// MessageType implements Outbound
+func (HostVerification) MessageType() MessageType { return TypeHostVerification }
+
+// HostChanged tells the game it has a new host
+type HostChanged struct {
+	Header
+	HostID string `json:"hostId"`
+	GameID string `json:"gameId"`
+}
+
+// MessageType implements Outbound
+func (HostChanged) MessageType() MessageType { return TypeHostChanged }
+
+// ActivePlayers lists the players in the game with their lobby profiles
+type ActivePlayers struct {
+	Header
+	GameID      string                   `json:"gameId"`
+	Players     []map[string]interface{} `json:"players"`
+	HostID      string                   `json:"hostId"`
+	MaxPlayers  int                      `json:"maxPlayers"`
+	GameInfo    map[string]interface{}   `json:"gameInfo"`
+	BuyIn       int                      `json:"buyIn,omitempty"`
+	Status      string                   `json:"status,omitempty"`
+	GameStarted bool                     `json:"gameStarted,omitempty"`
+	GamePhase   string                   `json:"gamePhase,omitempty"`
+}
+
+// MessageType implements Outbound
+func (ActivePlayers) MessageType() MessageType { return TypeActivePlayers }
+
+// PlayerLeft is the active_players update sent when a player disconnects from the game
+type PlayerLeft struct {
+	Header
+	GameID       string            `json:"gameId"`
+	Players      []models.Player   `json:"players"`
+	HostID       string            `json:"hostId"`
+	PreviousHost string            `json:"previousHost"`
+	LeftPlayerID string            `json:"leftPlayerId"`
+	GameStatus   models.GameStatus `json:"gameStatus"`
+}
+
+// MessageType implements Outbound
+func (PlayerLeft) MessageType() MessageType { return TypeActivePlayers }
+
+// PlayerDisconnected tells the game a player's socket closed
+type PlayerDisconnected struct {
+	Header
+	PlayerID string `json:"playerId"`
+	GameID   string `json:"gameId"`
+}
+
+// MessageType implements Outbound
+func (PlayerDisconnected) MessageType() MessageType { return TypePlayerDisconnected }
+
+// PlayerReconnected tells the game a player came back
+type PlayerReconnected struct {
+	Header
+	PlayerID  string `json:"playerId"`
+	GameID    string `json:"gameId"`
+	Timestamp string `json:"timestamp"` // RFC 3339
+}
+
+// MessageType implements Outbound
+func (PlayerReconnected) MessageType() MessageType { return TypePlayerReconnected }
+
+// ReconnectionSucceeded tells a reconnected player their new session
+type ReconnectionSucceeded struct {
+	Header
+	PlayerID        string `json:"playerId"`
+	GameID          string `json:"gameId"`
+	SessionID       string `json:"sessionId"`
+	PreviousSession string `json:"previousSession"`
+	Timestamp       string `json:"timestamp"` // RFC 3339
+}
+
+// MessageType implements Outbound
+func (ReconnectionSucceeded) MessageType() MessageType { return TypeReconnectionSucceeded }
+
+// CompleteStateSync carries the full game state
+type CompleteStateSync struct {
+	Header
+	GameID      string          `json:"gameId"`
+	Status      string          `json:"status"`
+	CurrentTurn string          `json:"currentTurn"`
+	Players     []models.Player `json:"players"`
+	TurnOrder   []string        `json:"turnOrder"`
+	Timestamp   string          `json:"timestamp"` // RFC 3339
+}
+
+// MessageType implements Outbound
+func (CompleteStateSync) MessageType() MessageType { return TypeCompleteStateSync }
+
+// GameStarted tells the game play has begun
+type GameStarted struct {
+	Header
+	GameID      string          `json:"gameId"`
+	Status      string          `json:"status"`
+	CurrentTurn string          `json:"currentTurn"`
+	Players     []models.Player `json:"players"`
+	TurnOrder   []string        `json:"turnOrder"`
+	Timestamp   string          `json:"timestamp"` // RFC 3339
+}
+
+// MessageType implements Outbound
+func (GameStarted) MessageType() MessageType { return TypeGameStarted }
+
+// GameTurn announces the first turn of a game
+type GameTurn struct {
+	Header
+	GameID      string   `json:"gameId"`
+	CurrentTurn string   `json:"currentTurn"`
+	TurnOrder   []string `json:"turnOrder"`
+	Timestamp   string   `json:"timestamp"` // RFC 3339
+}
+
+// MessageType implements Outbound
+func (GameTurn) MessageType() MessageType { return TypeGameTurn }
+
+// TurnChanged announces whose turn it is after a roll
+type TurnChanged struct {
+	Header
+	CurrentTurn   string `json:"currentTurn"`
+	PlayerName    string `json:"playerName"`
+	RolledDoubles bool   `json:"rolledDoubles"`
+}
+
+// MessageType implements Outbound
+func (TurnChanged) MessageType() MessageType { return TypeTurnChanged }
+
+// Jail events
+const (
+	JailEventJailed       = "jailed"
+	JailEventReleased     = "released"      // Rolled doubles
+	JailEventReleasedTime = "released_time" // Served the full sentence
+	JailEventStay         = "stay"
+)
+
+// JailEvent reports a player going to, staying in or leaving jail
+type JailEvent struct {
+	Header
+	PlayerID  string `json:"playerId"`
+	Event     string `json:"event"`
+	JailTurns int    `json:"jailTurns,omitempty"`
+	Dice      []int  `json:"dice,omitempty"`
+}
+
+// MessageType implements Outbound
+func (JailEvent) MessageType() MessageType { return TypeJailEvent }
+
+// DepositStatus reports a player's buy-in payment
+type DepositStatus struct {
+	Header
+	PlayerID        string               `json:"playerId"`
+	DepositStatus   models.DepositStatus `json:"depositStatus"`
+	DepositedAmount int                  `json:"depositedAmount"`
+	BuyIn           int                  `json:"buyIn"`
+	Timestamp       string               `json:"timestamp"` // RFC 3339
+}
+
+// MessageType implements Outbound
+func (DepositStatus) MessageType() MessageType { return TypeDepositStatus }
+
+// PlayerEvicted tells the game a player was removed from the lobby, or by an operator
+// from a game being played
+type PlayerEvicted struct {
+	Header
+	PlayerID  string `json:"playerId"`
+	Reason    string `json:"reason"`    // deposit_timeout, kicked, banned or removed
+	Timestamp string `json:"timestamp"` // RFC 3339
+}
+
+// MessageType implements Outbound
+func (PlayerEvicted) MessageType() MessageType { return TypePlayerEvicted }
+
+// GameLocked tells the game the host locked or unlocked it to new players
+type GameLocked struct {
+	Header
+	Locked    bool   `json:"locked"`
+	Timestamp string `json:"timestamp"` // RFC 3339
+}
+
+// MessageType implements Outbound
+func (GameLocked) MessageType() MessageType { return TypeGameLocked }
+
+// BotSeat tells the game a bot joined, took over a disconnected player's seat, or
+// handed the seat back
+type BotSeat struct {
+	Header
+	PlayerID   string `json:"playerId"`
+	Strategy   string `json:"strategy,omitempty"`   // Empty once the bot has handed the seat back
+	Substitute bool   `json:"substitute,omitempty"` // The seat belongs to a disconnected player
+	Timestamp  string `json:"timestamp"`            // RFC 3339
+}
+
+// MessageType implements Outbound
+func (BotSeat) MessageType() MessageType { return TypeBotSeat }
+
+// GamePaused tells the game play is paused. Clients stop their turn timers until
+// game_resumed.
+type GamePaused struct {
+	Header
+	PausedBy  string `json:"pausedBy"`  // The host's ID, or "vote"
+	ResumesAt string `json:"resumesAt"` // RFC 3339; the game resumes by itself then
+	Timestamp string `json:"timestamp"` // RFC 3339
+}
+
+// MessageType implements Outbound
+func (GamePaused) MessageType() MessageType { return TypeGamePaused }
+
+// GameResumed tells the game play has resumed
+type GameResumed struct {
+	Header
+	ResumedBy string `json:"resumedBy"` // The host's ID, "vote" or "timeout"
+	Timestamp string `json:"timestamp"` // RFC 3339
+}
+
+// MessageType implements Outbound
+func (GameResumed) MessageType() MessageType { return TypeGameResumed }
+
+// PauseVote tells the game a player voted to pause or resume it
+type PauseVote struct {
+	Header
+	PlayerID  string `json:"playerId"`
+	Action    string `json:"action"` // "pause" or "resume"
+	Votes     int    `json:"votes"`
+	Needed    int    `json:"needed"`    // Votes that carry it
+	Timestamp string `json:"timestamp"` // RFC 3339
+}
+
+// MessageType implements Outbound
+func (PauseVote) MessageType() MessageType { return TypePauseVote }
+
+// GameEnded tells the game an operator ended it
+type GameEnded struct {
+	Header
+	Status    string `json:"status"`             // COMPLETED, or ABANDONED when buy-ins are refunded
+	WinnerID  string `json:"winnerId,omitempty"` // Empty when nobody was named the winner
+	Timestamp string `json:"timestamp"`          // RFC 3339
+}
+
+// MessageType implements Outbound
+func (GameEnded) MessageType() MessageType { return TypeGameEnded }
+
+// GameListing describes a joinable game in the lobby
+type GameListing struct {
+	ID           string        `json:"id"`
+	Name         string        `json:"name"`
+	Status       string        `json:"status"`
+	Players      int           `json:"players"` // Players still active in the game
+	MaxPlayers   int           `json:"maxPlayers"`
+	CreatedAt    string        `json:"createdAt"` // RFC 3339
+	HostName     string        `json:"hostName"`
+	UpdatedAt    string        `json:"updatedAt"` // RFC 3339
+	BuyIn        int           `json:"buyIn"`
+	Mode         string        `json:"mode"`
+	OpenSeats    int           `json:"openSeats"` // 0 once the game has started or is locked
+	ReadyPlayers int           `json:"readyPlayers"`
+	Locked       bool          `json:"locked,omitempty"`
+	Password     bool          `json:"password,omitempty"` // Joining needs the game's password
+	Seats        []ListingSeat `json:"seats"`
+}
+
+// ListingSeat is one player in a game listing
+type ListingSeat struct {
+	PlayerID string `json:"playerId"`
+	Status   string `json:"status"`
+	Ready    bool   `json:"ready"`
+	Bot      bool   `json:"bot,omitempty"`
+}
+
+// NewGameCreated tells lobby clients about a new game
+type NewGameCreated struct {
+	Header
+	Game      GameListing `json:"game"`
+	Timestamp string      `json:"timestamp"` // RFC 3339
+}
+
+// MessageType implements Outbound
+func (NewGameCreated) MessageType() MessageType { return TypeNewGameCreated }
+
+// LobbyGames lists the games matching a lobby client's filter
+type LobbyGames struct {
+	Header
+	Games []GameListing `json:"games"`
+}
+
+// MessageType implements Outbound
+func (LobbyGames) MessageType() MessageType { return TypeLobbyGames }
+
+// LobbyUpdate carries the changes to a lobby client's listings since the last update.
+// A game that stops matching the filter is removed, and one that starts matching is added.
+type LobbyUpdate struct {
+	Header
+	Added   []GameListing `json:"added,omitempty"`
+	Updated []GameListing `json:"updated,omitempty"`
+	Removed []string      `json:"removed,omitempty"` // Game IDs
+}
+
+// MessageType implements Outbound
+func (LobbyUpdate) MessageType() MessageType { return TypeLobbyUpdate }
+
+// MatchFound tells a queued player the game quick match put them in
+type MatchFound struct {
+	Header
+	GameID  string   `json:"gameId"`
+	Players []string `json:"players"`
+}
+
+// MessageType implements Outbound
+func (MatchFound) MessageType() MessageType { return TypeMatchFound }
+
+// Resumed ends the reply to a resume request. Missed broadcasts come first, or a
+// complete_state_sync when they are no longer kept.
+type Resumed struct {
+	Header
+	LastSeq  int64 `json:"lastSeq"`  // The game's latest sequence number
+	Replayed int   `json:"replayed"` // How many broadcasts were sent again
+	Snapshot bool  `json:"snapshot"` // Whether a complete_state_sync was sent instead
+}
+
+// MessageType implements Outbound
+func (Resumed) MessageType() MessageType { return TypeResumed }
+
+// StateFull carries the whole game, for clients subscribed with sync_state
+type StateFull struct {
+	Header
+	GameID  string      `json:"gameId"`
+	Version int64       `json:"version"`
+	State   interface{} `json:"state"` // The game as returned by the REST API
+}
+
+// MessageType implements Outbound
+func (StateFull) MessageType() MessageType { return TypeStateFull }
+
+// StateDelta carries the changes to the game since BaseVersion as a JSON Patch
+// (RFC 6902). It is only sent to clients holding BaseVersion.
+type StateDelta struct {
+	Header
+	GameID      string          `json:"gameId"`
+	BaseVersion int64           `json:"baseVersion"`
+	Version     int64           `json:"version"`
+	Patch       statediff.Patch `json:"patch"`
+}
+
+// MessageType implements Outbound
+func (StateDelta) MessageType() MessageType { return TypeStateDelta }
+
+// ChatEntry is a chat message as kept in a game's history
+type ChatEntry struct {
+	ID       int64  `json:"id"` // Numbered per game, so game rules can refer to a message
+	PlayerID string `json:"playerId"`
+	To       string `json:"to,omitempty"` // Set on whispers
+	Message  string `json:"message"`
+	SentAt   string `json:"sentAt"`
+}
+
+// ChatMessage is a message posted to the game's chat, or a whisper
+type ChatMessage struct {
+	Header
+	GameID string `json:"gameId"`
+	ChatEntry
+}
+
+// MessageType implements Outbound
+func (ChatMessage) MessageType() MessageType { return TypeChatMessage }
+
+// MemePosted puts a player's chat message forward as their meme, for the other
+// players to judge
+type MemePosted struct {
+	Header
+	PlayerID      string `json:"playerId"`
+	ChatMessageID int64  `json:"chatMessageId"`
+	Message       string `json:"message"`
+}
+
+// MessageType implements Outbound
+func (MemePosted) MessageType() MessageType { return TypeMemePosted }
+
+// ChatHistory carries the game's recent chat that the client may see, oldest first
+type ChatHistory struct {
+	Header
+	GameID   string      `json:"gameId"`
+	Messages []ChatEntry `json:"messages"`
+}
+
+// MessageType implements Outbound
+func (ChatHistory) MessageType() MessageType { return TypeChatHistory }
+
+// ChatModerated reports that the host muted, unmuted or kicked a player from chat
+type ChatModerated struct {
+	Header
+	GameID     string `json:"gameId"`
+	PlayerID   string `json:"playerId"`
+	MutedUntil string `json:"mutedUntil,omitempty"` // Unset when not muted
+	Kicked     bool   `json:"kicked"`
+}
+
+// MessageType implements Outbound
+func (ChatModerated) MessageType() MessageType { return TypeChatModerated }
+
+// spec describes one message for the registry and the schema
+type spec struct {
+	types       []MessageType
+	new         func() interface{}
+	description string
+}
+
+// clientMessages are the messages clients send, in schema order
+var clientMessages = []spec{
+	{[]MessageType{TypeVerifyHost}, func() interface{} { return &VerifyHostRequest{} }, "Ask whether the sender is the host; answered with host_verification"},
+	{[]MessageType{TypeStartGame}, func() interface{} { return &StartGameRequest{} }, "Start the game; only the host may"},
+	{[]MessageType{TypePlayerJoined}, func() interface{} { return &PlayerJoinedRequest{} }, "Share the sender's lobby profile"},
+	{[]MessageType{TypeGetActivePlayers}, func() interface{} { return &GetActivePlayersRequest{} }, "Ask for an active_players broadcast"},
+	{[]MessageType{TypeRollDice}, func() interface{} { return &RollDiceRequest{} }, "Roll the dice on the sender's turn"},
+	{[]MessageType{TypeUpdatePlayerInfo, TypeUpdatePlayer, TypeSetPlayerToken}, func() interface{} { return &UpdatePlayerRequest{} }, "Change a player's token or profile"},
+	{[]MessageType{TypePlayerReady}, func() interface{} { return &PlayerReadyRequest{} }, "Change a player's ready flag"},
+	{[]MessageType{TypeGetGameState}, func() interface{} { return &GetGameStateRequest{} }, "Ask for a game_state_update"},
+	{[]MessageType{TypeSetHost}, func() interface{} { return &SetHostRequest{} }, "Make another player the host"},
+	{[]MessageType{TypeResume}, func() interface{} { return &ResumeRequest{} }, "Replay the game broadcasts after lastSeq; answered with resumed"},
+	{[]MessageType{TypeSyncState}, func() interface{} { return &SyncStateRequest{} }, "Subscribe to state_full and state_delta updates"},
+	{[]MessageType{TypeChatMessage}, func() interface{} { return &ChatMessageRequest{} }, "Post to the game's chat, or whisper to one player"},
+	{[]MessageType{TypeGetChatHistory}, func() interface{} { return &GetChatHistoryRequest{} }, "Ask for a chat_history"},
+	{[]MessageType{TypeMutePlayer}, func() interface{} { return &MutePlayerRequest{} }, "Mute or unmute a player in chat; only the host may"},
+	{[]MessageType{TypeKickFromChat}, func() interface{} { return &KickFromChatRequest{} }, "Remove a player from chat for the rest of the game; only the host may"},
+	{[]MessageType{TypeLobbySubscribe}, func() interface{} { return &LobbySubscribeRequest{} }, "Lobby only: choose which games to list; answered with lobby_games"},
+	{[]MessageType{TypeGetHost}, func() interface{} { return &GetHostRequest{} }, "Relayed: ask the other clients who the host is"},
+	{[]MessageType{TypeHostInfo}, func() interface{} { return &HostInfo{} }, "Relayed: answer get_host"},
+	{[]MessageType{TypeGetCurrentTurn}, func() interface{} { return &GetCurrentTurnRequest{} }, "Relayed: ask the other clients whose turn it is"},
+	{[]MessageType{TypeCurrentTurnResponse}, func() interface{} { return &CurrentTurnResponse{} }, "Relayed: answer get_current_turn"},
+	{[]MessageType{TypeCheckGameStarted}, func() interface{} { return &CheckGameStartedRequest{} }, "Relayed: ask the other clients whether the game started"},
+	{[]MessageType{TypeGameStartedStatus}, func() interface{} { return &GameStartedStatus{} }, "Relayed: answer check_game_started"},
+	{[]MessageType{TypeGameStarted, TypeBroadcastGameStarted}, func() interface{} { return &GameStartedNotice{} }, "Relayed: tell the other clients the game started"},
+	{[]MessageType{TypeBuyProperty}, func() interface{} { return &BuyPropertyNotice{} }, "Relayed: tell the other clients a property was bought"},
+	{[]MessageType{TypeClientNavigating}, func() interface{} { return &ClientNavigating{} }, "Relayed: the sender's socket is about to close"},
+}
+
+// serverMessages are the messages the server sends, in schema order
+var serverMessages = []spec{
+	{[]MessageType{TypeAck}, func() interface{} { return &Ack{} }, "A versioned request with a requestId succeeded"},
+	{[]MessageType{TypeError}, func() interface{} { return &Error{} }, "A request failed"},
+	{[]MessageType{TypePlayerJoinedAck}, func() interface{} { return &PlayerJoinedAck{} }, "Confirms the sender's player_joined"},
+	{[]MessageType{TypePlayerJoined}, func() interface{} { return &PlayerJoined{} }, "A player shared their lobby profile"},
+	{[]MessageType{TypePlayerUpdated}, func() interface{} { return &PlayerUpdated{} }, "A player's profile changed"},
+	{[]MessageType{TypePlayerReady}, func() interface{} { return &PlayerReady{} }, "A player's ready flag changed"},
+	{[]MessageType{TypeDiceRolled}, func() interface{} { return &DiceRolled{} }, "A player rolled the dice"},
+	{[]MessageType{TypeGameStateUpdate}, func() interface{} { return &GameStateUpdate{} }, "Answers get_game_state"},
+	{[]MessageType{TypeHostSetConfirmed}, func() interface{} { return &HostSetConfirmed{} }, "Answers set_host"},
+	{[]MessageType{TypeHostVerification}, func() interface{} { return &HostVerification{} }, "Answers verify_host"},
+	{[]MessageType{TypeHostChanged}, func() interface{} { return &HostChanged{} }, "The game has a new host"},
+	{[]MessageType{TypeActivePlayers}, func() interface{} { return &ActivePlayers{} }, "The players in the game"},
+	{[]MessageType{TypeActivePlayers}, func() interface{} { return &PlayerLeft{} }, "The players in the game after one disconnected"},
+	{[]MessageType{TypePlayerDisconnected}, func() interface{} { return &PlayerDisconnected{} }, "A player's socket closed"},
+	{[]MessageType{TypePlayerReconnected}, func() interface{} { return &PlayerReconnected{} }, "A player came back"},
+	{[]MessageType{TypeReconnectionSucceeded}, func() interface{} { return &ReconnectionSucceeded{} }, "The sender reconnected"},
+	{[]MessageType{TypeCompleteStateSync}, func() interface{} { return &CompleteStateSync{} }, "The full game state"},
+	{[]MessageType{TypeGameStarted}, func() interface{} { return &GameStarted{} }, "Play has begun"},
+	{[]MessageType{TypeGameTurn}, func() interface{} { return &GameTurn{} }, "The first turn of the game"},
+	{[]MessageType{TypeTurnChanged}, func() interface{} { return &TurnChanged{} }, "Whose turn it is after a roll"},
+	{[]MessageType{TypeJailEvent}, func() interface{} { return &JailEvent{} }, "A player went to, stayed in or left jail"},
+	{[]MessageType{TypeDepositStatus}, func() interface{} { return &DepositStatus{} }, "A player's buy-in payment"},
+	{[]MessageType{TypePlayerEvicted}, func() interface{} { return &PlayerEvicted{} }, "A player was removed from the lobby, or by an operator from the game"},
+	{[]MessageType{TypeGameLocked}, func() interface{} { return &GameLocked{} }, "The host locked or unlocked the game to new players"},
+	{[]MessageType{TypeBotSeat}, func() interface{} { return &BotSeat{} }, "A bot joined, took over a disconnected player's seat, or handed it back"},
+	{[]MessageType{TypeGamePaused}, func() interface{} { return &GamePaused{} }, "Play is paused"},
+	{[]MessageType{TypeGameResumed}, func() interface{} { return &GameResumed{} }, "Play has resumed"},
+	{[]MessageType{TypePauseVote}, func() interface{} { return &PauseVote{} }, "A player voted to pause or resume the game"},
+	{[]MessageType{TypeGameEnded}, func() interface{} { return &GameEnded{} }, "An operator ended the game"},
+	{[]MessageType{TypeNewGameCreated}, func() interface{} { return &NewGameCreated{} }, "Lobby only: a new game was created, for clients that haven't sent lobby_subscribe"},
+	{[]MessageType{TypeLobbyGames}, func() interface{} { return &LobbyGames{} }, "Lobby only: the games matching the client's filter"},
+	{[]MessageType{TypeLobbyUpdate}, func() interface{} { return &LobbyUpdate{} }, "Lobby only: games added, changed or removed since the last update"},
+	{[]MessageType{TypeMatchFound}, func() interface{} { return &MatchFound{} }, "Lobby only: quick match put the player in a game"},
+	{[]MessageType{TypeResumed}, func() interface{} { return &Resumed{} }, "Ends the reply to resume"},
+	{[]MessageType{TypeStateFull}, func() interface{} { return &StateFull{} }, "The whole game at a version"},
+	{[]MessageType{TypeStateDelta}, func() interface{} { return &StateDelta{} }, "The changes to the game between two versions"},
+	{[]MessageType{TypeChatMessage}, func() interface{} { return &ChatMessage{} }, "A chat message or whisper"},
+	{[]MessageType{TypeChatHistory}, func() interface{} { return &ChatHistory{} }, "Recent chat, sent on connecting and in answer to get_chat_history"},
+	{[]MessageType{TypeChatModerated}, func() interface{} { return &ChatModerated{} }, "The host muted, unmuted or kicked a player from chat"},
+	{[]MessageType{TypeMemePosted}, func() interface{} { return &MemePosted{} }, "A player posted a meme, pointing to their chat message"},
+}
+
+// relayed are client messages the server passes on to the other clients
+var relayed = map[MessageType]bool{
+	TypeGetHost:              true,
+	TypeHostInfo:             true,
+	TypeGetCurrentTurn:       true,
+	TypeCurrentTurnResponse:  true,
+	TypeCheckGameStarted:     true,
+	TypeGameStartedStatus:    true,
+	TypeGameStarted:          true,
+	TypeBroadcastGameStarted: true,
+	TypeBuyProperty:          true,
+	TypeClientNavigating:     true,
+}
+
+// NewRequest returns an empty payload for a client message type
+func NewRequest(t MessageType) (interface{}, bool) {
+	for _, s := range clientMessages {
+		for _, candidate := range s.types {
+			if candidate == t {
+				return s.new(), true
+			}
+		}
+	}
+	return nil, false
+}
+
+// Relayed reports whether the server passes a client message on to the other clients
+func Relayed(t MessageType) bool {
+	return relayed[t]
+}
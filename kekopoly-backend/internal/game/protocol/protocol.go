@@ -0,0 +1,179 @@
+// Package protocol defines the messages exchanged over game WebSockets.
+//
+// Clients may send version 1 envelopes:
+//
+//	{"v": 1, "type": "roll_dice", "requestId": "r-12", "payload": {...}}
+//
+// and get an "ack" or "error" carrying the same requestId. Messages without "v"
+// are the legacy format, with the payload fields next to "type"; they are still
+// accepted so older clients keep working. Server messages are flat objects with
+// "type" and "v" alongside their fields.
+package protocol
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Version is the protocol version this server speaks
+const Version = 1
+
+var (
+	// ErrMalformed is returned for a message that isn't a JSON object
+	ErrMalformed = errors.New("malformed message")
+	// ErrMissingType is returned for a message without a type
+	ErrMissingType = errors.New("message type is required")
+	// ErrUnsupportedVersion is returned for a message from a newer protocol version
+	ErrUnsupportedVersion = errors.New("unsupported protocol version")
+	// ErrInvalidPayload is returned when a payload doesn't match its message type
+	ErrInvalidPayload = errors.New("invalid payload")
+)
+
+// Error codes sent in "error" messages
+const (
+	CodeInvalidMessage     = "invalid_message"
+	CodeUnsupportedVersion = "unsupported_version"
+	CodeUnknownType        = "unknown_type"
+	CodeNotYourTurn        = "not_your_turn"
+	CodeGameNotFound       = "game_not_found"
+	CodeActionFailed       = "action_failed"
+	CodeReadOnly           = "read_only"
+	CodeNotHost            = "not_host"
+	CodeRateLimited        = "rate_limited"
+	CodeMuted              = "muted"
+	CodeMessageRejected    = "message_rejected"
+	CodeGamePaused         = "game_paused"
+)
+
+// Header is embedded in every server message
+type Header struct {
+	Type           MessageType `json:"type"`
+	Version        int         `json:"v"`
+	RequestID      string      `json:"requestId,omitempty"`      // Set on replies to a client request
+	Seq            int64       `json:"seq,omitempty"`            // Position in the game's broadcasts, set when they are numbered
+	SpectatorCount int         `json:"spectatorCount,omitempty"` // Set on game broadcasts while anyone is spectating
+}
+
+func (h *Header) header() *Header { return h }
+
+// Outbound is a server message
+type Outbound interface {
+	MessageType() MessageType
+	header() *Header
+}
+
+// Encode stamps a server message with its type and the protocol version and marshals it
+func Encode(msg Outbound) ([]byte, error) {
+	h := msg.header()
+	h.Type = msg.MessageType()
+	h.Version = Version
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s message: %w", h.Type, err)
+	}
+	return data, nil
+}
+
+// Validator is implemented by payloads with rules beyond their field types
+type Validator interface {
+	Validate() error
+}
+
+// Message is a decoded client message
+type Message struct {
+	Version   int             `json:"v,omitempty"`
+	Type      MessageType     `json:"type"`
+	RequestID string          `json:"requestId,omitempty"`
+	Payload   json.RawMessage `json:"payload,omitempty"`
+
+	raw []byte
+}
+
+// Decode parses a client message in either the versioned or the legacy format
+func Decode(data []byte) (*Message, error) {
+	var msg Message
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	if msg.Type == "" {
+		return nil, ErrMissingType
+	}
+	if msg.Version > Version {
+		return nil, fmt.Errorf("%w: %d (server speaks %d)", ErrUnsupportedVersion, msg.Version, Version)
+	}
+	msg.raw = data
+	return &msg, nil
+}
+
+// Legacy reports whether the message uses the unversioned flat format
+func (m *Message) Legacy() bool {
+	return m.Version == 0
+}
+
+// body returns the JSON object holding the message's fields
+func (m *Message) body() []byte {
+	if m.Legacy() {
+		return m.raw
+	}
+	if len(bytes.TrimSpace(m.Payload)) == 0 || string(bytes.TrimSpace(m.Payload)) == "null" {
+		return []byte("{}")
+	}
+	return m.Payload
+}
+
+// DecodePayload unmarshals the message's fields into v and validates them against
+// the same rules the JSON Schema publishes: required fields must be present and
+// every field must have the right type
+func (m *Message) DecodePayload(v interface{}) error {
+	body := m.body()
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidPayload, m.Type, err)
+	}
+
+	var present map[string]json.RawMessage
+	if err := json.Unmarshal(body, &present); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidPayload, m.Type, err)
+	}
+	for _, field := range requiredFields(reflect.TypeOf(v)) {
+		if value, ok := present[field]; !ok || string(value) == "null" {
+			return fmt.Errorf("%w: %s: %s is required", ErrInvalidPayload, m.Type, field)
+		}
+	}
+
+	if validator, ok := v.(Validator); ok {
+		if err := validator.Validate(); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidPayload, m.Type, err)
+		}
+	}
+	return nil
+}
+
+// Flatten returns the message in the flat format, for relaying to other clients
+func (m *Message) Flatten() ([]byte, error) {
+	if m.Legacy() {
+		return m.raw, nil
+	}
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(m.body(), &fields); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidPayload, m.Type, err)
+	}
+	fields["type"] = m.Type
+	fields["v"] = m.Version
+	if m.RequestID != "" {
+		fields["requestId"] = m.RequestID
+	}
+	return json.Marshal(fields)
+}
+
+// ErrorCode picks the error code for a decoding error
+func ErrorCode(err error) string {
+	switch {
+	case errors.Is(err, ErrUnsupportedVersion):
+		return CodeUnsupportedVersion
+	default:
+		return CodeInvalidMessage
+	}
+}
@@ -0,0 +1,142 @@
+package protocol
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeAcceptsBothFormats(t *testing.T) {
+	legacy, err := Decode([]byte(`{"type":"player_ready","playerId":"p1","isReady":true}`))
+	require.NoError(t, err)
+	assert.True(t, legacy.Legacy())
+
+	var ready PlayerReadyRequest
+	require.NoError(t, legacy.DecodePayload(&ready))
+	assert.Equal(t, "p1", ready.PlayerID)
+	assert.True(t, ready.IsReady)
+
+	versioned, err := Decode([]byte(`{"v":1,"type":"player_ready","requestId":"r-1","payload":{"playerId":"p2","isReady":false}}`))
+	require.NoError(t, err)
+	assert.False(t, versioned.Legacy())
+	assert.Equal(t, "r-1", versioned.RequestID)
+
+	ready = PlayerReadyRequest{}
+	require.NoError(t, versioned.DecodePayload(&ready))
+	assert.Equal(t, "p2", ready.PlayerID)
+	assert.False(t, ready.IsReady)
+}
+
+func TestDecodeRejectsBadMessages(t *testing.T) {
+	_, err := Decode([]byte(`not json`))
+	assert.ErrorIs(t, err, ErrMalformed)
+	assert.Equal(t, CodeInvalidMessage, ErrorCode(err))
+
+	_, err = Decode([]byte(`{"payload":{}}`))
+	assert.ErrorIs(t, err, ErrMissingType)
+
+	_, err = Decode([]byte(`{"v":2,"type":"roll_dice"}`))
+	assert.ErrorIs(t, err, ErrUnsupportedVersion)
+	assert.Equal(t, CodeUnsupportedVersion, ErrorCode(err))
+}
+
+func TestDecodePayloadValidates(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		wantErr bool
+	}{
+		{"required field present", `{"v":1,"type":"set_host","payload":{"hostId":"h"}}`, false},
+		{"required field missing", `{"v":1,"type":"set_host","payload":{}}`, true},
+		{"required field null", `{"v":1,"type":"set_host","payload":{"hostId":null}}`, true},
+		{"missing payload", `{"v":1,"type":"set_host"}`, true},
+		{"wrong field type", `{"v":1,"type":"player_ready","payload":{"playerId":"p","isReady":"yes"}}`, true},
+		{"custom rule", `{"v":1,"type":"player_joined","payload":{"player":{"name":"x"}}}`, true},
+		{"legacy playerData variant", `{"type":"player_joined","playerData":{"id":"p"}}`, true},
+		{"nothing required", `{"v":1,"type":"roll_dice"}`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := Decode([]byte(tt.message))
+			require.NoError(t, err)
+			payload, ok := NewRequest(msg.Type)
+			require.True(t, ok)
+
+			err = msg.DecodePayload(payload)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidPayload)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestPlayerJoinedKeepsUnknownFields(t *testing.T) {
+	msg, err := Decode([]byte(`{"type":"player_joined","player":{"id":"p1","walletAddress":"abc"}}`))
+	require.NoError(t, err)
+
+	var req PlayerJoinedRequest
+	require.NoError(t, msg.DecodePayload(&req))
+	assert.Equal(t, "p1", req.Player.ID)
+	assert.Equal(t, "abc", req.Fields()["walletAddress"])
+}
+
+func TestEncodeStampsHeader(t *testing.T) {
+	data, err := Encode(&Error{Header: Header{RequestID: "r-9"}, Code: CodeNotYourTurn, Message: "Not your turn", CurrentTurn: "p2"})
+	require.NoError(t, err)
+
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, map[string]interface{}{
+		"type":        "error",
+		"v":           float64(Version),
+		"requestId":   "r-9",
+		"code":        CodeNotYourTurn,
+		"message":     "Not your turn",
+		"currentTurn": "p2",
+	}, decoded)
+}
+
+func TestFlatten(t *testing.T) {
+	legacy := []byte(`{"type":"get_host","gameId":"g"}`)
+	msg, err := Decode(legacy)
+	require.NoError(t, err)
+	flat, err := msg.Flatten()
+	require.NoError(t, err)
+	assert.Equal(t, legacy, flat)
+
+	msg, err = Decode([]byte(`{"v":1,"type":"host_info","requestId":"r","payload":{"hostId":"h"}}`))
+	require.NoError(t, err)
+	flat, err = msg.Flatten()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type":"host_info","v":1,"requestId":"r","hostId":"h"}`, string(flat))
+}
+
+func TestEveryServerMessageIsRegistered(t *testing.T) {
+	for _, s := range serverMessages {
+		msg, ok := s.new().(Outbound)
+		require.True(t, ok, "%T does not implement Outbound", s.new())
+		assert.Equal(t, s.types, []MessageType{msg.MessageType()})
+	}
+	for relayedType := range relayed {
+		_, ok := NewRequest(relayedType)
+		assert.True(t, ok, "relayed type %s has no payload", relayedType)
+	}
+}
+
+func TestSchemaIsUpToDate(t *testing.T) {
+	schema, err := Schema()
+	require.NoError(t, err)
+
+	committed, err := os.ReadFile("../../../docs/websocket-protocol.schema.json")
+	if errors.Is(err, os.ErrNotExist) {
+		t.Skip("schema file not present")
+	}
+	require.NoError(t, err)
+	assert.Equal(t, string(committed), string(schema), "docs/websocket-protocol.schema.json is stale; run go generate ./internal/game/protocol")
+}
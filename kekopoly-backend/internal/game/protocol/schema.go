@@ -0,0 +1,217 @@
+package protocol
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+//go:generate go run ../../../cmd/wsschema -o ../../../docs/websocket-protocol.schema.json
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	objectIDType = reflect.TypeOf(primitive.ObjectID{})
+	headerType   = reflect.TypeOf(Header{})
+)
+
+// jsonField is a struct field as encoding/json sees it
+type jsonField struct {
+	name      string
+	omitempty bool
+	field     reflect.StructField
+}
+
+// jsonFields lists a struct's JSON fields, flattening embedded structs like encoding/json
+func jsonFields(t reflect.Type) []jsonField {
+	var fields []jsonField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			fields = append(fields, jsonFields(f.Type)...)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, jsonField{name: name, omitempty: strings.Contains(opts, "omitempty"), field: f})
+	}
+	return fields
+}
+
+var requiredCache sync.Map // reflect.Type -> []string
+
+// requiredFields lists the JSON fields of a struct that aren't omitempty
+func requiredFields(t reflect.Type) []string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	if cached, ok := requiredCache.Load(t); ok {
+		return cached.([]string)
+	}
+	var names []string
+	for _, f := range jsonFields(t) {
+		if !f.omitempty {
+			names = append(names, f.name)
+		}
+	}
+	requiredCache.Store(t, names)
+	return names
+}
+
+// schemaBuilder collects the definitions of every struct a message refers to
+type schemaBuilder struct {
+	defs map[string]interface{}
+}
+
+// typeSchema returns the schema for a Go type, adding struct definitions as needed
+func (b *schemaBuilder) typeSchema(t reflect.Type) map[string]interface{} {
+	switch t {
+	case timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case objectIDType:
+		return map[string]interface{}{"type": "string", "pattern": "^[0-9a-f]{24}$"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return b.typeSchema(t.Elem())
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": b.typeSchema(t.Elem())}
+	case reflect.Map:
+		schema := map[string]interface{}{"type": "object"}
+		if t.Elem().Kind() != reflect.Interface {
+			schema["additionalProperties"] = b.typeSchema(t.Elem())
+		}
+		return schema
+	case reflect.Struct:
+		name := t.Name()
+		if _, done := b.defs[name]; !done {
+			b.defs[name] = nil // Placeholder so recursive types terminate
+			b.defs[name] = b.structSchema(t, nil)
+		}
+		return map[string]interface{}{"$ref": "#/$defs/" + name}
+	default:
+		// interface{} and anything else accepts any value
+		return map[string]interface{}{}
+	}
+}
+
+// structSchema describes a struct's fields. Server messages pass their types,
+// which replace the embedded Header's type field with a constant.
+func (b *schemaBuilder) structSchema(t reflect.Type, types []MessageType) map[string]interface{} {
+	properties := map[string]interface{}{}
+	required := []string{}
+	for _, f := range jsonFields(t) {
+		properties[f.name] = b.typeSchema(f.field.Type)
+		if !f.omitempty {
+			required = append(required, f.name)
+		}
+	}
+
+	if _, hasHeader := t.FieldByName(headerType.Name()); hasHeader && types != nil {
+		properties["type"] = typeConstraint(types)
+		properties["v"] = map[string]interface{}{"const": Version}
+	}
+
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// typeConstraint restricts a "type" property to the message's types
+func typeConstraint(types []MessageType) map[string]interface{} {
+	if len(types) == 1 {
+		return map[string]interface{}{"const": types[0]}
+	}
+	return map[string]interface{}{"enum": types}
+}
+
+// Schema returns a JSON Schema (draft 2020-12) describing every message. Client
+// messages are described in the versioned envelope format; ServerMessage covers
+// what the server sends.
+func Schema() ([]byte, error) {
+	b := &schemaBuilder{defs: map[string]interface{}{}}
+
+	var client []interface{}
+	for _, s := range clientMessages {
+		payload := reflect.TypeOf(s.new()).Elem()
+		envelope := map[string]interface{}{
+			"type":        "object",
+			"description": s.description,
+			"properties": map[string]interface{}{
+				"v":         map[string]interface{}{"const": Version},
+				"type":      typeConstraint(s.types),
+				"requestId": map[string]interface{}{"type": "string", "description": "Echoed in the ack or error reply"},
+				"payload":   b.typeSchema(payload),
+			},
+			"required": []string{"v", "type"},
+		}
+		if len(requiredFields(payload)) > 0 {
+			envelope["required"] = []string{"v", "type", "payload"}
+		}
+		client = append(client, envelope)
+	}
+
+	var server []interface{}
+	for _, s := range serverMessages {
+		t := reflect.TypeOf(s.new()).Elem()
+		schema := b.structSchema(t, s.types)
+		schema["title"] = t.Name()
+		schema["description"] = s.description
+		server = append(server, schema)
+	}
+
+	b.defs["ClientMessage"] = map[string]interface{}{
+		"description": "A message a client sends, in the versioned envelope",
+		"anyOf":       client,
+	}
+	b.defs["ServerMessage"] = map[string]interface{}{
+		"description": "A message the server sends",
+		"anyOf":       server,
+	}
+
+	doc := map[string]interface{}{
+		"$schema":     "https://json-schema.org/draft/2020-12/schema",
+		"title":       "Kekopoly WebSocket protocol",
+		"description": "Game WebSocket messages, protocol version 1. Generated by cmd/wsschema; do not edit.",
+		"$defs":       b.defs,
+		"anyOf": []interface{}{
+			map[string]interface{}{"$ref": "#/$defs/ClientMessage"},
+			map[string]interface{}{"$ref": "#/$defs/ServerMessage"},
+		},
+	}
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
@@ -0,0 +1,125 @@
+package simulation
+
+import (
+	"github.com/kekopoly/backend/internal/game/models"
+)
+
+// drawCard draws a card from the deck for the player and carries it out
+func (t *table) drawCard(player *models.Player, deck models.CardType) {
+	card := t.pick(deck)
+	before := player.Balance
+	for _, effect := range card.Effects {
+		if player.Status != models.PlayerStatusActive {
+			break
+		}
+		t.apply(player, card, effect)
+	}
+	t.result.draws = append(t.result.draws, draw{
+		card:     card.Name,
+		deck:     deck,
+		playerID: player.ID,
+		cash:     player.Balance - before,
+	})
+	t.dirty = true
+}
+
+// pick draws a card from the deck by weight
+func (t *table) pick(deck models.CardType) CardSpec {
+	total := 0
+	for _, card := range t.rules.Cards {
+		if card.Deck == deck {
+			total += card.weight()
+		}
+	}
+	roll := t.rng.Intn(total)
+	for _, card := range t.rules.Cards {
+		if card.Deck != deck {
+			continue
+		}
+		if roll -= card.weight(); roll < 0 {
+			return card
+		}
+	}
+	panic("unreachable: deck weights changed while drawing")
+}
+
+// apply carries out one of a card's effects
+func (t *table) apply(player *models.Player, card CardSpec, effect Effect) {
+	switch effect.Kind {
+	case EffectCollect:
+		player.Balance += effect.Amount
+	case EffectPay:
+		t.charge(player, nil, effect.Amount, "card", card.Name)
+	case EffectCollectEach:
+		for _, other := range t.others(player) {
+			t.charge(other, player, effect.Amount, "card", card.Name)
+		}
+	case EffectPayEach:
+		for _, other := range t.others(player) {
+			t.charge(player, other, effect.Amount, "card", card.Name)
+		}
+	case EffectPayNext:
+		if next := t.neighbour(player, 1); next != nil {
+			t.charge(player, next, effect.Amount, "card", card.Name)
+		}
+	case EffectCollectPrevious:
+		if previous := t.neighbour(player, -1); previous != nil {
+			t.charge(previous, player, effect.Amount, "card", card.Name)
+		}
+	case EffectPerProperty:
+		player.Balance += effect.Amount * len(player.Properties)
+	case EffectCollectRoll:
+		player.Balance += effect.Amount * (t.rng.Intn(6) + 1)
+	case EffectAdvance:
+		player.Position = effect.Position
+		player.Balance += effect.Amount
+		t.result.landings[player.Position]++
+	case EffectBack:
+		player.Position = ((player.Position-effect.Amount)%boardSize + boardSize) % boardSize
+		t.result.landings[player.Position]++
+	case EffectJail:
+		player.Position = jailPosition
+		player.InJail = true
+		player.JailTurns = jailTurns
+	case EffectLosePercent:
+		player.Balance -= player.Balance * effect.Amount / 100
+	case EffectAllLosePercent:
+		for i := range t.game.Players {
+			if other := &t.game.Players[i]; other.Status == models.PlayerStatusActive {
+				other.Balance -= other.Balance * effect.Amount / 100
+			}
+		}
+	case EffectPayPoorest:
+		poorest := player
+		for _, other := range t.others(player) {
+			if other.Balance < poorest.Balance {
+				poorest = other
+			}
+		}
+		if poorest != player {
+			t.charge(player, poorest, player.Balance*effect.Amount/100, "card", card.Name)
+		}
+	}
+}
+
+// others returns the players still in the game besides player
+func (t *table) others(player *models.Player) []*models.Player {
+	var others []*models.Player
+	for _, id := range t.game.TurnOrder {
+		if id != player.ID {
+			others = append(others, t.player(id))
+		}
+	}
+	return others
+}
+
+// neighbour returns the player step seats after player in turn order
+func (t *table) neighbour(player *models.Player, step int) *models.Player {
+	order := t.game.TurnOrder
+	for i, id := range order {
+		if id == player.ID && len(order) > 1 {
+			return t.player(order[((i+step)%len(order)+len(order))%len(order)])
+		}
+	}
+	return nil
+}
@@ -0,0 +1,279 @@
+package simulation
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/kekopoly/backend/internal/game/models"
+)
+
+// Report sums up a run
+type Report struct {
+	Games        int            `json:"games"`
+	Players      int            `json:"players"`
+	Seed         int64          `json:"seed"`
+	Turns        Summary        `json:"turns"`
+	Rounds       Summary        `json:"rounds"`
+	Endings      map[string]int `json:"endings"`
+	Seats        []WinRate      `json:"seats"`
+	Strategies   []WinRate      `json:"strategies"`
+	Landings     []Landing      `json:"landings"`
+	Bankruptcies []Bankruptcy   `json:"bankruptcies"`
+	Cards        []CardImpact   `json:"cards"`
+}
+
+// Summary describes how a number varied across games
+type Summary struct {
+	Mean   float64 `json:"mean"`
+	Min    int     `json:"min"`
+	Median int     `json:"median"`
+	P90    int     `json:"p90"`
+	Max    int     `json:"max"`
+}
+
+// WinRate is how often a seat or strategy won. Seat 1 plays first.
+type WinRate struct {
+	Seat     int     `json:"seat,omitempty"`
+	Strategy string  `json:"strategy,omitempty"`
+	Played   int     `json:"played"`
+	Wins     int     `json:"wins"`
+	Rate     float64 `json:"rate"`
+}
+
+// Landing is how often players ended a move on a square
+type Landing struct {
+	Position int     `json:"position"`
+	Name     string  `json:"name"`
+	Count    int     `json:"count"`
+	Share    float64 `json:"share"`
+}
+
+// Bankruptcy counts players bankrupted by a property's rent or a card
+type Bankruptcy struct {
+	Cause  string `json:"cause"`
+	Source string `json:"source"`
+	Count  int    `json:"count"`
+}
+
+// CardImpact is what a card did for the players who drew it. DrawerWinRate is how often
+// a player who drew it went on to win.
+type CardImpact struct {
+	Name          string          `json:"name"`
+	Deck          models.CardType `json:"deck"`
+	Draws         int             `json:"draws"`
+	MeanCash      float64         `json:"meanCash"`
+	DrawerWinRate float64         `json:"drawerWinRate"`
+}
+
+func newReport(rules *Ruleset, cfg Config, results []*gameResult) *Report {
+	report := &Report{
+		Games:   len(results),
+		Players: cfg.Players,
+		Seed:    cfg.Seed,
+		Endings: make(map[string]int),
+	}
+
+	seats := make([]WinRate, cfg.Players)
+	strategies := make(map[string]*WinRate)
+	landings := make(map[int]int)
+	bankruptcies := make(map[bankruptcy]int)
+	cards := make(map[string]*CardImpact)
+	cardWins := make(map[string]int)
+	var turns, rounds []int
+
+	for _, result := range results {
+		turns = append(turns, result.turns)
+		rounds = append(rounds, result.rounds)
+		report.Endings[result.ending]++
+
+		for i, id := range result.seats {
+			seats[i].Seat = i + 1
+			seats[i].Played++
+			name := result.strategies[id]
+			if strategies[name] == nil {
+				strategies[name] = &WinRate{Strategy: name}
+			}
+			strategies[name].Played++
+			if id == result.winner {
+				seats[i].Wins++
+				strategies[name].Wins++
+			}
+		}
+		for position, count := range result.landings {
+			landings[position] += count
+		}
+		for _, b := range result.bankruptcies {
+			bankruptcies[b]++
+		}
+		for _, d := range result.draws {
+			impact := cards[d.card]
+			if impact == nil {
+				impact = &CardImpact{Name: d.card, Deck: d.deck}
+				cards[d.card] = impact
+			}
+			impact.Draws++
+			impact.MeanCash += float64(d.cash)
+			if d.playerID == result.winner {
+				cardWins[d.card]++
+			}
+		}
+	}
+
+	report.Turns = summarize(turns)
+	report.Rounds = summarize(rounds)
+
+	for i := range seats {
+		seats[i].Rate = rate(seats[i].Wins, seats[i].Played)
+	}
+	report.Seats = seats
+	for _, name := range sortedKeys(strategies) {
+		strategy := strategies[name]
+		strategy.Rate = rate(strategy.Wins, strategy.Played)
+		report.Strategies = append(report.Strategies, *strategy)
+	}
+
+	total := 0
+	for _, count := range landings {
+		total += count
+	}
+	for position := 0; position < boardSize; position++ {
+		report.Landings = append(report.Landings, Landing{
+			Position: position,
+			Name:     rules.squareName(position),
+			Count:    landings[position],
+			Share:    rate(landings[position], total),
+		})
+	}
+
+	for b, count := range bankruptcies {
+		report.Bankruptcies = append(report.Bankruptcies, Bankruptcy{Cause: b.cause, Source: b.source, Count: count})
+	}
+	sort.Slice(report.Bankruptcies, func(i, j int) bool {
+		a, b := report.Bankruptcies[i], report.Bankruptcies[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.Cause != b.Cause {
+			return a.Cause < b.Cause
+		}
+		return a.Source < b.Source
+	})
+
+	for _, name := range sortedKeys(cards) {
+		impact := cards[name]
+		impact.MeanCash /= float64(impact.Draws)
+		impact.DrawerWinRate = rate(cardWins[name], impact.Draws)
+		report.Cards = append(report.Cards, *impact)
+	}
+	return report
+}
+
+func summarize(values []int) Summary {
+	if len(values) == 0 {
+		return Summary{}
+	}
+	sorted := append([]int(nil), values...)
+	sort.Ints(sorted)
+	sum := 0
+	for _, v := range sorted {
+		sum += v
+	}
+	return Summary{
+		Mean:   float64(sum) / float64(len(sorted)),
+		Min:    sorted[0],
+		Median: sorted[len(sorted)/2],
+		P90:    sorted[len(sorted)*9/10],
+		Max:    sorted[len(sorted)-1],
+	}
+}
+
+func rate(count, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(count) / float64(total)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// WriteJSON writes the report as indented JSON
+func (r *Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// WriteCSV writes the report as one row per figure, with the columns section, key,
+// metric and value, so it can be pivoted in a spreadsheet
+func (r *Report) WriteCSV(w io.Writer) error {
+	out := csv.NewWriter(w)
+	row := func(section, key, metric string, value interface{}) {
+		var text string
+		switch v := value.(type) {
+		case int:
+			text = strconv.Itoa(v)
+		case int64:
+			text = strconv.FormatInt(v, 10)
+		case float64:
+			text = strconv.FormatFloat(v, 'f', 4, 64)
+		}
+		out.Write([]string{section, key, metric, text})
+	}
+
+	out.Write([]string{"section", "key", "metric", "value"})
+	row("run", "games", "count", r.Games)
+	row("run", "players", "count", r.Players)
+	row("run", "seed", "value", r.Seed)
+	for _, length := range []struct {
+		name    string
+		summary Summary
+	}{{"turns", r.Turns}, {"rounds", r.Rounds}} {
+		name, summary := length.name, length.summary
+		row("length", name, "mean", summary.Mean)
+		row("length", name, "min", summary.Min)
+		row("length", name, "median", summary.Median)
+		row("length", name, "p90", summary.P90)
+		row("length", name, "max", summary.Max)
+	}
+	for _, ending := range sortedKeys(r.Endings) {
+		row("ending", ending, "count", r.Endings[ending])
+	}
+	for _, seat := range r.Seats {
+		key := strconv.Itoa(seat.Seat)
+		row("seat", key, "played", seat.Played)
+		row("seat", key, "wins", seat.Wins)
+		row("seat", key, "win_rate", seat.Rate)
+	}
+	for _, strategy := range r.Strategies {
+		row("strategy", strategy.Strategy, "played", strategy.Played)
+		row("strategy", strategy.Strategy, "wins", strategy.Wins)
+		row("strategy", strategy.Strategy, "win_rate", strategy.Rate)
+	}
+	for _, landing := range r.Landings {
+		key := strconv.Itoa(landing.Position) + " " + landing.Name
+		row("landing", key, "count", landing.Count)
+		row("landing", key, "share", landing.Share)
+	}
+	for _, b := range r.Bankruptcies {
+		row("bankruptcy", b.Cause+": "+b.Source, "count", b.Count)
+	}
+	for _, card := range r.Cards {
+		key := string(card.Deck) + ": " + card.Name
+		row("card", key, "draws", card.Draws)
+		row("card", key, "mean_cash", card.MeanCash)
+		row("card", key, "drawer_win_rate", card.DrawerWinRate)
+	}
+
+	out.Flush()
+	return out.Error()
+}
@@ -0,0 +1,272 @@
+package simulation
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/kekopoly/backend/internal/game/models"
+)
+
+// Board layout the game manager uses
+const (
+	boardSize        = 40
+	jailPosition     = 25
+	goToJailPosition = 30
+	jailTurns        = 3
+)
+
+// Card effect kinds
+const (
+	EffectCollect         = "collect"          // Collect amount from the bank
+	EffectPay             = "pay"              // Pay amount to the bank
+	EffectCollectEach     = "collect_each"     // Collect amount from every other player
+	EffectPayEach         = "pay_each"         // Pay amount to every other player
+	EffectPayNext         = "pay_next"         // Pay amount to the next player
+	EffectCollectPrevious = "collect_previous" // Collect amount from the previous player
+	EffectPerProperty     = "per_property"     // Collect amount for each property owned
+	EffectCollectRoll     = "collect_roll"     // Roll a die and collect that many times amount
+	EffectAdvance         = "advance"          // Move forward to position and collect amount
+	EffectBack            = "back"             // Move back amount squares
+	EffectJail            = "jail"             // Go straight to jail
+	EffectLosePercent     = "lose_percent"     // Lose amount percent of cash to the bank
+	EffectAllLosePercent  = "all_lose_percent" // Every player loses amount percent of cash
+	EffectPayPoorest      = "pay_poorest"      // Pay amount percent of cash to the poorest player
+)
+
+var effectKinds = map[string]bool{
+	EffectCollect: true, EffectPay: true, EffectCollectEach: true, EffectPayEach: true,
+	EffectPayNext: true, EffectCollectPrevious: true, EffectPerProperty: true,
+	EffectCollectRoll: true, EffectAdvance: true, EffectBack: true, EffectJail: true,
+	EffectLosePercent: true, EffectAllLosePercent: true, EffectPayPoorest: true,
+}
+
+// rarityWeights are the draw odds of cards that don't set their own weight
+var rarityWeights = map[models.CardRarity]int{
+	models.CardRarityCommon:    6,
+	models.CardRarityRare:      3,
+	models.CardRarityLegendary: 1,
+}
+
+//go:embed ruleset.json
+var defaultRuleset []byte
+
+// Ruleset is the board and the rules the simulator applies around the game manager.
+// The manager moves players, handles jail and doubles, sells property and collects rent;
+// clients take care of everything else, so the ruleset says how.
+type Ruleset struct {
+	// StartingBalance is every player's cash when the game starts
+	StartingBalance int `json:"startingBalance"`
+	// PassStart is paid to a player whose roll takes them past START
+	PassStart int `json:"passStart"`
+	// MaxTurns ends a game still running after this many turns; the richest player wins
+	MaxTurns int `json:"maxTurns"`
+	// Market is rolled for at the start of every round
+	Market MarketRules `json:"market"`
+	// Properties are the squares that can be bought
+	Properties []PropertySpec `json:"properties"`
+	// CardSquares are the squares where a card is drawn
+	CardSquares []CardSquare `json:"cardSquares"`
+	// Cards make up the decks
+	Cards []CardSpec `json:"cards"`
+}
+
+// MarketRules sets the odds of the market rolled for each round. Bull and crash
+// markets change rent as the game manager decides.
+type MarketRules struct {
+	CrashOn   int `json:"crashOn"`   // Die face that crashes the market; 0 never does
+	BullOn    int `json:"bullOn"`    // Die face that starts a bull market; 0 never does
+	CrashLoss int `json:"crashLoss"` // Percent of their cash every player loses in a crash
+}
+
+// PropertySpec is a property on the board
+type PropertySpec struct {
+	ID       string              `json:"id"`
+	Name     string              `json:"name"`
+	Type     models.PropertyType `json:"type"`
+	Group    string              `json:"group"`
+	Position int                 `json:"position"`
+	Price    int                 `json:"price"`
+	Rent     int                 `json:"rent"`
+}
+
+// CardSquare is a square where landing draws from a deck
+type CardSquare struct {
+	Position int             `json:"position"`
+	Deck     models.CardType `json:"deck"`
+}
+
+// CardSpec is a card and what it does when drawn
+type CardSpec struct {
+	Name   string            `json:"name"`
+	Deck   models.CardType   `json:"deck"`
+	Rarity models.CardRarity `json:"rarity"`
+	// Weight is the card's share of draws from its deck; 0 uses its rarity's weight
+	Weight  int      `json:"weight"`
+	Effects []Effect `json:"effects"`
+}
+
+// Effect is one thing a card does
+type Effect struct {
+	Kind     string `json:"kind"`
+	Amount   int    `json:"amount"`
+	Position int    `json:"position"`
+}
+
+// DefaultRuleset returns the built-in board and cards
+func DefaultRuleset() (*Ruleset, error) {
+	return ParseRuleset(defaultRuleset)
+}
+
+// LoadRuleset reads a ruleset from a JSON file
+func LoadRuleset(path string) (*Ruleset, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ruleset: %w", err)
+	}
+	return ParseRuleset(data)
+}
+
+// ParseRuleset decodes and checks a JSON ruleset
+func ParseRuleset(data []byte) (*Ruleset, error) {
+	var rules Ruleset
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("invalid ruleset: %w", err)
+	}
+	if err := rules.validate(); err != nil {
+		return nil, fmt.Errorf("invalid ruleset: %w", err)
+	}
+	return &rules, nil
+}
+
+func (r *Ruleset) validate() error {
+	if r.StartingBalance <= 0 {
+		return fmt.Errorf("startingBalance must be positive")
+	}
+	if r.MaxTurns <= 0 {
+		return fmt.Errorf("maxTurns must be positive")
+	}
+	if r.Market.CrashOn < 0 || r.Market.CrashOn > 6 || r.Market.BullOn < 0 || r.Market.BullOn > 6 {
+		return fmt.Errorf("market faces must be between 1 and 6, or 0")
+	}
+
+	taken := make(map[int]string)
+	ids := make(map[string]bool)
+	for _, property := range r.Properties {
+		if property.ID == "" || ids[property.ID] {
+			return fmt.Errorf("property %q needs a unique id", property.Name)
+		}
+		ids[property.ID] = true
+		if err := claim(taken, property.Position, property.ID); err != nil {
+			return err
+		}
+		if property.Price <= 0 {
+			return fmt.Errorf("property %s needs a price", property.ID)
+		}
+	}
+
+	decks := make(map[models.CardType]int)
+	for _, card := range r.Cards {
+		if card.Name == "" || len(card.Effects) == 0 {
+			return fmt.Errorf("cards need a name and at least one effect")
+		}
+		if card.weight() <= 0 {
+			return fmt.Errorf("card %s has no weight or known rarity", card.Name)
+		}
+		for _, effect := range card.Effects {
+			if !effectKinds[effect.Kind] {
+				return fmt.Errorf("card %s has unknown effect %q", card.Name, effect.Kind)
+			}
+			if effect.Kind == EffectAdvance && (effect.Position < 0 || effect.Position >= boardSize) {
+				return fmt.Errorf("card %s advances off the board", card.Name)
+			}
+		}
+		decks[card.Deck]++
+	}
+	for _, square := range r.CardSquares {
+		if err := claim(taken, square.Position, string(square.Deck)); err != nil {
+			return err
+		}
+		if decks[square.Deck] == 0 {
+			return fmt.Errorf("square %d draws from %s, which has no cards", square.Position, square.Deck)
+		}
+	}
+	return nil
+}
+
+// claim checks a square is on the board and not already used
+func claim(taken map[int]string, position int, by string) error {
+	if position < 0 || position >= boardSize {
+		return fmt.Errorf("%s is off the board at %d", by, position)
+	}
+	if position == 0 || position == jailPosition || position == goToJailPosition {
+		return fmt.Errorf("%s can't use square %d, which the game reserves", by, position)
+	}
+	if other, ok := taken[position]; ok {
+		return fmt.Errorf("%s and %s both use square %d", other, by, position)
+	}
+	taken[position] = by
+	return nil
+}
+
+func (c CardSpec) weight() int {
+	if c.Weight > 0 {
+		return c.Weight
+	}
+	return rarityWeights[c.Rarity]
+}
+
+// board returns a fresh, unowned copy of the properties for a game
+func (r *Ruleset) board() []models.Property {
+	properties := make([]models.Property, 0, len(r.Properties))
+	for _, spec := range r.Properties {
+		kind := spec.Type
+		if kind == "" {
+			kind = models.PropertyTypeRegular
+		}
+		properties = append(properties, models.Property{
+			ID:       spec.ID,
+			Name:     spec.Name,
+			Type:     kind,
+			Group:    spec.Group,
+			Position: spec.Position,
+			Price:    spec.Price,
+			RentBase: spec.Rent,
+		})
+	}
+	return properties
+}
+
+// squareName names a board position for the landing report
+func (r *Ruleset) squareName(position int) string {
+	switch position {
+	case 0:
+		return "START"
+	case jailPosition:
+		return "JAIL"
+	case goToJailPosition:
+		return "GO TO JAIL"
+	}
+	for _, property := range r.Properties {
+		if property.Position == position {
+			return property.Name
+		}
+	}
+	for _, square := range r.CardSquares {
+		if square.Position == position {
+			return string(square.Deck) + " CARD"
+		}
+	}
+	return ""
+}
+
+// deckAt returns the deck drawn from at a position, if any
+func (r *Ruleset) deckAt(position int) (models.CardType, bool) {
+	for _, square := range r.CardSquares {
+		if square.Position == position {
+			return square.Deck, true
+		}
+	}
+	return "", false
+}
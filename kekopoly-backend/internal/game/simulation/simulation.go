@@ -0,0 +1,428 @@
+// Package simulation plays bot-vs-bot games in memory to measure how the ruleset plays.
+//
+// Each game runs on its own game manager with an in-memory store and dice seeded from
+// the run's seed, so a run can be repeated exactly. The manager applies its own rules to
+// every move, purchase and rent payment; the simulator plays the part clients play,
+// paying players for passing START, drawing cards, rolling the market and declaring
+// bankruptcies, as the Ruleset describes.
+package simulation
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/kekopoly/backend/internal/game/bots"
+	"github.com/kekopoly/backend/internal/game/manager"
+	"github.com/kekopoly/backend/internal/game/models"
+)
+
+// Ways a game can end
+const (
+	EndingBankruptcy = "bankruptcy" // Everyone but the winner went bankrupt
+	EndingTurnLimit  = "turn_limit" // The game ran out of turns; the richest player won
+)
+
+// Config says which games to play
+type Config struct {
+	Games   int   // Number of games to play
+	Players int   // Seats in each game
+	Seed    int64 // Game i rolls its dice from Seed+i
+	// Strategies are handed out to players in turn, before the turn order is shuffled,
+	// so every strategy plays from every seat
+	Strategies []string
+	// Workers is how many games are played at once; 0 means one per CPU
+	Workers int
+}
+
+// Run plays the configured games and reports on them
+func Run(ctx context.Context, rules *Ruleset, cfg Config) (*Report, error) {
+	if cfg.Games <= 0 {
+		return nil, fmt.Errorf("at least one game must be played")
+	}
+	if cfg.Players < 2 {
+		return nil, fmt.Errorf("games need at least 2 players")
+	}
+	if len(cfg.Strategies) == 0 {
+		cfg.Strategies = []string{bots.StrategyHeuristic}
+	}
+	for _, name := range cfg.Strategies {
+		if _, err := bots.New(name); err != nil {
+			return nil, err
+		}
+	}
+	workers := cfg.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	results := make([]*gameResult, cfg.Games)
+	errs := make([]error, cfg.Games)
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				results[i], errs[i] = playGame(ctx, rules, cfg, i)
+			}
+		}()
+	}
+feed:
+	for i := 0; i < cfg.Games; i++ {
+		select {
+		case <-ctx.Done():
+			break feed
+		case next <- i:
+		}
+	}
+	close(next)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("game %d: %w", i, err)
+		}
+	}
+	return newReport(rules, cfg, results), nil
+}
+
+// quietHub drops broadcasts. The game manager only passes the turn on after a roll
+// when it has a hub.
+type quietHub struct{}
+
+func (quietHub) BroadcastToGame(gameID string, message []byte) {}
+
+// playStore stops saving the game once play starts. The manager plays from the game it
+// holds in memory and nothing reads the store back, so saving every move would only
+// slow the run down.
+type playStore struct {
+	*manager.MemoryGameStore
+	playing atomic.Bool
+}
+
+// SetGameFields implements manager.GameStore
+func (s *playStore) SetGameFields(ctx context.Context, id primitive.ObjectID, fields interface{}) error {
+	if s.playing.Load() {
+		return nil
+	}
+	return s.MemoryGameStore.SetGameFields(ctx, id, fields)
+}
+
+// gameResult is what one game contributes to the report
+type gameResult struct {
+	turns, rounds int
+	ending        string
+	winner        string
+	seats         []string          // Player IDs in turn order
+	strategies    map[string]string // Player ID -> strategy
+	landings      map[int]int
+	bankruptcies  []bankruptcy
+	draws         []draw
+}
+
+type bankruptcy struct {
+	cause  string // "rent" or "card"
+	source string // The property or card
+}
+
+type draw struct {
+	card     string
+	deck     models.CardType
+	playerID string
+	cash     int // Change in the drawer's cash
+}
+
+// table is a game being played
+type table struct {
+	rules  *Ruleset
+	gm     *manager.GameManager
+	game   *models.Game
+	rng    *rand.Rand
+	played map[string]bots.Strategy
+	result *gameResult
+	dirty  bool // The simulator changed the game since it was last saved
+}
+
+// playGame plays game i of the run to the end
+func playGame(ctx context.Context, rules *Ruleset, cfg Config, i int) (*gameResult, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	rng := rand.New(rand.NewSource(cfg.Seed + int64(i)))
+	store := &playStore{MemoryGameStore: manager.NewMemoryGameStore()}
+	gm := manager.NewGameManagerWithOptions(ctx, nil, nil, zap.NewNop().Sugar(), quietHub{}, nil, manager.Options{
+		Store:        store,
+		InviteSecret: "simulation",
+		Rand:         rng,
+	})
+
+	t := &table{
+		rules:  rules,
+		gm:     gm,
+		rng:    rng,
+		played: make(map[string]bots.Strategy, cfg.Players),
+		result: &gameResult{
+			strategies: make(map[string]string, cfg.Players),
+			landings:   make(map[int]int),
+		},
+	}
+	if err := t.setUp(cfg, i); err != nil {
+		return nil, err
+	}
+	store.playing.Store(true)
+
+	previous := ""
+	for t.active() > 1 && t.result.turns < rules.MaxTurns {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		playerID := t.game.CurrentTurn
+		if playerID != previous && playerID == t.game.TurnOrder[0] {
+			t.startRound()
+		}
+		previous = playerID
+		if err := t.takeTurn(playerID); err != nil {
+			return nil, err
+		}
+		t.result.turns++
+	}
+
+	t.finish()
+	return t.result, nil
+}
+
+// setUp seats the players, lays out the board and starts the game
+func (t *table) setUp(cfg Config, i int) error {
+	ids := make([]string, cfg.Players)
+	for n := range ids {
+		ids[n] = fmt.Sprintf("player-%d", n+1)
+		strategy, _ := bots.New(cfg.Strategies[n%len(cfg.Strategies)])
+		t.played[ids[n]] = strategy
+		t.result.strategies[ids[n]] = strategy.Name()
+	}
+
+	gameID, err := t.gm.CreateGame(ids[0], ids[0], fmt.Sprintf("Simulation %d", i), cfg.Players, 0)
+	if err != nil {
+		return fmt.Errorf("failed to create game: %w", err)
+	}
+	for _, id := range ids[1:] {
+		if _, err := t.gm.JoinGame(gameID, id, id); err != nil {
+			return fmt.Errorf("failed to seat %s: %w", id, err)
+		}
+	}
+
+	// Clients sync the board and balances the same way
+	game, err := t.gm.GetGame(gameID)
+	if err != nil {
+		return err
+	}
+	game.BoardState.Properties = t.rules.board()
+	for n := range game.Players {
+		game.Players[n].Balance = t.rules.StartingBalance
+		game.Players[n].NetWorth = t.rules.StartingBalance
+	}
+	if err := t.gm.UpdateGame(game); err != nil {
+		return err
+	}
+	if err := t.gm.StartGame(gameID, ids[0]); err != nil {
+		return fmt.Errorf("failed to start game: %w", err)
+	}
+
+	if t.game, err = t.gm.GetGame(gameID); err != nil {
+		return err
+	}
+	t.result.seats = append([]string(nil), t.game.TurnOrder...)
+	return nil
+}
+
+// startRound rolls the market for the round
+func (t *table) startRound() {
+	t.result.rounds++
+	market := t.rules.Market
+	if market.CrashOn == 0 && market.BullOn == 0 {
+		return
+	}
+
+	condition := models.MarketConditionNormal
+	switch t.rng.Intn(6) + 1 {
+	case market.CrashOn:
+		condition = models.MarketConditionCrash
+		for i := range t.game.Players {
+			if player := &t.game.Players[i]; player.Status == models.PlayerStatusActive {
+				player.Balance -= player.Balance * market.CrashLoss / 100
+			}
+		}
+	case market.BullOn:
+		condition = models.MarketConditionBull
+	}
+	t.game.MarketCondition = condition
+	t.game.MarketConditionRemainingTurns = len(t.game.TurnOrder)
+	t.dirty = true
+}
+
+// takeTurn plays the current player's bot through its turn, settling what the game
+// manager leaves to clients
+func (t *table) takeTurn(playerID string) error {
+	player := t.player(playerID)
+	for _, action := range bots.TakeTurn(t.played[playerID], t.game, playerID, t.rng) {
+		switch action.Type {
+		case models.ActionTypePayRent:
+			property := t.property(action)
+			if err := t.gm.ProcessGameAction(action); err != nil {
+				// The manager only refuses rent the player can't afford
+				t.bankrupt(player, t.player(property.OwnerID), "rent", property.Name)
+				return t.save()
+			}
+		case models.ActionTypeRollDice:
+			from, jailed := player.Position, player.InJail
+			if err := t.gm.ProcessGameAction(action); err != nil {
+				return fmt.Errorf("%s failed to roll: %w", playerID, err)
+			}
+			t.moved(player, from, jailed)
+		default:
+			// Purchases the strategy can't make are just skipped
+			_ = t.gm.ProcessGameAction(action)
+		}
+	}
+	return t.save()
+}
+
+// moved settles a roll: START money, the landing, and a card if the square has one
+func (t *table) moved(player *models.Player, from int, jailed bool) {
+	switch {
+	case jailed && player.InJail:
+		return // Didn't get out
+	case player.InJail:
+		t.result.landings[goToJailPosition]++
+		return
+	}
+	if player.Position < from {
+		player.Balance += t.rules.PassStart
+		t.dirty = true
+	}
+	t.result.landings[player.Position]++
+	if deck, ok := t.rules.deckAt(player.Position); ok {
+		t.drawCard(player, deck)
+	}
+}
+
+// charge moves money from payer to payee, or to the bank when payee is nil. A payer
+// who can't cover it pays what they have and goes bankrupt.
+func (t *table) charge(payer, payee *models.Player, amount int, cause, source string) {
+	if amount <= 0 || payer.Status != models.PlayerStatusActive {
+		return
+	}
+	if payer.Balance < amount {
+		t.bankrupt(payer, payee, cause, source)
+		return
+	}
+	payer.Balance -= amount
+	if payee != nil {
+		payee.Balance += amount
+	}
+	t.dirty = true
+}
+
+// bankrupt takes a player out of the game. Their cash goes to the creditor, if any,
+// and their properties go back to the bank.
+func (t *table) bankrupt(player, creditor *models.Player, cause, source string) {
+	if creditor != nil {
+		creditor.Balance += player.Balance
+	}
+	player.Balance = 0
+	player.NetWorth = 0
+	player.Status = models.PlayerStatusBankrupt
+	for i := range t.game.BoardState.Properties {
+		if property := &t.game.BoardState.Properties[i]; property.OwnerID == player.ID {
+			property.OwnerID = ""
+		}
+	}
+	player.Properties = nil
+
+	order := t.game.TurnOrder
+	for i, id := range order {
+		if id != player.ID {
+			continue
+		}
+		if t.game.CurrentTurn == player.ID {
+			t.game.CurrentTurn = order[(i+1)%len(order)]
+		}
+		t.game.TurnOrder = append(order[:i:i], order[i+1:]...)
+		break
+	}
+
+	t.result.bankruptcies = append(t.result.bankruptcies, bankruptcy{cause: cause, source: source})
+	t.dirty = true
+}
+
+// save hands the simulator's changes to the game manager
+func (t *table) save() error {
+	if !t.dirty {
+		return nil
+	}
+	t.dirty = false
+	return t.gm.UpdateGame(t.game)
+}
+
+// finish records how the game ended and who won
+func (t *table) finish() {
+	t.result.ending = EndingTurnLimit
+	if t.active() <= 1 {
+		t.result.ending = EndingBankruptcy
+	}
+
+	best := -1
+	for _, id := range t.game.TurnOrder {
+		if worth := t.netWorth(t.player(id)); worth > best {
+			best = worth
+			t.result.winner = id
+		}
+	}
+}
+
+// netWorth is a player's cash plus what their properties cost
+func (t *table) netWorth(player *models.Player) int {
+	worth := player.Balance
+	for _, property := range t.game.BoardState.Properties {
+		if property.OwnerID == player.ID {
+			worth += property.Price
+		}
+	}
+	return worth
+}
+
+// active counts the players still in the game
+func (t *table) active() int {
+	return len(t.game.TurnOrder)
+}
+
+func (t *table) player(id string) *models.Player {
+	for i := range t.game.Players {
+		if t.game.Players[i].ID == id {
+			return &t.game.Players[i]
+		}
+	}
+	return nil
+}
+
+// property returns the property a bot's action is about
+func (t *table) property(action models.GameAction) *models.Property {
+	id, _ := action.Payload.(map[string]interface{})["propertyId"].(string)
+	for i := range t.game.BoardState.Properties {
+		if t.game.BoardState.Properties[i].ID == id {
+			return &t.game.BoardState.Properties[i]
+		}
+	}
+	return nil
+}
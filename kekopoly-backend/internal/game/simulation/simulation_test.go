@@ -0,0 +1,156 @@
+package simulation
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kekopoly/backend/internal/game/models"
+)
+
+func TestRunIsRepeatable(t *testing.T) {
+	rules, err := DefaultRuleset()
+	require.NoError(t, err)
+	rules.MaxTurns = 300
+
+	cfg := Config{Games: 12, Players: 3, Seed: 42, Strategies: []string{"greedy", "random"}, Workers: 1}
+	report, err := Run(context.Background(), rules, cfg)
+	require.NoError(t, err)
+
+	// The same seed plays the same games, however many run at once
+	cfg.Workers = 4
+	again, err := Run(context.Background(), rules, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, report, again)
+
+	assert.Equal(t, 12, report.Endings[EndingBankruptcy]+report.Endings[EndingTurnLimit])
+	assert.LessOrEqual(t, report.Turns.Max, 300)
+	require.Len(t, report.Seats, 3)
+	wins := 0
+	for _, seat := range report.Seats {
+		assert.Equal(t, 12, seat.Played)
+		wins += seat.Wins
+	}
+	assert.Equal(t, 12, wins)
+
+	// Strategies alternate between players, so greedy plays two seats in three
+	require.Len(t, report.Strategies, 2)
+	assert.Equal(t, "greedy", report.Strategies[0].Strategy)
+	assert.Equal(t, 24, report.Strategies[0].Played)
+	assert.Equal(t, 12, report.Strategies[1].Played)
+
+	require.Len(t, report.Landings, boardSize)
+	assert.Equal(t, "COLMER CORNER", report.Landings[1].Name)
+	assert.NotZero(t, report.Landings[goToJailPosition].Count)
+	assert.NotEmpty(t, report.Cards)
+
+	var out bytes.Buffer
+	require.NoError(t, report.WriteCSV(&out))
+	rows, err := csv.NewReader(&out).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"section", "key", "metric", "value"}, rows[0])
+	assert.Contains(t, rows, []string{"seat", "1", "played", "12"})
+
+	_, err = Run(context.Background(), rules, Config{Games: 1, Players: 2, Strategies: []string{"cheater"}})
+	assert.Error(t, err)
+}
+
+func TestParseRuleset(t *testing.T) {
+	for name, rules := range map[string]string{
+		"no balance":       `{"maxTurns": 10}`,
+		"reserved square":  `{"startingBalance": 1, "maxTurns": 10, "properties": [{"id": "a", "position": 25, "price": 1}]}`,
+		"shared square":    `{"startingBalance": 1, "maxTurns": 10, "properties": [{"id": "a", "position": 3, "price": 1}, {"id": "b", "position": 3, "price": 1}]}`,
+		"empty deck":       `{"startingBalance": 1, "maxTurns": 10, "cardSquares": [{"position": 2, "deck": "MEME"}]}`,
+		"unknown effect":   `{"startingBalance": 1, "maxTurns": 10, "cards": [{"name": "x", "deck": "MEME", "rarity": "RARE", "effects": [{"kind": "win"}]}]}`,
+		"unweighted card":  `{"startingBalance": 1, "maxTurns": 10, "cards": [{"name": "x", "deck": "MEME", "effects": [{"kind": "jail"}]}]}`,
+		"off board":        `{"startingBalance": 1, "maxTurns": 10, "properties": [{"id": "a", "position": 40, "price": 1}]}`,
+		"impossible crash": `{"startingBalance": 1, "maxTurns": 10, "market": {"crashOn": 7}}`,
+	} {
+		_, err := ParseRuleset([]byte(rules))
+		assert.Error(t, err, name)
+	}
+}
+
+// cardTable seats three players for card effects, with player-1 holding a property
+func cardTable() *table {
+	return &table{
+		rules: &Ruleset{PassStart: 100},
+		rng:   rand.New(rand.NewSource(1)),
+		game: &models.Game{
+			CurrentTurn: "player-2",
+			TurnOrder:   []string{"player-1", "player-2", "player-3"},
+			Players: []models.Player{
+				{ID: "player-1", Status: models.PlayerStatusActive, Balance: 500, Position: 10, Properties: []string{"a"}},
+				{ID: "player-2", Status: models.PlayerStatusActive, Balance: 30},
+				{ID: "player-3", Status: models.PlayerStatusActive, Balance: 1000},
+			},
+			BoardState: models.BoardState{Properties: []models.Property{{ID: "a", OwnerID: "player-1", Price: 100}}},
+		},
+		result: &gameResult{landings: make(map[int]int)},
+	}
+}
+
+func TestCardEffects(t *testing.T) {
+	card := CardSpec{Name: "Based"}
+
+	// Anyone who can't pay goes bankrupt, handing over what they have and the turn
+	tbl := cardTable()
+	p1, p2, p3 := tbl.player("player-1"), tbl.player("player-2"), tbl.player("player-3")
+	tbl.apply(p1, card, Effect{Kind: EffectCollectEach, Amount: 50})
+	assert.Equal(t, 580, p1.Balance)
+	assert.Equal(t, 950, p3.Balance)
+	assert.Equal(t, models.PlayerStatusBankrupt, p2.Status)
+	assert.Equal(t, []string{"player-1", "player-3"}, tbl.game.TurnOrder)
+	assert.Equal(t, "player-3", tbl.game.CurrentTurn)
+	assert.Equal(t, []bankruptcy{{cause: "card", source: "Based"}}, tbl.result.bankruptcies)
+
+	// Bankrupt players' properties go back to the bank
+	tbl = cardTable()
+	p1, p2 = tbl.player("player-1"), tbl.player("player-2")
+	tbl.apply(p2, card, Effect{Kind: EffectCollectPrevious, Amount: 600})
+	assert.Equal(t, models.PlayerStatusBankrupt, p1.Status)
+	assert.Empty(t, p1.Properties)
+	assert.Empty(t, tbl.game.BoardState.Properties[0].OwnerID)
+	assert.Equal(t, 530, p2.Balance)
+
+	tbl = cardTable()
+	p1, p2, p3 = tbl.player("player-1"), tbl.player("player-2"), tbl.player("player-3")
+	tbl.apply(p1, card, Effect{Kind: EffectPayPoorest, Amount: 10})
+	assert.Equal(t, 450, p1.Balance)
+	assert.Equal(t, 80, p2.Balance)
+	tbl.apply(p3, card, Effect{Kind: EffectPayNext, Amount: 100})
+	assert.Equal(t, 550, p1.Balance, "the next player wraps around the table")
+	tbl.apply(p1, card, Effect{Kind: EffectPerProperty, Amount: 25})
+	assert.Equal(t, 575, p1.Balance)
+
+	tbl.apply(p1, card, Effect{Kind: EffectBack, Amount: 12})
+	assert.Equal(t, 38, p1.Position)
+	tbl.apply(p1, card, Effect{Kind: EffectJail})
+	assert.True(t, p1.InJail)
+	assert.Equal(t, jailPosition, p1.Position)
+
+	tbl.apply(p1, card, Effect{Kind: EffectAllLosePercent, Amount: 20})
+	assert.Equal(t, 460, p1.Balance)
+	assert.Equal(t, 64, p2.Balance)
+}
+
+func TestPick(t *testing.T) {
+	tbl := cardTable()
+	tbl.rules.Cards = []CardSpec{
+		{Name: "common", Deck: models.CardTypeMeme, Rarity: models.CardRarityCommon},
+		{Name: "legendary", Deck: models.CardTypeMeme, Rarity: models.CardRarityLegendary},
+		{Name: "other deck", Deck: models.CardTypeRedpill, Rarity: models.CardRarityCommon},
+	}
+	drawn := make(map[string]int)
+	for i := 0; i < 7000; i++ {
+		drawn[tbl.pick(models.CardTypeMeme).Name]++
+	}
+	assert.Zero(t, drawn["other deck"])
+	assert.InDelta(t, 6000, drawn["common"], 300)
+	assert.InDelta(t, 1000, drawn["legendary"], 300)
+}
@@ -0,0 +1,196 @@
+// Package snapshot saves a game, with its recent events and transactions, to a
+// versioned JSON archive. Archives are attached to bug reports, imported to recreate a
+// game, and used as fixtures in tests.
+package snapshot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/kekopoly/backend/internal/game/models"
+)
+
+// Version is the archive format this build writes. Archives from newer builds are refused.
+const Version = 1
+
+var (
+	// ErrUnsupportedVersion is returned for an archive with no version or a newer one
+	ErrUnsupportedVersion = errors.New("unsupported archive version")
+	// ErrInvalidArchive is returned for an archive whose game doesn't hang together
+	ErrInvalidArchive = errors.New("invalid archive")
+)
+
+// Archive is a saved game
+type Archive struct {
+	Version      int                  `json:"version"`
+	ExportedAt   time.Time            `json:"exportedAt"`
+	Game         models.Game          `json:"game"`
+	Events       []Event              `json:"events,omitempty"`       // The game's broadcasts still kept, oldest first
+	Transactions []models.Transaction `json:"transactions,omitempty"` // Deposits, refunds and payouts
+}
+
+// Event is a game broadcast as clients received it
+type Event struct {
+	Seq     int64           `json:"seq"`
+	Exclude string          `json:"exclude,omitempty"` // Player the broadcast wasn't sent to
+	Message json.RawMessage `json:"message"`
+}
+
+// New archives a game. The game's password hash is never included.
+func New(game *models.Game, events []Event, transactions []models.Transaction) *Archive {
+	saved := *game
+	saved.PasswordHash = ""
+	return &Archive{
+		Version:      Version,
+		ExportedAt:   time.Now().UTC(),
+		Game:         saved,
+		Events:       events,
+		Transactions: transactions,
+	}
+}
+
+// Read decodes and checks an archive
+func Read(r io.Reader) (*Archive, error) {
+	var archive Archive
+	if err := json.NewDecoder(r).Decode(&archive); err != nil {
+		return nil, fmt.Errorf("failed to decode archive: %w", err)
+	}
+	if err := archive.Validate(); err != nil {
+		return nil, err
+	}
+	return &archive, nil
+}
+
+// Load reads an archive from a file
+func Load(path string) (*Archive, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer file.Close()
+	return Read(file)
+}
+
+// Write encodes the archive as indented JSON
+func (a *Archive) Write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(a)
+}
+
+// Validate checks the archive can be read by this build and that everyone its game
+// refers to is a player in it
+func (a *Archive) Validate() error {
+	if a.Version < 1 || a.Version > Version {
+		return fmt.Errorf("%w %d; this server reads up to version %d", ErrUnsupportedVersion, a.Version, Version)
+	}
+
+	game := &a.Game
+	if len(game.Players) == 0 {
+		return fmt.Errorf("%w: the game has no players", ErrInvalidArchive)
+	}
+	players := make(map[string]bool)
+	for _, player := range game.Players {
+		if player.ID == "" || players[player.ID] {
+			return fmt.Errorf("%w: player IDs must be unique and not empty", ErrInvalidArchive)
+		}
+		players[player.ID] = true
+	}
+	if !players[game.HostID] {
+		return fmt.Errorf("%w: host %q is not a player", ErrInvalidArchive, game.HostID)
+	}
+	if game.CurrentTurn != "" && !players[game.CurrentTurn] {
+		return fmt.Errorf("%w: current turn %q is not a player", ErrInvalidArchive, game.CurrentTurn)
+	}
+	for _, id := range game.TurnOrder {
+		if !players[id] {
+			return fmt.Errorf("%w: %q in the turn order is not a player", ErrInvalidArchive, id)
+		}
+	}
+	return nil
+}
+
+// RenamePlayers gives players new IDs throughout the archive, so an imported game can be
+// played from other accounts. names maps old IDs to new ones; players left out keep theirs.
+func (a *Archive) RenamePlayers(names map[string]string) error {
+	if len(names) == 0 {
+		return nil
+	}
+	game := &a.Game
+
+	renamed := make(map[string]bool)
+	for _, player := range game.Players {
+		id := player.ID
+		if name, ok := names[id]; ok {
+			id = name
+		}
+		if id == "" || renamed[id] {
+			return fmt.Errorf("%w: renaming would give two players the ID %q", ErrInvalidArchive, id)
+		}
+		renamed[id] = true
+	}
+	for old := range names {
+		if findPlayer(game, old) == nil {
+			return fmt.Errorf("%w: %q is not a player", ErrInvalidArchive, old)
+		}
+	}
+
+	rename := func(id *string) {
+		if name, ok := names[*id]; ok {
+			*id = name
+		}
+	}
+	renameAll := func(ids []string) {
+		for i := range ids {
+			rename(&ids[i])
+		}
+	}
+
+	for i := range game.Players {
+		player := &game.Players[i]
+		if player.UserID == player.ID {
+			rename(&player.UserID)
+		}
+		rename(&player.ID)
+	}
+	rename(&game.HostID)
+	rename(&game.CurrentTurn)
+	rename(&game.WinnerID)
+	renameAll(game.TurnOrder)
+	renameAll(game.BannedPlayers)
+	renameAll(game.PauseVotes)
+	if game.Pause != nil {
+		renameAll(game.Pause.ResumeVotes)
+		if game.Pause.PausedBy != "vote" {
+			rename(&game.Pause.PausedBy)
+		}
+	}
+	for i := range game.BoardState.Properties {
+		property := &game.BoardState.Properties[i]
+		rename(&property.OwnerID)
+		for j := range property.SpecialEffects {
+			rename(&property.SpecialEffects[j].AppliedBy)
+		}
+	}
+	for i := range a.Transactions {
+		rename(&a.Transactions[i].FromPlayerID)
+		rename(&a.Transactions[i].ToPlayerID)
+	}
+	for i := range a.Events {
+		rename(&a.Events[i].Exclude)
+	}
+	return nil
+}
+
+func findPlayer(game *models.Game, playerID string) *models.Player {
+	for i := range game.Players {
+		if game.Players[i].ID == playerID {
+			return &game.Players[i]
+		}
+	}
+	return nil
+}
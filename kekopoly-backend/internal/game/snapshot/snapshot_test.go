@@ -0,0 +1,78 @@
+package snapshot
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kekopoly/backend/internal/game/models"
+)
+
+func testArchive() *Archive {
+	game := &models.Game{
+		Name:         "Friday night",
+		Status:       models.GameStatusActive,
+		HostID:       "alice",
+		CurrentTurn:  "brian",
+		TurnOrder:    []string{"alice", "brian"},
+		PasswordHash: "$2a$10$secret",
+		Players: []models.Player{
+			{ID: "alice", UserID: "alice", Balance: 1500},
+			{ID: "brian", UserID: "brian", Balance: 900},
+		},
+		BoardState: models.BoardState{Properties: []models.Property{{ID: "meme-1", OwnerID: "brian"}}},
+		CreatedAt:  time.Date(2026, 10, 18, 11, 0, 0, 0, time.UTC),
+	}
+	archive := New(game, []Event{{Seq: 1, Exclude: "alice", Message: []byte(`{"seq":1,"type":"turn_changed"}`)}},
+		[]models.Transaction{{ID: "tx-1", FromPlayerID: "brian", Amount: 100}})
+	archive.ExportedAt = time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	return archive
+}
+
+func TestArchiveRoundTrip(t *testing.T) {
+	archive := testArchive()
+	assert.Empty(t, archive.Game.PasswordHash, "password hashes are never saved")
+
+	var out bytes.Buffer
+	require.NoError(t, archive.Write(&out))
+	read, err := Read(&out)
+	require.NoError(t, err)
+	// Messages come back indented along with the rest of the archive
+	require.Len(t, read.Events, 1)
+	assert.JSONEq(t, string(archive.Events[0].Message), string(read.Events[0].Message))
+	read.Events[0].Message = archive.Events[0].Message
+	assert.Equal(t, archive, read)
+
+	for name, body := range map[string]string{
+		"no version":    `{"game": {}}`,
+		"newer version": `{"version": 2, "game": {}}`,
+	} {
+		_, err := Read(strings.NewReader(body))
+		assert.ErrorIs(t, err, ErrUnsupportedVersion, name)
+	}
+
+	archive.Game.HostID = "carol"
+	assert.ErrorIs(t, archive.Validate(), ErrInvalidArchive)
+}
+
+func TestRenamePlayers(t *testing.T) {
+	archive := testArchive()
+	require.NoError(t, archive.RenamePlayers(map[string]string{"brian": "tester-2"}))
+
+	game := archive.Game
+	assert.Equal(t, "tester-2", game.Players[1].ID)
+	assert.Equal(t, "tester-2", game.Players[1].UserID)
+	assert.Equal(t, "tester-2", game.CurrentTurn)
+	assert.Equal(t, []string{"alice", "tester-2"}, game.TurnOrder)
+	assert.Equal(t, "tester-2", game.BoardState.Properties[0].OwnerID)
+	assert.Equal(t, "tester-2", archive.Transactions[0].FromPlayerID)
+	assert.Equal(t, "alice", game.HostID)
+	require.NoError(t, archive.Validate())
+
+	assert.ErrorIs(t, testArchive().RenamePlayers(map[string]string{"brian": "alice"}), ErrInvalidArchive)
+	assert.ErrorIs(t, testArchive().RenamePlayers(map[string]string{"carol": "tester-3"}), ErrInvalidArchive)
+}
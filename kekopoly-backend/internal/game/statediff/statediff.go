@@ -0,0 +1,249 @@
+// Package statediff computes JSON Patch (RFC 6902) deltas between game snapshots.
+//
+// Snapshots are the generic JSON form of a value (maps, slices, float64, string,
+// bool and nil), as produced by Normalize. Diff compares two snapshots and Apply
+// replays a patch, which clients do to keep their copy of the game current.
+package statediff
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Patch operations
+const (
+	OpAdd     = "add"
+	OpRemove  = "remove"
+	OpReplace = "replace"
+)
+
+// ErrInvalidPath is returned when a patch refers to a location the document doesn't have
+var ErrInvalidPath = errors.New("invalid patch path")
+
+// Operation is one JSON Patch operation
+type Operation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"` // Set for add and replace, even when null
+}
+
+// MarshalJSON keeps "value" on add and replace operations whose value is empty
+func (o Operation) MarshalJSON() ([]byte, error) {
+	if o.Op == OpRemove {
+		return json.Marshal(struct {
+			Op   string `json:"op"`
+			Path string `json:"path"`
+		}{o.Op, o.Path})
+	}
+	return json.Marshal(struct {
+		Op    string      `json:"op"`
+		Path  string      `json:"path"`
+		Value interface{} `json:"value"`
+	}{o.Op, o.Path, o.Value})
+}
+
+// Patch is a list of operations applied in order
+type Patch []Operation
+
+// Normalize converts a value to its snapshot form
+func Normalize(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot: %w", err)
+	}
+	return doc, nil
+}
+
+// Diff returns the operations that turn snapshot from into snapshot to. Array
+// elements are compared by index, which suits the game's players and board, whose
+// order doesn't change.
+func Diff(from, to interface{}) Patch {
+	var patch Patch
+	diff("", from, to, &patch)
+	return patch
+}
+
+func diff(path string, from, to interface{}, patch *Patch) {
+	switch a := from.(type) {
+	case map[string]interface{}:
+		b, ok := to.(map[string]interface{})
+		if !ok {
+			*patch = append(*patch, Operation{Op: OpReplace, Path: path, Value: to})
+			return
+		}
+		for _, key := range sortedKeys(a) {
+			if _, kept := b[key]; !kept {
+				*patch = append(*patch, Operation{Op: OpRemove, Path: path + "/" + escape(key)})
+			}
+		}
+		for _, key := range sortedKeys(b) {
+			if old, existed := a[key]; existed {
+				diff(path+"/"+escape(key), old, b[key], patch)
+			} else {
+				*patch = append(*patch, Operation{Op: OpAdd, Path: path + "/" + escape(key), Value: b[key]})
+			}
+		}
+	case []interface{}:
+		b, ok := to.([]interface{})
+		if !ok {
+			*patch = append(*patch, Operation{Op: OpReplace, Path: path, Value: to})
+			return
+		}
+		shared := len(a)
+		if len(b) < shared {
+			shared = len(b)
+		}
+		for i := 0; i < shared; i++ {
+			diff(path+"/"+strconv.Itoa(i), a[i], b[i], patch)
+		}
+		// Remove from the end so the earlier indexes stay valid
+		for i := len(a) - 1; i >= len(b); i-- {
+			*patch = append(*patch, Operation{Op: OpRemove, Path: path + "/" + strconv.Itoa(i)})
+		}
+		for i := len(a); i < len(b); i++ {
+			*patch = append(*patch, Operation{Op: OpAdd, Path: path + "/" + strconv.Itoa(i), Value: b[i]})
+		}
+	default:
+		switch to.(type) {
+		case map[string]interface{}, []interface{}:
+			*patch = append(*patch, Operation{Op: OpReplace, Path: path, Value: to})
+		default:
+			if from != to {
+				*patch = append(*patch, Operation{Op: OpReplace, Path: path, Value: to})
+			}
+		}
+	}
+}
+
+// sortedKeys keeps patches deterministic
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// escape encodes a key as a JSON Pointer reference token
+func escape(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
+
+// unescape decodes a JSON Pointer reference token
+func unescape(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+}
+
+// Apply returns the snapshot with the patch applied. The snapshot passed in may be
+// modified.
+func Apply(doc interface{}, patch Patch) (interface{}, error) {
+	for _, op := range patch {
+		var err error
+		doc, err = apply(doc, op)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return doc, nil
+}
+
+func apply(doc interface{}, op Operation) (interface{}, error) {
+	if op.Path == "" {
+		if op.Op == OpRemove {
+			return nil, nil
+		}
+		return op.Value, nil
+	}
+	if !strings.HasPrefix(op.Path, "/") {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidPath, op.Path)
+	}
+	tokens := strings.Split(op.Path[1:], "/")
+	for i := range tokens {
+		tokens[i] = unescape(tokens[i])
+	}
+	return applyAt(doc, tokens, op)
+}
+
+// applyAt walks down the path and applies the operation at its last token
+func applyAt(node interface{}, tokens []string, op Operation) (interface{}, error) {
+	token, last := tokens[0], len(tokens) == 1
+
+	switch container := node.(type) {
+	case map[string]interface{}:
+		child, exists := container[token]
+		if !last {
+			if !exists {
+				return nil, fmt.Errorf("%w: %q", ErrInvalidPath, op.Path)
+			}
+			updated, err := applyAt(child, tokens[1:], op)
+			if err != nil {
+				return nil, err
+			}
+			container[token] = updated
+			return container, nil
+		}
+		switch op.Op {
+		case OpAdd:
+			container[token] = op.Value
+		case OpReplace:
+			if !exists {
+				return nil, fmt.Errorf("%w: %q", ErrInvalidPath, op.Path)
+			}
+			container[token] = op.Value
+		case OpRemove:
+			if !exists {
+				return nil, fmt.Errorf("%w: %q", ErrInvalidPath, op.Path)
+			}
+			delete(container, token)
+		default:
+			return nil, fmt.Errorf("unsupported patch operation %q", op.Op)
+		}
+		return container, nil
+
+	case []interface{}:
+		if last && op.Op == OpAdd && token == "-" {
+			return append(container, op.Value), nil
+		}
+		index, err := strconv.Atoi(token)
+		limit := len(container)
+		if last && op.Op == OpAdd {
+			limit++ // Adding may append
+		}
+		if err != nil || index < 0 || index >= limit {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidPath, op.Path)
+		}
+		if !last {
+			updated, err := applyAt(container[index], tokens[1:], op)
+			if err != nil {
+				return nil, err
+			}
+			container[index] = updated
+			return container, nil
+		}
+		switch op.Op {
+		case OpAdd:
+			container = append(container, nil)
+			copy(container[index+1:], container[index:])
+			container[index] = op.Value
+		case OpReplace:
+			container[index] = op.Value
+		case OpRemove:
+			container = append(container[:index], container[index+1:]...)
+		default:
+			return nil, fmt.Errorf("unsupported patch operation %q", op.Op)
+		}
+		return container, nil
+
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrInvalidPath, op.Path)
+	}
+}
@@ -0,0 +1,230 @@
+package statediff
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kekopoly/backend/internal/game/models"
+)
+
+// decode parses a JSON document into snapshot form
+func decode(t testing.TB, doc string) interface{} {
+	t.Helper()
+	var v interface{}
+	require.NoError(t, json.Unmarshal([]byte(doc), &v))
+	return v
+}
+
+func TestDiffRoundTrips(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to string
+		patch    string
+	}{
+		{"unchanged", `{"a":1,"b":[1,2]}`, `{"a":1,"b":[1,2]}`, `null`},
+		{"replace scalar", `{"a":1}`, `{"a":2}`, `[{"op":"replace","path":"/a","value":2}]`},
+		{"add and remove keys", `{"a":1,"b":2}`, `{"b":2,"c":3}`, `[{"op":"remove","path":"/a"},{"op":"add","path":"/c","value":3}]`},
+		{"null and false keep their value", `{"a":1,"b":true}`, `{"a":null,"b":false}`, `[{"op":"replace","path":"/a","value":null},{"op":"replace","path":"/b","value":false}]`},
+		{"array grows", `{"p":[1]}`, `{"p":[1,2,3]}`, `[{"op":"add","path":"/p/1","value":2},{"op":"add","path":"/p/2","value":3}]`},
+		{"array shrinks from the end", `{"p":[1,2,3]}`, `{"p":[1]}`, `[{"op":"remove","path":"/p/2"},{"op":"remove","path":"/p/1"}]`},
+		{"nested change", `{"players":[{"id":"a","balance":1500}]}`, `{"players":[{"id":"a","balance":1300}]}`, `[{"op":"replace","path":"/players/0/balance","value":1300}]`},
+		{"type change", `{"a":{"b":1}}`, `{"a":[1]}`, `[{"op":"replace","path":"/a","value":[1]}]`},
+		{"escaped keys", `{"a/b":1,"c~d":1}`, `{"a/b":2,"c~d":2}`, `[{"op":"replace","path":"/a~1b","value":2},{"op":"replace","path":"/c~0d","value":2}]`},
+		{"whole document", `1`, `{"a":1}`, `[{"op":"replace","path":"","value":{"a":1}}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch := Diff(decode(t, tt.from), decode(t, tt.to))
+
+			encoded, err := json.Marshal(patch)
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.patch, string(encoded))
+
+			applied, err := Apply(decode(t, tt.from), patch)
+			require.NoError(t, err)
+			assert.Equal(t, decode(t, tt.to), applied)
+		})
+	}
+}
+
+func TestApplyRejectsBadPaths(t *testing.T) {
+	doc := `{"a":{"b":[1,2]}}`
+	for _, op := range []Operation{
+		{Op: OpReplace, Path: "/missing", Value: 1},
+		{Op: OpRemove, Path: "/a/missing"},
+		{Op: OpReplace, Path: "/a/b/5", Value: 1},
+		{Op: OpAdd, Path: "/a/b/x", Value: 1},
+		{Op: OpAdd, Path: "/a/missing/c", Value: 1},
+		{Op: OpReplace, Path: "no-slash", Value: 1},
+	} {
+		_, err := Apply(decode(t, doc), Patch{op})
+		assert.ErrorIs(t, err, ErrInvalidPath, "%s %s", op.Op, op.Path)
+	}
+
+	applied, err := Apply(decode(t, doc), Patch{{Op: OpAdd, Path: "/a/b/-", Value: 3.0}, {Op: OpAdd, Path: "/a/b/0", Value: 0.0}})
+	require.NoError(t, err)
+	assert.Equal(t, decode(t, `{"a":{"b":[0,1,2,3]}}`), applied)
+}
+
+// scriptedGame plays a deterministic game and returns the state after each turn
+func scriptedGame(turns int) []*models.Game {
+	rng := rand.New(rand.NewSource(42))
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	game := &models.Game{
+		Name:       "Benchmark",
+		Status:     models.GameStatusActive,
+		CreatedAt:  now,
+		MaxPlayers: 4,
+		BoardState: models.BoardState{CardsRemaining: models.CardCount{Meme: 16, Redpill: 16, Eegi: 16}},
+	}
+	for i := 0; i < 4; i++ {
+		id := fmt.Sprintf("player-%d", i)
+		game.Players = append(game.Players, models.Player{
+			ID:            id,
+			WalletAddress: fmt.Sprintf("wallet-%d", i),
+			Balance:       1500,
+			Status:        models.PlayerStatusActive,
+			Properties:    []string{},
+			Cards:         []models.Card{},
+		})
+		game.TurnOrder = append(game.TurnOrder, id)
+	}
+	for i := 0; i < 28; i++ {
+		game.BoardState.Properties = append(game.BoardState.Properties, models.Property{
+			ID:       fmt.Sprintf("property-%d", i),
+			Name:     fmt.Sprintf("Property %d", i),
+			Group:    fmt.Sprintf("group-%d", i/4),
+			Position: i + 1,
+			Price:    100 + 20*i,
+			RentBase: 10 + 2*i,
+		})
+	}
+
+	states := make([]*models.Game, 0, turns+1)
+	snapshot := func() {
+		copied := *game
+		copied.Players = append([]models.Player(nil), game.Players...)
+		for i := range copied.Players {
+			copied.Players[i].Properties = append([]string(nil), game.Players[i].Properties...)
+			copied.Players[i].Cards = append([]models.Card(nil), game.Players[i].Cards...)
+		}
+		copied.BoardState.Properties = append([]models.Property(nil), game.BoardState.Properties...)
+		states = append(states, &copied)
+	}
+	snapshot()
+
+	for turn := 0; turn < turns; turn++ {
+		player := &game.Players[turn%len(game.Players)]
+		game.CurrentTurn = player.ID
+		player.Position = (player.Position + 2 + rng.Intn(11)) % 40
+		game.UpdatedAt = now.Add(time.Duration(turn) * time.Minute)
+		game.LastActivity = game.UpdatedAt
+
+		switch rng.Intn(3) {
+		case 0: // Buy an unowned property
+			property := &game.BoardState.Properties[rng.Intn(len(game.BoardState.Properties))]
+			if property.OwnerID == "" && player.Balance >= property.Price {
+				property.OwnerID = player.ID
+				player.Balance -= property.Price
+				player.Properties = append(player.Properties, property.ID)
+			}
+		case 1: // Pay rent to someone
+			owner := &game.Players[rng.Intn(len(game.Players))]
+			player.Balance -= 50
+			owner.Balance += 50
+		case 2: // Draw a card
+			game.BoardState.CardsRemaining.Meme--
+			player.Cards = append(player.Cards, models.Card{
+				ID:   fmt.Sprintf("card-%d", turn),
+				Name: "Meme card",
+				Type: models.CardType("MEME"),
+			})
+		}
+		snapshot()
+	}
+	return states
+}
+
+// bytesPerGame returns the bytes sent for the whole scripted game as full states
+// and as deltas against the previous state
+func bytesPerGame(tb testing.TB, states []*models.Game) (full, delta int) {
+	var previous interface{}
+	for _, state := range states {
+		doc, err := Normalize(state)
+		require.NoError(tb, err)
+
+		encoded, err := json.Marshal(doc)
+		require.NoError(tb, err)
+		full += len(encoded)
+
+		if previous == nil {
+			delta += len(encoded) // The first update is always a full state
+		} else {
+			patch, err := json.Marshal(Diff(previous, doc))
+			require.NoError(tb, err)
+			delta += len(patch)
+		}
+		previous = doc
+	}
+	return full, delta
+}
+
+func TestScriptedGameDeltasRebuildEveryState(t *testing.T) {
+	states := scriptedGame(50)
+
+	current, err := Normalize(states[0])
+	require.NoError(t, err)
+	for _, state := range states[1:] {
+		next, err := Normalize(state)
+		require.NoError(t, err)
+
+		// Apply to a copy decoded from the wire, as a client would
+		encoded, err := json.Marshal(Diff(current, next))
+		require.NoError(t, err)
+		var patch Patch
+		require.NoError(t, json.Unmarshal(encoded, &patch))
+
+		current, err = Apply(current, patch)
+		require.NoError(t, err)
+		assert.Equal(t, next, current)
+		current, err = Normalize(current)
+		require.NoError(t, err)
+	}
+
+	full, delta := bytesPerGame(t, states)
+	assert.Less(t, delta*5, full, "deltas should be far smaller than full states (full %d, delta %d)", full, delta)
+}
+
+func BenchmarkFullState(b *testing.B) {
+	states := scriptedGame(50)
+	var full int
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		full = 0
+		for _, state := range states {
+			encoded, err := json.Marshal(state)
+			if err != nil {
+				b.Fatal(err)
+			}
+			full += len(encoded)
+		}
+	}
+	b.ReportMetric(float64(full), "bytes/game")
+}
+
+func BenchmarkDeltaState(b *testing.B) {
+	states := scriptedGame(50)
+	var delta int
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, delta = bytesPerGame(b, states)
+	}
+	b.ReportMetric(float64(delta), "bytes/game")
+}
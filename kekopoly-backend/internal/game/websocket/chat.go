@@ -0,0 +1,472 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/kekopoly/backend/internal/game/models"
+	"github.com/kekopoly/backend/internal/game/protocol"
+)
+
+var (
+	// ErrChatRejected is returned by a ChatFilter for a message that can't be posted
+	ErrChatRejected = errors.New("message not allowed")
+	// ErrChatMessageNotFound is returned for a chat message ID that isn't in the history
+	ErrChatMessageNotFound = errors.New("chat message not found")
+)
+
+// ChatFilter checks a chat message before it is posted. It returns the text to post,
+// which may be masked, or an error wrapping ErrChatRejected to refuse it.
+type ChatFilter func(playerID, message string) (string, error)
+
+// linkPattern matches URLs and bare domains such as example.com/path
+var linkPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)\S+|\b[a-z0-9-]+(?:\.[a-z0-9-]+)*\.(?:com|net|org|io|xyz|gg|co|me|app|link|ly)\b\S*`)
+
+// WordFilter masks the blocked words with asterisks, ignoring case, and rejects
+// messages with links when blockLinks is set
+func WordFilter(words []string, blockLinks bool) ChatFilter {
+	var blocked *regexp.Regexp
+	quoted := make([]string, 0, len(words))
+	for _, word := range words {
+		if word = strings.TrimSpace(word); word != "" {
+			quoted = append(quoted, regexp.QuoteMeta(word))
+		}
+	}
+	if len(quoted) > 0 {
+		blocked = regexp.MustCompile(`(?i)\b(?:` + strings.Join(quoted, "|") + `)\b`)
+	}
+
+	return func(playerID, message string) (string, error) {
+		if blockLinks && linkPattern.MatchString(message) {
+			return "", fmt.Errorf("%w: links can't be posted in chat", ErrChatRejected)
+		}
+		if blocked != nil {
+			message = blocked.ReplaceAllStringFunc(message, func(word string) string {
+				return strings.Repeat("*", len(word))
+			})
+		}
+		return message, nil
+	}
+}
+
+// ChatOptions configures a game chat
+type ChatOptions struct {
+	HistorySize int           // Messages kept per game
+	HistoryTTL  time.Duration // How long a quiet game's chat is kept
+	MaxLength   int           // Longest message allowed, in characters
+	RateLimit   int           // Messages a player may post per RateWindow; 0 for no limit
+	RateWindow  time.Duration
+	Filter      ChatFilter // Optional
+}
+
+// chatModeration is the host's restrictions on one player
+type chatModeration struct {
+	MutedUntil time.Time `json:"mutedUntil,omitempty"`
+	Kicked     bool      `json:"kicked,omitempty"`
+}
+
+// Chat keeps each game's chat history and moderation in Redis, so every node sees
+// the same, and limits how fast players on this node can post
+type Chat struct {
+	client *redis.Client
+	opts   ChatOptions
+
+	mu     sync.Mutex
+	recent map[string][]time.Time // Post times by game and player, within the rate window
+}
+
+// NewChat creates a game chat
+func NewChat(client *redis.Client, opts ChatOptions) *Chat {
+	if opts.HistorySize <= 0 {
+		opts.HistorySize = 100
+	}
+	if opts.HistoryTTL < time.Second {
+		opts.HistoryTTL = 24 * time.Hour
+	}
+	if opts.MaxLength <= 0 {
+		opts.MaxLength = 500
+	}
+	if opts.RateWindow <= 0 {
+		opts.RateWindow = 10 * time.Second
+	}
+	return &Chat{client: client, opts: opts, recent: make(map[string][]time.Time)}
+}
+
+// chatKey is the list of a game's recent chat messages, oldest first
+func chatKey(gameID string) string {
+	return fmt.Sprintf("kekopoly:game:%s:chat", strings.ToLower(gameID))
+}
+
+// chatIDKey holds the last chat message ID used in a game
+func chatIDKey(gameID string) string {
+	return fmt.Sprintf("kekopoly:game:%s:chat_id", strings.ToLower(gameID))
+}
+
+// chatModerationKey is the hash of playerID -> chatModeration for a game
+func chatModerationKey(gameID string) string {
+	return fmt.Sprintf("kekopoly:game:%s:chat_moderation", strings.ToLower(gameID))
+}
+
+// allow records a post and reports whether the player is within the rate limit
+func (c *Chat) allow(gameID, playerID string, now time.Time) bool {
+	if c.opts.RateLimit <= 0 {
+		return true
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := strings.ToLower(gameID) + "/" + playerID
+	kept := c.recent[key][:0]
+	for _, sent := range c.recent[key] {
+		if now.Sub(sent) < c.opts.RateWindow {
+			kept = append(kept, sent)
+		}
+	}
+	if len(kept) >= c.opts.RateLimit {
+		c.recent[key] = kept
+		return false
+	}
+	c.recent[key] = append(kept, now)
+	return true
+}
+
+// forget drops a game's rate limit records once it has no clients left on this node
+func (c *Chat) forget(gameID string) {
+	prefix := strings.ToLower(gameID) + "/"
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.recent {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.recent, key)
+		}
+	}
+}
+
+// Append numbers a chat message and adds it to the game's history
+func (c *Chat) Append(ctx context.Context, gameID string, entry protocol.ChatEntry) (protocol.ChatEntry, error) {
+	id, err := c.client.Incr(ctx, chatIDKey(gameID)).Result()
+	if err != nil {
+		return entry, fmt.Errorf("failed to number chat message for game %s: %w", gameID, err)
+	}
+	entry.ID = id
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return entry, fmt.Errorf("failed to marshal chat message: %w", err)
+	}
+	pipe := c.client.TxPipeline()
+	pipe.RPush(ctx, chatKey(gameID), data)
+	pipe.LTrim(ctx, chatKey(gameID), int64(-c.opts.HistorySize), -1)
+	pipe.Expire(ctx, chatKey(gameID), c.opts.HistoryTTL)
+	pipe.Expire(ctx, chatIDKey(gameID), c.opts.HistoryTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return entry, fmt.Errorf("failed to record chat message for game %s: %w", gameID, err)
+	}
+	return entry, nil
+}
+
+// History returns the game's recent chat, oldest first
+func (c *Chat) History(ctx context.Context, gameID string) ([]protocol.ChatEntry, error) {
+	raw, err := c.client.LRange(ctx, chatKey(gameID), 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chat for game %s: %w", gameID, err)
+	}
+	entries := make([]protocol.ChatEntry, 0, len(raw))
+	for _, item := range raw {
+		var entry protocol.ChatEntry
+		if err := json.Unmarshal([]byte(item), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// Message returns a chat message still in the game's history, so game rules such as
+// posting a meme can check the message a player points to
+func (c *Chat) Message(ctx context.Context, gameID string, id int64) (*protocol.ChatEntry, error) {
+	entries, err := c.History(ctx, gameID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range entries {
+		if entries[i].ID == id {
+			return &entries[i], nil
+		}
+	}
+	return nil, ErrChatMessageNotFound
+}
+
+// moderation returns the host's restrictions on a player
+func (c *Chat) moderation(ctx context.Context, gameID, playerID string) (chatModeration, error) {
+	var mod chatModeration
+	raw, err := c.client.HGet(ctx, chatModerationKey(gameID), playerID).Result()
+	if errors.Is(err, redis.Nil) {
+		return mod, nil
+	}
+	if err != nil {
+		return mod, fmt.Errorf("failed to read chat moderation for game %s: %w", gameID, err)
+	}
+	if err := json.Unmarshal([]byte(raw), &mod); err != nil {
+		return mod, fmt.Errorf("failed to decode chat moderation for game %s: %w", gameID, err)
+	}
+	return mod, nil
+}
+
+// kicked returns the players removed from a game's chat
+func (c *Chat) kicked(ctx context.Context, gameID string) (map[string]bool, error) {
+	raw, err := c.client.HGetAll(ctx, chatModerationKey(gameID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chat moderation for game %s: %w", gameID, err)
+	}
+	kicked := make(map[string]bool)
+	for playerID, value := range raw {
+		var mod chatModeration
+		if json.Unmarshal([]byte(value), &mod) == nil && mod.Kicked {
+			kicked[playerID] = true
+		}
+	}
+	return kicked, nil
+}
+
+// setModeration records the host's restrictions on a player
+func (c *Chat) setModeration(ctx context.Context, gameID, playerID string, mod chatModeration) error {
+	data, err := json.Marshal(mod)
+	if err != nil {
+		return fmt.Errorf("failed to marshal chat moderation: %w", err)
+	}
+	pipe := c.client.TxPipeline()
+	pipe.HSet(ctx, chatModerationKey(gameID), playerID, data)
+	pipe.Expire(ctx, chatModerationKey(gameID), c.opts.HistoryTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to record chat moderation for game %s: %w", gameID, err)
+	}
+	return nil
+}
+
+// SetChat enables chat in games
+func (h *Hub) SetChat(chat *Chat) {
+	h.chat = chat
+	h.logger.Info("Chat set for WebSocket hub")
+}
+
+// ChatMessage returns a message from a game's chat history by ID
+func (h *Hub) ChatMessage(gameID string, id int64) (*protocol.ChatEntry, error) {
+	if h.chat == nil {
+		return nil, ErrChatMessageNotFound
+	}
+	ctx, cancel := context.WithTimeout(h.ctx, 2*time.Second)
+	defer cancel()
+	return h.chat.Message(ctx, gameID, id)
+}
+
+// sendToPlayers queues a message for some of a game's players, on whichever node they
+// are connected to
+func (h *Hub) sendToPlayers(gameID string, playerIDs []string, message []byte, priority string) {
+	h.publishRemote(Envelope{GameID: gameID, Data: message, Priority: priority, PlayerIDs: playerIDs})
+	for _, playerID := range playerIDs {
+		h.SendToPlayerWithPriority(gameID, playerID, message, priority)
+	}
+}
+
+// sendChatHistory sends a client the chat it may see, if there is any
+func (h *Hub) sendChatHistory(client *Client, requestID string) error {
+	ctx, cancel := context.WithTimeout(h.ctx, 2*time.Second)
+	defer cancel()
+	if mod, err := h.chat.moderation(ctx, client.gameID, client.playerID); err != nil || mod.Kicked {
+		return err
+	}
+	entries, err := h.chat.History(ctx, client.gameID)
+	if err != nil {
+		return err
+	}
+
+	visible := make([]protocol.ChatEntry, 0, len(entries))
+	for _, entry := range entries {
+		if entry.To == "" || entry.To == client.playerID || entry.PlayerID == client.playerID {
+			visible = append(visible, entry)
+		}
+	}
+	// Nothing to say to a client that connected to a quiet game
+	if len(visible) == 0 && requestID == "" {
+		return nil
+	}
+	h.sendMessage(client.gameID, client.playerID, &protocol.ChatHistory{
+		Header:   protocol.Header{RequestID: requestID},
+		GameID:   client.gameID,
+		Messages: visible,
+	}, PriorityLow)
+	return nil
+}
+
+// chatGame loads the client's game for a chat request
+func (c *Client) chatGame() (*models.Game, error) {
+	if c.hub.chat == nil {
+		return nil, &requestError{code: protocol.CodeActionFailed, message: "Chat is not enabled"}
+	}
+	if c.hub.gameManager == nil {
+		return nil, &requestError{code: protocol.CodeGameNotFound, message: "Failed to get game"}
+	}
+	game, err := c.hub.gameManager.GetGame(c.gameID)
+	if err != nil {
+		return nil, &requestError{code: protocol.CodeGameNotFound, message: fmt.Sprintf("Failed to get game: %v", err)}
+	}
+	return game, nil
+}
+
+// inGame reports whether a player is in the game
+func inGame(game *models.Game, playerID string) bool {
+	for _, player := range game.Players {
+		if player.ID == playerID {
+			return true
+		}
+	}
+	return false
+}
+
+// handleChatMessage posts a chat message or whisper from the client
+func (c *Client) handleChatMessage(req *protocol.ChatMessageRequest) error {
+	game, err := c.chatGame()
+	if err != nil {
+		return err
+	}
+	chat := c.hub.chat
+	if !inGame(game, c.playerID) {
+		return &requestError{code: protocol.CodeActionFailed, message: "Only players can chat"}
+	}
+	if req.To != "" && (req.To == c.playerID || !inGame(game, req.To)) {
+		return &requestError{code: protocol.CodeInvalidMessage, message: "Whispers must go to another player in the game"}
+	}
+
+	text := strings.TrimSpace(req.Message)
+	if len([]rune(text)) > chat.opts.MaxLength {
+		return &requestError{code: protocol.CodeInvalidMessage, message: fmt.Sprintf("Messages can't be longer than %d characters", chat.opts.MaxLength)}
+	}
+
+	ctx, cancel := context.WithTimeout(c.hub.ctx, 2*time.Second)
+	defer cancel()
+	now := time.Now()
+	mod, err := chat.moderation(ctx, c.gameID, c.playerID)
+	if err != nil {
+		return err
+	}
+	if mod.Kicked {
+		return &requestError{code: protocol.CodeMuted, message: "You were removed from the chat"}
+	}
+	if now.Before(mod.MutedUntil) {
+		return &requestError{code: protocol.CodeMuted, message: fmt.Sprintf("You are muted until %s", mod.MutedUntil.Format(time.RFC3339))}
+	}
+	if req.To != "" {
+		recipient, err := chat.moderation(ctx, c.gameID, req.To)
+		if err != nil {
+			return err
+		}
+		if recipient.Kicked {
+			return &requestError{code: protocol.CodeInvalidMessage, message: "That player was removed from the chat"}
+		}
+	}
+	if !chat.allow(c.gameID, c.playerID, now) {
+		return &requestError{code: protocol.CodeRateLimited, message: "You are sending messages too quickly"}
+	}
+	if chat.opts.Filter != nil {
+		if text, err = chat.opts.Filter(c.playerID, text); err != nil {
+			return &requestError{code: protocol.CodeMessageRejected, message: err.Error()}
+		}
+	}
+
+	entry, err := chat.Append(ctx, c.gameID, protocol.ChatEntry{
+		PlayerID: c.playerID,
+		To:       req.To,
+		Message:  text,
+		SentAt:   now.Format(time.RFC3339),
+	})
+	if err != nil {
+		return err
+	}
+	message, err := protocol.Encode(&protocol.ChatMessage{GameID: c.gameID, ChatEntry: entry})
+	if err != nil {
+		return err
+	}
+
+	// Whispers only reach the two players; everything else reaches everyone still in the chat
+	recipients := []string{c.playerID, req.To}
+	if req.To == "" {
+		kicked, err := chat.kicked(ctx, c.gameID)
+		if err != nil {
+			return err
+		}
+		recipients = recipients[:0]
+		for _, player := range game.Players {
+			if !kicked[player.ID] {
+				recipients = append(recipients, player.ID)
+			}
+		}
+	}
+	c.hub.sendToPlayers(c.gameID, recipients, message, PriorityLow)
+	return nil
+}
+
+// handleGetChatHistory sends the client the chat it may see
+func (c *Client) handleGetChatHistory(requestID string) error {
+	if _, err := c.chatGame(); err != nil {
+		return err
+	}
+	return c.hub.sendChatHistory(c, requestID)
+}
+
+// moderateChat applies a host's restriction to a player and tells the game
+func (c *Client) moderateChat(playerID string, change func(*chatModeration)) error {
+	game, err := c.chatGame()
+	if err != nil {
+		return err
+	}
+	if game.HostID != c.playerID {
+		return &requestError{code: protocol.CodeNotHost, message: "Only the host can moderate the chat"}
+	}
+	if playerID == c.playerID || !inGame(game, playerID) {
+		return &requestError{code: protocol.CodeInvalidMessage, message: "Only other players in the game can be moderated"}
+	}
+
+	ctx, cancel := context.WithTimeout(c.hub.ctx, 2*time.Second)
+	defer cancel()
+	mod, err := c.hub.chat.moderation(ctx, c.gameID, playerID)
+	if err != nil {
+		return err
+	}
+	change(&mod)
+	if err := c.hub.chat.setModeration(ctx, c.gameID, playerID, mod); err != nil {
+		return err
+	}
+
+	notice := &protocol.ChatModerated{GameID: c.gameID, PlayerID: playerID, Kicked: mod.Kicked}
+	if !mod.MutedUntil.IsZero() {
+		notice.MutedUntil = mod.MutedUntil.Format(time.RFC3339)
+	}
+	c.hub.broadcastMessage(c.gameID, notice)
+	return nil
+}
+
+// handleMutePlayer mutes a player for some minutes, or lifts the mute
+func (c *Client) handleMutePlayer(req *protocol.MutePlayerRequest) error {
+	return c.moderateChat(req.PlayerID, func(mod *chatModeration) {
+		mod.MutedUntil = time.Time{}
+		if req.Minutes > 0 {
+			mod.MutedUntil = time.Now().Add(time.Duration(req.Minutes) * time.Minute).UTC()
+		}
+	})
+}
+
+// handleKickFromChat removes a player from the chat for the rest of the game
+func (c *Client) handleKickFromChat(req *protocol.KickFromChatRequest) error {
+	return c.moderateChat(req.PlayerID, func(mod *chatModeration) {
+		mod.Kicked = true
+	})
+}
@@ -0,0 +1,212 @@
+package websocket
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/kekopoly/backend/internal/game/manager"
+	"github.com/kekopoly/backend/internal/game/models"
+	"github.com/kekopoly/backend/internal/game/protocol"
+)
+
+// receivedOfType returns the messages of one type queued for the client
+func receivedOfType(t *testing.T, client *Client, msgType string) []map[string]interface{} {
+	t.Helper()
+	var out []map[string]interface{}
+	for _, msg := range decodeAll(t, received(client, 50*time.Millisecond)) {
+		if msg["type"] == msgType {
+			out = append(out, msg)
+		}
+	}
+	return out
+}
+
+func TestWordFilter(t *testing.T) {
+	filter := WordFilter([]string{"heck", " "}, true)
+
+	text, err := filter("alice", "What the HECK, heckler")
+	require.NoError(t, err)
+	assert.Equal(t, "What the ****, heckler", text)
+
+	for _, link := range []string{"see https://scam.example", "go to www.example.org", "visit free-kmt.xyz/claim"} {
+		_, err := filter("alice", link)
+		assert.ErrorIs(t, err, ErrChatRejected, link)
+	}
+
+	text, err = WordFilter(nil, false)("alice", "visit kekopoly.io")
+	require.NoError(t, err)
+	assert.Equal(t, "visit kekopoly.io", text)
+}
+
+func TestChat(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	mr := miniredis.RunT(t)
+
+	hub := newTestNode(t, ctx, mr, "node-a")
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { redisClient.Close() })
+	gm := manager.NewGameManagerWithOptions(ctx, nil, redisClient, zap.NewNop().Sugar(), hub, nil, manager.Options{
+		Store: manager.NewMemoryGameStore(),
+	})
+	hub.SetGameManager(gm)
+	hub.SetChat(NewChat(redisClient, ChatOptions{
+		RateLimit:  3,
+		RateWindow: time.Minute,
+		Filter:     WordFilter([]string{"heck"}, true),
+	}))
+
+	gameID, err := gm.CreateGame("alice", "wallet-alice", "", 4, 0)
+	require.NoError(t, err)
+	for _, player := range []string{"bob", "carol"} {
+		_, err := gm.JoinGame(gameID, player, "wallet-"+player)
+		require.NoError(t, err)
+	}
+	alice := addTestClient(t, mr, hub, gameID, "alice")
+	bob := addTestClient(t, mr, hub, gameID, "bob")
+	carol := addTestClient(t, mr, hub, gameID, "carol")
+	everyone := []*Client{alice, bob, carol}
+
+	// Messages go to every player, numbered and filtered
+	alice.handleMessage([]byte(`{"type":"chat_message","message":"what the heck"}`))
+	for _, client := range everyone {
+		got := receivedOfType(t, client, "chat_message")
+		require.Len(t, got, 1)
+		assert.Equal(t, float64(1), got[0]["id"])
+		assert.Equal(t, "alice", got[0]["playerId"])
+		assert.Equal(t, "what the ****", got[0]["message"])
+	}
+
+	// Whispers only reach the two players
+	bob.handleMessage([]byte(`{"v":1,"type":"chat_message","payload":{"message":"psst","to":"alice"}}`))
+	for _, client := range []*Client{alice, bob} {
+		got := receivedOfType(t, client, "chat_message")
+		require.Len(t, got, 1)
+		assert.Equal(t, float64(2), got[0]["id"])
+		assert.Equal(t, "alice", got[0]["to"])
+	}
+	assert.Empty(t, receivedOfType(t, carol, "chat_message"))
+
+	whisper, err := hub.ChatMessage(gameID, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "psst", whisper.Message)
+	_, err = hub.ChatMessage(gameID, 99)
+	assert.ErrorIs(t, err, ErrChatMessageNotFound)
+
+	// Links are refused
+	bob.handleMessage([]byte(`{"v":1,"type":"chat_message","payload":{"message":"free KMT at https://scam.example"}}`))
+	got := receivedOfType(t, bob, "error")
+	require.Len(t, got, 1)
+	assert.Equal(t, protocol.CodeMessageRejected, got[0]["code"])
+
+	// Only the host moderates
+	bob.handleMessage([]byte(`{"v":1,"type":"mute_player","payload":{"playerId":"carol","minutes":5}}`))
+	got = receivedOfType(t, bob, "error")
+	require.Len(t, got, 1)
+	assert.Equal(t, protocol.CodeNotHost, got[0]["code"])
+
+	alice.handleMessage([]byte(`{"v":1,"type":"mute_player","payload":{"playerId":"bob","minutes":5}}`))
+	for _, client := range everyone {
+		got := receivedOfType(t, client, "chat_moderated")
+		require.Len(t, got, 1)
+		assert.Equal(t, "bob", got[0]["playerId"])
+		assert.NotEmpty(t, got[0]["mutedUntil"])
+	}
+	bob.handleMessage([]byte(`{"v":1,"type":"chat_message","payload":{"message":"hello?"}}`))
+	got = receivedOfType(t, bob, "error")
+	require.Len(t, got, 1)
+	assert.Equal(t, protocol.CodeMuted, got[0]["code"])
+
+	// Kicked players stop getting the chat
+	alice.handleMessage([]byte(`{"v":1,"type":"kick_from_chat","payload":{"playerId":"carol"}}`))
+	for _, client := range everyone {
+		require.Len(t, receivedOfType(t, client, "chat_moderated"), 1)
+	}
+	alice.handleMessage([]byte(`{"type":"chat_message","message":"just us now"}`))
+	assert.Len(t, receivedOfType(t, bob, "chat_message"), 1)
+	assert.Empty(t, receivedOfType(t, carol, "chat_message"))
+	carol.handleMessage([]byte(`{"v":1,"type":"chat_message","payload":{"message":"let me in"}}`))
+	got = receivedOfType(t, carol, "error")
+	require.Len(t, got, 1)
+	assert.Equal(t, protocol.CodeMuted, got[0]["code"])
+
+	// Players are limited to rate_limit messages per window
+	alice.handleMessage([]byte(`{"type":"chat_message","message":"three"}`))
+	alice.handleMessage([]byte(`{"v":1,"type":"chat_message","payload":{"message":"four"}}`))
+	got = receivedOfType(t, alice, "error")
+	require.Len(t, got, 1)
+	assert.Equal(t, protocol.CodeRateLimited, got[0]["code"])
+
+	// Kicked players get no history
+	carol.handleMessage([]byte(`{"v":1,"type":"get_chat_history","requestId":"h-1","payload":{}}`))
+	assert.Empty(t, receivedOfType(t, carol, "chat_history"))
+
+	alice.handleMessage([]byte(`{"v":1,"type":"get_chat_history","requestId":"h-2","payload":{}}`))
+	got = receivedOfType(t, alice, "chat_history")
+	require.Len(t, got, 1)
+	assert.Equal(t, "h-2", got[0]["requestId"])
+	assert.Len(t, got[0]["messages"], 4)
+}
+
+func TestPostMemeFromChat(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	mr := miniredis.RunT(t)
+
+	hub := newTestNode(t, ctx, mr, "node-a")
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { redisClient.Close() })
+	gm := manager.NewGameManagerWithOptions(ctx, nil, redisClient, zap.NewNop().Sugar(), hub, nil, manager.Options{
+		Store: manager.NewMemoryGameStore(),
+	})
+	hub.SetGameManager(gm)
+	hub.SetChat(NewChat(redisClient, ChatOptions{RateLimit: 10, RateWindow: time.Minute}))
+
+	gameID, err := gm.CreateGame("alice", "wallet-alice", "", 4, 0)
+	require.NoError(t, err)
+	_, err = gm.JoinGame(gameID, "bob", "wallet-bob")
+	require.NoError(t, err)
+	require.NoError(t, gm.StartGame(gameID, "alice"))
+	game, err := gm.GetGame(gameID)
+	require.NoError(t, err)
+	player, other := game.CurrentTurn, "alice"
+	if player == "alice" {
+		other = "bob"
+	}
+
+	poster := addTestClient(t, mr, hub, gameID, player)
+	watcher := addTestClient(t, mr, hub, gameID, other)
+	poster.handleMessage([]byte(`{"v":1,"type":"chat_message","payload":{"message":"such wow"}}`))
+	poster.handleMessage([]byte(`{"v":1,"type":"chat_message","payload":{"message":"psst","to":"` + other + `"}}`))
+	watcher.handleMessage([]byte(`{"v":1,"type":"chat_message","payload":{"message":"not yours"}}`))
+	require.Len(t, receivedOfType(t, watcher, "chat_message"), 3)
+
+	postMeme := func(id int64) error {
+		return gm.ProcessGameAction(models.GameAction{
+			Type:     models.ActionTypeSpecial,
+			PlayerID: player,
+			GameID:   gameID,
+			Payload:  map[string]interface{}{"action": manager.SpecialPostMeme, "chatMessageId": float64(id)},
+		})
+	}
+
+	// Whispers, other players' messages and unknown IDs can't be posted as memes
+	for _, id := range []int64{2, 3, 99} {
+		assert.ErrorIs(t, postMeme(id), manager.ErrMemeNotFound, id)
+	}
+	assert.Empty(t, receivedOfType(t, watcher, "meme_posted"))
+
+	require.NoError(t, postMeme(1))
+	got := receivedOfType(t, watcher, "meme_posted")
+	require.Len(t, got, 1)
+	assert.Equal(t, player, got[0]["playerId"])
+	assert.Equal(t, float64(1), got[0]["chatMessageId"])
+	assert.Equal(t, "such wow", got[0]["message"])
+}
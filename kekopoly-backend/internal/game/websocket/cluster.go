@@ -0,0 +1,303 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	redisdb "github.com/kekopoly/backend/internal/db/redis"
+	"github.com/kekopoly/backend/internal/game/protocol"
+)
+
+// Envelope wraps a game message published to the other nodes
+type Envelope struct {
+	ID              string       `json:"id"`
+	NodeID          string       `json:"nodeId"`
+	GameID          string       `json:"gameId"`
+	ExcludePlayerID string       `json:"excludePlayerId,omitempty"`
+	Priority        string       `json:"priority,omitempty"` // Set for BroadcastToGameWithPriority
+	Data            []byte       `json:"data"`
+	State           *StateUpdate `json:"state,omitempty"`     // Set instead of Data by PublishState
+	PlayerIDs       []string     `json:"playerIds,omitempty"` // Set when Data is only for these players
+
+	// Set instead of Data by lobby updates: the game's new listing, or Unlisted when it
+	// left the lobby
+	Listing  *protocol.GameListing `json:"listing,omitempty"`
+	Unlisted bool                  `json:"unlisted,omitempty"`
+	// Set when Data is for the lobby connections of PlayerIDs rather than the game
+	Lobby bool `json:"lobby,omitempty"`
+	// Set instead of Data when PlayerIDs' game sockets should be closed
+	Disconnect bool `json:"disconnect,omitempty"`
+	// Set instead of Data when a spectator joined or left: the game's spectator count
+	SpectatorCount *int `json:"spectatorCount,omitempty"`
+}
+
+// Presence records which node a player's socket is connected to
+type Presence struct {
+	NodeID    string    `json:"nodeId"`
+	SessionID string    `json:"sessionId"`
+	LastSeen  time.Time `json:"lastSeen"`
+}
+
+// clearPresenceScript removes a presence entry only if it still belongs to the session,
+// so a node doesn't clear a player who has since reconnected elsewhere
+var clearPresenceScript = redis.NewScript(`
+local raw = redis.call("HGET", KEYS[1], ARGV[1])
+if not raw then return 0 end
+local ok, entry = pcall(cjson.decode, raw)
+if ok and entry.sessionId == ARGV[2] then
+	return redis.call("HDEL", KEYS[1], ARGV[1])
+end
+return 0
+`)
+
+// Bus fans game messages out to the other server nodes over Redis pub/sub and
+// tracks which node each player is connected to
+type Bus struct {
+	client      *redis.Client
+	nodeID      string
+	presenceTTL time.Duration
+	logger      *zap.SugaredLogger
+
+	pubsub *redis.PubSub
+
+	mu     sync.Mutex
+	games  map[string]bool // Games this node is subscribed to
+	recent map[string]bool // IDs of messages already delivered
+	order  []string        // recent IDs, oldest first
+}
+
+// maxRecentMessages bounds the dedup window
+const maxRecentMessages = 4096
+
+// NewBus creates a bus for this node. An empty nodeID gets a random one.
+func NewBus(client *redis.Client, nodeID string, presenceTTL time.Duration, logger *zap.SugaredLogger) *Bus {
+	if nodeID == "" {
+		nodeID = uuid.New().String()
+	}
+	if presenceTTL <= 0 {
+		presenceTTL = 90 * time.Second
+	}
+	return &Bus{
+		client:      client,
+		nodeID:      nodeID,
+		presenceTTL: presenceTTL,
+		logger:      logger,
+		games:       make(map[string]bool),
+		recent:      make(map[string]bool),
+	}
+}
+
+// NodeID returns this node's ID
+func (b *Bus) NodeID() string {
+	return b.nodeID
+}
+
+// gameChannel is the pub/sub channel carrying a game's messages
+func gameChannel(gameID string) string {
+	return fmt.Sprintf("kekopoly:game:%s:broadcast", strings.ToLower(gameID))
+}
+
+// lobbyChannel is the pub/sub channel carrying lobby listing changes
+const lobbyChannel = "kekopoly:lobby"
+
+// presenceKey is the hash of playerID -> Presence for a game
+func presenceKey(gameID string) string {
+	return fmt.Sprintf("kekopoly:game:%s:presence", strings.ToLower(gameID))
+}
+
+// Start listens for messages from other nodes and passes them to deliver until ctx is done
+func (b *Bus) Start(ctx context.Context, deliver func(*Envelope)) {
+	b.mu.Lock()
+	b.pubsub = redisdb.Subscribe(ctx, b.client)
+	b.mu.Unlock()
+
+	go func() {
+		defer b.pubsub.Close()
+		messages := b.pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				env, ok := b.accept(msg.Payload)
+				if ok {
+					deliver(env)
+				}
+			}
+		}
+	}()
+}
+
+// accept decodes a published message, dropping this node's own and any already seen
+func (b *Bus) accept(payload string) (*Envelope, bool) {
+	var env Envelope
+	if err := json.Unmarshal([]byte(payload), &env); err != nil {
+		b.logger.Warnf("[Bus] Dropping malformed message: %v", err)
+		return nil, false
+	}
+	if env.NodeID == b.nodeID {
+		return nil, false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.recent[env.ID] {
+		return nil, false
+	}
+	b.remember(env.ID)
+	return &env, true
+}
+
+// remember adds an ID to the dedup window; the caller must hold the lock
+func (b *Bus) remember(id string) {
+	b.recent[id] = true
+	b.order = append(b.order, id)
+	if len(b.order) > maxRecentMessages {
+		delete(b.recent, b.order[0])
+		b.order = b.order[1:]
+	}
+}
+
+// Publish sends a game message to the other nodes
+func (b *Bus) Publish(ctx context.Context, env Envelope) error {
+	return b.publish(ctx, gameChannel(env.GameID), env)
+}
+
+// PublishLobby sends a listing change to the other nodes' lobbies
+func (b *Bus) PublishLobby(ctx context.Context, env Envelope) error {
+	return b.publish(ctx, lobbyChannel, env)
+}
+
+func (b *Bus) publish(ctx context.Context, channel string, env Envelope) error {
+	env.ID = uuid.New().String()
+	env.NodeID = b.nodeID
+	payload, err := json.Marshal(env)
+	if err != nil {
+		return fmt.Errorf("failed to marshal envelope: %w", err)
+	}
+	return redisdb.Publish(ctx, b.client, channel, payload)
+}
+
+// Join subscribes to a game's messages. It is a no-op if already subscribed.
+func (b *Bus) Join(ctx context.Context, gameID string) error {
+	gameID = strings.ToLower(gameID)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.pubsub == nil || b.games[gameID] {
+		return nil
+	}
+	if err := b.pubsub.Subscribe(ctx, gameChannel(gameID)); err != nil {
+		return fmt.Errorf("failed to subscribe to game %s: %w", gameID, err)
+	}
+	b.games[gameID] = true
+	return nil
+}
+
+// JoinLobby subscribes to lobby listing changes
+func (b *Bus) JoinLobby(ctx context.Context) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.pubsub == nil {
+		return nil
+	}
+	if err := b.pubsub.Subscribe(ctx, lobbyChannel); err != nil {
+		return fmt.Errorf("failed to subscribe to the lobby: %w", err)
+	}
+	return nil
+}
+
+// Leave unsubscribes from a game's messages once it has no local clients
+func (b *Bus) Leave(ctx context.Context, gameID string) error {
+	gameID = strings.ToLower(gameID)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.pubsub == nil || !b.games[gameID] {
+		return nil
+	}
+	delete(b.games, gameID)
+	if err := b.pubsub.Unsubscribe(ctx, gameChannel(gameID)); err != nil {
+		return fmt.Errorf("failed to unsubscribe from game %s: %w", gameID, err)
+	}
+	return nil
+}
+
+// spectatorsKey is the hash of sessionID -> Presence for a game's spectators
+func spectatorsKey(gameID string) string {
+	return fmt.Sprintf("kekopoly:game:%s:spectators", strings.ToLower(gameID))
+}
+
+// SetPresence records that the player is connected to this node
+func (b *Bus) SetPresence(ctx context.Context, gameID, playerID, sessionID string) error {
+	return b.setPresence(ctx, presenceKey(gameID), playerID, sessionID)
+}
+
+// ClearPresence removes the player's entry if it still belongs to the session
+func (b *Bus) ClearPresence(ctx context.Context, gameID, playerID, sessionID string) error {
+	return clearPresenceScript.Run(ctx, b.client, []string{presenceKey(gameID)}, playerID, sessionID).Err()
+}
+
+// Presence returns the players connected to any node, leaving out entries that
+// haven't been refreshed within the presence TTL
+func (b *Bus) Presence(ctx context.Context, gameID string) (map[string]Presence, error) {
+	return b.readPresence(ctx, presenceKey(gameID))
+}
+
+// SetSpectator records that a spectator session is watching from this node
+func (b *Bus) SetSpectator(ctx context.Context, gameID, sessionID string) error {
+	return b.setPresence(ctx, spectatorsKey(gameID), sessionID, sessionID)
+}
+
+// ClearSpectator removes a spectator session
+func (b *Bus) ClearSpectator(ctx context.Context, gameID, sessionID string) error {
+	return b.client.HDel(ctx, spectatorsKey(gameID), sessionID).Err()
+}
+
+// Spectators returns the spectator sessions watching from any node
+func (b *Bus) Spectators(ctx context.Context, gameID string) (map[string]Presence, error) {
+	return b.readPresence(ctx, spectatorsKey(gameID))
+}
+
+// setPresence records an entry for this node in a presence hash
+func (b *Bus) setPresence(ctx context.Context, key, field, sessionID string) error {
+	entry, err := json.Marshal(Presence{NodeID: b.nodeID, SessionID: sessionID, LastSeen: time.Now()})
+	if err != nil {
+		return err
+	}
+	if err := redisdb.HashSet(ctx, b.client, key, map[string]interface{}{field: entry}); err != nil {
+		return fmt.Errorf("failed to record presence: %w", err)
+	}
+	// The whole hash expires if every node serving the game goes away
+	return b.client.Expire(ctx, key, 2*b.presenceTTL).Err()
+}
+
+// readPresence returns the entries of a presence hash refreshed within the presence TTL
+func (b *Bus) readPresence(ctx context.Context, key string) (map[string]Presence, error) {
+	raw, err := redisdb.HashGetAll(ctx, b.client, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read presence: %w", err)
+	}
+
+	present := make(map[string]Presence, len(raw))
+	for field, value := range raw {
+		var entry Presence
+		if err := json.Unmarshal([]byte(value), &entry); err != nil {
+			continue
+		}
+		if time.Since(entry.LastSeen) > b.presenceTTL {
+			continue
+		}
+		present[field] = entry
+	}
+	return present, nil
+}
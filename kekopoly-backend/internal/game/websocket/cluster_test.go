@@ -0,0 +1,158 @@
+package websocket
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newTestNode starts a hub connected to the shared Redis as its own node
+func newTestNode(t *testing.T, ctx context.Context, mr *miniredis.Miniredis, nodeID string) *Hub {
+	t.Helper()
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	logger := zap.NewNop().Sugar()
+	hub := NewHub(ctx, nil, nil, client, logger, nil)
+	hub.SetBus(NewBus(client, nodeID, time.Minute, logger))
+	go hub.Run()
+	return hub
+}
+
+// addTestClient registers a socket-less client on the hub and joins its game
+func addTestClient(t *testing.T, mr *miniredis.Miniredis, hub *Hub, gameID, playerID string) *Client {
+	t.Helper()
+	client := &Client{
+		hub:                 hub,
+		highPriorityQueue:   make(chan []byte, 16),
+		normalPriorityQueue: make(chan []byte, 16),
+		lowPriorityQueue:    make(chan []byte, 16),
+		gameID:              gameID,
+		playerID:            playerID,
+		sessionID:           playerID + "-session",
+		lastPongTime:        time.Now(),
+	}
+
+	hub.clientsMutex.Lock()
+	if hub.clients[gameID] == nil {
+		hub.clients[gameID] = make(map[string]*Client)
+	}
+	hub.clients[gameID][playerID] = client
+	hub.clientsMutex.Unlock()
+
+	// The hub closes its clients' sockets on shutdown, and these have none
+	t.Cleanup(func() {
+		hub.clientsMutex.Lock()
+		delete(hub.clients[gameID], playerID)
+		hub.clientsMutex.Unlock()
+	})
+
+	hub.joinCluster(client)
+
+	// Wait until Redis has the subscription so published messages aren't missed
+	require.Eventually(t, func() bool {
+		return mr.PubSubNumSub(gameChannel(gameID))[gameChannel(gameID)] > 0
+	}, time.Second, 5*time.Millisecond)
+	return client
+}
+
+// received drains every message queued for the client within the wait
+func received(client *Client, wait time.Duration) []string {
+	var out []string
+	deadline := time.After(wait)
+	for {
+		select {
+		case msg := <-client.highPriorityQueue:
+			out = append(out, string(msg))
+		case msg := <-client.normalPriorityQueue:
+			out = append(out, string(msg))
+		case msg := <-client.lowPriorityQueue:
+			out = append(out, string(msg))
+		case <-deadline:
+			return out
+		}
+	}
+}
+
+func TestBroadcastReachesClientsOnOtherNodes(t *testing.T) {
+	// Cleanups run last-in first-out, so the test clients are gone before the hubs stop
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	mr := miniredis.RunT(t)
+
+	nodeA := newTestNode(t, ctx, mr, "node-a")
+	nodeB := newTestNode(t, ctx, mr, "node-b")
+
+	alice := addTestClient(t, mr, nodeA, "game1", "alice")
+	bob := addTestClient(t, mr, nodeB, "game1", "bob")
+	carol := addTestClient(t, mr, nodeB, "game1", "carol")
+
+	nodeA.BroadcastToGame("game1", []byte(`{"type":"dice_rolled"}`))
+	nodeB.BroadcastToGameExcept("game1", []byte(`{"type":"player_typing"}`), "alice")
+
+	// Each message arrives once, including on the node that published it
+	assert.Equal(t, []string{`{"type":"dice_rolled"}`}, received(alice, 200*time.Millisecond))
+	assert.ElementsMatch(t, []string{`{"type":"dice_rolled"}`, `{"type":"player_typing"}`}, received(bob, 200*time.Millisecond))
+	assert.ElementsMatch(t, []string{`{"type":"dice_rolled"}`, `{"type":"player_typing"}`}, received(carol, 200*time.Millisecond))
+}
+
+func TestBusDropsOwnAndRepeatedMessages(t *testing.T) {
+	bus := NewBus(nil, "node-a", time.Minute, zap.NewNop().Sugar())
+
+	_, ok := bus.accept(`{"id":"1","nodeId":"node-a","gameId":"g"}`)
+	assert.False(t, ok, "own message should be dropped")
+
+	env, ok := bus.accept(`{"id":"2","nodeId":"node-b","gameId":"g"}`)
+	require.True(t, ok)
+	assert.Equal(t, "g", env.GameID)
+
+	_, ok = bus.accept(`{"id":"2","nodeId":"node-b","gameId":"g"}`)
+	assert.False(t, ok, "repeated message should be dropped")
+
+	_, ok = bus.accept(`not json`)
+	assert.False(t, ok)
+}
+
+func TestPresenceIsSharedBetweenNodes(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	logger := zap.NewNop().Sugar()
+	busA := NewBus(client, "node-a", time.Minute, logger)
+	busB := NewBus(client, "node-b", time.Minute, logger)
+
+	require.NoError(t, busA.SetPresence(ctx, "game1", "alice", "s1"))
+	require.NoError(t, busB.SetPresence(ctx, "game1", "bob", "s2"))
+
+	present, err := busA.Presence(ctx, "game1")
+	require.NoError(t, err)
+	require.Len(t, present, 2)
+	assert.Equal(t, "node-b", present["bob"].NodeID)
+
+	// Alice reconnected on node B; node A's stale session must not clear her
+	require.NoError(t, busB.SetPresence(ctx, "game1", "alice", "s3"))
+	require.NoError(t, busA.ClearPresence(ctx, "game1", "alice", "s1"))
+	present, err = busB.Presence(ctx, "game1")
+	require.NoError(t, err)
+	assert.Equal(t, "node-b", present["alice"].NodeID)
+
+	require.NoError(t, busB.ClearPresence(ctx, "game1", "alice", "s3"))
+	present, err = busB.Presence(ctx, "game1")
+	require.NoError(t, err)
+	assert.NotContains(t, present, "alice")
+
+	// Entries from a node that stopped refreshing them are ignored
+	stale := NewBus(client, "node-c", time.Millisecond, logger)
+	time.Sleep(5 * time.Millisecond)
+	present, err = stale.Presence(ctx, "game1")
+	require.NoError(t, err)
+	assert.Empty(t, present)
+}
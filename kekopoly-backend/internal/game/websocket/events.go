@@ -0,0 +1,119 @@
+package websocket
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Event is a game broadcast kept for replay
+type Event struct {
+	Seq             int64  `json:"seq"`
+	ExcludePlayerID string `json:"exclude,omitempty"`
+	Data            string `json:"data"` // The message as sent, with its seq
+}
+
+// appendEventScript numbers a message, stamps the number into it and keeps it in the
+// game's ring buffer, all in one step so every node agrees on the order
+var appendEventScript = redis.NewScript(`
+local seq = redis.call("INCR", KEYS[1])
+local stamped = '{"seq":' .. seq .. ',' .. string.sub(ARGV[1], 2)
+local entry = cjson.encode({seq = seq, exclude = ARGV[2], data = stamped})
+redis.call("ZADD", KEYS[2], seq, entry)
+redis.call("ZREMRANGEBYRANK", KEYS[2], 0, -(tonumber(ARGV[3]) + 1))
+redis.call("EXPIRE", KEYS[1], ARGV[4])
+redis.call("EXPIRE", KEYS[2], ARGV[4])
+return stamped
+`)
+
+// EventLog numbers each game's broadcasts and keeps the most recent ones in Redis,
+// so a client that missed some can catch up without a full snapshot
+type EventLog struct {
+	client *redis.Client
+	size   int
+	ttl    time.Duration
+}
+
+// NewEventLog creates an event log keeping up to size events per game. A game's
+// events are dropped after ttl without broadcasts.
+func NewEventLog(client *redis.Client, size int, ttl time.Duration) *EventLog {
+	if size <= 0 {
+		size = 500
+	}
+	if ttl < time.Second {
+		ttl = time.Hour
+	}
+	return &EventLog{client: client, size: size, ttl: ttl}
+}
+
+// seqKey holds the last sequence number used in a game
+func seqKey(gameID string) string {
+	return fmt.Sprintf("kekopoly:game:%s:seq", strings.ToLower(gameID))
+}
+
+// eventsKey is the sorted set of a game's recent events, scored by sequence number
+func eventsKey(gameID string) string {
+	return fmt.Sprintf("kekopoly:game:%s:events", strings.ToLower(gameID))
+}
+
+// sequenced reports whether a message can carry a sequence number: only JSON
+// objects with at least one field can
+func sequenced(message []byte) bool {
+	return bytes.HasPrefix(message, []byte(`{"`))
+}
+
+// Append numbers a game message and records it. It returns the message with its
+// "seq" field added.
+func (l *EventLog) Append(ctx context.Context, gameID, excludePlayerID string, message []byte) ([]byte, error) {
+	if !sequenced(message) {
+		return message, nil
+	}
+	stamped, err := appendEventScript.Run(ctx, l.client,
+		[]string{seqKey(gameID), eventsKey(gameID)},
+		string(message), excludePlayerID, l.size, int(l.ttl/time.Second)).Text()
+	if err != nil {
+		return message, fmt.Errorf("failed to record event for game %s: %w", gameID, err)
+	}
+	return []byte(stamped), nil
+}
+
+// Since returns the events after lastSeq and the game's latest sequence number.
+// complete is false when some of those events are no longer kept, in which case
+// the client needs a snapshot instead.
+func (l *EventLog) Since(ctx context.Context, gameID string, lastSeq int64) (events []Event, latest int64, complete bool, err error) {
+	pipe := l.client.TxPipeline()
+	latestCmd := pipe.Get(ctx, seqKey(gameID))
+	rangeCmd := pipe.ZRangeByScore(ctx, eventsKey(gameID), &redis.ZRangeBy{
+		Min: "(" + strconv.FormatInt(lastSeq, 10),
+		Max: "+inf",
+	})
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, 0, false, fmt.Errorf("failed to read events for game %s: %w", gameID, err)
+	}
+
+	latest, err = latestCmd.Int64()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, 0, false, fmt.Errorf("failed to read sequence for game %s: %w", gameID, err)
+	}
+
+	for _, raw := range rangeCmd.Val() {
+		var event Event
+		if err := json.Unmarshal([]byte(raw), &event); err != nil {
+			return nil, latest, false, fmt.Errorf("failed to decode event for game %s: %w", gameID, err)
+		}
+		events = append(events, event)
+	}
+
+	// A client ahead of the counter saw a sequence that has since expired
+	if lastSeq > latest {
+		return nil, latest, false, nil
+	}
+	return events, latest, int64(len(events)) == latest-lastSeq, nil
+}
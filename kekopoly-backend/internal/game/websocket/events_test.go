@@ -0,0 +1,153 @@
+package websocket
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/kekopoly/backend/internal/game/manager"
+	"github.com/kekopoly/backend/internal/game/protocol"
+)
+
+// receivedByPriority drains the client's queues the way writePump would, high first
+func receivedByPriority(client *Client, wait time.Duration) []string {
+	time.Sleep(wait)
+	var out []string
+	for _, queue := range []chan []byte{client.highPriorityQueue, client.normalPriorityQueue, client.lowPriorityQueue} {
+		for len(queue) > 0 {
+			out = append(out, string(<-queue))
+		}
+	}
+	return out
+}
+
+func TestEventLogNumbersAndReplays(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	log := NewEventLog(client, 3, time.Minute)
+
+	first, err := log.Append(ctx, "Game1", "", []byte(`{"type":"dice_rolled"}`))
+	require.NoError(t, err)
+	assert.Equal(t, `{"seq":1,"type":"dice_rolled"}`, string(first))
+
+	_, err = log.Append(ctx, "game1", "bob", []byte(`{"type":"player_typing"}`))
+	require.NoError(t, err)
+	_, err = log.Append(ctx, "game1", "", []byte(`{"type":"turn_changed"}`))
+	require.NoError(t, err)
+
+	// Messages that can't carry a number pass through
+	raw, err := log.Append(ctx, "game1", "", []byte(`{}`))
+	require.NoError(t, err)
+	assert.Equal(t, `{}`, string(raw))
+
+	events, latest, complete, err := log.Since(ctx, "game1", 1)
+	require.NoError(t, err)
+	assert.True(t, complete)
+	assert.Equal(t, int64(3), latest)
+	require.Len(t, events, 2)
+	assert.Equal(t, "bob", events[0].ExcludePlayerID)
+	assert.Equal(t, `{"seq":3,"type":"turn_changed"}`, events[1].Data)
+
+	events, _, complete, err = log.Since(ctx, "game1", 3)
+	require.NoError(t, err)
+	assert.True(t, complete)
+	assert.Empty(t, events)
+
+	// The buffer holds three events, so the first falls out on the fourth
+	_, err = log.Append(ctx, "game1", "", []byte(`{"type":"jail_event"}`))
+	require.NoError(t, err)
+	_, _, complete, err = log.Since(ctx, "game1", 0)
+	require.NoError(t, err)
+	assert.False(t, complete)
+	events, _, complete, err = log.Since(ctx, "game1", 1)
+	require.NoError(t, err)
+	assert.True(t, complete)
+	assert.Len(t, events, 3)
+
+	// After the game's events expire, old sequence numbers need a snapshot
+	mr.FastForward(2 * time.Minute)
+	_, latest, complete, err = log.Since(ctx, "game1", 4)
+	require.NoError(t, err)
+	assert.False(t, complete)
+	assert.Zero(t, latest)
+}
+
+func TestResume(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	mr := miniredis.RunT(t)
+
+	hub := newTestNode(t, ctx, mr, "node-a")
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { redisClient.Close() })
+	hub.SetEventLog(NewEventLog(redisClient, 3, time.Minute))
+
+	gm := manager.NewGameManagerWithOptions(ctx, nil, redisClient, zap.NewNop().Sugar(), hub, nil, manager.Options{
+		Store: manager.NewMemoryGameStore(),
+	})
+	hub.SetGameManager(gm)
+	gameID, err := gm.CreateGame("alice", "wallet-alice", "", 4, 0)
+	require.NoError(t, err)
+
+	alice := addTestClient(t, mr, hub, gameID, "alice")
+
+	hub.BroadcastToGame(gameID, []byte(`{"type":"dice_rolled"}`))
+	hub.BroadcastToGameExcept(gameID, []byte(`{"type":"buy_property"}`), "alice")
+	hub.BroadcastToGame(gameID, []byte(`{"type":"turn_changed"}`))
+	assert.Len(t, received(alice, 100*time.Millisecond), 2)
+
+	t.Run("missed events are replayed", func(t *testing.T) {
+		alice.handleMessage([]byte(`{"v":1,"type":"resume","requestId":"r-1","payload":{"lastSeq":1}}`))
+
+		got := decodeAll(t, receivedByPriority(alice, 100*time.Millisecond))
+		require.Len(t, got, 3)
+		// The broadcast that left alice out isn't replayed to her
+		assert.Equal(t, "turn_changed", got[0]["type"])
+		assert.Equal(t, float64(3), got[0]["seq"])
+		assert.Equal(t, "resumed", got[1]["type"])
+		assert.Equal(t, float64(3), got[1]["lastSeq"])
+		assert.Equal(t, float64(1), got[1]["replayed"])
+		assert.Equal(t, false, got[1]["snapshot"])
+		assert.Equal(t, "ack", got[2]["type"])
+	})
+
+	t.Run("old gap gets a snapshot", func(t *testing.T) {
+		hub.BroadcastToGame(gameID, []byte(`{"type":"jail_event"}`))
+		received(alice, 100*time.Millisecond)
+
+		alice.handleMessage([]byte(`{"type":"resume","lastSeq":0}`))
+
+		got := decodeAll(t, receivedByPriority(alice, 100*time.Millisecond))
+		require.Len(t, got, 2)
+		assert.Equal(t, "complete_state_sync", got[0]["type"])
+		assert.Equal(t, float64(4), got[0]["seq"])
+		assert.Equal(t, gameID, got[0]["gameId"])
+		assert.Equal(t, "resumed", got[1]["type"])
+		assert.Equal(t, true, got[1]["snapshot"])
+	})
+}
+
+func TestResumeRejectsNegativeSeq(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	mr := miniredis.RunT(t)
+
+	hub := newTestNode(t, ctx, mr, "node-a")
+	alice := addTestClient(t, mr, hub, "game1", "alice")
+
+	alice.handleMessage([]byte(`{"v":1,"type":"resume","payload":{"lastSeq":-1}}`))
+
+	got := decodeAll(t, received(alice, 100*time.Millisecond))
+	require.Len(t, got, 1)
+	assert.Equal(t, "error", got[0]["type"])
+	assert.Equal(t, protocol.CodeInvalidMessage, got[0]["code"])
+}
@@ -3,6 +3,7 @@ package websocket
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"hash/fnv"
 	"math/rand"
@@ -15,6 +16,7 @@ import (
 	"github.com/gorilla/websocket"
 	"github.com/kekopoly/backend/internal/game/manager"
 	"github.com/kekopoly/backend/internal/game/models"
+	"github.com/kekopoly/backend/internal/game/protocol"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.uber.org/zap"
 )
@@ -81,6 +83,33 @@ type Hub struct {
 
 	// Mutex for sessionHistory map
 	sessionHistoryMutex sync.RWMutex
+
+	// Cross-node broadcast bus, nil when running as a single node
+	bus *Bus
+
+	// Numbered game broadcasts kept for clients catching up, nil when disabled
+	events *EventLog
+
+	// Last state sent per game, for state_delta patches
+	states      map[string]*stateSnapshot
+	statesMutex sync.Mutex
+
+	// State version counter used without Redis
+	localStateVersion int64
+
+	// Spectators by gameID, with the limit per game and how far behind players they see
+	// broadcasts. A limit of 0 disables spectating.
+	spectators      map[string]*spectatorFeed
+	spectatorCounts map[string]int // Spectators on any node, by gameID, for stamping broadcasts
+	spectatorsMutex sync.RWMutex
+	spectatorLimit  int
+	spectatorDelay  time.Duration
+
+	// Game chat, nil when disabled
+	chat *Chat
+
+	// Game listings streamed to lobby connections, nil when disabled
+	lobby *Lobby
 }
 
 // SessionInfo stores information about a player's session
@@ -139,6 +168,19 @@ type Client struct {
 
 	// Connection timestamp
 	connectedAt time.Time
+
+	// State version the client holds, and whether it subscribed with sync_state.
+	// Accessed atomically.
+	heldStateVersion int64
+	stateSubscribed  int32
+
+	// Set for read-only spectator connections, with the last broadcast queued for the
+	// game's spectators before this one joined
+	spectator      bool
+	spectatorSince uint64
+
+	// Set for lobby connections, which aren't in any game
+	lobby bool
 }
 
 // BroadcastMessage represents a message to be broadcast to clients
@@ -172,6 +214,9 @@ func NewHub(ctx context.Context, gameManager *manager.GameManager, mongoClient *
 		messageQueue:        messageQueue,
 		sessionHistory:      make(map[string]map[string][]SessionInfo),
 		sessionHistoryMutex: sync.RWMutex{},
+		states:              make(map[string]*stateSnapshot),
+		spectators:          make(map[string]*spectatorFeed),
+		spectatorCounts:     make(map[string]int),
 	}
 }
 
@@ -187,6 +232,162 @@ func (h *Hub) SetGameManager(gameManager *manager.GameManager) {
 	h.logger.Info("Game manager set for WebSocket hub")
 }
 
+// SetBus connects the hub to the other server nodes. Game messages are published to
+// them and messages they publish are delivered to this node's clients.
+func (h *Hub) SetBus(bus *Bus) {
+	h.bus = bus
+	bus.Start(h.ctx, h.deliverRemote)
+	if h.lobby != nil {
+		ctx, cancel := context.WithTimeout(h.ctx, 2*time.Second)
+		if err := bus.JoinLobby(ctx); err != nil {
+			h.logger.Errorf("[Lobby] %v", err)
+		}
+		cancel()
+	}
+	h.logger.Infof("Cluster bus set for WebSocket hub (node %s)", bus.NodeID())
+}
+
+// SetEventLog numbers game broadcasts and keeps recent ones so clients can resume
+func (h *Hub) SetEventLog(events *EventLog) {
+	h.events = events
+	h.logger.Info("Event log set for WebSocket hub")
+}
+
+// GameEvents returns the game's broadcasts the event log still keeps, oldest first.
+// Without an event log there are none.
+func (h *Hub) GameEvents(ctx context.Context, gameID string) ([]Event, error) {
+	if h.events == nil {
+		return nil, nil
+	}
+	events, _, _, err := h.events.Since(ctx, gameID, 0)
+	return events, err
+}
+
+// sequence numbers a game broadcast. It returns the message unchanged if there is no
+// event log or it couldn't be recorded; clients then see a gap and ask for a snapshot.
+func (h *Hub) sequence(gameID, excludePlayerID string, message []byte) []byte {
+	if h.events == nil {
+		return message
+	}
+	ctx, cancel := context.WithTimeout(h.ctx, 2*time.Second)
+	defer cancel()
+	stamped, err := h.events.Append(ctx, gameID, excludePlayerID, message)
+	if err != nil {
+		h.logger.Errorf("[Events] %v", err)
+	}
+	return stamped
+}
+
+// deliverRemote hands a message published by another node to this node's clients
+func (h *Hub) deliverRemote(env *Envelope) {
+	if env.SpectatorCount != nil {
+		h.setSpectatorCount(env.GameID, *env.SpectatorCount)
+		return
+	}
+	if env.State != nil {
+		h.deliverRemoteState(env.GameID, env.State)
+		return
+	}
+	if env.Listing != nil || env.Unlisted {
+		if h.lobby != nil {
+			h.lobby.apply(env.GameID, env.Listing)
+		}
+		return
+	}
+	if env.Lobby {
+		h.sendToLobbyPlayers(env.PlayerIDs, env.Data)
+		return
+	}
+	if env.Disconnect {
+		for _, playerID := range env.PlayerIDs {
+			h.disconnectLocal(env.GameID, playerID)
+		}
+		return
+	}
+	if len(env.PlayerIDs) > 0 {
+		for _, playerID := range env.PlayerIDs {
+			h.SendToPlayerWithPriority(env.GameID, playerID, env.Data, env.Priority)
+		}
+		return
+	}
+	if env.Priority != "" {
+		h.broadcastLocalWithPriority(env.GameID, env.Data, env.Priority)
+		return
+	}
+	h.broadcast <- &BroadcastMessage{
+		gameID:          env.GameID,
+		data:            env.Data,
+		excludePlayerID: env.ExcludePlayerID,
+	}
+}
+
+// publishRemote sends a game message to the other nodes, if clustering is enabled
+func (h *Hub) publishRemote(env Envelope) {
+	if h.bus == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(h.ctx, 2*time.Second)
+	defer cancel()
+	if err := h.bus.Publish(ctx, env); err != nil {
+		h.logger.Errorf("[Bus] Failed to publish message for game %s: %v", env.GameID, err)
+	}
+}
+
+// joinCluster subscribes to the game's messages and records the client's presence
+func (h *Hub) joinCluster(client *Client) {
+	if h.bus == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(h.ctx, 2*time.Second)
+	defer cancel()
+	if err := h.bus.Join(ctx, client.gameID); err != nil {
+		h.logger.Errorf("[Bus] %v", err)
+	}
+	if err := h.bus.SetPresence(ctx, client.gameID, client.playerID, client.sessionID); err != nil {
+		h.logger.Errorf("[Bus] Failed to record presence for player %s in game %s: %v", client.playerID, client.gameID, err)
+	}
+}
+
+// leaveCluster clears the client's presence, and unsubscribes from the game when it
+// was the last local client
+func (h *Hub) leaveCluster(client *Client, lastInGame bool) {
+	if h.bus == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(h.ctx, 2*time.Second)
+	defer cancel()
+	if err := h.bus.ClearPresence(ctx, client.gameID, client.playerID, client.sessionID); err != nil {
+		h.logger.Errorf("[Bus] Failed to clear presence for player %s in game %s: %v", client.playerID, client.gameID, err)
+	}
+	if lastInGame {
+		if err := h.bus.Leave(ctx, client.gameID); err != nil {
+			h.logger.Errorf("[Bus] %v", err)
+		}
+	}
+}
+
+// refreshPresence keeps this node's presence entries from going stale
+func (h *Hub) refreshPresence() {
+	if h.bus == nil {
+		return
+	}
+
+	h.clientsMutex.RLock()
+	clients := make([]*Client, 0)
+	for _, gamePlayers := range h.clients {
+		for _, client := range gamePlayers {
+			clients = append(clients, client)
+		}
+	}
+	h.clientsMutex.RUnlock()
+
+	for _, client := range clients {
+		if err := h.bus.SetPresence(h.ctx, client.gameID, client.playerID, client.sessionID); err != nil {
+			h.logger.Warnf("[Bus] Failed to refresh presence for player %s in game %s: %v", client.playerID, client.gameID, err)
+		}
+	}
+}
+
 // getPlayerInfo retrieves stored player information for a specific player in a game
 func (h *Hub) getPlayerInfo(gameID, playerID string) map[string]interface{} {
 	h.playerInfoMutex.RLock()
@@ -348,6 +549,18 @@ func (h *Hub) getPlayerSessions(gameID, playerID string) []SessionInfo {
 	return nil
 }
 
+// PlayerSessions returns the socket sessions this node has seen for each of the players
+// in a game. Players with none are left out.
+func (h *Hub) PlayerSessions(gameID string, playerIDs []string) map[string][]SessionInfo {
+	sessions := make(map[string][]SessionInfo)
+	for _, playerID := range playerIDs {
+		if playerSessions := h.getPlayerSessions(gameID, playerID); len(playerSessions) > 0 {
+			sessions[playerID] = playerSessions
+		}
+	}
+	return sessions
+}
+
 // getLatestSession retrieves the most recent session for a player in a game
 func (h *Hub) getLatestSession(gameID, playerID string) *SessionInfo {
 	sessions := h.getPlayerSessions(gameID, playerID)
@@ -403,14 +616,7 @@ func (h *Hub) UpdateHostID(gameID string, hostID string) {
 	h.storeGameInfo(gameID, gameInfo)
 
 	// Broadcast host change to all clients in the game
-	hostChangeMsg := map[string]interface{}{
-		"type":   "host_changed",
-		"hostId": hostID,
-		"gameId": gameID,
-	}
-
-	// Marshal to JSON
-	msgBytes, err := json.Marshal(hostChangeMsg)
+	msgBytes, err := protocol.Encode(&protocol.HostChanged{HostID: hostID, GameID: gameID})
 	if err != nil {
 		h.logger.Errorf("Failed to marshal host change message: %v", err)
 		return
@@ -461,6 +667,32 @@ func (h *Hub) UpdateHostID(gameID string, hostID string) {
 	}()
 }
 
+// disconnectGrace is how long a removed player's socket stays open, so the
+// player_evicted notice reaches them before it closes
+const disconnectGrace = 500 * time.Millisecond
+
+// DisconnectPlayer closes a player's game socket on whichever node it is connected to.
+// The game manager calls it when the host kicks or bans someone.
+func (h *Hub) DisconnectPlayer(gameID, playerID string) {
+	h.disconnectLocal(gameID, playerID)
+	h.publishRemote(Envelope{GameID: gameID, PlayerIDs: []string{playerID}, Disconnect: true})
+}
+
+// disconnectLocal closes a player's game socket if it is connected to this node
+func (h *Hub) disconnectLocal(gameID, playerID string) {
+	h.clientsMutex.RLock()
+	client := h.clients[gameID][playerID]
+	h.clientsMutex.RUnlock()
+	if client == nil || client.conn == nil {
+		return
+	}
+
+	h.logger.Infof("Closing socket of player %s removed from game %s", playerID, gameID)
+	time.AfterFunc(disconnectGrace, func() {
+		client.conn.Close()
+	})
+}
+
 // handlePlayerDisconnected handles a player disconnection
 func (h *Hub) handlePlayerDisconnected(gameID, playerID, sessionID string) {
 	// Log the event
@@ -529,14 +761,7 @@ func (h *Hub) handlePlayerDisconnected(gameID, playerID, sessionID string) {
 	}
 
 	// Broadcast player disconnection to all clients
-	disconnectMsg := map[string]interface{}{
-		"type":     "player_disconnected",
-		"playerId": playerID,
-		"gameId":   gameID,
-	}
-
-	// Marshal to JSON
-	msgBytes, err := json.Marshal(disconnectMsg)
+	msgBytes, err := protocol.Encode(&protocol.PlayerDisconnected{PlayerID: playerID, GameID: gameID})
 	if err != nil {
 		h.logger.Errorf("Failed to marshal player disconnection message: %v", err)
 		return
@@ -623,6 +848,8 @@ func (h *Hub) Run() {
 		case <-gameInfoUpdateTicker.C:
 			// Update game info cache for all active games
 			h.updateAllGameInfoCache()
+			h.refreshPresence()
+			h.refreshSpectators()
 
 		case <-h.ctx.Done():
 			// Shutdown all clients
@@ -636,6 +863,7 @@ func (h *Hub) Run() {
 				delete(h.clients, gameID)
 			}
 			h.clientsMutex.Unlock()
+			h.closeSpectators()
 			return
 
 		case client := <-h.register:
@@ -659,6 +887,18 @@ func (h *Hub) Run() {
 			h.clients[client.gameID][client.playerID] = client
 			h.clientsMutex.Unlock() // Unlock before potentially long-running calls
 
+			// Receive the game's messages from other nodes and let them see this player
+			h.joinCluster(client)
+
+			// Catch the player up on the game's chat
+			if h.chat != nil {
+				go func(client *Client) {
+					if err := h.sendChatHistory(client, ""); err != nil {
+						h.logger.Errorf("[Chat] %v", err)
+					}
+				}(client)
+			}
+
 			// --- Fetch player details from GameManager and store in Hub's cache ---
 			go func(gID, pID string) { // Use goroutine to avoid blocking hub loop
 				gameData, err := h.gameManager.GetGame(gID)
@@ -707,6 +947,14 @@ func (h *Hub) Run() {
 			// Removed unused debug logging code
 
 		case client := <-h.unregister:
+			if client.spectator {
+				h.removeSpectator(client)
+				continue
+			}
+			if client.lobby {
+				h.removeLobbyClient(client)
+				continue
+			}
 			h.clientsMutex.Lock()
 			if gameClients, ok := h.clients[client.gameID]; ok {
 				if clientObj, ok := gameClients[client.playerID]; ok {
@@ -728,9 +976,15 @@ func (h *Hub) Run() {
 						delete(h.clients[client.gameID], client.playerID)
 
 						// If no more clients in this game, remove the game entry from the hub
-						if len(h.clients[client.gameID]) == 0 {
+						lastInGame := len(h.clients[client.gameID]) == 0
+						if lastInGame {
 							delete(h.clients, client.gameID)
+							h.forgetState(client.gameID)
+							if h.chat != nil {
+								h.chat.forget(client.gameID)
+							}
 						}
+						h.leaveCluster(client, lastInGame && !h.hasSpectators(client.gameID))
 
 						h.handlePlayerDisconnected(client.gameID, client.playerID, client.sessionID)
 					} else {
@@ -799,16 +1053,19 @@ func (h *Hub) Run() {
 					}()
 				}
 
-			} else {
+			} else if !h.hasSpectators(message.gameID) {
 				h.logger.Warnf("Attempted to broadcast to non-existent game: %s", message.gameID)
 			}
 			h.clientsMutex.RUnlock()
+			h.feedSpectators(message.gameID, message.data)
 		}
 	}
 }
 
 // BroadcastToGame sends a message to all clients in a game
 func (h *Hub) BroadcastToGame(gameID string, message []byte) {
+	message = h.sequence(gameID, "", h.stampSpectators(gameID, message))
+	h.publishRemote(Envelope{GameID: gameID, Data: message})
 	h.broadcast <- &BroadcastMessage{
 		gameID: gameID,
 		data:   message,
@@ -817,6 +1074,15 @@ func (h *Hub) BroadcastToGame(gameID string, message []byte) {
 
 // BroadcastToGameWithPriority sends a message to all clients in a game with specified priority
 func (h *Hub) BroadcastToGameWithPriority(gameID string, message []byte, priority string) {
+	message = h.sequence(gameID, "", h.stampSpectators(gameID, message))
+	h.publishRemote(Envelope{GameID: gameID, Data: message, Priority: priority})
+	h.broadcastLocalWithPriority(gameID, message, priority)
+}
+
+// broadcastLocalWithPriority queues a message for this node's clients in a game
+func (h *Hub) broadcastLocalWithPriority(gameID string, message []byte, priority string) {
+	defer h.feedSpectators(gameID, message)
+
 	// Get all clients for this game
 	h.clientsMutex.RLock()
 	defer h.clientsMutex.RUnlock()
@@ -869,14 +1135,13 @@ func (h *Hub) BroadcastCompleteState(gameID string, game *models.Game) {
 	h.logger.Infof("Broadcasting complete state for game %s with %d players", gameID, len(game.Players))
 
 	// Create a complete state object with all necessary data
-	completeState := map[string]interface{}{
-		"type":        "complete_state_sync",
-		"gameId":      gameID,
-		"status":      string(game.Status),
-		"currentTurn": game.CurrentTurn,
-		"players":     game.Players,
-		"turnOrder":   game.TurnOrder,
-		"timestamp":   time.Now().Format(time.RFC3339),
+	completeState := &protocol.CompleteStateSync{
+		GameID:      gameID,
+		Status:      string(game.Status),
+		CurrentTurn: game.CurrentTurn,
+		Players:     game.Players,
+		TurnOrder:   game.TurnOrder,
+		Timestamp:   time.Now().Format(time.RFC3339),
 	}
 
 	// Log player token data for debugging
@@ -886,23 +1151,22 @@ func (h *Hub) BroadcastCompleteState(gameID string, game *models.Game) {
 	}
 
 	// Marshal to JSON
-	stateJSON, err := json.Marshal(completeState)
+	stateJSON, err := protocol.Encode(completeState)
 	if err != nil {
 		h.logger.Errorf("Failed to marshal complete state: %v", err)
 		return
 	}
 
 	// Broadcast with high priority
-	h.broadcast <- &BroadcastMessage{
-		gameID: gameID,
-		data:   stateJSON,
-	}
+	h.BroadcastToGame(gameID, stateJSON)
 
 	h.logger.Infof("Complete state sync broadcast sent for game %s", gameID)
 }
 
 // BroadcastToGameExcept sends a message to all clients in a game except one
 func (h *Hub) BroadcastToGameExcept(gameID string, message []byte, excludePlayerID string) {
+	message = h.sequence(gameID, excludePlayerID, h.stampSpectators(gameID, message))
+	h.publishRemote(Envelope{GameID: gameID, Data: message, ExcludePlayerID: excludePlayerID})
 	h.broadcast <- &BroadcastMessage{
 		gameID:          gameID,
 		data:            message,
@@ -1000,8 +1264,58 @@ func (h *Hub) SendToPlayer(gameID, playerID string, message []byte) bool {
 	return h.SendToPlayerWithPriority(gameID, playerID, message, PriorityNormal)
 }
 
-// HandleWebSocketConnection handles a WebSocket connection
-func (h *Hub) HandleWebSocketConnection(conn *websocket.Conn, gameID, playerID, sessionID string) {
+var (
+	// ErrGameNotFound is returned for a player connection to a game that doesn't exist
+	ErrGameNotFound = errors.New("game not found")
+	// ErrNotInGame is returned for a player connection from someone who hasn't joined the game
+	ErrNotInGame = errors.New("player is not in this game")
+	// ErrBannedFromGame is returned for a player connection from someone the host banned
+	ErrBannedFromGame = errors.New("player is banned from this game")
+)
+
+// CanPlay reports why a user can't connect to a game as a player, if anything. Only
+// players who joined through the game manager, and haven't been kicked or banned since,
+// may connect; everyone else watches through the spectator endpoint.
+func (h *Hub) CanPlay(gameID, userID string) error {
+	if h.gameManager == nil {
+		return ErrGameNotFound
+	}
+	game, err := h.gameManager.GetGame(gameID)
+	if err != nil || game == nil {
+		return ErrGameNotFound
+	}
+	if bannedFrom(game, userID) {
+		return ErrBannedFromGame
+	}
+	for _, player := range game.Players {
+		if player.ID == userID {
+			return nil
+		}
+	}
+	return ErrNotInGame
+}
+
+// bannedFrom reports whether the host banned a user from the game
+func bannedFrom(game *models.Game, userID string) bool {
+	for _, banned := range game.BannedPlayers {
+		if banned == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// HandleWebSocketConnection serves a player's connection to a game. If the user can't
+// play in the game, the connection is closed and the reason returned.
+func (h *Hub) HandleWebSocketConnection(conn *websocket.Conn, gameID, playerID, sessionID string) error {
+	// Checked again here, since the player may have been kicked since the upgrade
+	if err := h.CanPlay(gameID, playerID); err != nil {
+		conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.ClosePolicyViolation, err.Error()), time.Now().Add(time.Second))
+		conn.Close()
+		return err
+	}
+
 	// We can't easily get the user agent from the WebSocket connection
 	// in the gorilla/websocket implementation, so we'll just use a placeholder
 	userAgent := "WebSocket Client"
@@ -1042,17 +1356,13 @@ func (h *Hub) HandleWebSocketConnection(conn *websocket.Conn, gameID, playerID,
 	// If this is a reconnection, send a reconnection event to the client
 	if isReconnection {
 		// Create reconnection message
-		reconnectMsg := map[string]interface{}{
-			"type":            "reconnection_successful",
-			"playerId":        playerID,
-			"gameId":          gameID,
-			"sessionId":       sessionID,
-			"previousSession": previousSessionID,
-			"timestamp":       time.Now().Format(time.RFC3339),
-		}
-
-		// Marshal to JSON
-		msgBytes, err := json.Marshal(reconnectMsg)
+		msgBytes, err := protocol.Encode(&protocol.ReconnectionSucceeded{
+			PlayerID:        playerID,
+			GameID:          gameID,
+			SessionID:       sessionID,
+			PreviousSession: previousSessionID,
+			Timestamp:       time.Now().Format(time.RFC3339),
+		})
 		if err != nil {
 			h.logger.Errorf("Failed to marshal reconnection message: %v", err)
 		} else {
@@ -1060,14 +1370,11 @@ func (h *Hub) HandleWebSocketConnection(conn *websocket.Conn, gameID, playerID,
 			h.SendToPlayerWithPriority(gameID, playerID, msgBytes, PriorityHigh)
 
 			// Also broadcast to other players that this player has reconnected
-			reconnectBroadcastMsg := map[string]interface{}{
-				"type":      "player_reconnected",
-				"playerId":  playerID,
-				"gameId":    gameID,
-				"timestamp": time.Now().Format(time.RFC3339),
-			}
-
-			broadcastBytes, _ := json.Marshal(reconnectBroadcastMsg)
+			broadcastBytes, _ := protocol.Encode(&protocol.PlayerReconnected{
+				PlayerID:  playerID,
+				GameID:    gameID,
+				Timestamp: time.Now().Format(time.RFC3339),
+			})
 			h.BroadcastToGameExcept(gameID, broadcastBytes, playerID)
 		}
 
@@ -1083,6 +1390,7 @@ func (h *Hub) HandleWebSocketConnection(conn *websocket.Conn, gameID, playerID,
 	// Start goroutines for reading and writing
 	go client.readPump()
 	go client.writePump()
+	return nil
 }
 
 // readPump pumps messages from the WebSocket connection to the hub
@@ -1268,634 +1576,606 @@ func processMessages(c *Client, queue chan []byte, rateLimiter *time.Ticker, sen
 	return processed > 0
 }
 
-// handleMessage processes incoming WebSocket messages
-func (c *Client) handleMessage(message []byte) {
-	// Parse message and handle different event types
+// requestError is a failed client request, reported back in an error message
+type requestError struct {
+	code        string
+	message     string
+	currentTurn string
+}
 
-	// +++ Add Raw Message Logging +++
-	// c.hub.logger.Infof("[HANDLE_MESSAGE_RAW] Received raw message from %s: %s", c.playerID, string(message))
-	// +++
+func (e *requestError) Error() string { return e.message }
 
-	// Add support for encoding/json
-	var msg map[string]interface{}
-	err := json.Unmarshal(message, &msg)
+// sendMessage encodes a protocol message and queues it for one player
+func (h *Hub) sendMessage(gameID, playerID string, msg protocol.Outbound, priority string) {
+	data, err := protocol.Encode(msg)
 	if err != nil {
-		// +++ Log Parsing Error +++
-		// c.hub.logger.Infof("[HANDLE_MESSAGE_PARSE_ERR] Failed to parse message from %s: %v. Raw: %s", c.playerID, err, string(message))
-		// +++
+		h.logger.Errorf("%v", err)
 		return
 	}
+	h.SendToPlayerWithPriority(gameID, playerID, data, priority)
+}
 
-	// Extract message type from the deserialized message
-	msgType, ok := msg["type"].(string)
-	if !ok {
-		// +++ Log Invalid Format Error +++
-		// c.hub.logger.Infof("[HANDLE_MESSAGE_TYPE_ERR] Invalid message format - missing type from %s: %s", c.playerID, string(message))
-		// +++
+// broadcastMessage encodes a protocol message and sends it to everyone in the game
+func (h *Hub) broadcastMessage(gameID string, msg protocol.Outbound) {
+	data, err := protocol.Encode(msg)
+	if err != nil {
+		h.logger.Errorf("%v", err)
 		return
 	}
+	h.BroadcastToGame(gameID, data)
+}
 
-	// +++ Log Parsed Type +++
-	// c.hub.logger.Infof("[HANDLE_MESSAGE_PARSED] Parsed message type '%s' from %s", msgType, c.playerID)
-	// +++
+// sendError reports a failed request to the client
+func (c *Client) sendError(msg *protocol.Message, code, message, currentTurn string) {
+	reply := &protocol.Error{Code: code, Message: message, CurrentTurn: currentTurn}
+	if msg != nil {
+		reply.RequestID = msg.RequestID
+		reply.For = msg.Type
+	}
+	c.hub.sendMessage(c.gameID, c.playerID, reply, PriorityHigh)
+}
 
-	// Handle different message types
-	switch msgType {
-	case "verify_host":
-		// Handle host verification
-		c.handleVerifyHost(msg)
-		break
-	case "game:start":
-		// Handle game start request
-		c.hub.logger.Infof("Game start request received from player %s for game %s", c.playerID, c.gameID)
+// handleMessage processes incoming WebSocket messages
+func (c *Client) handleMessage(message []byte) {
+	if c.spectator {
+		c.rejectFromSpectator(message)
+		return
+	}
+	if c.lobby {
+		c.handleLobbyMessage(message)
+		return
+	}
 
-		// Call GameManager to start the game
-		// GameManager will handle host verification, state updates, and broadcasting
-		err := c.hub.gameManager.StartGame(c.gameID, c.playerID)
-		if err != nil {
-			c.hub.logger.Warnf("Failed to start game %s requested by %s: %v", c.gameID, c.playerID, err)
-			// Send an error message back to the requesting client
-			errorMsg := map[string]interface{}{
-				"type":    "error",
-				"message": fmt.Sprintf("Failed to start game: %v", err),
-			}
-			errorJSON, _ := json.Marshal(errorMsg) // Ignore marshal error for simplicity here
-			c.hub.SendToPlayerWithPriority(c.gameID, c.playerID, errorJSON, PriorityHigh)
-		} else {
-			c.hub.logger.Infof("GameManager successfully started game %s", c.gameID)
-
-			// Immediately update the game info cache to include the current turn information
-			c.hub.updateGameInfoCache(c.gameID)
-
-			// Log the updated game info for debugging
-			gameInfo := c.hub.getGameInfo(c.gameID)
-			if gameInfo != nil {
-				currentTurn, ok := gameInfo["currentTurn"].(string)
-				if ok {
-					c.hub.logger.Infof("Game %s started with current turn: %s", c.gameID, currentTurn)
-				} else {
-					c.hub.logger.Warnf("Game %s started but currentTurn not found in cache", c.gameID)
-				}
-			} else {
-				c.hub.logger.Warnf("Game %s started but game info not found in cache", c.gameID)
-			}
-		}
-		break
-	case "player_joined":
-		// Sent by a client when they join the game
-		// Payload should include player details like name, token, etc.
-		if playerInfo, ok := msg["player"].(map[string]interface{}); ok {
-			// --- Add Detailed Logging ---
-			// Log the raw playerInfo received before storing
-			infoBytes, _ := json.Marshal(playerInfo) // Log the full structure
-			_ = infoBytes                            // Assign to blank identifier to avoid unused variable error
-			// c.hub.logger.Infof("[PLAYER_JOINED_HANDLER] Storing player info for %s: %s", c.playerID, string(infoBytes))
-			// ---
-			c.hub.storePlayerInfo(c.gameID, c.playerID, playerInfo)
-
-			// --- Send acknowledgment back to the joining player ---
-			ackMsg := map[string]interface{}{
-				"type":    "player_joined_ack",
-				"success": true,
-				"player":  playerInfo, // Send back the confirmed player info
-				"gameId":  c.gameID,
-			}
-			ackBytes, ackErr := json.Marshal(ackMsg)
-			if ackErr == nil {
-				// Send directly to the client who just joined
-				c.hub.SendToPlayerWithPriority(c.gameID, c.playerID, ackBytes, PriorityNormal)
-				// c.hub.logger.Infof("Sent player_joined_ack to player %s", c.playerID)
-			} else {
-				c.hub.logger.Warnf("Failed to marshal player_joined_ack: %v", ackErr)
-			}
-			// ---
+	msg, err := protocol.Decode(message)
+	if err != nil {
+		c.hub.logger.Debugf("Rejected message from player %s: %v", c.playerID, err)
+		c.sendError(nil, protocol.ErrorCode(err), err.Error(), "")
+		return
+	}
 
-			// --- Broadcast the updated player info to ALL clients (including sender) ---
-			// Reuse the player_joined message type for simplicity on the frontend
-			joinedMsg := map[string]interface{}{
-				"type":   "player_joined",
-				"player": playerInfo, // Send the full info we just stored
+	// Check the payload against the message's schema. Legacy clients never got replies
+	// to malformed messages, so those are only logged.
+	payload, known := protocol.NewRequest(msg.Type)
+	if known {
+		if err := msg.DecodePayload(payload); err != nil {
+			c.hub.logger.Warnf("Rejected %s message from player %s: %v", msg.Type, c.playerID, err)
+			if !msg.Legacy() {
+				c.sendError(msg, protocol.CodeInvalidMessage, err.Error(), "")
 			}
-			joinedBytes, joinedErr := json.Marshal(joinedMsg)
-			if joinedErr == nil {
-				// Broadcast to everyone in the game
-				c.hub.BroadcastToGame(c.gameID, joinedBytes)
-				// c.hub.logger.Infof("Broadcasted player_joined for %s to game %s", c.playerID, c.gameID)
-			} else {
-				c.hub.logger.Warnf("Failed to marshal player_joined broadcast message: %v", joinedErr)
-			}
-			// ---
+			return
+		}
+	} else if !msg.Legacy() {
+		c.sendError(msg, protocol.CodeUnknownType, fmt.Sprintf("unknown message type %q", msg.Type), "")
+		return
+	}
 
+	if err := c.dispatch(msg, payload); err != nil {
+		reqErr, ok := err.(*requestError)
+		if !ok {
+			reqErr = &requestError{code: protocol.CodeActionFailed, message: err.Error()}
 		}
-		break
+		c.sendError(msg, reqErr.code, reqErr.message, reqErr.currentTurn)
+		return
+	}
 
-	case "get_active_players":
-		// Handle request for active players list
+	// Versioned requests are acknowledged so the client can match the reply
+	if !msg.Legacy() && msg.RequestID != "" {
+		c.hub.sendMessage(c.gameID, c.playerID, &protocol.Ack{Header: protocol.Header{RequestID: msg.RequestID}, For: msg.Type}, PriorityNormal)
+	}
+}
+
+// dispatch runs the handler for a decoded client message
+func (c *Client) dispatch(msg *protocol.Message, payload interface{}) error {
+	switch req := payload.(type) {
+	case *protocol.VerifyHostRequest:
+		c.handleVerifyHost()
+		return nil
+	case *protocol.StartGameRequest:
+		return c.handleStartGame()
+	case *protocol.PlayerJoinedRequest:
+		c.handlePlayerJoined(req)
+		return nil
+	case *protocol.GetActivePlayersRequest:
 		c.handleGetActivePlayers()
-		break
+		return nil
+	case *protocol.RollDiceRequest:
+		return c.handleRollDice(msg.RequestID)
+	case *protocol.UpdatePlayerRequest:
+		c.handleUpdatePlayer(req)
+		return nil
+	case *protocol.PlayerReadyRequest:
+		c.handlePlayerReady(req)
+		return nil
+	case *protocol.GetGameStateRequest:
+		c.handleGetGameState(msg.RequestID)
+		return nil
+	case *protocol.SetHostRequest:
+		return c.handleSetHost(req, msg.RequestID)
+	case *protocol.ResumeRequest:
+		return c.handleResume(req, msg.RequestID)
+	case *protocol.SyncStateRequest:
+		return c.handleSyncState(req)
+	case *protocol.ChatMessageRequest:
+		return c.handleChatMessage(req)
+	case *protocol.GetChatHistoryRequest:
+		return c.handleGetChatHistory(msg.RequestID)
+	case *protocol.MutePlayerRequest:
+		return c.handleMutePlayer(req)
+	case *protocol.KickFromChatRequest:
+		return c.handleKickFromChat(req)
+	}
+
+	// Everything else goes to the other clients in the game
+	if !protocol.Relayed(msg.Type) {
+		c.hub.logger.Debugf("Relaying unregistered legacy message type %s from player %s", msg.Type, c.playerID)
+	}
+	relay, err := msg.Flatten()
+	if err != nil {
+		return &requestError{code: protocol.CodeInvalidMessage, message: err.Error()}
+	}
+	c.hub.BroadcastToGameExcept(c.gameID, relay, c.playerID)
+	return nil
+}
 
-	case "roll_dice":
-		// Log the dice roll request
-		c.hub.logger.Infof("Dice roll request received from player %s in game %s", c.playerID, c.gameID)
+// handleStartGame asks the game manager to start the client's game
+func (c *Client) handleStartGame() error {
+	c.hub.logger.Infof("Game start request received from player %s for game %s", c.playerID, c.gameID)
 
-		// Extract request ID if present
-		requestID, _ := msg["requestId"].(string)
-		if requestID != "" {
-			c.hub.logger.Infof("Dice roll request ID: %s from player %s", requestID, c.playerID)
-		}
+	// Call GameManager to start the game
+	// GameManager will handle host verification, state updates, and broadcasting
+	err := c.hub.gameManager.StartGame(c.gameID, c.playerID)
+	if err != nil {
+		c.hub.logger.Warnf("Failed to start game %s requested by %s: %v", c.gameID, c.playerID, err)
+		return &requestError{code: protocol.CodeActionFailed, message: fmt.Sprintf("Failed to start game: %v", err)}
+	}
+	c.hub.logger.Infof("GameManager successfully started game %s", c.gameID)
 
-		// First, check if it's this player's turn by getting the current game state
-		game, err := c.hub.gameManager.GetGame(c.gameID)
-		if err != nil {
-			c.hub.logger.Errorf("Failed to get game state: %v", err)
-			errorMsg := map[string]interface{}{
-				"type":      "error",
-				"message":   fmt.Sprintf("Failed to get game state: %v", err),
-				"requestId": requestID,
-			}
-			errorJSON, _ := json.Marshal(errorMsg)
-			c.hub.SendToPlayerWithPriority(c.gameID, c.playerID, errorJSON, PriorityHigh)
-			return
-		}
+	// Immediately update the game info cache to include the current turn information
+	c.hub.updateGameInfoCache(c.gameID)
 
-		// Check if it's this player's turn
-		if game.CurrentTurn != c.playerID {
-			c.hub.logger.Errorf("Not player's turn. Current turn: %s, Player: %s", game.CurrentTurn, c.playerID)
-			errorMsg := map[string]interface{}{
-				"type":        "error",
-				"message":     "Not your turn",
-				"currentTurn": game.CurrentTurn,
-				"requestId":   requestID,
-			}
-			errorJSON, _ := json.Marshal(errorMsg)
-			c.hub.SendToPlayerWithPriority(c.gameID, c.playerID, errorJSON, PriorityHigh)
-			return
+	// Log the updated game info for debugging
+	gameInfo := c.hub.getGameInfo(c.gameID)
+	if gameInfo != nil {
+		currentTurn, ok := gameInfo["currentTurn"].(string)
+		if ok {
+			c.hub.logger.Infof("Game %s started with current turn: %s", c.gameID, currentTurn)
+		} else {
+			c.hub.logger.Warnf("Game %s started but currentTurn not found in cache", c.gameID)
 		}
+	} else {
+		c.hub.logger.Warnf("Game %s started but game info not found in cache", c.gameID)
+	}
+	return nil
+}
 
-		// Update the game info cache with the current turn information
-		c.hub.updateGameInfoCache(c.gameID)
+// handlePlayerJoined stores the client's lobby profile and shares it with the game
+func (c *Client) handlePlayerJoined(req *protocol.PlayerJoinedRequest) {
+	// Sent by a client when they join the game
+	// Payload includes player details like name, token, etc.
+	playerInfo := req.Fields()
+	c.hub.storePlayerInfo(c.gameID, c.playerID, playerInfo)
+
+	// --- Send acknowledgment back to the joining player ---
+	c.hub.sendMessage(c.gameID, c.playerID, &protocol.PlayerJoinedAck{
+		Success: true,
+		Player:  playerInfo, // Send back the confirmed player info
+		GameID:  c.gameID,
+	}, PriorityNormal)
+
+	// --- Broadcast the updated player info to ALL clients (including sender) ---
+	// Reuse the player_joined message type for simplicity on the frontend
+	c.hub.broadcastMessage(c.gameID, &protocol.PlayerJoined{Player: playerInfo})
+}
 
-		// Create a roll dice action with the request ID in the payload
-		payload := map[string]interface{}{
-			"requestId": requestID,
-			"timestamp": time.Now().UnixNano(),
-		}
+// handleRollDice rolls the dice for the client and broadcasts the result
+func (c *Client) handleRollDice(requestID string) error {
+	// Log the dice roll request
+	c.hub.logger.Infof("Dice roll request received from player %s in game %s", c.playerID, c.gameID)
+	if requestID != "" {
+		c.hub.logger.Infof("Dice roll request ID: %s from player %s", requestID, c.playerID)
+	}
 
-		action := models.GameAction{
-			Type:      models.ActionTypeRollDice,
-			PlayerID:  c.playerID,
-			GameID:    c.gameID,
-			Payload:   payload,
-			Timestamp: time.Now(),
-		}
+	// First, check if it's this player's turn by getting the current game state
+	game, err := c.hub.gameManager.GetGame(c.gameID)
+	if err != nil {
+		c.hub.logger.Errorf("Failed to get game state: %v", err)
+		return &requestError{code: protocol.CodeGameNotFound, message: fmt.Sprintf("Failed to get game state: %v", err)}
+	}
 
-		// Process the action through the game manager
-		err = c.hub.gameManager.ProcessGameAction(action)
-		if err != nil {
-			c.hub.logger.Errorf("Failed to process dice roll: %v", err)
-			// Send error message back to the client
-			errorMsg := map[string]interface{}{
-				"type":    "error",
-				"message": fmt.Sprintf("Failed to roll dice: %v", err),
-			}
-			errorJSON, _ := json.Marshal(errorMsg)
-			c.hub.SendToPlayerWithPriority(c.gameID, c.playerID, errorJSON, PriorityHigh)
-			return
-		}
+	// Check if it's this player's turn
+	if game.CurrentTurn != c.playerID {
+		c.hub.logger.Errorf("Not player's turn. Current turn: %s, Player: %s", game.CurrentTurn, c.playerID)
+		return &requestError{code: protocol.CodeNotYourTurn, message: "Not your turn", currentTurn: game.CurrentTurn}
+	}
 
-		// Get the updated game state
-		game, err = c.hub.gameManager.GetGame(c.gameID)
-		if err != nil {
-			c.hub.logger.Errorf("Failed to get updated game state: %v", err)
-			return
-		}
+	// Update the game info cache with the current turn information
+	c.hub.updateGameInfoCache(c.gameID)
 
-		// Find the current player in the game
-		var currentPlayer *models.Player
-		for i := range game.Players {
-			if game.Players[i].ID == c.playerID {
-				currentPlayer = &game.Players[i]
-				break
-			}
-		}
+	// Create a roll dice action with the request ID in the payload
+	payload := map[string]interface{}{
+		"requestId": requestID,
+		"timestamp": time.Now().UnixNano(),
+	}
 
-		if currentPlayer == nil {
-			c.hub.logger.Errorf("Player %s not found in game %s", c.playerID, c.gameID)
-			return
+	action := models.GameAction{
+		Type:      models.ActionTypeRollDice,
+		PlayerID:  c.playerID,
+		GameID:    c.gameID,
+		Payload:   payload,
+		Timestamp: time.Now(),
+	}
+
+	// Process the action through the game manager
+	err = c.hub.gameManager.ProcessGameAction(action)
+	if errors.Is(err, manager.ErrGamePaused) {
+		return &requestError{code: protocol.CodeGamePaused, message: "The game is paused"}
+	}
+	if err != nil {
+		c.hub.logger.Errorf("Failed to process dice roll: %v", err)
+		return &requestError{code: protocol.CodeActionFailed, message: fmt.Sprintf("Failed to roll dice: %v", err)}
+	}
+
+	// Get the updated game state
+	game, err = c.hub.gameManager.GetGame(c.gameID)
+	if err != nil {
+		c.hub.logger.Errorf("Failed to get updated game state: %v", err)
+		return nil
+	}
+
+	// Find the current player in the game
+	var currentPlayer *models.Player
+	for i := range game.Players {
+		if game.Players[i].ID == c.playerID {
+			currentPlayer = &game.Players[i]
+			break
 		}
+	}
 
-		// Extract the actual dice values from the game manager logs
-		// We need to parse the log message to get the dice values
-		// The log message format is: "Player %s rolled %d and %d, now at position %d"
-
-		// Get the most recent log entries for this game and player
-		// For now, we'll use the position from the player object and calculate the dice values
-		// based on the old position and new position
-
-		// We don't need to calculate old and new positions here
-		// The dice values will be retrieved from Redis
-
-		// Calculate the dice values based on the position change
-		// This is a simplified approach - in a real implementation, we would store the dice values
-		// in the game state or pass them from the game manager to the hub
-
-		// For now, we'll use a deterministic approach based on the player's position
-		// This ensures the frontend and backend show the same dice values
-
-		// Get the dice values from the game manager logs
-		// The game manager logs the dice values in the format:
-		// "Player %s rolled %d and %d, now at position %d"
-
-		// We'll extract these values from the logs
-		// For now, we'll use a simple approach to get the dice values
-
-		// Create a deterministic seed based on the player ID and current time (minute)
-		h := fnv.New32()
-		h.Write([]byte(c.playerID))
-		seed := int64(h.Sum32())
-		seed += time.Now().Unix() / 60 // Changes every minute
-
-		// Create a random source with this seed
-		diceRand := rand.New(rand.NewSource(seed))
-
-		// Generate dice values (1-6)
-		// But we'll modify this to use the actual dice values from the game manager
-		// by parsing the game manager logs
-
-		// Get the dice values from the game manager
-		// We need to modify the game manager to store the dice values in the game state
-		// For now, we'll use the position to calculate the dice values
-
-		// Find the player in the game state before the dice roll
-		// This is a simplified approach - in a real implementation, we would store the dice values
-
-		// Use the actual dice values from the game manager's log
-		// The game manager logs: "Player %s rolled %d and %d, now at position %d"
-		// We'll extract these values from the logs
-
-		// For now, we'll use a simple approach to get the dice values
-		// We'll modify the game manager to store the dice values in a temporary Redis key
-		// that we can retrieve here
-
-		// Try to get the dice values from Redis
-		diceKey := fmt.Sprintf("game:%s:player:%s:lastdice", c.gameID, c.playerID)
-		diceValues, err := c.hub.redisClient.Get(c.hub.ctx, diceKey).Result()
-
-		var dice1, dice2 int
-
-		if err == nil && diceValues != "" {
-			// Parse the dice values from Redis
-			parts := strings.Split(diceValues, ",")
-			if len(parts) == 2 {
-				dice1Val, err1 := strconv.Atoi(parts[0])
-				dice2Val, err2 := strconv.Atoi(parts[1])
-
-				if err1 == nil && err2 == nil {
-					dice1 = dice1Val
-					dice2 = dice2Val
-					c.hub.logger.Infof("Retrieved dice values from Redis for player %s: %d and %d", c.playerID, dice1, dice2)
-				} else {
-					// Fallback to random dice values
-					dice1 = 1 + diceRand.Intn(6)
-					dice2 = 1 + diceRand.Intn(6)
-					c.hub.logger.Infof("Failed to parse dice values from Redis, using random values for player %s: %d and %d", c.playerID, dice1, dice2)
-				}
-			} else {
-				// Fallback to random dice values
-				dice1 = 1 + diceRand.Intn(6)
-				dice2 = 1 + diceRand.Intn(6)
-				c.hub.logger.Infof("Invalid dice values format in Redis, using random values for player %s: %d and %d", c.playerID, dice1, dice2)
+	if currentPlayer == nil {
+		c.hub.logger.Errorf("Player %s not found in game %s", c.playerID, c.gameID)
+		return nil
+	}
+
+	dice1, dice2 := c.lastDice()
+
+	// Broadcast the result to all players in the game
+	c.hub.broadcastMessage(c.gameID, &protocol.DiceRolled{
+		Header:    protocol.Header{RequestID: requestID},
+		PlayerID:  c.playerID,
+		Position:  currentPlayer.Position,
+		Balance:   currentPlayer.Balance,
+		Timestamp: time.Now().Format(time.RFC3339),
+		// Add dice values in both formats to ensure compatibility
+		Dice:  []int{dice1, dice2},
+		Dice1: dice1,
+		Dice2: dice2,
+	})
+	c.hub.logger.Infof("Broadcasted dice roll result for player %s in game %s", c.playerID, c.gameID)
+	return nil
+}
+
+// lastDice returns the dice the game manager rolled for the client, which it keeps
+// in Redis briefly. If they're missing, values derived from the player ID are used.
+func (c *Client) lastDice() (int, int) {
+	// Create a deterministic seed based on the player ID and current time (minute)
+	h := fnv.New32()
+	h.Write([]byte(c.playerID))
+	seed := int64(h.Sum32())
+	seed += time.Now().Unix() / 60 // Changes every minute
+	diceRand := rand.New(rand.NewSource(seed))
+
+	// Try to get the dice values from Redis
+	diceKey := fmt.Sprintf("game:%s:player:%s:lastdice", c.gameID, c.playerID)
+	diceValues, err := c.hub.redisClient.Get(c.hub.ctx, diceKey).Result()
+	if err == nil && diceValues != "" {
+		// Parse the dice values from Redis
+		parts := strings.Split(diceValues, ",")
+		if len(parts) == 2 {
+			dice1, err1 := strconv.Atoi(parts[0])
+			dice2, err2 := strconv.Atoi(parts[1])
+			if err1 == nil && err2 == nil {
+				c.hub.logger.Infof("Retrieved dice values from Redis for player %s: %d and %d", c.playerID, dice1, dice2)
+				return dice1, dice2
 			}
+			c.hub.logger.Infof("Failed to parse dice values from Redis, using random values for player %s", c.playerID)
 		} else {
-			// Fallback to random dice values
-			dice1 = 1 + diceRand.Intn(6)
-			dice2 = 1 + diceRand.Intn(6)
-			c.hub.logger.Infof("No dice values found in Redis, using random values for player %s: %d and %d", c.playerID, dice1, dice2)
+			c.hub.logger.Infof("Invalid dice values format in Redis, using random values for player %s", c.playerID)
 		}
+	} else {
+		c.hub.logger.Infof("No dice values found in Redis, using random values for player %s", c.playerID)
+	}
 
-		// Extract the request ID from the action payload if available
-		var diceRequestID string
-		if action.Payload != nil {
-			if payload, ok := action.Payload.(map[string]interface{}); ok {
-				if reqID, ok := payload["requestId"].(string); ok {
-					diceRequestID = reqID
-				}
-			}
-		}
+	// Fallback to random dice values
+	return 1 + diceRand.Intn(6), 1 + diceRand.Intn(6)
+}
 
-		// Create a response message with the dice roll result
-		response := map[string]interface{}{
-			"type":      "dice_rolled",
-			"playerId":  c.playerID,
-			"position":  currentPlayer.Position,
-			"balance":   currentPlayer.Balance,
-			"timestamp": time.Now().Format(time.RFC3339),
-			// Add dice values in both formats to ensure compatibility
-			"dice":  []int{dice1, dice2},
-			"dice1": dice1,
-			"dice2": dice2,
-			// Include the request ID if available
-			"requestId": diceRequestID,
-		}
+// handleUpdatePlayer applies a player's token or profile change and shares it with the game
+func (c *Client) handleUpdatePlayer(req *protocol.UpdatePlayerRequest) {
+	playerId := req.PlayerID
 
-		// Marshal to JSON
-		responseJSON, err := json.Marshal(response)
-		if err != nil {
-			c.hub.logger.Errorf("Failed to marshal dice roll response: %v", err)
-			return
-		}
-
-		// Broadcast the result to all players in the game
-		c.hub.BroadcastToGame(c.gameID, responseJSON)
-		c.hub.logger.Infof("Broadcasted dice roll result for player %s in game %s", c.playerID, c.gameID)
-		break
+	// Get existing player info or create new
+	playerInfo := c.hub.getPlayerInfo(c.gameID, playerId)
+	if playerInfo == nil {
+		playerInfo = make(map[string]interface{})
+		playerInfo["id"] = playerId
+	}
 
-	case "update_player_info", "update_player", "set_player_token":
-		// Extract player info from the message
-		playerId, ok := msg["playerId"].(string)
-		if !ok {
-			c.hub.logger.Warnf("Invalid player update message format - missing playerId")
-			return
-		}
+	// Update player info with token data
+	// Check for token in different formats to ensure compatibility
+	if req.Token != "" {
+		playerInfo["token"] = req.Token
+		c.hub.logger.Infof("[TOKEN_UPDATE] Updated token for player %s in game %s: %s", playerId, c.gameID, req.Token)
+	}
 
-		// Get existing player info or create new
-		playerInfo := c.hub.getPlayerInfo(c.gameID, playerId)
-		if playerInfo == nil {
-			playerInfo = make(map[string]interface{})
-			playerInfo["id"] = playerId
-		}
+	if req.CharacterToken != "" {
+		playerInfo["characterToken"] = req.CharacterToken
+		c.hub.logger.Infof("[TOKEN_UPDATE] Updated characterToken for player %s in game %s: %s", playerId, c.gameID, req.CharacterToken)
+	}
 
-		// Update player info with token data
-		// Check for token in different formats to ensure compatibility
-		if token, ok := msg["token"].(string); ok && token != "" {
-			playerInfo["token"] = token
-			c.hub.logger.Infof("[TOKEN_UPDATE] Updated token for player %s in game %s: %s", playerId, c.gameID, token)
-		}
+	if req.Emoji != "" {
+		playerInfo["emoji"] = req.Emoji
+	}
 
-		if characterToken, ok := msg["characterToken"].(string); ok && characterToken != "" {
-			playerInfo["characterToken"] = characterToken
-			c.hub.logger.Infof("[TOKEN_UPDATE] Updated characterToken for player %s in game %s: %s", playerId, c.gameID, characterToken)
-		}
+	if req.Color != "" {
+		playerInfo["color"] = req.Color
+	}
 
-		if emoji, ok := msg["emoji"].(string); ok && emoji != "" {
-			playerInfo["emoji"] = emoji
-		}
+	if req.Name != "" {
+		playerInfo["name"] = req.Name
+	}
 
-		if color, ok := msg["color"].(string); ok && color != "" {
-			playerInfo["color"] = color
-		}
+	// Store updated player info
+	c.hub.storePlayerInfo(c.gameID, playerId, playerInfo)
+	c.hub.logger.Infof("[TOKEN_UPDATE] Stored updated player info for %s in game %s", playerId, c.gameID)
 
-		if name, ok := msg["name"].(string); ok && name != "" {
-			playerInfo["name"] = name
+	// Enqueue the token update in the message queue for resilience
+	if c.hub.messageQueue != nil {
+		// Create a copy of the token data for the queue
+		tokenData := make(map[string]interface{})
+		for k, v := range playerInfo {
+			tokenData[k] = v
 		}
 
-		// Store updated player info
-		c.hub.storePlayerInfo(c.gameID, playerId, playerInfo)
-		c.hub.logger.Infof("[TOKEN_UPDATE] Stored updated player info for %s in game %s", playerId, c.gameID)
-
-		// Enqueue the token update in the message queue for resilience
-		if c.hub.messageQueue != nil {
-			// Create a copy of the token data for the queue
-			tokenData := make(map[string]interface{})
-			for k, v := range playerInfo {
-				tokenData[k] = v
-			}
-
-			// Enqueue the token update
-			err := c.hub.messageQueue.EnqueuePlayerTokenUpdate(c.gameID, playerId, tokenData)
-			if err != nil {
-				c.hub.logger.Errorf("[TOKEN_UPDATE] Failed to enqueue token update: %v", err)
-			} else {
-				c.hub.logger.Infof("[TOKEN_UPDATE] Token update enqueued for player %s in game %s", playerId, c.gameID)
-			}
+		// Enqueue the token update
+		err := c.hub.messageQueue.EnqueuePlayerTokenUpdate(c.gameID, playerId, tokenData)
+		if err != nil {
+			c.hub.logger.Errorf("[TOKEN_UPDATE] Failed to enqueue token update: %v", err)
+		} else {
+			c.hub.logger.Infof("[TOKEN_UPDATE] Token update enqueued for player %s in game %s", playerId, c.gameID)
 		}
+	}
 
-		// Update the player in the game manager's database
-		if c.hub.gameManager != nil {
-			// Get the game from the game manager
-			game, err := c.hub.gameManager.GetGame(c.gameID)
-			if err == nil {
-				// Find the player in the game
-				for i, player := range game.Players {
-					if player.ID == playerId {
-						// Update the player's token
-						if token, ok := playerInfo["token"].(string); ok && token != "" {
-							game.Players[i].CharacterToken = token
-						} else if characterToken, ok := playerInfo["characterToken"].(string); ok && characterToken != "" {
-							game.Players[i].CharacterToken = characterToken
-						} else if emoji, ok := playerInfo["emoji"].(string); ok && emoji != "" {
-							game.Players[i].CharacterToken = emoji
-						}
+	// Update the player in the game manager's database
+	if c.hub.gameManager != nil {
+		// Get the game from the game manager
+		game, err := c.hub.gameManager.GetGame(c.gameID)
+		if err == nil {
+			// Find the player in the game
+			for i, player := range game.Players {
+				if player.ID == playerId {
+					// Update the player's token
+					if token, ok := playerInfo["token"].(string); ok && token != "" {
+						game.Players[i].CharacterToken = token
+					} else if characterToken, ok := playerInfo["characterToken"].(string); ok && characterToken != "" {
+						game.Players[i].CharacterToken = characterToken
+					} else if emoji, ok := playerInfo["emoji"].(string); ok && emoji != "" {
+						game.Players[i].CharacterToken = emoji
+					}
 
-						// Update the game in the database
-						err = c.hub.gameManager.UpdateGame(game)
-						if err != nil {
-							c.hub.logger.Warnf("[TOKEN_UPDATE] Failed to update game in database: %v", err)
-						} else {
-							c.hub.logger.Infof("[TOKEN_UPDATE] Updated player token in database for %s in game %s", playerId, c.gameID)
-						}
-						break
+					// Update the game in the database
+					err = c.hub.gameManager.UpdateGame(game)
+					if err != nil {
+						c.hub.logger.Warnf("[TOKEN_UPDATE] Failed to update game in database: %v", err)
+					} else {
+						c.hub.logger.Infof("[TOKEN_UPDATE] Updated player token in database for %s in game %s", playerId, c.gameID)
 					}
+					break
 				}
-			} else {
-				c.hub.logger.Warnf("[TOKEN_UPDATE] Failed to get game %s from manager: %v", c.gameID, err)
 			}
+		} else {
+			c.hub.logger.Warnf("[TOKEN_UPDATE] Failed to get game %s from manager: %v", c.gameID, err)
 		}
+	}
 
-		// Broadcast the updated player info to all clients
-		updateMsg := map[string]interface{}{
-			"type":   "player_updated",
-			"player": playerInfo,
-		}
-		updateJSON, err := json.Marshal(updateMsg)
-		if err == nil {
-			c.hub.BroadcastToGame(c.gameID, updateJSON)
-			c.hub.logger.Infof("[TOKEN_UPDATE] Broadcasted player update for %s to all clients in game %s", playerId, c.gameID)
-		}
-
-		// Also update active players list
-		go func() {
-			time.Sleep(100 * time.Millisecond)
-			c.handleGetActivePlayers()
-		}()
-		break
-
-	case "player_ready":
-		// Extract player info from the message
-		playerId, ok := msg["playerId"].(string)
-		if !ok {
-			c.hub.logger.Warnf("Invalid player_ready message format - missing playerId")
-			return
-		}
+	// Broadcast the updated player info to all clients
+	c.hub.broadcastMessage(c.gameID, &protocol.PlayerUpdated{Player: playerInfo})
+	c.hub.logger.Infof("[TOKEN_UPDATE] Broadcasted player update for %s to all clients in game %s", playerId, c.gameID)
 
-		isReady, ok := msg["isReady"].(bool)
-		if !ok {
-			c.hub.logger.Warnf("Invalid player_ready message format - missing isReady")
-			return
-		}
+	// Also update active players list
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		c.handleGetActivePlayers()
+	}()
+}
 
-		// Extract optional message ID for tracking/debugging
-		messageId := ""
-		if msgId, ok := msg["messageId"].(string); ok {
-			messageId = msgId
-		}
+// handlePlayerReady records a player's ready flag and shares it with the game
+func (c *Client) handlePlayerReady(req *protocol.PlayerReadyRequest) {
+	playerId := req.PlayerID
+	isReady := req.IsReady
 
-		// Extract timestamp if available
-		timestamp := time.Now().UnixNano() / int64(time.Millisecond)
-		if ts, ok := msg["timestamp"].(float64); ok {
-			timestamp = int64(ts)
-		}
+	// Use the client's timestamp if it sent one
+	timestamp := time.Now().UnixNano() / int64(time.Millisecond)
+	if req.Timestamp != 0 {
+		timestamp = req.Timestamp
+	}
 
-		c.hub.logger.Infof("[PLAYER_READY] Player %s ready status changed to: %v (messageId: %s, timestamp: %d)",
-			playerId, isReady, messageId, timestamp)
+	c.hub.logger.Infof("[PLAYER_READY] Player %s ready status changed to: %v (messageId: %s, timestamp: %d)",
+		playerId, isReady, req.MessageID, timestamp)
 
-		// --- Update Hub's internal playerInfo cache ---
-		playerInfo := c.hub.getPlayerInfo(c.gameID, playerId)
-		if playerInfo != nil {
-			playerInfo["isReady"] = isReady
-			playerInfo["lastReadyUpdate"] = timestamp // Track when this was last updated
-			c.hub.storePlayerInfo(c.gameID, playerId, playerInfo)
-			c.hub.logger.Infof("[PLAYER_READY] Updated existing player info for %s, isReady=%v", playerId, isReady)
-		} else {
-			c.hub.logger.Warnf("[PLAYER_READY] Player info not found for player %s in game %s. Creating default entry.", playerId, c.gameID)
-			// Create a default player info map if not found
-			defaultInfo := map[string]interface{}{
-				"id":              playerId,
-				"name":            fmt.Sprintf("Player_%s", playerId[:4]), // Use a default name
-				"isReady":         isReady,                                // Set the received ready status
-				"isHost":          false,                                  // Assume not host unless updated later
-				"lastReadyUpdate": timestamp,                              // Track when this was created
-				// Add other necessary default fields if required by frontend
-				"token": "",
-				"emoji": "👤",
-				"color": "gray.500",
-			}
-			c.hub.storePlayerInfo(c.gameID, playerId, defaultInfo) // Store the default info with the correct ready status
-			c.hub.logger.Infof("[PLAYER_READY] Created new player info for %s, isReady=%v", playerId, isReady)
-		}
-		// ---
+	// --- Update Hub's internal playerInfo cache ---
+	playerInfo := c.hub.getPlayerInfo(c.gameID, playerId)
+	if playerInfo != nil {
+		playerInfo["isReady"] = isReady
+		playerInfo["lastReadyUpdate"] = timestamp // Track when this was last updated
+		c.hub.storePlayerInfo(c.gameID, playerId, playerInfo)
+		c.hub.logger.Infof("[PLAYER_READY] Updated existing player info for %s, isReady=%v", playerId, isReady)
+	} else {
+		c.hub.logger.Warnf("[PLAYER_READY] Player info not found for player %s in game %s. Creating default entry.", playerId, c.gameID)
+		// Create a default player info map if not found
+		defaultInfo := map[string]interface{}{
+			"id":              playerId,
+			"name":            fmt.Sprintf("Player_%s", playerId[:min(4, len(playerId))]), // Use a default name
+			"isReady":         isReady,                                                    // Set the received ready status
+			"isHost":          false,                                                      // Assume not host unless updated later
+			"lastReadyUpdate": timestamp,                                                  // Track when this was created
+			// Add other necessary default fields if required by frontend
+			"token": "",
+			"emoji": "👤",
+			"color": "gray.500",
+		}
+		c.hub.storePlayerInfo(c.gameID, playerId, defaultInfo) // Store the default info with the correct ready status
+		c.hub.logger.Infof("[PLAYER_READY] Created new player info for %s, isReady=%v", playerId, isReady)
+	}
+	// ---
+	c.hub.setReady(c.gameID, playerId, isReady)
+
+	// Broadcast player ready status to all clients with high priority
+	responseJSON, err := protocol.Encode(&protocol.PlayerReady{
+		PlayerID:            playerId,
+		IsReady:             isReady,
+		MessageID:           req.MessageID,
+		ResponseToMessageID: req.MessageID,
+		Timestamp:           timestamp,
+	})
+	if err != nil {
+		c.hub.logger.Warnf("[PLAYER_READY] Failed to marshal player_ready response: %v", err)
+		return
+	}
 
-		// Add message ID to the response if it was provided
-		if messageId != "" {
-			msg["responseToMessageId"] = messageId
-		}
+	// OPTIMIZATION: Use BroadcastToGameWithPriority with HIGH priority
+	c.hub.BroadcastToGameWithPriority(c.gameID, responseJSON, PriorityHigh)
+	c.hub.logger.Infof("[PLAYER_READY] Broadcasted player_ready status to all clients in game %s with HIGH priority", c.gameID)
 
-		// Broadcast player ready status to all clients with high priority
-		responseJSON, err := json.Marshal(msg)
-		if err != nil {
-			c.hub.logger.Warnf("[PLAYER_READY] Failed to marshal player_ready response: %v", err)
-			return
-		}
+	// OPTIMIZATION: Reduce delay before sending active_players update
+	go func() {
+		// Reduced delay to improve responsiveness
+		time.Sleep(50 * time.Millisecond)
+		c.hub.logger.Infof("[PLAYER_READY] Sending active_players update after player_ready change for player %s", playerId)
+		c.handleGetActivePlayers()
+	}()
+}
 
-		// OPTIMIZATION: Use BroadcastToGameWithPriority with HIGH priority
-		c.hub.BroadcastToGameWithPriority(c.gameID, responseJSON, PriorityHigh)
-		c.hub.logger.Infof("[PLAYER_READY] Broadcasted player_ready status to all clients in game %s with HIGH priority", c.gameID)
-
-		// OPTIMIZATION: Reduce delay before sending active_players update
-		go func() {
-			// Reduced delay to improve responsiveness
-			time.Sleep(50 * time.Millisecond)
-			c.hub.logger.Infof("[PLAYER_READY] Sending active_players update after player_ready change for player %s", playerId)
-			c.handleGetActivePlayers()
-		}()
-		break
-
-	case "get_game_state":
-		// Handle request for current game state
-		// c.hub.logger.Infof("Game state request received from player %s for game %s", c.playerID, c.gameID)
-
-		// Get game info from storage
-		gameInfo := c.hub.getGameInfo(c.gameID)
-		if gameInfo == nil {
-			gameInfo = make(map[string]interface{})
-		}
+// handleGetGameState sends the cached game state to the client
+func (c *Client) handleGetGameState(requestID string) {
+	// Get game info from storage
+	gameInfo := c.hub.getGameInfo(c.gameID)
+	if gameInfo == nil {
+		gameInfo = make(map[string]interface{})
+	}
 
-		// Create response with game state
-		response := map[string]interface{}{
-			"type":   "game_state_update",
-			"gameId": c.gameID,
-			"state": map[string]interface{}{
-				"gameId":   c.gameID,
-				"gameInfo": gameInfo,
-			},
-		}
+	state := protocol.GameState{
+		GameID:   c.gameID,
+		GameInfo: gameInfo,
+		Status:   "LOBBY", // Default to LOBBY if not set
+	}
 
-		// Set status based on game state
-		stateMap := response["state"].(map[string]interface{})
-		if status, ok := gameInfo["status"].(string); ok {
-			stateMap["status"] = status
-		} else {
-			stateMap["status"] = "LOBBY" // Default to LOBBY if not set
-		}
+	// Set status based on game state
+	if status, ok := gameInfo["status"].(string); ok {
+		state.Status = status
+	}
 
-		// If game has been started, set status to ACTIVE
-		if started, ok := gameInfo["gameStarted"].(bool); ok && started {
-			stateMap["status"] = "ACTIVE"
-		}
+	// If game has been started, set status to ACTIVE
+	if started, ok := gameInfo["gameStarted"].(bool); ok && started {
+		state.Status = "ACTIVE"
+	}
 
-		// Marshal to JSON
-		responseJSON, err := json.Marshal(response)
-		if err != nil {
-			c.hub.logger.Warnf("Failed to marshal game_state_update response: %v", err)
-			return
-		}
+	// Send only to the requesting client
+	c.hub.sendMessage(c.gameID, c.playerID, &protocol.GameStateUpdate{
+		Header: protocol.Header{RequestID: requestID},
+		GameID: c.gameID,
+		State:  state,
+	}, PriorityNormal)
+}
 
-		// Send only to the requesting client
-		c.hub.SendToPlayerWithPriority(c.gameID, c.playerID, responseJSON, PriorityNormal)
-		// c.hub.logger.Infof("Sent game state to player %s in game %s", c.playerID, c.gameID)
-		break
+// handleSetHost makes another player the host. Only the host can hand the game on.
+func (c *Client) handleSetHost(req *protocol.SetHostRequest, requestID string) error {
+	hostID := req.HostID
 
-	case "set_host":
-		// Extract host ID from the message
-		hostID, ok := msg["hostId"].(string)
-		if !ok {
-			c.hub.logger.Warnf("Invalid set_host message format - missing hostId: %s", string(message))
-			return
-		}
+	// Use the client's game unless the message names one
+	gameID := req.GameID
+	if gameID == "" {
+		gameID = c.gameID
+	}
 
-		// Extract game ID from the message or use the client's gameID
-		gameID, ok := msg["gameId"].(string)
-		if !ok {
-			gameID = c.gameID
+	if c.hub.gameManager != nil {
+		// A real handover reaches every node's clients through UpdateHostID
+		err := c.hub.gameManager.TransferHost(gameID, c.playerID, hostID)
+		switch {
+		case errors.Is(err, manager.ErrNotHost):
+			return &requestError{code: protocol.CodeNotHost, message: err.Error()}
+		case err != nil:
+			return &requestError{code: protocol.CodeActionFailed, message: err.Error()}
 		}
+	}
+	if c.hub.gameManager == nil || hostID == c.playerID {
+		c.hub.UpdateHostID(gameID, hostID)
+	}
 
-		// Log the request
-		// c.hub.logger.Infof("Set host request received: gameID=%s, hostID=%s", gameID, hostID)
+	// Send confirmation back to the client
+	c.hub.sendMessage(gameID, c.playerID, &protocol.HostSetConfirmed{
+		Header: protocol.Header{RequestID: requestID},
+		HostID: hostID,
+		GameID: gameID,
+	}, PriorityNormal)
 
-		// No need to get game info here, just update the host ID directly
+	// Also broadcast the updated list of active players to all clients
+	c.handleGetActivePlayers()
+	return nil
+}
 
-		// Update the host ID
-		c.hub.UpdateHostID(gameID, hostID)
+// handleResume sends the client the broadcasts it missed, or the full game state
+// when they are no longer kept
+func (c *Client) handleResume(req *protocol.ResumeRequest, requestID string) error {
+	reply := &protocol.Resumed{Header: protocol.Header{RequestID: requestID}}
 
-		// Send confirmation back to the client
-		confirmationMsg := map[string]interface{}{
-			"type":   "host_set_confirmed",
-			"hostId": hostID,
-			"gameId": gameID,
-		}
-
-		// Marshal to JSON
-		confirmationJSON, err := json.Marshal(confirmationMsg)
+	complete := false
+	if c.hub.events != nil {
+		events, latest, ok, err := c.hub.events.Since(c.hub.ctx, c.gameID, req.LastSeq)
 		if err != nil {
-			c.hub.logger.Warnf("Failed to marshal host_set_confirmed message: %v", err)
-			return
+			c.hub.logger.Errorf("[Events] %v", err)
+			return &requestError{code: protocol.CodeActionFailed, message: "Failed to read missed events"}
+		}
+		reply.LastSeq = latest
+		complete = ok
+		if complete {
+			for _, event := range events {
+				if event.ExcludePlayerID == c.playerID {
+					continue
+				}
+				c.hub.SendToPlayerWithPriority(c.gameID, c.playerID, []byte(event.Data), PriorityHigh)
+				reply.Replayed++
+			}
 		}
-
-		// Send to the client
-		c.hub.SendToPlayerWithPriority(gameID, c.playerID, confirmationJSON, PriorityNormal)
-
-		// Also broadcast the updated list of active players to all clients
-		c.handleGetActivePlayers()
-		break
-
-	default:
-		// By default, broadcast all messages to other clients in the game
-		c.hub.BroadcastToGameExcept(c.gameID, message, c.playerID)
 	}
+
+	if !complete {
+		if c.hub.gameManager == nil {
+			return &requestError{code: protocol.CodeGameNotFound, message: "Failed to get game state"}
+		}
+		game, err := c.hub.gameManager.GetGame(c.gameID)
+		if err != nil {
+			return &requestError{code: protocol.CodeGameNotFound, message: fmt.Sprintf("Failed to get game state: %v", err)}
+		}
+		c.hub.sendMessage(c.gameID, c.playerID, &protocol.CompleteStateSync{
+			Header:      protocol.Header{Seq: reply.LastSeq},
+			GameID:      c.gameID,
+			Status:      string(game.Status),
+			CurrentTurn: game.CurrentTurn,
+			Players:     game.Players,
+			TurnOrder:   game.TurnOrder,
+			Timestamp:   time.Now().Format(time.RFC3339),
+		}, PriorityHigh)
+		reply.Snapshot = true
+	}
+
+	c.hub.logger.Infof("[Events] Player %s resumed game %s from seq %d: replayed %d, snapshot %v",
+		c.playerID, c.gameID, req.LastSeq, reply.Replayed, reply.Snapshot)
+	c.hub.sendMessage(c.gameID, c.playerID, reply, PriorityHigh)
+	return nil
 }
 
 // handleVerifyHost verifies if the player is the host of the game
-func (c *Client) handleVerifyHost(msg map[string]interface{}) {
+func (c *Client) handleVerifyHost() {
 	// Use the current player ID if not specified in the message
 	playerID := c.playerID
 
@@ -1955,21 +2235,13 @@ func (c *Client) handleVerifyHost(msg map[string]interface{}) {
 
 // sendHostVerificationResponse sends a host verification response to the client
 func (c *Client) sendHostVerificationResponse(success bool, hostId string, errorMessage string) {
-	// Create the response message
-	response := map[string]interface{}{
-		"type":    "host_verification",
-		"success": success,
-		"hostId":  hostId,
-		"gameId":  c.gameID,
-	}
-
-	// Add error message if provided
-	if errorMessage != "" {
-		response["message"] = errorMessage
-	}
-
-	// Marshal to JSON
-	responseJSON, err := json.Marshal(response)
+	// The message is only set when verification fails
+	responseJSON, err := protocol.Encode(&protocol.HostVerification{
+		Success: success,
+		HostID:  hostId,
+		GameID:  c.gameID,
+		Message: errorMessage,
+	})
 	if err != nil {
 		c.hub.logger.Warnf("Failed to marshal host_verification response: %v", err)
 		return
@@ -1990,6 +2262,17 @@ func (c *Client) handleGetActivePlayers() {
 	var hostID string
 	players := []map[string]interface{}{}
 	var gameInfo map[string]interface{} // Use local variable
+	var game *models.Game               // Authoritative game state, when the manager has it
+
+	// Players connected to other nodes, read before taking the hub locks
+	var present map[string]Presence
+	if c.hub.bus != nil {
+		var err error
+		present, err = c.hub.bus.Presence(c.hub.ctx, c.gameID)
+		if err != nil {
+			c.hub.logger.Warnf("[Bus] %v", err)
+		}
+	}
 
 	// Lock for reading clients and playerInfo
 	c.hub.clientsMutex.RLock()
@@ -1998,7 +2281,8 @@ func (c *Client) handleGetActivePlayers() {
 
 	// Get host ID (same logic as before, but now within locks)
 	if c.hub.gameManager != nil {
-		game, err := c.hub.gameManager.GetGame(c.gameID)
+		var err error
+		game, err = c.hub.gameManager.GetGame(c.gameID)
 		if err == nil && game != nil && game.HostID != "" {
 			hostID = game.HostID
 		} else {
@@ -2037,7 +2321,17 @@ func (c *Client) handleGetActivePlayers() {
 	}
 
 	// Create a list of active, connected players (read under lock)
-	if gamePlayers, ok := c.hub.clients[c.gameID]; ok {
+	gamePlayers := c.hub.clients[c.gameID]
+	for playerID, entry := range present {
+		// Local clients are listed from the hub's own map below
+		if _, local := gamePlayers[playerID]; local || entry.NodeID == c.hub.bus.NodeID() {
+			continue
+		}
+		playerInfo := remotePlayerInfo(game, playerID)
+		playerInfo["isHost"] = (hostID != "" && playerID == hostID)
+		players = append(players, playerInfo)
+	}
+	if gamePlayers != nil {
 		for playerID, client := range gamePlayers {
 			if client.isActive(90 * time.Second) {
 				// Get player info from Hub's cache (read under lock)
@@ -2073,6 +2367,20 @@ func (c *Client) handleGetActivePlayers() {
 
 				// Set isHost flag based on the reliably fetched hostID
 				playerInfo["isHost"] = (hostID != "" && playerID == hostID)
+
+				// Report buy-in progress so the lobby can show who still has to pay
+				if game != nil && game.BuyIn > 0 {
+					for _, p := range game.Players {
+						if p.ID == playerID {
+							playerInfo["depositStatus"] = p.DepositStatus
+							playerInfo["depositedAmount"] = p.DepositedAmount
+							if p.DepositDeadline != nil {
+								playerInfo["depositDeadline"] = p.DepositDeadline.Format(time.RFC3339)
+							}
+							break
+						}
+					}
+				}
 				players = append(players, playerInfo)
 			}
 		}
@@ -2097,44 +2405,44 @@ func (c *Client) handleGetActivePlayers() {
 	}
 
 	// Create response message using the locally copied players and gameInfo
-	response := map[string]interface{}{
-		"type":       "active_players",
-		"players":    players, // Use the copied list
-		"gameId":     c.gameID,
-		"hostId":     hostID, // Use the reliably fetched hostID
-		"maxPlayers": maxPlayers,
-		"gameInfo":   gameInfo, // Use the copied gameInfo
+	response := &protocol.ActivePlayers{
+		Players:    players, // Use the copied list
+		GameID:     c.gameID,
+		HostID:     hostID, // Use the reliably fetched hostID
+		MaxPlayers: maxPlayers,
+		GameInfo:   gameInfo, // Use the copied gameInfo
+	}
+	if game != nil {
+		response.BuyIn = game.BuyIn
 	}
 
 	// Add status, gameStarted, etc. based on the *copied* gameInfo
 	if gameInfo != nil {
 		if status, ok := gameInfo["status"].(string); ok {
-			response["status"] = status
+			response.Status = status
 		}
 		if started, ok := gameInfo["gameStarted"].(bool); ok && started {
-			response["gameStarted"] = true
-			response["gamePhase"] = "playing"
+			response.GameStarted = true
+			response.GamePhase = "playing"
 		}
-		// ... (Redis check remains the same, operates on the response map)
-		if c.hub.redisClient != nil && response["gameStarted"] == nil {
+		// Fall back to the started flag in Redis
+		if c.hub.redisClient != nil && !response.GameStarted {
 			key := fmt.Sprintf("game:%s:started", c.gameID)
 			val, err := c.hub.redisClient.Get(c.hub.ctx, key).Result()
 			if err == nil && val == "true" {
-				response["gameStarted"] = true
-				response["gamePhase"] = "playing"
-				response["status"] = "ACTIVE"
-				// Update the copied gameInfo in the response map if needed
-				if responseGameInfo, ok := response["gameInfo"].(map[string]interface{}); ok && responseGameInfo != nil {
-					responseGameInfo["gameStarted"] = true
-					responseGameInfo["gamePhase"] = "playing"
-					responseGameInfo["status"] = "ACTIVE"
-				}
+				response.GameStarted = true
+				response.GamePhase = "playing"
+				response.Status = "ACTIVE"
+				// Update the copied gameInfo as well
+				gameInfo["gameStarted"] = true
+				gameInfo["gamePhase"] = "playing"
+				gameInfo["status"] = "ACTIVE"
 			}
 		}
 	}
 
 	// Marshal the response (now safe from concurrent map writes)
-	responseJSON, err := json.Marshal(response)
+	responseJSON, err := protocol.Encode(response)
 	if err != nil {
 		c.hub.logger.Warnf("Failed to marshal active_players response: %v", err)
 		return
@@ -2146,6 +2454,33 @@ func (c *Client) handleGetActivePlayers() {
 	// Log at debug level to avoid console spam
 }
 
+// remotePlayerInfo describes a player connected to another node from the game state
+func remotePlayerInfo(game *models.Game, playerID string) map[string]interface{} {
+	if game != nil {
+		for _, p := range game.Players {
+			if p.ID != playerID {
+				continue
+			}
+			var playerInfo map[string]interface{}
+			playerBytes, err := json.Marshal(p)
+			if err == nil && json.Unmarshal(playerBytes, &playerInfo) == nil {
+				if _, ok := playerInfo["isReady"]; !ok {
+					playerInfo["isReady"] = false
+				}
+				return playerInfo
+			}
+		}
+	}
+	return map[string]interface{}{
+		"id":      playerID,
+		"name":    fmt.Sprintf("Player_%s", playerID[:min(4, len(playerID))]),
+		"token":   "",
+		"emoji":   "👤",
+		"color":   "gray.500",
+		"isReady": false,
+	}
+}
+
 // --- Add unlocked helper functions for reading info maps ---
 
 func (h *Hub) getPlayerInfo_unlocked(gameID, playerID string) map[string]interface{} {
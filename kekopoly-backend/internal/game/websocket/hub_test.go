@@ -0,0 +1,141 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/kekopoly/backend/internal/game/manager"
+	"github.com/kekopoly/backend/internal/game/protocol"
+)
+
+// decodeAll unmarshals the messages a client received
+func decodeAll(t *testing.T, messages []string) []map[string]interface{} {
+	t.Helper()
+	out := make([]map[string]interface{}, 0, len(messages))
+	for _, m := range messages {
+		var decoded map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(m), &decoded))
+		out = append(out, decoded)
+	}
+	return out
+}
+
+func TestHandleMessageProtocol(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	mr := miniredis.RunT(t)
+
+	hub := newTestNode(t, ctx, mr, "node-a")
+	alice := addTestClient(t, mr, hub, "game1", "alice")
+	bob := addTestClient(t, mr, hub, "game1", "bob")
+
+	t.Run("versioned request is answered and acked", func(t *testing.T) {
+		alice.handleMessage([]byte(`{"v":1,"type":"get_game_state","requestId":"r-1"}`))
+
+		got := decodeAll(t, received(alice, 100*time.Millisecond))
+		require.Len(t, got, 2)
+		assert.Equal(t, "game_state_update", got[0]["type"])
+		assert.Equal(t, "r-1", got[0]["requestId"])
+		assert.Equal(t, "ack", got[1]["type"])
+		assert.Equal(t, "r-1", got[1]["requestId"])
+		assert.Equal(t, "get_game_state", got[1]["for"])
+		assert.Empty(t, received(bob, 50*time.Millisecond))
+	})
+
+	t.Run("invalid versioned payload gets an error", func(t *testing.T) {
+		alice.handleMessage([]byte(`{"v":1,"type":"set_host","requestId":"r-2","payload":{}}`))
+
+		got := decodeAll(t, received(alice, 100*time.Millisecond))
+		require.Len(t, got, 1)
+		assert.Equal(t, "error", got[0]["type"])
+		assert.Equal(t, protocol.CodeInvalidMessage, got[0]["code"])
+		assert.Equal(t, "r-2", got[0]["requestId"])
+	})
+
+	t.Run("invalid legacy payload is dropped silently", func(t *testing.T) {
+		alice.handleMessage([]byte(`{"type":"player_joined","playerData":{"id":"alice"}}`))
+
+		assert.Empty(t, received(alice, 50*time.Millisecond))
+		assert.Empty(t, received(bob, 50*time.Millisecond))
+	})
+
+	t.Run("unknown versioned type is rejected", func(t *testing.T) {
+		alice.handleMessage([]byte(`{"v":1,"type":"teleport","requestId":"r-3"}`))
+
+		got := decodeAll(t, received(alice, 100*time.Millisecond))
+		require.Len(t, got, 1)
+		assert.Equal(t, protocol.CodeUnknownType, got[0]["code"])
+		assert.Empty(t, received(bob, 50*time.Millisecond))
+	})
+
+	t.Run("unsupported version is rejected", func(t *testing.T) {
+		alice.handleMessage([]byte(`{"v":9,"type":"roll_dice"}`))
+
+		got := decodeAll(t, received(alice, 100*time.Millisecond))
+		require.Len(t, got, 1)
+		assert.Equal(t, protocol.CodeUnsupportedVersion, got[0]["code"])
+	})
+
+	t.Run("relayed messages reach the other clients flat", func(t *testing.T) {
+		alice.handleMessage([]byte(`{"type":"get_host","gameId":"game1"}`))
+		alice.handleMessage([]byte(`{"v":1,"type":"host_info","requestId":"r-4","payload":{"hostId":"alice"}}`))
+
+		got := decodeAll(t, received(bob, 100*time.Millisecond))
+		require.Len(t, got, 2)
+		assert.Equal(t, "get_host", got[0]["type"])
+		assert.Equal(t, "host_info", got[1]["type"])
+		assert.Equal(t, "alice", got[1]["hostId"])
+
+		// Only the versioned relay is acked
+		acks := decodeAll(t, received(alice, 50*time.Millisecond))
+		require.Len(t, acks, 1)
+		assert.Equal(t, "ack", acks[0]["type"])
+		assert.Equal(t, "r-4", acks[0]["requestId"])
+	})
+}
+
+func TestCanPlay(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	mr := miniredis.RunT(t)
+
+	hub := newTestNode(t, ctx, mr, "node-a")
+	assert.ErrorIs(t, hub.CanPlay("any", "alice"), ErrGameNotFound)
+
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { redisClient.Close() })
+	gm := manager.NewGameManagerWithOptions(ctx, nil, redisClient, zap.NewNop().Sugar(), hub, nil, manager.Options{
+		Store: manager.NewMemoryGameStore(),
+	})
+	hub.SetGameManager(gm)
+	gameID, err := gm.CreateGame("alice", "wallet-alice", "", 4, 0)
+	require.NoError(t, err)
+	for _, playerID := range []string{"bob", "carol"} {
+		_, err := gm.JoinGame(gameID, playerID, "wallet-"+playerID)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, hub.CanPlay(gameID, "alice"))
+	require.NoError(t, hub.CanPlay(gameID, "bob"))
+	assert.ErrorIs(t, hub.CanPlay(gameID, "dave"), ErrNotInGame)
+	assert.ErrorIs(t, hub.CanPlay("missing", "alice"), ErrGameNotFound)
+
+	// Kicked and banned players can't come straight back on a new session
+	require.NoError(t, gm.KickPlayer(gameID, "alice", "bob"))
+	require.NoError(t, gm.BanPlayer(gameID, "alice", "carol"))
+	assert.ErrorIs(t, hub.CanPlay(gameID, "bob"), ErrNotInGame)
+	assert.ErrorIs(t, hub.CanPlay(gameID, "carol"), ErrBannedFromGame)
+
+	// Nor can banned players watch instead
+	hub.SetSpectators(2, time.Millisecond)
+	assert.NoError(t, hub.CanSpectate(gameID, "bob"))
+	assert.ErrorIs(t, hub.CanSpectate(gameID, "carol"), ErrBannedFromGame)
+}
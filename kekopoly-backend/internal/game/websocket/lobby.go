@@ -0,0 +1,650 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/gorilla/websocket"
+
+	"github.com/kekopoly/backend/internal/game/manager"
+	"github.com/kekopoly/backend/internal/game/models"
+	"github.com/kekopoly/backend/internal/game/protocol"
+)
+
+// readyTTL is how long a game's ready flags are kept after the last change
+const readyTTL = 24 * time.Hour
+
+// LobbyOptions configures the lobby
+type LobbyOptions struct {
+	// How often lobby clients are sent the listings that changed. Changes within one
+	// interval are sent together, so a busy game costs at most one update per interval.
+	FlushInterval time.Duration
+	// How often every listing is rebuilt from the game store, catching changes made
+	// without a notification
+	ResyncInterval time.Duration
+}
+
+// lobbyClient is a lobby connection and the games it has been sent
+type lobbyClient struct {
+	client     *Client
+	filter     protocol.LobbyFilter
+	subscribed bool            // Sent lobby_subscribe; clients that haven't also get new_game_created
+	listed     map[string]bool // Games sent and not since removed
+}
+
+// Lobby keeps the listings of joinable and running games and streams changes to them
+// to lobby connections. Each node keeps its own copy; the node that notices a change
+// rebuilds the listing and publishes it to the others.
+type Lobby struct {
+	client *redis.Client
+	opts   LobbyOptions
+
+	mu       sync.Mutex
+	listings map[string]protocol.GameListing // By game ID
+	changed  map[string]bool                 // Listings changed since the last flush
+	dirty    map[string]bool                 // Games to rebuild on this node before the next flush
+	clients  map[string]*lobbyClient         // By session ID
+}
+
+// NewLobby creates a lobby. Ready flags are kept in Redis so every node lists them.
+func NewLobby(client *redis.Client, opts LobbyOptions) *Lobby {
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = time.Second
+	}
+	if opts.ResyncInterval <= 0 {
+		opts.ResyncInterval = time.Minute
+	}
+	return &Lobby{
+		client:   client,
+		opts:     opts,
+		listings: make(map[string]protocol.GameListing),
+		changed:  make(map[string]bool),
+		dirty:    make(map[string]bool),
+		clients:  make(map[string]*lobbyClient),
+	}
+}
+
+// readyKey is the hash of playerID -> ready flag for a game's lobby
+func readyKey(gameID string) string {
+	return fmt.Sprintf("kekopoly:game:%s:ready", strings.ToLower(gameID))
+}
+
+// setReady records a player's ready flag
+func (l *Lobby) setReady(ctx context.Context, gameID, playerID string, ready bool) error {
+	value := "0"
+	if ready {
+		value = "1"
+	}
+	pipe := l.client.TxPipeline()
+	pipe.HSet(ctx, readyKey(gameID), playerID, value)
+	pipe.Expire(ctx, readyKey(gameID), readyTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to record ready flag for player %s in game %s: %w", playerID, gameID, err)
+	}
+	return nil
+}
+
+// readyPlayers returns the players in a game who are ready
+func (l *Lobby) readyPlayers(ctx context.Context, gameID string) (map[string]bool, error) {
+	flags, err := l.client.HGetAll(ctx, readyKey(gameID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ready flags for game %s: %w", gameID, err)
+	}
+	ready := make(map[string]bool, len(flags))
+	for playerID, flag := range flags {
+		ready[playerID] = flag == "1"
+	}
+	return ready, nil
+}
+
+// touch marks a game's listing for rebuilding at the next flush
+func (l *Lobby) touch(gameID string) {
+	l.mu.Lock()
+	l.dirty[strings.ToLower(gameID)] = true
+	l.mu.Unlock()
+}
+
+// takeDirty returns the games waiting to be rebuilt and clears the set
+func (l *Lobby) takeDirty() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	gameIDs := make([]string, 0, len(l.dirty))
+	for gameID := range l.dirty {
+		gameIDs = append(gameIDs, gameID)
+	}
+	l.dirty = make(map[string]bool)
+	return gameIDs
+}
+
+// apply replaces a game's listing, or removes it when listing is nil, reporting
+// whether anything changed
+func (l *Lobby) apply(gameID string, listing *protocol.GameListing) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.applyLocked(gameID, listing)
+}
+
+// applyLocked is apply for callers holding the lock
+func (l *Lobby) applyLocked(gameID string, listing *protocol.GameListing) bool {
+	current, listed := l.listings[gameID]
+	if listing == nil {
+		if !listed {
+			return false
+		}
+		delete(l.listings, gameID)
+	} else {
+		if listed && sameListing(current, *listing) {
+			return false
+		}
+		l.listings[gameID] = *listing
+	}
+	l.changed[gameID] = true
+	return true
+}
+
+// replace swaps every listing for a freshly built set
+func (l *Lobby) replace(listings map[string]protocol.GameListing) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for gameID := range l.listings {
+		if _, ok := listings[gameID]; !ok {
+			l.applyLocked(gameID, nil)
+		}
+	}
+	for gameID := range listings {
+		listing := listings[gameID]
+		l.applyLocked(gameID, &listing)
+	}
+}
+
+// games returns the listings matching a filter, newest first
+func (l *Lobby) games(filter protocol.LobbyFilter) []protocol.GameListing {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.gamesLocked(filter)
+}
+
+// gamesLocked is games for callers holding the lock
+func (l *Lobby) gamesLocked(filter protocol.LobbyFilter) []protocol.GameListing {
+	games := make([]protocol.GameListing, 0, len(l.listings))
+	for _, listing := range l.listings {
+		if filter.Matches(listing) {
+			games = append(games, listing)
+		}
+	}
+	sort.Slice(games, func(i, j int) bool {
+		if games[i].CreatedAt != games[j].CreatedAt {
+			return games[i].CreatedAt > games[j].CreatedAt
+		}
+		return games[i].ID < games[j].ID
+	})
+	return games
+}
+
+// sameListing compares two listings, ignoring when they were built
+func sameListing(a, b protocol.GameListing) bool {
+	a.UpdatedAt, b.UpdatedAt = "", ""
+	return reflect.DeepEqual(a, b)
+}
+
+// Listed reports whether a game belongs in the lobby. Unlisted and private games
+// are only reached by room code or invite.
+func Listed(game *models.Game) bool {
+	if !game.IsPublic() {
+		return false
+	}
+	switch game.Status {
+	case models.GameStatusLobby, models.GameStatusActive, models.GameStatusAbandoned:
+		return true
+	}
+	return false
+}
+
+// newListing describes a game for the lobby
+func newListing(game *models.Game, ready map[string]bool, now time.Time) protocol.GameListing {
+	listing := protocol.GameListing{
+		ID:         game.ID.Hex(),
+		Name:       game.Name,
+		Status:     string(game.Status),
+		MaxPlayers: game.MaxPlayers,
+		CreatedAt:  game.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:  now.Format(time.RFC3339),
+		BuyIn:      game.BuyIn,
+		Mode:       string(game.Mode),
+		Locked:     game.Locked,
+		Password:   game.PasswordHash != "",
+		Seats:      make([]protocol.ListingSeat, 0, len(game.Players)),
+	}
+	if listing.Mode == "" {
+		listing.Mode = string(models.GameModeClassic)
+	}
+	if game.Status == models.GameStatusLobby && !game.Locked && game.MaxPlayers > len(game.Players) {
+		listing.OpenSeats = game.MaxPlayers - len(game.Players)
+	}
+
+	for i, player := range game.Players {
+		if player.ID == game.HostID || (i == 0 && listing.HostName == "") {
+			listing.HostName = shortWallet(player.WalletAddress)
+		}
+		if player.Status == models.PlayerStatusActive {
+			listing.Players++
+		}
+		if ready[player.ID] || player.Bot != "" {
+			listing.ReadyPlayers++
+		}
+		listing.Seats = append(listing.Seats, protocol.ListingSeat{
+			PlayerID: player.ID,
+			Status:   string(player.Status),
+			Ready:    ready[player.ID] || player.Bot != "",
+			Bot:      player.Bot != "",
+		})
+	}
+	return listing
+}
+
+// shortWallet truncates a wallet address for display
+func shortWallet(address string) string {
+	if len(address) > 10 {
+		return address[:6] + "..." + address[len(address)-4:]
+	}
+	return address
+}
+
+// SetLobby streams game listings to lobby connections
+func (h *Hub) SetLobby(lobby *Lobby) {
+	h.lobby = lobby
+	if h.bus != nil {
+		ctx, cancel := context.WithTimeout(h.ctx, 2*time.Second)
+		if err := h.bus.JoinLobby(ctx); err != nil {
+			h.logger.Errorf("[Lobby] %v", err)
+		}
+		cancel()
+	}
+	h.resyncLobby()
+	go h.runLobby()
+	h.logger.Infof("Lobby set for WebSocket hub (updates every %s)", lobby.opts.FlushInterval)
+}
+
+// GameListingChanged implements manager.ListingNotifier
+func (h *Hub) GameListingChanged(gameID string) {
+	if h.lobby != nil {
+		h.lobby.touch(gameID)
+	}
+}
+
+// LobbyGames returns the listed games matching a filter, newest first
+func (h *Hub) LobbyGames(filter protocol.LobbyFilter) ([]protocol.GameListing, error) {
+	if h.lobby != nil {
+		return h.lobby.games(filter), nil
+	}
+
+	// Without a lobby the listings are built on every call
+	listings, err := h.buildListings()
+	if err != nil {
+		return nil, err
+	}
+	games := make([]protocol.GameListing, 0, len(listings))
+	for _, listing := range listings {
+		if filter.Matches(listing) {
+			games = append(games, listing)
+		}
+	}
+	sort.Slice(games, func(i, j int) bool { return games[i].CreatedAt > games[j].CreatedAt })
+	return games, nil
+}
+
+// buildListings describes every listed game from the game manager
+func (h *Hub) buildListings() (map[string]protocol.GameListing, error) {
+	if h.gameManager == nil {
+		return nil, fmt.Errorf("game manager not set")
+	}
+	games, err := h.gameManager.ListAvailableGames()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list games: %w", err)
+	}
+
+	now := time.Now()
+	listings := make(map[string]protocol.GameListing, len(games))
+	for _, game := range games {
+		if !Listed(game) {
+			continue
+		}
+		listings[game.ID.Hex()] = newListing(game, h.readyPlayers(game.ID.Hex()), now)
+	}
+	return listings, nil
+}
+
+// readyPlayers returns a game's ready flags, or none if they can't be read
+func (h *Hub) readyPlayers(gameID string) map[string]bool {
+	if h.lobby == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(h.ctx, 2*time.Second)
+	defer cancel()
+	ready, err := h.lobby.readyPlayers(ctx, gameID)
+	if err != nil {
+		h.logger.Warnf("[Lobby] %v", err)
+	}
+	return ready
+}
+
+// setReady records a player's ready flag for the game's listing
+func (h *Hub) setReady(gameID, playerID string, ready bool) {
+	if h.lobby == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(h.ctx, 2*time.Second)
+	defer cancel()
+	if err := h.lobby.setReady(ctx, gameID, playerID, ready); err != nil {
+		h.logger.Errorf("[Lobby] %v", err)
+	}
+	h.lobby.touch(gameID)
+}
+
+// runLobby rebuilds changed listings and sends them to lobby clients until the hub stops
+func (h *Hub) runLobby() {
+	flush := time.NewTicker(h.lobby.opts.FlushInterval)
+	defer flush.Stop()
+	resync := time.NewTicker(h.lobby.opts.ResyncInterval)
+	defer resync.Stop()
+
+	for {
+		select {
+		case <-h.ctx.Done():
+			h.closeLobby()
+			return
+		case <-resync.C:
+			h.resyncLobby()
+		case <-flush.C:
+			h.rebuildListings()
+			h.flushLobby()
+		}
+	}
+}
+
+// resyncLobby rebuilds every listing from the game store. Each node does this itself,
+// so nothing is published.
+func (h *Hub) resyncLobby() {
+	listings, err := h.buildListings()
+	if err != nil {
+		h.logger.Errorf("[Lobby] %v", err)
+		return
+	}
+	h.lobby.replace(listings)
+}
+
+// rebuildListings rebuilds the listings of the games touched on this node and
+// publishes the ones that changed to the other nodes
+func (h *Hub) rebuildListings() {
+	if h.gameManager == nil {
+		return
+	}
+	for _, gameID := range h.lobby.takeDirty() {
+		var listing *protocol.GameListing
+		game, err := h.gameManager.GetGame(gameID)
+		switch {
+		case err == nil && Listed(game):
+			built := newListing(game, h.readyPlayers(gameID), time.Now())
+			listing = &built
+		case err == nil, errors.Is(err, manager.ErrGameNotFound):
+			// No longer listed
+		default:
+			h.logger.Warnf("[Lobby] Failed to load game %s for its listing: %v", gameID, err)
+			continue
+		}
+
+		if h.lobby.apply(gameID, listing) {
+			h.publishListing(gameID, listing)
+		}
+	}
+}
+
+// publishListing sends a game's new listing to the other nodes' lobbies
+func (h *Hub) publishListing(gameID string, listing *protocol.GameListing) {
+	if h.bus == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(h.ctx, 2*time.Second)
+	defer cancel()
+	if err := h.bus.PublishLobby(ctx, Envelope{GameID: gameID, Listing: listing, Unlisted: listing == nil}); err != nil {
+		h.logger.Errorf("[Lobby] Failed to publish listing for game %s: %v", gameID, err)
+	}
+}
+
+// flushLobby sends each lobby client the changes to the listings it follows
+func (h *Hub) flushLobby() {
+	l := h.lobby
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.changed) == 0 {
+		return
+	}
+	changed := l.changed
+	l.changed = make(map[string]bool)
+
+	for _, lc := range l.clients {
+		update := &protocol.LobbyUpdate{}
+		var created []protocol.GameListing
+		for gameID := range changed {
+			listing, listed := l.listings[gameID]
+			matches := listed && lc.filter.Matches(listing)
+			switch {
+			case matches && lc.listed[gameID]:
+				update.Updated = append(update.Updated, listing)
+			case matches:
+				update.Added = append(update.Added, listing)
+				lc.listed[gameID] = true
+				if !lc.subscribed && listing.Status == string(models.GameStatusLobby) {
+					created = append(created, listing)
+				}
+			case lc.listed[gameID]:
+				update.Removed = append(update.Removed, gameID)
+				delete(lc.listed, gameID)
+			}
+		}
+		if len(update.Added)+len(update.Updated)+len(update.Removed) == 0 {
+			continue
+		}
+		h.sendToLobbyClient(lc.client, update)
+		for _, listing := range created {
+			h.sendToLobbyClient(lc.client, &protocol.NewGameCreated{Game: listing, Timestamp: time.Now().Format(time.RFC3339)})
+		}
+	}
+}
+
+// sendToLobbyClient queues a message for a lobby connection. The caller must hold the
+// lobby's lock, which keeps the queues from being closed meanwhile.
+func (h *Hub) sendToLobbyClient(client *Client, msg protocol.Outbound) {
+	data, err := protocol.Encode(msg)
+	if err != nil {
+		h.logger.Errorf("[Lobby] %v", err)
+		return
+	}
+	select {
+	case client.normalPriorityQueue <- data:
+	default:
+		h.logger.Warnf("[Lobby] Failed to send to lobby client %s (buffer full)", client.sessionID)
+	}
+}
+
+// NotifyMatch implements matchmaking.Notifier, telling the matched players' lobby
+// connections on every node which game they are in
+func (h *Hub) NotifyMatch(gameID string, playerIDs []string) {
+	data, err := protocol.Encode(&protocol.MatchFound{GameID: gameID, Players: playerIDs})
+	if err != nil {
+		h.logger.Errorf("[Lobby] %v", err)
+		return
+	}
+	h.sendToLobbyPlayers(playerIDs, data)
+	if h.bus == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(h.ctx, 2*time.Second)
+	defer cancel()
+	if err := h.bus.PublishLobby(ctx, Envelope{GameID: gameID, Data: data, PlayerIDs: playerIDs, Lobby: true}); err != nil {
+		h.logger.Errorf("[Lobby] Failed to publish match for game %s: %v", gameID, err)
+	}
+}
+
+// sendToLobbyPlayers queues a message for this node's lobby connections of some players
+func (h *Hub) sendToLobbyPlayers(playerIDs []string, data []byte) {
+	if h.lobby == nil {
+		return
+	}
+	wanted := make(map[string]bool, len(playerIDs))
+	for _, playerID := range playerIDs {
+		wanted[playerID] = true
+	}
+	l := h.lobby
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, lc := range l.clients {
+		if !wanted[lc.client.playerID] {
+			continue
+		}
+		select {
+		case lc.client.normalPriorityQueue <- data:
+		default:
+			h.logger.Warnf("[Lobby] Failed to send to lobby client %s (buffer full)", lc.client.sessionID)
+		}
+	}
+}
+
+// HandleLobbyConnection serves a lobby connection. It starts with every listed game and
+// is sent changes until the client narrows it with lobby_subscribe.
+func (h *Hub) HandleLobbyConnection(conn *websocket.Conn, userID, sessionID string) {
+	if h.lobby == nil {
+		h.logger.Errorf("[Lobby] Lobby connection from user %s refused: lobby not set", userID)
+		conn.Close()
+		return
+	}
+
+	client := &Client{
+		hub:                 h,
+		conn:                conn,
+		highPriorityQueue:   make(chan []byte, 16),
+		normalPriorityQueue: make(chan []byte, 1024),
+		lowPriorityQueue:    make(chan []byte, 16),
+		playerID:            userID,
+		sessionID:           sessionID,
+		userAgent:           "WebSocket Lobby Client",
+		connectedAt:         time.Now(),
+		lobby:               true,
+	}
+	h.addLobbyClient(client)
+	h.logger.Infof("[Lobby] User %s connected to the lobby (session %s)", userID, sessionID)
+
+	go client.readPump()
+	go client.writePump()
+}
+
+// addLobbyClient registers a lobby connection and sends it the current listings
+func (h *Hub) addLobbyClient(client *Client) {
+	l := h.lobby
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if existing, ok := l.clients[client.sessionID]; ok {
+		closeQueues(existing.client)
+	}
+	lc := &lobbyClient{client: client, listed: make(map[string]bool)}
+	l.clients[client.sessionID] = lc
+	h.sendLobbyGames(lc, "")
+}
+
+// sendLobbyGames sends a lobby client the games matching its filter. The caller must
+// hold the lobby's lock.
+func (h *Hub) sendLobbyGames(lc *lobbyClient, requestID string) {
+	games := h.lobby.gamesLocked(lc.filter)
+	lc.listed = make(map[string]bool, len(games))
+	for _, game := range games {
+		lc.listed[game.ID] = true
+	}
+	h.sendToLobbyClient(lc.client, &protocol.LobbyGames{Header: protocol.Header{RequestID: requestID}, Games: games})
+}
+
+// removeLobbyClient unregisters a lobby connection
+func (h *Hub) removeLobbyClient(client *Client) {
+	l := h.lobby
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if lc, ok := l.clients[client.sessionID]; ok && lc.client == client {
+		closeQueues(client)
+		delete(l.clients, client.sessionID)
+		h.logger.Infof("[Lobby] User %s left the lobby (session %s)", client.playerID, client.sessionID)
+	}
+}
+
+// closeLobby closes every lobby connection when the hub shuts down
+func (h *Hub) closeLobby() {
+	l := h.lobby
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for sessionID, lc := range l.clients {
+		if lc.client.conn != nil {
+			lc.client.conn.Close()
+		}
+		delete(l.clients, sessionID)
+	}
+}
+
+// handleLobbyMessage handles a message on a lobby connection, where only
+// lobby_subscribe is understood. Legacy messages of other types are ignored.
+func (c *Client) handleLobbyMessage(message []byte) {
+	msg, err := protocol.Decode(message)
+	if err != nil {
+		c.sendLobbyError(nil, protocol.ErrorCode(err), err.Error())
+		return
+	}
+	if msg.Type != protocol.TypeLobbySubscribe {
+		if !msg.Legacy() {
+			c.sendLobbyError(msg, protocol.CodeUnknownType, fmt.Sprintf("lobby connections don't accept %q", msg.Type))
+		}
+		return
+	}
+
+	var req protocol.LobbySubscribeRequest
+	if err := msg.DecodePayload(&req); err != nil {
+		if msg.Legacy() {
+			c.hub.logger.Warnf("[Lobby] Ignoring invalid lobby_subscribe from session %s: %v", c.sessionID, err)
+			return
+		}
+		c.sendLobbyError(msg, protocol.CodeInvalidMessage, err.Error())
+		return
+	}
+
+	l := c.hub.lobby
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	lc, ok := l.clients[c.sessionID]
+	if !ok || lc.client != c {
+		return
+	}
+	lc.filter = req.LobbyFilter
+	lc.subscribed = true
+	c.hub.sendLobbyGames(lc, msg.RequestID)
+	if !msg.Legacy() && msg.RequestID != "" {
+		c.hub.sendToLobbyClient(c, &protocol.Ack{Header: protocol.Header{RequestID: msg.RequestID}, For: msg.Type})
+	}
+}
+
+// sendLobbyError reports a rejected lobby message
+func (c *Client) sendLobbyError(msg *protocol.Message, code, message string) {
+	reply := &protocol.Error{Code: code, Message: message}
+	if msg != nil {
+		reply.RequestID = msg.RequestID
+		reply.For = msg.Type
+	}
+	l := c.hub.lobby
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if lc, ok := l.clients[c.sessionID]; ok && lc.client == c {
+		c.hub.sendToLobbyClient(c, reply)
+	}
+}
@@ -0,0 +1,193 @@
+package websocket
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/kekopoly/backend/internal/game/manager"
+	"github.com/kekopoly/backend/internal/game/models"
+	"github.com/kekopoly/backend/internal/game/protocol"
+)
+
+// newTestLobbyNode starts a node with its own game manager and a lobby that only
+// flushes when the test calls flushTestLobby
+func newTestLobbyNode(t *testing.T, ctx context.Context, mr *miniredis.Miniredis, nodeID string, store manager.GameStore) (*Hub, *manager.GameManager) {
+	t.Helper()
+	hub := newTestNode(t, ctx, mr, nodeID)
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { redisClient.Close() })
+	gm := manager.NewGameManagerWithOptions(ctx, nil, redisClient, zap.NewNop().Sugar(), hub, nil, manager.Options{
+		Store: store,
+	})
+	hub.SetGameManager(gm)
+	hub.SetLobby(NewLobby(redisClient, LobbyOptions{FlushInterval: time.Hour, ResyncInterval: time.Hour}))
+	return hub, gm
+}
+
+// flushTestLobby does what the lobby's flush ticker does
+func flushTestLobby(hub *Hub) {
+	hub.rebuildListings()
+	hub.flushLobby()
+}
+
+// addTestLobbyClient registers a socket-less lobby connection on the hub
+func addTestLobbyClient(t *testing.T, hub *Hub, sessionID string) *Client {
+	t.Helper()
+	client := &Client{
+		hub:                 hub,
+		highPriorityQueue:   make(chan []byte, 16),
+		normalPriorityQueue: make(chan []byte, 16),
+		lowPriorityQueue:    make(chan []byte, 16),
+		playerID:            "user-" + sessionID,
+		sessionID:           sessionID,
+		lobby:               true,
+	}
+	hub.addLobbyClient(client)
+	t.Cleanup(func() { hub.removeLobbyClient(client) })
+	return client
+}
+
+func TestLobby(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	mr := miniredis.RunT(t)
+
+	hub, gm := newTestLobbyNode(t, ctx, mr, "node-a", manager.NewMemoryGameStore())
+	gameID, err := gm.CreateGame("alice", "wallet-alice", "", 4, 0)
+	require.NoError(t, err)
+	flushTestLobby(hub)
+
+	// New connections start with every listed game
+	everyone := addTestLobbyClient(t, hub, "everyone")
+	got := receivedOfType(t, everyone, "lobby_games")
+	require.Len(t, got, 1)
+	require.Len(t, got[0]["games"], 1)
+	game := got[0]["games"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, gameID, game["id"])
+	assert.Equal(t, "CLASSIC", game["mode"])
+	assert.Equal(t, "wallet...lice", game["hostName"])
+	assert.Equal(t, float64(1), game["players"])
+	assert.Equal(t, float64(3), game["openSeats"])
+
+	// Filters narrow the listings
+	picky := addTestLobbyClient(t, hub, "picky")
+	picky.handleMessage([]byte(`{"v":1,"type":"lobby_subscribe","requestId":"l-1","payload":{"openSeats":3,"mode":"classic"}}`))
+	got = receivedOfType(t, picky, "lobby_games")
+	require.Len(t, got, 2)
+	assert.Equal(t, "l-1", got[1]["requestId"])
+	assert.Len(t, got[1]["games"], 1)
+
+	picky.handleMessage([]byte(`{"v":1,"type":"lobby_subscribe","requestId":"l-2","payload":{"status":"FINISHED"}}`))
+	got = receivedOfType(t, picky, "error")
+	require.Len(t, got, 1)
+	assert.Equal(t, protocol.CodeInvalidMessage, got[0]["code"])
+	picky.handleMessage([]byte(`{"v":1,"type":"roll_dice","requestId":"l-3","payload":{}}`))
+	got = receivedOfType(t, picky, "error")
+	require.Len(t, got, 1)
+	assert.Equal(t, protocol.CodeUnknownType, got[0]["code"])
+
+	// Changes within one interval arrive as one update
+	_, err = gm.JoinGame(gameID, "bob", "wallet-bob")
+	require.NoError(t, err)
+	hub.setReady(gameID, "bob", true)
+	flushTestLobby(hub)
+
+	got = receivedOfType(t, everyone, "lobby_update")
+	require.Len(t, got, 1)
+	require.Len(t, got[0]["updated"], 1)
+	game = got[0]["updated"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, float64(2), game["players"])
+	assert.Equal(t, float64(1), game["readyPlayers"])
+	assert.Equal(t, float64(2), game["openSeats"])
+	assert.Len(t, game["seats"], 2)
+
+	// A game that stops matching the filter is removed
+	got = receivedOfType(t, picky, "lobby_update")
+	require.Len(t, got, 1)
+	assert.Equal(t, []interface{}{gameID}, got[0]["removed"])
+
+	// Only clients that haven't subscribed get new_game_created
+	otherID, err := gm.CreateGame("carol", "wallet-carol", "", 4, 0)
+	require.NoError(t, err)
+	flushTestLobby(hub)
+	msgs := decodeAll(t, received(everyone, 50*time.Millisecond))
+	require.Len(t, msgs, 2)
+	assert.Equal(t, "lobby_update", msgs[0]["type"])
+	assert.Len(t, msgs[0]["added"], 1)
+	assert.Equal(t, "new_game_created", msgs[1]["type"])
+	msgs = decodeAll(t, received(picky, 50*time.Millisecond))
+	require.Len(t, msgs, 1)
+	assert.Len(t, msgs[0]["added"], 1)
+
+	// Nothing is sent when nothing changed, and unlisted games change nothing
+	_, err = gm.CreateGameWithAccess("dave", "wallet-dave", "", 4, 0, manager.GameAccess{Visibility: models.GameVisibilityUnlisted})
+	require.NoError(t, err)
+	flushTestLobby(hub)
+	assert.Empty(t, received(everyone, 50*time.Millisecond))
+
+	games, err := hub.LobbyGames(protocol.LobbyFilter{OpenSeats: 3})
+	require.NoError(t, err)
+	require.Len(t, games, 1)
+	assert.Equal(t, otherID, games[0].ID)
+}
+
+func TestLobbyOnAnotherNode(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	mr := miniredis.RunT(t)
+
+	// Each node has its own store, so node-b only learns of the game over the bus
+	nodeA, gm := newTestLobbyNode(t, ctx, mr, "node-a", manager.NewMemoryGameStore())
+	nodeB, _ := newTestLobbyNode(t, ctx, mr, "node-b", manager.NewMemoryGameStore())
+	client := addTestLobbyClient(t, nodeB, "remote")
+	require.Len(t, receivedOfType(t, client, "lobby_games"), 1)
+
+	gameID, err := gm.CreateGame("alice", "wallet-alice", "", 4, 0)
+	require.NoError(t, err)
+	flushTestLobby(nodeA)
+	require.Eventually(t, func() bool {
+		return len(nodeB.lobby.games(protocol.LobbyFilter{})) == 1
+	}, 2*time.Second, 10*time.Millisecond)
+
+	nodeB.flushLobby()
+	got := receivedOfType(t, client, "lobby_update")
+	require.Len(t, got, 1)
+	require.Len(t, got[0]["added"], 1)
+	assert.Equal(t, gameID, got[0]["added"].([]interface{})[0].(map[string]interface{})["id"])
+}
+
+func TestNotifyMatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	mr := miniredis.RunT(t)
+
+	nodeA, _ := newTestLobbyNode(t, ctx, mr, "node-a", manager.NewMemoryGameStore())
+	nodeB, _ := newTestLobbyNode(t, ctx, mr, "node-b", manager.NewMemoryGameStore())
+	local := addTestLobbyClient(t, nodeA, "local")
+	remote := addTestLobbyClient(t, nodeB, "remote")
+	bystander := addTestLobbyClient(t, nodeB, "bystander")
+	for _, client := range []*Client{local, remote, bystander} {
+		require.Len(t, receivedOfType(t, client, "lobby_games"), 1)
+	}
+
+	nodeA.NotifyMatch("game-1", []string{"user-local", "user-remote"})
+	got := receivedOfType(t, local, "match_found")
+	require.Len(t, got, 1)
+	assert.Equal(t, "game-1", got[0]["gameId"])
+	assert.Equal(t, []interface{}{"user-local", "user-remote"}, got[0]["players"])
+
+	var msgs []string
+	require.Eventually(t, func() bool {
+		msgs = append(msgs, received(remote, 10*time.Millisecond)...)
+		return len(msgs) > 0
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "match_found", decodeAll(t, msgs)[0]["type"])
+	assert.Empty(t, received(bystander, 50*time.Millisecond))
+}
@@ -0,0 +1,493 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/kekopoly/backend/internal/game/models"
+	"github.com/kekopoly/backend/internal/game/protocol"
+)
+
+var (
+	// ErrSpectatingDisabled is returned when the server doesn't allow spectators
+	ErrSpectatingDisabled = errors.New("spectating is disabled")
+	// ErrNotSpectatable is returned for a game that can't be watched
+	ErrNotSpectatable = errors.New("game is not open to spectators")
+	// ErrSpectatorsFull is returned when a game already has as many spectators as allowed
+	ErrSpectatorsFull = errors.New("game has no room for more spectators")
+)
+
+// spectatorQueueSize bounds the broadcasts waiting out the delay for one game
+const spectatorQueueSize = 1024
+
+// hiddenFromSpectators are fields removed from broadcasts before spectators see them:
+// trade terms and wallet proofs. Players' hands are replaced by a count.
+var hiddenFromSpectators = map[string]bool{
+	"terms":           true,
+	"walletSignature": true,
+}
+
+// spectatorItem is a broadcast waiting out the spectator delay
+type spectatorItem struct {
+	id        uint64
+	deliverAt time.Time
+	sessionID string // Only this spectator gets it, when set
+	message   []byte
+}
+
+// spectatorFeed holds a game's spectators on this node and the broadcasts on their way
+// to them
+type spectatorFeed struct {
+	clients map[string]*Client // By session ID
+	queue   chan spectatorItem
+	lastID  uint64
+	stop    context.CancelFunc
+}
+
+// SetSpectators allows up to limit spectators per game, who see broadcasts delay
+// after players do. A limit of 0 disables spectating.
+func (h *Hub) SetSpectators(limit int, delay time.Duration) {
+	h.spectatorsMutex.Lock()
+	h.spectatorLimit = limit
+	h.spectatorDelay = delay
+	h.spectatorsMutex.Unlock()
+	h.logger.Infof("Spectators set for WebSocket hub (limit %d, delay %s)", limit, delay)
+}
+
+// Spectatable reports whether a game may be watched. Private games are only
+// for the players the host let in.
+func Spectatable(game *models.Game) bool {
+	if game.Visibility == models.GameVisibilityPrivate {
+		return false
+	}
+	return game.Status == models.GameStatusLobby || game.Status == models.GameStatusActive
+}
+
+// SpectatorLimit returns how many spectators a game may have, 0 if spectating is disabled
+func (h *Hub) SpectatorLimit() int {
+	h.spectatorsMutex.RLock()
+	defer h.spectatorsMutex.RUnlock()
+	return h.spectatorLimit
+}
+
+// SpectatorCount returns how many spectators are watching a game on any node
+func (h *Hub) SpectatorCount(gameID string) int {
+	if h.bus != nil {
+		ctx, cancel := context.WithTimeout(h.ctx, 2*time.Second)
+		defer cancel()
+		spectators, err := h.bus.Spectators(ctx, gameID)
+		if err == nil {
+			return len(spectators)
+		}
+		h.logger.Errorf("[Spectators] %v", err)
+	}
+	return h.localSpectatorCount(gameID)
+}
+
+// localSpectatorCount returns how many spectators are watching a game on this node
+func (h *Hub) localSpectatorCount(gameID string) int {
+	h.spectatorsMutex.RLock()
+	defer h.spectatorsMutex.RUnlock()
+	if feed, ok := h.spectators[gameID]; ok {
+		return len(feed.clients)
+	}
+	return 0
+}
+
+// CanSpectate reports why a game can't take another spectator, or nil if it can.
+// Players the host banned can't watch either.
+func (h *Hub) CanSpectate(gameID, userID string) error {
+	limit := h.SpectatorLimit()
+	if limit <= 0 {
+		return ErrSpectatingDisabled
+	}
+	if h.gameManager == nil {
+		return ErrNotSpectatable
+	}
+	game, err := h.gameManager.GetGame(gameID)
+	if err != nil || !Spectatable(game) {
+		return ErrNotSpectatable
+	}
+	if bannedFrom(game, userID) {
+		return ErrBannedFromGame
+	}
+	if h.SpectatorCount(gameID) >= limit {
+		return ErrSpectatorsFull
+	}
+	return nil
+}
+
+// HandleSpectatorConnection serves a read-only connection watching a game. If the
+// game can't take the spectator, the connection is closed and the reason returned.
+func (h *Hub) HandleSpectatorConnection(conn *websocket.Conn, gameID, userID, sessionID string) error {
+	if err := h.CanSpectate(gameID, userID); err != nil {
+		conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.ClosePolicyViolation, err.Error()), time.Now().Add(time.Second))
+		conn.Close()
+		return err
+	}
+
+	client := &Client{
+		hub:                 h,
+		conn:                conn,
+		highPriorityQueue:   make(chan []byte, 64),
+		normalPriorityQueue: make(chan []byte, 4096),
+		lowPriorityQueue:    make(chan []byte, 64),
+		playerID:            userID,
+		gameID:              gameID,
+		sessionID:           sessionID,
+		userAgent:           "WebSocket Spectator",
+		connectedAt:         time.Now(),
+		spectator:           true,
+	}
+	h.addSpectator(client)
+	h.logger.Infof("[Spectators] User %s watching game %s (session %s)", userID, gameID, sessionID)
+
+	// Start from the game as it is now, delayed like everything after it
+	if game, err := h.gameManager.GetGame(gameID); err == nil {
+		snapshot, err := protocol.Encode(&protocol.CompleteStateSync{
+			GameID:      gameID,
+			Status:      string(game.Status),
+			CurrentTurn: game.CurrentTurn,
+			Players:     game.Players,
+			TurnOrder:   game.TurnOrder,
+			Timestamp:   time.Now().Format(time.RFC3339),
+		})
+		if err != nil {
+			h.logger.Errorf("[Spectators] %v", err)
+		} else {
+			h.enqueueForSpectators(gameID, sessionID, snapshot)
+		}
+	}
+
+	go client.readPump()
+	go client.writePump()
+	return nil
+}
+
+// addSpectator registers a spectator, starting the game's feed if it is the first
+func (h *Hub) addSpectator(client *Client) {
+	h.spectatorsMutex.Lock()
+	feed, ok := h.spectators[client.gameID]
+	if !ok {
+		ctx, cancel := context.WithCancel(h.ctx)
+		feed = &spectatorFeed{
+			clients: make(map[string]*Client),
+			queue:   make(chan spectatorItem, spectatorQueueSize),
+			stop:    cancel,
+		}
+		h.spectators[client.gameID] = feed
+		go h.runSpectatorFeed(ctx, client.gameID, feed)
+	}
+	if existing, ok := feed.clients[client.sessionID]; ok {
+		closeQueues(existing)
+	}
+	// Broadcasts already on their way predate this spectator's snapshot
+	client.spectatorSince = feed.lastID
+	feed.clients[client.sessionID] = client
+	h.spectatorsMutex.Unlock()
+
+	if h.bus != nil {
+		ctx, cancel := context.WithTimeout(h.ctx, 2*time.Second)
+		defer cancel()
+		if err := h.bus.Join(ctx, client.gameID); err != nil {
+			h.logger.Errorf("[Bus] %v", err)
+		}
+		if err := h.bus.SetSpectator(ctx, client.gameID, client.sessionID); err != nil {
+			h.logger.Errorf("[Spectators] Failed to record spectator %s in game %s: %v", client.sessionID, client.gameID, err)
+		}
+	}
+	h.updateSpectatorCount(client.gameID)
+}
+
+// removeSpectator unregisters a spectator, stopping the game's feed if it was the last
+func (h *Hub) removeSpectator(client *Client) {
+	h.spectatorsMutex.Lock()
+	feed, ok := h.spectators[client.gameID]
+	if !ok || feed.clients[client.sessionID] != client {
+		h.spectatorsMutex.Unlock()
+		return
+	}
+	closeQueues(client)
+	delete(feed.clients, client.sessionID)
+	lastSpectator := len(feed.clients) == 0
+	if lastSpectator {
+		feed.stop()
+		delete(h.spectators, client.gameID)
+	}
+	h.spectatorsMutex.Unlock()
+	h.logger.Infof("[Spectators] User %s stopped watching game %s (session %s)", client.playerID, client.gameID, client.sessionID)
+
+	if h.bus == nil {
+		h.updateSpectatorCount(client.gameID)
+		return
+	}
+	h.clientsMutex.RLock()
+	_, hasPlayers := h.clients[client.gameID]
+	h.clientsMutex.RUnlock()
+
+	ctx, cancel := context.WithTimeout(h.ctx, 2*time.Second)
+	defer cancel()
+	if err := h.bus.ClearSpectator(ctx, client.gameID, client.sessionID); err != nil {
+		h.logger.Errorf("[Spectators] Failed to clear spectator %s in game %s: %v", client.sessionID, client.gameID, err)
+	}
+	h.updateSpectatorCount(client.gameID)
+	if lastSpectator && !hasPlayers {
+		if err := h.bus.Leave(ctx, client.gameID); err != nil {
+			h.logger.Errorf("[Bus] %v", err)
+		}
+	}
+}
+
+// updateSpectatorCount recounts a game's spectators after one joined or left this node,
+// and passes the count on to the other nodes
+func (h *Hub) updateSpectatorCount(gameID string) {
+	count := h.SpectatorCount(gameID)
+	h.setSpectatorCount(gameID, count)
+	h.publishRemote(Envelope{GameID: gameID, SpectatorCount: &count})
+}
+
+// setSpectatorCount records how many spectators a game has on any node
+func (h *Hub) setSpectatorCount(gameID string, count int) {
+	h.spectatorsMutex.Lock()
+	defer h.spectatorsMutex.Unlock()
+	if count > 0 {
+		h.spectatorCounts[gameID] = count
+	} else {
+		delete(h.spectatorCounts, gameID)
+	}
+}
+
+// hasSpectators reports whether a game has spectators on this node
+func (h *Hub) hasSpectators(gameID string) bool {
+	return h.localSpectatorCount(gameID) > 0
+}
+
+// refreshSpectators keeps this node's spectator entries from going stale, and recounts
+// the spectators of the games it serves, which drops those of a node that died
+func (h *Hub) refreshSpectators() {
+	if h.bus == nil {
+		return
+	}
+
+	games := make(map[string]bool)
+	h.spectatorsMutex.RLock()
+	clients := make([]*Client, 0)
+	for gameID, feed := range h.spectators {
+		games[gameID] = true
+		for _, client := range feed.clients {
+			clients = append(clients, client)
+		}
+	}
+	for gameID := range h.spectatorCounts {
+		games[gameID] = true
+	}
+	h.spectatorsMutex.RUnlock()
+
+	for _, client := range clients {
+		if err := h.bus.SetSpectator(h.ctx, client.gameID, client.sessionID); err != nil {
+			h.logger.Warnf("[Spectators] Failed to refresh spectator %s in game %s: %v", client.sessionID, client.gameID, err)
+		}
+	}
+
+	h.clientsMutex.RLock()
+	for gameID := range h.clients {
+		games[gameID] = true
+	}
+	h.clientsMutex.RUnlock()
+	for gameID := range games {
+		h.setSpectatorCount(gameID, h.SpectatorCount(gameID))
+	}
+}
+
+// closeSpectators closes every spectator connection when the hub shuts down
+func (h *Hub) closeSpectators() {
+	h.spectatorsMutex.Lock()
+	defer h.spectatorsMutex.Unlock()
+	for gameID, feed := range h.spectators {
+		feed.stop()
+		for _, client := range feed.clients {
+			client.conn.Close()
+		}
+		delete(h.spectators, gameID)
+	}
+}
+
+// stampSpectators adds the game's spectator count to a broadcast while anyone is watching.
+// The count is the one kept as spectators come and go, so broadcasts don't wait on Redis.
+func (h *Hub) stampSpectators(gameID string, message []byte) []byte {
+	if !sequenced(message) {
+		return message
+	}
+	h.spectatorsMutex.RLock()
+	count := h.spectatorCounts[gameID]
+	h.spectatorsMutex.RUnlock()
+	if count == 0 {
+		return message
+	}
+	stamped := []byte(fmt.Sprintf(`{"spectatorCount":%d,`, count))
+	return append(stamped, message[1:]...)
+}
+
+// feedSpectators passes a broadcast this node delivered to players on to the game's
+// spectators, once the delay has passed and private details are removed
+func (h *Hub) feedSpectators(gameID string, message []byte) {
+	if !h.hasSpectators(gameID) {
+		return
+	}
+	h.enqueueForSpectators(gameID, "", message)
+}
+
+// enqueueForSpectators redacts a message and queues it for the game's spectators, or
+// only the one with sessionID when it is set
+func (h *Hub) enqueueForSpectators(gameID, sessionID string, message []byte) {
+	redacted, ok := redactForSpectators(message)
+	if !ok {
+		return
+	}
+
+	h.spectatorsMutex.Lock()
+	defer h.spectatorsMutex.Unlock()
+	feed, exists := h.spectators[gameID]
+	if !exists {
+		return
+	}
+	feed.lastID++
+	item := spectatorItem{
+		id:        feed.lastID,
+		deliverAt: time.Now().Add(h.spectatorDelay),
+		sessionID: sessionID,
+		message:   redacted,
+	}
+	select {
+	case feed.queue <- item:
+	default:
+		h.logger.Warnf("[Spectators] Dropping broadcast for game %s (feed full)", gameID)
+	}
+}
+
+// runSpectatorFeed delivers a game's queued broadcasts in order as their delay passes
+func (h *Hub) runSpectatorFeed(ctx context.Context, gameID string, feed *spectatorFeed) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case item := <-feed.queue:
+			if wait := time.Until(item.deliverAt); wait > 0 {
+				timer := time.NewTimer(wait)
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return
+				case <-timer.C:
+				}
+			}
+			h.deliverToSpectators(gameID, feed, item)
+		}
+	}
+}
+
+// deliverToSpectators queues a broadcast for the spectators who were watching when
+// it was sent
+func (h *Hub) deliverToSpectators(gameID string, feed *spectatorFeed, item spectatorItem) {
+	h.spectatorsMutex.RLock()
+	defer h.spectatorsMutex.RUnlock()
+	for sessionID, client := range feed.clients {
+		if item.sessionID != "" && item.sessionID != sessionID {
+			continue
+		}
+		if item.id <= client.spectatorSince {
+			continue
+		}
+		select {
+		case client.normalPriorityQueue <- item.message:
+		default:
+			h.logger.Warnf("[Spectators] Failed to send to spectator %s in game %s (buffer full)", sessionID, gameID)
+		}
+	}
+}
+
+// rejectFromSpectator answers a message sent on a read-only connection. Legacy
+// messages are dropped as before; versioned ones get an error.
+func (c *Client) rejectFromSpectator(message []byte) {
+	msg, err := protocol.Decode(message)
+	if err != nil || msg.Legacy() {
+		return
+	}
+	reply, err := protocol.Encode(&protocol.Error{
+		Header:  protocol.Header{RequestID: msg.RequestID},
+		For:     msg.Type,
+		Code:    protocol.CodeReadOnly,
+		Message: "Spectators can't send game messages",
+	})
+	if err != nil {
+		c.hub.logger.Errorf("[Spectators] %v", err)
+		return
+	}
+	c.hub.spectatorsMutex.RLock()
+	defer c.hub.spectatorsMutex.RUnlock()
+	select {
+	case c.normalPriorityQueue <- reply:
+	default:
+	}
+}
+
+// redactForSpectators removes the private details from a broadcast. It reports false
+// for messages that can't be checked, which spectators don't get.
+func redactForSpectators(message []byte) ([]byte, bool) {
+	var doc interface{}
+	if err := json.Unmarshal(message, &doc); err != nil {
+		return nil, false
+	}
+	if !redact(doc) {
+		return message, true
+	}
+	redacted, err := json.Marshal(doc)
+	if err != nil {
+		return nil, false
+	}
+	return redacted, true
+}
+
+// redact removes private fields in place, reporting whether it found any
+func redact(node interface{}) bool {
+	changed := false
+	switch v := node.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if hiddenFromSpectators[key] {
+				delete(v, key)
+				changed = true
+				continue
+			}
+			if cards, ok := value.([]interface{}); ok && key == "cards" {
+				v[key] = []interface{}{}
+				v["cardCount"] = len(cards)
+				changed = true
+				continue
+			}
+			if redact(value) {
+				changed = true
+			}
+		}
+	case []interface{}:
+		for _, value := range v {
+			if redact(value) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// closeQueues closes a client's outbound queues, which ends its writePump
+func closeQueues(client *Client) {
+	close(client.highPriorityQueue)
+	close(client.normalPriorityQueue)
+	close(client.lowPriorityQueue)
+}
@@ -0,0 +1,144 @@
+package websocket
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/kekopoly/backend/internal/game/manager"
+	"github.com/kekopoly/backend/internal/game/protocol"
+)
+
+// addTestSpectator registers a socket-less spectator on the hub
+func addTestSpectator(t *testing.T, hub *Hub, gameID, sessionID string) *Client {
+	t.Helper()
+	client := &Client{
+		hub:                 hub,
+		highPriorityQueue:   make(chan []byte, 16),
+		normalPriorityQueue: make(chan []byte, 16),
+		lowPriorityQueue:    make(chan []byte, 16),
+		gameID:              gameID,
+		playerID:            "viewer-" + sessionID,
+		sessionID:           sessionID,
+		spectator:           true,
+	}
+	hub.addSpectator(client)
+
+	// The hub closes its spectators' sockets on shutdown, and these have none
+	t.Cleanup(func() {
+		hub.spectatorsMutex.Lock()
+		if feed, ok := hub.spectators[gameID]; ok {
+			delete(feed.clients, sessionID)
+		}
+		hub.spectatorsMutex.Unlock()
+	})
+	return client
+}
+
+func TestSpectators(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	mr := miniredis.RunT(t)
+
+	hub := newTestNode(t, ctx, mr, "node-a")
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { redisClient.Close() })
+	gm := manager.NewGameManagerWithOptions(ctx, nil, redisClient, zap.NewNop().Sugar(), hub, nil, manager.Options{
+		Store: manager.NewMemoryGameStore(),
+	})
+	hub.SetGameManager(gm)
+	gameID, err := gm.CreateGame("alice", "wallet-alice", "", 4, 0)
+	require.NoError(t, err)
+	alice := addTestClient(t, mr, hub, gameID, "alice")
+
+	assert.ErrorIs(t, hub.CanSpectate(gameID, "eve"), ErrSpectatingDisabled)
+	hub.SetSpectators(2, 100*time.Millisecond)
+	assert.ErrorIs(t, hub.CanSpectate("missing", "eve"), ErrNotSpectatable)
+	require.NoError(t, hub.CanSpectate(gameID, "eve"))
+
+	eve := addTestSpectator(t, hub, gameID, "eve-session")
+	assert.Equal(t, 1, hub.SpectatorCount(gameID))
+
+	hub.BroadcastToGame(gameID, []byte(`{"type":"game_state_update","players":[{"playerId":"alice","walletSignature":"sig","cards":[{"cardId":"c1"}]}],"terms":{"give":100}}`))
+
+	// Players see the broadcast straight away, with the spectator count
+	got := decodeAll(t, received(alice, 50*time.Millisecond))
+	require.Len(t, got, 1)
+	assert.Equal(t, float64(1), got[0]["spectatorCount"])
+
+	// Spectators see it after the delay, without the private details
+	assert.Empty(t, received(eve, 10*time.Millisecond))
+	frank := addTestSpectator(t, hub, gameID, "frank-session")
+	got = decodeAll(t, received(eve, 200*time.Millisecond))
+	require.Len(t, got, 1)
+	assert.Equal(t, "game_state_update", got[0]["type"])
+	assert.NotContains(t, got[0], "terms")
+	player := got[0]["players"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, []interface{}{}, player["cards"])
+	assert.Equal(t, float64(1), player["cardCount"])
+	assert.NotContains(t, player, "walletSignature")
+
+	// Broadcasts sent before a spectator joined aren't replayed to it
+	assert.Empty(t, received(frank, 10*time.Millisecond))
+	assert.ErrorIs(t, hub.CanSpectate(gameID, "eve"), ErrSpectatorsFull)
+
+	// Spectators are read-only
+	eve.handleMessage([]byte(`{"v":1,"type":"roll_dice","requestId":"r-1","payload":{}}`))
+	got = decodeAll(t, received(eve, 20*time.Millisecond))
+	require.Len(t, got, 1)
+	assert.Equal(t, protocol.CodeReadOnly, got[0]["code"])
+	assert.Equal(t, "r-1", got[0]["requestId"])
+	eve.handleMessage([]byte(`{"type":"roll_dice"}`))
+	assert.Empty(t, received(eve, 20*time.Millisecond))
+	assert.Empty(t, received(alice, 20*time.Millisecond))
+
+	hub.unregister <- eve
+	hub.unregister <- frank
+	require.Eventually(t, func() bool { return hub.SpectatorCount(gameID) == 0 }, time.Second, 5*time.Millisecond)
+
+	hub.BroadcastToGame(gameID, []byte(`{"type":"turn_changed"}`))
+	got = decodeAll(t, received(alice, 50*time.Millisecond))
+	require.Len(t, got, 1)
+	assert.NotContains(t, got[0], "spectatorCount")
+}
+
+func TestSpectatorsOnAnotherNode(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	mr := miniredis.RunT(t)
+
+	nodeA := newTestNode(t, ctx, mr, "node-a")
+	nodeB := newTestNode(t, ctx, mr, "node-b")
+	for _, hub := range []*Hub{nodeA, nodeB} {
+		hub.SetSpectators(5, 0)
+	}
+	alice := addTestClient(t, mr, nodeA, "game1", "alice")
+	eve := addTestSpectator(t, nodeB, "game1", "eve-session")
+
+	// Node B has no players in the game, but still follows it for its spectator
+	require.Eventually(t, func() bool {
+		return mr.PubSubNumSub(gameChannel("game1"))[gameChannel("game1")] == 2
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, 1, nodeA.SpectatorCount("game1"))
+
+	// Node B tells node A about its spectator, so node A's broadcasts needn't ask Redis
+	require.Eventually(t, func() bool {
+		nodeA.spectatorsMutex.RLock()
+		defer nodeA.spectatorsMutex.RUnlock()
+		return nodeA.spectatorCounts["game1"] == 1
+	}, time.Second, 5*time.Millisecond)
+
+	nodeA.BroadcastToGame("game1", []byte(`{"type":"dice_rolled","dice":[3,4]}`))
+	for _, client := range []*Client{alice, eve} {
+		got := decodeAll(t, received(client, 100*time.Millisecond))
+		require.Len(t, got, 1)
+		assert.Equal(t, "dice_rolled", got[0]["type"])
+		assert.Equal(t, float64(1), got[0]["spectatorCount"])
+	}
+}
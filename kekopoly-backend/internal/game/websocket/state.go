@@ -0,0 +1,190 @@
+package websocket
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/kekopoly/backend/internal/game/models"
+	"github.com/kekopoly/backend/internal/game/protocol"
+	"github.com/kekopoly/backend/internal/game/statediff"
+)
+
+// StateUpdate is a new game state, encoded for clients holding the base version
+// and for everyone else
+type StateUpdate struct {
+	BaseVersion int64  `json:"baseVersion,omitempty"`
+	Version     int64  `json:"version"`
+	Delta       []byte `json:"delta,omitempty"` // state_delta from BaseVersion, when smaller than Full
+	Full        []byte `json:"full"`            // state_full
+}
+
+// stateSnapshot is the last state sent for a game
+type stateSnapshot struct {
+	version int64
+	doc     interface{} // Only kept by the node that sent it, which diffs against it
+	full    []byte      // state_full at this version
+}
+
+// stateVersionKey counts a game's state versions across nodes, so a version
+// names the same state whichever node sent it
+func stateVersionKey(gameID string) string {
+	return fmt.Sprintf("kekopoly:game:%s:state_version", strings.ToLower(gameID))
+}
+
+// nextStateVersion returns a new version number for a game's state
+func (h *Hub) nextStateVersion(gameID string) (int64, error) {
+	if h.redisClient == nil {
+		return atomic.AddInt64(&h.localStateVersion, 1), nil
+	}
+	ctx, cancel := context.WithTimeout(h.ctx, 2*time.Second)
+	defer cancel()
+	version, err := h.redisClient.Incr(ctx, stateVersionKey(gameID)).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get state version for game %s: %w", gameID, err)
+	}
+	return version, nil
+}
+
+// PublishState sends a game's new state to the clients subscribed with sync_state.
+// Clients holding the previous version get a patch; the rest get the whole game.
+func (h *Hub) PublishState(gameID string, game *models.Game) {
+	doc, err := statediff.Normalize(game)
+	if err != nil {
+		h.logger.Errorf("[State] %v", err)
+		return
+	}
+	version, err := h.nextStateVersion(gameID)
+	if err != nil {
+		h.logger.Errorf("[State] %v", err)
+		return
+	}
+
+	update := &StateUpdate{Version: version}
+	update.Full, err = protocol.Encode(&protocol.StateFull{GameID: gameID, Version: version, State: doc})
+	if err != nil {
+		h.logger.Errorf("[State] %v", err)
+		return
+	}
+
+	h.statesMutex.Lock()
+	previous := h.states[gameID]
+	if previous != nil && previous.version > version {
+		// A newer state went out while this one was being prepared
+		h.statesMutex.Unlock()
+		return
+	}
+	h.states[gameID] = &stateSnapshot{version: version, doc: doc, full: update.Full}
+	h.statesMutex.Unlock()
+
+	if previous != nil && previous.doc != nil {
+		delta, err := protocol.Encode(&protocol.StateDelta{
+			GameID:      gameID,
+			BaseVersion: previous.version,
+			Version:     version,
+			Patch:       statediff.Diff(previous.doc, doc),
+		})
+		if err != nil {
+			h.logger.Errorf("[State] %v", err)
+		} else if len(delta) < len(update.Full) {
+			update.BaseVersion = previous.version
+			update.Delta = delta
+		}
+	}
+
+	h.publishRemote(Envelope{GameID: gameID, State: update})
+	h.deliverState(gameID, update)
+}
+
+// deliverRemoteState records a state another node sent and passes it to this node's clients
+func (h *Hub) deliverRemoteState(gameID string, update *StateUpdate) {
+	h.statesMutex.Lock()
+	if current := h.states[gameID]; current == nil || current.version < update.Version {
+		h.states[gameID] = &stateSnapshot{version: update.Version, full: update.Full}
+	}
+	h.statesMutex.Unlock()
+	h.deliverState(gameID, update)
+}
+
+// deliverState sends a state update to this node's subscribed clients in the game
+func (h *Hub) deliverState(gameID string, update *StateUpdate) {
+	h.clientsMutex.RLock()
+	subscribed := make([]*Client, 0)
+	for _, client := range h.clients[gameID] {
+		if client.syncsState() {
+			subscribed = append(subscribed, client)
+		}
+	}
+	h.clientsMutex.RUnlock()
+
+	for _, client := range subscribed {
+		message := update.Full
+		if update.Delta != nil && client.stateVersion() == update.BaseVersion {
+			message = update.Delta
+		}
+		client.sendState(message, update.Version)
+	}
+}
+
+// currentState returns the last state sent for a game
+func (h *Hub) currentState(gameID string) *stateSnapshot {
+	h.statesMutex.Lock()
+	defer h.statesMutex.Unlock()
+	return h.states[gameID]
+}
+
+// forgetState drops a game's last state once it has no clients left on this node
+func (h *Hub) forgetState(gameID string) {
+	h.statesMutex.Lock()
+	delete(h.states, gameID)
+	h.statesMutex.Unlock()
+}
+
+// syncsState reports whether the client subscribed to state updates
+func (c *Client) syncsState() bool {
+	return atomic.LoadInt32(&c.stateSubscribed) == 1
+}
+
+// stateVersion returns the state version the client holds, 0 if unknown
+func (c *Client) stateVersion() int64 {
+	return atomic.LoadInt64(&c.heldStateVersion)
+}
+
+// sendState queues a state message. If it can't be queued the client's copy is
+// treated as unknown, so it gets the whole game next time.
+func (c *Client) sendState(message []byte, version int64) {
+	if c.hub.SendToPlayerWithPriority(c.gameID, c.playerID, message, PriorityHigh) {
+		atomic.StoreInt64(&c.heldStateVersion, version)
+	} else {
+		atomic.StoreInt64(&c.heldStateVersion, 0)
+	}
+}
+
+// handleSyncState subscribes the client to state updates and sends the whole game
+// if the client's version is out of date
+func (c *Client) handleSyncState(req *protocol.SyncStateRequest) error {
+	atomic.StoreInt64(&c.heldStateVersion, req.Version)
+	atomic.StoreInt32(&c.stateSubscribed, 1)
+
+	current := c.hub.currentState(c.gameID)
+	if current == nil {
+		// Nothing sent yet; start from the game as it is now
+		if c.hub.gameManager == nil {
+			return &requestError{code: protocol.CodeGameNotFound, message: "Failed to get game state"}
+		}
+		game, err := c.hub.gameManager.GetGame(c.gameID)
+		if err != nil {
+			return &requestError{code: protocol.CodeGameNotFound, message: fmt.Sprintf("Failed to get game state: %v", err)}
+		}
+		c.hub.PublishState(c.gameID, game)
+		return nil
+	}
+	if current.version == req.Version {
+		return nil
+	}
+
+	c.sendState(current.full, current.version)
+	return nil
+}
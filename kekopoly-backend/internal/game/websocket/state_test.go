@@ -0,0 +1,129 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/kekopoly/backend/internal/game/manager"
+	"github.com/kekopoly/backend/internal/game/models"
+	"github.com/kekopoly/backend/internal/game/protocol"
+	"github.com/kekopoly/backend/internal/game/statediff"
+)
+
+// nextState reads the one state message queued for the client
+func nextState(t *testing.T, client *Client) map[string]json.RawMessage {
+	t.Helper()
+	messages := receivedByPriority(client, 50*time.Millisecond)
+	require.Len(t, messages, 1)
+	var msg map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal([]byte(messages[0]), &msg))
+	return msg
+}
+
+func TestStateUpdates(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	mr := miniredis.RunT(t)
+
+	hub := newTestNode(t, ctx, mr, "node-a")
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { redisClient.Close() })
+	gm := manager.NewGameManagerWithOptions(ctx, nil, redisClient, zap.NewNop().Sugar(), hub, nil, manager.Options{
+		Store: manager.NewMemoryGameStore(),
+	})
+	hub.SetGameManager(gm)
+	gameID, err := gm.CreateGame("alice", "wallet-alice", "", 4, 0)
+	require.NoError(t, err)
+
+	alice := addTestClient(t, mr, hub, gameID, "alice")
+	bob := addTestClient(t, mr, hub, gameID, "bob")
+
+	// Subscribing with no state gets the whole game
+	alice.handleMessage([]byte(`{"type":"sync_state","version":0}`))
+	msg := nextState(t, alice)
+	assert.JSONEq(t, `"state_full"`, string(msg["type"]))
+	assert.JSONEq(t, `1`, string(msg["version"]))
+	var held interface{}
+	require.NoError(t, json.Unmarshal(msg["state"], &held))
+
+	// Changes arrive as a patch against the version alice holds
+	game, err := gm.GetGame(gameID)
+	require.NoError(t, err)
+	changed := *game
+	changed.CurrentTurn = "alice"
+	changed.Players = append(changed.Players[:0:0], game.Players...)
+	changed.Players[0].Position = 7
+	hub.PublishState(gameID, &changed)
+
+	msg = nextState(t, alice)
+	assert.JSONEq(t, `"state_delta"`, string(msg["type"]))
+	assert.JSONEq(t, `1`, string(msg["baseVersion"]))
+	assert.JSONEq(t, `2`, string(msg["version"]))
+	var patch statediff.Patch
+	require.NoError(t, json.Unmarshal(msg["patch"], &patch))
+	held, err = statediff.Apply(held, patch)
+	require.NoError(t, err)
+	expected, err := statediff.Normalize(&changed)
+	require.NoError(t, err)
+	assert.Equal(t, expected, held)
+
+	// Clients that didn't subscribe get nothing
+	assert.Empty(t, received(bob, 50*time.Millisecond))
+
+	// A client on another version gets the whole game, then patches from there
+	bob.handleMessage([]byte(`{"v":1,"type":"sync_state","requestId":"r-1","payload":{"version":1}}`))
+	got := receivedByPriority(bob, 50*time.Millisecond)
+	require.Len(t, got, 2)
+	assert.Contains(t, got[0], `"type":"state_full"`)
+	assert.Contains(t, got[0], `"version":2`)
+	assert.Contains(t, got[1], `"type":"ack"`)
+
+	changed.Players[0].Position = 9
+	hub.PublishState(gameID, &changed)
+	for _, client := range []*Client{alice, bob} {
+		msg = nextState(t, client)
+		assert.JSONEq(t, `"state_delta"`, string(msg["type"]))
+		assert.JSONEq(t, `2`, string(msg["baseVersion"]))
+	}
+}
+
+func TestStateUpdatesReachOtherNodes(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	mr := miniredis.RunT(t)
+
+	nodeA := newTestNode(t, ctx, mr, "node-a")
+	nodeB := newTestNode(t, ctx, mr, "node-b")
+	carol := addTestClient(t, mr, nodeB, "game1", "carol")
+
+	// Node B has no game manager, so it has nothing to send yet
+	carol.handleMessage([]byte(`{"v":1,"type":"sync_state","payload":{"version":0}}`))
+	got := decodeAll(t, received(carol, 50*time.Millisecond))
+	require.Len(t, got, 1)
+	assert.Equal(t, protocol.CodeGameNotFound, got[0]["code"])
+
+	game := &models.Game{Name: "Remote", CurrentTurn: "carol"}
+	nodeA.PublishState("game1", game)
+	msg := nextState(t, carol)
+	assert.JSONEq(t, `"state_full"`, string(msg["type"]))
+
+	game.CurrentTurn = "dave"
+	nodeA.PublishState("game1", game)
+	msg = nextState(t, carol)
+	assert.JSONEq(t, `"state_delta"`, string(msg["type"]))
+	assert.JSONEq(t, `[{"op":"replace","path":"/currentTurn","value":"dave"}]`, string(msg["patch"]))
+
+	// Node B keeps the latest state for clients that fall behind
+	carol.handleMessage([]byte(`{"type":"sync_state","version":1}`))
+	msg = nextState(t, carol)
+	assert.JSONEq(t, `"state_full"`, string(msg["type"]))
+	assert.JSONEq(t, `2`, string(msg["version"]))
+}
@@ -0,0 +1,398 @@
+package matchmaking
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/kekopoly/backend/internal/game/models"
+)
+
+// Game sizes the matcher can create, as CreateGame allows
+const (
+	MinPlayers = 2
+	MaxPlayers = 6
+)
+
+var (
+	// ErrInvalidPreferences is returned for preferences no game could meet
+	ErrInvalidPreferences = errors.New("invalid matchmaking preferences")
+	// ErrNotQueued is returned for a player who isn't waiting for a match
+	ErrNotQueued = errors.New("player is not queued")
+)
+
+// Games creates the matched games, and abandons those too few players could join;
+// *manager.GameManager implements it
+type Games interface {
+	CreateGame(hostPlayerID, hostWalletAddress, gameName string, maxPlayers, buyIn int) (string, error)
+	JoinGame(gameID, playerID, walletAddress string) (string, error)
+	EndGame(gameID string, status models.GameStatus, winnerID string) error
+}
+
+// Notifier tells matched players which game they are in
+type Notifier interface {
+	NotifyMatch(gameID string, playerIDs []string)
+}
+
+// Preferences are what a player asks of a quick match
+type Preferences struct {
+	Players int    `json:"players"` // Preferred number of players
+	BuyIn   int    `json:"buyIn"`   // Raw KMT units, 0 for the server default
+	Mode    string `json:"mode"`    // Empty for CLASSIC
+}
+
+// Ticket is a player waiting for a match
+type Ticket struct {
+	PlayerID      string      `json:"playerId"`
+	WalletAddress string      `json:"walletAddress"`
+	Preferences   Preferences `json:"preferences"`
+	EnqueuedAt    time.Time   `json:"enqueuedAt"`
+}
+
+// Status describes a queued player's wait
+type Status struct {
+	Ticket
+	Position int `json:"position"` // 1 for the player who has waited longest
+	Widened  int `json:"widened"`  // Widening steps applied to the preferences so far
+}
+
+// Options configures matching
+type Options struct {
+	Interval   time.Duration // Between matching rounds
+	WidenAfter time.Duration // Wait before each widening step
+	BuyInStep  int           // Percent of the preferred buy-in each step allows either way
+	MaxWiden   int           // Widening steps; each also allows one more or fewer player
+	MaxWait    time.Duration // Before an unmatched player leaves the queue
+	AllowBuyIn bool          // Whether players may ask for a buy-in, which needs settlement
+}
+
+// Matchmaker queues players for quick matches and groups compatible ones into games.
+// Every node may run it; a lock in Redis keeps rounds from overlapping.
+type Matchmaker struct {
+	client   *redis.Client
+	games    Games
+	notifier Notifier
+	opts     Options
+	logger   *zap.SugaredLogger
+	token    string // Identifies this node's hold on the lock
+	now      func() time.Time
+}
+
+// NewMatchmaker creates a matchmaker
+func NewMatchmaker(client *redis.Client, games Games, notifier Notifier, opts Options, logger *zap.SugaredLogger) *Matchmaker {
+	if opts.Interval <= 0 {
+		opts.Interval = 2 * time.Second
+	}
+	if opts.WidenAfter <= 0 {
+		opts.WidenAfter = 15 * time.Second
+	}
+	if opts.BuyInStep < 0 {
+		opts.BuyInStep = 0
+	}
+	if opts.MaxWiden < 0 {
+		opts.MaxWiden = 0
+	}
+	if opts.MaxWait <= 0 {
+		opts.MaxWait = 10 * time.Minute
+	}
+	return &Matchmaker{
+		client:   client,
+		games:    games,
+		notifier: notifier,
+		opts:     opts,
+		logger:   logger,
+		token:    uuid.New().String(),
+		now:      time.Now,
+	}
+}
+
+// Enqueue puts a player in the queue, replacing any earlier preferences. Their wait
+// starts over.
+func (m *Matchmaker) Enqueue(ctx context.Context, playerID, walletAddress string, prefs Preferences) (*Status, error) {
+	prefs, err := m.normalize(prefs)
+	if err != nil {
+		return nil, err
+	}
+	ticket := Ticket{
+		PlayerID:      playerID,
+		WalletAddress: walletAddress,
+		Preferences:   prefs,
+		EnqueuedAt:    m.now().Truncate(time.Millisecond),
+	}
+	if err := put(ctx, m.client, ticket); err != nil {
+		return nil, err
+	}
+	m.logger.Infof("[Matchmaking] Player %s queued for %d players, buy-in %d, mode %s", playerID, prefs.Players, prefs.BuyIn, prefs.Mode)
+	return m.Status(ctx, playerID)
+}
+
+// normalize checks a player's preferences and fills in the defaults
+func (m *Matchmaker) normalize(prefs Preferences) (Preferences, error) {
+	if prefs.Players < MinPlayers || prefs.Players > MaxPlayers {
+		return prefs, fmt.Errorf("%w: players must be between %d and %d", ErrInvalidPreferences, MinPlayers, MaxPlayers)
+	}
+	if prefs.BuyIn < 0 {
+		return prefs, fmt.Errorf("%w: buy-in must not be negative", ErrInvalidPreferences)
+	}
+	if prefs.BuyIn > 0 && !m.opts.AllowBuyIn {
+		return prefs, fmt.Errorf("%w: buy-ins are not available on this server", ErrInvalidPreferences)
+	}
+	switch models.GameMode(strings.ToUpper(prefs.Mode)) {
+	case "", models.GameModeClassic:
+		prefs.Mode = string(models.GameModeClassic)
+	default:
+		return prefs, fmt.Errorf("%w: unknown mode %q", ErrInvalidPreferences, prefs.Mode)
+	}
+	return prefs, nil
+}
+
+// Dequeue takes a player out of the queue
+func (m *Matchmaker) Dequeue(ctx context.Context, playerID string) error {
+	pipe := m.client.TxPipeline()
+	removed := pipe.ZRem(ctx, queueKey, playerID)
+	pipe.HDel(ctx, ticketsKey, playerID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to leave the queue: %w", err)
+	}
+	if removed.Val() == 0 {
+		return ErrNotQueued
+	}
+	m.logger.Infof("[Matchmaking] Player %s left the queue", playerID)
+	return nil
+}
+
+// Status returns a queued player's ticket and place in the queue
+func (m *Matchmaker) Status(ctx context.Context, playerID string) (*Status, error) {
+	raw, err := m.client.HGet(ctx, ticketsKey, playerID).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotQueued
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ticket: %w", err)
+	}
+	status := &Status{}
+	if err := json.Unmarshal([]byte(raw), &status.Ticket); err != nil {
+		return nil, fmt.Errorf("failed to decode ticket: %w", err)
+	}
+	rank, err := m.client.ZRank(ctx, queueKey, playerID).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotQueued
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read queue position: %w", err)
+	}
+	status.Position = int(rank) + 1
+	status.Widened = m.widened(status.Ticket, m.now())
+	return status, nil
+}
+
+// Run matches players every interval until ctx is done
+func (m *Matchmaker) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.opts.Interval)
+	defer ticker.Stop()
+	m.logger.Infof("[Matchmaking] Matching every %s", m.opts.Interval)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := m.Match(ctx); err != nil {
+				m.logger.Errorf("[Matchmaking] %v", err)
+			}
+		}
+	}
+}
+
+// Match runs one matching round, returning how many games it started. It does
+// nothing while another node is running a round.
+func (m *Matchmaker) Match(ctx context.Context) (int, error) {
+	locked, err := m.client.SetNX(ctx, lockKey, m.token, lockTTL).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to take the matchmaking lock: %w", err)
+	}
+	if !locked {
+		return 0, nil
+	}
+	defer func() {
+		if err := releaseScript.Run(ctx, m.client, []string{lockKey}, m.token).Err(); err != nil {
+			m.logger.Warnf("[Matchmaking] Failed to release the lock: %v", err)
+		}
+	}()
+
+	tickets, err := load(ctx, m.client)
+	if err != nil {
+		return 0, err
+	}
+
+	now := m.now()
+	waiting := tickets[:0]
+	for _, ticket := range tickets {
+		if now.Sub(ticket.EnqueuedAt) <= m.opts.MaxWait {
+			waiting = append(waiting, ticket)
+			continue
+		}
+		if _, err := claim(ctx, m.client, []Ticket{ticket}); err != nil {
+			return 0, err
+		}
+		m.logger.Infof("[Matchmaking] Player %s left the queue unmatched after %s", ticket.PlayerID, m.opts.MaxWait)
+	}
+
+	started := 0
+	for _, group := range m.group(waiting, now) {
+		claimed, err := claim(ctx, m.client, group)
+		if err != nil {
+			return started, err
+		}
+		if !claimed {
+			continue
+		}
+		if m.start(ctx, group) {
+			started++
+		}
+	}
+	return started, nil
+}
+
+// start creates a game for a matched group, hosted by the player who waited longest,
+// and joins the others to it. If the game can't be created the group goes back in the
+// queue. Players who can't be joined go back in the queue too, keeping their place,
+// unless that leaves too few to play; then the game is abandoned and the whole group
+// goes back.
+func (m *Matchmaker) start(ctx context.Context, group []Ticket) bool {
+	host := group[0]
+	gameID, err := m.games.CreateGame(host.PlayerID, host.WalletAddress, "", len(group), host.Preferences.BuyIn)
+	if err != nil {
+		m.logger.Errorf("[Matchmaking] Failed to create a game for %d players: %v", len(group), err)
+		m.requeue(ctx, group)
+		return false
+	}
+
+	players := []string{host.PlayerID}
+	var unjoined []Ticket
+	for _, ticket := range group[1:] {
+		if _, err := m.games.JoinGame(gameID, ticket.PlayerID, ticket.WalletAddress); err != nil {
+			m.logger.Errorf("[Matchmaking] Failed to join player %s to game %s: %v", ticket.PlayerID, gameID, err)
+			unjoined = append(unjoined, ticket)
+			continue
+		}
+		players = append(players, ticket.PlayerID)
+	}
+
+	if len(players) < MinPlayers {
+		if err := m.games.EndGame(gameID, models.GameStatusAbandoned, ""); err != nil {
+			m.logger.Errorf("[Matchmaking] Failed to abandon game %s: %v", gameID, err)
+		}
+		m.logger.Infof("[Matchmaking] Abandoned game %s with only players %v", gameID, players)
+		m.requeue(ctx, group)
+		return false
+	}
+	m.requeue(ctx, unjoined)
+
+	m.logger.Infof("[Matchmaking] Matched players %v into game %s", players, gameID)
+	if m.notifier != nil {
+		m.notifier.NotifyMatch(gameID, players)
+	}
+	return true
+}
+
+// requeue puts claimed tickets back in the queue as they were
+func (m *Matchmaker) requeue(ctx context.Context, tickets []Ticket) {
+	if len(tickets) == 0 {
+		return
+	}
+	if err := put(ctx, m.client, tickets...); err != nil {
+		m.logger.Errorf("[Matchmaking] Failed to requeue players: %v", err)
+	}
+}
+
+// group finds the games the queued players can be matched into. Going from the
+// longest waiting player, each takes the players who accept its buy-in and mode and a
+// game size both allow, trying its preferred size first.
+func (m *Matchmaker) group(tickets []Ticket, now time.Time) [][]Ticket {
+	matched := make(map[string]bool, len(tickets))
+	var groups [][]Ticket
+	for i, anchor := range tickets {
+		if matched[anchor.PlayerID] {
+			continue
+		}
+		steps := m.widened(anchor, now)
+
+		var candidates []Ticket
+		for _, ticket := range tickets[i+1:] {
+			if matched[ticket.PlayerID] || ticket.Preferences.Mode != anchor.Preferences.Mode {
+				continue
+			}
+			if m.acceptsBuyIn(ticket, anchor.Preferences.BuyIn, now) {
+				candidates = append(candidates, ticket)
+			}
+		}
+
+		for _, size := range sizes(anchor.Preferences.Players, steps) {
+			group := []Ticket{anchor}
+			for _, ticket := range candidates {
+				if len(group) == size {
+					break
+				}
+				if abs(size-ticket.Preferences.Players) <= m.widened(ticket, now) {
+					group = append(group, ticket)
+				}
+			}
+			if len(group) < size {
+				continue
+			}
+			for _, ticket := range group {
+				matched[ticket.PlayerID] = true
+			}
+			groups = append(groups, group)
+			break
+		}
+	}
+	return groups
+}
+
+// widened returns how many widening steps a ticket has earned by now
+func (m *Matchmaker) widened(ticket Ticket, now time.Time) int {
+	steps := int(now.Sub(ticket.EnqueuedAt) / m.opts.WidenAfter)
+	if steps < 0 {
+		return 0
+	}
+	if steps > m.opts.MaxWiden {
+		return m.opts.MaxWiden
+	}
+	return steps
+}
+
+// acceptsBuyIn reports whether a ticket's player would now play for a buy-in
+func (m *Matchmaker) acceptsBuyIn(ticket Ticket, buyIn int, now time.Time) bool {
+	slack := ticket.Preferences.BuyIn * m.opts.BuyInStep * m.widened(ticket, now) / 100
+	return abs(buyIn-ticket.Preferences.BuyIn) <= slack
+}
+
+// sizes lists the game sizes within steps of a preferred size, nearest first and
+// larger before smaller
+func sizes(preferred, steps int) []int {
+	out := []int{preferred}
+	for d := 1; d <= steps; d++ {
+		if preferred+d <= MaxPlayers {
+			out = append(out, preferred+d)
+		}
+		if preferred-d >= MinPlayers {
+			out = append(out, preferred-d)
+		}
+	}
+	return out
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
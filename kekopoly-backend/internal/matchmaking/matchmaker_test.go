@@ -0,0 +1,254 @@
+package matchmaking
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/kekopoly/backend/internal/game/models"
+)
+
+// fakeGames records the games the matcher creates
+type fakeGames struct {
+	mu       sync.Mutex
+	fail     bool
+	failJoin map[string]bool // Players who can't be joined to a game
+	created  []createdGame
+	ended    []string
+}
+
+type createdGame struct {
+	host       string
+	maxPlayers int
+	buyIn      int
+	players    []string
+}
+
+func (f *fakeGames) CreateGame(hostPlayerID, hostWalletAddress, gameName string, maxPlayers, buyIn int) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.fail {
+		return "", errors.New("store unavailable")
+	}
+	f.created = append(f.created, createdGame{host: hostPlayerID, maxPlayers: maxPlayers, buyIn: buyIn, players: []string{hostPlayerID}})
+	return fmt.Sprintf("game-%d", len(f.created)), nil
+}
+
+func (f *fakeGames) JoinGame(gameID, playerID, walletAddress string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failJoin[playerID] {
+		return "", errors.New("player is in another game")
+	}
+	game := &f.created[len(f.created)-1]
+	game.players = append(game.players, playerID)
+	return gameID, nil
+}
+
+func (f *fakeGames) EndGame(gameID string, status models.GameStatus, winnerID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.ended = append(f.ended, gameID)
+	return nil
+}
+
+// fakeNotifier records the matches announced
+type fakeNotifier struct {
+	matches map[string][]string
+}
+
+func (f *fakeNotifier) NotifyMatch(gameID string, playerIDs []string) {
+	f.matches[gameID] = playerIDs
+}
+
+type testMatchmaker struct {
+	*Matchmaker
+	mr       *miniredis.Miniredis
+	games    *fakeGames
+	notifier *fakeNotifier
+	clock    time.Time
+}
+
+func newTestMatchmaker(t *testing.T, opts Options) *testMatchmaker {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	tm := &testMatchmaker{
+		mr:       mr,
+		games:    &fakeGames{},
+		notifier: &fakeNotifier{matches: make(map[string][]string)},
+		clock:    time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC),
+	}
+	tm.Matchmaker = NewMatchmaker(client, tm.games, tm.notifier, opts, zap.NewNop().Sugar())
+	tm.now = func() time.Time { return tm.clock }
+	return tm
+}
+
+func (tm *testMatchmaker) enqueue(t *testing.T, playerID string, prefs Preferences) {
+	t.Helper()
+	_, err := tm.Enqueue(context.Background(), playerID, "wallet-"+playerID, prefs)
+	require.NoError(t, err)
+	tm.clock = tm.clock.Add(time.Second)
+}
+
+func TestQueue(t *testing.T) {
+	ctx := context.Background()
+	tm := newTestMatchmaker(t, Options{})
+
+	for _, prefs := range []Preferences{{Players: 1}, {Players: 7}, {Players: 4, BuyIn: 100}, {Players: 4, BuyIn: -1}, {Players: 4, Mode: "speed"}} {
+		_, err := tm.Enqueue(ctx, "alice", "wallet-alice", prefs)
+		assert.ErrorIs(t, err, ErrInvalidPreferences, "%+v", prefs)
+	}
+
+	tm.enqueue(t, "alice", Preferences{Players: 4, Mode: "classic"})
+	tm.enqueue(t, "bob", Preferences{Players: 3})
+	status, err := tm.Status(ctx, "bob")
+	require.NoError(t, err)
+	assert.Equal(t, 2, status.Position)
+	assert.Equal(t, "CLASSIC", status.Preferences.Mode)
+
+	// Queuing again starts the wait over
+	tm.enqueue(t, "alice", Preferences{Players: 4})
+	status, err = tm.Status(ctx, "bob")
+	require.NoError(t, err)
+	assert.Equal(t, 1, status.Position)
+
+	require.NoError(t, tm.Dequeue(ctx, "bob"))
+	assert.ErrorIs(t, tm.Dequeue(ctx, "bob"), ErrNotQueued)
+	_, err = tm.Status(ctx, "bob")
+	assert.ErrorIs(t, err, ErrNotQueued)
+}
+
+func TestMatchWidensPlayerCount(t *testing.T) {
+	ctx := context.Background()
+	tm := newTestMatchmaker(t, Options{WidenAfter: 10 * time.Second, MaxWiden: 3})
+
+	for _, player := range []string{"alice", "bob", "carol"} {
+		tm.enqueue(t, player, Preferences{Players: 4})
+	}
+	tm.enqueue(t, "dave", Preferences{Players: 2})
+
+	started, err := tm.Match(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, started)
+
+	// Once dave will play with three others, everyone fits in one game
+	tm.clock = tm.clock.Add(20 * time.Second)
+	status, err := tm.Status(ctx, "dave")
+	require.NoError(t, err)
+	assert.Equal(t, 2, status.Widened)
+
+	started, err = tm.Match(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, started)
+	require.Len(t, tm.games.created, 1)
+	game := tm.games.created[0]
+	assert.Equal(t, "alice", game.host)
+	assert.Equal(t, 4, game.maxPlayers)
+	assert.Equal(t, []string{"alice", "bob", "carol", "dave"}, game.players)
+	assert.Equal(t, game.players, tm.notifier.matches["game-1"])
+
+	_, err = tm.Status(ctx, "alice")
+	assert.ErrorIs(t, err, ErrNotQueued)
+}
+
+func TestMatchWidensBuyIn(t *testing.T) {
+	ctx := context.Background()
+	tm := newTestMatchmaker(t, Options{WidenAfter: 10 * time.Second, BuyInStep: 10, MaxWiden: 5, AllowBuyIn: true})
+	tm.enqueue(t, "alice", Preferences{Players: 2, BuyIn: 1000})
+	tm.enqueue(t, "bob", Preferences{Players: 2, BuyIn: 1150})
+	tm.enqueue(t, "carol", Preferences{Players: 2, BuyIn: 5000})
+
+	// bob accepts 115 either way after one step and 230 after two
+	tm.clock = tm.clock.Add(10 * time.Second)
+	started, err := tm.Match(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, started)
+
+	tm.clock = tm.clock.Add(10 * time.Second)
+	started, err = tm.Match(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, started)
+	assert.Equal(t, 1000, tm.games.created[0].buyIn)
+	assert.Equal(t, []string{"alice", "bob"}, tm.games.created[0].players)
+
+	status, err := tm.Status(ctx, "carol")
+	require.NoError(t, err)
+	assert.Equal(t, 1, status.Position)
+}
+
+func TestMatchRound(t *testing.T) {
+	ctx := context.Background()
+	tm := newTestMatchmaker(t, Options{MaxWait: time.Minute})
+	tm.enqueue(t, "alice", Preferences{Players: 2})
+	tm.enqueue(t, "bob", Preferences{Players: 2})
+
+	// Another node is matching
+	require.NoError(t, tm.mr.Set(lockKey, "node-b"))
+	started, err := tm.Match(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, started)
+	tm.mr.Del(lockKey)
+
+	// A game that can't be created puts the players back
+	tm.games.fail = true
+	started, err = tm.Match(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, started)
+	assert.False(t, tm.mr.Exists(lockKey))
+	status, err := tm.Status(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, 1, status.Position)
+
+	// Players who wait too long leave the queue
+	tm.games.fail = false
+	tm.clock = tm.clock.Add(2 * time.Minute)
+	started, err = tm.Match(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, started)
+	_, err = tm.Status(ctx, "bob")
+	assert.ErrorIs(t, err, ErrNotQueued)
+}
+
+func TestMatchRequeuesPlayersWhoCantJoin(t *testing.T) {
+	ctx := context.Background()
+	tm := newTestMatchmaker(t, Options{})
+	for _, player := range []string{"alice", "bob", "carol"} {
+		tm.enqueue(t, player, Preferences{Players: 3})
+	}
+	tm.enqueue(t, "dave", Preferences{Players: 3})
+
+	// carol can't join, so she goes back in the queue and the game starts without her
+	tm.games.failJoin = map[string]bool{"carol": true}
+	started, err := tm.Match(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, started)
+	assert.Equal(t, []string{"alice", "bob"}, tm.notifier.matches["game-1"])
+	status, err := tm.Status(ctx, "carol")
+	require.NoError(t, err)
+	assert.Equal(t, 1, status.Position, "carol keeps her place ahead of dave")
+
+	// A game nobody could join is abandoned and the whole group goes back
+	tm.enqueue(t, "erin", Preferences{Players: 3})
+	tm.games.failJoin = map[string]bool{"dave": true, "erin": true}
+	started, err = tm.Match(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, started)
+	assert.Equal(t, []string{"game-2"}, tm.games.ended)
+	assert.NotContains(t, tm.notifier.matches, "game-2")
+	for _, player := range []string{"carol", "dave", "erin"} {
+		_, err := tm.Status(ctx, player)
+		assert.NoError(t, err, player)
+	}
+}
@@ -0,0 +1,102 @@
+package matchmaking
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// The queue is kept in Redis so every server node matches from the same players
+const (
+	queueKey   = "kekopoly:matchmaking:queue"   // Sorted set of player IDs by enqueue time
+	ticketsKey = "kekopoly:matchmaking:tickets" // Hash of player ID -> Ticket
+	lockKey    = "kekopoly:matchmaking:lock"    // Held by the node running a matching round
+)
+
+// lockTTL bounds how long a node that died mid-round keeps others from matching
+const lockTTL = 30 * time.Second
+
+// claimScript removes a group of tickets from the queue only if every one of them is
+// still queued as it was read, so a player who left or changed their preferences
+// meanwhile isn't matched. ARGV holds player ID and score pairs.
+var claimScript = redis.NewScript(`
+for i = 1, #ARGV, 2 do
+	local score = redis.call("ZSCORE", KEYS[1], ARGV[i])
+	if not score or tonumber(score) ~= tonumber(ARGV[i + 1]) then return 0 end
+end
+for i = 1, #ARGV, 2 do
+	redis.call("ZREM", KEYS[1], ARGV[i])
+	redis.call("HDEL", KEYS[2], ARGV[i])
+end
+return 1
+`)
+
+// releaseScript deletes the lock only if this node still holds it
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// score orders tickets by when they were queued
+func score(ticket Ticket) float64 {
+	return float64(ticket.EnqueuedAt.UnixMilli())
+}
+
+// put adds or replaces tickets in the queue
+func put(ctx context.Context, client *redis.Client, tickets ...Ticket) error {
+	pipe := client.TxPipeline()
+	for _, ticket := range tickets {
+		raw, err := json.Marshal(ticket)
+		if err != nil {
+			return fmt.Errorf("failed to marshal ticket: %w", err)
+		}
+		pipe.HSet(ctx, ticketsKey, ticket.PlayerID, raw)
+		pipe.ZAdd(ctx, queueKey, &redis.Z{Score: score(ticket), Member: ticket.PlayerID})
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to queue players: %w", err)
+	}
+	return nil
+}
+
+// load returns every queued ticket, longest waiting first
+func load(ctx context.Context, client *redis.Client) ([]Ticket, error) {
+	raw, err := client.HGetAll(ctx, ticketsKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the queue: %w", err)
+	}
+	tickets := make([]Ticket, 0, len(raw))
+	for _, value := range raw {
+		var ticket Ticket
+		if err := json.Unmarshal([]byte(value), &ticket); err != nil {
+			continue
+		}
+		tickets = append(tickets, ticket)
+	}
+	sort.Slice(tickets, func(i, j int) bool {
+		if !tickets[i].EnqueuedAt.Equal(tickets[j].EnqueuedAt) {
+			return tickets[i].EnqueuedAt.Before(tickets[j].EnqueuedAt)
+		}
+		return tickets[i].PlayerID < tickets[j].PlayerID
+	})
+	return tickets, nil
+}
+
+// claim takes a group of tickets off the queue, reporting false if any had changed
+func claim(ctx context.Context, client *redis.Client, tickets []Ticket) (bool, error) {
+	args := make([]interface{}, 0, 2*len(tickets))
+	for _, ticket := range tickets {
+		args = append(args, ticket.PlayerID, int64(score(ticket)))
+	}
+	claimed, err := claimScript.Run(ctx, client, []string{queueKey, ticketsKey}, args...).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to claim tickets: %w", err)
+	}
+	return claimed == 1, nil
+}
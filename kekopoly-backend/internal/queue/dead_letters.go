@@ -0,0 +1,254 @@
+package queue
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+// DeadLetterQueue summarises one game's dead letter queue
+type DeadLetterQueue struct {
+	GameID string    `json:"gameId"`
+	Count  int64     `json:"count"`
+	Oldest time.Time `json:"oldest"` // When its oldest message was parked
+	Newest time.Time `json:"newest"` // When its newest message was parked
+}
+
+// DeadLetterFilter picks dead letters. Empty fields match every message.
+type DeadLetterFilter struct {
+	IDs    []string
+	Type   MessageType
+	Before time.Time // Parked before this time
+}
+
+// IsEmpty reports whether the filter matches every message
+func (f DeadLetterFilter) IsEmpty() bool {
+	return len(f.IDs) == 0 && f.Type == "" && f.Before.IsZero()
+}
+
+// matches reports whether the filter picks msg
+func (f DeadLetterFilter) matches(msg *QueueMessage) bool {
+	if len(f.IDs) > 0 {
+		found := false
+		for _, id := range f.IDs {
+			if id == msg.ID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if f.Type != "" && msg.Type != f.Type {
+		return false
+	}
+	return f.Before.IsZero() || ParkedAt(msg).Before(f.Before)
+}
+
+// ParkedAt returns when a message was moved to its dead letter queue. Messages parked
+// before that was recorded fall back to when they were enqueued.
+func ParkedAt(msg *QueueMessage) time.Time {
+	if msg.DeadLetteredAt != nil {
+		return *msg.DeadLetteredAt
+	}
+	return msg.Timestamp
+}
+
+// deadLetterQueueName returns the name of a game's dead letter queue
+func deadLetterQueueName(gameID string) string {
+	return fmt.Sprintf("game:%s:queue:dead", gameID)
+}
+
+// deadLetter is a dead-lettered message as stored and as decoded
+type deadLetter struct {
+	raw string
+	msg QueueMessage
+}
+
+// ListDeadLetterQueues returns every game's dead letter queue that has messages in it,
+// ordered by game ID
+func (q *RedisQueue) ListDeadLetterQueues() ([]DeadLetterQueue, error) {
+	keys, err := q.client.Keys(q.ctx, "game:*:queue:dead").Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dead letter queue keys: %w", err)
+	}
+	sort.Strings(keys)
+
+	queues := make([]DeadLetterQueue, 0, len(keys))
+	for _, key := range keys {
+		pipe := q.client.Pipeline()
+		length := pipe.LLen(q.ctx, key)
+		first := pipe.LIndex(q.ctx, key, 0)
+		last := pipe.LIndex(q.ctx, key, -1)
+		if _, err := pipe.Exec(q.ctx); err != nil && err != redis.Nil {
+			return nil, fmt.Errorf("failed to read dead letter queue %s: %w", key, err)
+		}
+		if length.Val() == 0 {
+			continue // Drained since it was listed
+		}
+
+		queue := DeadLetterQueue{
+			GameID: strings.TrimSuffix(strings.TrimPrefix(key, "game:"), ":queue:dead"),
+			Count:  length.Val(),
+		}
+		var msg QueueMessage
+		if json.Unmarshal([]byte(first.Val()), &msg) == nil {
+			queue.Oldest = ParkedAt(&msg)
+		}
+		msg = QueueMessage{}
+		if json.Unmarshal([]byte(last.Val()), &msg) == nil {
+			queue.Newest = ParkedAt(&msg)
+		}
+		queues = append(queues, queue)
+	}
+	return queues, nil
+}
+
+// DeadLetters returns a game's dead letters, oldest first. Messages that can't be
+// decoded are left out.
+func (q *RedisQueue) DeadLetters(gameID string) ([]QueueMessage, error) {
+	letters, err := q.readDeadLetters(gameID)
+	if err != nil {
+		return nil, err
+	}
+	messages := make([]QueueMessage, 0, len(letters))
+	for _, letter := range letters {
+		messages = append(messages, letter.msg)
+	}
+	return messages, nil
+}
+
+// DeadLetter returns one of a game's dead letters, or nil if it has none with that ID
+func (q *RedisQueue) DeadLetter(gameID, id string) (*QueueMessage, error) {
+	letters, err := q.readDeadLetters(gameID)
+	if err != nil {
+		return nil, err
+	}
+	for _, letter := range letters {
+		if letter.msg.ID == id {
+			return &letter.msg, nil
+		}
+	}
+	return nil, nil
+}
+
+// readDeadLetters reads and decodes a game's dead letter queue
+func (q *RedisQueue) readDeadLetters(gameID string) ([]deadLetter, error) {
+	deadLetterQueue := deadLetterQueueName(gameID)
+	results, err := q.client.LRange(q.ctx, deadLetterQueue, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dead letter queue: %w", err)
+	}
+
+	letters := make([]deadLetter, 0, len(results))
+	for _, result := range results {
+		var msg QueueMessage
+		if err := json.Unmarshal([]byte(result), &msg); err != nil {
+			q.logger.Warn("Skipping undecodable message in dead letter queue",
+				zap.String("deadLetterQueue", deadLetterQueue),
+				zap.Error(err))
+			continue
+		}
+		letters = append(letters, deadLetter{raw: result, msg: msg})
+	}
+	return letters, nil
+}
+
+// RequeueDeadLetters moves the dead letters the filter picks back onto the game's stream,
+// oldest first, with their attempts reset. Their error history is kept. It returns how
+// many were moved. Messages that can't be decoded stay in the dead letter queue.
+func (q *RedisQueue) RequeueDeadLetters(gameID string, filter DeadLetterFilter) (int, error) {
+	deadLetterQueue := deadLetterQueueName(gameID)
+
+	letters, err := q.readDeadLetters(gameID)
+	if err != nil {
+		return 0, err
+	}
+
+	requeued := 0
+	for _, letter := range letters {
+		if !filter.matches(&letter.msg) {
+			continue
+		}
+		// Whoever removes the message first gets to move it
+		removed, err := q.client.LRem(q.ctx, deadLetterQueue, 1, letter.raw).Result()
+		if err != nil {
+			return requeued, fmt.Errorf("failed to take dead letter: %w", err)
+		}
+		if removed == 0 {
+			continue
+		}
+
+		msg := letter.msg
+		msg.Attempts = 0
+		msg.DeadLetteredAt = nil
+		if err := q.enqueueMessage(msg); err != nil {
+			// Put it back so it isn't lost
+			if pushErr := q.client.LPush(q.ctx, deadLetterQueue, letter.raw).Err(); pushErr != nil {
+				q.logger.Error("Failed to put dead letter back",
+					zap.String("deadLetterQueue", deadLetterQueue),
+					zap.Error(pushErr))
+			}
+			return requeued, err
+		}
+		requeued++
+	}
+
+	q.logger.Info("Requeued dead letters",
+		zap.String("deadLetterQueue", deadLetterQueue),
+		zap.Int("count", requeued))
+	return requeued, nil
+}
+
+// PurgeDeadLetters deletes the dead letters the filter picks and returns how many were
+// deleted. Messages that can't be decoded are only deleted by DrainDeadLetterQueue.
+func (q *RedisQueue) PurgeDeadLetters(gameID string, filter DeadLetterFilter) (int, error) {
+	deadLetterQueue := deadLetterQueueName(gameID)
+
+	letters, err := q.readDeadLetters(gameID)
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, letter := range letters {
+		if !filter.matches(&letter.msg) {
+			continue
+		}
+		removed, err := q.client.LRem(q.ctx, deadLetterQueue, 1, letter.raw).Result()
+		if err != nil {
+			return purged, fmt.Errorf("failed to delete dead letter: %w", err)
+		}
+		purged += int(removed)
+	}
+
+	q.logger.Info("Purged dead letters",
+		zap.String("deadLetterQueue", deadLetterQueue),
+		zap.Int("count", purged))
+	return purged, nil
+}
+
+// DrainDeadLetterQueue removes all messages from a game's dead letter queue and returns
+// how many there were
+func (q *RedisQueue) DrainDeadLetterQueue(gameID string) (int64, error) {
+	deadLetterQueue := deadLetterQueueName(gameID)
+
+	// Count and delete in one round trip so messages parked in between aren't lost uncounted
+	pipe := q.client.TxPipeline()
+	length := pipe.LLen(q.ctx, deadLetterQueue)
+	pipe.Del(q.ctx, deadLetterQueue)
+	if _, err := pipe.Exec(q.ctx); err != nil {
+		return 0, fmt.Errorf("failed to drain dead letter queue: %w", err)
+	}
+
+	q.logger.Info("Drained dead letter queue",
+		zap.String("deadLetterQueue", deadLetterQueue),
+		zap.Int64("count", length.Val()))
+	return length.Val(), nil
+}
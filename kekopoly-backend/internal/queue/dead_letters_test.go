@@ -0,0 +1,111 @@
+package queue
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListDeadLetters(t *testing.T) {
+	q, _ := newTestQueue(t)
+
+	msg := &QueueMessage{Type: PlayerTokenUpdate, GameID: "g1", PlayerID: "p1"}
+	msg.RecordError(errors.New("player not found in game"))
+	require.NoError(t, q.MoveToDeadLetterQueue("game:g1:queue", msg))
+	require.NoError(t, q.MoveToDeadLetterQueue("game:g1:queue", &QueueMessage{Type: GameStart, GameID: "g1"}))
+	require.NoError(t, q.MoveToDeadLetterQueue("game:g2:queue", &QueueMessage{Type: GameStart, GameID: "g2"}))
+
+	queues, err := q.ListDeadLetterQueues()
+	require.NoError(t, err)
+	require.Len(t, queues, 2)
+	assert.Equal(t, "g1", queues[0].GameID)
+	assert.Equal(t, int64(2), queues[0].Count)
+	assert.False(t, queues[0].Oldest.IsZero())
+	assert.False(t, queues[0].Newest.Before(queues[0].Oldest))
+
+	letters, err := q.DeadLetters("g1")
+	require.NoError(t, err)
+	require.Len(t, letters, 2)
+	assert.NotEmpty(t, letters[0].ID)
+	assert.Equal(t, "player not found in game", letters[0].LastError)
+	require.Len(t, letters[0].Errors, 1)
+	assert.Equal(t, 1, letters[0].Errors[0].Attempt)
+
+	shown, err := q.DeadLetter("g1", letters[0].ID)
+	require.NoError(t, err)
+	require.NotNil(t, shown)
+	assert.Equal(t, "p1", shown.PlayerID)
+	shown, err = q.DeadLetter("g1", "missing")
+	require.NoError(t, err)
+	assert.Nil(t, shown)
+}
+
+func TestRequeueDeadLetters(t *testing.T) {
+	q, mr := newTestQueue(t)
+	queueName := "game:g1:queue"
+
+	for _, playerID := range []string{"p1", "p2", "p3"} {
+		require.NoError(t, q.MoveToDeadLetterQueue(queueName, &QueueMessage{Type: PlayerTokenUpdate, GameID: "g1", PlayerID: playerID, Attempts: 2}))
+	}
+	_, err := mr.RPush(queueName+":dead", "not json")
+	require.NoError(t, err)
+	letters, err := q.DeadLetters("g1")
+	require.NoError(t, err)
+	require.Len(t, letters, 3)
+
+	// One message by ID
+	requeued, err := q.RequeueDeadLetters("g1", DeadLetterFilter{IDs: []string{letters[1].ID}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, requeued)
+	messages := streamMessages(t, q, "g1")
+	require.Len(t, messages, 1)
+	assert.Equal(t, "p2", messages[0].PlayerID)
+	assert.Equal(t, letters[1].ID, messages[0].ID)
+	assert.Zero(t, messages[0].Attempts)
+	assert.Nil(t, messages[0].DeadLetteredAt)
+
+	// The rest come back in order, ready for another full set of attempts
+	requeued, err = q.RequeueDeadLetters("g1", DeadLetterFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, requeued)
+	messages = streamMessages(t, q, "g1")
+	require.Len(t, messages, 3)
+	for i, playerID := range []string{"p1", "p3"} {
+		assert.Equal(t, playerID, messages[i+1].PlayerID)
+		assert.Zero(t, messages[i+1].Attempts)
+	}
+	left, err := mr.List(queueName + ":dead")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"not json"}, left)
+
+	drained, err := q.DrainDeadLetterQueue("g1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), drained)
+	assert.False(t, mr.Exists(queueName+":dead"))
+}
+
+func TestPurgeDeadLetters(t *testing.T) {
+	q, _ := newTestQueue(t)
+	queueName := "game:g1:queue"
+
+	require.NoError(t, q.MoveToDeadLetterQueue(queueName, &QueueMessage{Type: PlayerTokenUpdate, GameID: "g1", PlayerID: "p1"}))
+	require.NoError(t, q.MoveToDeadLetterQueue(queueName, &QueueMessage{Type: GameStart, GameID: "g1", PlayerID: "p2"}))
+	require.NoError(t, q.MoveToDeadLetterQueue(queueName, &QueueMessage{Type: PlayerTokenUpdate, GameID: "g1", PlayerID: "p3"}))
+
+	// Nothing was parked before an hour ago
+	purged, err := q.PurgeDeadLetters("g1", DeadLetterFilter{Before: time.Now().Add(-time.Hour)})
+	require.NoError(t, err)
+	assert.Zero(t, purged)
+
+	purged, err = q.PurgeDeadLetters("g1", DeadLetterFilter{Type: PlayerTokenUpdate})
+	require.NoError(t, err)
+	assert.Equal(t, 2, purged)
+
+	letters, err := q.DeadLetters("g1")
+	require.NoError(t, err)
+	require.Len(t, letters, 1)
+	assert.Equal(t, "p2", letters[0].PlayerID)
+}
@@ -0,0 +1,201 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+// renewLeaseScript extends a lease only if this worker still holds it
+var renewLeaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// releaseLeaseScript deletes a lease only if this worker still holds it
+var releaseLeaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// partitionReader is the goroutine reading one partition
+type partitionReader struct {
+	cancel  context.CancelFunc
+	done    chan struct{}
+	renewed time.Time // When the lease was last taken or renewed
+}
+
+// runPartitions keeps this worker's share of the partitions until the worker stops.
+// Every third of the lease TTL it renews its leases, gives up partitions beyond its
+// share and takes free ones up to it.
+func (w *Worker) runPartitions() {
+	defer w.done.Done()
+	ticker := time.NewTicker(w.leaseTTL / 3)
+	defer ticker.Stop()
+
+	w.rebalance()
+	for {
+		select {
+		case <-w.shutdownChan:
+			var partitions []int
+			for partition := range w.owned {
+				partitions = append(partitions, partition)
+			}
+			w.release(partitions...)
+			w.queue.client.ZRem(w.queue.ctx, workersKey, w.consumer)
+			w.logger.Info("Worker shutting down")
+			return
+		case <-ticker.C:
+			w.rebalance()
+		}
+	}
+}
+
+// rebalance renews this worker's leases and moves it toward its fair share of the
+// partitions: the partition count divided by the live workers, rounded up
+func (w *Worker) rebalance() {
+	// The leases are renewed even without a heartbeat, so a worker that can't record
+	// one doesn't lose partitions it is still reading
+	workers, heartbeatErr := w.heartbeat()
+	if heartbeatErr != nil {
+		w.logger.Error("Failed to record worker heartbeat", zap.Error(heartbeatErr))
+	}
+
+	var lost []int
+	for partition, reader := range w.owned {
+		renewedAt := time.Now()
+		held, err := w.renew(partition)
+		if err != nil {
+			if time.Since(reader.renewed) < w.leaseTTL {
+				// Keep reading; if the lease has really gone, the next renewal says so
+				w.logger.Error("Failed to renew partition lease",
+					zap.Int("partition", partition),
+					zap.Error(err))
+				continue
+			}
+			// The lease has expired by now, so another worker may be reading it
+			w.logger.Error("Partition lease expired without renewal",
+				zap.Int("partition", partition),
+				zap.Error(err))
+			lost = append(lost, partition)
+			continue
+		}
+		if !held {
+			w.logger.Warn("Lost partition lease", zap.Int("partition", partition))
+			lost = append(lost, partition)
+			continue
+		}
+		reader.renewed = renewedAt
+	}
+	// The leases are gone, so there is nothing to release; just stop reading
+	w.stopReading(lost...)
+
+	// Without a heartbeat there is no worker count to take a share of
+	if heartbeatErr != nil {
+		return
+	}
+	share := (w.queue.partitions + workers - 1) / workers
+
+	// Give up the highest partitions first, so workers settle on the same split
+	var extra []int
+	for partition := w.queue.partitions - 1; partition >= 0 && len(w.owned)-len(extra) > share; partition-- {
+		if _, ok := w.owned[partition]; ok {
+			extra = append(extra, partition)
+		}
+	}
+	w.release(extra...)
+
+	for partition := 0; partition < w.queue.partitions && len(w.owned) < share; partition++ {
+		if _, ok := w.owned[partition]; ok {
+			continue
+		}
+		acquiredAt := time.Now()
+		acquired, err := w.queue.client.SetNX(w.ctx, partitionLeaseKey(partition), w.consumer, w.leaseTTL).Result()
+		if err != nil {
+			w.logger.Error("Failed to acquire partition lease",
+				zap.Int("partition", partition),
+				zap.Error(err))
+			return
+		}
+		if acquired {
+			w.startReading(partition, acquiredAt)
+		}
+	}
+}
+
+// heartbeat marks this worker as alive and returns how many workers are
+func (w *Worker) heartbeat() (int, error) {
+	now := time.Now()
+	pipe := w.queue.client.TxPipeline()
+	pipe.ZAdd(w.ctx, workersKey, &redis.Z{Score: float64(now.UnixMilli()), Member: w.consumer})
+	pipe.ZRemRangeByScore(w.ctx, workersKey, "-inf", "("+strconv.FormatInt(now.Add(-w.leaseTTL).UnixMilli(), 10))
+	count := pipe.ZCard(w.ctx, workersKey)
+	if _, err := pipe.Exec(w.ctx); err != nil {
+		return 0, err
+	}
+	if count.Val() < 1 {
+		return 1, nil
+	}
+	return int(count.Val()), nil
+}
+
+// renew extends this worker's lease on a partition, reporting false if it has lost it
+func (w *Worker) renew(partition int) (bool, error) {
+	n, err := renewLeaseScript.Run(w.ctx, w.queue.client, []string{partitionLeaseKey(partition)}, w.consumer, w.leaseTTL.Milliseconds()).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to renew lease for partition %d: %w", partition, err)
+	}
+	return n == 1, nil
+}
+
+// startReading begins reading a partition this worker acquired at acquiredAt
+func (w *Worker) startReading(partition int, acquiredAt time.Time) {
+	ctx, cancel := context.WithCancel(w.ctx)
+	reader := &partitionReader{cancel: cancel, done: make(chan struct{}), renewed: acquiredAt}
+	w.owned[partition] = reader
+	w.logger.Info("Reading partition",
+		zap.Int("partition", partition),
+		zap.String("consumer", w.consumer))
+
+	go func() {
+		defer close(reader.done)
+		w.consume(ctx, partition)
+	}()
+}
+
+// stopReading stops reading the partitions and waits for their readers to finish
+func (w *Worker) stopReading(partitions ...int) {
+	// A reader can take a whole block to notice, so stop them all before waiting
+	readers := make([]*partitionReader, 0, len(partitions))
+	for _, partition := range partitions {
+		reader := w.owned[partition]
+		delete(w.owned, partition)
+		reader.cancel()
+		readers = append(readers, reader)
+	}
+	for _, reader := range readers {
+		<-reader.done
+	}
+}
+
+// release stops reading the partitions and gives up their leases. The leases are only
+// deleted once the readers have finished, so the next owners can't overtake them.
+func (w *Worker) release(partitions ...int) {
+	w.stopReading(partitions...)
+
+	for _, partition := range partitions {
+		if err := releaseLeaseScript.Run(w.queue.ctx, w.queue.client, []string{partitionLeaseKey(partition)}, w.consumer).Err(); err != nil {
+			w.logger.Error("Failed to release partition lease",
+				zap.Int("partition", partition),
+				zap.Error(err))
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
@@ -20,25 +21,71 @@ const (
 	GameStart         MessageType = "game_start"
 )
 
+// maxErrorHistory is how many failures a message remembers
+const maxErrorHistory = 10
+
 // QueueMessage represents a message in the queue
 type QueueMessage struct {
-	Type      MessageType            `json:"type"`
-	GameID    string                 `json:"gameId"`
-	PlayerID  string                 `json:"playerId,omitempty"`
-	Data      map[string]interface{} `json:"data"`
-	Timestamp time.Time              `json:"timestamp"`
-	Attempts  int                    `json:"attempts"`
+	ID             string                 `json:"id,omitempty"` // Set when first enqueued
+	Type           MessageType            `json:"type"`
+	GameID         string                 `json:"gameId"`
+	PlayerID       string                 `json:"playerId,omitempty"`
+	Data           map[string]interface{} `json:"data"`
+	Timestamp      time.Time              `json:"timestamp"`
+	Attempts       int                    `json:"attempts"`
+	LastError      string                 `json:"lastError,omitempty"`
+	Errors         []MessageError         `json:"errors,omitempty"`         // The latest failures, oldest first
+	DeadLetteredAt *time.Time             `json:"deadLetteredAt,omitempty"` // When the message was parked in the dead letter queue
+}
+
+// MessageError is one failed attempt at handling a message
+type MessageError struct {
+	Attempt int       `json:"attempt"`
+	Error   string    `json:"error"`
+	Time    time.Time `json:"time"`
+}
+
+// RecordError notes a failed attempt at handling the message. Only the last
+// maxErrorHistory failures are kept.
+func (m *QueueMessage) RecordError(err error) {
+	m.LastError = err.Error()
+	m.Errors = append(m.Errors, MessageError{Attempt: m.Attempts + 1, Error: m.LastError, Time: time.Now().UTC()})
+	if len(m.Errors) > maxErrorHistory {
+		m.Errors = m.Errors[len(m.Errors)-maxErrorHistory:]
+	}
+}
+
+// Options tunes the queue's streams. Every node sharing a Redis must use the same
+// partition count, or a game's messages would land in different streams.
+type Options struct {
+	Partitions int   // Streams the games are spread over; 16 if zero
+	MaxLen     int64 // Entries each stream keeps, roughly; 10000 if zero
 }
 
-// RedisQueue implements a Redis-based message queue
+// RedisQueue implements a message queue on Redis Streams. Each game's messages go to
+// one of a fixed set of partition streams, in order; see Worker for how they are read.
 type RedisQueue struct {
-	client *redis.Client
-	logger *zap.Logger
-	ctx    context.Context
+	client     *redis.Client
+	logger     *zap.Logger
+	ctx        context.Context
+	partitions int
+	maxLen     int64
 }
 
-// NewRedisQueue creates a new Redis queue
+// NewRedisQueue creates a new Redis queue with the default options
 func NewRedisQueue(redisAddr string, logger *zap.Logger) (*RedisQueue, error) {
+	return NewRedisQueueWithOptions(redisAddr, logger, Options{})
+}
+
+// NewRedisQueueWithOptions creates a new Redis queue
+func NewRedisQueueWithOptions(redisAddr string, logger *zap.Logger, opts Options) (*RedisQueue, error) {
+	if opts.Partitions <= 0 {
+		opts.Partitions = 16
+	}
+	if opts.MaxLen <= 0 {
+		opts.MaxLen = 10000
+	}
+
 	client := redis.NewClient(&redis.Options{
 		Addr:     redisAddr,
 		Password: "", // no password set
@@ -54,9 +101,11 @@ func NewRedisQueue(redisAddr string, logger *zap.Logger) (*RedisQueue, error) {
 	}
 
 	return &RedisQueue{
-		client: client,
-		logger: logger,
-		ctx:    ctx,
+		client:     client,
+		logger:     logger,
+		ctx:        ctx,
+		partitions: opts.Partitions,
+		maxLen:     opts.MaxLen,
 	}, nil
 }
 
@@ -76,7 +125,7 @@ func (q *RedisQueue) EnqueuePlayerTokenUpdate(gameID, playerID string, tokenData
 		Attempts:  0,
 	}
 
-	return q.enqueueMessage(fmt.Sprintf("game:%s:queue", gameID), msg)
+	return q.enqueueMessage(msg)
 }
 
 // EnqueueGameStateUpdate adds a game state update message to the queue
@@ -89,7 +138,7 @@ func (q *RedisQueue) EnqueueGameStateUpdate(gameID string, gameState map[string]
 		Attempts:  0,
 	}
 
-	return q.enqueueMessage(fmt.Sprintf("game:%s:queue", gameID), msg)
+	return q.enqueueMessage(msg)
 }
 
 // EnqueueGameStart adds a game start message to the queue
@@ -103,25 +152,34 @@ func (q *RedisQueue) EnqueueGameStart(gameID string, hostID string, data map[str
 		Attempts:  0,
 	}
 
-	return q.enqueueMessage(fmt.Sprintf("game:%s:queue", gameID), msg)
+	return q.enqueueMessage(msg)
 }
 
-// enqueueMessage adds a message to the specified queue
-func (q *RedisQueue) enqueueMessage(queueName string, msg QueueMessage) error {
+// enqueueMessage appends a message to its game's stream
+func (q *RedisQueue) enqueueMessage(msg QueueMessage) error {
+	if msg.ID == "" {
+		msg.ID = uuid.New().String()
+	}
+
 	// Serialize the message to JSON
 	msgJSON, err := json.Marshal(msg)
 	if err != nil {
 		return fmt.Errorf("failed to marshal message: %w", err)
 	}
 
-	// Add the message to the queue (using a list in Redis)
-	err = q.client.RPush(q.ctx, queueName, msgJSON).Err()
+	stream := streamKey(q.partition(msg.GameID))
+	err = q.client.XAdd(q.ctx, &redis.XAddArgs{
+		Stream: stream,
+		MaxLen: q.maxLen,
+		Approx: true,
+		Values: map[string]interface{}{messageField: msgJSON},
+	}).Err()
 	if err != nil {
-		return fmt.Errorf("failed to push message to queue: %w", err)
+		return fmt.Errorf("failed to add message to stream: %w", err)
 	}
 
 	q.logger.Info("Message enqueued",
-		zap.String("queue", queueName),
+		zap.String("stream", stream),
 		zap.String("type", string(msg.Type)),
 		zap.String("gameId", msg.GameID),
 		zap.String("playerId", msg.PlayerID))
@@ -129,61 +187,15 @@ func (q *RedisQueue) enqueueMessage(queueName string, msg QueueMessage) error {
 	return nil
 }
 
-// DequeueMessage retrieves and removes a message from the specified queue
-func (q *RedisQueue) DequeueMessage(queueName string) (*QueueMessage, error) {
-	// Get the message from the queue (using LPOP for non-blocking pop)
-	// This is safer than BLPOP which can block indefinitely
-	result, err := q.client.LPop(q.ctx, queueName).Result()
-	if err != nil {
-		if err == redis.Nil {
-			// Queue is empty
-			return nil, fmt.Errorf("queue is empty")
-		}
-		return nil, fmt.Errorf("failed to pop message from queue: %w", err)
-	}
-
-	// Deserialize the message from JSON
-	var msg QueueMessage
-	err = json.Unmarshal([]byte(result), &msg)
-	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal message: %w", err)
-	}
-
-	q.logger.Info("Message dequeued",
-		zap.String("queue", queueName),
-		zap.String("type", string(msg.Type)),
-		zap.String("gameId", msg.GameID),
-		zap.String("playerId", msg.PlayerID))
-
-	return &msg, nil
-}
-
-// PeekMessage retrieves but does not remove a message from the specified queue
-func (q *RedisQueue) PeekMessage(queueName string) (*QueueMessage, error) {
-	// Get the message from the queue without removing it
-	result, err := q.client.LRange(q.ctx, queueName, 0, 0).Result()
-	if err != nil {
-		return nil, fmt.Errorf("failed to peek message from queue: %w", err)
-	}
-
-	if len(result) == 0 {
-		return nil, nil // No messages in the queue
-	}
-
-	// Deserialize the message from JSON
-	var msg QueueMessage
-	err = json.Unmarshal([]byte(result[0]), &msg)
-	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal message: %w", err)
-	}
-
-	return &msg, nil
-}
-
 // MoveToDeadLetterQueue moves a failed message to a dead letter queue
 func (q *RedisQueue) MoveToDeadLetterQueue(queueName string, msg *QueueMessage) error {
 	// Increment the attempts counter
 	msg.Attempts++
+	if msg.ID == "" {
+		msg.ID = uuid.New().String()
+	}
+	now := time.Now().UTC()
+	msg.DeadLetteredAt = &now
 
 	// Serialize the message to JSON
 	msgJSON, err := json.Marshal(msg)
@@ -209,65 +221,6 @@ func (q *RedisQueue) MoveToDeadLetterQueue(queueName string, msg *QueueMessage)
 	return nil
 }
 
-// RetryMessage puts a message back into the queue for retry
-func (q *RedisQueue) RetryMessage(queueName string, msg *QueueMessage) error {
-	// Increment the attempts counter
-	msg.Attempts++
-
-	// Serialize the message to JSON
-	msgJSON, err := json.Marshal(msg)
-	if err != nil {
-		return fmt.Errorf("failed to marshal message: %w", err)
-	}
-
-	// Add the message back to the queue
-	err = q.client.RPush(q.ctx, queueName, msgJSON).Err()
-	if err != nil {
-		return fmt.Errorf("failed to push message to queue for retry: %w", err)
-	}
-
-	q.logger.Info("Message requeued for retry",
-		zap.String("queue", queueName),
-		zap.String("type", string(msg.Type)),
-		zap.String("gameId", msg.GameID),
-		zap.String("playerId", msg.PlayerID),
-		zap.Int("attempts", msg.Attempts))
-
-	return nil
-}
-
-// GetQueueLength returns the number of messages in the specified queue
-func (q *RedisQueue) GetQueueLength(queueName string) (int64, error) {
-	return q.client.LLen(q.ctx, queueName).Result()
-}
-
-// ClearQueue removes all messages from the specified queue
-func (q *RedisQueue) ClearQueue(queueName string) error {
-	return q.client.Del(q.ctx, queueName).Err()
-}
-
-// ClearAllQueues removes all game queues from Redis
-func (q *RedisQueue) ClearAllQueues() (int64, error) {
-	// Get all keys matching the pattern "game:*:queue"
-	keys, err := q.client.Keys(q.ctx, "game:*:queue").Result()
-	if err != nil {
-		return 0, fmt.Errorf("failed to get queue keys: %w", err)
-	}
-
-	if len(keys) == 0 {
-		return 0, nil // No queues found
-	}
-
-	// Delete all queues
-	count, err := q.client.Del(q.ctx, keys...).Result()
-	if err != nil {
-		return 0, fmt.Errorf("failed to delete queues: %w", err)
-	}
-
-	q.logger.Info("Cleared all game queues", zap.Int64("count", count))
-	return count, nil
-}
-
 // ClearDeadLetterQueues removes all dead letter queues from Redis
 func (q *RedisQueue) ClearDeadLetterQueues() (int64, error) {
 	// Get all keys matching the pattern "game:*:queue:dead"
@@ -0,0 +1,58 @@
+package queue
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newTestQueue(t *testing.T) (*RedisQueue, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	q, err := NewRedisQueue(mr.Addr(), zap.NewNop())
+	require.NoError(t, err)
+	t.Cleanup(func() { q.Close() })
+	return q, mr
+}
+
+// streamMessages returns the messages on a game's stream, oldest first
+func streamMessages(t *testing.T, q *RedisQueue, gameID string) []QueueMessage {
+	t.Helper()
+	entries, err := q.client.XRange(q.ctx, streamKey(q.partition(gameID)), "-", "+").Result()
+	require.NoError(t, err)
+	var messages []QueueMessage
+	for _, entry := range entries {
+		msg, err := decodeEntry(entry)
+		require.NoError(t, err)
+		if msg.GameID == gameID {
+			messages = append(messages, *msg)
+		}
+	}
+	return messages
+}
+
+func TestMigrateListQueues(t *testing.T) {
+	q, mr := newTestQueue(t)
+	for _, playerID := range []string{"p1", "p2"} {
+		raw, err := json.Marshal(QueueMessage{Type: PlayerTokenUpdate, GameID: "g1", PlayerID: playerID})
+		require.NoError(t, err)
+		_, err = mr.RPush("game:g1:queue", string(raw))
+		require.NoError(t, err)
+	}
+	_, err := mr.RPush("game:g1:queue", "not json")
+	require.NoError(t, err)
+
+	moved, err := q.MigrateListQueues()
+	require.NoError(t, err)
+	assert.Equal(t, 2, moved)
+	assert.False(t, mr.Exists("game:g1:queue"))
+
+	messages := streamMessages(t, q, "g1")
+	require.Len(t, messages, 2)
+	assert.Equal(t, "p1", messages[0].PlayerID)
+	assert.Equal(t, "p2", messages[1].PlayerID)
+}
@@ -0,0 +1,142 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+const (
+	// consumerGroup is the group every worker reads the streams in
+	consumerGroup = "kekopoly-workers"
+	// messageField holds a stream entry's JSON-encoded QueueMessage
+	messageField = "message"
+	// workersKey is a sorted set of live workers, scored by their last heartbeat in milliseconds
+	workersKey = "kekopoly:queue:workers"
+)
+
+// streamKey returns the name of a partition's stream
+func streamKey(partition int) string {
+	return fmt.Sprintf("kekopoly:queue:%d", partition)
+}
+
+// partitionLeaseKey holds the name of the worker reading a partition
+func partitionLeaseKey(partition int) string {
+	return fmt.Sprintf("kekopoly:queue:%d:lease", partition)
+}
+
+// partition returns the partition a game's messages go to. All of a game's messages
+// share a partition, so they are handled in the order they were sent.
+func (q *RedisQueue) partition(gameID string) int {
+	h := fnv.New32a()
+	h.Write([]byte(strings.ToLower(gameID)))
+	return int(h.Sum32() % uint32(q.partitions))
+}
+
+// ensureGroups creates the consumer group on every partition's stream
+func (q *RedisQueue) ensureGroups(ctx context.Context) error {
+	for p := 0; p < q.partitions; p++ {
+		err := q.client.XGroupCreateMkStream(ctx, streamKey(p), consumerGroup, "0").Err()
+		if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+			return fmt.Errorf("failed to create consumer group on %s: %w", streamKey(p), err)
+		}
+	}
+	return nil
+}
+
+// decodeEntry reads the message out of a stream entry
+func decodeEntry(entry redis.XMessage) (*QueueMessage, error) {
+	raw, ok := entry.Values[messageField].(string)
+	if !ok {
+		return nil, fmt.Errorf("stream entry %s has no message", entry.ID)
+	}
+	var msg QueueMessage
+	if err := json.Unmarshal([]byte(raw), &msg); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal message: %w", err)
+	}
+	return &msg, nil
+}
+
+// StreamInfo describes one partition's stream
+type StreamInfo struct {
+	Partition int    `json:"partition"`
+	Stream    string `json:"stream"`
+	Length    int64  `json:"length"`          // Entries kept, handled or not
+	Pending   int64  `json:"pending"`         // Entries read but not yet acknowledged
+	Owner     string `json:"owner,omitempty"` // The worker reading the partition
+}
+
+// Streams describes every partition's stream and the worker reading it
+func (q *RedisQueue) Streams() ([]StreamInfo, error) {
+	infos := make([]StreamInfo, 0, q.partitions)
+	for p := 0; p < q.partitions; p++ {
+		info := StreamInfo{Partition: p, Stream: streamKey(p)}
+
+		pipe := q.client.Pipeline()
+		length := pipe.XLen(q.ctx, info.Stream)
+		pending := pipe.XPending(q.ctx, info.Stream, consumerGroup)
+		owner := pipe.Get(q.ctx, partitionLeaseKey(p))
+		if _, err := pipe.Exec(q.ctx); err != nil && err != redis.Nil && !strings.HasPrefix(err.Error(), "NOGROUP") {
+			return nil, fmt.Errorf("failed to read stream %s: %w", info.Stream, err)
+		}
+
+		// A stream nobody has written to or read yet shows up as empty
+		info.Length = length.Val()
+		if pending.Val() != nil {
+			info.Pending = pending.Val().Count
+		}
+		info.Owner = owner.Val()
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// MigrateListQueues moves messages left in the per-game lists used before streams,
+// game:<gameId>:queue, onto the streams and deletes the lists. It returns how many
+// messages were moved.
+func (q *RedisQueue) MigrateListQueues() (int, error) {
+	keys, err := q.client.Keys(q.ctx, "game:*:queue").Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get queue keys: %w", err)
+	}
+
+	moved := 0
+	for _, key := range keys {
+		for {
+			result, err := q.client.LPop(q.ctx, key).Result()
+			if err == redis.Nil {
+				break
+			}
+			if err != nil {
+				return moved, fmt.Errorf("failed to pop message from %s: %w", key, err)
+			}
+			var msg QueueMessage
+			if err := json.Unmarshal([]byte(result), &msg); err != nil {
+				q.logger.Warn("Dropping undecodable message from old queue",
+					zap.String("queue", key),
+					zap.Error(err))
+				continue
+			}
+			if err := q.enqueueMessage(msg); err != nil {
+				// Put it back for the next attempt
+				if pushErr := q.client.LPush(q.ctx, key, result).Err(); pushErr != nil {
+					q.logger.Error("Failed to put message back on old queue",
+						zap.String("queue", key),
+						zap.Error(pushErr))
+				}
+				return moved, err
+			}
+			moved++
+		}
+	}
+
+	if moved > 0 {
+		q.logger.Info("Moved messages from old list queues to streams", zap.Int("count", moved))
+	}
+	return moved, nil
+}
@@ -4,8 +4,11 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
 	"github.com/kekopoly/backend/internal/game/manager"
 	"github.com/kekopoly/backend/internal/game/models"
 	"go.uber.org/zap"
@@ -14,7 +17,16 @@ import (
 // MessageHandler is a function that processes a queue message
 type MessageHandler func(msg *QueueMessage) error
 
-// Worker processes messages from a Redis queue
+// WorkerOptions tunes how a worker reads the streams
+type WorkerOptions struct {
+	Consumer string        // This worker's name in the consumer group; random if empty
+	Block    time.Duration // How long a read waits for new messages; 5 seconds if zero
+	LeaseTTL time.Duration // How long a dead worker keeps its partitions; 15 seconds if zero
+}
+
+// Worker handles the messages on the queue's streams. The partitions are shared out
+// between the running workers through leases in Redis, so each partition, and each
+// game's messages, are handled by one worker at a time and in order.
 type Worker struct {
 	queue        *RedisQueue
 	gameManager  *manager.GameManager
@@ -24,11 +36,31 @@ type Worker struct {
 	shutdownChan chan struct{}
 	ctx          context.Context
 	cancel       context.CancelFunc
+
+	consumer string
+	block    time.Duration
+	leaseTTL time.Duration
+	owned    map[int]*partitionReader // Partitions this worker holds the lease for
+	done     sync.WaitGroup
 }
 
-// NewWorker creates a new queue worker
+// NewWorker creates a new queue worker with the default options
 func NewWorker(queue *RedisQueue, gameManager *manager.GameManager, logger *zap.Logger) *Worker {
+	return NewWorkerWithOptions(queue, gameManager, logger, WorkerOptions{})
+}
+
+// NewWorkerWithOptions creates a new queue worker
+func NewWorkerWithOptions(queue *RedisQueue, gameManager *manager.GameManager, logger *zap.Logger, opts WorkerOptions) *Worker {
 	ctx, cancel := context.WithCancel(context.Background())
+	if opts.Consumer == "" {
+		opts.Consumer = uuid.New().String()
+	}
+	if opts.Block <= 0 {
+		opts.Block = 5 * time.Second
+	}
+	if opts.LeaseTTL <= 0 {
+		opts.LeaseTTL = 15 * time.Second
+	}
 
 	worker := &Worker{
 		queue:        queue,
@@ -39,6 +71,10 @@ func NewWorker(queue *RedisQueue, gameManager *manager.GameManager, logger *zap.
 		shutdownChan: make(chan struct{}),
 		ctx:          ctx,
 		cancel:       cancel,
+		consumer:     opts.Consumer,
+		block:        opts.Block,
+		leaseTTL:     opts.LeaseTTL,
+		owned:        make(map[int]*partitionReader),
 	}
 
 	// Register default handlers
@@ -244,214 +280,254 @@ func (w *Worker) RegisterHandler(msgType MessageType, handler MessageHandler) {
 	w.handlers[msgType] = handler
 }
 
-// Start begins processing messages from the queue
-func (w *Worker) Start() {
-	go w.processMessages()
-	go w.runPeriodicCleanup()
+// Start creates the consumer group if need be and begins handling messages
+func (w *Worker) Start() error {
+	if err := w.queue.ensureGroups(w.ctx); err != nil {
+		return err
+	}
+	w.done.Add(1)
+	go w.runPartitions()
+	return nil
 }
 
-// Stop stops the worker
+// Stop stops the worker. Messages being handled are finished, and the worker's
+// partitions are released so other workers can take them over at once.
 func (w *Worker) Stop() {
 	w.cancel()
 	close(w.shutdownChan)
+	w.done.Wait()
 }
 
-// runPeriodicCleanup periodically cleans up stale queues
-func (w *Worker) runPeriodicCleanup() {
-	ticker := time.NewTicker(30 * time.Minute) // Run cleanup every 30 minutes
-	defer ticker.Stop()
+// consume reads a partition's stream until ctx is cancelled. Messages a previous reader
+// took but never acknowledged are handled first, then new ones as they arrive.
+func (w *Worker) consume(ctx context.Context, partition int) {
+	stream := streamKey(partition)
+	w.reclaim(ctx, stream)
+
+	for ctx.Err() == nil {
+		streams, err := w.queue.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    consumerGroup,
+			Consumer: w.consumer,
+			Streams:  []string{stream, ">"},
+			Count:    10,
+			Block:    w.block,
+		}).Result()
+		if err == redis.Nil {
+			continue // Nothing arrived while blocked
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			w.logger.Error("Failed to read stream",
+				zap.String("stream", stream),
+				zap.Error(err))
+			if strings.HasPrefix(err.Error(), "NOGROUP") {
+				// The stream was deleted from under us
+				if err := w.queue.ensureGroups(ctx); err != nil {
+					w.logger.Error("Failed to recreate consumer group", zap.Error(err))
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Second):
+			}
+			continue
+		}
 
-	for {
-		select {
-		case <-w.shutdownChan:
-			w.logger.Info("Cleanup task shutting down")
-			return
-		case <-ticker.C:
-			w.logger.Info("Running periodic queue cleanup")
-			w.CleanupStaleQueues()
+		for _, read := range streams {
+			for _, entry := range read.Messages {
+				w.handleEntry(ctx, stream, entry)
+			}
 		}
 	}
 }
 
-// CleanupStaleQueues cleans up queues for games that no longer exist
-func (w *Worker) CleanupStaleQueues() {
-	// Get all keys matching the pattern "game:*:queue"
-	keys, err := w.queue.client.Keys(w.ctx, "game:*:queue").Result()
-	if err != nil {
-		w.logger.Error("Failed to get queue keys for cleanup", zap.Error(err))
-		return
-	}
-
-	if len(keys) == 0 {
-		return // No queues found
-	}
-
-	w.logger.Info("Checking queues for stale games", zap.Int("queueCount", len(keys)))
-
-	staleQueuesCount := 0
-	// Check each queue
-	for _, queueName := range keys {
-		// Extract game ID from queue name
-		parts := strings.Split(queueName, ":")
-		if len(parts) != 3 {
-			continue
+// reclaim takes over the messages left unacknowledged in a stream, by a worker that
+// crashed or by this one before a restart, and handles them in order. A message that
+// has been delivered maxDeliveries times without being acknowledged probably crashes
+// its worker, so it is dead-lettered instead.
+func (w *Worker) reclaim(ctx context.Context, stream string) {
+	seen := make(map[string]bool)
+	for ctx.Err() == nil {
+		pending, err := w.queue.client.XPendingExt(ctx, &redis.XPendingExtArgs{
+			Stream: stream,
+			Group:  consumerGroup,
+			Start:  "-",
+			End:    "+",
+			Count:  100,
+		}).Result()
+		if err != nil {
+			w.logger.Error("Failed to list pending messages",
+				zap.String("stream", stream),
+				zap.Error(err))
+			return
 		}
-		gameID := parts[1]
 
-		// Check if the game exists
-		if !w.gameExists(gameID) {
-			staleQueuesCount++
-			w.logger.Info("Found stale queue for non-existent game",
-				zap.String("queue", queueName),
-				zap.String("gameId", gameID))
-
-			// Move all messages to dead letter queue
-			w.moveAllMessagesToDeadLetterQueue(queueName, gameID)
+		var ids []string
+		deliveries := make(map[string]int64)
+		for _, entry := range pending {
+			if !seen[entry.ID] {
+				ids = append(ids, entry.ID)
+				deliveries[entry.ID] = entry.RetryCount
+				seen[entry.ID] = true
+			}
+		}
+		if len(ids) == 0 {
+			return // Everything left has been tried already
 		}
-	}
-
-	w.logger.Info("Stale queue cleanup complete",
-		zap.Int("totalQueues", len(keys)),
-		zap.Int("staleQueues", staleQueuesCount))
-}
 
-// processMessages continuously processes messages from the queue
-func (w *Worker) processMessages() {
-	for {
-		select {
-		case <-w.shutdownChan:
-			w.logger.Info("Worker shutting down")
+		entries, err := w.queue.client.XClaim(ctx, &redis.XClaimArgs{
+			Stream:   stream,
+			Group:    consumerGroup,
+			Consumer: w.consumer,
+			Messages: ids,
+		}).Result()
+		if err != nil {
+			w.logger.Error("Failed to claim pending messages",
+				zap.String("stream", stream),
+				zap.Error(err))
 			return
-		default:
-			// Process messages for each active game and games with pending operations
-			games, err := w.gameManager.GetActiveGames()
-			if err != nil {
-				// For errors, log and wait longer
-				w.logger.Error("Failed to get active games", zap.Error(err))
-				time.Sleep(5 * time.Second)
-				continue
+		}
+		w.logger.Info("Reclaimed pending messages",
+			zap.String("stream", stream),
+			zap.Int("count", len(entries)))
+
+		// Entries trimmed from the stream come back empty; there is nothing left to handle
+		claimed := make(map[string]bool, len(entries))
+		for _, entry := range entries {
+			claimed[entry.ID] = entry.Values != nil
+		}
+		for _, id := range ids {
+			if !claimed[id] {
+				w.ack(stream, id)
 			}
+		}
 
-			// If no games were found, we'll still check for direct queue access
-			if len(games) == 0 {
-				w.logger.Debug("No active or lobby games found, checking for direct queue access")
-				// We'll check for any game start messages in Redis directly
-				w.processDirectQueueMessages()
-				time.Sleep(1 * time.Second)
+		for _, entry := range entries {
+			if entry.Values == nil {
+				continue
+			}
+			if deliveries[entry.ID] >= maxDeliveries {
+				w.deadLetterEntry(stream, entry, deliveries[entry.ID])
 				continue
 			}
+			w.handleEntry(ctx, stream, entry)
+		}
+	}
+}
 
-			for _, game := range games {
-				queueName := fmt.Sprintf("game:%s:queue", game.ID.Hex())
+// maxDeliveries is how many times a message is read without being acknowledged before
+// it is dead-lettered
+const maxDeliveries = 5
 
-				// Check if there are messages in the queue
-				length, err := w.queue.GetQueueLength(queueName)
-				if err != nil {
-					w.logger.Error("Failed to get queue length",
-						zap.String("queue", queueName),
-						zap.Error(err))
-					continue
-				}
+// handleEntry handles one stream entry and acknowledges it. A failing message is
+// retried in place, so the messages behind it wait and stay in order, and is
+// dead-lettered once it runs out of attempts. If ctx is cancelled while it waits to
+// retry, the entry is left unacknowledged for the partition's next reader.
+func (w *Worker) handleEntry(ctx context.Context, stream string, entry redis.XMessage) {
+	msg, err := decodeEntry(entry)
+	if err != nil {
+		// Nothing to retry or dead-letter without a game
+		w.logger.Error("Dropping undecodable stream entry",
+			zap.String("stream", stream),
+			zap.String("entryId", entry.ID),
+			zap.Error(err))
+		w.ack(stream, entry.ID)
+		return
+	}
+	queueName := fmt.Sprintf("game:%s:queue", msg.GameID)
 
-				if length == 0 {
-					continue // No messages in this game's queue
-				}
+	for {
+		err := w.processMessage(queueName, msg)
+		if err == nil {
+			break
+		}
 
-				// Log at debug level to avoid console spam
-				w.logger.Debug("Processing messages for game",
-					zap.String("gameId", game.ID.Hex()),
-					zap.Int64("messageCount", length))
-
-				// Process all messages in the queue
-				for i := int64(0); i < length; i++ {
-					// Check for shutdown signal
-					select {
-					case <-w.shutdownChan:
-						w.logger.Info("Worker shutting down during message processing")
-						return
-					default:
-						// Continue processing
-					}
-
-					// Dequeue a message
-					msg, err := w.queue.DequeueMessage(queueName)
-					if err != nil {
-						// If the queue is empty, just continue to the next queue
-						if err.Error() == "queue is empty" {
-							w.logger.Debug("Queue is empty, continuing",
-								zap.String("queue", queueName))
-							break
-						}
-
-						w.logger.Error("Failed to dequeue message",
-							zap.String("queue", queueName),
-							zap.Error(err))
-						break
-					}
-
-					// Process the message
-					err = w.processMessage(queueName, msg)
-					if err != nil {
-						w.logger.Error("Failed to process message",
-							zap.String("queue", queueName),
-							zap.String("type", string(msg.Type)),
-							zap.String("gameId", msg.GameID),
-							zap.String("playerId", msg.PlayerID),
-							zap.Error(err))
-
-						// Check if the error is due to game not found
-						if strings.Contains(err.Error(), "game not found") ||
-							strings.Contains(err.Error(), "failed to get game") {
-							w.logger.Warn("Game not found, moving message to dead letter queue",
-								zap.String("queue", queueName),
-								zap.String("type", string(msg.Type)),
-								zap.String("gameId", msg.GameID))
-
-							err = w.queue.MoveToDeadLetterQueue(queueName, msg)
-							if err != nil {
-								w.logger.Error("Failed to move message to dead letter queue",
-									zap.String("queue", queueName),
-									zap.Error(err))
-							}
-						} else if msg.Attempts < w.maxAttempts {
-							// For other errors, retry if under max attempts
-							w.logger.Info("Retrying message",
-								zap.String("queue", queueName),
-								zap.String("type", string(msg.Type)),
-								zap.Int("attempt", msg.Attempts+1),
-								zap.Int("maxAttempts", w.maxAttempts))
-
-							// Wait a bit before retrying
-							time.Sleep(time.Duration(msg.Attempts+1) * time.Second)
-
-							err = w.queue.RetryMessage(queueName, msg)
-							if err != nil {
-								w.logger.Error("Failed to requeue message",
-									zap.String("queue", queueName),
-									zap.Error(err))
-							}
-						} else {
-							w.logger.Warn("Moving message to dead letter queue after max attempts",
-								zap.String("queue", queueName),
-								zap.String("type", string(msg.Type)),
-								zap.Int("attempts", msg.Attempts),
-								zap.Int("maxAttempts", w.maxAttempts))
-
-							err = w.queue.MoveToDeadLetterQueue(queueName, msg)
-							if err != nil {
-								w.logger.Error("Failed to move message to dead letter queue",
-									zap.String("queue", queueName),
-									zap.Error(err))
-							}
-						}
-					}
-				}
+		// A message for a game that is gone will never succeed
+		if strings.Contains(err.Error(), "game not found") ||
+			strings.Contains(err.Error(), "failed to get game") {
+			w.logger.Warn("Game not found, moving message to dead letter queue",
+				zap.String("queue", queueName),
+				zap.String("type", string(msg.Type)),
+				zap.String("gameId", msg.GameID))
+			if !w.deadLetter(queueName, msg) {
+				return
 			}
+			break
+		}
+		if msg.Attempts >= w.maxAttempts {
+			w.logger.Warn("Moving message to dead letter queue after max attempts",
+				zap.String("queue", queueName),
+				zap.String("type", string(msg.Type)),
+				zap.Int("attempts", msg.Attempts),
+				zap.Int("maxAttempts", w.maxAttempts))
+			if !w.deadLetter(queueName, msg) {
+				return
+			}
+			break
+		}
 
-			// Sleep a bit to avoid hammering Redis
-			time.Sleep(100 * time.Millisecond)
+		msg.Attempts++
+		w.logger.Info("Retrying message",
+			zap.String("queue", queueName),
+			zap.String("type", string(msg.Type)),
+			zap.Int("attempt", msg.Attempts),
+			zap.Int("maxAttempts", w.maxAttempts))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Duration(msg.Attempts) * time.Second):
 		}
 	}
+
+	w.ack(stream, entry.ID)
+}
+
+// deadLetterEntry dead-letters a message that keeps getting delivered without being acknowledged
+func (w *Worker) deadLetterEntry(stream string, entry redis.XMessage, deliveries int64) {
+	msg, err := decodeEntry(entry)
+	if err != nil {
+		w.logger.Error("Dropping undecodable stream entry",
+			zap.String("stream", stream),
+			zap.String("entryId", entry.ID),
+			zap.Error(err))
+		w.ack(stream, entry.ID)
+		return
+	}
+	queueName := fmt.Sprintf("game:%s:queue", msg.GameID)
+	msg.RecordError(fmt.Errorf("delivered %d times without being acknowledged", deliveries))
+	w.logger.Warn("Moving message that keeps failing its worker to dead letter queue",
+		zap.String("queue", queueName),
+		zap.String("type", string(msg.Type)),
+		zap.Int64("deliveries", deliveries))
+	if w.deadLetter(queueName, msg) {
+		w.ack(stream, entry.ID)
+	}
+}
+
+// deadLetter parks a message, reporting whether it worked. A message that couldn't be
+// parked is left unacknowledged rather than lost.
+func (w *Worker) deadLetter(queueName string, msg *QueueMessage) bool {
+	if err := w.queue.MoveToDeadLetterQueue(queueName, msg); err != nil {
+		w.logger.Error("Failed to move message to dead letter queue",
+			zap.String("queue", queueName),
+			zap.Error(err))
+		return false
+	}
+	return true
+}
+
+// ack acknowledges a stream entry. It uses the queue's context, so a message handled
+// while the worker stops is still acknowledged.
+func (w *Worker) ack(stream, id string) {
+	if err := w.queue.client.XAck(w.queue.ctx, stream, consumerGroup, id).Err(); err != nil {
+		w.logger.Error("Failed to acknowledge message",
+			zap.String("stream", stream),
+			zap.String("entryId", id),
+			zap.Error(err))
+	}
 }
 
 // processMessage processes a single message from the queue
@@ -470,7 +546,9 @@ func (w *Worker) processMessage(queueName string, msg *QueueMessage) error {
 		w.logger.Error("No handler registered for message type",
 			zap.String("type", string(msg.Type)),
 			zap.String("gameId", msg.GameID))
-		return fmt.Errorf("no handler registered for message type: %s", msg.Type)
+		err := fmt.Errorf("no handler registered for message type: %s", msg.Type)
+		msg.RecordError(err)
+		return err
 	}
 
 	// Call the handler
@@ -481,6 +559,8 @@ func (w *Worker) processMessage(queueName string, msg *QueueMessage) error {
 			zap.String("type", string(msg.Type)),
 			zap.String("gameId", msg.GameID),
 			zap.Error(err))
+		// Kept on the message so a retry or dead letter shows why it failed
+		msg.RecordError(err)
 		return err
 	}
 
@@ -491,254 +571,7 @@ func (w *Worker) processMessage(queueName string, msg *QueueMessage) error {
 	return nil
 }
 
-// gameExists checks if a game exists in the database
-func (w *Worker) gameExists(gameID string) bool {
-	// Try to get the game from the database
-	_, err := w.gameManager.GetGame(gameID)
-	return err == nil
-}
-
-// processDirectQueueMessages checks for game start messages directly in Redis
-// This is a fallback mechanism when no active games are found in memory
-func (w *Worker) processDirectQueueMessages() {
-	// Get all keys matching the pattern "game:*:queue"
-	keys, err := w.queue.client.Keys(w.ctx, "game:*:queue").Result()
-	if err != nil {
-		w.logger.Error("Failed to get queue keys", zap.Error(err))
-		return
-	}
-
-	if len(keys) == 0 {
-		return // No queues found
-	}
-
-	w.logger.Info("Found queue keys for direct processing", zap.Int("count", len(keys)))
-
-	// Process each queue
-	for _, queueName := range keys {
-		// Extract game ID from queue name
-		parts := strings.Split(queueName, ":")
-		if len(parts) != 3 {
-			w.logger.Warn("Invalid queue name format", zap.String("queue", queueName))
-			continue
-		}
-		gameID := parts[1]
-
-		// Check if the game exists before processing its messages
-		if !w.gameExists(gameID) {
-			w.logger.Warn("Game no longer exists, moving all messages to dead letter queue",
-				zap.String("queue", queueName),
-				zap.String("gameId", gameID))
-
-			// Move all messages to dead letter queue
-			w.moveAllMessagesToDeadLetterQueue(queueName, gameID)
-			continue
-		}
-
-		// Ensure the game is loaded into memory by explicitly getting it
-		// This is critical for games in LOBBY status that might not be in the activeGames map
-		game, err := w.gameManager.GetGame(gameID)
-		if err != nil {
-			w.logger.Error("Failed to load game into memory for queue processing",
-				zap.String("gameId", gameID),
-				zap.Error(err))
-			continue
-		}
-
-		// Log the game status to help with debugging
-		w.logger.Info("Processing queue for game",
-			zap.String("gameId", gameID),
-			zap.String("status", string(game.Status)),
-			zap.String("queue", queueName))
-
-		// Check if there are messages in the queue
-		length, err := w.queue.GetQueueLength(queueName)
-		if err != nil {
-			w.logger.Error("Failed to get queue length",
-				zap.String("queue", queueName),
-				zap.Error(err))
-			continue
-		}
-
-		if length == 0 {
-			continue // No messages in this queue
-		}
-
-		w.logger.Info("Processing messages directly from queue",
-			zap.String("queue", queueName),
-			zap.String("gameId", gameID),
-			zap.Int64("messageCount", length))
-
-		// Process all messages in the queue
-		for i := int64(0); i < length; i++ {
-			// Dequeue a message
-			msg, err := w.queue.DequeueMessage(queueName)
-			if err != nil {
-				// If the queue is empty, just continue to the next queue
-				if err.Error() == "queue is empty" {
-					w.logger.Debug("Queue is empty, continuing",
-						zap.String("queue", queueName))
-					break
-				}
-
-				w.logger.Error("Failed to dequeue message",
-					zap.String("queue", queueName),
-					zap.Error(err))
-				break
-			}
-
-			// Process the message
-			err = w.processMessage(queueName, msg)
-			if err != nil {
-				w.logger.Error("Failed to process message directly",
-					zap.String("queue", queueName),
-					zap.String("type", string(msg.Type)),
-					zap.String("gameId", msg.GameID),
-					zap.Error(err))
-
-				// Check if the error is due to game not found
-				if strings.Contains(err.Error(), "game not found") ||
-					strings.Contains(err.Error(), "failed to get game") {
-					w.logger.Warn("Game not found, moving message to dead letter queue",
-						zap.String("queue", queueName),
-						zap.String("type", string(msg.Type)),
-						zap.String("gameId", msg.GameID))
-
-					err = w.queue.MoveToDeadLetterQueue(queueName, msg)
-					if err != nil {
-						w.logger.Error("Failed to move message to dead letter queue",
-							zap.String("queue", queueName),
-							zap.Error(err))
-					}
-				} else if msg.Attempts < w.maxAttempts {
-					// For other errors, retry if under max attempts
-					w.logger.Info("Retrying message",
-						zap.String("queue", queueName),
-						zap.String("type", string(msg.Type)),
-						zap.Int("attempt", msg.Attempts+1),
-						zap.Int("maxAttempts", w.maxAttempts))
-
-					// Wait a bit before retrying
-					time.Sleep(time.Duration(msg.Attempts+1) * time.Second)
-
-					err = w.queue.RetryMessage(queueName, msg)
-					if err != nil {
-						w.logger.Error("Failed to requeue message",
-							zap.String("queue", queueName),
-							zap.Error(err))
-					}
-				} else {
-					w.logger.Warn("Moving message to dead letter queue after max attempts",
-						zap.String("queue", queueName),
-						zap.String("type", string(msg.Type)),
-						zap.Int("attempts", msg.Attempts),
-						zap.Int("maxAttempts", w.maxAttempts))
-
-					err = w.queue.MoveToDeadLetterQueue(queueName, msg)
-					if err != nil {
-						w.logger.Error("Failed to move message to dead letter queue",
-							zap.String("queue", queueName),
-							zap.Error(err))
-					}
-				}
-			}
-		}
-	}
-}
-
-// moveAllMessagesToDeadLetterQueue moves all messages from a queue to its dead letter queue
-func (w *Worker) moveAllMessagesToDeadLetterQueue(queueName, gameID string) {
-	// Get queue length
-	length, err := w.queue.GetQueueLength(queueName)
-	if err != nil {
-		w.logger.Error("Failed to get queue length for cleanup",
-			zap.String("queue", queueName),
-			zap.Error(err))
-		return
-	}
-
-	if length == 0 {
-		return // No messages to move
-	}
-
-	w.logger.Info("Moving all messages to dead letter queue",
-		zap.String("queue", queueName),
-		zap.String("gameId", gameID),
-		zap.Int64("messageCount", length))
-
-	// Move all messages to dead letter queue
-	for i := int64(0); i < length; i++ {
-		msg, err := w.queue.DequeueMessage(queueName)
-		if err != nil {
-			if err.Error() == "queue is empty" {
-				break // No more messages
-			}
-
-			w.logger.Error("Failed to dequeue message during cleanup",
-				zap.String("queue", queueName),
-				zap.Error(err))
-			continue
-		}
-
-		// Move to dead letter queue
-		err = w.queue.MoveToDeadLetterQueue(queueName, msg)
-		if err != nil {
-			w.logger.Error("Failed to move message to dead letter queue during cleanup",
-				zap.String("queue", queueName),
-				zap.Error(err))
-		}
-	}
-}
-
 // SetMaxAttempts sets the maximum number of retry attempts
 func (w *Worker) SetMaxAttempts(maxAttempts int) {
 	w.maxAttempts = maxAttempts
 }
-
-// ClearAllStaleQueues clears all queues for games that no longer exist
-func (w *Worker) ClearAllStaleQueues() (int, error) {
-	// Get all keys matching the pattern "game:*:queue"
-	keys, err := w.queue.client.Keys(w.ctx, "game:*:queue").Result()
-	if err != nil {
-		return 0, fmt.Errorf("failed to get queue keys: %w", err)
-	}
-
-	if len(keys) == 0 {
-		return 0, nil // No queues found
-	}
-
-	w.logger.Info("Checking for stale queues", zap.Int("totalQueues", len(keys)))
-
-	staleQueues := []string{}
-	// Check each queue
-	for _, queueName := range keys {
-		// Extract game ID from queue name
-		parts := strings.Split(queueName, ":")
-		if len(parts) != 3 {
-			continue
-		}
-		gameID := parts[1]
-
-		// Check if the game exists
-		if !w.gameExists(gameID) {
-			staleQueues = append(staleQueues, queueName)
-			w.logger.Info("Found stale queue for deletion",
-				zap.String("queue", queueName),
-				zap.String("gameId", gameID))
-		}
-	}
-
-	if len(staleQueues) == 0 {
-		w.logger.Info("No stale queues found")
-		return 0, nil
-	}
-
-	// Delete all stale queues
-	_, err = w.queue.client.Del(w.ctx, staleQueues...).Result()
-	if err != nil {
-		return 0, fmt.Errorf("failed to delete stale queues: %w", err)
-	}
-
-	w.logger.Info("Cleared stale queues", zap.Int("count", len(staleQueues)))
-	return len(staleQueues), nil
-}
@@ -0,0 +1,271 @@
+package queue
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestProcessMessageRecordsErrors(t *testing.T) {
+	q, _ := newTestQueue(t)
+	w := NewWorker(q, nil, zap.NewNop())
+	attempt := 0
+	w.RegisterHandler(GameStateUpdate, func(msg *QueueMessage) error {
+		attempt++
+		return fmt.Errorf("attempt %d failed", attempt)
+	})
+
+	msg := &QueueMessage{Type: GameStateUpdate, GameID: "g1"}
+	for i := 0; i < maxErrorHistory+2; i++ {
+		require.Error(t, w.processMessage("game:g1:queue", msg))
+		msg.Attempts++
+	}
+	assert.Equal(t, fmt.Sprintf("attempt %d failed", maxErrorHistory+2), msg.LastError)
+	require.Len(t, msg.Errors, maxErrorHistory)
+	assert.Equal(t, 3, msg.Errors[0].Attempt)
+	assert.Equal(t, msg.LastError, msg.Errors[maxErrorHistory-1].Error)
+
+	unknown := &QueueMessage{Type: "unknown", GameID: "g1"}
+	require.Error(t, w.processMessage("game:g1:queue", unknown))
+	assert.Contains(t, unknown.LastError, "no handler registered")
+
+	// A message that succeeds keeps the failures it had before
+	w.RegisterHandler(GameStateUpdate, func(msg *QueueMessage) error { return nil })
+	require.NoError(t, w.processMessage("game:g1:queue", msg))
+	assert.NotEmpty(t, msg.LastError)
+}
+
+// newTestWorker starts a worker with short timings on a queue split into four partitions
+func newTestWorker(t *testing.T, q *RedisQueue, consumer string, handler MessageHandler) *Worker {
+	t.Helper()
+	w := NewWorkerWithOptions(q, nil, zap.NewNop(), WorkerOptions{
+		Consumer: consumer,
+		Block:    50 * time.Millisecond,
+		LeaseTTL: 300 * time.Millisecond,
+	})
+	w.RegisterHandler(PlayerTokenUpdate, handler)
+	return w
+}
+
+func newPartitionedQueue(t *testing.T) (*RedisQueue, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	q, err := NewRedisQueueWithOptions(mr.Addr(), zap.NewNop(), Options{Partitions: 4})
+	require.NoError(t, err)
+	t.Cleanup(func() { q.Close() })
+	return q, mr
+}
+
+// requireNothingPending fails unless every stream entry has been acknowledged
+func requireNothingPending(t *testing.T, q *RedisQueue) {
+	t.Helper()
+	streams, err := q.Streams()
+	require.NoError(t, err)
+	for _, stream := range streams {
+		assert.Zero(t, stream.Pending, stream.Stream)
+	}
+}
+
+func TestWorkerHandlesEachGameInOrder(t *testing.T) {
+	q, _ := newPartitionedQueue(t)
+
+	var mu sync.Mutex
+	handled := make(map[string][]float64)
+	w := newTestWorker(t, q, "w1", func(msg *QueueMessage) error {
+		mu.Lock()
+		defer mu.Unlock()
+		handled[msg.GameID] = append(handled[msg.GameID], msg.Data["n"].(float64))
+		return nil
+	})
+
+	games := []string{"g1", "g2", "g3"}
+	for n := 0; n < 10; n++ {
+		for _, gameID := range games {
+			require.NoError(t, q.EnqueuePlayerTokenUpdate(gameID, "p1", map[string]interface{}{"n": n}))
+		}
+	}
+	require.NoError(t, w.Start())
+	defer w.Stop()
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		total := 0
+		for _, numbers := range handled {
+			total += len(numbers)
+		}
+		return total == 30
+	}, 5*time.Second, 10*time.Millisecond)
+
+	mu.Lock()
+	for _, gameID := range games {
+		assert.Equal(t, []float64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, handled[gameID], gameID)
+	}
+	mu.Unlock()
+	requireNothingPending(t, q)
+}
+
+func TestWorkerReclaimsUnacknowledgedMessages(t *testing.T) {
+	q, _ := newPartitionedQueue(t)
+	require.NoError(t, q.EnqueuePlayerTokenUpdate("g1", "p1", map[string]interface{}{"n": 0}))
+	require.NoError(t, q.EnqueuePlayerTokenUpdate("g1", "p1", map[string]interface{}{"n": 1}))
+
+	// A worker reads the first message and crashes before acknowledging it
+	require.NoError(t, q.ensureGroups(q.ctx))
+	_, err := q.client.XReadGroup(q.ctx, &redis.XReadGroupArgs{
+		Group:    consumerGroup,
+		Consumer: "crashed",
+		Streams:  []string{streamKey(q.partition("g1")), ">"},
+		Count:    1,
+	}).Result()
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	var handled []float64
+	w := newTestWorker(t, q, "w1", func(msg *QueueMessage) error {
+		mu.Lock()
+		defer mu.Unlock()
+		handled = append(handled, msg.Data["n"].(float64))
+		return nil
+	})
+	require.NoError(t, w.Start())
+	defer w.Stop()
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(handled) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	mu.Lock()
+	assert.Equal(t, []float64{0, 1}, handled)
+	mu.Unlock()
+	requireNothingPending(t, q)
+}
+
+func TestWorkerDeadLettersFailingMessages(t *testing.T) {
+	q, _ := newPartitionedQueue(t)
+	w := newTestWorker(t, q, "w1", func(msg *QueueMessage) error {
+		return fmt.Errorf("player not found in game")
+	})
+	w.SetMaxAttempts(0)
+
+	require.NoError(t, q.EnqueuePlayerTokenUpdate("g1", "p1", nil))
+	require.NoError(t, w.Start())
+	defer w.Stop()
+
+	require.Eventually(t, func() bool {
+		letters, err := q.DeadLetters("g1")
+		return err == nil && len(letters) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	letters, err := q.DeadLetters("g1")
+	require.NoError(t, err)
+	assert.Equal(t, "player not found in game", letters[0].LastError)
+
+	require.Eventually(t, func() bool {
+		streams, err := q.Streams()
+		if err != nil {
+			return false
+		}
+		for _, stream := range streams {
+			if stream.Pending > 0 {
+				return false
+			}
+		}
+		return true
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestWorkersSharePartitions(t *testing.T) {
+	q, mr := newPartitionedQueue(t)
+	noop := func(msg *QueueMessage) error { return nil }
+
+	// owners counts the partitions each worker holds the lease for
+	owners := func() map[string]int {
+		counts := make(map[string]int)
+		for p := 0; p < q.partitions; p++ {
+			if owner, err := mr.Get(partitionLeaseKey(p)); err == nil {
+				counts[owner]++
+			}
+		}
+		return counts
+	}
+
+	w1 := newTestWorker(t, q, "w1", noop)
+	require.NoError(t, w1.Start())
+	require.Eventually(t, func() bool {
+		return owners()["w1"] == 4
+	}, 5*time.Second, 10*time.Millisecond)
+
+	w2 := newTestWorker(t, q, "w2", noop)
+	require.NoError(t, w2.Start())
+	defer w2.Stop()
+	require.Eventually(t, func() bool {
+		counts := owners()
+		return counts["w1"] == 2 && counts["w2"] == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// A worker that stops hands its partitions back straight away
+	w1.Stop()
+	require.Eventually(t, func() bool {
+		return owners()["w2"] == 4
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+// ownedPartitions lists the partitions a worker is reading, lowest first
+func ownedPartitions(w *Worker) []int {
+	var partitions []int
+	for p := 0; p < w.queue.partitions; p++ {
+		if _, ok := w.owned[p]; ok {
+			partitions = append(partitions, p)
+		}
+	}
+	return partitions
+}
+
+func TestWorkerKeepsLeasesThroughFailures(t *testing.T) {
+	q, mr := newPartitionedQueue(t)
+	require.NoError(t, q.ensureGroups(q.ctx))
+
+	// The worker's rounds are driven by hand, so its partitions can be inspected
+	w := newTestWorker(t, q, "w1", func(msg *QueueMessage) error { return nil })
+	defer func() {
+		w.cancel()
+		w.stopReading(ownedPartitions(w)...)
+	}()
+	w.rebalance()
+	require.Equal(t, []int{0, 1, 2, 3}, ownedPartitions(w))
+
+	// Leases are still renewed while the heartbeat fails
+	mr.Del(workersKey)
+	require.NoError(t, mr.Set(workersKey, "not a sorted set"))
+	mr.FastForward(200 * time.Millisecond)
+	w.rebalance()
+	assert.Equal(t, []int{0, 1, 2, 3}, ownedPartitions(w))
+	assert.Equal(t, 300*time.Millisecond, mr.TTL(partitionLeaseKey(0)))
+	mr.Del(workersKey)
+
+	// A partition another worker has taken is dropped, once its reader has stopped
+	reader := w.owned[1]
+	require.NoError(t, mr.Set(partitionLeaseKey(1), "w2"))
+	w.rebalance()
+	assert.Equal(t, []int{0, 2, 3}, ownedPartitions(w))
+	select {
+	case <-reader.done:
+	default:
+		t.Fatal("reader of a lost partition is still running")
+	}
+
+	// While Redis is unreachable, partitions are kept until their leases would have expired
+	mr.SetError("connection refused")
+	w.owned[2].renewed = time.Now().Add(-time.Second)
+	w.rebalance()
+	assert.Equal(t, []int{0, 3}, ownedPartitions(w))
+	mr.SetError("")
+}
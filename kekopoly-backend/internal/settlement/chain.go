@@ -0,0 +1,79 @@
+package settlement
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+
+	"github.com/kekopoly/backend/internal/config"
+)
+
+// Transfer is a finalized KMT movement into or out of the escrow account
+type Transfer struct {
+	Signature string // On-chain transaction signature
+	Wallet    string // The player's wallet (sender for deposits, recipient for payouts)
+	Amount    uint64 // Raw token units
+	Incoming  bool   // True for deposits into escrow, false for payouts
+}
+
+var (
+	// ErrDepositNotFound is returned when a deposit transaction isn't finalized on chain
+	ErrDepositNotFound = errors.New("deposit transaction not found or not finalized")
+	// ErrDepositMismatch is returned when a transaction isn't a valid deposit for the game
+	ErrDepositMismatch = errors.New("transaction is not a valid deposit")
+)
+
+// DepositVerifier checks a deposit transaction submitted by a player
+type DepositVerifier interface {
+	// VerifyDeposit checks that signature is a finalized, successful transfer of the
+	// settlement mint from wallet into the escrow account, tagged with reference
+	VerifyDeposit(ctx context.Context, signature, wallet, reference string) (*Transfer, error)
+}
+
+// ChainClient is the on-chain side of settlement. Every transfer is tagged with a
+// reference memo so deposits and payouts can be matched back to a game.
+type ChainClient interface {
+	DepositVerifier
+	// FindTransfers returns finalized escrow transfers whose memo matches reference
+	FindTransfers(ctx context.Context, reference string) ([]Transfer, error)
+	// Pay sends amount from escrow to wallet with reference as memo and waits for finalization
+	Pay(ctx context.Context, wallet string, amount uint64, reference string) (string, error)
+}
+
+// DepositReference is the memo players attach to their buy-in transfer for a game
+func DepositReference(gameID string) string {
+	return fmt.Sprintf("kekopoly:deposit:%s", gameID)
+}
+
+// PayoutReference is the memo attached to a player's payout for a game
+func PayoutReference(gameID, playerID string) string {
+	return fmt.Sprintf("kekopoly:payout:%s:%s", gameID, playerID)
+}
+
+// RefundReference is the memo attached to a refund. A refund returns every deposit a
+// player made since they last joined the game, and is named after the first of them.
+func RefundReference(gameID, depositSignature string) string {
+	return fmt.Sprintf("kekopoly:refund:%s:%s", gameID, depositSignature)
+}
+
+// NewChainClient builds the chain client selected in the configuration
+func NewChainClient(cfg *config.Config) (ChainClient, error) {
+	switch cfg.Settlement.Chain {
+	case "fake":
+		return NewFakeChain(), nil
+	case "", "solana":
+		escrow, err := solana.PrivateKeyFromSolanaKeygenFile(cfg.Settlement.EscrowKeypair)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load escrow keypair: %w", err)
+		}
+		mint, err := solana.PublicKeyFromBase58(cfg.Settlement.TokenMint)
+		if err != nil {
+			return nil, fmt.Errorf("invalid token mint: %w", err)
+		}
+		return NewSolanaChain(cfg.Solana.RpcURL, escrow, mint)
+	default:
+		return nil, fmt.Errorf("unknown settlement chain %q", cfg.Settlement.Chain)
+	}
+}
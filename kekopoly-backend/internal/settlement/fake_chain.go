@@ -0,0 +1,113 @@
+package settlement
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrFakePayFailed is returned by FakeChain when a payout failure was scheduled
+var ErrFakePayFailed = errors.New("fake chain: payout failed")
+
+type fakeTransfer struct {
+	Transfer
+	reference string
+}
+
+// FakeChain is an in-memory ChainClient for tests and offline development
+type FakeChain struct {
+	mu        sync.Mutex
+	transfers []fakeTransfer
+	failPays  int
+	nextSig   int
+}
+
+// NewFakeChain creates an empty fake chain
+func NewFakeChain() *FakeChain {
+	return &FakeChain{}
+}
+
+// Deposit records a finalized transfer from wallet into escrow and returns its signature
+func (f *FakeChain) Deposit(wallet string, amount uint64, reference string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.record(wallet, amount, reference, true)
+}
+
+// FailNextPays makes the next n calls to Pay fail without moving tokens
+func (f *FakeChain) FailNextPays(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failPays = n
+}
+
+// Payouts returns all payouts made so far
+func (f *FakeChain) Payouts() []Transfer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []Transfer
+	for _, t := range f.transfers {
+		if !t.Incoming {
+			out = append(out, t.Transfer)
+		}
+	}
+	return out
+}
+
+// FindTransfers implements ChainClient
+func (f *FakeChain) FindTransfers(ctx context.Context, reference string) ([]Transfer, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []Transfer
+	for _, t := range f.transfers {
+		if t.reference == reference {
+			out = append(out, t.Transfer)
+		}
+	}
+	return out, nil
+}
+
+// VerifyDeposit implements DepositVerifier
+func (f *FakeChain) VerifyDeposit(ctx context.Context, signature, wallet, reference string) (*Transfer, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, t := range f.transfers {
+		if t.Signature != signature {
+			continue
+		}
+		if !t.Incoming || t.Wallet != wallet || t.reference != reference {
+			return nil, ErrDepositMismatch
+		}
+		transfer := t.Transfer
+		return &transfer, nil
+	}
+	return nil, ErrDepositNotFound
+}
+
+// Pay implements ChainClient
+func (f *FakeChain) Pay(ctx context.Context, wallet string, amount uint64, reference string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failPays > 0 {
+		f.failPays--
+		return "", ErrFakePayFailed
+	}
+	return f.record(wallet, amount, reference, false), nil
+}
+
+// record appends a transfer; callers must hold the lock
+func (f *FakeChain) record(wallet string, amount uint64, reference string, incoming bool) string {
+	f.nextSig++
+	sig := fmt.Sprintf("fake-tx-%d", f.nextSig)
+	f.transfers = append(f.transfers, fakeTransfer{
+		Transfer: Transfer{
+			Signature: sig,
+			Wallet:    wallet,
+			Amount:    amount,
+			Incoming:  incoming,
+		},
+		reference: reference,
+	})
+	return sig
+}
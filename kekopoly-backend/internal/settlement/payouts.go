@@ -0,0 +1,92 @@
+package settlement
+
+import (
+	"sort"
+
+	"github.com/kekopoly/backend/internal/game/models"
+)
+
+// Payout is one player's share of a game's pot
+type Payout struct {
+	PlayerID string `json:"playerId"`
+	Wallet   string `json:"walletAddress"`
+	Rank     int    `json:"rank"` // 1 for the winner
+	Amount   uint64 `json:"amount"`
+}
+
+// Standings orders a finished game's players: the winner first, then players still
+// in the game by net worth, then forfeited and bankrupt players by net worth.
+func Standings(game *models.Game) []models.Player {
+	players := make([]models.Player, len(game.Players))
+	copy(players, game.Players)
+
+	tier := func(p models.Player) int {
+		switch {
+		case p.ID == game.WinnerID:
+			return 0
+		case p.Status == models.PlayerStatusForfeited || p.Status == models.PlayerStatusBankrupt:
+			return 2
+		default:
+			return 1
+		}
+	}
+
+	sort.SliceStable(players, func(i, j int) bool {
+		ti, tj := tier(players[i]), tier(players[j])
+		if ti != tj {
+			return ti < tj
+		}
+		return players[i].NetWorth > players[j].NetWorth
+	})
+
+	return players
+}
+
+// ComputePayouts splits pot across the standings. shares are percentages by finishing
+// position; feeBps is the house cut in basis points. Shares for positions nobody
+// finished in, and rounding dust, go to the winner. Players without a wallet are skipped.
+func ComputePayouts(game *models.Game, pot uint64, shares []int, feeBps int) []Payout {
+	if pot == 0 || len(shares) == 0 {
+		return nil
+	}
+
+	var ranked []models.Player
+	for _, p := range Standings(game) {
+		if p.WalletAddress != "" {
+			ranked = append(ranked, p)
+		}
+	}
+	if len(ranked) == 0 {
+		return nil
+	}
+
+	fee := pot * uint64(feeBps) / 10000
+	prize := pot - fee
+
+	payouts := make([]Payout, 0, len(shares))
+	var paid uint64
+	for i, share := range shares {
+		if i >= len(ranked) || share <= 0 {
+			continue
+		}
+		amount := prize * uint64(share) / 100
+		payouts = append(payouts, Payout{
+			PlayerID: ranked[i].ID,
+			Wallet:   ranked[i].WalletAddress,
+			Rank:     i + 1,
+			Amount:   amount,
+		})
+		paid += amount
+	}
+
+	if len(payouts) == 0 || payouts[0].Rank != 1 {
+		payouts = append([]Payout{{
+			PlayerID: ranked[0].ID,
+			Wallet:   ranked[0].WalletAddress,
+			Rank:     1,
+		}}, payouts...)
+	}
+	payouts[0].Amount += prize - paid
+
+	return payouts
+}
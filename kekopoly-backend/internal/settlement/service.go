@@ -0,0 +1,368 @@
+package settlement
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/kekopoly/backend/internal/game/models"
+)
+
+// ErrDepositRecorded is returned when a deposit transaction was already credited to the game
+var ErrDepositRecorded = errors.New("deposit transaction already recorded for this game")
+
+// StatusRecorder persists a game's settlement progress
+type StatusRecorder interface {
+	SetSettlementStatus(gameID string, status models.SettlementStatus) error
+}
+
+// Config controls how pots are split and how hard settlement retries
+type Config struct {
+	PayoutShares  []int // Percent of the pot by finishing position, must sum to 100
+	FeeBps        int   // House cut in basis points
+	MaxAttempts   int
+	RetryInterval time.Duration
+}
+
+// Service verifies escrow deposits before a game starts and pays out when it ends
+type Service struct {
+	chain    ChainClient
+	store    Store
+	recorder StatusRecorder
+	cfg      Config
+	logger   *zap.SugaredLogger
+}
+
+// NewService creates a settlement service
+func NewService(chain ChainClient, store Store, recorder StatusRecorder, cfg Config, logger *zap.SugaredLogger) (*Service, error) {
+	total := 0
+	for _, share := range cfg.PayoutShares {
+		if share < 0 {
+			return nil, fmt.Errorf("payout shares must not be negative")
+		}
+		total += share
+	}
+	if total != 100 {
+		return nil, fmt.Errorf("payout shares must sum to 100, got %d", total)
+	}
+	if cfg.FeeBps < 0 || cfg.FeeBps > 10000 {
+		return nil, fmt.Errorf("fee must be between 0 and 10000 basis points")
+	}
+	if cfg.MaxAttempts < 1 {
+		cfg.MaxAttempts = 1
+	}
+
+	return &Service{
+		chain:    chain,
+		store:    store,
+		recorder: recorder,
+		cfg:      cfg,
+		logger:   logger,
+	}, nil
+}
+
+// VerifyDeposit checks a deposit transaction a player submitted for a game, records
+// it and returns the amount that reached escrow
+func (s *Service) VerifyDeposit(ctx context.Context, game *models.Game, playerID, signature string) (int, error) {
+	gameID := game.ID.Hex()
+
+	player := playerByID(game, playerID)
+	if player == nil {
+		return 0, fmt.Errorf("player %s is not in game %s", playerID, gameID)
+	}
+	if player.WalletAddress == "" {
+		return 0, fmt.Errorf("%w: player has no wallet", ErrDepositMismatch)
+	}
+
+	transfer, err := s.chain.VerifyDeposit(ctx, signature, player.WalletAddress, DepositReference(gameID))
+	if err != nil {
+		return 0, err
+	}
+
+	// A transaction is credited once per game, even to a player who was refunded and
+	// rejoined since, whatever became of the deposit
+	deposits, err := s.store.GameTransactions(ctx, gameID, models.TransactionTypeDeposit)
+	if err != nil {
+		return 0, err
+	}
+	for _, deposit := range deposits {
+		if deposit.OnChainTxID == transfer.Signature {
+			return 0, ErrDepositRecorded
+		}
+	}
+
+	tx := &models.Transaction{
+		ID:            fmt.Sprintf("%s:deposit:%s", gameID, transfer.Signature),
+		GameID:        gameID,
+		Type:          models.TransactionTypeDeposit,
+		FromPlayerID:  playerID,
+		Amount:        int(transfer.Amount),
+		Timestamp:     time.Now(),
+		OnChainStatus: models.OnChainStatusCompleted,
+		OnChainTxID:   transfer.Signature,
+		Wallet:        player.WalletAddress,
+		Reference:     DepositReference(gameID),
+	}
+	if err := s.store.SaveTransaction(ctx, tx); err != nil {
+		return 0, err
+	}
+
+	s.logger.Infof("[Settlement] Verified deposit of %d from player %s for game %s (tx %s)", transfer.Amount, playerID, gameID, transfer.Signature)
+	return int(transfer.Amount), nil
+}
+
+// Refund returns the deposits a player made since they joined the game from escrow.
+// Each refund is sent once, and is retried like payouts; one that still fails stays
+// recorded as failed and is sent again by RetryRefunds.
+func (s *Service) Refund(ctx context.Context, game *models.Game, player models.Player) error {
+	if player.DepositedAmount <= 0 {
+		return nil
+	}
+	if len(player.DepositTxIDs) == 0 {
+		return fmt.Errorf("player %s has no deposit transactions to refund", player.ID)
+	}
+	gameID := game.ID.Hex()
+
+	tx := &models.Transaction{
+		ID:            fmt.Sprintf("%s:refund:%s", gameID, player.DepositTxIDs[0]),
+		GameID:        gameID,
+		Type:          models.TransactionTypeRefund,
+		ToPlayerID:    player.ID,
+		Amount:        player.DepositedAmount,
+		Timestamp:     time.Now(),
+		OnChainStatus: models.OnChainStatusPending,
+		Wallet:        player.WalletAddress,
+		Reference:     RefundReference(gameID, player.DepositTxIDs[0]),
+	}
+
+	existing, err := s.store.GameTransactions(ctx, gameID, models.TransactionTypeRefund)
+	if err != nil {
+		return err
+	}
+	for _, refund := range existing {
+		if refund.ID == tx.ID && refund.OnChainStatus == models.OnChainStatusCompleted {
+			return nil
+		}
+	}
+
+	return s.sendRefund(ctx, tx)
+}
+
+// RetryRefunds sends every recorded refund that hasn't reached the chain, such as those
+// that failed before the last restart
+func (s *Service) RetryRefunds(ctx context.Context) error {
+	refunds, err := s.store.UnsettledTransactions(ctx, models.TransactionTypeRefund)
+	if err != nil {
+		return err
+	}
+
+	var failed int
+	for i := range refunds {
+		if err := s.sendRefund(ctx, &refunds[i]); err != nil {
+			s.logger.Errorf("[Settlement] %v", err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d refunds still failed", failed, len(refunds))
+	}
+	return nil
+}
+
+// sendRefund records a refund as pending and sends it, retrying like Settle
+func (s *Service) sendRefund(ctx context.Context, tx *models.Transaction) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = s.refundOnce(ctx, tx); err == nil {
+			return nil
+		}
+
+		s.logger.Warnf("[Settlement] Refund attempt %d/%d for %s failed: %v", attempt, s.cfg.MaxAttempts, tx.ID, err)
+		if attempt >= s.cfg.MaxAttempts {
+			break
+		}
+
+		timer := time.NewTimer(s.cfg.RetryInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("refund %s failed: %w", tx.ID, ctx.Err())
+		case <-timer.C:
+		}
+	}
+	return fmt.Errorf("refund %s failed: %w", tx.ID, err)
+}
+
+// refundOnce submits a refund unless it is already on chain
+func (s *Service) refundOnce(ctx context.Context, tx *models.Transaction) error {
+	// A previous attempt may have landed on chain after we gave up waiting for it
+	sent, err := s.chain.FindTransfers(ctx, tx.Reference)
+	if err != nil {
+		return fmt.Errorf("failed to look up refund: %w", err)
+	}
+	if len(sent) > 0 {
+		tx.OnChainStatus = models.OnChainStatusCompleted
+		tx.OnChainTxID = sent[0].Signature
+		return s.store.SaveTransaction(ctx, tx)
+	}
+
+	tx.OnChainStatus = models.OnChainStatusPending
+	if err := s.store.SaveTransaction(ctx, tx); err != nil {
+		return err
+	}
+
+	sig, payErr := s.chain.Pay(ctx, tx.Wallet, uint64(tx.Amount), tx.Reference)
+	if payErr != nil {
+		tx.OnChainStatus = models.OnChainStatusFailed
+		if err := s.store.SaveTransaction(ctx, tx); err != nil {
+			s.logger.Errorf("[Settlement] Failed to record failed refund %s: %v", tx.ID, err)
+		}
+		return fmt.Errorf("refund to %s failed: %w", tx.ToPlayerID, payErr)
+	}
+
+	tx.OnChainStatus = models.OnChainStatusCompleted
+	tx.OnChainTxID = sig
+	if err := s.store.SaveTransaction(ctx, tx); err != nil {
+		return err
+	}
+
+	s.logger.Infof("[Settlement] Refunded %d to player %s for game %s (tx %s)", tx.Amount, tx.ToPlayerID, tx.GameID, sig)
+	return nil
+}
+
+// Settle pays out a completed game, retrying failed transfers. It is safe to call
+// again for a game that was partly settled; payouts already on chain are not repeated.
+func (s *Service) Settle(ctx context.Context, game *models.Game) error {
+	gameID := game.ID.Hex()
+	s.setStatus(gameID, models.SettlementStatusInProgress)
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = s.settleOnce(ctx, game); err == nil {
+			s.setStatus(gameID, models.SettlementStatusCompleted)
+			s.logger.Infof("[Settlement] Game %s settled", gameID)
+			return nil
+		}
+
+		s.logger.Warnf("[Settlement] Attempt %d/%d for game %s failed: %v", attempt, s.cfg.MaxAttempts, gameID, err)
+		if attempt >= s.cfg.MaxAttempts {
+			break
+		}
+
+		timer := time.NewTimer(s.cfg.RetryInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			s.setStatus(gameID, models.SettlementStatusFailed)
+			return fmt.Errorf("failed to settle game %s: %w", gameID, ctx.Err())
+		case <-timer.C:
+		}
+	}
+
+	s.setStatus(gameID, models.SettlementStatusFailed)
+	return fmt.Errorf("failed to settle game %s: %w", gameID, err)
+}
+
+// settleOnce computes the payouts and submits every one that isn't on chain yet
+func (s *Service) settleOnce(ctx context.Context, game *models.Game) error {
+	gameID := game.ID.Hex()
+
+	// The pot is what the players still in the game paid in. Deposits refunded to
+	// players who left, even ones who rejoined since, were already paid back.
+	var pot uint64
+	for _, player := range game.Players {
+		if player.DepositedAmount > 0 {
+			pot += uint64(player.DepositedAmount)
+		}
+	}
+
+	existing, err := s.store.GameTransactions(ctx, gameID, models.TransactionTypeGameSettlement)
+	if err != nil {
+		return err
+	}
+	done := make(map[string]bool)
+	for _, tx := range existing {
+		if tx.OnChainStatus == models.OnChainStatusCompleted {
+			done[tx.ID] = true
+		}
+	}
+
+	for _, payout := range ComputePayouts(game, pot, s.cfg.PayoutShares, s.cfg.FeeBps) {
+		if payout.Amount == 0 {
+			continue
+		}
+
+		tx := &models.Transaction{
+			ID:            fmt.Sprintf("%s:payout:%s", gameID, payout.PlayerID),
+			GameID:        gameID,
+			Type:          models.TransactionTypeGameSettlement,
+			ToPlayerID:    payout.PlayerID,
+			Amount:        int(payout.Amount),
+			Timestamp:     time.Now(),
+			OnChainStatus: models.OnChainStatusPending,
+		}
+		if done[tx.ID] {
+			continue
+		}
+
+		// A previous attempt may have landed on chain after we gave up waiting for it
+		reference := PayoutReference(gameID, payout.PlayerID)
+		sent, err := s.chain.FindTransfers(ctx, reference)
+		if err != nil {
+			return fmt.Errorf("failed to look up payout: %w", err)
+		}
+		if len(sent) > 0 {
+			tx.OnChainStatus = models.OnChainStatusCompleted
+			tx.OnChainTxID = sent[0].Signature
+			if err := s.store.SaveTransaction(ctx, tx); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := s.store.SaveTransaction(ctx, tx); err != nil {
+			return err
+		}
+
+		sig, payErr := s.chain.Pay(ctx, payout.Wallet, payout.Amount, reference)
+		if payErr != nil {
+			tx.OnChainStatus = models.OnChainStatusFailed
+			if err := s.store.SaveTransaction(ctx, tx); err != nil {
+				s.logger.Errorf("[Settlement] Failed to record failed payout %s: %v", tx.ID, err)
+			}
+			return fmt.Errorf("payout to %s failed: %w", payout.PlayerID, payErr)
+		}
+
+		tx.OnChainStatus = models.OnChainStatusCompleted
+		tx.OnChainTxID = sig
+		if err := s.store.SaveTransaction(ctx, tx); err != nil {
+			return err
+		}
+		s.logger.Infof("[Settlement] Paid %d to player %s for game %s (tx %s)", payout.Amount, payout.PlayerID, gameID, sig)
+	}
+
+	return nil
+}
+
+// setStatus records settlement progress, logging rather than failing on errors
+func (s *Service) setStatus(gameID string, status models.SettlementStatus) {
+	if s.recorder == nil {
+		return
+	}
+	if err := s.recorder.SetSettlementStatus(gameID, status); err != nil {
+		s.logger.Errorf("[Settlement] Failed to record status %s for game %s: %v", status, gameID, err)
+	}
+}
+
+// playerByID finds a player in the game
+func playerByID(game *models.Game, playerID string) *models.Player {
+	for i := range game.Players {
+		if game.Players[i].ID == playerID {
+			return &game.Players[i]
+		}
+	}
+	return nil
+}
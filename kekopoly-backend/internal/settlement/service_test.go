@@ -0,0 +1,285 @@
+package settlement
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/kekopoly/backend/internal/game/models"
+)
+
+type recordedStatuses struct {
+	mu       sync.Mutex
+	statuses []models.SettlementStatus
+}
+
+func (r *recordedStatuses) SetSettlementStatus(gameID string, status models.SettlementStatus) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statuses = append(r.statuses, status)
+	return nil
+}
+
+func newTestService(t *testing.T, shares []int, feeBps int) (*Service, *FakeChain, *MemoryStore, *recordedStatuses) {
+	t.Helper()
+	chain := NewFakeChain()
+	store := NewMemoryStore()
+	recorder := &recordedStatuses{}
+	svc, err := NewService(chain, store, recorder, Config{
+		PayoutShares:  shares,
+		FeeBps:        feeBps,
+		MaxAttempts:   3,
+		RetryInterval: time.Millisecond,
+	}, zap.NewNop().Sugar())
+	require.NoError(t, err)
+	return svc, chain, store, recorder
+}
+
+func testGame() *models.Game {
+	return &models.Game{
+		ID:       primitive.NewObjectID(),
+		Status:   models.GameStatusCompleted,
+		WinnerID: "p2",
+		Players: []models.Player{
+			{ID: "p1", WalletAddress: "wallet1", InitialDeposit: 100, NetWorth: 900, Status: models.PlayerStatusActive},
+			{ID: "p2", WalletAddress: "wallet2", InitialDeposit: 100, NetWorth: 2500, Status: models.PlayerStatusActive},
+			{ID: "p3", WalletAddress: "wallet3", InitialDeposit: 100, NetWorth: 1800, Status: models.PlayerStatusForfeited},
+		},
+	}
+}
+
+// fundAll has every player pay their buy-in and credits it to them
+func fundAll(chain *FakeChain, game *models.Game) {
+	for i := range game.Players {
+		p := &game.Players[i]
+		sig := chain.Deposit(p.WalletAddress, uint64(p.InitialDeposit), DepositReference(game.ID.Hex()))
+		p.DepositedAmount = p.InitialDeposit
+		p.DepositTxIDs = []string{sig}
+	}
+}
+
+func TestVerifyDepositChecksSenderAndGame(t *testing.T) {
+	svc, chain, store, _ := newTestService(t, []int{100}, 0)
+	game := testGame()
+	ctx := context.Background()
+	ref := DepositReference(game.ID.Hex())
+
+	good := chain.Deposit("wallet1", 60, ref)
+	otherGame := chain.Deposit("wallet2", 100, DepositReference("another-game"))
+
+	amount, err := svc.VerifyDeposit(ctx, game, "p1", good)
+	require.NoError(t, err)
+	assert.Equal(t, 60, amount)
+
+	// Someone else's transaction can't be claimed
+	_, err = svc.VerifyDeposit(ctx, game, "p3", good)
+	assert.ErrorIs(t, err, ErrDepositMismatch)
+
+	// Nor can a deposit made for a different game
+	_, err = svc.VerifyDeposit(ctx, game, "p2", otherGame)
+	assert.ErrorIs(t, err, ErrDepositMismatch)
+
+	_, err = svc.VerifyDeposit(ctx, game, "p2", "not-on-chain")
+	assert.ErrorIs(t, err, ErrDepositNotFound)
+
+	deposits, err := store.GameTransactions(ctx, game.ID.Hex(), models.TransactionTypeDeposit)
+	require.NoError(t, err)
+	require.Len(t, deposits, 1)
+	assert.Equal(t, "p1", deposits[0].FromPlayerID)
+	assert.Equal(t, good, deposits[0].OnChainTxID)
+	assert.Equal(t, models.OnChainStatusCompleted, deposits[0].OnChainStatus)
+}
+
+func TestVerifyDepositRejectsRecordedSignature(t *testing.T) {
+	svc, chain, _, _ := newTestService(t, []int{100}, 0)
+	game := testGame()
+	ctx := context.Background()
+
+	sig := chain.Deposit("wallet1", 100, DepositReference(game.ID.Hex()))
+	_, err := svc.VerifyDeposit(ctx, game, "p1", sig)
+	require.NoError(t, err)
+
+	// The player is refunded, rejoins and submits the same transaction again
+	player := game.Players[0]
+	player.DepositedAmount = 100
+	player.DepositTxIDs = []string{sig}
+	require.NoError(t, svc.Refund(ctx, game, player))
+
+	_, err = svc.VerifyDeposit(ctx, game, "p1", sig)
+	assert.ErrorIs(t, err, ErrDepositRecorded)
+}
+
+func TestRefundRetriesAndIsNotRepeated(t *testing.T) {
+	svc, chain, store, _ := newTestService(t, []int{100}, 0)
+	game := testGame()
+	ctx := context.Background()
+	player := game.Players[0]
+	player.DepositedAmount = 40
+	player.DepositTxIDs = []string{"deposit-1"}
+
+	// A failed transfer is retried
+	chain.FailNextPays(1)
+	require.NoError(t, svc.Refund(ctx, game, player))
+	require.NoError(t, svc.Refund(ctx, game, player))
+
+	payouts := chain.Payouts()
+	require.Len(t, payouts, 1)
+	assert.Equal(t, uint64(40), payouts[0].Amount)
+
+	// After rejoining, the player's new deposit is refunded on its own
+	player.DepositedAmount = 60
+	player.DepositTxIDs = []string{"deposit-2"}
+	require.NoError(t, svc.Refund(ctx, game, player))
+
+	payouts = chain.Payouts()
+	require.Len(t, payouts, 2)
+	assert.Equal(t, uint64(60), payouts[1].Amount)
+
+	refunds, err := store.GameTransactions(ctx, game.ID.Hex(), models.TransactionTypeRefund)
+	require.NoError(t, err)
+	require.Len(t, refunds, 2)
+	for _, refund := range refunds {
+		assert.Equal(t, models.OnChainStatusCompleted, refund.OnChainStatus)
+	}
+}
+
+func TestRetryRefundsSendsFailedRefunds(t *testing.T) {
+	svc, chain, store, _ := newTestService(t, []int{100}, 0)
+	game := testGame()
+	ctx := context.Background()
+	player := game.Players[0]
+	player.DepositedAmount = 40
+	player.DepositTxIDs = []string{"deposit-1"}
+
+	chain.FailNextPays(3)
+	assert.ErrorIs(t, svc.Refund(ctx, game, player), ErrFakePayFailed)
+	assert.Empty(t, chain.Payouts())
+
+	refunds, err := store.UnsettledTransactions(ctx, models.TransactionTypeRefund)
+	require.NoError(t, err)
+	require.Len(t, refunds, 1)
+	assert.Equal(t, models.OnChainStatusFailed, refunds[0].OnChainStatus)
+
+	require.NoError(t, svc.RetryRefunds(ctx))
+	payouts := chain.Payouts()
+	require.Len(t, payouts, 1)
+	assert.Equal(t, Transfer{Signature: payouts[0].Signature, Wallet: "wallet1", Amount: 40}, payouts[0])
+
+	refunds, err = store.UnsettledTransactions(ctx, models.TransactionTypeRefund)
+	require.NoError(t, err)
+	assert.Empty(t, refunds)
+}
+
+func TestSettlePotLeavesOutRefundedDeposits(t *testing.T) {
+	svc, chain, _, _ := newTestService(t, []int{100}, 0)
+	game := testGame()
+	fundAll(chain, game)
+
+	// p1 was refunded and rejoined with a smaller deposit that is still on chain
+	// alongside the refunded one
+	chain.Deposit("wallet1", 30, DepositReference(game.ID.Hex()))
+	game.Players[0].DepositedAmount = 30
+
+	require.NoError(t, svc.Settle(context.Background(), game))
+	payouts := chain.Payouts()
+	require.Len(t, payouts, 1)
+	assert.Equal(t, uint64(230), payouts[0].Amount)
+}
+
+func TestSettlePaysOutFromStandings(t *testing.T) {
+	svc, chain, store, recorder := newTestService(t, []int{70, 30}, 500)
+	game := testGame()
+	fundAll(chain, game)
+
+	require.NoError(t, svc.Settle(context.Background(), game))
+
+	// Pot 300, 5% fee leaves 285: winner p2 gets 70%, runner-up p1 (still active) gets 30%
+	payouts := chain.Payouts()
+	require.Len(t, payouts, 2)
+	assert.Equal(t, Transfer{Signature: payouts[0].Signature, Wallet: "wallet2", Amount: 200}, payouts[0])
+	assert.Equal(t, Transfer{Signature: payouts[1].Signature, Wallet: "wallet1", Amount: 85}, payouts[1])
+
+	assert.Equal(t, []models.SettlementStatus{
+		models.SettlementStatusInProgress,
+		models.SettlementStatusCompleted,
+	}, recorder.statuses)
+
+	txs, err := store.GameTransactions(context.Background(), game.ID.Hex(), models.TransactionTypeGameSettlement)
+	require.NoError(t, err)
+	require.Len(t, txs, 2)
+	for _, tx := range txs {
+		assert.Equal(t, models.OnChainStatusCompleted, tx.OnChainStatus)
+	}
+}
+
+func TestSettleRetriesWithoutDoublePaying(t *testing.T) {
+	svc, chain, _, recorder := newTestService(t, []int{70, 30}, 0)
+	game := testGame()
+	fundAll(chain, game)
+
+	// The first attempt pays the winner, then the runner-up's transfer fails once
+	svc.chain = &failAfter{FakeChain: chain, okPays: 1}
+
+	require.NoError(t, svc.Settle(context.Background(), game))
+	assert.Len(t, chain.Payouts(), 2)
+	assert.Equal(t, models.SettlementStatusCompleted, recorder.statuses[len(recorder.statuses)-1])
+
+	// Settling again is a no-op
+	require.NoError(t, svc.Settle(context.Background(), game))
+	assert.Len(t, chain.Payouts(), 2)
+}
+
+func TestSettleMarksFailedAfterMaxAttempts(t *testing.T) {
+	svc, chain, store, recorder := newTestService(t, []int{100}, 0)
+	game := testGame()
+	fundAll(chain, game)
+	chain.FailNextPays(3)
+
+	err := svc.Settle(context.Background(), game)
+	assert.ErrorIs(t, err, ErrFakePayFailed)
+	assert.Equal(t, models.SettlementStatusFailed, recorder.statuses[len(recorder.statuses)-1])
+
+	txs, err := store.GameTransactions(context.Background(), game.ID.Hex(), models.TransactionTypeGameSettlement)
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+	assert.Equal(t, models.OnChainStatusFailed, txs[0].OnChainStatus)
+}
+
+func TestComputePayoutsGivesDustAndUnfilledSharesToWinner(t *testing.T) {
+	game := testGame()
+	game.Players = game.Players[1:2] // Only the winner has a seat
+
+	payouts := ComputePayouts(game, 301, []int{50, 30, 20}, 0)
+	require.Len(t, payouts, 1)
+	assert.Equal(t, "p2", payouts[0].PlayerID)
+	assert.Equal(t, uint64(301), payouts[0].Amount)
+
+	assert.Empty(t, ComputePayouts(testGame(), 0, []int{100}, 0))
+}
+
+func TestNewServiceRejectsBadShares(t *testing.T) {
+	_, err := NewService(NewFakeChain(), NewMemoryStore(), nil, Config{PayoutShares: []int{60, 30}}, zap.NewNop().Sugar())
+	assert.Error(t, err)
+}
+
+// failAfter lets okPays payouts through and fails the next one
+type failAfter struct {
+	*FakeChain
+	okPays int
+	failed bool
+}
+
+func (f *failAfter) Pay(ctx context.Context, wallet string, amount uint64, reference string) (string, error) {
+	if f.okPays == 0 && !f.failed {
+		f.failed = true
+		return "", ErrFakePayFailed
+	}
+	f.okPays--
+	return f.FakeChain.Pay(ctx, wallet, amount, reference)
+}
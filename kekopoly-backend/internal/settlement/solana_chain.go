@@ -0,0 +1,292 @@
+package settlement
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	associatedtokenaccount "github.com/gagliardetto/solana-go/programs/associated-token-account"
+	"github.com/gagliardetto/solana-go/programs/memo"
+	"github.com/gagliardetto/solana-go/programs/token"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// SolanaChain settles KMT through an escrow wallet on Solana
+type SolanaChain struct {
+	client        *rpc.Client
+	escrow        solana.PrivateKey
+	mint          solana.PublicKey
+	escrowAccount solana.PublicKey // The escrow wallet's associated token account for the mint
+	confirmWait   time.Duration
+}
+
+// NewSolanaChain creates a chain client that pays out of the escrow wallet's token account
+func NewSolanaChain(rpcURL string, escrow solana.PrivateKey, mint solana.PublicKey) (*SolanaChain, error) {
+	if rpcURL == "" {
+		rpcURL = rpc.MainNetBeta_RPC
+	}
+
+	escrowAccount, _, err := solana.FindAssociatedTokenAddress(escrow.PublicKey(), mint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive escrow token account: %w", err)
+	}
+
+	return &SolanaChain{
+		client:        rpc.New(rpcURL),
+		escrow:        escrow,
+		mint:          mint,
+		escrowAccount: escrowAccount,
+		confirmWait:   90 * time.Second,
+	}, nil
+}
+
+// FindTransfers implements ChainClient by scanning the escrow token account's recent history
+func (s *SolanaChain) FindTransfers(ctx context.Context, reference string) ([]Transfer, error) {
+	limit := 1000
+	sigs, err := s.client.GetSignaturesForAddressWithOpts(ctx, s.escrowAccount, &rpc.GetSignaturesForAddressOpts{
+		Limit:      &limit,
+		Commitment: rpc.CommitmentFinalized,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list escrow transactions: %w", err)
+	}
+
+	var transfers []Transfer
+	for _, sig := range sigs {
+		if sig.Err != nil || sig.Memo == nil || !strings.Contains(*sig.Memo, reference) {
+			continue
+		}
+
+		t, _, err := s.loadTransfer(ctx, sig.Signature)
+		if err != nil {
+			return nil, err
+		}
+		if t != nil {
+			transfers = append(transfers, *t)
+		}
+	}
+
+	return transfers, nil
+}
+
+// VerifyDeposit implements DepositVerifier
+func (s *SolanaChain) VerifyDeposit(ctx context.Context, signature, wallet, reference string) (*Transfer, error) {
+	sig, err := solana.SignatureFromBase58(signature)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid signature", ErrDepositMismatch)
+	}
+
+	t, logs, err := s.loadTransfer(ctx, sig)
+	if err != nil {
+		if errors.Is(err, rpc.ErrNotFound) {
+			return nil, ErrDepositNotFound
+		}
+		return nil, err
+	}
+	if t == nil || !t.Incoming {
+		return nil, fmt.Errorf("%w: no KMT transfer into escrow", ErrDepositMismatch)
+	}
+	if t.Wallet != wallet {
+		return nil, fmt.Errorf("%w: sent from a different wallet", ErrDepositMismatch)
+	}
+
+	tagged := false
+	for _, line := range logs {
+		if strings.Contains(line, "Memo") && strings.Contains(line, reference) {
+			tagged = true
+			break
+		}
+	}
+	if !tagged {
+		return nil, fmt.Errorf("%w: missing memo %q", ErrDepositMismatch, reference)
+	}
+
+	return t, nil
+}
+
+// loadTransfer fetches a finalized transaction and extracts its escrow transfer, if any.
+// Failed transactions yield no transfer.
+func (s *SolanaChain) loadTransfer(ctx context.Context, sig solana.Signature) (*Transfer, []string, error) {
+	maxVersion := uint64(0)
+	result, err := s.client.GetTransaction(ctx, sig, &rpc.GetTransactionOpts{
+		Encoding:                       solana.EncodingBase64,
+		Commitment:                     rpc.CommitmentFinalized,
+		MaxSupportedTransactionVersion: &maxVersion,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load transaction %s: %w", sig, err)
+	}
+	if result == nil || result.Meta == nil || result.Transaction == nil {
+		return nil, nil, fmt.Errorf("failed to load transaction %s: %w", sig, rpc.ErrNotFound)
+	}
+	if result.Meta.Err != nil {
+		return nil, result.Meta.LogMessages, nil
+	}
+
+	tx, err := result.Transaction.GetTransaction()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decode transaction %s: %w", sig, err)
+	}
+
+	// Balance entries refer to accounts by index over static then lookup-table keys
+	keys := append(solana.PublicKeySlice{}, tx.Message.AccountKeys...)
+	keys = append(keys, result.Meta.LoadedAddresses.Writable...)
+	keys = append(keys, result.Meta.LoadedAddresses.ReadOnly...)
+
+	t, ok := s.escrowTransfer(result.Meta, keys)
+	if !ok {
+		return nil, result.Meta.LogMessages, nil
+	}
+	t.Signature = sig.String()
+	return &t, result.Meta.LogMessages, nil
+}
+
+// escrowTransfer works out who moved how many tokens of the mint in or out of the
+// escrow token account from the transaction's balance changes
+func (s *SolanaChain) escrowTransfer(meta *rpc.TransactionMeta, keys solana.PublicKeySlice) (Transfer, bool) {
+	var escrowDelta int64
+	deltas := make(map[string]int64)
+	apply := func(balances []rpc.TokenBalance, sign int64) {
+		for _, b := range balances {
+			if b.Mint != s.mint || b.UiTokenAmount == nil || int(b.AccountIndex) >= len(keys) {
+				continue
+			}
+			amount, err := strconv.ParseInt(b.UiTokenAmount.Amount, 10, 64)
+			if err != nil {
+				continue
+			}
+			if keys[b.AccountIndex].Equals(s.escrowAccount) {
+				escrowDelta += sign * amount
+			} else if b.Owner != nil {
+				deltas[b.Owner.String()] += sign * amount
+			}
+		}
+	}
+	apply(meta.PreTokenBalances, -1)
+	apply(meta.PostTokenBalances, 1)
+
+	if escrowDelta == 0 {
+		return Transfer{}, false
+	}
+
+	// The counterparty is the owner whose balance moved the opposite way
+	for owner, delta := range deltas {
+		if delta == 0 || (delta > 0) == (escrowDelta > 0) {
+			continue
+		}
+		amount := -delta
+		if escrowDelta < 0 {
+			amount = delta
+		}
+		return Transfer{Wallet: owner, Amount: uint64(amount), Incoming: escrowDelta > 0}, true
+	}
+
+	return Transfer{}, false
+}
+
+// Pay implements ChainClient with an SPL TransferChecked out of escrow
+func (s *SolanaChain) Pay(ctx context.Context, wallet string, amount uint64, reference string) (string, error) {
+	recipient, err := solana.PublicKeyFromBase58(wallet)
+	if err != nil {
+		return "", fmt.Errorf("invalid payout wallet: %w", err)
+	}
+
+	recipientAccount, _, err := solana.FindAssociatedTokenAddress(recipient, s.mint)
+	if err != nil {
+		return "", fmt.Errorf("failed to derive recipient token account: %w", err)
+	}
+
+	balance, err := s.client.GetTokenAccountBalance(ctx, s.escrowAccount, rpc.CommitmentFinalized)
+	if err != nil {
+		return "", fmt.Errorf("failed to read escrow balance: %w", err)
+	}
+
+	instructions := []solana.Instruction{}
+
+	// Create the recipient's token account if they never held KMT
+	if _, err := s.client.GetAccountInfo(ctx, recipientAccount); errors.Is(err, rpc.ErrNotFound) {
+		instructions = append(instructions,
+			associatedtokenaccount.NewCreateInstruction(s.escrow.PublicKey(), recipient, s.mint).Build())
+	} else if err != nil {
+		return "", fmt.Errorf("failed to look up recipient token account: %w", err)
+	}
+
+	instructions = append(instructions,
+		token.NewTransferCheckedInstruction(
+			amount,
+			balance.Value.Decimals,
+			s.escrowAccount,
+			s.mint,
+			recipientAccount,
+			s.escrow.PublicKey(),
+			nil,
+		).Build(),
+		memo.NewMemoInstruction([]byte(reference), s.escrow.PublicKey()).Build(),
+	)
+
+	blockhash, err := s.client.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
+	if err != nil {
+		return "", fmt.Errorf("failed to get blockhash: %w", err)
+	}
+
+	tx, err := solana.NewTransaction(instructions, blockhash.Value.Blockhash, solana.TransactionPayer(s.escrow.PublicKey()))
+	if err != nil {
+		return "", fmt.Errorf("failed to build payout transaction: %w", err)
+	}
+
+	if _, err := tx.Sign(func(key solana.PublicKey) *solana.PrivateKey {
+		if key.Equals(s.escrow.PublicKey()) {
+			return &s.escrow
+		}
+		return nil
+	}); err != nil {
+		return "", fmt.Errorf("failed to sign payout transaction: %w", err)
+	}
+
+	sig, err := s.client.SendTransactionWithOpts(ctx, tx, rpc.TransactionOpts{
+		PreflightCommitment: rpc.CommitmentFinalized,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to send payout transaction: %w", err)
+	}
+
+	if err := s.waitFinalized(ctx, sig); err != nil {
+		return "", err
+	}
+
+	return sig.String(), nil
+}
+
+// waitFinalized polls until the transaction is finalized, fails, or the wait times out
+func (s *SolanaChain) waitFinalized(ctx context.Context, sig solana.Signature) error {
+	ctx, cancel := context.WithTimeout(ctx, s.confirmWait)
+	defer cancel()
+
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("payout %s not finalized: %w", sig, ctx.Err())
+		case <-ticker.C:
+		}
+
+		statuses, err := s.client.GetSignatureStatuses(ctx, true, sig)
+		if err != nil || len(statuses.Value) == 0 || statuses.Value[0] == nil {
+			continue
+		}
+
+		status := statuses.Value[0]
+		if status.Err != nil {
+			return fmt.Errorf("payout %s failed on chain: %v", sig, status.Err)
+		}
+		if status.ConfirmationStatus == rpc.ConfirmationStatusFinalized {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package settlement
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/kekopoly/backend/internal/game/models"
+)
+
+// Store persists deposit and payout transactions
+type Store interface {
+	// SaveTransaction inserts or replaces a transaction by its ID
+	SaveTransaction(ctx context.Context, tx *models.Transaction) error
+	// GameTransactions returns a game's transactions of the given type, or all of them
+	// when txType is empty
+	GameTransactions(ctx context.Context, gameID string, txType models.TransactionType) ([]models.Transaction, error)
+	// UnsettledTransactions returns the transactions of the given type, across all games,
+	// that haven't been completed on chain
+	UnsettledTransactions(ctx context.Context, txType models.TransactionType) ([]models.Transaction, error)
+}
+
+// MongoStore keeps settlement transactions in the transactions collection
+type MongoStore struct {
+	collection *mongo.Collection
+}
+
+// NewMongoStore creates a Mongo-backed settlement store
+func NewMongoStore(client *mongo.Client, dbName, collName string) *MongoStore {
+	return &MongoStore{collection: client.Database(dbName).Collection(collName)}
+}
+
+// SaveTransaction implements Store
+func (s *MongoStore) SaveTransaction(ctx context.Context, tx *models.Transaction) error {
+	_, err := s.collection.ReplaceOne(ctx,
+		bson.M{"transactionId": tx.ID},
+		tx,
+		options.Replace().SetUpsert(true),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save transaction: %w", err)
+	}
+	return nil
+}
+
+// GameTransactions implements Store
+func (s *MongoStore) GameTransactions(ctx context.Context, gameID string, txType models.TransactionType) ([]models.Transaction, error) {
+	filter := bson.M{"gameId": gameID}
+	if txType != "" {
+		filter["type"] = txType
+	}
+	cursor, err := s.collection.Find(ctx, filter,
+		options.Find().SetSort(bson.D{{Key: "timestamp", Value: 1}}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query transactions: %w", err)
+	}
+
+	var txs []models.Transaction
+	if err := cursor.All(ctx, &txs); err != nil {
+		return nil, fmt.Errorf("failed to decode transactions: %w", err)
+	}
+	return txs, nil
+}
+
+// UnsettledTransactions implements Store
+func (s *MongoStore) UnsettledTransactions(ctx context.Context, txType models.TransactionType) ([]models.Transaction, error) {
+	cursor, err := s.collection.Find(ctx,
+		bson.M{"type": txType, "onChainStatus": bson.M{"$ne": models.OnChainStatusCompleted}},
+		options.Find().SetSort(bson.D{{Key: "timestamp", Value: 1}}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query transactions: %w", err)
+	}
+
+	var txs []models.Transaction
+	if err := cursor.All(ctx, &txs); err != nil {
+		return nil, fmt.Errorf("failed to decode transactions: %w", err)
+	}
+	return txs, nil
+}
+
+// MemoryStore is an in-process Store for tests and development
+type MemoryStore struct {
+	mu  sync.Mutex
+	txs []models.Transaction
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// SaveTransaction implements Store
+func (s *MemoryStore) SaveTransaction(ctx context.Context, tx *models.Transaction) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.txs {
+		if s.txs[i].ID == tx.ID {
+			s.txs[i] = *tx
+			return nil
+		}
+	}
+	s.txs = append(s.txs, *tx)
+	return nil
+}
+
+// GameTransactions implements Store
+func (s *MemoryStore) GameTransactions(ctx context.Context, gameID string, txType models.TransactionType) ([]models.Transaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []models.Transaction
+	for _, tx := range s.txs {
+		if tx.GameID == gameID && (txType == "" || tx.Type == txType) {
+			out = append(out, tx)
+		}
+	}
+	return out, nil
+}
+
+// UnsettledTransactions implements Store
+func (s *MemoryStore) UnsettledTransactions(ctx context.Context, txType models.TransactionType) ([]models.Transaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []models.Transaction
+	for _, tx := range s.txs {
+		if tx.Type == txType && tx.OnChainStatus != models.OnChainStatusCompleted {
+			out = append(out, tx)
+		}
+	}
+	return out, nil
+}
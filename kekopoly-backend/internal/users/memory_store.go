@@ -0,0 +1,141 @@
+package users
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryStore is an in-process Store used by tests and tools that run without MongoDB
+type MemoryStore struct {
+	mu    sync.RWMutex
+	users map[string]User
+}
+
+// NewMemoryStore creates an empty in-memory user store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{users: make(map[string]User)}
+}
+
+// Create inserts a new user, enforcing the same uniqueness rules as MongoStore
+func (s *MemoryStore) Create(ctx context.Context, user *User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user.Email = NormalizeEmail(user.Email)
+	user.UsernameKey = UsernameKey(user.Username)
+
+	if err := s.checkUnique(user.ID, user.Email, user.UsernameKey, user.WalletAddress); err != nil {
+		return err
+	}
+
+	s.users[user.ID] = *user
+	return nil
+}
+
+// FindByID looks up a user by ID
+func (s *MemoryStore) FindByID(ctx context.Context, id string) (*User, error) {
+	return s.find(func(u User) bool { return u.ID == id })
+}
+
+// FindByEmail looks up a user by email
+func (s *MemoryStore) FindByEmail(ctx context.Context, email string) (*User, error) {
+	email = NormalizeEmail(email)
+	return s.find(func(u User) bool { return email != "" && u.Email == email })
+}
+
+// FindByWallet looks up a user by linked wallet address
+func (s *MemoryStore) FindByWallet(ctx context.Context, walletAddress string) (*User, error) {
+	return s.find(func(u User) bool { return walletAddress != "" && u.WalletAddress == walletAddress })
+}
+
+// UpdateProfile applies a profile update and returns the updated user
+func (s *MemoryStore) UpdateProfile(ctx context.Context, id string, update ProfileUpdate) (*User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[id]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+
+	if update.Username != nil {
+		key := UsernameKey(*update.Username)
+		if err := s.checkUnique(id, "", key, ""); err != nil {
+			return nil, err
+		}
+		user.Username = strings.TrimSpace(*update.Username)
+		user.UsernameKey = key
+	}
+	if update.AvatarURL != nil {
+		user.AvatarURL = *update.AvatarURL
+	}
+	user.UpdatedAt = time.Now()
+
+	s.users[id] = user
+	return &user, nil
+}
+
+// LinkWallet attaches a wallet address to an existing account
+func (s *MemoryStore) LinkWallet(ctx context.Context, id, walletAddress string) (*User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[id]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	if err := s.checkUnique(id, "", "", walletAddress); err != nil {
+		return nil, err
+	}
+
+	user.WalletAddress = walletAddress
+	user.UpdatedAt = time.Now()
+	s.users[id] = user
+	return &user, nil
+}
+
+// TouchLogin records a successful login
+func (s *MemoryStore) TouchLogin(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if user, ok := s.users[id]; ok {
+		user.LastLoginAt = time.Now()
+		s.users[id] = user
+	}
+	return nil
+}
+
+// find returns a copy of the first user matching the predicate
+func (s *MemoryStore) find(match func(User) bool) (*User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, u := range s.users {
+		if match(u) {
+			user := u
+			return &user, nil
+		}
+	}
+	return nil, ErrUserNotFound
+}
+
+// checkUnique reports a conflict with any user other than id. Caller must hold the lock.
+func (s *MemoryStore) checkUnique(id, email, usernameKey, walletAddress string) error {
+	for _, u := range s.users {
+		if u.ID == id {
+			continue
+		}
+		switch {
+		case email != "" && u.Email == email:
+			return ErrEmailTaken
+		case usernameKey != "" && u.UsernameKey == usernameKey:
+			return ErrUsernameTaken
+		case walletAddress != "" && u.WalletAddress == walletAddress:
+			return ErrWalletLinked
+		}
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Index names, also used to map duplicate key errors back to a field
+const (
+	emailIndex    = "email_unique"
+	usernameIndex = "usernameKey_unique"
+	walletIndex   = "walletAddress_unique"
+)
+
+// MongoStore is a Store backed by a MongoDB collection
+type MongoStore struct {
+	coll *mongo.Collection
+}
+
+// NewMongoStore creates a user store on the given collection
+func NewMongoStore(client *mongo.Client, dbName, collName string) *MongoStore {
+	return &MongoStore{
+		coll: client.Database(dbName).Collection(collName),
+	}
+}
+
+// EnsureIndexes creates the unique indexes on email, username and wallet.
+// They are partial so accounts without one of those fields don't collide.
+func (s *MongoStore) EnsureIndexes(ctx context.Context) error {
+	partial := func(field string) *options.IndexOptions {
+		return options.Index().
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{field: bson.M{"$type": "string"}})
+	}
+
+	models := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "email", Value: 1}}, Options: partial("email").SetName(emailIndex)},
+		{Keys: bson.D{{Key: "usernameKey", Value: 1}}, Options: partial("usernameKey").SetName(usernameIndex)},
+		{Keys: bson.D{{Key: "walletAddress", Value: 1}}, Options: partial("walletAddress").SetName(walletIndex)},
+	}
+
+	if _, err := s.coll.Indexes().CreateMany(ctx, models); err != nil {
+		return fmt.Errorf("failed to create user indexes: %w", err)
+	}
+	return nil
+}
+
+// Create inserts a new user
+func (s *MongoStore) Create(ctx context.Context, user *User) error {
+	user.Email = NormalizeEmail(user.Email)
+	user.UsernameKey = UsernameKey(user.Username)
+
+	if _, err := s.coll.InsertOne(ctx, user); err != nil {
+		return mapDuplicateKey(err)
+	}
+	return nil
+}
+
+// FindByID looks up a user by ID
+func (s *MongoStore) FindByID(ctx context.Context, id string) (*User, error) {
+	return s.findOne(ctx, bson.M{"_id": id})
+}
+
+// FindByEmail looks up a user by email
+func (s *MongoStore) FindByEmail(ctx context.Context, email string) (*User, error) {
+	return s.findOne(ctx, bson.M{"email": NormalizeEmail(email)})
+}
+
+// FindByWallet looks up a user by linked wallet address
+func (s *MongoStore) FindByWallet(ctx context.Context, walletAddress string) (*User, error) {
+	return s.findOne(ctx, bson.M{"walletAddress": walletAddress})
+}
+
+// UpdateProfile applies a profile update and returns the updated user
+func (s *MongoStore) UpdateProfile(ctx context.Context, id string, update ProfileUpdate) (*User, error) {
+	set := bson.M{"updatedAt": time.Now()}
+	if update.Username != nil {
+		set["username"] = strings.TrimSpace(*update.Username)
+		set["usernameKey"] = UsernameKey(*update.Username)
+	}
+	if update.AvatarURL != nil {
+		set["avatarUrl"] = *update.AvatarURL
+	}
+
+	return s.findOneAndSet(ctx, id, set)
+}
+
+// LinkWallet attaches a wallet address to an existing account
+func (s *MongoStore) LinkWallet(ctx context.Context, id, walletAddress string) (*User, error) {
+	return s.findOneAndSet(ctx, id, bson.M{
+		"walletAddress": walletAddress,
+		"updatedAt":     time.Now(),
+	})
+}
+
+// TouchLogin records a successful login
+func (s *MongoStore) TouchLogin(ctx context.Context, id string) error {
+	_, err := s.coll.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"lastLoginAt": time.Now()}})
+	if err != nil {
+		return fmt.Errorf("failed to record login: %w", err)
+	}
+	return nil
+}
+
+// findOne returns the single user matching filter
+func (s *MongoStore) findOne(ctx context.Context, filter bson.M) (*User, error) {
+	var user User
+	if err := s.coll.FindOne(ctx, filter).Decode(&user); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+	return &user, nil
+}
+
+// findOneAndSet applies $set to the user and returns the updated document
+func (s *MongoStore) findOneAndSet(ctx context.Context, id string, set bson.M) (*User, error) {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var user User
+	err := s.coll.FindOneAndUpdate(ctx, bson.M{"_id": id}, bson.M{"$set": set}, opts).Decode(&user)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrUserNotFound
+		}
+		return nil, mapDuplicateKey(err)
+	}
+	return &user, nil
+}
+
+// mapDuplicateKey converts unique index violations into the matching store error
+func mapDuplicateKey(err error) error {
+	if !mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("failed to write user: %w", err)
+	}
+
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, emailIndex):
+		return ErrEmailTaken
+	case strings.Contains(msg, usernameIndex):
+		return ErrUsernameTaken
+	case strings.Contains(msg, walletIndex):
+		return ErrWalletLinked
+	default:
+		return fmt.Errorf("failed to write user: %w", err)
+	}
+}
@@ -0,0 +1,82 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// User is a persistent account. Accounts created through wallet sign-in have
+// no email, username or password until the owner adds them.
+type User struct {
+	ID            string    `bson:"_id" json:"userId"`
+	Email         string    `bson:"email,omitempty" json:"email,omitempty"`
+	Username      string    `bson:"username,omitempty" json:"username,omitempty"`
+	UsernameKey   string    `bson:"usernameKey,omitempty" json:"-"` // Lowercased username for case-insensitive uniqueness
+	PasswordHash  string    `bson:"passwordHash,omitempty" json:"-"`
+	WalletAddress string    `bson:"walletAddress,omitempty" json:"walletAddress,omitempty"`
+	AvatarURL     string    `bson:"avatarUrl,omitempty" json:"avatarUrl,omitempty"`
+	CreatedAt     time.Time `bson:"createdAt" json:"createdAt"`
+	UpdatedAt     time.Time `bson:"updatedAt" json:"updatedAt"`
+	LastLoginAt   time.Time `bson:"lastLoginAt,omitempty" json:"lastLoginAt,omitempty"`
+}
+
+// ProfileUpdate holds the profile fields a user may change. Nil fields are left untouched.
+type ProfileUpdate struct {
+	Username  *string
+	AvatarURL *string
+}
+
+var (
+	// ErrUserNotFound is returned when no user matches a lookup
+	ErrUserNotFound = errors.New("user not found")
+	// ErrEmailTaken is returned when the email belongs to another account
+	ErrEmailTaken = errors.New("email is already registered")
+	// ErrUsernameTaken is returned when the username belongs to another account
+	ErrUsernameTaken = errors.New("username is already taken")
+	// ErrWalletLinked is returned when the wallet belongs to another account
+	ErrWalletLinked = errors.New("wallet is already linked to another account")
+	// ErrInvalidCredentials is returned when an email/password pair does not match
+	ErrInvalidCredentials = errors.New("invalid email or password")
+)
+
+// Store persists user accounts
+type Store interface {
+	Create(ctx context.Context, user *User) error
+	FindByID(ctx context.Context, id string) (*User, error)
+	FindByEmail(ctx context.Context, email string) (*User, error)
+	FindByWallet(ctx context.Context, walletAddress string) (*User, error)
+	UpdateProfile(ctx context.Context, id string, update ProfileUpdate) (*User, error)
+	LinkWallet(ctx context.Context, id, walletAddress string) (*User, error)
+	TouchLogin(ctx context.Context, id string) error
+}
+
+// NormalizeEmail lowercases and trims an email for storage and lookup
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// UsernameKey returns the key used to enforce case-insensitive username uniqueness
+func UsernameKey(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+// HashPassword hashes a password with bcrypt
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// CheckPassword reports whether password matches the user's stored hash
+func (u *User) CheckPassword(password string) bool {
+	if u.PasswordHash == "" {
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)) == nil
+}
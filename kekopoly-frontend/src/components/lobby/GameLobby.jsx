@@ -38,65 +38,13 @@ import {
   Avatar,
   Tooltip,
 } from '@chakra-ui/react';
-import { FaPlay, FaPlusCircle, FaDice, FaUsers, FaChevronDown, FaSync, FaTrash } from 'react-icons/fa';
+import { FaPlay, FaPlusCircle, FaDice, FaUsers, FaChevronDown, FaSync } from 'react-icons/fa';
 import { useDispatch, useSelector } from 'react-redux';
 import { setRoomCode } from '../../store/gameSlice';
 import { disconnectPhantomWallet } from '../../store/authSlice';
 import socketService from '../../services/socket';
 import { apiGet, apiPost } from '../../utils/apiUtils';
 
-// Add function to prompt for password
-const useCleanupPrompt = () => {
-  const [isOpen, setIsOpen] = useState(false);
-  const [password, setPassword] = useState('');
-  const [isSubmitting, setIsSubmitting] = useState(false);
-  const toast = useToast();
-
-  const onOpen = () => {
-    setIsOpen(true);
-    setPassword('');
-  };
-
-  const onClose = () => {
-    setIsOpen(false);
-    setPassword('');
-  };
-
-  const handlePasswordChange = (e) => {
-    setPassword(e.target.value);
-  };
-
-  const validatePassword = (callback) => {
-    setIsSubmitting(true);
-
-    // Simple validation - in a real app this would be a secure check
-    if (password === 'kekadmin123') {
-      onClose();
-      callback();
-    } else {
-      toast({
-        title: 'Invalid Password',
-        description: 'The admin password is incorrect',
-        status: 'error',
-        duration: 3000,
-        isClosable: true,
-      });
-    }
-
-    setIsSubmitting(false);
-  };
-
-  return {
-    isOpen,
-    onOpen,
-    onClose,
-    password,
-    handlePasswordChange,
-    validatePassword,
-    isSubmitting
-  };
-};
-
 const GameLobby = () => {
   const navigate = useNavigate();
   const dispatch = useDispatch();
@@ -116,18 +64,6 @@ const GameLobby = () => {
   const [loading, setLoading] = useState(false);
   const [error, setError] = useState(null);
 
-  // Add this at the end of the GameLobby component, just before return statement
-  // Add admin password prompt
-  const {
-    isOpen: isPasswordPromptOpen,
-    onOpen: openPasswordPrompt,
-    onClose: closePasswordPrompt,
-    password,
-    handlePasswordChange,
-    validatePassword,
-    isSubmitting: isValidatingPassword
-  } = useCleanupPrompt();
-
   // Process the games data to remove duplicates
   const deduplicateGames = (games) => {
     if (!Array.isArray(games)) return [];
@@ -388,49 +324,6 @@ const GameLobby = () => {
     }
   };
 
-  // Handle resetting an abandoned game
-  const handleResetGame = async (gameId) => {
-    // Check if we have a valid token
-    if (!token) {
-      toast({
-        title: 'Authentication Required',
-        description: 'Please connect your wallet first',
-        status: 'error',
-        duration: 3000,
-        isClosable: true,
-      });
-      return;
-    }
-
-    try {
-      // Use our apiPost utility which handles authentication automatically
-      const resetResponse = await apiPost(`/api/v1/games/${gameId}/reset`, {});
-
-      // Log response for debugging
-      // console.log('Reset game response:', resetResponse);
-
-      toast({
-        title: 'Game Reset',
-        description: 'Game has been reset to LOBBY status. You can now join it.',
-        status: 'success',
-        duration: 3000,
-        isClosable: true,
-      });
-
-      // Refresh the game list
-      refreshGameList();
-    } catch (error) {
-      console.error('Error resetting game:', error);
-      toast({
-        title: 'Error',
-        description: 'Failed to reset game. Please try again.',
-        status: 'error',
-        duration: 3000,
-        isClosable: true,
-      });
-    }
-  };
-
   // Handle joining an available game
   const handleJoinGame = async (gameId) => {
     // Check if we have a valid token
@@ -559,39 +452,6 @@ const GameLobby = () => {
     }
   };
 
-  // Add a function to clean up stale games
-  const cleanupGames = async () => {
-    setLoading(true);
-
-    try {
-      // Use our apiPost utility which handles authentication automatically
-      const data = await apiPost('/api/v1/games/cleanup', {});
-      // console.log('Cleanup response:', data);
-
-      toast({
-        title: 'Games cleaned up',
-        description: `Removed ${data.gamesRemoved} stale games`,
-        status: 'success',
-        duration: 3000,
-        isClosable: true,
-      });
-
-      // Refresh the game list
-      refreshGameList();
-    } catch (error) {
-      console.error('Error cleaning up games:', error);
-      toast({
-        title: 'Error',
-        description: 'Failed to clean up games',
-        status: 'error',
-        duration: 3000,
-        isClosable: true,
-      });
-    } finally {
-      setLoading(false);
-    }
-  };
-
   return (
     <Box minH="100vh" bg="gray.100">
       <Container maxW="container.xl" py={8}>
@@ -685,15 +545,6 @@ const GameLobby = () => {
           <Flex justifyContent="space-between" alignItems="center" mb={4}>
             <Heading size="md">Available Games</Heading>
             <HStack spacing={2}>
-              <Button
-                size="sm"
-                colorScheme="red"
-                leftIcon={<FaTrash />}
-                onClick={openPasswordPrompt}
-                isLoading={loading}
-              >
-                Clean Up
-              </Button>
               <Button
                 size="sm"
                 leftIcon={<FaSync />}
@@ -745,13 +596,8 @@ const GameLobby = () => {
                         </HStack>
 
                         {game.status === 'ABANDONED' ? (
-                          <Button
-                            colorScheme="red"
-                            size="sm"
-                            rightIcon={<FaSync />}
-                            onClick={() => handleResetGame(game.id)}
-                          >
-                            Reset Game
+                          <Button colorScheme="red" size="sm" isDisabled>
+                            Abandoned
                           </Button>
                         ) : (
                           <Button
@@ -832,35 +678,6 @@ const GameLobby = () => {
           </ModalFooter>
         </ModalContent>
       </Modal>
-
-      {/* Admin Password Modal */}
-      <Modal isOpen={isPasswordPromptOpen} onClose={closePasswordPrompt}>
-        <ModalOverlay />
-        <ModalContent>
-          <ModalHeader>Admin Authentication Required</ModalHeader>
-          <ModalCloseButton />
-          <ModalBody>
-            <Text mb={4}>Please enter the admin password to clean up stale games:</Text>
-            <Input
-              type="password"
-              placeholder="Admin password"
-              value={password}
-              onChange={handlePasswordChange}
-            />
-          </ModalBody>
-          <ModalFooter>
-            <Button
-              colorScheme="blue"
-              mr={3}
-              onClick={() => validatePassword(cleanupGames)}
-              isLoading={isValidatingPassword}
-            >
-              Submit
-            </Button>
-            <Button variant="ghost" onClick={closePasswordPrompt}>Cancel</Button>
-          </ModalFooter>
-        </ModalContent>
-      </Modal>
     </Box>
   );
 };
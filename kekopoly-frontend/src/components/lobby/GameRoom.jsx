@@ -8,7 +8,6 @@ import {
   syncGameStatus,
   startGameAsync
 } from '../../store/gameSlice';
-import ResetGameButton from '../game/ResetGameButton';
 import {
   Box,
   Container,
@@ -1800,11 +1799,6 @@ const GameRoom = () => {
                 >
                   Leave Room
                 </Button>
-
-                {/* Reset Game Button - Only show for abandoned games */}
-                {gameState.status === 'ABANDONED' && (
-                  <ResetGameButton />
-                )}
               </VStack>
             </Box>
           </Flex>
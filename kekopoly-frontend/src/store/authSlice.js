@@ -41,9 +41,18 @@ const authSlice = createSlice({
       // Store token and user in localStorage
       localStorage.setItem('kekopoly_token', token);
       localStorage.setItem('kekopoly_user', JSON.stringify(action.payload.user));
+      if (action.payload.refreshToken) {
+        localStorage.setItem('kekopoly_refresh_token', action.payload.refreshToken);
+      }
       
       // console.log('Token saved to Redux and localStorage:', token.substring(0, 30) + '...');
     },
+    setToken: (state, action) => {
+      // Used after a refresh-token rotation; the user is unchanged
+      const token = action.payload.startsWith('Bearer ') ? action.payload : `Bearer ${action.payload}`;
+      state.token = token;
+      localStorage.setItem('kekopoly_token', token);
+    },
     connectFailure: (state, action) => {
       state.loading = false;
       state.error = action.payload;
@@ -56,11 +65,12 @@ const authSlice = createSlice({
       // Remove token and user from localStorage
       localStorage.removeItem('kekopoly_token');
       localStorage.removeItem('kekopoly_user');
+      localStorage.removeItem('kekopoly_refresh_token');
     },
   },
 });
 
-export const { connectStart, connectSuccess, connectFailure, disconnect } = authSlice.actions;
+export const { connectStart, connectSuccess, setToken, connectFailure, disconnect } = authSlice.actions;
 
 // Try authenticating with a specific signature format
 const tryAuthenticate = async (payload) => {
@@ -82,13 +92,24 @@ const tryAuthenticate = async (payload) => {
   return await response.json();
 };
 
+// Fetch a single-use sign-in challenge message for the wallet
+const fetchChallenge = async (walletAddress) => {
+  const response = await fetch(`/api/v1/auth/challenge?wallet=${encodeURIComponent(walletAddress)}`);
+  if (!response.ok) {
+    const errorData = await response.json().catch(() => ({ message: response.statusText }));
+    throw new Error(`Failed to get sign-in challenge (${response.status}): ${errorData.message || response.statusText}`);
+  }
+  const challenge = await response.json();
+  return challenge.message;
+};
+
 // Phantom wallet connection function
 export const connectPhantomWallet = () => async (dispatch) => {
   dispatch(connectStart());
   
   try {
     // Use our utility to connect and sign with Phantom
-    const { walletAddress, signature, messageToSign } = await connectAndSignWithPhantom();
+    const { walletAddress, signature, messageToSign } = await connectAndSignWithPhantom(fetchChallenge);
     
     // console.log('Successfully connected to wallet and signed message');
     
@@ -140,6 +161,7 @@ export const connectPhantomWallet = () => async (dispatch) => {
     // Store token and user info
     dispatch(connectSuccess({
       token: authResult.token,
+      refreshToken: authResult.refreshToken,
       user: {
         walletAddress,
         ...(authResult.user || {})
@@ -45,6 +45,58 @@ export const getAuthToken = () => {
   return token;
 };
 
+// The refresh in flight, shared by every request that hits a 401 meanwhile
+let pendingRefresh = null;
+
+/**
+ * Exchange the stored refresh token for a new access token. The server rotates
+ * the refresh token on every use and treats a second use as theft, so concurrent
+ * callers share a single refresh rather than each sending the same token.
+ * @returns {Promise<string|null>} The new access token or null if refresh failed
+ */
+export const refreshAuthToken = () => {
+  if (!pendingRefresh) {
+    pendingRefresh = sendRefresh().finally(() => {
+      pendingRefresh = null;
+    });
+  }
+  return pendingRefresh;
+};
+
+/**
+ * Send the stored refresh token and store the rotated tokens it returns
+ * @returns {Promise<string|null>} The new access token or null if refresh failed
+ */
+const sendRefresh = async () => {
+  const refreshToken = localStorage.getItem('kekopoly_refresh_token');
+  if (!refreshToken) {
+    return null;
+  }
+
+  const response = await fetch('/api/v1/auth/refresh-token', {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify({ refreshToken }),
+  });
+
+  if (!response.ok) {
+    localStorage.removeItem('kekopoly_refresh_token');
+    return null;
+  }
+
+  const data = await response.json();
+  localStorage.setItem('kekopoly_refresh_token', data.refreshToken);
+
+  const store = getReduxStore();
+  if (store && store.dispatch) {
+    store.dispatch({ type: 'auth/setToken', payload: data.token });
+  } else {
+    localStorage.setItem('kekopoly_token', `Bearer ${data.token}`);
+  }
+
+  return `Bearer ${data.token}`;
+};
+
 /**
  * Make an authenticated API request
  * @param {string} url - The API endpoint URL
@@ -76,14 +128,17 @@ export const apiRequest = async (url, options = {}) => {
   if (response.status === 401) {
     console.error('Authentication failed for API request:', url);
 
-    // Try to refresh the token from localStorage
-    const refreshedToken = localStorage.getItem('kekopoly_token');
+    // Try a stored token that differs from ours, otherwise refresh the access token
+    let refreshedToken = localStorage.getItem('kekopoly_token');
+    if (!refreshedToken || refreshedToken === token) {
+      refreshedToken = await refreshAuthToken().catch(() => null);
+    }
     if (refreshedToken && refreshedToken !== token) {
       // console.log('Found different token in localStorage, retrying request with new token');
 
       // Update Redux store with the new token if available
       const store = getReduxStore();
-      if (store && store.dispatch) {
+      if (store && store.dispatch && store.getState().auth.token !== refreshedToken) {
         store.dispatch({ type: 'auth/setToken', payload: refreshedToken });
       }
 
@@ -63,8 +63,10 @@ export const connectAndSignWithPhantom = async (message) => {
   const connectResponse = await solana.connect();
   const walletAddress = connectResponse.publicKey.toString();
   
-  // Create message if not provided
-  const messageToSign = message || `Login to Kekopoly with wallet ${walletAddress} at ${new Date().toISOString()}`;
+  // The message may be a string or a function that builds it for the connected wallet
+  const messageToSign = typeof message === 'function'
+    ? await message(walletAddress)
+    : message || `Login to Kekopoly with wallet ${walletAddress} at ${new Date().toISOString()}`;
   
   // Sign the message
   const signatureData = await signMessageWithSolana(solana, messageToSign);